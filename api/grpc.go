@@ -0,0 +1,397 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	errors2 "errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/livepeer/catalyst-api/audit"
+	"github.com/livepeer/catalyst-api/config"
+	"github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/handlers"
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/livepeer/catalyst-api/middleware"
+	"github.com/livepeer/catalyst-api/pipeline"
+	"github.com/livepeer/catalyst-api/requests"
+	"github.com/livepeer/catalyst-api/rpc"
+	"github.com/livepeer/catalyst-api/thumbnails"
+	"github.com/livepeer/catalyst-api/video"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// grpcMethodAuth is how each gRPC method is authorized, matching the equivalent HTTP route
+var grpcMethodAuth = map[string]struct {
+	scope string
+	// ifConfigured methods only require authorization once JWTs or API keys are configured, like /api/events
+	ifConfigured bool
+}{
+	rpc.Catalyst_SubmitVOD_FullMethodName:      {scope: middleware.ScopeVODWrite},
+	rpc.Catalyst_GetJobStatus_FullMethodName:   {scope: middleware.ScopeRead},
+	rpc.Catalyst_BroadcastEvent_FullMethodName: {scope: middleware.ScopeEventsWrite, ifConfigured: true},
+}
+
+// grpcAuditActions are the state-changing gRPC methods recorded in the API audit log, matching the equivalent HTTP
+// routes
+var grpcAuditActions = map[string]string{
+	rpc.Catalyst_SubmitVOD_FullMethodName:      audit.ActionVODSubmit,
+	rpc.Catalyst_BroadcastEvent_FullMethodName: audit.ActionEventBroadcast,
+}
+
+// grpcCodes are the gRPC equivalents of the HTTP statuses returned by the shared handler logic
+var grpcCodes = map[int]codes.Code{
+	http.StatusBadRequest:            codes.InvalidArgument,
+	http.StatusUnauthorized:          codes.Unauthenticated,
+	http.StatusForbidden:             codes.PermissionDenied,
+	http.StatusNotFound:              codes.NotFound,
+	http.StatusRequestEntityTooLarge: codes.ResourceExhausted,
+	http.StatusTooManyRequests:       codes.ResourceExhausted,
+	http.StatusServiceUnavailable:    codes.Unavailable,
+}
+
+func ListenAndServeGRPC(ctx context.Context, cli config.Cli, vodEngine *pipeline.Coordinator, metricsDB *sql.DB, limiter *middleware.RateLimiter, capacity *middleware.CapacityMiddleware, eventsHandler *handlers.EventsHandlersCollection) error {
+	listener, err := net.Listen("tcp", cli.GRPCAddress)
+	if err != nil {
+		return fmt.Errorf("error listening for gRPC: %w", err)
+	}
+
+	authorizer := middleware.NewAuthorizer(cli.APIToken, cli.JWTAuth, cli.APIKeys)
+	auditLog := audit.NewLog(metricsDB)
+	if !cli.IsApiMode() {
+		vodEngine = nil
+	}
+	opts := []grpc.ServerOption{grpc.ChainUnaryInterceptor(
+		requestIDGRPC,
+		authorizeGRPC(authorizer),
+		rateLimitGRPC(limiter, vodEngine),
+		auditGRPC(auditLog),
+		capacityGRPC(capacity, vodEngine),
+	)}
+	if cli.HTTPInternalTLS.CertFile != "" {
+		creds, err := credentials.NewServerTLSFromFile(cli.HTTPInternalTLS.CertFile, cli.HTTPInternalTLS.KeyFile)
+		if err != nil {
+			return fmt.Errorf("error loading gRPC TLS certificate: %w", err)
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+	server := grpc.NewServer(opts...)
+	rpc.RegisterCatalystServer(server, newGRPCServer(cli, vodEngine, eventsHandler))
+
+	log.LogNoRequestID(
+		"Starting Catalyst gRPC API!",
+		"version", config.Version,
+		"host", cli.GRPCAddress,
+	)
+
+	go func() {
+		<-ctx.Done()
+		stopped := make(chan struct{})
+		go func() {
+			server.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(cli.ShutdownDrainTimeout):
+			server.Stop()
+		}
+	}()
+	return server.Serve(listener)
+}
+
+// grpcServer implements the gRPC API with the same handler logic as the HTTP routes
+type grpcServer struct {
+	rpc.UnimplementedCatalystServer
+
+	// only set in the modes serving the equivalent HTTP routes
+	vod    *handlers.CatalystAPIHandlersCollection
+	events *handlers.EventsHandlersCollection
+}
+
+func newGRPCServer(cli config.Cli, vodEngine *pipeline.Coordinator, eventsHandler *handlers.EventsHandlersCollection) *grpcServer {
+	s := &grpcServer{}
+	if cli.IsApiMode() {
+		s.vod = &handlers.CatalystAPIHandlersCollection{VODEngine: vodEngine}
+	}
+	// the handler of the HTTP events API, whose recent event IDs deduplicate the events submitted to either
+	if cli.IsClusterMode() {
+		s.events = eventsHandler
+	}
+	return s
+}
+
+func (s *grpcServer) SubmitVOD(ctx context.Context, req *rpc.SubmitVODRequest) (*rpc.SubmitVODResponse, error) {
+	if s.vod == nil {
+		return nil, status.Error(codes.Unimplemented, "VOD API not served in this mode")
+	}
+	requestID, err := s.vod.StartUploadVOD(ctx, uploadVODRequestFromProto(req))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &rpc.SubmitVODResponse{RequestId: requestID}, nil
+}
+
+func (s *grpcServer) GetJobStatus(_ context.Context, req *rpc.GetJobStatusRequest) (*rpc.JobStatus, error) {
+	if s.vod == nil {
+		return nil, status.Error(codes.Unimplemented, "VOD API not served in this mode")
+	}
+	job, err := s.vod.JobStatus(req.GetRequestId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &rpc.JobStatus{
+		RequestId:        job.RequestID,
+		ExternalId:       job.ExternalID,
+		PipelineStrategy: string(job.PipelineStrategy),
+		Stage:            job.Stage.String(),
+		CompletionRatio:  job.CompletionRatio,
+	}, nil
+}
+
+func (s *grpcServer) BroadcastEvent(ctx context.Context, req *rpc.BroadcastEventRequest) (*rpc.BroadcastEventResponse, error) {
+	if s.events == nil {
+		return nil, status.Error(codes.Unimplemented, "events API not served in this mode")
+	}
+	payload, err := json.Marshal(handlers.Event{
+		Resource:   req.GetResource(),
+		PlaybackID: req.GetPlaybackId(),
+		Version:    int(req.GetVersion()),
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot marshal event: %s", err)
+	}
+
+	requester := handlers.Caller(ctx)
+	if p, ok := peer.FromContext(ctx); ok && requester == "" {
+		requester = p.Addr.String()
+	}
+	eventID, err := s.events.Broadcast(ctx, handlers.BroadcastRequest{
+		Payload:       payload,
+		EventID:       req.GetEventId(),
+		Requester:     requester,
+		Authorization: incomingMetadata(ctx, "authorization"),
+	})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &rpc.BroadcastEventResponse{EventId: eventID}, nil
+}
+
+// requestIDGRPC attaches the "x-request-id" metadata, or a new request ID, to the context and returns it in the
+// response header, like the request ID middleware of the HTTP servers
+func requestIDGRPC(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	requestID := middleware.AcceptRequestID(incomingMetadata(ctx, strings.ToLower(requests.RequestIDHeader)))
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requests.RequestIDHeader, requestID))
+	return handler(log.WithRequestID(ctx, requestID), req)
+}
+
+// authorizeGRPC checks the bearer token in the "authorization" metadata, like the Authorization header of the
+// HTTP routes
+func authorizeGRPC(authorizer *middleware.Authorizer) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		auth, ok := grpcMethodAuth[info.FullMethod]
+		if !ok {
+			return nil, status.Errorf(codes.PermissionDenied, "no authorization configured for %s", info.FullMethod)
+		}
+		if auth.ifConfigured && !authorizer.Required() {
+			return handler(ctx, req)
+		}
+		caller, err := authorizer.Check(ctx, incomingMetadata(ctx, "authorization"), auth.scope)
+		if err != nil {
+			return nil, grpcError(err)
+		}
+		return handler(handlers.WithCaller(ctx, caller), req)
+	}
+}
+
+// rateLimitGRPC applies the caller's request rate and concurrent job limits to SubmitVOD, like the rate limit
+// middleware of the HTTP VOD routes. It has to run after authorizeGRPC to know the caller.
+func rateLimitGRPC(limiter *middleware.RateLimiter, vodEngine *pipeline.Coordinator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if info.FullMethod != rpc.Catalyst_SubmitVOD_FullMethodName || vodEngine == nil {
+			return handler(ctx, req)
+		}
+		release, err := limiter.Acquire(vodEngine, handlers.Caller(ctx))
+		if err != nil {
+			_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(err.RetryAfterSecs())))
+			return nil, status.Error(codes.ResourceExhausted, "Rate limit exceeded")
+		}
+		defer release()
+		return handler(ctx, req)
+	}
+}
+
+// capacityGRPC rejects SubmitVOD when the node is running as many jobs as it can, like the capacity middleware of
+// the HTTP VOD routes
+func capacityGRPC(capacity *middleware.CapacityMiddleware, vodEngine *pipeline.Coordinator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		submit, ok := req.(*rpc.SubmitVODRequest)
+		if !ok || vodEngine == nil {
+			return handler(ctx, req)
+		}
+		release, ok := capacity.Acquire(vodEngine, submit.GetClipStrategy().GetPlaybackId() != "")
+		if !ok {
+			return nil, status.Error(codes.ResourceExhausted, "Too many VOD jobs in flight")
+		}
+		defer release()
+		return handler(ctx, req)
+	}
+}
+
+// auditGRPC records the state-changing methods to the API audit log, like the audit middleware of the HTTP routes.
+// It has to run after authorizeGRPC to record the caller.
+func auditGRPC(auditLog audit.Log) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		action, ok := grpcAuditActions[info.FullMethod]
+		if !ok {
+			return handler(ctx, req)
+		}
+		var payload []byte
+		if msg, ok := req.(proto.Message); ok {
+			payload, _ = proto.Marshal(msg)
+		}
+		hash := sha256.Sum256(payload)
+
+		resp, err := handler(ctx, req)
+
+		var remoteAddr string
+		if p, ok := peer.FromContext(ctx); ok {
+			remoteAddr = p.Addr.String()
+		}
+		httpStatus := httpStatusFromGRPC(status.Code(err))
+		middleware.RecordAudit(ctx, auditLog, audit.Record{
+			Timestamp:     time.Now().UTC(),
+			RequestID:     log.RequestID(ctx),
+			Caller:        handlers.Caller(ctx),
+			RemoteAddr:    remoteAddr,
+			Action:        action,
+			Method:        "gRPC",
+			Path:          info.FullMethod,
+			PayloadSHA256: hex.EncodeToString(hash[:]),
+			Status:        httpStatus,
+			Outcome:       audit.OutcomeOf(httpStatus),
+		})
+		return resp, err
+	}
+}
+
+// httpStatusFromGRPC reverses grpcCodes, so that the gRPC calls are recorded with the same statuses as the HTTP ones
+func httpStatusFromGRPC(code codes.Code) int {
+	if code == codes.OK {
+		return http.StatusOK
+	}
+	httpStatus := http.StatusInternalServerError
+	for s, c := range grpcCodes {
+		// ResourceExhausted is used for two statuses, pick the lowest one so that it's deterministic
+		if c == code && (httpStatus == http.StatusInternalServerError || s < httpStatus) {
+			httpStatus = s
+		}
+	}
+	return httpStatus
+}
+
+func incomingMetadata(ctx context.Context, key string) string {
+	if values := metadata.ValueFromIncomingContext(ctx, key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// grpcError converts the errors.APIError returned by the handler logic to a gRPC status. As with the HTTP API,
+// the details of internal errors are logged rather than returned.
+func grpcError(err error) error {
+	var apiErr errors.APIError
+	if !errors2.As(err, &apiErr) {
+		apiErr = errors.NewAPIError("Internal error", http.StatusInternalServerError, err)
+	}
+	code, ok := grpcCodes[apiErr.Status]
+	if !ok {
+		log.LogNoRequestID("returning gRPC internal error", "msg", apiErr.Msg, "err", apiErr.Err)
+		return status.Error(codes.Internal, apiErr.Msg)
+	}
+	return status.Error(code, apiErr.Error())
+}
+
+func uploadVODRequestFromProto(req *rpc.SubmitVODRequest) handlers.UploadVODRequest {
+	r := handlers.UploadVODRequest{
+		ExternalID:            req.GetExternalId(),
+		Url:                   req.GetUrl(),
+		CallbackUrl:           req.GetCallbackUrl(),
+		AccessToken:           req.GetAccessToken(),
+		TranscodeAPIUrl:       req.GetTranscodeApiUrl(),
+		C2PA:                  req.GetC2Pa(),
+		TargetSegmentSizeSecs: req.GetTargetSegmentSizeSecs(),
+		PipelineStrategy:      pipeline.Strategy(req.GetPipelineStrategy()),
+	}
+	for _, o := range req.GetOutputLocations() {
+		r.OutputLocations = append(r.OutputLocations, handlers.UploadVODRequestOutputLocation{
+			Type:            o.GetType(),
+			URL:             o.GetUrl(),
+			PinataAccessKey: o.GetPinataAccessKey(),
+			Outputs: handlers.UploadVODRequestOutputLocationOutputs{
+				HLS:           o.GetOutputs().GetHls(),
+				MP4:           o.GetOutputs().GetMp4(),
+				FragmentedMP4: o.GetOutputs().GetFragmentedMp4(),
+				Clip:          o.GetOutputs().GetClip(),
+				SourceMp4:     o.GetOutputs().GetSourceMp4(),
+				Thumbnails:    o.GetOutputs().GetThumbnails(),
+			},
+		})
+	}
+	for _, p := range req.GetProfiles() {
+		r.Profiles = append(r.Profiles, video.EncodedProfile{
+			Name:         p.GetName(),
+			Width:        p.GetWidth(),
+			Height:       p.GetHeight(),
+			Bitrate:      p.GetBitrate(),
+			FPS:          p.GetFps(),
+			FPSDen:       p.GetFpsDen(),
+			Profile:      p.GetProfile(),
+			GOP:          p.GetGop(),
+			Encoder:      p.GetEncoder(),
+			ColorDepth:   p.GetColorDepth(),
+			ChromaFormat: p.GetChromaFormat(),
+			Quality:      uint(p.GetQuality()),
+		})
+	}
+	if e := req.GetEncryption(); e != nil {
+		r.Encryption = &pipeline.EncryptionPayload{
+			EncryptedKey:       e.GetEncryptedKey(),
+			Algorithm:          e.GetAlgorithm(),
+			KeyID:              e.GetKeyId(),
+			KeyWrapping:        e.GetKeyWrapping(),
+			EphemeralPublicKey: e.GetEphemeralPublicKey(),
+		}
+	}
+	if t := req.GetThumbnailOptions(); t != nil {
+		r.ThumbnailOptions = thumbnails.Options{
+			Resolution:  t.GetResolution(),
+			Interval:    t.GetInterval(),
+			Quality:     int(t.GetQuality()),
+			Format:      t.GetFormat(),
+			SkipMissing: t.GetSkipMissing(),
+		}
+	}
+	if c := req.GetClipStrategy(); c != nil {
+		r.ClipStrategy = video.ClipStrategy{
+			StartTime:  c.GetStartTime(),
+			EndTime:    c.GetEndTime(),
+			PlaybackID: c.GetPlaybackId(),
+		}
+	}
+	return r
+}
@@ -2,15 +2,18 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"net/http"
-	"time"
+	"strings"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/livepeer/catalyst-api/balancer"
+	"github.com/livepeer/catalyst-api/clients"
 	"github.com/livepeer/catalyst-api/config"
 	"github.com/livepeer/catalyst-api/handlers"
 	"github.com/livepeer/catalyst-api/handlers/analytics"
 	"github.com/livepeer/catalyst-api/handlers/geolocation"
+	"github.com/livepeer/catalyst-api/handlers/misttriggers"
 	"github.com/livepeer/catalyst-api/log"
 	mistapiconnector "github.com/livepeer/catalyst-api/mapic"
 	"github.com/livepeer/catalyst-api/metrics"
@@ -19,37 +22,39 @@ import (
 	"github.com/livepeer/go-api-client"
 )
 
-func ListenAndServe(ctx context.Context, cli config.Cli, vodEngine *pipeline.Coordinator, bal balancer.Balancer, mapic mistapiconnector.IMac, serfMembersEndpoint string) error {
-	router := NewCatalystAPIRouter(cli, vodEngine, bal, mapic, serfMembersEndpoint)
-	server := http.Server{Addr: cli.HTTPAddress, Handler: router}
-	ctx, cancel := context.WithCancel(ctx)
+func ListenAndServe(ctx context.Context, cli config.Cli, vodEngine *pipeline.Coordinator, bal balancer.Balancer, mapic mistapiconnector.IMac, broker misttriggers.TriggerBroker, mist clients.MistAPIClient, health *handlers.HealthHandlersCollection, serfMembersEndpoint string) error {
+	router := NewCatalystAPIRouter(cli, vodEngine, bal, mapic, broker, mist, health, serfMembersEndpoint)
+	server := newServer(cli.HTTPAddress, middleware.RequestID(router), cli.HTTPLimits)
 
 	log.LogNoRequestID(
 		"Starting Catalyst API!",
 		"version", config.Version,
 		"host", cli.HTTPAddress,
+		"tls", cli.HTTPTLS.Enabled(),
 	)
 
-	var err error
-	go func() {
-		err = server.ListenAndServe()
-		cancel()
-	}()
+	return serve(ctx, server, cli.HTTPTLS, cli.ShutdownDrainTimeout)
+}
 
-	<-ctx.Done()
-	if err != nil {
-		return err
+// newServer returns a server enforcing the configured timeouts, so slow clients can't hold connections open
+func newServer(addr string, handler http.Handler, limits config.HTTPLimitsConfig) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: limits.ReadHeaderTimeout,
+		ReadTimeout:       limits.ReadTimeout,
+		WriteTimeout:      limits.WriteTimeout,
+		IdleTimeout:       limits.IdleTimeout,
+		MaxHeaderBytes:    limits.MaxHeaderBytes,
 	}
-
-	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	return server.Shutdown(ctx)
 }
 
-func NewCatalystAPIRouter(cli config.Cli, vodEngine *pipeline.Coordinator, bal balancer.Balancer, mapic mistapiconnector.IMac, serfMembersEndpoint string) *httprouter.Router {
+func NewCatalystAPIRouter(cli config.Cli, vodEngine *pipeline.Coordinator, bal balancer.Balancer, mapic mistapiconnector.IMac, broker misttriggers.TriggerBroker, mist clients.MistAPIClient, health *handlers.HealthHandlersCollection, serfMembersEndpoint string) *httprouter.Router {
 	router := httprouter.New()
 	withLogging := middleware.LogRequest()
 	withCORS := middleware.AllowCORS()
+	withAnalyticsBodyLimit := middleware.LimitBody(cli.HTTPLimits.MaxBodyBytesFor(config.BodyLimitRouteAnalytics))
+	withWHIPBodyLimit := middleware.LimitBody(cli.HTTPLimits.MaxBodyBytesFor(config.BodyLimitRouteWHIP))
 	withGatingCheck := middleware.NewGatingHandler(cli, mapic).GatingCheck
 
 	lapi, _ := api.NewAPIClientGeolocated(api.ClientOptions{
@@ -58,15 +63,19 @@ func NewCatalystAPIRouter(cli config.Cli, vodEngine *pipeline.Coordinator, bal b
 	})
 	catalystApiHandlers := &handlers.CatalystAPIHandlersCollection{VODEngine: vodEngine}
 	geoHandlers := geolocation.NewGeolocationHandlersCollection(bal, cli, lapi, serfMembersEndpoint)
+	geoHandlers.ViewerLimits = newViewerLimiter(cli, bal, mist)
 
 	router.GET("/ok", withLogging(catalystApiHandlers.Ok()))
 	router.GET("/healthcheck", withLogging(catalystApiHandlers.Healthcheck()))
+	// Per-dependency liveness and readiness checks
+	router.GET("/healthz", withLogging(health.Healthz()))
+	router.GET("/readyz", withLogging(health.Readyz()))
 
 	if cli.EnableAnalytics == "true" || cli.EnableAnalytics == "enabled" {
 		logProcessor := analytics.NewLogProcessor(cli.KafkaBootstrapServers, cli.KafkaUser, cli.KafkaPassword, cli.AnalyticsKafkaTopic)
 
 		analyticsApiHandlers := handlers.NewAnalyticsHandlersCollection(mapic, lapi, logProcessor)
-		router.POST("/analytics/log", withCORS(analyticsApiHandlers.Log()))
+		router.POST("/analytics/log", withCORS(withAnalyticsBodyLimit(analyticsApiHandlers.Log())))
 		// Redirect GET /analytics/log to the specific catalyst node, e.g. "mdw-staging-staging-catalyst-0.livepeer.monster"
 		// This is useful for the player, because then it can stick to one node while sending analytics logs
 		router.GET("/analytics/log", withLogging(withCORS(geoHandlers.RedirectConstPathHandler())))
@@ -83,10 +92,43 @@ func NewCatalystAPIRouter(cli config.Cli, vodEngine *pipeline.Coordinator, bal b
 	)
 	router.GET("/asset/hls/:playbackID/*file", playback)
 	router.HEAD("/asset/hls/:playbackID/*file", playback)
-	for _, path := range [...]string{"/asset/hls/:playbackID/*file", "/webrtc/:playbackID"} {
-		router.OPTIONS(path, playback)
+	router.OPTIONS("/asset/hls/:playbackID/*file", playback)
+
+	// Key server for encrypted HLS outputs, gated in the same way as playback
+	if hlsKeySecret, _ := cli.HLSKeySecretBytes(); hlsKeySecret != nil {
+		hlsKeys := middleware.CountRequests(metrics.Metrics.HLSKeyRequestCount)(
+			withCORS(
+				withGatingCheck(
+					handlers.NewHLSKeyHandler(hlsKeySecret).Handle,
+				),
+			),
+		)
+		router.GET("/asset/keys/:playbackID", withLogging(hlsKeys))
+		router.HEAD("/asset/keys/:playbackID", withLogging(hlsKeys))
+		router.OPTIONS("/asset/keys/:playbackID", withLogging(hlsKeys))
 	}
 
+	// Live contribution
+	var whipPreflight httprouter.Handle
+	if cli.IsClusterMode() && mist != nil {
+		whip := handlers.NewWHIPHandler(broker, mist, fmt.Sprintf("http://%s:%d", cli.MistHost, cli.MistHTTPPort), cli.MistStreamSource)
+		router.POST("/webrtc/whip/:streamKey", withLogging(withCORS(withWHIPBodyLimit(whip.Handle))))
+		whipPreflight = withLogging(withCORS(whip.Handle))
+
+		// SRT contribution, tells the encoder which node to publish to and provisions the stream there
+		router.GET("/srt/:streamKey", withLogging(geoHandlers.SRTIngestHandler(broker, mist)))
+	}
+
+	// Browsers preflight WebRTC playback and the application/sdp offers of WHIP, both answered by the CORS
+	// middleware. They share a route since httprouter can't have both /webrtc/:playbackID and /webrtc/whip/:streamKey.
+	router.OPTIONS("/webrtc/*path", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		if whipPreflight != nil && strings.HasPrefix(ps.ByName("path"), "/whip/") {
+			whipPreflight(w, r, ps)
+			return
+		}
+		playback(w, r, ps)
+	})
+
 	// Handling incoming playback redirection requests
 	redirectHandler := withLogging(withCORS(geoHandlers.RedirectHandler()))
 	router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
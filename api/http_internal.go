@@ -11,10 +11,14 @@ import (
 	"time"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/audit"
 	"github.com/livepeer/catalyst-api/balancer"
+	"github.com/livepeer/catalyst-api/clients"
 	"github.com/livepeer/catalyst-api/cluster"
 	"github.com/livepeer/catalyst-api/config"
 	"github.com/livepeer/catalyst-api/crypto"
+	"github.com/livepeer/catalyst-api/crypto/signedurl"
+	"github.com/livepeer/catalyst-api/dvr"
 	"github.com/livepeer/catalyst-api/handlers"
 	"github.com/livepeer/catalyst-api/handlers/accesscontrol"
 	"github.com/livepeer/catalyst-api/handlers/admin"
@@ -22,48 +26,55 @@ import (
 	"github.com/livepeer/catalyst-api/handlers/ffmpeg"
 	"github.com/livepeer/catalyst-api/handlers/geolocation"
 	"github.com/livepeer/catalyst-api/handlers/misttriggers"
+	"github.com/livepeer/catalyst-api/liveclip"
+	"github.com/livepeer/catalyst-api/liveprofiles"
 	"github.com/livepeer/catalyst-api/log"
 	mistapiconnector "github.com/livepeer/catalyst-api/mapic"
 	"github.com/livepeer/catalyst-api/middleware"
+	"github.com/livepeer/catalyst-api/multistream"
 	"github.com/livepeer/catalyst-api/pipeline"
+	"github.com/livepeer/catalyst-api/pprof"
+	"github.com/livepeer/catalyst-api/recording"
+	"github.com/livepeer/catalyst-api/streamhealth"
+	"github.com/livepeer/catalyst-api/streamkeys"
+	"github.com/livepeer/catalyst-api/streammeta"
 	"github.com/livepeer/go-api-client"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-func ListenAndServeInternal(ctx context.Context, cli config.Cli, vodEngine *pipeline.Coordinator, mapic mistapiconnector.IMac, bal balancer.Balancer, c cluster.Cluster, broker misttriggers.TriggerBroker, metricsDB *sql.DB, serfMembersEndpoint, eventsEndpoint string, catalystApiURL string) error {
-	router := NewCatalystAPIRouterInternal(cli, vodEngine, mapic, bal, c, broker, metricsDB, serfMembersEndpoint, eventsEndpoint, catalystApiURL)
-	server := http.Server{Addr: cli.HTTPInternalAddress, Handler: router}
-	ctx, cancel := context.WithCancel(ctx)
+// uploadVODV1DeprecatedSince is when /api/vod was superseded by /api/v2/vod
+var uploadVODV1DeprecatedSince = time.Date(2026, time.October, 15, 0, 0, 0, 0, time.UTC)
+
+func ListenAndServeInternal(ctx context.Context, cli config.Cli, vodEngine *pipeline.Coordinator, mapic mistapiconnector.IMac, bal balancer.Balancer, c cluster.Cluster, broker misttriggers.TriggerBroker, mist clients.MistAPIClient, multistreamManager *multistream.Manager, recorder *recording.Recorder, dvrManager *dvr.Manager, streamMeta *streammeta.Manager, liveProfiles *liveprofiles.Manager, liveClipper *liveclip.Clipper, streamHealth *streamhealth.Tracker, metricsDB *sql.DB, health *handlers.HealthHandlersCollection, limiter *middleware.RateLimiter, capacity *middleware.CapacityMiddleware, eventsHandler *handlers.EventsHandlersCollection, serfMembersEndpoint, eventsEndpoint string, catalystApiURL string) error {
+	router := NewCatalystAPIRouterInternal(cli, vodEngine, mapic, bal, c, broker, mist, multistreamManager, recorder, dvrManager, streamMeta, liveProfiles, liveClipper, streamHealth, metricsDB, health, limiter, capacity, eventsHandler, serfMembersEndpoint, eventsEndpoint, catalystApiURL)
+	server := newServer(cli.HTTPInternalAddress, middleware.RequestID(router), cli.HTTPLimits)
 
 	log.LogNoRequestID(
 		"Starting Catalyst Internal API!",
 		"version", config.Version,
 		"host", cli.HTTPInternalAddress,
+		"tls", cli.HTTPInternalTLS.Enabled(),
 	)
 
-	var err error
-	go func() {
-		err = server.ListenAndServe()
-		cancel()
-	}()
-
-	<-ctx.Done()
-	if err != nil {
-		return err
-	}
-
-	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	return server.Shutdown(ctx)
+	return serve(ctx, server, cli.HTTPInternalTLS, cli.ShutdownDrainTimeout)
 }
 
-func NewCatalystAPIRouterInternal(cli config.Cli, vodEngine *pipeline.Coordinator, mapic mistapiconnector.IMac, bal balancer.Balancer, c cluster.Cluster, broker misttriggers.TriggerBroker, metricsDB *sql.DB, serfMembersEndpoint, eventsEndpoint string, catalystApiURL string) *httprouter.Router {
+func NewCatalystAPIRouterInternal(cli config.Cli, vodEngine *pipeline.Coordinator, mapic mistapiconnector.IMac, bal balancer.Balancer, c cluster.Cluster, broker misttriggers.TriggerBroker, mist clients.MistAPIClient, multistreamManager *multistream.Manager, recorder *recording.Recorder, dvrManager *dvr.Manager, streamMeta *streammeta.Manager, liveProfiles *liveprofiles.Manager, liveClipper *liveclip.Clipper, streamHealth *streamhealth.Tracker, metricsDB *sql.DB, health *handlers.HealthHandlersCollection, limiter *middleware.RateLimiter, capacity *middleware.CapacityMiddleware, eventsHandler *handlers.EventsHandlersCollection, serfMembersEndpoint, eventsEndpoint string, catalystApiURL string) *httprouter.Router {
 	router := httprouter.New()
 	withLogging := middleware.LogRequest()
-	withAuth := middleware.IsAuthorized
+	authorizer := middleware.NewAuthorizer(cli.APIToken, cli.JWTAuth, cli.APIKeys)
+	withAuth := authorizer.Authorize
+	withRateLimit := limiter.Limit
+	withCORS := middleware.NewCORS(cli.CORS)
+	withCompression := middleware.Compress()
+	withBodyLimit := middleware.LimitBody(cli.HTTPLimits.MaxBodyBytes)
+	withVODBodyLimit := middleware.LimitBody(cli.HTTPLimits.MaxBodyBytesFor(config.BodyLimitRouteVOD))
+	apiAuditLog := audit.NewLog(metricsDB)
+	withAudit := func(action string, next httprouter.Handle) httprouter.Handle {
+		return middleware.Audit(apiAuditLog, action)(next)
+	}
 
-	capacityMiddleware := middleware.CapacityMiddleware{}
-	withCapacityChecking := capacityMiddleware.HasCapacity
+	withCapacityChecking := capacity.HasCapacity
 
 	lapi, _ := api.NewAPIClientGeolocated(api.ClientOptions{
 		Server:      cli.APIServer,
@@ -74,16 +85,51 @@ func NewCatalystAPIRouterInternal(cli config.Cli, vodEngine *pipeline.Coordinato
 	spkiPublicKey, _ := crypto.ConvertToSpki(cli.VodDecryptPublicKey)
 
 	catalystApiHandlers := &handlers.CatalystAPIHandlersCollection{VODEngine: vodEngine}
-	eventsHandler := handlers.NewEventsHandlersCollection(c, mapic, bal, eventsEndpoint)
+	eventsHandler.Recorder = recorder
+	eventsHandler.DVR = dvrManager
+	eventsHandler.Metadata = streamMeta
+	eventsHandler.LiveProfiles = liveProfiles
+	pprof.RegisterCache("event_ids", eventsHandler.RecentBroadcastCount)
+	pprof.RegisterCache("request_loggers", log.CacheSize)
 	ffmpegSegmentingHandlers := &ffmpeg.HandlersCollection{VODEngine: vodEngine}
 	accessControlHandlers := accesscontrol.NewAccessControlHandlersCollection(cli, mapic)
 	analyticsHandlers := analytics.NewAnalyticsHandler(cli, metricsDB)
 	encryptionHandlers := accesscontrol.NewEncryptionHandlersCollection(cli, spkiPublicKey)
-	adminHandlers := &admin.AdminHandlersCollection{Cluster: c}
+	// the keys are validated on startup
+	playbackSigner, _ := signedurl.FromKeys(cli.PlaybackSigningSecret, cli.PlaybackSigningKey, cli.PlaybackVerificationKey)
+	streamKeys := streamkeys.NewStore(metricsDB)
+	adminHandlers := &admin.AdminHandlersCollection{Cluster: c, AuditLog: apiAuditLog, PlaybackSigner: playbackSigner, StreamKeys: streamKeys, Config: cli.EffectiveConfig, Mist: mist, StreamCache: mapic}
 	mistCallbackHandlers := misttriggers.NewMistCallbackHandlersCollection(cli, broker)
 
 	// Simple endpoint for healthchecks
 	router.GET("/ok", withLogging(catalystApiHandlers.Ok()))
+	// Per-dependency liveness and readiness checks
+	router.GET("/healthz", withLogging(health.Healthz()))
+	router.GET("/readyz", withLogging(health.Readyz()))
+
+	// Profiling and runtime stats, to diagnose e.g. goroutine leaks without restarting with the pprof listener
+	router.GET("/debug/pprof/*profile", withLogging(withAuth(middleware.ScopeAdmin, pprof.Handler())))
+	router.POST("/debug/pprof/*profile", withLogging(withAuth(middleware.ScopeAdmin, pprof.Handler())))
+	router.GET("/debug/runtime", withLogging(withAuth(middleware.ScopeAdmin, withCompression(pprof.RuntimeStatsHandler()))))
+	// Changes the log verbosity without a restart
+	router.POST("/admin/loglevel", withLogging(withAuth(middleware.ScopeAdmin, withBodyLimit(withAudit(audit.ActionLogLevelChange, adminHandlers.LogLevelHandler())))))
+	// Audit log of the state-changing API calls
+	router.GET("/admin/audit", withLogging(withAuth(middleware.ScopeAdmin, withCompression(adminHandlers.APIAuditHandler()))))
+	router.GET("/admin/config", withLogging(withAuth(middleware.ScopeAdmin, adminHandlers.ConfigHandler())))
+	if mist != nil {
+		router.GET("/admin/streams", withLogging(withAuth(middleware.ScopeAdmin, withCompression(adminHandlers.StreamsHandler()))))
+		nukeHandlers := &handlers.NukeHandlers{Events: eventsHandler, Mist: mist, NodeName: cli.NodeName, BaseStreamName: cli.MistBaseStreamName}
+		router.DELETE("/admin/stream/:playbackID", withLogging(withAuth(middleware.ScopeAdmin, withBodyLimit(withAudit(audit.ActionStreamNuke, nukeHandlers.NukeStream())))))
+	}
+	if mapic != nil {
+		stopSessionsHandlers := &handlers.StopSessionsHandlers{Mapic: mapic}
+		router.POST("/admin/stream/:playbackID/stop-sessions", withLogging(withAuth(middleware.ScopeAdmin, withBodyLimit(withAudit(audit.ActionStreamStopSessions, stopSessionsHandlers.StopSessions())))))
+		streamRefreshHandlers := &handlers.StreamRefreshHandlers{Mapic: mapic}
+		router.POST("/admin/stream/:playbackID/refresh", withLogging(withAuth(middleware.ScopeAdmin, withBodyLimit(withAudit(audit.ActionStreamRefresh, streamRefreshHandlers.RefreshStream())))))
+	}
+	router.GET("/admin/feature-flags", withLogging(withAuth(middleware.ScopeAdmin, adminHandlers.FeatureFlagsHandler())))
+	router.PUT("/admin/feature-flags/:name", withLogging(withAuth(middleware.ScopeAdmin, withBodyLimit(withAudit(audit.ActionFeatureFlagSet, adminHandlers.SetFeatureFlagHandler())))))
+	router.DELETE("/admin/feature-flags/:name", withLogging(withAuth(middleware.ScopeAdmin, withBodyLimit(withAudit(audit.ActionFeatureFlagClear, adminHandlers.ClearFeatureFlagHandler())))))
 
 	var metricsHandlers []http.Handler
 
@@ -97,38 +143,164 @@ func NewCatalystAPIRouterInternal(cli config.Cli, vodEngine *pipeline.Coordinato
 		}
 
 		// Public Catalyst API
-		router.POST("/api/vod",
+		uploadVOD := func(handler httprouter.Handle) httprouter.Handle {
+			return withLogging(
+				withCORS(
+					withAuth(
+						middleware.ScopeVODWrite,
+						withRateLimit(
+							vodEngine,
+							withVODBodyLimit(
+								withAudit(
+									audit.ActionVODSubmit,
+									withCapacityChecking(
+										vodEngine,
+										handler,
+									),
+								),
+							),
+						),
+					),
+				),
+			)
+		}
+		router.POST("/api/v2/vod", uploadVOD(catalystApiHandlers.UploadVODV2()))
+		// Superseded by /api/v2/vod, its payload is mapped onto the v2 one
+		router.POST("/api/vod", uploadVOD(middleware.Deprecated(uploadVODV1DeprecatedSince, "/api/v2/vod")(catalystApiHandlers.UploadVOD())))
+
+		router.POST("/api/vod/:requestID/thumbnails",
 			withLogging(
-				withAuth(
-					cli.APIToken,
-					withCapacityChecking(
-						vodEngine,
-						catalystApiHandlers.UploadVOD(),
+				withCORS(
+					withAuth(
+						middleware.ScopeVODWrite,
+						withRateLimit(
+							nil,
+							withVODBodyLimit(withAudit(audit.ActionVODThumbnails, catalystApiHandlers.RegenerateThumbnails())),
+						),
 					),
 				),
 			),
 		)
 
 		// Public GET handler to retrieve the public key for vod encryption
-		router.GET("/api/pubkey", withLogging(encryptionHandlers.PublicKeyHandler()))
+		router.GET("/api/pubkey", withLogging(withCORS(encryptionHandlers.PublicKeyHandler())))
+		// Public GET handler listing all the accepted keys, their IDs and the supported algorithms
+		router.GET("/api/vod/encryption-key", withLogging(withCORS(withCompression(catalystApiHandlers.EncryptionKeys()))))
+
+		// CORS preflight requests for the POST routes above, answered by the CORS middleware without authorization.
+		// The GET routes are unauthenticated so browsers don't preflight them.
+		for _, path := range []string{"/api/vod", "/api/v2/vod", "/api/vod/:requestID/thumbnails"} {
+			router.OPTIONS(path, withLogging(withCORS(preflightOnly)))
+		}
 
 		// Endpoint to receive "Triggers" (callbacks) from Mist
-		router.POST("/api/mist/trigger", withLogging(mistCallbackHandlers.Trigger()))
+		router.POST("/api/mist/trigger", withLogging(withBodyLimit(mistCallbackHandlers.Trigger())))
 
 		// Handler for STREAM_SOURCE triggers
 		broker.OnStreamSource(geoHandlers.HandleStreamSource)
 
-		// Handler for USER_NEW triggers
+		// Handler for USER_NEW triggers, also enforcing the streams' max concurrent viewers
+		accessControlHandlers.ViewerLimits = newViewerLimiter(cli, bal, mist)
 		broker.OnUserNew(accessControlHandlers.HandleUserNew)
 
 		// Handler for USER_END triggers.
 		broker.OnUserEnd(analyticsHandlers.HandleUserEnd)
 
+		// Records of the playback sessions, exported to the data warehouse
+		if sessionSink := analytics.NewSessionSink(cli); sessionSink != nil {
+			sessionExporter := analytics.NewSessionExporter(sessionSink, cli.NodeName, cli.NodeLatitude, cli.NodeLongitude)
+			broker.OnUserEnd(sessionExporter.HandleUserEnd)
+		}
+
+		// Stream keys that RTMP, SRT and WHIP pushes are authorized with
+		if cli.StreamKeyAuth {
+			broker.OnPushRewrite(streamkeys.HandlePushRewrite(streamKeys))
+			router.POST("/admin/stream-keys", withLogging(withAuth(middleware.ScopeAdmin, withBodyLimit(withAudit(audit.ActionStreamKeyCreate, adminHandlers.CreateStreamKeyHandler())))))
+			router.POST("/admin/stream-keys/:streamName/rotate", withLogging(withAuth(middleware.ScopeAdmin, withBodyLimit(withAudit(audit.ActionStreamKeyRotate, adminHandlers.RotateStreamKeyHandler())))))
+			router.DELETE("/admin/stream-keys/:streamName", withLogging(withAuth(middleware.ScopeAdmin, withBodyLimit(withAudit(audit.ActionStreamKeyRevoke, adminHandlers.RevokeStreamKeyHandler())))))
+		}
+
+		// Restreaming of the live streams ingested on this node to RTMP(S) and SRT targets
+		if multistreamManager != nil {
+			multistreamHandlers := &handlers.MultistreamHandlers{Manager: multistreamManager}
+			router.POST("/api/stream/:playbackID/multistream", withLogging(withAuth(middleware.ScopeStreamsWrite, withBodyLimit(withAudit(audit.ActionMultistreamAdd, multistreamHandlers.AddTargets())))))
+			router.GET("/api/stream/:playbackID/multistream", withLogging(withAuth(middleware.ScopeRead, multistreamHandlers.TargetsStatus())))
+			router.DELETE("/api/stream/:playbackID/multistream/:targetID", withLogging(withAuth(middleware.ScopeStreamsWrite, withBodyLimit(withAudit(audit.ActionMultistreamRemove, multistreamHandlers.RemoveTarget())))))
+		}
+
+		// Recording of live streams, processed by the VOD pipeline once each session ends
+		if recorder != nil {
+			recordingHandlers := &handlers.RecordingHandlers{Events: eventsHandler, Recorder: recorder}
+			router.PUT("/api/stream/:playbackID/recording", withLogging(withAuth(middleware.ScopeStreamsWrite, withBodyLimit(withAudit(audit.ActionRecordingSet, recordingHandlers.SetRecording())))))
+			router.GET("/api/stream/:playbackID/recording", withLogging(withAuth(middleware.ScopeRead, recordingHandlers.GetRecording())))
+		}
+
+		// DVR window of live streams
+		if dvrManager != nil {
+			dvrHandlers := &handlers.DVRHandlers{Events: eventsHandler, DVR: dvrManager}
+			router.PUT("/api/stream/:playbackID/dvr", withLogging(withAuth(middleware.ScopeStreamsWrite, withBodyLimit(withAudit(audit.ActionDVRSet, dvrHandlers.SetDVR())))))
+			router.GET("/api/stream/:playbackID/dvr", withLogging(withAuth(middleware.ScopeRead, dvrHandlers.GetDVR())))
+		}
+
+		// Metadata of live streams translated into their Mist settings
+		if streamMeta != nil {
+			metadataHandlers := &handlers.StreamMetadataHandlers{Events: eventsHandler, Metadata: streamMeta}
+			router.PUT("/api/stream/:playbackID/metadata", withLogging(withAuth(middleware.ScopeStreamsWrite, withBodyLimit(withAudit(audit.ActionStreamMetadataSet, metadataHandlers.SetMetadata())))))
+			router.GET("/api/stream/:playbackID/metadata", withLogging(withAuth(middleware.ScopeRead, metadataHandlers.GetMetadata())))
+		}
+
+		// Live transcoding ladder of streams
+		if liveProfiles != nil {
+			liveProfilesHandlers := &handlers.LiveProfilesHandlers{Events: eventsHandler, LiveProfiles: liveProfiles}
+			router.PUT("/api/stream/:playbackID/profiles", withLogging(withAuth(middleware.ScopeStreamsWrite, withBodyLimit(withAudit(audit.ActionLiveProfilesSet, liveProfilesHandlers.SetProfiles())))))
+			router.GET("/api/stream/:playbackID/profiles", withLogging(withAuth(middleware.ScopeRead, liveProfilesHandlers.GetProfiles())))
+			router.DELETE("/api/stream/:playbackID/profiles", withLogging(withAuth(middleware.ScopeStreamsWrite, withBodyLimit(withAudit(audit.ActionLiveProfilesClear, liveProfilesHandlers.DeleteProfiles())))))
+		}
+
+		// Viewer counts of live streams, for display in dashboards
+		if mist != nil {
+			streamViewersHandlers := handlers.NewStreamViewersHandlers(mist, nodeViewerCounter(bal), cli.NodeName, cli.MistBaseStreamName)
+			router.GET("/api/stream/:playbackID/viewers", withLogging(withAuth(middleware.ScopeRead, streamViewersHandlers.StreamViewers())))
+			// the same viewer counts along with the stream's health, pushed as Server-Sent Events
+			viewershipHandlers := handlers.NewViewershipHandlers(streamViewersHandlers, streamHealth)
+			router.GET("/api/stream/:playbackID/viewership", withLogging(withAuth(middleware.ScopeRead, viewershipHandlers.Viewership())))
+		}
+
+		// Health of the live streams on this node
+		if streamHealth != nil {
+			streamHealthHandlers := &handlers.StreamHealthHandlers{Tracker: streamHealth}
+			router.GET("/api/stream/:playbackID/health", withLogging(withAuth(middleware.ScopeRead, streamHealthHandlers.StreamHealth())))
+		}
+
+		// Clipping of live streams, processed as VOD jobs
+		if liveClipper != nil {
+			liveClipHandlers := &handlers.LiveClipHandlers{VOD: catalystApiHandlers, Clipper: liveClipper}
+			router.POST("/api/clip",
+				withLogging(
+					withAuth(
+						middleware.ScopeVODWrite,
+						withRateLimit(
+							vodEngine,
+							withVODBodyLimit(
+								withAudit(
+									audit.ActionLiveClip,
+									withCapacityChecking(
+										vodEngine,
+										liveClipHandlers.Clip(),
+									),
+								),
+							),
+						),
+					),
+				),
+			)
+		}
+
 		// Endpoint to receive segments and manifests that ffmpeg produces
 		router.POST("/api/ffmpeg/:id/:filename", withLogging(ffmpegSegmentingHandlers.NewFile()))
 
 		// Handler to forward the user event from Catalyst => Catalyst API
-		router.POST("/api/serf/receiveUserEvent", withLogging(eventsHandler.ReceiveUserEvent()))
+		router.POST("/api/serf/receiveUserEvent", withLogging(withBodyLimit(eventsHandler.ReceiveUserEvent())))
 	} else {
 		// Endpoint to receive "Triggers" (callbacks) from Mist and redirect them to the standalone Catalyst API
 		mistTriggerHandlerEndpoint := fmt.Sprintf("%s/api/mist/trigger", catalystApiURL)
@@ -141,11 +313,20 @@ func NewCatalystAPIRouterInternal(cli config.Cli, vodEngine *pipeline.Coordinato
 
 	if cli.IsClusterMode() {
 		// Temporary endpoint for admin queries
-		router.GET("/admin/members", withLogging(adminHandlers.MembersHandler()))
+		router.GET("/admin/members", withLogging(withCompression(authorizer.AuthorizeIfConfigured(middleware.ScopeRead, adminHandlers.MembersHandler()))))
+		// Audit log of the events received by /api/events
+		router.GET("/admin/events", withLogging(withAuth(middleware.ScopeAdmin, withCompression(adminHandlers.EventsHandler()))))
+		// Generates signed, expiring playback URLs for gated playback
+		router.POST("/admin/playback-urls", withLogging(withAuth(middleware.ScopeAdmin, withBodyLimit(withAudit(audit.ActionPlaybackURLSign, adminHandlers.SignPlaybackURLHandler())))))
 		// Handler to get members Catalyst API => Catalyst
-		router.GET("/api/serf/members", withLogging(adminHandlers.MembersHandler()))
+		router.GET("/api/serf/members", withLogging(withCompression(adminHandlers.MembersHandler())))
 		// Public handler to propagate an event to all Catalyst nodes, execute from Studio API => Catalyst
-		router.POST("/api/events", withLogging(eventsHandler.Events()))
+		// historically unauthenticated, so only authorized once JWTs or API keys are configured
+		router.POST("/api/events", withLogging(authorizer.AuthorizeIfConfigured(middleware.ScopeEventsWrite, withBodyLimit(withAudit(audit.ActionEventBroadcast, eventsHandler.Events())))))
+		// Recent state-changing events, fetched by nodes joining the cluster to replay them
+		router.GET("/api/events/recent", withLogging(withCompression(eventsHandler.RecentEvents(cli.EventsReplayWindow))))
+		// Schema versions supported for each event resource
+		router.GET("/api/events/versions", withLogging(withCompression(eventsHandler.EventVersions())))
 	} else {
 		router.POST("/api/events", withLogging(handlers.ProxyRequest(eventsEndpoint)))
 	}
@@ -174,3 +355,8 @@ func concatHandlers(handlers ...http.Handler) httprouter.Handle {
 		outbuf.WriteTo(w) // nolint:errcheck
 	}
 }
+
+// preflightOnly is behind the CORS middleware on OPTIONS routes, so it's only reached by plain OPTIONS requests
+func preflightOnly(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	w.WriteHeader(http.StatusNoContent)
+}
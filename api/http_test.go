@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/livepeer/catalyst-api/clients"
+	"github.com/livepeer/catalyst-api/config"
+	"github.com/livepeer/catalyst-api/handlers"
+	"github.com/livepeer/catalyst-api/handlers/misttriggers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWHIPPreflight(t *testing.T) {
+	mist := clients.NewMistAPIClient("", "", "localhost", 4242, time.Second)
+	router := NewCatalystAPIRouter(config.Cli{Mode: "all", APIServer: "http://localhost"}, nil, nil, nil, misttriggers.NewTriggerBroker(), mist, handlers.NewHealthHandlersCollection(), "")
+
+	req := httptest.NewRequest(http.MethodOptions, "/webrtc/whip/stream-key", nil)
+	req.Header.Set("Origin", "https://publisher.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	req.Header.Set("Access-Control-Request-Headers", "content-type")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "https://publisher.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+	require.Contains(t, rr.Header().Get("Access-Control-Allow-Methods"), http.MethodPost)
+	require.Equal(t, "*", rr.Header().Get("Access-Control-Allow-Headers"))
+	require.Equal(t, "Location", rr.Header().Get("Access-Control-Expose-Headers"))
+}
+
+func TestWebRTCPlaybackPreflight(t *testing.T) {
+	router := NewCatalystAPIRouter(config.Cli{Mode: "all", APIServer: "http://localhost"}, nil, nil, nil, misttriggers.NewTriggerBroker(), nil, handlers.NewHealthHandlersCollection(), "")
+
+	req := httptest.NewRequest(http.MethodOptions, "/webrtc/playback-id", nil)
+	req.Header.Set("Origin", "https://player.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "https://player.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+}
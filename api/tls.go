@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/livepeer/catalyst-api/config"
+	"github.com/livepeer/catalyst-api/log"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// serve runs the server with the TLS configuration until the context is done, then shuts it down gracefully,
+// giving in-flight requests up to drainTimeout to complete
+func serve(ctx context.Context, server *http.Server, tlsConfig config.TLSConfig, drainTimeout time.Duration) error {
+	servers := []*http.Server{server}
+
+	listen := server.ListenAndServe
+	if tlsConfig.CertFile != "" {
+		listen = func() error {
+			return server.ListenAndServeTLS(tlsConfig.CertFile, tlsConfig.KeyFile)
+		}
+	} else if len(tlsConfig.AutocertDomains) > 0 {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(tlsConfig.AutocertDomains...),
+			Cache:      autocert.DirCache(tlsConfig.AutocertCacheDir),
+			Email:      tlsConfig.AutocertEmail,
+		}
+		server.TLSConfig = manager.TLSConfig()
+		listen = func() error {
+			// the certificate comes from the manager
+			return server.ListenAndServeTLS("", "")
+		}
+
+		if tlsConfig.AutocertHTTPAddress != "" {
+			challengeServer := &http.Server{Addr: tlsConfig.AutocertHTTPAddress, Handler: manager.HTTPHandler(nil)}
+			servers = append(servers, challengeServer)
+			log.LogNoRequestID("Answering ACME challenges", "host", tlsConfig.AutocertHTTPAddress)
+			go func() {
+				if err := challengeServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					log.LogNoRequestID("ACME challenge server failed", "err", err)
+				}
+			}()
+		}
+	}
+
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- listen()
+	}()
+
+	select {
+	case err := <-listenErr:
+		// the server only stops by itself if it fails, e.g. when the address is in use
+		return err
+	case <-ctx.Done():
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+	for _, s := range servers[1:] {
+		_ = s.Shutdown(ctx)
+	}
+	return server.Shutdown(ctx)
+}
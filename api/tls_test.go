@@ -0,0 +1,37 @@
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/livepeer/catalyst-api/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeShutsDownWhenTheContextIsDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- serve(ctx, &http.Server{Addr: "127.0.0.1:0"}, config.TLSConfig{}, time.Second)
+	}()
+
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("serve didn't return once the context was done")
+	}
+}
+
+func TestServeReturnsTheListenError(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	err = serve(context.Background(), &http.Server{Addr: listener.Addr().String()}, config.TLSConfig{}, time.Second)
+	require.ErrorContains(t, err, "address already in use")
+}
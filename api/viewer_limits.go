@@ -0,0 +1,29 @@
+package api
+
+import (
+	"github.com/livepeer/catalyst-api/balancer"
+	"github.com/livepeer/catalyst-api/clients"
+	"github.com/livepeer/catalyst-api/config"
+	"github.com/livepeer/catalyst-api/handlers"
+	"github.com/livepeer/catalyst-api/viewerlimit"
+)
+
+// nodeViewerCounter returns the catabalancer, which knows the viewers of the streams on every node, nil if it isn't
+// enabled
+func nodeViewerCounter(bal balancer.Balancer) handlers.NodeViewerCounter {
+	if combined, ok := bal.(balancer.CombinedBalancer); ok {
+		if counter, ok := combined.Catabalancer.(handlers.NodeViewerCounter); ok {
+			return counter
+		}
+	}
+	return nil
+}
+
+// newViewerLimiter returns the limiter of the streams' concurrent viewers, nil if no limits are configured
+func newViewerLimiter(cli config.Cli, bal balancer.Balancer, mist clients.MistAPIClient) *viewerlimit.Limiter {
+	if (len(cli.ViewerLimits) == 0 && cli.ViewerLimitPolicyURL == "") || mist == nil {
+		return nil
+	}
+	counter := handlers.NewStreamViewersHandlers(mist, nodeViewerCounter(bal), cli.NodeName, cli.MistBaseStreamName)
+	return viewerlimit.NewLimiter(cli.ViewerLimits, cli.ViewerLimitPolicyURL, counter)
+}
@@ -0,0 +1,329 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	tableName = "api_audit_log"
+
+	// Number of records kept by the in-memory audit log when no database is configured
+	memoryLogSize = 10000
+
+	// Maximum number of records returned by a single audit log query
+	MaxQueryLimit = 1000
+
+	OutcomeSucceeded = "succeeded"
+	OutcomeFailed    = "failed"
+)
+
+// Actions recorded in the audit log, one for each state-changing API call
+const (
+	ActionVODSubmit          = "vod.submit"
+	ActionVODThumbnails      = "vod.thumbnails"
+	ActionEventBroadcast     = "event.broadcast"
+	ActionPlaybackURLSign    = "admin.playback_url.sign"
+	ActionLogLevelChange     = "admin.loglevel.change"
+	ActionFeatureFlagSet     = "admin.feature_flag.set"
+	ActionFeatureFlagClear   = "admin.feature_flag.clear"
+	ActionStreamKeyCreate    = "admin.stream_key.create"
+	ActionStreamKeyRotate    = "admin.stream_key.rotate"
+	ActionStreamKeyRevoke    = "admin.stream_key.revoke"
+	ActionStreamNuke         = "admin.stream.nuke"
+	ActionStreamStopSessions = "admin.stream.stop_sessions"
+	ActionStreamRefresh      = "admin.stream.refresh"
+	ActionMultistreamAdd     = "stream.multistream.add"
+	ActionMultistreamRemove  = "stream.multistream.remove"
+	ActionRecordingSet       = "stream.recording.set"
+	ActionDVRSet             = "stream.dvr.set"
+	ActionStreamMetadataSet  = "stream.metadata.set"
+	ActionLiveProfilesSet    = "stream.live_profiles.set"
+	ActionLiveProfilesClear  = "stream.live_profiles.clear"
+	ActionLiveClip           = "stream.clip"
+
+	// ActionClusterEvent is an event broadcast to the cluster, by /api/events and the routes changing a stream. It's
+	// recorded with the resource and playback ID of the event, and the succeeded nukes are replayed to nodes joining
+	// the cluster.
+	ActionClusterEvent = "cluster.event"
+)
+
+// Record describes a single state-changing API call or cluster event. Only a hash of the payload is kept, since
+// payloads can contain credentials, e.g. in object store URLs.
+type Record struct {
+	Timestamp     time.Time `json:"timestamp"`
+	RequestID     string    `json:"request_id"`
+	Caller        string    `json:"caller"`
+	RemoteAddr    string    `json:"remote_addr"`
+	Action        string    `json:"action"`
+	Method        string    `json:"method,omitempty"`
+	Path          string    `json:"path,omitempty"`
+	PayloadSHA256 string    `json:"payload_sha256,omitempty"`
+	Status        int       `json:"status,omitempty"`
+	Outcome       string    `json:"outcome"`
+	// Resource and PlaybackID of a cluster event
+	Resource   string `json:"resource,omitempty"`
+	PlaybackID string `json:"playback_id,omitempty"`
+	// Error of a failed cluster event
+	Error string `json:"error,omitempty"`
+}
+
+// OutcomeOf returns the outcome of a call from its HTTP status
+func OutcomeOf(status int) string {
+	if status >= 400 {
+		return OutcomeFailed
+	}
+	return OutcomeSucceeded
+}
+
+// Filter narrows down the records returned by Log.Query. Zero values are ignored.
+type Filter struct {
+	Caller    string
+	Action    string
+	Outcome   string
+	RequestID string
+	Resource  string
+	// Resources matches the records of any of the resources
+	Resources  []string
+	PlaybackID string
+	Since      time.Time
+	Until      time.Time
+	Limit      int
+}
+
+func (f Filter) matches(r Record) bool {
+	if f.Caller != "" && f.Caller != r.Caller {
+		return false
+	}
+	if f.Action != "" && f.Action != r.Action {
+		return false
+	}
+	if f.Outcome != "" && f.Outcome != r.Outcome {
+		return false
+	}
+	if f.RequestID != "" && f.RequestID != r.RequestID {
+		return false
+	}
+	if f.Resource != "" && f.Resource != r.Resource {
+		return false
+	}
+	if len(f.Resources) > 0 && !slices.Contains(f.Resources, r.Resource) {
+		return false
+	}
+	if f.PlaybackID != "" && f.PlaybackID != r.PlaybackID {
+		return false
+	}
+	if !f.Since.IsZero() && r.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && r.Timestamp.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+func (f Filter) limit() int {
+	if f.Limit <= 0 || f.Limit > MaxQueryLimit {
+		return MaxQueryLimit
+	}
+	return f.Limit
+}
+
+// Log is an append-only sink of audit records
+type Log interface {
+	Record(ctx context.Context, record Record) error
+	// Query returns the records matching the filter, most recent first
+	Query(ctx context.Context, filter Filter) ([]Record, error)
+}
+
+// NewLog returns an audit log persisted to Postgres if a DB is configured, otherwise falls back to a bounded
+// in-memory log that only covers the lifetime of this process
+func NewLog(db *sql.DB) Log {
+	if db != nil {
+		return &dbLog{db: db}
+	}
+	return NewMemoryLog(memoryLogSize)
+}
+
+// CreateTable creates the table of the audit log in the metrics DB if it doesn't exist
+func CreateTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `create table if not exists "`+tableName+`" (
+		"timestamp_ms"   bigint not null,
+		"request_id"     text,
+		"caller"         text,
+		"remote_addr"    text,
+		"action"         text,
+		"method"         text,
+		"path"           text,
+		"payload_sha256" text,
+		"status"         integer,
+		"outcome"        text,
+		"resource"       text,
+		"playback_id"    text,
+		"error"          text
+	);
+	create index if not exists "`+tableName+`_timestamp_ms_idx" on "`+tableName+`" ("timestamp_ms")`)
+	if err != nil {
+		return fmt.Errorf("error creating API audit log table: %w", err)
+	}
+	return nil
+}
+
+type dbLog struct {
+	db *sql.DB
+}
+
+func (a *dbLog) Record(ctx context.Context, record Record) error {
+	insertDynStmt := `insert into "` + tableName + `"(
+		"timestamp_ms",
+		"request_id",
+		"caller",
+		"remote_addr",
+		"action",
+		"method",
+		"path",
+		"payload_sha256",
+		"status",
+		"outcome",
+		"resource",
+		"playback_id",
+		"error"
+		) values($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+	_, err := a.db.ExecContext(
+		ctx,
+		insertDynStmt,
+		record.Timestamp.UnixMilli(),
+		record.RequestID,
+		record.Caller,
+		record.RemoteAddr,
+		record.Action,
+		record.Method,
+		record.Path,
+		record.PayloadSHA256,
+		record.Status,
+		record.Outcome,
+		record.Resource,
+		record.PlaybackID,
+		record.Error,
+	)
+	if err != nil {
+		return fmt.Errorf("error writing API audit record: %w", err)
+	}
+	return nil
+}
+
+func (a *dbLog) Query(ctx context.Context, filter Filter) ([]Record, error) {
+	var conditions []string
+	var args []any
+	addCondition := func(cond string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(cond, len(args)))
+	}
+	if filter.Caller != "" {
+		addCondition(`"caller" = $%d`, filter.Caller)
+	}
+	if filter.Action != "" {
+		addCondition(`"action" = $%d`, filter.Action)
+	}
+	if filter.Outcome != "" {
+		addCondition(`"outcome" = $%d`, filter.Outcome)
+	}
+	if filter.RequestID != "" {
+		addCondition(`"request_id" = $%d`, filter.RequestID)
+	}
+	if filter.Resource != "" {
+		addCondition(`"resource" = $%d`, filter.Resource)
+	}
+	if len(filter.Resources) > 0 {
+		placeholders := make([]string, len(filter.Resources))
+		for i, resource := range filter.Resources {
+			args = append(args, resource)
+			placeholders[i] = fmt.Sprintf("$%d", len(args))
+		}
+		conditions = append(conditions, `"resource" in (`+strings.Join(placeholders, ", ")+`)`)
+	}
+	if filter.PlaybackID != "" {
+		addCondition(`"playback_id" = $%d`, filter.PlaybackID)
+	}
+	if !filter.Since.IsZero() {
+		addCondition(`"timestamp_ms" >= $%d`, filter.Since.UnixMilli())
+	}
+	if !filter.Until.IsZero() {
+		addCondition(`"timestamp_ms" <= $%d`, filter.Until.UnixMilli())
+	}
+
+	query := `select "timestamp_ms", "request_id", "caller", "remote_addr", "action", "method", "path", "payload_sha256", "status", "outcome", "resource", "playback_id", "error" from "` + tableName + `"`
+	if len(conditions) > 0 {
+		query += " where " + strings.Join(conditions, " and ")
+	}
+	args = append(args, filter.limit())
+	query += fmt.Sprintf(` order by "timestamp_ms" desc limit $%d`, len(args))
+
+	rows, err := a.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying API audit log: %w", err)
+	}
+	defer rows.Close()
+
+	records := []Record{}
+	for rows.Next() {
+		var r Record
+		var timestampMs int64
+		if err := rows.Scan(&timestampMs, &r.RequestID, &r.Caller, &r.RemoteAddr, &r.Action, &r.Method, &r.Path, &r.PayloadSHA256, &r.Status, &r.Outcome, &r.Resource, &r.PlaybackID, &r.Error); err != nil {
+			return nil, fmt.Errorf("error reading API audit record: %w", err)
+		}
+		r.Timestamp = time.UnixMilli(timestampMs).UTC()
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// MemoryLog keeps the most recent audit records in a fixed-size ring buffer
+type MemoryLog struct {
+	mu      sync.Mutex
+	records []Record
+	next    int
+	full    bool
+}
+
+func NewMemoryLog(size int) *MemoryLog {
+	return &MemoryLog{records: make([]Record, size)}
+}
+
+func (m *MemoryLog) Record(_ context.Context, record Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.records) == 0 {
+		return nil
+	}
+	m.records[m.next] = record
+	m.next = (m.next + 1) % len(m.records)
+	if m.next == 0 {
+		m.full = true
+	}
+	return nil
+}
+
+func (m *MemoryLog) Query(_ context.Context, filter Filter) ([]Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	count := m.next
+	if m.full {
+		count = len(m.records)
+	}
+	limit := filter.limit()
+	records := []Record{}
+	for i := 1; i <= count && len(records) < limit; i++ {
+		r := m.records[(m.next-i+len(m.records))%len(m.records)]
+		if filter.matches(r) {
+			records = append(records, r)
+		}
+	}
+	return records, nil
+}
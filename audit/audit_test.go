@@ -0,0 +1,143 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryLogReturnsMostRecentFirst(t *testing.T) {
+	ctx := context.Background()
+	auditLog := NewMemoryLog(3)
+	start := time.Now()
+	for i, requestID := range []string{"a", "b", "c", "d"} {
+		require.NoError(t, auditLog.Record(ctx, Record{
+			Timestamp: start.Add(time.Duration(i) * time.Second),
+			RequestID: requestID,
+			Action:    ActionVODSubmit,
+			Outcome:   OutcomeSucceeded,
+		}))
+	}
+
+	records, err := auditLog.Query(ctx, Filter{})
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	require.Equal(t, "d", records[0].RequestID)
+	require.Equal(t, "c", records[1].RequestID)
+	require.Equal(t, "b", records[2].RequestID)
+}
+
+func TestMemoryLogFilters(t *testing.T) {
+	ctx := context.Background()
+	auditLog := NewMemoryLog(10)
+	start := time.Now()
+	require.NoError(t, auditLog.Record(ctx, Record{Timestamp: start, RequestID: "a", Caller: "key:studio", Action: ActionVODSubmit, Outcome: OutcomeSucceeded}))
+	require.NoError(t, auditLog.Record(ctx, Record{Timestamp: start.Add(time.Minute), RequestID: "b", Caller: "key:studio", Action: ActionEventBroadcast, Outcome: OutcomeFailed}))
+	require.NoError(t, auditLog.Record(ctx, Record{Timestamp: start.Add(2 * time.Minute), RequestID: "c", Caller: "api-token", Action: ActionVODSubmit, Outcome: OutcomeSucceeded}))
+
+	records, err := auditLog.Query(ctx, Filter{Action: ActionVODSubmit})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	records, err = auditLog.Query(ctx, Filter{Caller: "key:studio", Outcome: OutcomeFailed})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, "b", records[0].RequestID)
+
+	records, err = auditLog.Query(ctx, Filter{RequestID: "c"})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, "api-token", records[0].Caller)
+
+	records, err = auditLog.Query(ctx, Filter{Since: start.Add(30 * time.Second), Until: start.Add(90 * time.Second)})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, "b", records[0].RequestID)
+
+	records, err = auditLog.Query(ctx, Filter{Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, "c", records[0].RequestID)
+}
+
+func TestMemoryLogFiltersClusterEvents(t *testing.T) {
+	ctx := context.Background()
+	auditLog := NewMemoryLog(10)
+	start := time.Now()
+	require.NoError(t, auditLog.Record(ctx, Record{Timestamp: start, Action: ActionClusterEvent, Resource: "nuke", PlaybackID: "abc", Outcome: OutcomeSucceeded}))
+	require.NoError(t, auditLog.Record(ctx, Record{Timestamp: start.Add(time.Minute), Action: ActionClusterEvent, Resource: "stream", PlaybackID: "abc", Outcome: OutcomeFailed, Error: "serf unavailable"}))
+	require.NoError(t, auditLog.Record(ctx, Record{Timestamp: start.Add(2 * time.Minute), Action: ActionClusterEvent, Resource: "nuke", PlaybackID: "def", Outcome: OutcomeSucceeded}))
+
+	records, err := auditLog.Query(ctx, Filter{Resource: "nuke"})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	records, err = auditLog.Query(ctx, Filter{Resources: []string{"nuke", "stopSessions"}})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	records, err = auditLog.Query(ctx, Filter{PlaybackID: "abc", Outcome: OutcomeFailed})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, "serf unavailable", records[0].Error)
+}
+
+func TestOutcomeOf(t *testing.T) {
+	require.Equal(t, OutcomeSucceeded, OutcomeOf(200))
+	require.Equal(t, OutcomeSucceeded, OutcomeOf(302))
+	require.Equal(t, OutcomeFailed, OutcomeOf(400))
+	require.Equal(t, OutcomeFailed, OutcomeOf(503))
+}
+
+func TestDBLog(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	auditLog := NewLog(db)
+	ts := time.UnixMilli(1700000000000).UTC()
+	record := Record{
+		Timestamp:     ts,
+		RequestID:     "abc",
+		Caller:        "key:studio",
+		RemoteAddr:    "1.2.3.4",
+		Action:        ActionVODSubmit,
+		Method:        "POST",
+		Path:          "/api/v2/vod",
+		PayloadSHA256: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		Status:        200,
+		Outcome:       OutcomeSucceeded,
+	}
+
+	mock.ExpectExec("insert into \"api_audit_log\"").
+		WithArgs(ts.UnixMilli(), "abc", "key:studio", "1.2.3.4", ActionVODSubmit, "POST", "/api/v2/vod", record.PayloadSHA256, 200, OutcomeSucceeded, "", "", "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	require.NoError(t, auditLog.Record(context.Background(), record))
+
+	mock.ExpectQuery(`select .* from "api_audit_log" where "caller" = \$1 and "action" = \$2 order by "timestamp_ms" desc limit \$3`).
+		WithArgs("key:studio", ActionVODSubmit, 50).
+		WillReturnRows(sqlmock.NewRows([]string{"timestamp_ms", "request_id", "caller", "remote_addr", "action", "method", "path", "payload_sha256", "status", "outcome", "resource", "playback_id", "error"}).
+			AddRow(ts.UnixMilli(), "abc", "key:studio", "1.2.3.4", ActionVODSubmit, "POST", "/api/v2/vod", record.PayloadSHA256, 200, OutcomeSucceeded, "", "", ""))
+	records, err := auditLog.Query(context.Background(), Filter{Caller: "key:studio", Action: ActionVODSubmit, Limit: 50})
+	require.NoError(t, err)
+	require.Equal(t, []Record{record}, records)
+
+	mock.ExpectQuery(`select .* from "api_audit_log" where "action" = \$1 and "resource" in \(\$2, \$3\) order by "timestamp_ms" desc limit \$4`).
+		WithArgs(ActionClusterEvent, "nuke", "stopSessions", MaxQueryLimit).
+		WillReturnRows(sqlmock.NewRows([]string{"timestamp_ms", "request_id", "caller", "remote_addr", "action", "method", "path", "payload_sha256", "status", "outcome", "resource", "playback_id", "error"}))
+	_, err = auditLog.Query(context.Background(), Filter{Action: ActionClusterEvent, Resources: []string{"nuke", "stopSessions"}})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateTable(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec(`create table if not exists "api_audit_log"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	require.NoError(t, CreateTable(context.Background(), db))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/livepeer/catalyst-api/cluster"
+	"github.com/livepeer/catalyst-api/featureflags"
 	"github.com/livepeer/catalyst-api/log"
 	"github.com/livepeer/catalyst-api/metrics"
 )
@@ -105,7 +106,7 @@ func (c CombinedBalancer) GetBestNode(ctx context.Context, redirectPrefixes []st
 		}
 	}
 
-	if c.CatabalancerPlaybackEnabled {
+	if c.CatabalancerPlaybackEnabled || featureflags.Enabled(featureflags.CatabalancerPlayback, playbackID) {
 		start := time.Now()
 		node, fullPlaybackID, err := c.Catabalancer.GetBestNode(ctx, redirectPrefixes, playbackID, lat, lon, fallbackPrefix, isStudioReq, false)
 		metrics.Metrics.CatabalancerRequestDurationSec.
@@ -139,7 +140,7 @@ func (c CombinedBalancer) GetBestNode(ctx context.Context, redirectPrefixes []st
 
 func (c CombinedBalancer) MistUtilLoadSource(ctx context.Context, stream, lat, lon string) (string, error) {
 	start := time.Now()
-	if c.CatabalancerIngestEnabled {
+	if c.CatabalancerIngestEnabled || featureflags.Enabled(featureflags.CatabalancerIngest, stream) {
 		dtsc, err := c.Catabalancer.MistUtilLoadSource(ctx, stream, lat, lon)
 		metrics.Metrics.CatabalancerRequestDurationSec.
 			WithLabelValues(strconv.FormatBool(err == nil), "ingest", "", "false").
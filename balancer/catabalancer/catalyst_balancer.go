@@ -10,6 +10,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	_ "github.com/lib/pq"
@@ -51,6 +52,7 @@ type Node struct {
 type Stream struct {
 	ID         string
 	PlaybackID string
+	Viewers    int       // the number of viewers of the stream on the node
 	Timestamp  time.Time // the time we received these stream details, old streams can be removed on a timeout
 }
 
@@ -62,6 +64,7 @@ type NodeMetrics struct {
 	LoadAvg                  float64   `json:"l,omitempty"`
 	GeoLatitude              float64   `json:"la,omitempty"`
 	GeoLongitude             float64   `json:"lo,omitempty"`
+	Draining                 bool      `json:"d,omitempty"` // node is shutting down and shouldn't receive new streams
 	Timestamp                time.Time `json:"t,omitempty"` // the time we received these node metrics
 }
 
@@ -101,6 +104,7 @@ type NodeUpdateEvent struct {
 	NodeID      string      `json:"n,omitempty"`
 	NodeMetrics NodeMetrics `json:"nm,omitempty"`
 	Streams     string      `json:"s,omitempty"`
+	Viewers     string      `json:"v,omitempty"`
 }
 
 func (n *NodeUpdateEvent) SetStreams(streamIDs []string, ingestStreamIDs []string) {
@@ -123,6 +127,35 @@ func (n *NodeUpdateEvent) GetIngestStreams() []string {
 	return []string{}
 }
 
+// SetViewers sets the viewer counts of the streams on the node, only the streams with viewers are included
+func (n *NodeUpdateEvent) SetViewers(viewers map[string]int) {
+	var entries []string
+	for streamID, count := range viewers {
+		if count > 0 {
+			entries = append(entries, streamID+":"+strconv.Itoa(count))
+		}
+	}
+	sort.Strings(entries)
+	n.Viewers = strings.Join(entries, "|")
+}
+
+func (n *NodeUpdateEvent) GetViewers() map[string]int {
+	viewers := make(map[string]int)
+	if len(n.Viewers) == 0 {
+		return viewers
+	}
+	for _, entry := range strings.Split(n.Viewers, "|") {
+		streamID, count, ok := strings.Cut(entry, ":")
+		if !ok {
+			continue
+		}
+		if c, err := strconv.Atoi(count); err == nil {
+			viewers[streamID] = c
+		}
+	}
+	return viewers
+}
+
 func NewBalancer(nodeName string, metricTimeout time.Duration, ingestStreamTimeout time.Duration, nodeStatsDB *sql.DB, cacheExpiry time.Duration) *CataBalancer {
 	return &CataBalancer{
 		NodeName:            nodeName,
@@ -190,6 +223,10 @@ func (c *CataBalancer) createScoredNodes(s stats) []ScoredNode {
 			log.LogNoRequestID("catabalancer ignoring node with stale metrics", "nodeName", nodeName, "timestamp", metrics.Timestamp)
 			continue
 		}
+		if metrics.Draining {
+			log.LogNoRequestID("catabalancer ignoring draining node", "nodeName", nodeName)
+			continue
+		}
 		// make a copy of the streams map so that we can release the nodesLock (UpdateStreams will be making changes in the background)
 		streams := make(Streams)
 		for streamID, stream := range s.Streams[nodeName] {
@@ -363,14 +400,15 @@ func (c *CataBalancer) refreshNodes(ctx context.Context) (stats, error) {
 		s.Streams[event.NodeID] = make(Streams)
 		s.IngestStreams[event.NodeID] = make(Streams)
 
+		viewers := event.GetViewers()
 		for _, stream := range event.GetStreams() {
 			playbackID := getPlaybackID(stream)
-			s.Streams[event.NodeID][playbackID] = Stream{ID: stream, PlaybackID: playbackID, Timestamp: time.Now()}
+			s.Streams[event.NodeID][playbackID] = Stream{ID: stream, PlaybackID: playbackID, Viewers: viewers[stream], Timestamp: time.Now()}
 		}
 		for _, stream := range event.GetIngestStreams() {
 			playbackID := getPlaybackID(stream)
-			s.Streams[event.NodeID][playbackID] = Stream{ID: stream, PlaybackID: playbackID, Timestamp: time.Now()}
-			s.IngestStreams[event.NodeID][stream] = Stream{ID: stream, PlaybackID: playbackID, Timestamp: time.Now()}
+			s.Streams[event.NodeID][playbackID] = Stream{ID: stream, PlaybackID: playbackID, Viewers: viewers[stream], Timestamp: time.Now()}
+			s.IngestStreams[event.NodeID][stream] = Stream{ID: stream, PlaybackID: playbackID, Viewers: viewers[stream], Timestamp: time.Now()}
 		}
 	}
 
@@ -404,13 +442,29 @@ func (c *CataBalancer) MistUtilLoadSource(ctx context.Context, streamID, lat, lo
 				return "", fmt.Errorf("catabalancer no node found for ingest stream: %s stale: true", streamID)
 			}
 			dtsc := "dtsc://" + nodeName
-			log.LogNoRequestID("catabalancer MistUtilLoadSource found node", "DTSC", dtsc, "nodeName", nodeName, "stream", streamID)
+			log.LogNoRequestID("catabalancer MistUtilLoadSource found node", "DTSC", dtsc, "nodeName", nodeName, log.KeyStream, streamID)
 			return dtsc, nil
 		}
 	}
 	return "", fmt.Errorf("catabalancer no node found for ingest stream: %s stale: false", streamID)
 }
 
+// StreamViewers returns the number of viewers of a stream on each node that has any, as of the last node updates
+func (c *CataBalancer) StreamViewers(ctx context.Context, playbackID string) (map[string]int, error) {
+	s, err := c.refreshNodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error refreshing nodes: %w", err)
+	}
+
+	viewers := make(map[string]int)
+	for nodeName := range s.NodeMetrics {
+		if stream, ok := s.Streams[nodeName][playbackID]; ok && stream.Viewers > 0 {
+			viewers[nodeName] = stream.Viewers
+		}
+	}
+	return viewers, nil
+}
+
 var StatsUpdateInterval = 5 * time.Second
 var StatsUpdateTimeout = StatsUpdateInterval - 500*time.Millisecond // have the timeout sit within the update interval so we don't miss sending updates
 
@@ -418,11 +472,29 @@ func isStale(timestamp time.Time, stale time.Duration) bool {
 	return time.Since(timestamp) >= stale
 }
 
+var draining atomic.Bool
+
+// metricSender sends this node's metrics, set once StartMetricSending is called
+var metricSender atomic.Pointer[func()]
+
+// SetDraining flags this node as draining in the metrics it sends, so that balancers stop selecting it for new streams.
+// The metrics are sent straight away rather than on the next tick, which could come after the node has shut down.
+func SetDraining(d bool) {
+	draining.Store(d)
+	if send := metricSender.Load(); send != nil {
+		(*send)()
+	}
+}
+
 func StartMetricSending(nodeName string, latitude float64, longitude float64, mist clients.MistAPIClient, nodeStatsDB *sql.DB) {
+	send := func() {
+		sendMetrics(nodeName, latitude, longitude, mist, nodeStatsDB)
+	}
+	metricSender.Store(&send)
 	ticker := time.NewTicker(StatsUpdateInterval)
 	go func() {
 		for range ticker.C {
-			sendMetrics(nodeName, latitude, longitude, mist, nodeStatsDB)
+			send()
 		}
 	}()
 }
@@ -445,6 +517,7 @@ func sendMetrics(nodeName string, latitude float64, longitude float64, mist clie
 			LoadAvg:                  sysusage.LoadAvg.Load5Min,
 			GeoLatitude:              latitude,
 			GeoLongitude:             longitude,
+			Draining:                 draining.Load(),
 			Timestamp:                time.Now(),
 		},
 	}
@@ -457,14 +530,19 @@ func sendMetrics(nodeName string, latitude float64, longitude float64, mist clie
 		}
 
 		var nonIngestStreams, ingestStreams []string
+		viewers := make(map[string]int)
 		for streamID := range mistState.ActiveStreams {
 			if mistState.IsIngestStream(streamID) {
 				ingestStreams = append(ingestStreams, streamID)
 			} else {
 				nonIngestStreams = append(nonIngestStreams, streamID)
 			}
+			if stats := mistState.StreamsStats[streamID]; stats != nil {
+				viewers[streamID] = stats.Clients
+			}
 		}
 		event.SetStreams(nonIngestStreams, ingestStreams)
+		event.SetViewers(viewers)
 	}
 
 	payload, err := json.Marshal(event)
@@ -2,6 +2,7 @@ package catabalancer
 
 import (
 	"context"
+	"database/sql/driver"
 	"encoding/json"
 	"fmt"
 	"testing"
@@ -90,6 +91,42 @@ func TestStaleNodes(t *testing.T) {
 	require.Equal(t, "video+playbackID", prefix)
 }
 
+func TestDrainingNodes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	c := NewBalancer("me", 5*time.Second, time.Second, db, 0)
+
+	setNodeMetrics(t, mock, []NodeUpdateEvent{{NodeID: "node1", NodeMetrics: NodeMetrics{Timestamp: time.Now(), Draining: true}}})
+	nodeName, _, err := c.GetBestNode(context.Background(), nil, "playbackID", "", "", "", false, false)
+	require.NoError(t, err)
+	require.Equal(t, "me", nodeName) // we expect the draining node1 to be ignored
+}
+
+type drainingPayload struct{}
+
+func (drainingPayload) Match(v driver.Value) bool {
+	var event NodeUpdateEvent
+	b, ok := v.([]byte)
+	return ok && json.Unmarshal(b, &event) == nil && event.NodeMetrics.Draining
+}
+
+func TestSetDrainingSendsMetrics(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	mock.ExpectExec("insert into \"node_stats\"").WithArgs("me", drainingPayload{}).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	send := func() { sendMetrics("me", 0, 0, nil, db) }
+	metricSender.Store(&send)
+	t.Cleanup(func() {
+		metricSender.Store(nil)
+		draining.Store(false)
+	})
+
+	// The metrics are sent before SetDraining returns, without waiting for the next tick
+	SetDraining(true)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestItReturnsBadNodeIfOnlyAvailable(t *testing.T) {
 	selectionNodes := []ScoredNode{
 		CPUOverloadedNode,
@@ -497,3 +534,27 @@ func TestItCanMarshalAndUnMarshalStreamIDsWithNoNonIngestStreams(t *testing.T) {
 	require.Equal(t, []string{}, n2.GetStreams())
 	require.Equal(t, []string{"ingest1", "ingest2"}, n2.GetIngestStreams())
 }
+
+func TestStreamViewers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	c := NewBalancer("", time.Second, time.Second, db, time.Second)
+
+	ingestNode := NodeUpdateEvent{NodeID: "ingest-node", NodeMetrics: NodeMetrics{Timestamp: time.Now()}}
+	ingestNode.SetStreams(nil, []string{"video+abc"})
+	ingestNode.SetViewers(map[string]int{"video+abc": 3})
+	playbackNode := NodeUpdateEvent{NodeID: "playback-node", NodeMetrics: NodeMetrics{Timestamp: time.Now()}}
+	playbackNode.SetStreams([]string{"video+abc", "video+def"}, nil)
+	playbackNode.SetViewers(map[string]int{"video+abc": 5, "video+def": 0})
+	require.Equal(t, "video+abc:5", playbackNode.Viewers)
+	idleNode := NodeUpdateEvent{NodeID: "idle-node", NodeMetrics: NodeMetrics{Timestamp: time.Now()}}
+	setNodeMetrics(t, mock, []NodeUpdateEvent{ingestNode, playbackNode, idleNode})
+
+	viewers, err := c.StreamViewers(context.Background(), "abc")
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"ingest-node": 3, "playback-node": 5}, viewers)
+
+	viewers, err = c.StreamViewers(context.Background(), "def")
+	require.NoError(t, err)
+	require.Empty(t, viewers)
+}
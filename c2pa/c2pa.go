@@ -3,33 +3,45 @@ package c2pa
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
 )
 
-const c2paManifestTemplate = `
-{
-  "alg": "%s",
-  "private_key": "%s",
-  "sign_cert": "%s",
-  "ta_url": "http://timestamp.digicert.com",
-
-  "claim_generator": "LivepeerStudio",
-  "title": "%s",
-  "assertions": [
-    {
-      "label": "c2pa.actions",
-      "data": {
-        "actions": [
-          {
-            "action": "c2pa.published"
-          }
-        ]
-      }
-    }
-  ]
+// ProvenanceAssertionLabel is the label of the assertion recording the source an output was transcoded from
+const ProvenanceAssertionLabel = "org.livepeer.provenance"
+
+// Provenance describes how an output was produced, recorded as assertions in its C2PA manifest
+type Provenance struct {
+	// Hex encoded SHA-256 of the source file
+	SourceHash string
+	// Parameters the output was transcoded with, e.g. the rendition profile
+	Parameters interface{}
+}
+
+type manifest struct {
+	Alg            string      `json:"alg"`
+	PrivateKey     string      `json:"private_key"`
+	SignCert       string      `json:"sign_cert"`
+	TAURL          string      `json:"ta_url"`
+	ClaimGenerator string      `json:"claim_generator"`
+	Title          string      `json:"title"`
+	Assertions     []assertion `json:"assertions"`
+}
+
+type assertion struct {
+	Label string      `json:"label"`
+	Data  interface{} `json:"data"`
+}
+
+type action struct {
+	Action     string      `json:"action"`
+	Parameters interface{} `json:"parameters,omitempty"`
 }
-`
 
 type C2PA struct {
 	alg            string
@@ -45,15 +57,45 @@ func NewC2PA(alg, privateKeyPath, signCertPath string) C2PA {
 	}
 }
 
-func (c C2PA) c2paManifest(title string) string {
-	return fmt.Sprintf(c2paManifestTemplate, c.alg, c.privateKeyPath, c.signCertPath, title)
+func (c C2PA) c2paManifest(title string, provenance Provenance) (string, error) {
+	actions := []action{{Action: "c2pa.published"}}
+	if provenance.Parameters != nil {
+		actions = append(actions, action{Action: "c2pa.transcoded", Parameters: provenance.Parameters})
+	}
+	assertions := []assertion{{Label: "c2pa.actions", Data: map[string]interface{}{"actions": actions}}}
+	if provenance.SourceHash != "" {
+		assertions = append(assertions, assertion{
+			Label: ProvenanceAssertionLabel,
+			Data:  map[string]string{"source_hash_alg": "sha256", "source_hash": provenance.SourceHash},
+		})
+	}
+
+	m, err := json.Marshal(manifest{
+		Alg:            c.alg,
+		PrivateKey:     c.privateKeyPath,
+		SignCert:       c.signCertPath,
+		TAURL:          "http://timestamp.digicert.com",
+		ClaimGenerator: "LivepeerStudio",
+		Title:          title,
+		Assertions:     assertions,
+	})
+	return string(m), err
 }
 
 func (c C2PA) SignFile(inFile, outFile, title, parent string) error {
+	return c.SignFileWithProvenance(inFile, outFile, title, parent, Provenance{})
+}
+
+// SignFileWithProvenance signs the file, additionally recording the source it was produced from and how
+func (c C2PA) SignFileWithProvenance(inFile, outFile, title, parent string, provenance Provenance) error {
+	m, err := c.c2paManifest(title, provenance)
+	if err != nil {
+		return fmt.Errorf("failed creating C2PA Manifest: %w", err)
+	}
 	args := []string{
 		inFile,
 		"--config",
-		c.c2paManifest(title),
+		m,
 		"--force",
 		"--output",
 		outFile,
@@ -61,10 +103,24 @@ func (c C2PA) SignFile(inFile, outFile, title, parent string) error {
 	if parent != "" {
 		args = append(args, "--parent", parent)
 	}
-	_, err := runCmd(exec.CommandContext(context.TODO(), "c2patool", args...))
+	_, err = runCmd(exec.CommandContext(context.TODO(), "c2patool", args...))
 	return err
 }
 
+// HashFile returns the hex encoded SHA-256 of the file, for use as Provenance.SourceHash
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
 func runCmd(cmd *exec.Cmd) (string, error) {
 	var stdOut bytes.Buffer
 	var stdErr bytes.Buffer
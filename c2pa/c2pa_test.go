@@ -72,3 +72,24 @@ func TestSign_NotExistingSigningCert(t *testing.T) {
 	err = c.SignFile("test/tiny.mp4", "test/tiny_signed.mp4", "Tiny", "")
 	require.ErrorContains(t, err, "No such file or directory")
 }
+
+func TestManifestProvenance(t *testing.T) {
+	c := NewC2PA("es256", "test/es256_private.key", "test/es256_certs.pem")
+
+	m, err := c.c2paManifest("Tiny", Provenance{})
+	require.NoError(t, err)
+	assert.Contains(t, m, `"action":"c2pa.published"`)
+	assert.NotContains(t, m, ProvenanceAssertionLabel)
+
+	sourceHash, err := HashFile("test/tiny.mp4")
+	require.NoError(t, err)
+	require.Len(t, sourceHash, 64)
+
+	m, err = c.c2paManifest("Tiny", Provenance{
+		SourceHash: sourceHash,
+		Parameters: map[string]interface{}{"name": "360p0", "bitrate": 1000000},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, m, `"action":"c2pa.transcoded","parameters":{"bitrate":1000000,"name":"360p0"}`)
+	assert.Contains(t, m, `"label":"org.livepeer.provenance","data":{"source_hash":"`+sourceHash+`","source_hash_alg":"sha256"}`)
+}
@@ -1,12 +1,14 @@
 package clients
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"path"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
@@ -26,7 +28,11 @@ func NewDStorageDownload() *DStorageDownload {
 	return &DStorageDownload{}
 }
 
-func (d *DStorageDownload) DownloadDStorageFromGatewayList(u, requestID string) (io.ReadCloser, error) {
+// DStorageStallTimeout is how long a gateway can go without sending any data before its download is aborted, so that
+// the copy is retried from the next gateway rather than hanging on a stalled one
+var DStorageStallTimeout = time.Minute
+
+func (d *DStorageDownload) DownloadDStorageFromGatewayList(ctx context.Context, u, requestID string) (io.ReadCloser, error) {
 	var err error
 	var gateways []*url.URL
 	dStorageURL, err := url.Parse(u)
@@ -69,7 +75,7 @@ func (d *DStorageDownload) DownloadDStorageFromGatewayList(u, requestID string)
 	for i := d.gatewaysListPosition; i < until; i++ {
 		d.gatewaysListPosition = i % length
 		gateway := gateways[d.gatewaysListPosition]
-		opContent, err := downloadDStorageResourceFromSingleGateway(gateway, resourceID, requestID)
+		opContent, err := downloadDStorageResourceFromSingleGateway(ctx, gateway, resourceID, requestID)
 		if err == nil {
 			return opContent, nil
 		}
@@ -79,27 +85,106 @@ func (d *DStorageDownload) DownloadDStorageFromGatewayList(u, requestID string)
 	return nil, fmt.Errorf("failed to fetch %s from any of the gateways: %w", u, lastErr)
 }
 
-func downloadDStorageResourceFromSingleGateway(gateway *url.URL, resourceId, requestID string) (io.ReadCloser, error) {
+func downloadDStorageResourceFromSingleGateway(ctx context.Context, gateway *url.URL, resourceId, requestID string) (io.ReadCloser, error) {
 	fullURL := gateway.JoinPath(resourceId).String()
 	log.Log(requestID, "downloading from gateway", "resourceID", resourceId, "url", fullURL)
-	resp, err := http.DefaultClient.Get(fullURL)
-
+	body, err := newResumableReader(ctx, openGatewayRange(requestID, fullURL), nil)
 	if err != nil {
 		log.LogError(requestID, "failed to fetch content from gateway", err, "url", fullURL)
 		return nil, err
 	}
+	return body, nil
+}
 
-	if resp.StatusCode == 404 {
-		resp.Body.Close()
-		log.Log(requestID, "dstorage gateway not found", "status_code", resp.StatusCode, "url", fullURL)
-		return nil, catErrs.NewObjectNotFoundError("not found in dstorage", nil)
-	} else if resp.StatusCode >= 300 {
-		resp.Body.Close()
-		log.Log(requestID, "unexpected response from gateway", "status_code", resp.StatusCode, "url", fullURL)
-		return nil, fmt.Errorf("unexpected response from gateway: %d", resp.StatusCode)
+// openGatewayRange opens a resource from a gateway from an offset, resuming a download that failed from the same
+// gateway when it serves byte ranges. Each request is aborted when the gateway stalls.
+func openGatewayRange(requestID, fullURL string) rangeOpener {
+	return func(ctx context.Context, offset int64, info downloadInfo) (io.ReadCloser, downloadInfo, error) {
+		ctx, cancel := context.WithCancel(ctx)
+		body := newStallReader(cancel, DStorageStallTimeout)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+		if err != nil {
+			body.Close()
+			return nil, downloadInfo{}, err
+		}
+		setRangeHeaders(req, offset, info)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			body.Close()
+			return nil, downloadInfo{}, body.wrap(err)
+		}
+
+		if offset > 0 {
+			if resp.StatusCode != http.StatusPartialContent {
+				resp.Body.Close()
+				body.Close()
+				return nil, downloadInfo{}, fmt.Errorf("gateway download can't be resumed: status code %d", resp.StatusCode)
+			}
+			body.body = resp.Body
+			return body, downloadInfo{etag: resp.Header.Get("ETag")}, nil
+		}
+
+		if resp.StatusCode == 404 {
+			resp.Body.Close()
+			body.Close()
+			log.Log(requestID, "dstorage gateway not found", "status_code", resp.StatusCode, "url", fullURL)
+			return nil, downloadInfo{}, catErrs.NewObjectNotFoundError("not found in dstorage", nil)
+		} else if resp.StatusCode >= 300 {
+			resp.Body.Close()
+			body.Close()
+			log.Log(requestID, "unexpected response from gateway", "status_code", resp.StatusCode, "url", fullURL)
+			return nil, downloadInfo{}, fmt.Errorf("unexpected response from gateway: %d", resp.StatusCode)
+		}
+
+		body.body = resp.Body
+		return body, httpDownloadInfo(resp), nil
 	}
+}
 
-	return resp.Body, nil
+// stallReader aborts a gateway's download when it goes without sending data for the timeout, which a stalled gateway
+// can otherwise do for as long as the connection stays open
+type stallReader struct {
+	body    io.ReadCloser
+	timeout time.Duration
+	timer   *time.Timer
+	cancel  context.CancelFunc
+	stalled atomic.Bool
+}
+
+func newStallReader(cancel context.CancelFunc, timeout time.Duration) *stallReader {
+	r := &stallReader{timeout: timeout, cancel: cancel}
+	r.timer = time.AfterFunc(timeout, func() {
+		r.stalled.Store(true)
+		cancel()
+	})
+	return r
+}
+
+func (r *stallReader) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	if n > 0 {
+		r.timer.Reset(r.timeout)
+	}
+	if err != nil && err != io.EOF {
+		err = r.wrap(err)
+	}
+	return n, err
+}
+
+func (r *stallReader) wrap(err error) error {
+	if r.stalled.Load() {
+		return fmt.Errorf("gateway stalled, no data received for %s: %w", r.timeout, err)
+	}
+	return err
+}
+
+func (r *stallReader) Close() error {
+	r.timer.Stop()
+	r.cancel()
+	if r.body == nil {
+		return nil
+	}
+	return r.body.Close()
 }
 
 func IsDStorageResource(dStorage string) bool {
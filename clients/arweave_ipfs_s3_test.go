@@ -1,6 +1,8 @@
 package clients
 
 import (
+	"context"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -8,6 +10,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/cenkalti/backoff/v4"
 	"github.com/livepeer/catalyst-api/config"
@@ -170,7 +173,7 @@ func TestDownloadDStorageFromGatewayListLooping(t *testing.T) {
 	var runTest = func(s int, errExpected bool, expectedCalls []int) {
 		successfulGateway = s
 		gatewayCalls = []int{}
-		_, err := dStorage.DownloadDStorageFromGatewayList("ipfs://Qme7ss3ARVgxv6rXqVPiikMJ8u2NLgmgszg13pYrDKEoiu", "reqID")
+		_, err := dStorage.DownloadDStorageFromGatewayList(context.Background(), "ipfs://Qme7ss3ARVgxv6rXqVPiikMJ8u2NLgmgszg13pYrDKEoiu", "reqID")
 		if errExpected {
 			require.Error(t, err)
 		} else {
@@ -187,6 +190,41 @@ func TestDownloadDStorageFromGatewayListLooping(t *testing.T) {
 	runTest(gatewayCount, true, []int{1, 2, 3, 0})
 }
 
+func TestDownloadDStorageFromGatewayListMovesOnFromAStalledGateway(t *testing.T) {
+	defer func(timeout time.Duration) { DStorageStallTimeout = timeout }(DStorageStallTimeout)
+	DStorageStallTimeout = 100 * time.Millisecond
+
+	stalled := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer stalled.Close()
+	working := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("complete"))
+	}))
+	defer working.Close()
+	stalledURL, _ := url.Parse(stalled.URL)
+	workingURL, _ := url.Parse(working.URL)
+	defer func(gateways []*url.URL) { config.ImportIPFSGatewayURLs = gateways }(config.ImportIPFSGatewayURLs)
+	config.ImportIPFSGatewayURLs = []*url.URL{stalledURL, workingURL}
+
+	// the download is retried by the copy with the same DStorageDownload, which starts from the next gateway
+	dStorage := NewDStorageDownload()
+	content, err := dStorage.DownloadDStorageFromGatewayList(context.Background(), "ipfs://Qme7ss3ARVgxv6rXqVPiikMJ8u2NLgmgszg13pYrDKEoiu", "reqID")
+	require.NoError(t, err)
+	_, err = io.ReadAll(content)
+	require.ErrorContains(t, err, "gateway stalled")
+	content.Close()
+
+	content, err = dStorage.DownloadDStorageFromGatewayList(context.Background(), "ipfs://Qme7ss3ARVgxv6rXqVPiikMJ8u2NLgmgszg13pYrDKEoiu", "reqID")
+	require.NoError(t, err)
+	body, err := io.ReadAll(content)
+	require.NoError(t, err)
+	require.Equal(t, "complete", string(body))
+	content.Close()
+}
+
 func TestItExtractsGatewayDStorageType(t *testing.T) {
 	u, err := url.Parse("https://cloudflare-ipfs.com/ipfs/12345/file.json?queryString=value")
 	require.NoError(t, err)
@@ -291,7 +329,7 @@ func Test_IPFSResourceIDParsing(t *testing.T) {
 
 func copyDStorageToS3(url, s3URL string, requestID string) error {
 	return backoff.Retry(func() error {
-		content, err := NewDStorageDownload().DownloadDStorageFromGatewayList(url, requestID)
+		content, err := NewDStorageDownload().DownloadDStorageFromGatewayList(context.Background(), url, requestID)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,186 @@
+package clients
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The bandwidth caps of the storage transfers in megabits per second, 0 for no cap, set with -upload-bandwidth,
+// -download-bandwidth, -job-upload-bandwidth and -job-download-bandwidth. The node caps are shared by every transfer,
+// the job caps by the transfers of each VOD job, so that bulk reprocessing leaves room for the live traffic.
+var (
+	UploadBandwidthMbps      float64
+	DownloadBandwidthMbps    float64
+	JobUploadBandwidthMbps   float64
+	JobDownloadBandwidthMbps float64
+)
+
+// bandwidthChunk is the most bytes a throttled transfer reads at once, so that it waits little and often rather than
+// bursting
+const bandwidthChunk = 64 * 1024
+
+// bandwidthLimiter paces the bytes taken by its transfers to a rate. Idle time isn't banked, so it never bursts.
+type bandwidthLimiter struct {
+	bytesPerSec float64
+
+	mu sync.Mutex
+	// next is when the bytes taken so far will have been transferred at the rate
+	next time.Time
+}
+
+// newBandwidthLimiter returns a limiter of a rate in megabits per second, nil for no limit
+func newBandwidthLimiter(mbps float64) *bandwidthLimiter {
+	if mbps <= 0 {
+		return nil
+	}
+	return &bandwidthLimiter{bytesPerSec: mbps * 1e6 / 8}
+}
+
+// take accounts for n bytes transferred and waits until they're within the rate, or the context is done
+func (l *bandwidthLimiter) take(ctx context.Context, n int) error {
+	if n <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	l.next = l.next.Add(time.Duration(float64(n) / l.bytesPerSec * float64(time.Second)))
+	wait := l.next.Sub(now)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+var (
+	nodeBandwidthOnce                      sync.Once
+	nodeUploadLimiter, nodeDownloadLimiter *bandwidthLimiter
+)
+
+// jobBandwidth is the limiters of a job's transfers, under the URL prefixes of its source and outputs
+type jobBandwidth struct {
+	prefixes         []string
+	upload, download *bandwidthLimiter
+}
+
+var (
+	jobBandwidthsMu sync.Mutex
+	jobBandwidths   = map[*jobBandwidth]struct{}{}
+)
+
+// LimitJobBandwidth caps the transfers under the URLs of a job with the job caps, until the returned func is called
+func LimitJobBandwidth(urls ...*url.URL) (release func()) {
+	jb := &jobBandwidth{
+		upload:   newBandwidthLimiter(JobUploadBandwidthMbps),
+		download: newBandwidthLimiter(JobDownloadBandwidthMbps),
+	}
+	for _, u := range urls {
+		if u != nil {
+			jb.prefixes = append(jb.prefixes, u.String())
+		}
+	}
+	if (jb.upload == nil && jb.download == nil) || len(jb.prefixes) == 0 {
+		return func() {}
+	}
+
+	jobBandwidthsMu.Lock()
+	defer jobBandwidthsMu.Unlock()
+	jobBandwidths[jb] = struct{}{}
+	return func() {
+		jobBandwidthsMu.Lock()
+		defer jobBandwidthsMu.Unlock()
+		delete(jobBandwidths, jb)
+	}
+}
+
+// transferBandwidth is the limiters a transfer is paced by
+type transferBandwidth []*bandwidthLimiter
+
+// bandwidthFor returns the limiters of a transfer to or from an OS or HTTP URL: the node's and those of the jobs the
+// URL belongs to
+func bandwidthFor(fileURL, operation string) transferBandwidth {
+	nodeBandwidthOnce.Do(func() {
+		nodeUploadLimiter = newBandwidthLimiter(UploadBandwidthMbps)
+		nodeDownloadLimiter = newBandwidthLimiter(DownloadBandwidthMbps)
+	})
+	var bw transferBandwidth
+	add := func(upload, download *bandwidthLimiter) {
+		l := download
+		if operation == osOperationWrite {
+			l = upload
+		}
+		if l != nil {
+			bw = append(bw, l)
+		}
+	}
+	add(nodeUploadLimiter, nodeDownloadLimiter)
+
+	jobBandwidthsMu.Lock()
+	defer jobBandwidthsMu.Unlock()
+	for jb := range jobBandwidths {
+		for _, prefix := range jb.prefixes {
+			if strings.HasPrefix(fileURL, prefix) {
+				add(jb.upload, jb.download)
+				break
+			}
+		}
+	}
+	return bw
+}
+
+// wait takes n bytes from every limiter, for the transfers whose bodies can't be wrapped, e.g. as they're re-read on
+// retries
+func (bw transferBandwidth) wait(ctx context.Context, n int64) error {
+	for n > 0 {
+		chunk := min(n, bandwidthChunk)
+		for _, l := range bw {
+			if err := l.take(ctx, int(chunk)); err != nil {
+				return err
+			}
+		}
+		n -= chunk
+	}
+	return nil
+}
+
+// reader paces the reads of a transfer's body, which is returned as it is when there are no limiters
+func (bw transferBandwidth) reader(ctx context.Context, r io.Reader) io.Reader {
+	if len(bw) == 0 {
+		return r
+	}
+	return throttledReader{ctx: ctx, r: r, bw: bw}
+}
+
+type throttledReader struct {
+	ctx context.Context
+	r   io.Reader
+	bw  transferBandwidth
+}
+
+func (t throttledReader) Read(p []byte) (int, error) {
+	if len(p) > bandwidthChunk {
+		p = p[:bandwidthChunk]
+	}
+	n, err := t.r.Read(p)
+	if waitErr := t.bw.wait(t.ctx, int64(n)); waitErr != nil {
+		return n, waitErr
+	}
+	return n, err
+}
+
+type throttledReadCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,68 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBandwidthLimiterPacesReads(t *testing.T) {
+	// 8Mbps is 1MB per second
+	bw := transferBandwidth{newBandwidthLimiter(8)}
+	data := make([]byte, 300*1000)
+
+	start := time.Now()
+	read, err := io.ReadAll(bw.reader(context.Background(), bytes.NewReader(data)))
+	require.NoError(t, err)
+	require.Equal(t, data, read)
+	elapsed := time.Since(start)
+	require.GreaterOrEqual(t, elapsed, 280*time.Millisecond)
+	require.Less(t, elapsed, 2*time.Second)
+}
+
+func TestBandwidthLimiterStopsWithContext(t *testing.T) {
+	bw := transferBandwidth{newBandwidthLimiter(0.008)}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := io.ReadAll(bw.reader(ctx, bytes.NewReader(make([]byte, 1000))))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestNoBandwidthLimit(t *testing.T) {
+	require.Nil(t, newBandwidthLimiter(0))
+	r := bytes.NewReader(nil)
+	require.Equal(t, r, transferBandwidth(nil).reader(context.Background(), r))
+}
+
+func TestJobBandwidthIsSharedByItsTransfers(t *testing.T) {
+	JobUploadBandwidthMbps = 8
+	defer func() { JobUploadBandwidthMbps = 0 }()
+
+	dir := t.TempDir()
+	output, _ := url.Parse(filepath.Join(dir, "job"))
+	release := LimitJobBandwidth(output)
+
+	bw := bandwidthFor(output.JoinPath("720p", "0.ts").String(), osOperationWrite)
+	require.Len(t, bw, 1)
+	require.Same(t, bw[0], bandwidthFor(output.JoinPath("index.m3u8").String(), osOperationWrite)[0])
+	require.Empty(t, bandwidthFor(output.JoinPath("0.ts").String(), osOperationRead), "only the uploads of the job should be capped")
+	require.Empty(t, bandwidthFor(filepath.Join(dir, "other", "0.ts"), osOperationWrite))
+
+	start := time.Now()
+	require.NoError(t, UploadToOSURL(output.String(), "0.ts", bytes.NewReader(make([]byte, 200*1000)), time.Minute))
+	require.GreaterOrEqual(t, time.Since(start), 180*time.Millisecond)
+	written, err := os.ReadFile(filepath.Join(dir, "job", "0.ts"))
+	require.NoError(t, err)
+	require.Len(t, written, 200*1000)
+
+	release()
+	require.Empty(t, bandwidthFor(output.JoinPath("0.ts").String(), osOperationWrite))
+}
@@ -22,12 +22,34 @@ type TranscodeResult struct {
 	Renditions []*RenditionSegment
 }
 
+// Close removes the files of the rendition segments that were spilled to disk
+func (t TranscodeResult) Close() {
+	for _, rendition := range t.Renditions {
+		_ = rendition.Media.Close()
+	}
+}
+
 type RenditionSegment struct {
-	Name      string
+	Name string
+	// Media is the segment as read from the broadcaster, MediaData the segment when it's given in memory
+	Media     *SpillBuffer
 	MediaData []byte
 	MediaUrl  *string
 }
 
+// Segment returns the media of the rendition segment, nil when it only has a URL
+func (r *RenditionSegment) Segment() *SpillBuffer {
+	if r.Media != nil {
+		return r.Media
+	}
+	if r.MediaData == nil {
+		return nil
+	}
+	b := &SpillBuffer{}
+	_, _ = b.Write(r.MediaData)
+	return b
+}
+
 type createStreamPayload struct {
 	Name     string                 `json:"name,omitempty"`
 	Profiles []video.EncodedProfile `json:"profiles"`
@@ -110,17 +132,20 @@ func transcodeSegment(inputSegment io.Reader, sequenceNumber, mediaDurationMilli
 			break
 		}
 		if err != nil {
-			return t, fmt.Errorf("multipart.NextPart() error: %v", err)
+			t.Close()
+			return TranscodeResult{}, fmt.Errorf("multipart.NextPart() error: %v", err)
 		}
 		mediaType, _, err = mime.ParseMediaType(part.Header.Get("Content-Type"))
 		if err != nil {
-			return t, fmt.Errorf("multipart mime.ParseMediaType() error: %v; headers=%v", err, part.Header)
-		}
-		body, err := io.ReadAll(part)
-		if err != nil {
-			return t, fmt.Errorf("multipart io.ReadAll(part) error: %v; headers=%v", err, part.Header)
+			t.Close()
+			return TranscodeResult{}, fmt.Errorf("multipart mime.ParseMediaType() error: %v; headers=%v", err, part.Header)
 		}
 		if mediaType == "application/vnd+livepeer.uri" {
+			body, err := io.ReadAll(part)
+			if err != nil {
+				t.Close()
+				return TranscodeResult{}, fmt.Errorf("multipart io.ReadAll(part) error: %v; headers=%v", err, part.Header)
+			}
 			renditionUrl := string(body)
 			rendition := RenditionSegment{
 				Name:     part.Header.Get("Rendition-Name"),
@@ -128,9 +153,16 @@ func transcodeSegment(inputSegment io.Reader, sequenceNumber, mediaDurationMilli
 			}
 			t.Renditions = append(t.Renditions, &rendition)
 		} else {
+			// the segments are streamed to disk past the spill threshold rather than read in memory
+			media := NewSpillBuffer()
+			if _, err := io.Copy(media, part); err != nil {
+				media.Close()
+				t.Close()
+				return TranscodeResult{}, fmt.Errorf("multipart io.Copy(part) error: %v; headers=%v", err, part.Header)
+			}
 			rendition := RenditionSegment{
-				Name:      part.Header.Get("Rendition-Name"),
-				MediaData: body,
+				Name:  part.Header.Get("Rendition-Name"),
+				Media: media,
 			}
 			t.Renditions = append(t.Renditions, &rendition)
 		}
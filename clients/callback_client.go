@@ -7,12 +7,14 @@ import (
 	"net/http"
 	"runtime/debug"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/hashicorp/go-retryablehttp"
 	"github.com/livepeer/catalyst-api/config"
 	"github.com/livepeer/catalyst-api/log"
 	"github.com/livepeer/catalyst-api/metrics"
+	"github.com/livepeer/catalyst-api/requests"
 )
 
 const MAX_TIME_WITHOUT_UPDATE = 30 * time.Minute
@@ -33,22 +35,31 @@ type PeriodicCallbackClient struct {
 	httpClient               *http.Client
 	callbackInterval         time.Duration
 	headers                  map[string]string
+	// lastLoop is when the callback loop last completed, in Unix nanoseconds
+	lastLoop atomic.Int64
 }
 
 func NewPeriodicCallbackClient(callbackInterval time.Duration, headers map[string]string) *PeriodicCallbackClient {
+	policy := config.DefaultCallbackPolicy
+	policy.Interval = callbackInterval
+	return NewPeriodicCallbackClientWithPolicy(policy, headers)
+}
+
+// NewPeriodicCallbackClientWithPolicy creates a callback client that sends and retries the callbacks as configured
+func NewPeriodicCallbackClientWithPolicy(policy config.CallbackPolicy, headers map[string]string) *PeriodicCallbackClient {
 	client := retryablehttp.NewClient()
-	client.RetryMax = 2                          // Retry a maximum of this+1 times
-	client.RetryWaitMin = 200 * time.Millisecond // Wait at least this long between retries
-	client.RetryWaitMax = 1 * time.Second        // Wait at most this long between retries (exponential backoff)
+	client.RetryMax = policy.RetryMax         // Retry a maximum of this+1 times
+	client.RetryWaitMin = policy.RetryWaitMin // Wait at least this long between retries
+	client.RetryWaitMax = policy.RetryWaitMax // Wait at most this long between retries (exponential backoff)
 	client.CheckRetry = metrics.HttpRetryHook
 	client.HTTPClient = &http.Client{
-		Timeout: 5 * time.Second, // Give up on requests that take more than this long
+		Timeout: policy.Timeout, // Give up on requests that take more than this long
 	}
 	client.Logger = log.NewRetryableHTTPLogger()
 
 	return &PeriodicCallbackClient{
 		httpClient:               client.StandardClient(),
-		callbackInterval:         callbackInterval,
+		callbackInterval:         policy.Interval,
 		requestIDToLatestMessage: map[string]TranscodeStatusMessage{},
 		mapLock:                  sync.RWMutex{},
 		headers:                  headers,
@@ -58,17 +69,35 @@ func NewPeriodicCallbackClient(callbackInterval time.Duration, headers map[strin
 // Start looping through all active jobs, sending a callback for the latest status of each
 // and then pausing for a set amount of time
 func (pcc *PeriodicCallbackClient) Start() *PeriodicCallbackClient {
+	pcc.lastLoop.Store(time.Now().UnixNano())
 	go func() {
 		for {
 			recoverer(func() {
 				time.Sleep(pcc.callbackInterval)
 				pcc.SendCallbacks()
+				pcc.lastLoop.Store(time.Now().UnixNano())
 			})
 		}
 	}()
 	return pcc
 }
 
+// ActiveJobs returns the number of jobs that periodic callbacks are being sent for
+func (pcc *PeriodicCallbackClient) ActiveJobs() int {
+	pcc.mapLock.RLock()
+	defer pcc.mapLock.RUnlock()
+	return len(pcc.requestIDToLatestMessage)
+}
+
+// CheckLoop returns an error if the callback loop hasn't completed for a few intervals, e.g. if it's stuck
+func (pcc *PeriodicCallbackClient) CheckLoop() error {
+	since := time.Since(time.Unix(0, pcc.lastLoop.Load()))
+	if since > 3*pcc.callbackInterval {
+		return fmt.Errorf("callback loop last completed %s ago", since.Round(time.Second))
+	}
+	return nil
+}
+
 func recoverer(f func()) {
 	defer func() {
 		if err := recover(); err != nil {
@@ -109,7 +138,7 @@ func (pcc *PeriodicCallbackClient) updateTranscodeStatus(tsm TranscodeStatusMess
 	}
 
 	log.Log(tsm.RequestID, "Updated transcode status",
-		"timestamp", tsm.Timestamp, "status", tsm.Status, "completion_ratio", tsm.CompletionRatio,
+		"timestamp", tsm.Timestamp, log.KeyStage, tsm.Status, "completion_ratio", tsm.CompletionRatio,
 		"error", tsm.Error)
 
 	// Error is a terminal state so remove the job from the list after sending the callback
@@ -160,6 +189,7 @@ func (pcc *PeriodicCallbackClient) sendCallback(tsm TranscodeStatusMessage) erro
 		log.LogError(tsm.RequestID, "failed to create callback HTTP request", err)
 		return err
 	}
+	r.Header.Set(requests.RequestIDHeader, tsm.RequestID)
 
 	err = pcc.doWithRetries(r)
 	if err != nil {
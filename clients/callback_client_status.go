@@ -73,6 +73,8 @@ type TranscodeStatusMessage struct {
 	// Only used for the "Error" status message
 	Error       string `json:"error,omitempty"`
 	Unretriable bool   `json:"unretriable,omitempty"`
+	// ErrorCode identifies failures that the caller can act on, e.g. a source above the tenant's limits
+	ErrorCode string `json:"error_code,omitempty"`
 
 	// Only used for the "Completed" status message
 	Type       string              `json:"type,omitempty"`
@@ -80,6 +82,26 @@ type TranscodeStatusMessage struct {
 	Outputs    []video.OutputVideo `json:"outputs,omitempty"`
 
 	SourcePlayback *video.OutputVideo `json:"source_playback,omitempty"`
+
+	// Progress of thumbnail generation, if thumbnails were requested
+	Thumbnails *ThumbnailsStatus `json:"thumbnails,omitempty"`
+
+	// Files that were written to the backup of their output location, after failing to upload to it
+	BackupOutputs []BackupOutput `json:"backup_outputs,omitempty"`
+
+	// SHA-256 of the files written to the outputs, also listed in a checksum manifest at the root of each output
+	Checksums []OutputChecksum `json:"checksums,omitempty"`
+}
+
+type ThumbnailsStatus struct {
+	Total     int                `json:"total"`
+	Completed int                `json:"completed"`
+	Failed    []ThumbnailFailure `json:"failed,omitempty"`
+}
+
+type ThumbnailFailure struct {
+	Segment string `json:"segment"`
+	Error   string `json:"error"`
 }
 
 // This method will accept the completion ratio of the current stage and will translate that into the overall ratio
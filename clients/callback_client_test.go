@@ -183,3 +183,20 @@ func TestItCalculatesTheOverallCompletionRatioCorrectly(t *testing.T) {
 		})
 	}
 }
+
+func TestCallbackLoopCheck(t *testing.T) {
+	client := NewPeriodicCallbackClient(50*time.Millisecond, map[string]string{})
+	// the loop hasn't been started
+	require.Error(t, client.CheckLoop())
+
+	client.Start()
+	require.NoError(t, client.CheckLoop())
+	time.Sleep(200 * time.Millisecond)
+	require.NoError(t, client.CheckLoop())
+
+	// a stuck loop, e.g. blocked on the status map
+	client.mapLock.Lock()
+	defer client.mapLock.Unlock()
+	time.Sleep(200 * time.Millisecond)
+	require.Error(t, client.CheckLoop())
+}
@@ -2,11 +2,11 @@ package clients
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"os"
 	"path"
 	"strings"
 	"time"
@@ -17,8 +17,8 @@ import (
 	"github.com/livepeer/catalyst-api/crypto"
 	catErrs "github.com/livepeer/catalyst-api/errors"
 	"github.com/livepeer/catalyst-api/log"
+	"github.com/livepeer/catalyst-api/metrics"
 	"github.com/livepeer/catalyst-api/video"
-	"github.com/livepeer/go-tools/drivers"
 )
 
 const MaxCopyFileDuration = 2 * time.Hour
@@ -31,8 +31,14 @@ var IgnoreProbeErrs = []string{
 	"non-existing sps 0",
 }
 
+// corruptionCodes are the callback error codes of the kinds of corrupt sources
+var corruptionCodes = map[string]string{
+	video.CorruptionTruncated: catErrs.CodeSourceTruncated,
+	video.CorruptionInvalid:   catErrs.CodeSourceCorrupt,
+}
+
 type InputCopier interface {
-	CopyInputToS3(requestID string, inputFile, osTransferURL *url.URL, decryptor *crypto.DecryptionKeys) (video.InputVideo, string, error)
+	CopyInputToS3(requestID string, inputFile, osTransferURL *url.URL, decryptor *crypto.DecryptionKeys, progress func(completionRatio float64)) (video.InputVideo, string, error)
 }
 
 type InputCopy struct {
@@ -47,14 +53,14 @@ func NewInputCopy() *InputCopy {
 }
 
 // CopyInputToS3 copies the input video to our S3 transfer bucket and probes the file.
-func (s *InputCopy) CopyInputToS3(requestID string, inputFile, osTransferURL *url.URL, decryptor *crypto.DecryptionKeys) (video.InputVideo, string, error) {
+func (s *InputCopy) CopyInputToS3(requestID string, inputFile, osTransferURL *url.URL, decryptor *crypto.DecryptionKeys, progress func(completionRatio float64)) (video.InputVideo, string, error) {
 	var signedURL string
 	var err error
 	if IsHLSInput(inputFile) {
 		log.Log(requestID, "skipping copy for hls")
 		signedURL = inputFile.String()
 	} else {
-		if err := CopyAllInputFiles(requestID, inputFile, osTransferURL, decryptor); err != nil {
+		if err := CopyAllInputFiles(requestID, inputFile, osTransferURL, decryptor, progress); err != nil {
 			return video.InputVideo{}, "", fmt.Errorf("failed to copy file(s): %w", err)
 		}
 
@@ -68,7 +74,11 @@ func (s *InputCopy) CopyInputToS3(requestID string, inputFile, osTransferURL *ur
 	inputFileProbe, err := s.Probe.ProbeFile(requestID, signedURL, "-analyzeduration", "15000000")
 	if err != nil {
 		log.Log(requestID, "probe failed", "err", err, "source", inputFile.Redacted(), "dest", osTransferURL.Redacted())
-		return video.InputVideo{}, "", fmt.Errorf("error probing MP4 input file from S3: %w", err)
+		if corrupt := video.ClassifyProbeError(err); corrupt != nil {
+			log.Log(requestID, "input file is corrupt", "kind", corrupt.Kind)
+			return video.InputVideo{}, "", catErrs.Unretriable(catErrs.WithFailureReason(catErrs.FailureInvalidSource, catErrs.WithCode(corruptionCodes[corrupt.Kind], corrupt)))
+		}
+		return video.InputVideo{}, "", catErrs.WithFailureReason(catErrs.FailureInvalidSource, fmt.Errorf("error probing MP4 input file from S3: %w", err))
 	}
 
 	log.Log(requestID, "probe succeeded", "source", inputFile.Redacted(), "dest", osTransferURL.Redacted())
@@ -92,18 +102,22 @@ func (s *InputCopy) CopyInputToS3(requestID string, inputFile, osTransferURL *ur
 	}
 
 	if hasVideoTrack {
-		log.Log(requestID, "probed video track:", "container", inputFileProbe.Format, "codec", videoTrack.Codec, "bitrate", videoTrack.Bitrate, "duration", videoTrack.DurationSec, "w", videoTrack.Width, "h", videoTrack.Height, "pix-format", videoTrack.PixelFormat, "FPS", videoTrack.FPS)
+		log.Log(requestID, "probed video track:", "container", inputFileProbe.Format, "codec", videoTrack.Codec, "bitrate", videoTrack.Bitrate, "duration", videoTrack.DurationSec, "w", videoTrack.Width, "h", videoTrack.Height, "pix-format", videoTrack.PixelFormat, "bit-depth", videoTrack.ColorBitDepth, "hdr", videoTrack.HDR, "FPS", videoTrack.FPS)
 	}
 	if hasVideoTrack && videoTrack.FPS <= 0 {
 		// unsupported, includes things like motion jpegs
-		return video.InputVideo{}, "", fmt.Errorf("invalid framerate: %f", videoTrack.FPS)
+		return video.InputVideo{}, "", catErrs.WithFailureReason(catErrs.FailureInvalidSource, fmt.Errorf("invalid framerate: %f", videoTrack.FPS))
 	}
 	if inputFileProbe.SizeBytes > config.MaxInputFileSizeBytes {
-		return video.InputVideo{}, "", fmt.Errorf("input file %d bytes was greater than %d bytes", inputFileProbe.SizeBytes, config.MaxInputFileSizeBytes)
+		return video.InputVideo{}, "", catErrs.WithFailureReason(catErrs.FailureInvalidSource, fmt.Errorf("input file %d bytes was greater than %d bytes", inputFileProbe.SizeBytes, config.MaxInputFileSizeBytes))
 	}
 
 	audioTrack, _ := inputFileProbe.GetTrack(video.TrackTypeAudio)
-	log.Log(requestID, "probed audio track", "codec", audioTrack.Codec, "bitrate", audioTrack.Bitrate, "duration", audioTrack.DurationSec, "channels", audioTrack.Channels)
+	log.Log(requestID, "probed audio track", "codec", audioTrack.Codec, "bitrate", audioTrack.Bitrate, "duration", audioTrack.DurationSec, "channels", audioTrack.Channels, "layout", audioTrack.ChannelLayout)
+	if err := video.SourceAllowlist.Check(inputFileProbe); err != nil {
+		// the same source would be rejected again, so there's no point in retrying it
+		return video.InputVideo{}, "", catErrs.Unretriable(catErrs.WithFailureReason(catErrs.FailureInvalidSource, err))
+	}
 	return inputFileProbe, signedURL, nil
 }
 
@@ -181,8 +195,9 @@ func getSegmentTransferLocation(srcManifestUrl, dstTransferUrl *url.URL, srcSegm
 }
 
 // CopyAllInputFiles will copy the m3u8 manifest and all ts segments for HLS input whereas
-// it will copy just the single video file for MP4/MOV input
-func CopyAllInputFiles(requestID string, srcInputUrl, dstOutputUrl *url.URL, decryptor *crypto.DecryptionKeys) (err error) {
+// it will copy just the single video file for MP4/MOV input. The progress, if not nil, is reported as the share of the
+// file that's been copied, or of the files for HLS input.
+func CopyAllInputFiles(requestID string, srcInputUrl, dstOutputUrl *url.URL, decryptor *crypto.DecryptionKeys, progress func(completionRatio float64)) (err error) {
 	fileList := make(map[string]string)
 	if IsHLSInput(srcInputUrl) {
 		// Download the m3u8 manifest using the input url
@@ -211,10 +226,15 @@ func CopyAllInputFiles(requestID string, srcInputUrl, dstOutputUrl *url.URL, dec
 	}
 
 	var byteCount int64
+	var copied int
 	for inFile, outFile := range fileList {
 		log.Log(requestID, "Copying input file to S3", "source", inFile, "dest", outFile)
 
-		size, err := CopyFileWithDecryption(context.Background(), inFile, outFile, "", requestID, decryptor)
+		var fileProgress func(float64)
+		if progress != nil && len(fileList) == 1 {
+			fileProgress = progress
+		}
+		size, err := copyFile(context.Background(), inFile, outFile, "", requestID, decryptor, DefaultSourceCache, fileProgress)
 
 		if err != nil {
 			err = fmt.Errorf("error copying input file to S3: %w", err)
@@ -228,23 +248,37 @@ func CopyAllInputFiles(requestID string, srcInputUrl, dstOutputUrl *url.URL, dec
 			}
 		}
 		byteCount += size
+		copied++
+		if progress != nil && len(fileList) > 1 {
+			progress(float64(copied) / float64(len(fileList)))
+		}
 	}
 	log.Log(requestID, "Copied", "bytes", byteCount, "source", srcInputUrl.Redacted(), "dest", dstOutputUrl.Redacted())
 	return nil
 }
 
 func CopyFileWithDecryption(ctx context.Context, sourceURL, destOSBaseURL, filename, requestID string, decryptor *crypto.DecryptionKeys) (writtenBytes int64, err error) {
+	return copyFile(ctx, sourceURL, destOSBaseURL, filename, requestID, decryptor, nil, nil)
+}
+
+// maxCopyFileBytes is the size of the largest source that's copied, checked against the size the source announces
+// before the copy and against the bytes read as it's copied
+var maxCopyFileBytes int64 = config.MaxInputFileSizeBytes
+
+// copyFile is CopyFileWithDecryption, reading the source from the cache when it's there and caching it otherwise. The
+// source is cached as it was downloaded, i.e. before it's decrypted. The progress, if not nil, is reported every
+// copyProgressInterval for the sources of known size.
+func copyFile(ctx context.Context, sourceURL, destOSBaseURL, filename, requestID string, decryptor *crypto.DecryptionKeys, cache *SourceCache, progress func(completionRatio float64)) (writtenBytes int64, err error) {
 	dStorage := NewDStorageDownload()
 	err = backoff.Retry(func() error {
-		// currently this timeout is only used for http downloads in the getFileHTTP function when it calls http.NewRequestWithContext
+		// currently this timeout is only used for http and dStorage gateway downloads, which make their requests with it
 		ctx, cancel := context.WithTimeout(ctx, MaxCopyFileDuration)
 		defer cancel()
 
 		byteAccWriter := ByteAccumulatorWriter{count: 0}
 		defer func() { writtenBytes = byteAccWriter.count }()
 
-		var c io.ReadCloser
-		c, err := GetFile(ctx, requestID, sourceURL, dStorage)
+		c, size, err := getCachedFile(ctx, requestID, sourceURL, dStorage, cache)
 
 		if err != nil {
 			return fmt.Errorf("download error: %w", err)
@@ -252,18 +286,30 @@ func CopyFileWithDecryption(ctx context.Context, sourceURL, destOSBaseURL, filen
 
 		defer c.Close()
 
+		if size > maxCopyFileBytes {
+			return sourceTooLargeError(size)
+		}
+		read := &copyProgress{reader: c, size: size, report: progress}
+
+		// decrypt as the file is read rather than up front, so large sources aren't held in memory
+		var r io.Reader = read
 		if decryptor != nil {
-			decryptedFile, err := crypto.DecryptAESCBC(c, decryptor.DecryptKey, decryptor.EncryptedKey)
+			r, err = decryptor.DecryptReader(ctx, read)
 			if err != nil {
-				return fmt.Errorf("error decrypting file: %w", err)
+				return decryptionError(requestID, err)
 			}
-			defer decryptedFile.Close()
-			c = decryptedFile
 		}
 
-		content := io.TeeReader(c, &byteAccWriter)
+		decrypted := &readErrorRecorder{reader: r}
+		content := io.TeeReader(decrypted, &byteAccWriter)
 
 		err = UploadToOSURL(destOSBaseURL, filename, content, MaxCopyFileDuration)
+		if read.tooLarge {
+			return sourceTooLargeError(read.read)
+		}
+		if crypto.FailureReason(decrypted.err) != "" {
+			return decryptionError(requestID, decrypted.err)
+		}
 		if err != nil {
 			log.Log(requestID, "Copy attempt failed", "source", sourceURL, "dest", path.Join(destOSBaseURL, filename), "err", err)
 		}
@@ -272,21 +318,125 @@ func CopyFileWithDecryption(ctx context.Context, sourceURL, destOSBaseURL, filen
 	return
 }
 
+// decryptionError records the failure metric and wraps the error, marking it unretriable when retrying can't help,
+// so that the job fails with an error callback straight away rather than after exhausting the retries
+func decryptionError(requestID string, err error) error {
+	reason := crypto.FailureReason(err)
+	if reason == "" {
+		reason = "other"
+	}
+	metrics.Metrics.VODDecryptionFailureCount.WithLabelValues(reason).Inc()
+	log.LogError(requestID, "Decrypting source failed", err, "reason", reason)
+
+	err = fmt.Errorf("error decrypting file: %w", err)
+	switch reason {
+	case "no_key", "unknown_key_id", "authentication_failed", "invalid_padding":
+		// the key isn't configured, has been retired, or the source is corrupt or was encrypted with another key
+		return catErrs.Unretriable(err)
+	}
+	return err
+}
+
+// readErrorRecorder keeps the last error from the reader, since the storage drivers don't wrap the errors
+// from the data they're uploading
+type readErrorRecorder struct {
+	reader io.Reader
+	err    error
+}
+
+func (r *readErrorRecorder) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return n, err
+}
+
 func CopyFile(ctx context.Context, sourceURL, destOSBaseURL, filename, requestID string) (writtenBytes int64, err error) {
 	return CopyFileWithDecryption(ctx, sourceURL, destOSBaseURL, filename, requestID, nil)
 }
 
 func GetFile(ctx context.Context, requestID, url string, dStorage *DStorageDownload) (io.ReadCloser, error) {
-	_, err := drivers.ParseOSURL(url, true)
+	_, err := parseOSURL(url)
 	if err == nil {
 		return DownloadOSURL(url)
 	} else if IsDStorageResource(url) && dStorage != nil {
-		return dStorage.DownloadDStorageFromGatewayList(url, requestID)
+		return dStorage.DownloadDStorageFromGatewayList(ctx, url, requestID)
 	} else {
 		return getFileHTTP(ctx, url)
 	}
 }
 
+// getCachedFile is GetFile through a source cache, which can be nil. It returns the size of the source too, -1 when
+// it's unknown.
+func getCachedFile(ctx context.Context, requestID, url string, dStorage *DStorageDownload, cache *SourceCache) (io.ReadCloser, int64, error) {
+	if rc, ok := cache.Open(url); ok {
+		log.Log(requestID, "Reading source from the source cache", "source", log.RedactURL(url))
+		return rc, sourceSize(rc), nil
+	}
+	rc, err := GetFile(ctx, requestID, url, dStorage)
+	if err != nil {
+		return nil, 0, err
+	}
+	return cache.Fill(url, rc), sourceSize(rc), nil
+}
+
+// sourceSize is the size of a source being downloaded, as given by its store or server, -1 when it's unknown
+func sourceSize(rc io.ReadCloser) int64 {
+	switch r := rc.(type) {
+	case interface{ Size() int64 }:
+		return r.Size()
+	case throttledReadCloser:
+		if sized, ok := r.Closer.(interface{ Size() int64 }); ok {
+			return sized.Size()
+		}
+	case *os.File:
+		if info, err := r.Stat(); err == nil {
+			return info.Size()
+		}
+	}
+	return -1
+}
+
+// copyProgressInterval is how often the progress of a copy is reported, so that short copies don't report any
+var copyProgressInterval = 5 * time.Second
+
+// copyProgress reports the share of a source that's been read, and stops reading it once it's larger than
+// maxCopyFileBytes, for the sources whose size isn't known up front
+type copyProgress struct {
+	reader io.Reader
+	// size of the source, -1 when unknown
+	size       int64
+	read       int64
+	report     func(completionRatio float64)
+	lastReport time.Time
+	tooLarge   bool
+}
+
+func (p *copyProgress) Read(b []byte) (int, error) {
+	n, err := p.reader.Read(b)
+	p.read += int64(n)
+	if p.read > maxCopyFileBytes {
+		p.tooLarge = true
+		return n, sourceTooLargeError(p.read)
+	}
+	if n > 0 && p.report != nil && p.size > 0 {
+		if p.lastReport.IsZero() {
+			p.lastReport = time.Now()
+		} else if time.Since(p.lastReport) >= copyProgressInterval {
+			p.lastReport = time.Now()
+			p.report(float64(p.read) / float64(p.size))
+		}
+	}
+	return n, err
+}
+
+// sourceTooLargeError fails the copy without retrying it, as the source won't get any smaller
+func sourceTooLargeError(size int64) error {
+	err := fmt.Errorf("source of %d bytes or more is larger than the limit of %d bytes", size, maxCopyFileBytes)
+	return backoff.Permanent(catErrs.Unretriable(catErrs.WithFailureReason(catErrs.FailureInvalidSource, catErrs.WithCode(catErrs.CodeSourceTooLarge, err))))
+}
+
 func GetFileWithBackup(ctx context.Context, requestID, url string, dStorage *DStorageDownload) (io.ReadCloser, string, error) {
 	rc, err := GetFile(ctx, requestID, url, dStorage)
 	if err == nil {
@@ -328,32 +478,21 @@ func newRetryableHttpClient() *http.Client {
 	return client.StandardClient()
 }
 
+// getFileHTTP downloads an HTTP URL, resuming a read that fails with a range request when the server supports them
 func getFileHTTP(ctx context.Context, url string) (io.ReadCloser, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	body, err := newResumableReader(ctx, openHTTPRange(url), nil)
 	if err != nil {
-		return nil, catErrs.Unretriable(fmt.Errorf("error creating http request: %w", err))
+		return nil, err
 	}
-	resp, err := retryableHttpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("error on import request: %w", err)
-	}
-
-	if resp.StatusCode >= 300 {
-		resp.Body.Close()
-
-		msg := fmt.Sprintf("bad status code from import request: %d %s", resp.StatusCode, resp.Status)
-		if resp.StatusCode == 404 {
-			return nil, catErrs.NewObjectNotFoundError(msg, nil)
-		} else if resp.StatusCode < 500 {
-			return nil, catErrs.Unretriable(errors.New(msg))
-		}
-		return nil, errors.New(msg)
+	bw := bandwidthFor(url, osOperationRead)
+	if len(bw) == 0 {
+		return body, nil
 	}
-	return resp.Body, nil
+	return throttledReadCloser{bw.reader(ctx, body), body}, nil
 }
 
 type StubInputCopy struct{}
 
-func (s *StubInputCopy) CopyInputToS3(requestID string, inputFile, osTransferURL *url.URL, decryptor *crypto.DecryptionKeys) (video.InputVideo, string, error) {
+func (s *StubInputCopy) CopyInputToS3(requestID string, inputFile, osTransferURL *url.URL, decryptor *crypto.DecryptionKeys, progress func(completionRatio float64)) (video.InputVideo, string, error) {
 	return video.InputVideo{}, "", nil
 }
@@ -1,10 +1,16 @@
 package clients
 
 import (
+	"fmt"
+	"io"
 	"net/url"
 	"testing"
 
+	"github.com/livepeer/catalyst-api/crypto"
+	catErrs "github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/metrics"
 	"github.com/livepeer/catalyst-api/video"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -101,8 +107,27 @@ func TestHLSDurationSet(t *testing.T) {
 		Probe: video.Probe{},
 	}
 	inputFile, _ := url.Parse("../test/fixtures/tiny.m3u8")
-	iv, _, err := i.CopyInputToS3("requestID", inputFile, &url.URL{}, nil)
+	iv, _, err := i.CopyInputToS3("requestID", inputFile, &url.URL{}, nil, nil)
 	require.NoError(t, err)
 	videoTrack, _ := iv.GetTrack(video.TrackTypeVideo)
 	require.Equal(t, 30.0, videoTrack.DurationSec)
 }
+
+func TestDecryptionError(t *testing.T) {
+	failures := metrics.Metrics.VODDecryptionFailureCount
+	before := testutil.ToFloat64(failures.WithLabelValues("authentication_failed"))
+
+	err := decryptionError("req-id", fmt.Errorf("reading chunk: %w", crypto.ErrAuthenticationFailed))
+	require.ErrorIs(t, err, crypto.ErrAuthenticationFailed)
+	require.True(t, catErrs.IsUnretriable(err))
+	require.Equal(t, before+1, testutil.ToFloat64(failures.WithLabelValues("authentication_failed")))
+
+	// the key provider being unavailable may be temporary
+	err = decryptionError("req-id", fmt.Errorf("%w: kms unavailable", crypto.ErrKeyUnwrap))
+	require.False(t, catErrs.IsUnretriable(err))
+
+	before = testutil.ToFloat64(failures.WithLabelValues("other"))
+	err = decryptionError("req-id", io.ErrUnexpectedEOF)
+	require.False(t, catErrs.IsUnretriable(err))
+	require.Equal(t, before+1, testutil.ToFloat64(failures.WithLabelValues("other")))
+}
@@ -32,7 +32,7 @@ const (
 )
 
 func DownloadRetryBackoffLong() backoff.BackOff {
-	return backoff.WithMaxRetries(backoff.NewConstantBackOff(5*time.Second), 10)
+	return retryCountingBackOff{backoff.WithMaxRetries(backoff.NewConstantBackOff(5*time.Second), 10), osOperationRead}
 }
 
 var DownloadRetryBackoff = DownloadRetryBackoffLong
@@ -233,7 +233,7 @@ func GetSourceSegmentURLs(sourceManifestURL string, manifest m3u8.MediaPlaylist)
 
 // Generate a Master manifest, plus one Rendition manifest for each Profile we're transcoding, then write them to storage
 // Returns the master manifest URL on success
-func GenerateAndUploadManifests(sourceManifest m3u8.MediaPlaylist, targetOSURL string, transcodedStats []*video.RenditionStats, isClip bool) (string, error) {
+func GenerateAndUploadManifests(sourceManifest m3u8.MediaPlaylist, targetOSURL string, transcodedStats []*video.RenditionStats, isClip bool, audio *video.AudioOptions, subtitles []*m3u8.Alternative, naming *video.OutputNaming, encryption *video.HLSEncryption) (string, error) {
 	// Generate the master + rendition output manifests
 	masterPlaylist := m3u8.NewMasterPlaylist()
 
@@ -246,19 +246,33 @@ func GenerateAndUploadManifests(sourceManifest m3u8.MediaPlaylist, targetOSURL s
 		transcodedStats[0], transcodedStats[1] = transcodedStats[1], transcodedStats[0]
 	}
 
+	audioGroup := audio.Alternative()
 	for i, profile := range transcodedStats {
+		namingVars := video.NamingVars{Rendition: profile.Name, Width: profile.Width, Height: profile.Height}
+		playlistPath := naming.PlaylistPath(namingVars)
+		variant := m3u8.VariantParams{
+			Name:       fmt.Sprintf("%d-%s", i, profile.Name),
+			Bandwidth:  profile.BitsPerSecond,
+			FrameRate:  float64(profile.FPS),
+			Resolution: fmt.Sprintf("%dx%d", profile.Width, profile.Height),
+			VideoRange: profile.VideoRange,
+		}
+		// every rendition has the same audio, so they share a single audio group
+		if audioGroup != nil {
+			variant.Audio = audioGroup.GroupId
+			variant.Alternatives = []*m3u8.Alternative{audioGroup}
+		}
+		if len(subtitles) > 0 {
+			variant.Subtitles = video.SubtitleGroupID
+			variant.Alternatives = append(variant.Alternatives, subtitles...)
+		}
 		// For each profile, add a new entry to the master manifest
 		masterPlaylist.Append(
-			path.Join(profile.Name, "index.m3u8"),
+			playlistPath,
 			&m3u8.MediaPlaylist{
 				TargetDuration: sourceManifest.TargetDuration,
 			},
-			m3u8.VariantParams{
-				Name:       fmt.Sprintf("%d-%s", i, profile.Name),
-				Bandwidth:  profile.BitsPerSecond,
-				FrameRate:  float64(profile.FPS),
-				Resolution: fmt.Sprintf("%dx%d", profile.Width, profile.Height),
-			},
+			variant,
 		)
 
 		// For each profile, create and upload a new rendition manifest
@@ -267,6 +281,13 @@ func GenerateAndUploadManifests(sourceManifest m3u8.MediaPlaylist, targetOSURL s
 			return "", fmt.Errorf("failed to create rendition manifest for profile %q: %s", profile.Name, err)
 		}
 
+		// The segments of encrypted outputs are decrypted with the key of the asset, their IV is their media sequence number
+		if encryption != nil {
+			if err := renditionPlaylist.SetDefaultKey(video.HLSEncryptionMethod, encryption.URI(), "", "", ""); err != nil {
+				return "", fmt.Errorf("failed to set the key of rendition manifest for profile %q: %s", profile.Name, err)
+			}
+		}
+
 		// Add segments to the manifest
 		for i, sourceSegment := range sourceManifest.Segments {
 			// The segments list is a ring buffer - see https://github.com/grafov/m3u8/issues/140
@@ -274,7 +295,8 @@ func GenerateAndUploadManifests(sourceManifest m3u8.MediaPlaylist, targetOSURL s
 			if sourceSegment == nil {
 				break
 			}
-			err := renditionPlaylist.Append(fmt.Sprintf("%d.ts", i), sourceSegment.Duration, "")
+			namingVars.Index = i
+			err := renditionPlaylist.Append(naming.SegmentURI(namingVars), sourceSegment.Duration, "")
 			if err != nil {
 				return "", fmt.Errorf("failed to append to rendition playlist number %d: %s", i, err)
 			}
@@ -292,14 +314,18 @@ func GenerateAndUploadManifests(sourceManifest m3u8.MediaPlaylist, targetOSURL s
 		// Write #EXT-X-ENDLIST
 		renditionPlaylist.Close()
 
-		manifestFilename := "index.m3u8"
-		renditionManifestBaseURL := fmt.Sprintf("%s/%s", targetOSURL, profile.Name)
-		err = backoff.Retry(func() error {
-			return UploadToOSURL(renditionManifestBaseURL, manifestFilename, strings.NewReader(renditionPlaylist.String()), ManifestUploadTimeout)
-		}, UploadRetryBackoff())
+		manifestFilename := path.Base(playlistPath)
+		renditionManifestBaseURL, err := url.JoinPath(targetOSURL, path.Dir(playlistPath))
+		if err != nil {
+			return "", fmt.Errorf("failed to build rendition playlist URL for profile %q: %s", profile.Name, err)
+		}
+		err = UploadWithBackup(renditionManifestBaseURL, manifestFilename, func(osURL string) error {
+			return UploadToOSURL(osURL, manifestFilename, strings.NewReader(renditionPlaylist.String()), ManifestUploadTimeout)
+		})
 		if err != nil {
 			return "", fmt.Errorf("failed to upload rendition playlist: %s", err)
 		}
+		RecordChecksum(renditionManifestBaseURL, manifestFilename, []byte(renditionPlaylist.String()))
 		// update manifest location
 		transcodedStats[i].ManifestLocation, err = url.JoinPath(renditionManifestBaseURL, manifestFilename)
 		if err != nil {
@@ -307,12 +333,13 @@ func GenerateAndUploadManifests(sourceManifest m3u8.MediaPlaylist, targetOSURL s
 			transcodedStats[i].ManifestLocation = ""
 		}
 	}
-	err := backoff.Retry(func() error {
-		return UploadToOSURL(targetOSURL, MasterManifestFilename, strings.NewReader(masterPlaylist.String()), ManifestUploadTimeout)
-	}, UploadRetryBackoff())
+	err := UploadWithBackup(targetOSURL, MasterManifestFilename, func(osURL string) error {
+		return UploadToOSURL(osURL, MasterManifestFilename, strings.NewReader(masterPlaylist.String()), ManifestUploadTimeout)
+	})
 	if err != nil {
 		return "", fmt.Errorf("failed to upload master playlist: %s", err)
 	}
+	RecordChecksum(targetOSURL, MasterManifestFilename, []byte(masterPlaylist.String()))
 
 	res, err := url.JoinPath(targetOSURL, MasterManifestFilename)
 	if err != nil {
@@ -156,6 +156,10 @@ func TestItCanGenerateAndWriteManifests(t *testing.T) {
 			},
 		},
 		false,
+		nil,
+		nil,
+		nil,
+		nil,
 	)
 	require.NoError(t, err)
 
@@ -185,6 +189,125 @@ lowlowlow/index.m3u8
 	require.NoFileExists(t, filepath.Join(outputDir, "small-high-def/index.m3u8"))
 }
 
+func TestItWritesTheAudioGroupOfTheRenditions(t *testing.T) {
+	sourceManifest, _, err := m3u8.DecodeFrom(strings.NewReader(validMediaManifest), true)
+	require.NoError(t, err)
+	sourceMediaPlaylist, ok := sourceManifest.(*m3u8.MediaPlaylist)
+	require.True(t, ok)
+	stats := []*video.RenditionStats{
+		{Name: "360p0", FPS: 30, Width: 640, Height: 360, BitsPerSecond: 1},
+		{Name: "720p0", FPS: 30, Width: 1280, Height: 720, BitsPerSecond: 1},
+	}
+
+	track := 1
+	outputDir := t.TempDir()
+	_, err = GenerateAndUploadManifests(*sourceMediaPlaylist, outputDir, stats, false, &video.AudioOptions{Downmix: true, Track: &track}, nil, nil, nil)
+	require.NoError(t, err)
+	masterManifestContents, err := os.ReadFile(filepath.Join(outputDir, "index.m3u8"))
+	require.NoError(t, err)
+	require.Equal(t, `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="audio",NAME="Stereo (track 1)",DEFAULT=YES,AUTOSELECT=YES
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1,RESOLUTION=640x360,AUDIO="audio",NAME="0-360p0",FRAME-RATE=30.000
+360p0/index.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1,RESOLUTION=1280x720,AUDIO="audio",NAME="1-720p0",FRAME-RATE=30.000
+720p0/index.m3u8
+`, string(masterManifestContents))
+
+	// renditions without audio have no audio group
+	outputDir = t.TempDir()
+	_, err = GenerateAndUploadManifests(*sourceMediaPlaylist, outputDir, stats, false, &video.AudioOptions{Drop: true}, nil, nil, nil)
+	require.NoError(t, err)
+	masterManifestContents, err = os.ReadFile(filepath.Join(outputDir, "index.m3u8"))
+	require.NoError(t, err)
+	require.NotContains(t, string(masterManifestContents), "AUDIO")
+}
+
+func TestItWritesTheVideoRangeOfHDRRenditions(t *testing.T) {
+	sourceManifest, _, err := m3u8.DecodeFrom(strings.NewReader(validMediaManifest), true)
+	require.NoError(t, err)
+	sourceMediaPlaylist, ok := sourceManifest.(*m3u8.MediaPlaylist)
+	require.True(t, ok)
+	stats := []*video.RenditionStats{
+		{Name: "360p0", FPS: 30, Width: 640, Height: 360, BitsPerSecond: 1, VideoRange: video.VideoRangePQ},
+	}
+
+	outputDir := t.TempDir()
+	_, err = GenerateAndUploadManifests(*sourceMediaPlaylist, outputDir, stats, false, nil, nil, nil, nil)
+	require.NoError(t, err)
+	masterManifestContents, err := os.ReadFile(filepath.Join(outputDir, "index.m3u8"))
+	require.NoError(t, err)
+	require.Contains(t, string(masterManifestContents), `#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1,RESOLUTION=640x360,NAME="0-360p0",FRAME-RATE=30.000,VIDEO-RANGE=PQ
+360p0/index.m3u8`)
+}
+
+func TestItReferencesTheSubtitlesOfTheRenditions(t *testing.T) {
+	sourceManifest, _, err := m3u8.DecodeFrom(strings.NewReader(validMediaManifest), true)
+	require.NoError(t, err)
+	sourceMediaPlaylist, ok := sourceManifest.(*m3u8.MediaPlaylist)
+	require.True(t, ok)
+	stats := []*video.RenditionStats{
+		{Name: "360p0", FPS: 30, Width: 640, Height: 360, BitsPerSecond: 1},
+		{Name: "720p0", FPS: 30, Width: 1280, Height: 720, BitsPerSecond: 1},
+	}
+	subtitles := []*m3u8.Alternative{
+		video.SubtitleFile{Name: "English", Language: "eng"}.Alternative("subtitles/0/index.m3u8"),
+	}
+
+	outputDir := t.TempDir()
+	_, err = GenerateAndUploadManifests(*sourceMediaPlaylist, outputDir, stats, false, nil, subtitles, nil, nil)
+	require.NoError(t, err)
+	masterManifestContents, err := os.ReadFile(filepath.Join(outputDir, "index.m3u8"))
+	require.NoError(t, err)
+	require.Equal(t, `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID="subtitles",NAME="English",DEFAULT=NO,AUTOSELECT=YES,LANGUAGE="eng",URI="subtitles/0/index.m3u8"
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1,RESOLUTION=640x360,SUBTITLES="subtitles",NAME="0-360p0",FRAME-RATE=30.000
+360p0/index.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1,RESOLUTION=1280x720,SUBTITLES="subtitles",NAME="1-720p0",FRAME-RATE=30.000
+720p0/index.m3u8
+`, string(masterManifestContents))
+}
+
+func TestItNamesTheRenditionPlaylistsAndSegments(t *testing.T) {
+	sourceManifest, _, err := m3u8.DecodeFrom(strings.NewReader(validMediaManifest), true)
+	require.NoError(t, err)
+	sourceMediaPlaylist, ok := sourceManifest.(*m3u8.MediaPlaylist)
+	require.True(t, ok)
+	stats := []*video.RenditionStats{
+		{Name: "360p0", FPS: 30, Width: 640, Height: 360, BitsPerSecond: 1},
+	}
+	naming := &video.OutputNaming{Segment: "{rendition}/seg-{index}.ts", Playlist: "{rendition}.m3u8"}
+
+	outputDir := t.TempDir()
+	_, err = GenerateAndUploadManifests(*sourceMediaPlaylist, outputDir, stats, false, nil, nil, naming, nil)
+	require.NoError(t, err)
+	masterManifestContents, err := os.ReadFile(filepath.Join(outputDir, "index.m3u8"))
+	require.NoError(t, err)
+	require.Contains(t, string(masterManifestContents), "\n360p0.m3u8\n")
+	renditionManifestContents, err := os.ReadFile(filepath.Join(outputDir, "360p0.m3u8"))
+	require.NoError(t, err)
+	require.Contains(t, string(renditionManifestContents), "\n360p0/seg-0.ts\n")
+	require.Contains(t, string(renditionManifestContents), "\n360p0/seg-1.ts\n")
+}
+
+func TestItAddsTheKeyOfEncryptedOutputsToTheRenditionPlaylists(t *testing.T) {
+	sourceManifest, _, err := m3u8.DecodeFrom(strings.NewReader(validMediaManifest), true)
+	require.NoError(t, err)
+	sourceMediaPlaylist, ok := sourceManifest.(*m3u8.MediaPlaylist)
+	require.True(t, ok)
+	stats := []*video.RenditionStats{
+		{Name: "360p0", FPS: 30, Width: 640, Height: 360, BitsPerSecond: 1},
+	}
+
+	outputDir := t.TempDir()
+	_, err = GenerateAndUploadManifests(*sourceMediaPlaylist, outputDir, stats, false, nil, nil, nil, &video.HLSEncryption{PlaybackID: "abc123"})
+	require.NoError(t, err)
+	renditionManifestContents, err := os.ReadFile(filepath.Join(outputDir, "360p0", "index.m3u8"))
+	require.NoError(t, err)
+	require.Contains(t, string(renditionManifestContents), "\n#EXT-X-KEY:METHOD=AES-128,URI=\"/asset/keys/abc123\"\n")
+}
+
 func TestCompliantMasterManifestOrdering(t *testing.T) {
 	// Set up the parameters we pass in
 	sourceManifest, _, err := m3u8.DecodeFrom(strings.NewReader(validMediaManifest), true)
@@ -230,6 +353,10 @@ func TestCompliantMasterManifestOrdering(t *testing.T) {
 			},
 		},
 		false,
+		nil,
+		nil,
+		nil,
+		nil,
 	)
 	require.NoError(t, err)
 
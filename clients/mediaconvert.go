@@ -135,7 +135,7 @@ func (mc *MediaConvert) Transcode(ctx context.Context, args TranscodeJobArgs) (o
 
 	if hasVideoTrack {
 		if len(mcArgs.Profiles) == 0 {
-			mcArgs.Profiles, err = video.GetDefaultPlaybackProfiles(videoTrack, false)
+			mcArgs.Profiles, err = video.GetConstrainedPlaybackProfiles(mcArgs.DefaultProfiles, mcArgs.LadderConstraints, videoTrack, false)
 			if err != nil {
 				return nil, fmt.Errorf("failed to get playback profiles: %w", err)
 			}
@@ -262,7 +262,7 @@ func (mc *MediaConvert) coreAwsTranscode(ctx context.Context, args TranscodeJobA
 		mp4OutputLocation = toStr(args.MP4OutputLocation)
 	}
 
-	payload := createJobPayload(args.InputFile.String(), toStr(args.HLSOutputLocation), mp4OutputLocation, mc.role, accelerated, args.Profiles, args.SegmentSizeSecs)
+	payload := createJobPayload(args.InputFile.String(), toStr(args.HLSOutputLocation), mp4OutputLocation, mc.role, accelerated, args.Profiles, args.SegmentSizeSecs, args.AudioOptions)
 	job, err := mc.client.CreateJob(payload)
 	if err != nil {
 		return fmt.Errorf("error creating mediaconvert job: %w", err)
@@ -332,25 +332,31 @@ func (mc *MediaConvert) coreAwsTranscode(ctx context.Context, args TranscodeJobA
 	}
 }
 
-func createJobPayload(inputFile, hlsOutputFile, mp4OutputFile, role string, accelerated bool, profiles []video.EncodedProfile, segmentSizeSecs int64) *mediaconvert.CreateJobInput {
+func createJobPayload(inputFile, hlsOutputFile, mp4OutputFile, role string, accelerated bool, profiles []video.EncodedProfile, segmentSizeSecs int64, audio *video.AudioOptions) *mediaconvert.CreateJobInput {
 	var acceleration *mediaconvert.AccelerationSettings
 	if accelerated {
 		acceleration = &mediaconvert.AccelerationSettings{
 			Mode: aws.String(mediaconvert.AccelerationModePreferred),
 		}
 	}
+	audioSelector := &mediaconvert.AudioSelector{
+		Offset:           aws.Int64(0),
+		DefaultSelection: aws.String("DEFAULT"),
+		SelectorType:     aws.String("TRACK"),
+		ProgramSelection: aws.Int64(1),
+	}
+	// the audio is always downmixed to stereo, so only the track selection and dropping apply
+	if audio != nil && audio.Track != nil {
+		audioSelector.Tracks = []*int64{aws.Int64(int64(*audio.Track) + 1)}
+	}
+	dropAudio := audio != nil && audio.Drop
 
 	return &mediaconvert.CreateJobInput{
 		Settings: &mediaconvert.JobSettings{
 			Inputs: []*mediaconvert.Input{
 				{
 					AudioSelectors: map[string]*mediaconvert.AudioSelector{
-						"Audio Selector 1": {
-							Offset:           aws.Int64(0),
-							DefaultSelection: aws.String("DEFAULT"),
-							SelectorType:     aws.String("TRACK"),
-							ProgramSelection: aws.Int64(1),
-						},
+						"Audio Selector 1": audioSelector,
 					},
 					FileInput:      aws.String(inputFile),
 					TimecodeSource: aws.String("ZEROBASED"),
@@ -359,7 +365,7 @@ func createJobPayload(inputFile, hlsOutputFile, mp4OutputFile, role string, acce
 					},
 				},
 			},
-			OutputGroups: outputGroups(hlsOutputFile, mp4OutputFile, profiles, segmentSizeSecs),
+			OutputGroups: outputGroups(hlsOutputFile, mp4OutputFile, profiles, segmentSizeSecs, dropAudio),
 			TimecodeConfig: &mediaconvert.TimecodeConfig{
 				Source: aws.String("ZEROBASED"),
 			},
@@ -369,7 +375,7 @@ func createJobPayload(inputFile, hlsOutputFile, mp4OutputFile, role string, acce
 	}
 }
 
-func outputGroups(hlsOutputFile, mp4OutputFile string, profiles []video.EncodedProfile, segmentSizeSecs int64) []*mediaconvert.OutputGroup {
+func outputGroups(hlsOutputFile, mp4OutputFile string, profiles []video.EncodedProfile, segmentSizeSecs int64, dropAudio bool) []*mediaconvert.OutputGroup {
 	var groups []*mediaconvert.OutputGroup
 	if hlsOutputFile != "" {
 		groups = append(groups, &mediaconvert.OutputGroup{
@@ -382,7 +388,7 @@ func outputGroups(hlsOutputFile, mp4OutputFile string, profiles []video.EncodedP
 				},
 				Type: aws.String("HLS_GROUP_SETTINGS"),
 			},
-			Outputs:    outputs("M3U8", profiles),
+			Outputs:    outputs("M3U8", profiles, dropAudio),
 			CustomName: aws.String("hls"),
 		})
 	}
@@ -398,21 +404,25 @@ func outputGroups(hlsOutputFile, mp4OutputFile string, profiles []video.EncodedP
 				},
 				Type: aws.String("FILE_GROUP_SETTINGS"),
 			},
-			Outputs:    outputs("MP4", profiles),
+			Outputs:    outputs("MP4", profiles, dropAudio),
 			CustomName: aws.String("mp4"),
 		})
 	}
 	return groups
 }
 
-func outputs(container string, profiles []video.EncodedProfile) []*mediaconvert.Output {
+func outputs(container string, profiles []video.EncodedProfile, dropAudio bool) []*mediaconvert.Output {
 	// If we don't have any video profiles, it means we're in audio-only mode
 	if len(profiles) == 0 {
 		return audioOnlyOutputs(container, "audioonly")
 	} else {
 		outs := make([]*mediaconvert.Output, 0, len(profiles))
 		for _, profile := range profiles {
-			outs = append(outs, output(container, profile.Name, profile.Height, profile.Bitrate))
+			out := output(container, profile.Name, profile.Height, profile.Bitrate)
+			if dropAudio {
+				out.AudioDescriptions = nil
+			}
+			outs = append(outs, out)
 		}
 		return outs
 	}
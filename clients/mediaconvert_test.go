@@ -252,13 +252,29 @@ func Test_createJobPayload(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			actual := createJobPayload(inputFile, hlsOutputFile, tt.args.mp4OutputFile, role, tt.args.accelerated, tt.args.profiles, config.DefaultSegmentSizeSecs)
+			actual := createJobPayload(inputFile, hlsOutputFile, tt.args.mp4OutputFile, role, tt.args.accelerated, tt.args.profiles, config.DefaultSegmentSizeSecs, nil)
 			require.NotNil(t, actual)
 			require.Equal(t, loadFixture(t, tt.want, actual.String()), actual.String())
 		})
 	}
 }
 
+func Test_createJobPayloadAudioOptions(t *testing.T) {
+	track := 2
+	payload := createJobPayload("input", "output", "mp4out", "role", false, video.DefaultTranscodeProfiles, config.DefaultSegmentSizeSecs, &video.AudioOptions{Track: &track})
+	selector := payload.Settings.Inputs[0].AudioSelectors["Audio Selector 1"]
+	require.Equal(t, []*int64{aws.Int64(3)}, selector.Tracks, "MediaConvert numbers tracks from 1")
+	require.NotEmpty(t, payload.Settings.OutputGroups[0].Outputs[0].AudioDescriptions)
+
+	payload = createJobPayload("input", "output", "mp4out", "role", false, video.DefaultTranscodeProfiles, config.DefaultSegmentSizeSecs, &video.AudioOptions{Drop: true})
+	require.Nil(t, payload.Settings.Inputs[0].AudioSelectors["Audio Selector 1"].Tracks)
+	for _, group := range payload.Settings.OutputGroups {
+		for _, output := range group.Outputs {
+			require.Empty(t, output.AudioDescriptions)
+		}
+	}
+}
+
 func Test_MP4OutDurationCheck(t *testing.T) {
 	require := require.New(t)
 
@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"github.com/golang/glog"
+	"github.com/livepeer/catalyst-api/log"
 	"github.com/livepeer/catalyst-api/metrics"
 	"github.com/patrickmn/go-cache"
 )
@@ -30,6 +31,8 @@ const (
 
 type MistAPIClient interface {
 	AddStream(streamName, sourceUrl string) error
+	AddStreamWithDVR(streamName, sourceUrl string, dvrWindow time.Duration) error
+	AddStreamWithSettings(streamName string, stream Stream) error
 	PushAutoAdd(streamName, targetURL string) error
 	PushAutoRemove(streamParams []interface{}) error
 	PushStop(id int64) error
@@ -37,10 +40,14 @@ type MistAPIClient interface {
 	DeleteStream(streamName string) error
 	NukeStream(streamName string) error
 	StopSessions(streamName string) error
+	GetSessions(streamName string) ([]MistSession, error)
+	StopSessionIDs(sessionIDs []string) error
 	AddTrigger(streamName []string, triggerName, triggerCallback string, sync bool) error
 	DeleteTrigger(streamName []string, triggerName string) error
+	ConfigureProtocol(connector string, options map[string]interface{}) error
 	GetStreamInfo(streamName string) (MistStreamInfo, error)
 	GetState() (MistState, error)
+	GetStreams() (map[string]Stream, error)
 }
 
 type MistClient struct {
@@ -205,6 +212,20 @@ func (p *ActiveStream) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// MistSession is a connection to a stream
+type MistSession struct {
+	ID       string
+	Protocol string
+	// ConnectedSecs is how long the session has been connected for
+	ConnectedSecs int64
+	Host          string
+}
+
+// IsViewer tells whether the session is playing the stream back, rather than pushing it into or out of Mist
+func (s MistSession) IsViewer() bool {
+	return !strings.HasPrefix(s.Protocol, "INPUT") && !strings.HasPrefix(s.Protocol, "OUTPUT")
+}
+
 type MistStreamStats struct {
 	Clients     int
 	MediaTimeMs int64
@@ -250,6 +271,18 @@ func (mc *MistClient) AddStream(streamName, sourceUrl string) error {
 	return wrapErr(validateAddStream(mc.sendCommand(c)), streamName)
 }
 
+// AddStreamWithDVR configures a stream like AddStream, keeping dvrWindow of its live buffer for playback
+func (mc *MistClient) AddStreamWithDVR(streamName, sourceUrl string, dvrWindow time.Duration) error {
+	c := commandAddStreamWithDVR(streamName, sourceUrl, dvrWindow)
+	return wrapErr(validateAddStream(mc.sendCommand(c)), streamName)
+}
+
+// AddStreamWithSettings configures a stream with all the settings of stream, replacing its previous config
+func (mc *MistClient) AddStreamWithSettings(streamName string, stream Stream) error {
+	c := commandAddStreamWithSettings(streamName, stream)
+	return wrapErr(validateAddStream(mc.sendCommand(c)), streamName)
+}
+
 func (mc *MistClient) PushAutoAdd(streamName, targetURL string) error {
 	c := commandPushAutoAdd(streamName, targetURL)
 	return wrapErr(validatePushAutoAdd(mc.sendCommand(c)), streamName)
@@ -284,6 +317,8 @@ func (mc *MistClient) DeleteStream(streamName string) error {
 	// Need to send both 'deletestream' and 'nuke_stream' in order to remove stream with all configuration and processes
 	deleteErr := wrapErr(validateDeleteStream(mc.sendCommand(commandDeleteStream(streamName))), streamName)
 	nukeErr := wrapErr(validateNukeStream(mc.sendCommand(commandNukeStream(streamName))), streamName)
+	// the cached state would still list the stream
+	mc.cache.Delete(stateCacheKey)
 	if deleteErr != nil || nukeErr != nil {
 		return fmt.Errorf("deleting stream failed, 'deletestream' command err: %v, 'nuke_stream' command err: %v", deleteErr, nukeErr)
 	}
@@ -306,6 +341,53 @@ func (mc *MistClient) StopSessions(streamName string) error {
 	return nil
 }
 
+// GetSessions returns the sessions connected to the stream `streamName`, i.e. its viewers, inputs and outputs
+func (mc *MistClient) GetSessions(streamName string) ([]MistSession, error) {
+	c := commandClients(streamName)
+	resp, err := mc.sendCommand(c)
+	if err := validateAuth(resp, err); err != nil {
+		return nil, err
+	}
+
+	r := struct {
+		Clients struct {
+			Fields []string            `json:"fields"`
+			Data   [][]json.RawMessage `json:"data"`
+		} `json:"clients"`
+	}{}
+	if err := json.Unmarshal([]byte(resp), &r); err != nil {
+		return nil, err
+	}
+
+	// Mist returns every session as an array of the values of the fields it lists
+	sessions := make([]MistSession, 0, len(r.Clients.Data))
+	for _, d := range r.Clients.Data {
+		var s MistSession
+		values := map[string]interface{}{"sessid": &s.ID, "protocol": &s.Protocol, "conntime": &s.ConnectedSecs, "host": &s.Host}
+		for i, field := range r.Clients.Fields {
+			if v, ok := values[field]; ok && i < len(d) {
+				if err := json.Unmarshal(d[i], v); err != nil {
+					return nil, fmt.Errorf("error parsing %s of Mist session: %w", field, err)
+				}
+			}
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
+}
+
+// StopSessionIDs disconnects the sessions with the given IDs, without invalidating the others of their streams
+func (mc *MistClient) StopSessionIDs(sessionIDs []string) error {
+	if len(sessionIDs) == 0 {
+		return nil
+	}
+	c := commandStopSessionIDs(sessionIDs)
+	if err := validateAuth(mc.sendCommand(c)); err != nil {
+		return err
+	}
+	return nil
+}
+
 // AddTrigger adds a trigger `triggerName` for the stream `streamName`.
 // Note that Mist API supports only overriding the whole trigger configuration, therefore this function needs to:
 // 1. Acquire a lock
@@ -347,14 +429,8 @@ func (mc *MistClient) DeleteTrigger(streamNames []string, triggerName string) er
 }
 
 func (mc *MistClient) getCurrentTriggers() (Triggers, error) {
-	c := commandGetTriggers()
-	resp, err := mc.sendCommand(c)
-	if err := validateAuth(resp, err); err != nil {
-		return nil, err
-	}
-
-	cc := MistConfig{}
-	if err := json.Unmarshal([]byte(resp), &cc); err != nil {
+	cc, err := mc.getCurrentConfig()
+	if err != nil {
 		return nil, err
 	}
 
@@ -365,12 +441,44 @@ func (mc *MistClient) getCurrentTriggers() (Triggers, error) {
 	return cc.Config.Triggers, nil
 }
 
+// ConfigureProtocol sets options of the Mist connector with the given name, e.g. HLS, keeping its other options.
+// The connector is added if it isn't configured yet.
+func (mc *MistClient) ConfigureProtocol(connector string, options map[string]interface{}) error {
+	mc.configMu.Lock()
+	defer mc.configMu.Unlock()
+
+	cc, err := mc.getCurrentConfig()
+	if err != nil {
+		return err
+	}
+	c := commandConfigureProtocol(connector, options, cc.Config.Protocols)
+	resp, err := mc.sendCommand(c)
+	if err := validateAuth(resp, err); err != nil {
+		return fmt.Errorf("error configuring Mist %s connector: %w", connector, err)
+	}
+	return nil
+}
+
+func (mc *MistClient) getCurrentConfig() (MistConfig, error) {
+	c := commandGetConfig()
+	resp, err := mc.sendCommand(c)
+	if err := validateAuth(resp, err); err != nil {
+		return MistConfig{}, err
+	}
+
+	cc := MistConfig{}
+	if err := json.Unmarshal([]byte(resp), &cc); err != nil {
+		return MistConfig{}, err
+	}
+	return cc, nil
+}
+
 func (mc *MistClient) sendCommand(command interface{}) (string, error) {
 	resp, err := mc.sendCommandToMist(command)
 	if authErr := validateAuth(resp, err); authErr != nil {
-		glog.Infof("Request to Mist not authorized, authorizing and retrying command: %v", command)
+		log.LogNoRequestID("Request to Mist not authorized, authorizing and retrying command", "command", fmt.Sprintf("%v", command))
 		if authErr := mc.authorize(resp); authErr != nil {
-			glog.Warningf("Failed to authorize Mist request: %v", authErr)
+			log.LogNoRequestID("Failed to authorize Mist request", "err", authErr)
 			return resp, err
 		}
 		return mc.sendCommandToMist(command)
@@ -496,6 +604,16 @@ func (mc *MistClient) GetState() (MistState, error) {
 	return stats, nil
 }
 
+// GetStreams returns the streams in the Mist config, i.e. the ones added with AddStream and the wildcard streams
+// they're based on
+func (mc *MistClient) GetStreams() (map[string]Stream, error) {
+	cc, err := mc.getCurrentConfig()
+	if err != nil {
+		return nil, err
+	}
+	return cc.Streams, nil
+}
+
 type authorizeCommand struct {
 	Authorize Authorize `json:"authorize"`
 }
@@ -520,6 +638,16 @@ type addStreamCommand struct {
 
 type Stream struct {
 	Source string `json:"source"`
+	// DVR is the length of the live buffer in milliseconds, Mist's default if 0
+	DVR int64 `json:"DVR,omitempty"`
+	// SegmentSize is the target duration of the segments in milliseconds, Mist's default if 0
+	SegmentSize int64 `json:"segmentsize,omitempty"`
+	// Video and Audio are the track selectors used for the viewers that don't select tracks themselves
+	Video string   `json:"video,omitempty"`
+	Audio string   `json:"audio,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+	// Processes are run on the stream by Mist, e.g. the Livepeer process transcoding it
+	Processes []map[string]interface{} `json:"processes,omitempty"`
 }
 
 func commandAddStream(name, url string) interface{} {
@@ -532,6 +660,25 @@ func commandAddStream(name, url string) interface{} {
 	}
 }
 
+func commandAddStreamWithDVR(name, url string, dvrWindow time.Duration) interface{} {
+	return addStreamCommand{
+		Addstream: map[string]Stream{
+			name: {
+				Source: url,
+				DVR:    dvrWindow.Milliseconds(),
+			},
+		},
+	}
+}
+
+func commandAddStreamWithSettings(name string, stream Stream) interface{} {
+	return addStreamCommand{
+		Addstream: map[string]Stream{
+			name: stream,
+		},
+	}
+}
+
 type invalidateSessionsCommand struct {
 	InvalidateSessions string `json:"invalidate_sessions"`
 }
@@ -572,6 +719,34 @@ func commandStopSessions(name string) stopSessionsCommand {
 	}
 }
 
+type stopSessionIDsCommand struct {
+	StopSessID []string `json:"stop_sessid"`
+}
+
+func commandStopSessionIDs(sessionIDs []string) stopSessionIDsCommand {
+	return stopSessionIDsCommand{
+		StopSessID: sessionIDs,
+	}
+}
+
+type clientsCommand struct {
+	Clients Clients `json:"clients"`
+}
+
+type Clients struct {
+	Streams []string `json:"streams"`
+	Fields  []string `json:"fields"`
+}
+
+func commandClients(streamName string) clientsCommand {
+	return clientsCommand{
+		Clients: Clients{
+			Streams: []string{streamName},
+			Fields:  []string{"sessid", "protocol", "conntime", "host"},
+		},
+	}
+}
+
 type pushAutoAddCommand struct {
 	PushAutoAdd PushAutoAdd `json:"push_auto_add"`
 }
@@ -612,6 +787,8 @@ func commandPushStop(id int64) pushStopCommand {
 
 type MistConfig struct {
 	Config Config `json:"config"`
+	// Streams are only read, they're changed with the addstream and deletestream commands
+	Streams map[string]Stream `json:"streams,omitempty"`
 }
 
 type ConfigTrigger struct {
@@ -624,6 +801,8 @@ type Triggers map[string][]ConfigTrigger
 
 type Config struct {
 	Triggers map[string][]ConfigTrigger `json:"triggers,omitempty"`
+	// Protocols are only read, they're changed with the addprotocol and updateprotocol commands
+	Protocols []map[string]interface{} `json:"protocols,omitempty"`
 }
 
 func commandAddTrigger(streamNames []string, triggerName, handlerUrl string, currentTriggers Triggers, sync bool) MistConfig {
@@ -649,7 +828,7 @@ func commandUpdateTrigger(streamNames []string, triggerName string, currentTrigg
 	}
 
 	triggersMap[triggerName] = triggers
-	return MistConfig{Config{Triggers: triggersMap}}
+	return MistConfig{Config: Config{Triggers: triggersMap}}
 }
 
 func deleteAllTriggersFor(triggers []ConfigTrigger, streamNames []string) []ConfigTrigger {
@@ -671,11 +850,49 @@ func sameStringSlice(s1, s2 []string) bool {
 	return reflect.DeepEqual(s1, s2)
 }
 
-func commandGetTriggers() MistConfig {
+func commandGetConfig() MistConfig {
 	// send an empty config struct returns the current Mist configuration
 	return MistConfig{}
 }
 
+type addProtocolCommand struct {
+	AddProtocol map[string]interface{} `json:"addprotocol"`
+}
+
+type updateProtocolCommand struct {
+	// the protocol as currently configured, followed by its new config
+	UpdateProtocol [2]map[string]interface{} `json:"updateprotocol"`
+}
+
+func commandConfigureProtocol(connector string, options map[string]interface{}, currentProtocols []map[string]interface{}) interface{} {
+	for _, current := range currentProtocols {
+		if current["connector"] != connector {
+			continue
+		}
+		updated := map[string]interface{}{}
+		for k, v := range current {
+			// read-only fields reported by Mist
+			if k != "online" && k != "online_since" {
+				updated[k] = v
+			}
+		}
+		old := map[string]interface{}{}
+		for k, v := range updated {
+			old[k] = v
+		}
+		for k, v := range options {
+			updated[k] = v
+		}
+		return updateProtocolCommand{UpdateProtocol: [2]map[string]interface{}{old, updated}}
+	}
+
+	added := map[string]interface{}{"connector": connector}
+	for k, v := range options {
+		added[k] = v
+	}
+	return addProtocolCommand{AddProtocol: added}
+}
+
 type stateCommand struct {
 	ActiveStreams []string `json:"active_streams"`
 	StatsStreams  []string `json:"stats_streams"`
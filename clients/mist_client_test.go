@@ -25,6 +25,14 @@ func TestRequestPayload(t *testing.T) {
 			"command=%7B%22addstream%22%3A%7B%22somestream%22%3A%7B%22source%22%3A%22http%3A%2F%2Fsome-storage-url.com%2Fvod.mp4%22%7D%7D%7D",
 			commandAddStream("somestream", "http://some-storage-url.com/vod.mp4"),
 		},
+		{
+			"command=%7B%22addstream%22%3A%7B%22video%2Babc%22%3A%7B%22source%22%3A%22push%3A%2F%2F%22%2C%22DVR%22%3A7200000%7D%7D%7D",
+			commandAddStreamWithDVR("video+abc", "push://", 2*time.Hour),
+		},
+		{
+			"command=%7B%22addstream%22%3A%7B%22video%2Babc%22%3A%7B%22source%22%3A%22push%3A%2F%2F%22%2C%22DVR%22%3A7200000%2C%22segmentsize%22%3A2000%2C%22video%22%3A%22maxbps%22%2C%22tags%22%3A%5B%22sports%22%5D%7D%7D%7D",
+			commandAddStreamWithSettings("video+abc", Stream{Source: "push://", DVR: 7200000, SegmentSize: 2000, Video: "maxbps", Tags: []string{"sports"}}),
+		},
 		{
 			"command=%7B%22push_auto_add%22%3A%7B%22stream%22%3A%22somestream%22%2C%22target%22%3A%22http%3A%2F%2Fsome-target-url.com%2Ftarget.mp4%22%7D%7D",
 			commandPushAutoAdd("somestream", "http://some-target-url.com/target.mp4"),
@@ -203,6 +211,35 @@ func TestCommandDeleteTrigger_EmptyStream(t *testing.T) {
 	require.Equal(c.Config.Triggers[tr][1].Handler, "http://onemoreotherstream.com/")
 }
 
+func TestCommandConfigureProtocol(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	currentProtocols := []map[string]interface{}{
+		{"connector": "RTMP", "online": 1},
+		{"connector": "HLS", "port": 8080, "online": 1, "online_since": 1700000000},
+	}
+
+	// when
+	c := commandConfigureProtocol("HLS", map[string]interface{}{"chunkpath": "seg"}, currentProtocols)
+
+	// then
+	require.Equal(updateProtocolCommand{UpdateProtocol: [2]map[string]interface{}{
+		{"connector": "HLS", "port": 8080},
+		{"connector": "HLS", "port": 8080, "chunkpath": "seg"},
+	}}, c)
+}
+
+func TestCommandConfigureProtocol_NotConfigured(t *testing.T) {
+	require := require.New(t)
+
+	// when
+	c := commandConfigureProtocol("HLS", map[string]interface{}{"chunkpath": "seg"}, []map[string]interface{}{{"connector": "RTMP"}})
+
+	// then
+	require.Equal(addProtocolCommand{AddProtocol: map[string]interface{}{"connector": "HLS", "chunkpath": "seg"}}, c)
+}
+
 func TestResponseValidation(t *testing.T) {
 	require := require.New(t)
 
@@ -416,6 +453,39 @@ func TestItCanGetStreamStats(t *testing.T) {
 	require.Equal(t, 2, callCount)
 }
 
+func TestItCanGetSessions(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.Equal(t, string(body), "command="+url.QueryEscape(`{"clients":{"streams":["video+abc123"],"fields":["sessid","protocol","conntime","host"]}}`))
+
+		// Mist can return the fields in a different order than requested
+		_, err = w.Write([]byte(`{
+			"authorize": {"status": "OK"},
+			"clients": {
+				"fields": ["host", "sessid", "protocol", "conntime"],
+				"data": [
+					["1.2.3.4", "a1", "HLS", 600],
+					["5.6.7.8", "b2", "INPUT:RTMP", 1200]
+				],
+				"time": 1700000000
+			}
+		}`))
+		require.NoError(t, err)
+	}))
+	defer svr.Close()
+
+	mc := &MistClient{ApiUrl: svr.URL, httpClient: http.DefaultClient}
+	sessions, err := mc.GetSessions("video+abc123")
+	require.NoError(t, err)
+	require.Equal(t, []MistSession{
+		{ID: "a1", Protocol: "HLS", ConnectedSecs: 600, Host: "1.2.3.4"},
+		{ID: "b2", Protocol: "INPUT:RTMP", ConnectedSecs: 1200, Host: "5.6.7.8"},
+	}, sessions)
+	require.True(t, sessions[0].IsViewer())
+	require.False(t, sessions[1].IsViewer())
+}
+
 func TestUnmarshalJSONArray(t *testing.T) {
 	var str string
 	var num int
@@ -0,0 +1,293 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/cenkalti/backoff/v4"
+	catErrs "github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/livepeer/catalyst-api/metrics"
+	"github.com/livepeer/go-tools/drivers"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/api/option"
+)
+
+// UploadPartSize is the size of the parts that large files are uploaded in, set with -upload-part-size
+var UploadPartSize int64 = 64 * 1024 * 1024
+
+// UploadPartConcurrency is how many parts of a file are uploaded to S3 at once, set with -upload-part-concurrency
+var UploadPartConcurrency = 8
+
+const (
+	// S3's limits on multipart uploads: every part but the last must be at least 5MiB, and there can be 10000 parts
+	minUploadPartSize = 5 * 1024 * 1024
+	maxUploadParts    = 10000
+	// the default region of the S3 compatible stores whose region can't be derived from their host, as in go-tools
+	defaultS3Region = "us-east-1"
+)
+
+// UploadFileToOSURL uploads a local file to the object store. Files larger than a part are uploaded in parts, each
+// retried on its own rather than restarting the whole upload: in parallel with a multipart upload to S3 compatible
+// stores, and in sequence with a resumable upload to Google Cloud Storage. Smaller files and other stores go through
+// UploadToOSURLFields in a single request.
+func UploadFileToOSURL(osURL, filename, localPath string, timeout time.Duration, fields *drivers.FileProperties) error {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to open %s to upload: %w", localPath, err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %s to upload: %w", localPath, err)
+	}
+
+	size := info.Size()
+	partSize := uploadPartSize(size)
+	if u, err := url.Parse(driverURL(osURL)); err == nil && size > partSize {
+		// the limits are looked up by the URL as given, as the driver URL can have credentials added
+		bw := bandwidthFor(joinOutputURL(osURL, filename), osOperationWrite)
+		switch u.Scheme {
+		case "s3", "s3+http", "s3+https":
+			return uploadS3Multipart(u, filename, f, size, partSize, timeout, fields, bw)
+		case "gs":
+			return uploadGCSResumable(u, filename, f, partSize, timeout, fields, bw)
+		}
+	}
+	return UploadToOSURLFields(osURL, filename, f, timeout, fields)
+}
+
+// uploadPartSize returns the configured part size, raised to fit S3's limits for a file of the given size
+func uploadPartSize(size int64) int64 {
+	partSize := UploadPartSize
+	if partSize < minUploadPartSize {
+		partSize = minUploadPartSize
+	}
+	if minForSize := (size + maxUploadParts - 1) / maxUploadParts; partSize < minForSize {
+		partSize = minForSize
+	}
+	return partSize
+}
+
+func uploadS3Multipart(u *url.URL, filename string, f io.ReaderAt, size, partSize int64, timeout time.Duration, fields *drivers.FileProperties, bw transferBandwidth) error {
+	provider := storageProvider(u.String())
+	client, bucket, key, err := newS3Client(u, filename)
+	if err != nil {
+		metrics.Metrics.ObjectStore.Errors.WithLabelValues(provider, osOperationWrite, osErrorInvalidURL).Inc()
+		return fmt.Errorf("failed to parse OS URL %q: %w", log.RedactURL(u.String()), err)
+	}
+	redactedURL := log.RedactURL(u.JoinPath(filename).String())
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err = func() error {
+		create := &s3.CreateMultipartUploadInput{
+			Bucket:      aws.String(bucket),
+			Key:         aws.String(key),
+			ContentType: aws.String(uploadContentType(filename, fields)),
+		}
+		if fields != nil {
+			if fields.CacheControl != "" {
+				create.CacheControl = aws.String(fields.CacheControl)
+			}
+			if len(fields.Metadata) > 0 {
+				create.Metadata = aws.StringMap(fields.Metadata)
+			}
+		}
+		var upload *s3.CreateMultipartUploadOutput
+		err := backoff.Retry(func() (err error) {
+			upload, err = client.CreateMultipartUploadWithContext(ctx, create)
+			return err
+		}, backoff.WithContext(UploadRetryBackoff(), ctx))
+		if err != nil {
+			return fmt.Errorf("failed to start multipart upload: %w", err)
+		}
+
+		parts, err := uploadS3Parts(ctx, client, upload, f, size, partSize, provider, bw)
+		if err != nil {
+			// abort with a fresh context so that the parts aren't left behind, and billed, when the upload times out
+			abortCtx, abortCancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer abortCancel()
+			if _, abortErr := client.AbortMultipartUploadWithContext(abortCtx, &s3.AbortMultipartUploadInput{
+				Bucket:   upload.Bucket,
+				Key:      upload.Key,
+				UploadId: upload.UploadId,
+			}); abortErr != nil {
+				log.LogNoRequestID("failed to abort multipart upload", "url", redactedURL, "err", abortErr)
+			}
+			return err
+		}
+
+		complete := &s3.CompleteMultipartUploadInput{
+			Bucket:          upload.Bucket,
+			Key:             upload.Key,
+			UploadId:        upload.UploadId,
+			MultipartUpload: &s3.CompletedMultipartUpload{Parts: parts},
+		}
+		return backoff.Retry(func() error {
+			_, err := client.CompleteMultipartUploadWithContext(ctx, complete)
+			return err
+		}, backoff.WithContext(UploadRetryBackoff(), ctx))
+	}()
+	observeStorageOperation(provider, osOperationWrite, start, err)
+
+	if err != nil {
+		metrics.Metrics.ObjectStoreClient.FailureCount.WithLabelValues(u.Host, "write", bucket).Inc()
+		return catErrs.WithFailureReason(catErrs.FailureUpload, fmt.Errorf("failed to write to OS URL %q: %w", redactedURL, err))
+	}
+	metrics.Metrics.ObjectStoreClient.RequestDuration.WithLabelValues(u.Host, "write", bucket).Observe(time.Since(start).Seconds())
+	return nil
+}
+
+// uploadS3Parts uploads the parts of a file in parallel, retrying each of them, and returns them in order for the
+// completion of the upload. Each attempt of a part takes its bytes from the bandwidth limits up front, as the SDK
+// re-reads the body of a part to sign it.
+func uploadS3Parts(ctx context.Context, client *s3.S3, upload *s3.CreateMultipartUploadOutput, f io.ReaderAt, size, partSize int64, provider string, bw transferBandwidth) ([]*s3.CompletedPart, error) {
+	numParts := int((size + partSize - 1) / partSize)
+	parts := make([]*s3.CompletedPart, numParts)
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(max(UploadPartConcurrency, 1))
+	for i := 0; i < numParts; i++ {
+		partNumber := int64(i + 1)
+		offset := int64(i) * partSize
+		length := min(partSize, size-offset)
+		i := i
+		g.Go(func() error {
+			var etag *string
+			err := backoff.Retry(func() error {
+				if err := bw.wait(ctx, length); err != nil {
+					return backoff.Permanent(err)
+				}
+				out, err := client.UploadPartWithContext(ctx, &s3.UploadPartInput{
+					Bucket:        upload.Bucket,
+					Key:           upload.Key,
+					UploadId:      upload.UploadId,
+					PartNumber:    aws.Int64(partNumber),
+					Body:          io.NewSectionReader(f, offset, length),
+					ContentLength: aws.Int64(length),
+				})
+				if err != nil {
+					return err
+				}
+				etag = out.ETag
+				return nil
+			}, backoff.WithContext(UploadRetryBackoff(), ctx))
+			if err != nil {
+				return fmt.Errorf("failed to upload part %d: %w", partNumber, err)
+			}
+			metrics.Metrics.ObjectStore.Bytes.WithLabelValues(provider, osOperationWrite).Add(float64(length))
+			parts[i] = &s3.CompletedPart{ETag: etag, PartNumber: aws.Int64(partNumber)}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return parts, nil
+}
+
+// newS3Client returns a client for the S3 compatible store of an OS URL, with the bucket and key of the file, parsing
+// the URL the way go-tools' drivers do
+func newS3Client(u *url.URL, filename string) (client *s3.S3, bucket, key string, err error) {
+	secret, ok := u.User.Password()
+	if !ok {
+		return nil, "", "", errors.New("password is required with s3:// OS")
+	}
+	bucket, keyPrefix, _ := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	if bucket == "" {
+		return nil, "", "", errors.New("S3 bucket not found in URL path")
+	}
+
+	// the parts are retried by the uploader rather than by the SDK, so that their retries are counted and backed off
+	// like the other uploads
+	cfg := aws.NewConfig().
+		WithCredentials(credentials.NewStaticCredentials(u.User.Username(), secret, "")).
+		WithMaxRetries(0)
+	if u.Scheme == "s3" {
+		cfg = cfg.WithRegion(u.Host)
+	} else {
+		cfg = cfg.WithRegion(s3Region(u.Hostname())).
+			WithEndpoint(u.Host).
+			WithS3ForcePathStyle(true).
+			WithDisableSSL(u.Scheme != "s3+https")
+	}
+	sess, err := session.NewSession(cfg)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return s3.New(sess), bucket, path.Join(keyPrefix, filename), nil
+}
+
+// s3Region derives the region of an S3 compatible store from its host, e.g. us-west-2 for s3.us-west-2.example.com
+func s3Region(host string) string {
+	if parts := strings.Split(host, "."); len(parts) >= 4 {
+		return parts[1]
+	}
+	return defaultS3Region
+}
+
+// uploadGCSResumable uploads a file to Google Cloud Storage in chunks of a part size, each retried on its own
+func uploadGCSResumable(u *url.URL, filename string, f io.Reader, partSize int64, timeout time.Duration, fields *drivers.FileProperties, bw transferBandwidth) error {
+	provider := storageProvider(u.String())
+	bucket := u.Host
+	key := path.Join(strings.TrimPrefix(u.Path, "/"), filename)
+	redactedURL := log.RedactURL(u.JoinPath(filename).String())
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := func() error {
+		client, err := storage.NewClient(ctx, option.WithCredentialsJSON([]byte(u.User.Username())))
+		if err != nil {
+			return fmt.Errorf("failed to create GCS client: %w", err)
+		}
+		defer client.Close()
+
+		// chunks are only retried with a policy that retries every upload, as uploads aren't idempotent
+		obj := client.Bucket(bucket).Object(key).Retryer(storage.WithPolicy(storage.RetryAlways))
+		w := obj.NewWriter(ctx)
+		w.ChunkSize = int(partSize)
+		w.ContentType = uploadContentType(filename, fields)
+		if fields != nil {
+			w.CacheControl = fields.CacheControl
+			w.Metadata = fields.Metadata
+		}
+		if _, err := io.Copy(w, storageBytesReader{bw.reader(ctx, f), provider, osOperationWrite}); err != nil {
+			w.Close()
+			return err
+		}
+		return w.Close()
+	}()
+	observeStorageOperation(provider, osOperationWrite, start, err)
+
+	if err != nil {
+		metrics.Metrics.ObjectStoreClient.FailureCount.WithLabelValues(u.Host, "write", bucket).Inc()
+		return catErrs.WithFailureReason(catErrs.FailureUpload, fmt.Errorf("failed to write to OS URL %q: %w", redactedURL, err))
+	}
+	metrics.Metrics.ObjectStoreClient.RequestDuration.WithLabelValues(u.Host, "write", bucket).Observe(time.Since(start).Seconds())
+	return nil
+}
+
+func uploadContentType(filename string, fields *drivers.FileProperties) string {
+	if fields != nil && fields.ContentType != "" {
+		return fields.ContentType
+	}
+	if contentType, err := drivers.TypeByExtension(path.Ext(filename)); err == nil {
+		return contentType
+	}
+	return "application/octet-stream"
+}
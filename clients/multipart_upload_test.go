@@ -0,0 +1,157 @@
+package clients
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 implements the multipart upload requests of the S3 API, failing the first attempt of the parts in failParts
+type fakeS3 struct {
+	mu        sync.Mutex
+	failParts map[int]bool
+	attempts  map[int]int
+	parts     map[int][]byte
+	object    []byte
+	key       string
+	aborted   bool
+}
+
+func newFakeS3(failParts ...int) *fakeS3 {
+	s := &fakeS3{failParts: map[int]bool{}, attempts: map[int]int{}, parts: map[int][]byte{}}
+	for _, p := range failParts {
+		s.failParts[p] = true
+	}
+	return s
+}
+
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	query := r.URL.Query()
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		s.key = strings.TrimPrefix(r.URL.Path, "/bucket/")
+		fmt.Fprintf(w, `<InitiateMultipartUploadResult><Bucket>bucket</Bucket><Key>%s</Key><UploadId>upload-1</UploadId></InitiateMultipartUploadResult>`, s.key)
+	case r.Method == http.MethodPut && query.Get("uploadId") == "upload-1":
+		partNumber, _ := strconv.Atoi(query.Get("partNumber"))
+		s.attempts[partNumber]++
+		body, _ := io.ReadAll(r.Body)
+		if s.failParts[partNumber] && s.attempts[partNumber] == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		s.parts[partNumber] = body
+		w.Header().Set("ETag", fmt.Sprintf(`"etag-%d"`, partNumber))
+	case r.Method == http.MethodPost && query.Get("uploadId") == "upload-1":
+		var complete struct {
+			Parts []struct {
+				PartNumber int
+				ETag       string
+			} `xml:"Part"`
+		}
+		if err := xml.NewDecoder(r.Body).Decode(&complete); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if !sort.SliceIsSorted(complete.Parts, func(i, j int) bool { return complete.Parts[i].PartNumber < complete.Parts[j].PartNumber }) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for _, p := range complete.Parts {
+			s.object = append(s.object, s.parts[p.PartNumber]...)
+		}
+		fmt.Fprintf(w, `<CompleteMultipartUploadResult><Bucket>bucket</Bucket><Key>%s</Key></CompleteMultipartUploadResult>`, s.key)
+	case r.Method == http.MethodDelete && query.Get("uploadId") == "upload-1":
+		s.aborted = true
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+func writeRandomFile(t *testing.T, size int) (string, []byte) {
+	content := make([]byte, size)
+	_, err := rand.Read(content)
+	require.NoError(t, err)
+	localPath := filepath.Join(t.TempDir(), "output.mp4")
+	require.NoError(t, os.WriteFile(localPath, content, 0644))
+	return localPath, content
+}
+
+func TestUploadFileToOSURLMultipart(t *testing.T) {
+	defer func(size int64) { UploadPartSize = size }(UploadPartSize)
+	UploadPartSize = minUploadPartSize
+	s3 := newFakeS3(2)
+	server := httptest.NewServer(s3)
+	defer server.Close()
+
+	localPath, content := writeRandomFile(t, 3*minUploadPartSize+123)
+	osURL := "s3+http://user:pass@" + strings.TrimPrefix(server.URL, "http://") + "/bucket/recordings/abc"
+	err := UploadFileToOSURL(osURL, "output.mp4", localPath, time.Minute, nil)
+	require.NoError(t, err)
+
+	require.Equal(t, "recordings/abc/output.mp4", s3.key)
+	require.Len(t, s3.parts, 4)
+	require.Equal(t, 2, s3.attempts[2], "the failed part should be retried on its own")
+	require.Equal(t, 1, s3.attempts[1])
+	require.True(t, bytes.Equal(content, s3.object))
+	require.False(t, s3.aborted)
+}
+
+func TestUploadFileToOSURLAbortsFailedMultipart(t *testing.T) {
+	defer func(size int64) { UploadPartSize = size }(UploadPartSize)
+	UploadPartSize = minUploadPartSize
+	s3 := newFakeS3()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("partNumber") == "3" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		s3.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	localPath, _ := writeRandomFile(t, 3*minUploadPartSize)
+	osURL := "s3+http://user:pass@" + strings.TrimPrefix(server.URL, "http://") + "/bucket"
+	err := UploadFileToOSURL(osURL, "output.mp4", localPath, 5*time.Second, nil)
+	require.Error(t, err)
+	require.True(t, s3.aborted)
+}
+
+func TestUploadFileToOSURLSmallFile(t *testing.T) {
+	localPath, content := writeRandomFile(t, 1024)
+	dir := t.TempDir()
+	require.NoError(t, UploadFileToOSURL(dir, "output.mp4", localPath, time.Minute, nil))
+
+	uploaded, err := os.ReadFile(filepath.Join(dir, "output.mp4"))
+	require.NoError(t, err)
+	require.Equal(t, content, uploaded)
+}
+
+func TestUploadPartSize(t *testing.T) {
+	defer func(size int64) { UploadPartSize = size }(UploadPartSize)
+
+	UploadPartSize = 1024
+	require.Equal(t, int64(minUploadPartSize), uploadPartSize(100*1024*1024))
+
+	UploadPartSize = 64 * 1024 * 1024
+	require.Equal(t, int64(64*1024*1024), uploadPartSize(2*1024*1024*1024))
+	// too many parts for S3
+	size := int64(maxUploadParts) * 100 * 1024 * 1024
+	require.Equal(t, int64(100*1024*1024), uploadPartSize(size))
+}
@@ -19,22 +19,24 @@ import (
 
 var maxRetryInterval = 5 * time.Second
 
+// DownloadOSURL reads the file at the object store URL. The bytes are counted in the metrics as the body is read.
+// A read that fails is resumed with a range request from the byte it stopped at, and the file is verified against its
+// MD5 ETag once read, when it has one.
 func DownloadOSURL(osURL string) (io.ReadCloser, error) {
-	fileInfoReader, err := GetOSURL(osURL, "")
-	if err != nil {
-		return nil, err
-	}
-	return fileInfoReader.Body, nil
+	return newResumableReader(context.Background(), openOSURLRange(osURL), func() {
+		metrics.Metrics.ObjectStore.Retries.WithLabelValues(osOperationRead).Inc()
+	})
 }
 
 func GetOSURL(osURL, byteRange string) (*drivers.FileInfoReader, error) {
-	storageDriver, err := drivers.ParseOSURL(osURL, true)
+	provider := storageProvider(osURL)
+	start := time.Now()
+	storageDriver, err := parseOSURL(osURL)
 	if err != nil {
+		metrics.Metrics.ObjectStore.Errors.WithLabelValues(provider, osOperationRead, osErrorInvalidURL).Inc()
 		return nil, catErrs.Unretriable(fmt.Errorf("failed to parse OS URL %q: %w", log.RedactURL(osURL), err))
 	}
 
-	start := time.Now()
-
 	sess := storageDriver.NewSession("")
 	info := sess.GetInfo()
 	var host, bucket string
@@ -48,6 +50,7 @@ func GetOSURL(osURL, byteRange string) (*drivers.FileInfoReader, error) {
 	} else {
 		fileInfoReader, err = sess.ReadDataRange(context.Background(), "", byteRange)
 	}
+	observeStorageOperation(provider, osOperationRead, start, err)
 
 	if err != nil {
 		metrics.Metrics.ObjectStoreClient.FailureCount.WithLabelValues(host, "read", bucket).Inc()
@@ -62,6 +65,8 @@ func GetOSURL(osURL, byteRange string) (*drivers.FileInfoReader, error) {
 
 	metrics.Metrics.ObjectStoreClient.RequestDuration.WithLabelValues(host, "read", bucket).Observe(duration.Seconds())
 
+	body := bandwidthFor(osURL, osOperationRead).reader(context.Background(), fileInfoReader.Body)
+	fileInfoReader.Body = storageBytesReadCloser{storageBytesReader{body, provider, osOperationRead}, fileInfoReader.Body}
 	return fileInfoReader, nil
 }
 
@@ -70,8 +75,10 @@ func UploadToOSURL(osURL, filename string, data io.Reader, timeout time.Duration
 }
 
 func UploadToOSURLFields(osURL, filename string, data io.Reader, timeout time.Duration, fields *drivers.FileProperties) error {
-	storageDriver, err := drivers.ParseOSURL(osURL, true)
+	provider := storageProvider(osURL)
+	storageDriver, err := parseOSURL(osURL)
 	if err != nil {
+		metrics.Metrics.ObjectStore.Errors.WithLabelValues(provider, osOperationWrite, osErrorInvalidURL).Inc()
 		return fmt.Errorf("failed to parse OS URL %q: %s", log.RedactURL(osURL), err)
 	}
 	start := time.Now()
@@ -84,11 +91,13 @@ func UploadToOSURLFields(osURL, filename string, data io.Reader, timeout time.Du
 		bucket = info.S3Info.Bucket
 	}
 
-	_, err = sess.SaveData(context.Background(), filename, data, fields, timeout)
+	data = bandwidthFor(joinOutputURL(osURL, filename), osOperationWrite).reader(context.Background(), data)
+	_, err = sess.SaveData(context.Background(), filename, storageBytesReader{data, provider, osOperationWrite}, fields, timeout)
+	observeStorageOperation(provider, osOperationWrite, start, err)
 
 	if err != nil {
 		metrics.Metrics.ObjectStoreClient.FailureCount.WithLabelValues(host, "write", bucket).Inc()
-		return fmt.Errorf("failed to write to OS URL %q: %s", log.RedactURL(osURL+"/"+filename), err)
+		return catErrs.WithFailureReason(catErrs.FailureUpload, fmt.Errorf("failed to write to OS URL %q: %s", log.RedactURL(osURL+"/"+filename), err))
 	}
 
 	duration := time.Since(start)
@@ -99,7 +108,7 @@ func UploadToOSURLFields(osURL, filename string, data io.Reader, timeout time.Du
 }
 
 func ListOSURL(ctx context.Context, osURL string) (drivers.PageInfo, error) {
-	osDriver, err := drivers.ParseOSURL(osURL, true)
+	osDriver, err := parseOSURL(osURL)
 	if err != nil {
 		return nil, fmt.Errorf("unexpected error parsing internal driver URL: %w", err)
 	}
@@ -156,7 +165,7 @@ func Publish(hlsTarget string, mp4Target string) (string, string, error) {
 // If driver does not support `Publish()`, e.g. S3, then return the input osUrl, video should be accessible with osUrl.
 // In case of any other error, return an empty string and an error.
 func publishDriverSession(osUrl string, relPath string) (string, error) {
-	osDriver, err := drivers.ParseOSURL(osUrl, true)
+	osDriver, err := parseOSURL(osUrl)
 	if err != nil {
 		return "", err
 	}
@@ -195,20 +204,25 @@ func newExponentialBackOffExecutor() *backoff.ExponentialBackOff {
 }
 
 func UploadRetryBackoff() backoff.BackOff {
-	return backoff.WithMaxRetries(newExponentialBackOffExecutor(), 5)
+	return retryCountingBackOff{backoff.WithMaxRetries(newExponentialBackOffExecutor(), 5), osOperationWrite}
 }
 
 func SignURL(u *url.URL) (string, error) {
+	return SignURLWithTTL(u, PresignDuration)
+}
+
+// SignURLWithTTL returns a signed GET URL of an OS URL that expires after the TTL. Other URLs are returned as they are.
+func SignURLWithTTL(u *url.URL, ttl time.Duration) (string, error) {
 	if u.Scheme == "" || u.Scheme == "file" || u.Scheme == "http" || u.Scheme == "https" { // not an OS url
 		return u.String(), nil
 	}
-	driver, err := drivers.ParseOSURL(u.String(), true)
+	driver, err := parseOSURL(u.String())
 	if err != nil {
 		return "", fmt.Errorf("failed to parse OS url: %w", err)
 	}
 
 	sess := driver.NewSession("")
-	signedURL, err := sess.Presign("", PresignDuration)
+	signedURL, err := sess.Presign("", ttl)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate signed url: %w", err)
 	}
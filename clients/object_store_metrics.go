@@ -0,0 +1,137 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/livepeer/catalyst-api/metrics"
+	"github.com/livepeer/go-tools/drivers"
+)
+
+const (
+	osOperationRead  = "read"
+	osOperationWrite = "write"
+)
+
+// Error classes of the object store metrics
+const (
+	osErrorNone       = ""
+	osErrorInvalidURL = "invalid_url"
+	osErrorNotFound   = "not_found"
+	osErrorTimeout    = "timeout"
+	osErrorCanceled   = "canceled"
+	osErrorAuth       = "auth"
+	osErrorOther      = "other"
+)
+
+// s3Providers maps the hosts of S3 compatible storage to a provider label, so that the metrics aren't labelled by
+// every bucket or region
+var s3Providers = map[string]string{
+	"amazonaws.com":            "aws",
+	"storage.googleapis.com":   "gcs",
+	"storjshare.io":            "storj",
+	"r2.cloudflarestorage.com": "r2",
+	"digitaloceanspaces.com":   "digitalocean",
+	"backblazeb2.com":          "backblaze",
+	"wasabisys.com":            "wasabi",
+	"filebase.com":             "filebase",
+}
+
+// storageProvider returns the provider label of an object store URL
+func storageProvider(osURL string) string {
+	u, err := url.Parse(osURL)
+	if err != nil {
+		return "unknown"
+	}
+	switch u.Scheme {
+	case "s3", "s3+http", "s3+https":
+		host := u.Hostname()
+		for suffix, provider := range s3Providers {
+			if host == suffix || strings.HasSuffix(host, "."+suffix) {
+				return provider
+			}
+		}
+		return "s3"
+	case "gs":
+		return "gcs"
+	case "b2":
+		return "backblaze"
+	case "azblob":
+		return "azure"
+	case "":
+		return "file"
+	}
+	return u.Scheme
+}
+
+// storageErrorClass classifies object store errors for the metrics
+func storageErrorClass(err error) string {
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return osErrorNone
+	case errors.Is(err, drivers.ErrNotExist):
+		return osErrorNotFound
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return osErrorTimeout
+	case errors.Is(err, context.Canceled):
+		return osErrorCanceled
+	}
+	msg := err.Error()
+	for _, authErr := range []string{"AccessDenied", "InvalidAccessKeyId", "SignatureDoesNotMatch", "Forbidden", "Unauthorized"} {
+		if strings.Contains(msg, authErr) {
+			return osErrorAuth
+		}
+	}
+	if strings.Contains(msg, "timeout") {
+		return osErrorTimeout
+	}
+	return osErrorOther
+}
+
+func observeStorageOperation(provider, operation string, start time.Time, err error) {
+	class := storageErrorClass(err)
+	metrics.Metrics.ObjectStore.Duration.WithLabelValues(provider, operation, class).Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.Metrics.ObjectStore.Errors.WithLabelValues(provider, operation, class).Inc()
+	}
+}
+
+// storageBytesReader counts the bytes read from or written to an object store as they're streamed
+type storageBytesReader struct {
+	io.Reader
+	provider, operation string
+}
+
+func (r storageBytesReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if n > 0 {
+		metrics.Metrics.ObjectStore.Bytes.WithLabelValues(r.provider, r.operation).Add(float64(n))
+	}
+	return n, err
+}
+
+type storageBytesReadCloser struct {
+	storageBytesReader
+	io.Closer
+}
+
+// retryCountingBackOff counts the retries of object store operations, i.e. each backoff that isn't a stop
+type retryCountingBackOff struct {
+	backoff.BackOff
+	operation string
+}
+
+func (b retryCountingBackOff) NextBackOff() time.Duration {
+	next := b.BackOff.NextBackOff()
+	if next != backoff.Stop {
+		metrics.Metrics.ObjectStore.Retries.WithLabelValues(b.operation).Inc()
+	}
+	return next
+}
@@ -0,0 +1,35 @@
+package clients
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/livepeer/go-tools/drivers"
+)
+
+// b2EndpointFormat is the S3 compatible endpoint of a Backblaze B2 region
+const b2EndpointFormat = "s3.%s.backblazeb2.com"
+
+// parseOSURL returns the storage driver of an OS URL. Besides go-tools' drivers, azblob:// URLs are handled by the
+// Azure Blob Storage driver, and b2://KEY_ID:APP_KEY@REGION/bucket/path URLs by the S3 driver through Backblaze B2's
+// S3 compatible API.
+func parseOSURL(osURL string) (drivers.OSDriver, error) {
+	osURL = driverURL(osURL)
+	if u, err := url.Parse(osURL); err == nil && u.Scheme == "azblob" {
+		return newAzureBlobDriver(u)
+	}
+	return drivers.ParseOSURL(osURL, true)
+}
+
+// driverURL returns the URL that an OS URL is handed to the drivers with: with the -storage-credentials of the URLs
+// that don't have any, and with b2:// URLs as the S3 URL of their region
+func driverURL(osURL string) string {
+	osURL = withStorageCredentials(osURL)
+	u, err := url.Parse(osURL)
+	if err != nil || u.Scheme != "b2" {
+		return osURL
+	}
+	u.Scheme = "s3+https"
+	u.Host = fmt.Sprintf(b2EndpointFormat, u.Host)
+	return u.String()
+}
@@ -0,0 +1,80 @@
+package clients
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/livepeer/catalyst-api/config"
+	"github.com/livepeer/catalyst-api/log"
+)
+
+// BackupOutput is a file that was written to the backup of its output location, as reported in the completion
+// callback. The URLs are redacted.
+type BackupOutput struct {
+	URL       string `json:"url"`
+	BackupURL string `json:"backup_url"`
+}
+
+var (
+	backupOutputsMu sync.Mutex
+	// backupOutputs holds the files written to a backup until their job completes, by their primary URL
+	backupOutputs = map[string]BackupOutput{}
+)
+
+// UploadWithBackup retries an upload to an output URL and, once its retries are exhausted, to the backup of the URL
+// from -output-backup-urls. The upload is called with the URL to write to. The files that land in a backup are kept
+// for the completion callback of their job, see TakeBackupOutputs.
+func UploadWithBackup(osURL, filename string, upload func(osURL string) error) error {
+	err := backoff.Retry(func() error { return upload(osURL) }, UploadRetryBackoff())
+	if err == nil {
+		return nil
+	}
+	backupURL := config.GetOutputBackupURL(osURL)
+	if backupURL == "" {
+		return err
+	}
+
+	fileURL := joinOutputURL(osURL, filename)
+	log.LogNoRequestID("upload failed, falling back to the backup output", "url", log.RedactURL(fileURL), "backup_url", log.RedactURL(backupURL), "err", err)
+	if backupErr := backoff.Retry(func() error { return upload(backupURL) }, UploadRetryBackoff()); backupErr != nil {
+		return fmt.Errorf("%w, and to the backup output: %s", err, backupErr)
+	}
+
+	backupOutputsMu.Lock()
+	defer backupOutputsMu.Unlock()
+	backupOutputs[fileURL] = BackupOutput{
+		URL:       log.RedactURL(fileURL),
+		BackupURL: log.RedactURL(joinOutputURL(backupURL, filename)),
+	}
+	return nil
+}
+
+// TakeBackupOutputs returns the files written to a backup under the output URLs of a job, and forgets them
+func TakeBackupOutputs(outputURLs ...*url.URL) []BackupOutput {
+	backupOutputsMu.Lock()
+	defer backupOutputsMu.Unlock()
+
+	var outputs []BackupOutput
+	for fileURL, output := range backupOutputs {
+		for _, u := range outputURLs {
+			if u != nil && strings.HasPrefix(fileURL, u.String()) {
+				outputs = append(outputs, output)
+				delete(backupOutputs, fileURL)
+				break
+			}
+		}
+	}
+	sort.Slice(outputs, func(i, j int) bool { return outputs[i].URL < outputs[j].URL })
+	return outputs
+}
+
+func joinOutputURL(osURL, filename string) string {
+	if filename == "" {
+		return osURL
+	}
+	return strings.TrimSuffix(osURL, "/") + "/" + filename
+}
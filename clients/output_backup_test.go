@@ -0,0 +1,45 @@
+package clients
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/livepeer/catalyst-api/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploadWithBackup(t *testing.T) {
+	dir := t.TempDir()
+	// the primary output can't be written to, as it's a file rather than a directory
+	primary := filepath.Join(dir, "primary")
+	require.NoError(t, os.WriteFile(primary, nil, 0644))
+	backup := filepath.Join(dir, "backup")
+	config.OutputBackupURLs = map[string]string{primary: backup}
+	defer func() { config.OutputBackupURLs = nil }()
+
+	upload := func(osURL string) error {
+		return UploadToOSURL(osURL, "0.ts", strings.NewReader("segment"), time.Minute)
+	}
+	require.NoError(t, UploadWithBackup(primary+"/720p", "0.ts", upload))
+
+	written, err := os.ReadFile(filepath.Join(backup, "720p", "0.ts"))
+	require.NoError(t, err)
+	require.Equal(t, "segment", string(written))
+
+	primaryURL, _ := url.Parse(primary)
+	require.Equal(t, []BackupOutput{{URL: primary + "/720p/0.ts", BackupURL: backup + "/720p/0.ts"}}, TakeBackupOutputs(nil, primaryURL))
+	require.Empty(t, TakeBackupOutputs(primaryURL), "the backup outputs should only be reported once")
+}
+
+func TestUploadWithoutBackup(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, UploadWithBackup(dir, "0.ts", func(osURL string) error {
+		return UploadToOSURL(osURL, "0.ts", strings.NewReader("segment"), time.Minute)
+	}))
+	dirURL, _ := url.Parse(dir)
+	require.Empty(t, TakeBackupOutputs(dirURL))
+}
@@ -0,0 +1,116 @@
+package clients
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/livepeer/catalyst-api/log"
+)
+
+// ChecksumManifestFilename is the sidecar written at the root of each output location, in the format of sha256sum
+const ChecksumManifestFilename = "checksums.sha256"
+
+// OutputChecksum is the SHA-256 of an output file, as reported in the completion callback. The URL is redacted.
+type OutputChecksum struct {
+	URL       string `json:"url"`
+	SHA256    string `json:"sha256"`
+	SizeBytes int64  `json:"size_bytes"`
+
+	// path is relative to the output location, as listed in its checksum manifest
+	path string
+}
+
+var (
+	checksumsMu sync.Mutex
+	// checksums holds the checksums of the files uploaded to outputs until their job completes, by URL
+	checksums = map[string]OutputChecksum{}
+)
+
+// RecordChecksum keeps the SHA-256 of a file uploaded to an output location, for the checksum manifest of its job
+func RecordChecksum(osURL, filename string, data []byte) {
+	sum := sha256.Sum256(data)
+	recordChecksum(joinOutputURL(osURL, filename), hex.EncodeToString(sum[:]), int64(len(data)))
+}
+
+// RecordFileChecksum is RecordChecksum for a local file that was uploaded
+func RecordFileChecksum(osURL, filename, localPath string) error {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := RecordReaderChecksum(osURL, filename, f); err != nil {
+		return fmt.Errorf("failed to compute the checksum of %s: %w", localPath, err)
+	}
+	return nil
+}
+
+// RecordReaderChecksum is RecordChecksum for a file that was uploaded from a reader, read again from its start
+func RecordReaderChecksum(osURL, filename string, r io.Reader) error {
+	h := sha256.New()
+	size, err := io.Copy(h, r)
+	if err != nil {
+		return err
+	}
+	recordChecksum(joinOutputURL(osURL, filename), hex.EncodeToString(h.Sum(nil)), size)
+	return nil
+}
+
+func recordChecksum(fileURL, sum string, size int64) {
+	checksumsMu.Lock()
+	defer checksumsMu.Unlock()
+	checksums[fileURL] = OutputChecksum{URL: log.RedactURL(fileURL), SHA256: sum, SizeBytes: size}
+}
+
+// TakeChecksums returns the checksums of the files uploaded under an output location, and forgets them
+func TakeChecksums(outputURL *url.URL) []OutputChecksum {
+	if outputURL == nil {
+		return nil
+	}
+	prefix := strings.TrimSuffix(outputURL.String(), "/") + "/"
+
+	checksumsMu.Lock()
+	defer checksumsMu.Unlock()
+	var sums []OutputChecksum
+	for fileURL, sum := range checksums {
+		if path, ok := strings.CutPrefix(fileURL, prefix); ok {
+			sum.path = path
+			sums = append(sums, sum)
+			delete(checksums, fileURL)
+		}
+	}
+	sort.Slice(sums, func(i, j int) bool { return sums[i].path < sums[j].path })
+	return sums
+}
+
+// WriteChecksumManifests writes the checksum manifest of each output location of a job, and returns the checksums
+// for the completion callback. A manifest that can't be written is logged rather than failing the job, since the
+// outputs themselves were written.
+func WriteChecksumManifests(requestID string, outputURLs ...*url.URL) []OutputChecksum {
+	var all []OutputChecksum
+	for _, outputURL := range outputURLs {
+		sums := TakeChecksums(outputURL)
+		if len(sums) == 0 {
+			continue
+		}
+		var manifest strings.Builder
+		for _, sum := range sums {
+			fmt.Fprintf(&manifest, "%s  %s\n", sum.SHA256, sum.path)
+		}
+		err := UploadWithBackup(outputURL.String(), ChecksumManifestFilename, func(osURL string) error {
+			return UploadToOSURL(osURL, ChecksumManifestFilename, strings.NewReader(manifest.String()), ManifestUploadTimeout)
+		})
+		if err != nil {
+			log.LogError(requestID, "failed to write checksum manifest", err, "url", log.RedactURL(outputURL.String()))
+		}
+		all = append(all, sums...)
+	}
+	return all
+}
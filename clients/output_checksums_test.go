@@ -0,0 +1,36 @@
+package clients
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteChecksumManifests(t *testing.T) {
+	hlsDir := t.TempDir()
+	mp4Dir := t.TempDir()
+	RecordChecksum(hlsDir, "index.m3u8", []byte("#EXTM3U"))
+	RecordChecksum(hlsDir+"/720p", "0.ts", []byte("segment"))
+	mp4File := filepath.Join(t.TempDir(), "720p.mp4")
+	require.NoError(t, os.WriteFile(mp4File, []byte("mp4"), 0644))
+	require.NoError(t, RecordFileChecksum(mp4Dir, "720p.mp4", mp4File))
+
+	hlsURL, _ := url.Parse(hlsDir)
+	mp4URL, _ := url.Parse(mp4Dir)
+	sums := WriteChecksumManifests("request-id", hlsURL, nil, mp4URL)
+	require.Equal(t, []OutputChecksum{
+		{URL: hlsDir + "/720p/0.ts", SHA256: "03e71c6d7dc6bd4e89ceaf32f6488ca0aa69b0de784b706cac5818d680e9d97f", SizeBytes: 7, path: "720p/0.ts"},
+		{URL: hlsDir + "/index.m3u8", SHA256: "00691126fdad8bc78e8720731ae14addc22e3517d920000fd9979395c86c7bdb", SizeBytes: 7, path: "index.m3u8"},
+		{URL: mp4Dir + "/720p.mp4", SHA256: "862c4ec62defaadafbf7638961214d286015c38ed4ccc7b10d52bdf434e5bee1", SizeBytes: 3, path: "720p.mp4"},
+	}, sums)
+
+	manifest, err := os.ReadFile(filepath.Join(hlsDir, ChecksumManifestFilename))
+	require.NoError(t, err)
+	require.Equal(t, "03e71c6d7dc6bd4e89ceaf32f6488ca0aa69b0de784b706cac5818d680e9d97f  720p/0.ts\n"+
+		"00691126fdad8bc78e8720731ae14addc22e3517d920000fd9979395c86c7bdb  index.m3u8\n", string(manifest))
+
+	require.Empty(t, WriteChecksumManifests("request-id", hlsURL, mp4URL), "the checksums should only be reported once")
+}
@@ -0,0 +1,270 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	catErrs "github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/log"
+)
+
+// DownloadResumeAttempts is how many times a download is resumed from the byte it stopped at before giving up
+var DownloadResumeAttempts = 5
+
+// md5ETagRegex matches the ETags that are the MD5 of the object, as S3 gives them for objects that weren't uploaded in
+// parts
+var md5ETagRegex = regexp.MustCompile(`^"?([0-9a-fA-F]{32})"?$`)
+
+// downloadInfo describes the object being downloaded, to resume it from a range of the same version of the object
+type downloadInfo struct {
+	// resumable is false for the sources that don't support range requests
+	resumable    bool
+	etag         string
+	lastModified string
+	// size is the size of the whole object, -1 when unknown
+	size int64
+	// md5 is the checksum the download is verified against, nil when the source doesn't give one
+	md5 []byte
+}
+
+// rangeOpener opens the object from an offset. The offset is 0 for the first request, which returns the object's info.
+type rangeOpener func(ctx context.Context, offset int64, info downloadInfo) (io.ReadCloser, downloadInfo, error)
+
+// resumableReader resumes a download with a range request when its body fails, rather than having it restart from the
+// first byte, and verifies the bytes read against the checksum of the object once it's read
+type resumableReader struct {
+	ctx     context.Context
+	open    rangeOpener
+	body    io.ReadCloser
+	info    downloadInfo
+	offset  int64
+	resumes int
+	backOff backoff.BackOff
+	hash    hash.Hash
+	// onResume is called before each resume, for the metrics
+	onResume func()
+}
+
+func newResumableReader(ctx context.Context, open rangeOpener, onResume func()) (io.ReadCloser, error) {
+	body, info, err := open(ctx, 0, downloadInfo{})
+	if err != nil {
+		return nil, err
+	}
+	return &resumableReader{
+		ctx:      ctx,
+		open:     open,
+		body:     body,
+		info:     info,
+		backOff:  newExponentialBackOffExecutor(),
+		hash:     md5.New(),
+		onResume: onResume,
+	}, nil
+}
+
+func (r *resumableReader) Read(p []byte) (int, error) {
+	for {
+		n, err := r.body.Read(p)
+		r.offset += int64(n)
+		r.hash.Write(p[:n])
+		if err == io.EOF && r.info.size >= 0 && r.offset < r.info.size {
+			err = io.ErrUnexpectedEOF
+		}
+		if err == nil || err == io.EOF {
+			if err == io.EOF {
+				if verifyErr := r.verify(); verifyErr != nil {
+					return n, verifyErr
+				}
+			}
+			return n, err
+		}
+
+		if resumeErr := r.resume(err); resumeErr != nil {
+			return n, resumeErr
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+// resume reopens the object from the current offset after the body failed with readErr
+func (r *resumableReader) resume(readErr error) error {
+	if !r.info.resumable || r.resumes >= DownloadResumeAttempts || r.ctx.Err() != nil {
+		return readErr
+	}
+	r.resumes++
+	if r.onResume != nil {
+		r.onResume()
+	}
+	log.LogNoRequestID("resuming download", "offset", r.offset, "size", r.info.size, "attempt", r.resumes, "err", readErr)
+	r.body.Close()
+
+	select {
+	case <-time.After(r.backOff.NextBackOff()):
+	case <-r.ctx.Done():
+		return readErr
+	}
+	body, info, err := r.open(r.ctx, r.offset, r.info)
+	if err != nil {
+		return fmt.Errorf("failed to resume download at byte %d: %w (after %s)", r.offset, err, readErr)
+	}
+	if r.info.etag != "" && info.etag != "" && info.etag != r.info.etag {
+		body.Close()
+		return fmt.Errorf("failed to resume download at byte %d: the source changed", r.offset)
+	}
+	r.body = body
+	return nil
+}
+
+func (r *resumableReader) verify() error {
+	if r.info.md5 == nil {
+		return nil
+	}
+	if sum := r.hash.Sum(nil); !bytes.Equal(sum, r.info.md5) {
+		return fmt.Errorf("download checksum mismatch: got md5 %x, expected %x", sum, r.info.md5)
+	}
+	return nil
+}
+
+// Size is the size of the whole object, -1 when unknown
+func (r *resumableReader) Size() int64 {
+	return r.info.size
+}
+
+func (r *resumableReader) Close() error {
+	return r.body.Close()
+}
+
+// md5FromETag returns the MD5 of an object from its ETag, nil for the ETags that aren't one, e.g. of multipart uploads
+func md5FromETag(etag string) []byte {
+	m := md5ETagRegex.FindStringSubmatch(etag)
+	if m == nil {
+		return nil
+	}
+	sum, _ := hex.DecodeString(m[1])
+	return sum
+}
+
+// md5FromHeaders returns the MD5 of an HTTP download from its Content-MD5 or x-goog-hash headers, or from its ETag
+// when it's served by S3 or a compatible store. Other servers' ETags can look like an MD5 without being one.
+func md5FromHeaders(header http.Header) []byte {
+	if sum, err := base64.StdEncoding.DecodeString(header.Get("Content-MD5")); err == nil && len(sum) == md5.Size {
+		return sum
+	}
+	for _, h := range header.Values("X-Goog-Hash") {
+		for _, part := range strings.Split(h, ",") {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(part), "md5="); ok {
+				if sum, err := base64.StdEncoding.DecodeString(v); err == nil && len(sum) == md5.Size {
+					return sum
+				}
+			}
+		}
+	}
+	if header.Get("X-Amz-Request-Id") != "" {
+		return md5FromETag(header.Get("ETag"))
+	}
+	return nil
+}
+
+// openOSURLRange opens an object store URL from an offset
+func openOSURLRange(osURL string) rangeOpener {
+	return func(ctx context.Context, offset int64, info downloadInfo) (io.ReadCloser, downloadInfo, error) {
+		var byteRange string
+		if offset > 0 {
+			byteRange = fmt.Sprintf("bytes=%d-", offset)
+		}
+		fileInfoReader, err := GetOSURL(osURL, byteRange)
+		if err != nil {
+			return nil, downloadInfo{}, err
+		}
+		if offset > 0 {
+			return fileInfoReader.Body, downloadInfo{etag: fileInfoReader.ETag}, nil
+		}
+		info = downloadInfo{resumable: true, etag: fileInfoReader.ETag, size: -1, md5: md5FromETag(fileInfoReader.ETag)}
+		if fileInfoReader.Size != nil {
+			info.size = *fileInfoReader.Size
+		}
+		return fileInfoReader.Body, info, nil
+	}
+}
+
+// openHTTPRange opens an HTTP URL from an offset, only resuming from the same version of the resource
+func openHTTPRange(url string) rangeOpener {
+	return func(ctx context.Context, offset int64, info downloadInfo) (io.ReadCloser, downloadInfo, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, downloadInfo{}, catErrs.Unretriable(fmt.Errorf("error creating http request: %w", err))
+		}
+		setRangeHeaders(req, offset, info)
+		resp, err := retryableHttpClient.Do(req)
+		if err != nil {
+			return nil, downloadInfo{}, fmt.Errorf("error on import request: %w", err)
+		}
+
+		if offset > 0 {
+			if resp.StatusCode != http.StatusPartialContent {
+				resp.Body.Close()
+				return nil, downloadInfo{}, fmt.Errorf("source can't be resumed: status code %d", resp.StatusCode)
+			}
+			return resp.Body, downloadInfo{etag: resp.Header.Get("ETag")}, nil
+		}
+
+		if resp.StatusCode >= 300 {
+			resp.Body.Close()
+
+			msg := fmt.Sprintf("bad status code from import request: %d %s", resp.StatusCode, resp.Status)
+			if resp.StatusCode == 404 {
+				return nil, downloadInfo{}, catErrs.NewObjectNotFoundError(msg, nil)
+			} else if resp.StatusCode < 500 {
+				return nil, downloadInfo{}, catErrs.Unretriable(errors.New(msg))
+			}
+			return nil, downloadInfo{}, errors.New(msg)
+		}
+		return resp.Body, httpDownloadInfo(resp), nil
+	}
+}
+
+// setRangeHeaders requests an HTTP resource from an offset, only from the same version of the resource
+func setRangeHeaders(req *http.Request, offset int64, info downloadInfo) {
+	if offset == 0 {
+		return
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	// the server sends the whole resource rather than the range when it changed. Weak ETags can't be used for ranges.
+	if info.etag != "" && !strings.HasPrefix(info.etag, "W/") {
+		req.Header.Set("If-Range", info.etag)
+	} else if info.lastModified != "" {
+		req.Header.Set("If-Range", info.lastModified)
+	}
+}
+
+// httpDownloadInfo describes an HTTP resource from the response to its first request
+func httpDownloadInfo(resp *http.Response) downloadInfo {
+	info := downloadInfo{size: -1}
+	// only resources served with byte ranges are resumed, others fail as before
+	if resp.Header.Get("Accept-Ranges") == "bytes" {
+		info.resumable = true
+		info.etag = resp.Header.Get("ETag")
+		info.lastModified = resp.Header.Get("Last-Modified")
+	}
+	if resp.ContentLength >= 0 {
+		info.size = resp.ContentLength
+	}
+	// the checksums of compressed responses are of the compressed bytes
+	if resp.Header.Get("Content-Encoding") == "" && !resp.Uncompressed {
+		info.md5 = md5FromHeaders(resp.Header)
+	}
+	return info
+}
@@ -0,0 +1,216 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/livepeer/catalyst-api/config"
+	catErrs "github.com/livepeer/catalyst-api/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyServer serves content with byte ranges, cutting the connection after cutAfter bytes of the first cuts responses
+type flakyServer struct {
+	mu       sync.Mutex
+	content  []byte
+	cutAfter int
+	cuts     int
+	noRanges bool
+	md5      []byte
+	ranges   []string
+	// changed has the source change before it's resumed
+	changed bool
+}
+
+func (s *flakyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	etag := `"v1"`
+	if s.changed && r.Header.Get("Range") != "" {
+		etag = `"v2"`
+	}
+	w.Header().Set("ETag", etag)
+	if !s.noRanges {
+		w.Header().Set("Accept-Ranges", "bytes")
+	}
+	if s.md5 != nil {
+		w.Header().Set("Content-MD5", base64.StdEncoding.EncodeToString(s.md5))
+	}
+
+	body := s.content
+	status := http.StatusOK
+	if rng := r.Header.Get("Range"); rng != "" && !s.noRanges {
+		s.ranges = append(s.ranges, rng)
+		if ifRange := r.Header.Get("If-Range"); ifRange == etag {
+			start, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rng, "bytes="), "-"))
+			body = s.content[start:]
+			status = http.StatusPartialContent
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(s.content)-1, len(s.content)))
+		}
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(status)
+
+	if s.cuts > 0 && len(body) > s.cutAfter {
+		s.cuts--
+		w.Write(body[:s.cutAfter]) // nolint:errcheck
+		w.(http.Flusher).Flush()
+		// drop the connection mid-body
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+		return
+	}
+	w.Write(body) // nolint:errcheck
+}
+
+func testContent() []byte {
+	content := bytes.Repeat([]byte("0123456789"), 10000)
+	return content
+}
+
+func TestHTTPDownloadResumesFromWhereItStopped(t *testing.T) {
+	content := testContent()
+	sum := md5.Sum(content)
+	s := &flakyServer{content: content, cutAfter: 30000, cuts: 2, md5: sum[:]}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	rc, err := getFileHTTP(context.Background(), server.URL+"/source.mp4")
+	require.NoError(t, err)
+	defer rc.Close()
+	downloaded, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	require.Equal(t, content, downloaded)
+	require.Equal(t, []string{"bytes=30000-", "bytes=60000-"}, s.ranges)
+}
+
+func TestHTTPDownloadFailsOnChecksumMismatch(t *testing.T) {
+	content := testContent()
+	sum := md5.Sum([]byte("something else"))
+	server := httptest.NewServer(&flakyServer{content: content, md5: sum[:]})
+	defer server.Close()
+
+	rc, err := getFileHTTP(context.Background(), server.URL+"/source.mp4")
+	require.NoError(t, err)
+	defer rc.Close()
+	_, err = io.ReadAll(rc)
+	require.ErrorContains(t, err, "checksum mismatch")
+}
+
+func TestHTTPDownloadIsNotResumedWithoutRanges(t *testing.T) {
+	server := httptest.NewServer(&flakyServer{content: testContent(), cutAfter: 30000, cuts: 1, noRanges: true})
+	defer server.Close()
+
+	rc, err := getFileHTTP(context.Background(), server.URL+"/source.mp4")
+	require.NoError(t, err)
+	defer rc.Close()
+	_, err = io.ReadAll(rc)
+	require.Error(t, err)
+}
+
+func TestHTTPDownloadIsNotResumedWhenSourceChanged(t *testing.T) {
+	s := &flakyServer{content: testContent(), cutAfter: 30000, cuts: 1, changed: true}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	rc, err := getFileHTTP(context.Background(), server.URL+"/source.mp4")
+	require.NoError(t, err)
+	defer rc.Close()
+	_, err = io.ReadAll(rc)
+	require.ErrorContains(t, err, "can't be resumed")
+}
+
+func TestMD5FromHeaders(t *testing.T) {
+	sum := md5.Sum([]byte("content"))
+	header := http.Header{}
+	require.Nil(t, md5FromHeaders(header))
+
+	header.Set("ETag", fmt.Sprintf(`"%x"`, sum))
+	require.Nil(t, md5FromHeaders(header), "only S3 ETags are MD5s")
+	header.Set("X-Amz-Request-Id", "abc")
+	require.Equal(t, sum[:], md5FromHeaders(header))
+	header.Set("ETag", `"d41d8cd98f00b204e9800998ecf8427e-3"`)
+	require.Nil(t, md5FromHeaders(header), "multipart uploads' ETags aren't MD5s")
+
+	header = http.Header{}
+	header.Add("X-Goog-Hash", "crc32c=n03x6A==")
+	header.Add("X-Goog-Hash", "md5="+base64.StdEncoding.EncodeToString(sum[:]))
+	require.Equal(t, sum[:], md5FromHeaders(header))
+}
+
+func TestGatewayDownloadResumesFromWhereItStopped(t *testing.T) {
+	content := testContent()
+	s := &flakyServer{content: content, cutAfter: 30000, cuts: 1}
+	server := httptest.NewServer(s)
+	defer server.Close()
+	gateway, _ := url.Parse(server.URL + "/ipfs/")
+	defer func(gateways []*url.URL) { config.ImportIPFSGatewayURLs = gateways }(config.ImportIPFSGatewayURLs)
+	config.ImportIPFSGatewayURLs = []*url.URL{gateway}
+
+	rc, err := NewDStorageDownload().DownloadDStorageFromGatewayList(context.Background(), "ipfs://Qme7ss3ARVgxv6rXqVPiikMJ8u2NLgmgszg13pYrDKEoiu", "requestID")
+	require.NoError(t, err)
+	defer rc.Close()
+	downloaded, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	require.Equal(t, content, downloaded)
+	require.Equal(t, []string{"bytes=30000-"}, s.ranges)
+}
+
+func TestCopyFileReportsProgress(t *testing.T) {
+	defer func(interval time.Duration) { copyProgressInterval = interval }(copyProgressInterval)
+	copyProgressInterval = 0
+	content := testContent()
+	server := httptest.NewServer(&flakyServer{content: content})
+	defer server.Close()
+
+	var progress []float64
+	size, err := copyFile(context.Background(), server.URL+"/source.mp4", t.TempDir(), "source.mp4", "requestID", nil, nil, func(completionRatio float64) {
+		progress = append(progress, completionRatio)
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(len(content)), size)
+	require.NotEmpty(t, progress)
+	require.IsIncreasing(t, progress)
+	require.Equal(t, 1.0, progress[len(progress)-1])
+}
+
+func TestCopyFileFailsForSourcesOverTheSizeLimit(t *testing.T) {
+	defer func(limit int64) { maxCopyFileBytes = limit }(maxCopyFileBytes)
+	maxCopyFileBytes = 50000
+
+	// the size is announced
+	server := httptest.NewServer(&flakyServer{content: testContent()})
+	defer server.Close()
+	_, err := copyFile(context.Background(), server.URL+"/source.mp4", t.TempDir(), "source.mp4", "requestID", nil, nil, nil)
+	require.ErrorContains(t, err, "larger than the limit of 50000 bytes")
+	require.True(t, catErrs.IsUnretriable(err))
+	require.Equal(t, catErrs.CodeSourceTooLarge, catErrs.Code(err))
+
+	// the size is only known once it's been read
+	var requests int
+	chunked := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		for i := 0; i < 10; i++ {
+			w.Write(bytes.Repeat([]byte("0123456789"), 1000)) // nolint:errcheck
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer chunked.Close()
+	_, err = copyFile(context.Background(), chunked.URL+"/source.mp4", t.TempDir(), "source.mp4", "requestID", nil, nil, nil)
+	require.ErrorContains(t, err, "larger than the limit of 50000 bytes")
+	require.True(t, catErrs.IsUnretriable(err))
+	require.Equal(t, 1, requests)
+}
@@ -0,0 +1,51 @@
+package clients
+
+import (
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/livepeer/catalyst-api/video"
+	"github.com/livepeer/go-tools/drivers"
+)
+
+// MaxSignedURLTTL is the longest a signed URL can be valid for, the limit of S3's presigned URLs
+const MaxSignedURLTTL = 7 * 24 * time.Hour
+
+// SignOutputs adds signed GET URLs that expire after the TTL to the manifest and MP4s of the outputs, so that outputs
+// written to a private bucket can be opened from the completion callback. The outputs that can't be signed, e.g. on
+// stores that don't support it, are logged and left unsigned, since they were written.
+func SignOutputs(requestID string, outputs []video.OutputVideo, ttl time.Duration) {
+	for i := range outputs {
+		outputs[i].SignedManifest = signOutputURL(requestID, outputs[i].Manifest, ttl)
+		for j, mp4 := range outputs[i].MP4Outputs {
+			if mp4.Type == "mp4" {
+				outputs[i].MP4Outputs[j].SignedLocation = signOutputURL(requestID, mp4.Location, ttl)
+			}
+		}
+	}
+}
+
+// signOutputURL returns the signed URL of an OS URL, empty for the URLs that are already public or can't be signed
+func signOutputURL(requestID, location string, ttl time.Duration) string {
+	if location == "" {
+		return ""
+	}
+	u, err := url.Parse(location)
+	if err != nil {
+		log.LogError(requestID, "failed to parse output URL to sign", err, "url", log.RedactURL(location))
+		return ""
+	}
+	signedURL, err := SignURLWithTTL(u, ttl)
+	if err != nil {
+		if !errors.Is(err, drivers.ErrNotSupported) {
+			log.LogError(requestID, "failed to sign output URL", err, "url", log.RedactURL(location))
+		}
+		return ""
+	}
+	if signedURL == location {
+		return ""
+	}
+	return signedURL
+}
@@ -0,0 +1,257 @@
+package clients
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/livepeer/catalyst-api/metrics"
+)
+
+// SourceCache keeps the source files and segments of VOD jobs on local disk, so that a source that's submitted again
+// isn't downloaded again. Files are stored under blobs/ by the SHA-256 of their content, so that a source behind
+// several URLs is only stored once, and indexed under urls/ by the SHA-256 of their URL. The least recently used files
+// are evicted once the cache is larger than its size, and URLs are downloaded again once their entry is older than
+// the TTL, in case the source was replaced.
+type SourceCache struct {
+	dir      string
+	maxBytes int64
+	ttl      time.Duration
+
+	mu    sync.Mutex
+	urls  map[string]sourceCacheURL
+	blobs map[string]*sourceCacheBlob
+	size  int64
+}
+
+type sourceCacheURL struct {
+	sum   string
+	added time.Time
+}
+
+type sourceCacheBlob struct {
+	size     int64
+	lastUsed time.Time
+}
+
+// DefaultSourceCache caches the sources copied by CopyAllInputFiles, nil when -source-cache-dir isn't set
+var DefaultSourceCache *SourceCache
+
+// NewSourceCache opens the cache in a directory, picking up the files cached by a previous run
+func NewSourceCache(dir string, maxBytes int64, ttl time.Duration) (*SourceCache, error) {
+	c := &SourceCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		ttl:      ttl,
+		urls:     map[string]sourceCacheURL{},
+		blobs:    map[string]*sourceCacheBlob{},
+	}
+	// the partial downloads of a previous run are dropped
+	if err := os.RemoveAll(filepath.Join(dir, "tmp")); err != nil {
+		return nil, fmt.Errorf("failed to clear source cache: %w", err)
+	}
+	for _, sub := range []string{"blobs", "urls", "tmp"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create source cache: %w", err)
+		}
+	}
+
+	blobs, err := os.ReadDir(filepath.Join(dir, "blobs"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source cache: %w", err)
+	}
+	for _, entry := range blobs {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		c.blobs[entry.Name()] = &sourceCacheBlob{size: info.Size(), lastUsed: info.ModTime()}
+		c.size += info.Size()
+	}
+	urls, err := os.ReadDir(filepath.Join(dir, "urls"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source cache: %w", err)
+	}
+	for _, entry := range urls {
+		info, infoErr := entry.Info()
+		sum, readErr := os.ReadFile(c.urlPath(entry.Name()))
+		if infoErr != nil || readErr != nil || c.blobs[string(sum)] == nil {
+			_ = os.Remove(c.urlPath(entry.Name()))
+			continue
+		}
+		c.urls[entry.Name()] = sourceCacheURL{sum: string(sum), added: info.ModTime()}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.evict()
+	return c, nil
+}
+
+// Open returns the cached source of a URL, false when it isn't cached or its entry has expired
+func (c *SourceCache) Open(sourceURL string) (io.ReadCloser, bool) {
+	if c == nil {
+		return nil, false
+	}
+	key := sourceCacheKey(sourceURL)
+	now := time.Now()
+
+	c.mu.Lock()
+	entry, ok := c.urls[key]
+	if ok && now.Sub(entry.added) > c.ttl {
+		delete(c.urls, key)
+		_ = os.Remove(c.urlPath(key))
+		ok = false
+	}
+	var blob *sourceCacheBlob
+	if ok {
+		blob, ok = c.blobs[entry.sum]
+	}
+	if ok {
+		blob.lastUsed = now
+	}
+	c.mu.Unlock()
+
+	if ok {
+		// the file can have been evicted since, which is a miss like any other
+		if f, err := os.Open(c.blobPath(entry.sum)); err == nil {
+			_ = os.Chtimes(c.blobPath(entry.sum), now, now)
+			metrics.Metrics.SourceCacheRequests.WithLabelValues("hit").Inc()
+			return f, true
+		}
+	}
+	metrics.Metrics.SourceCacheRequests.WithLabelValues("miss").Inc()
+	return nil, false
+}
+
+// Fill caches a source as it's read. It's only stored once it's been read to the end and closed, so that sources
+// whose download or copy failed part of the way aren't cached.
+func (c *SourceCache) Fill(sourceURL string, r io.ReadCloser) io.ReadCloser {
+	if c == nil {
+		return r
+	}
+	tmp, err := os.CreateTemp(filepath.Join(c.dir, "tmp"), "source-")
+	if err != nil {
+		log.LogNoRequestID("failed to create source cache file", "err", err)
+		return r
+	}
+	return &sourceCacheFill{cache: c, url: sourceURL, r: r, tmp: tmp, hash: sha256.New()}
+}
+
+type sourceCacheFill struct {
+	cache *SourceCache
+	url   string
+	r     io.ReadCloser
+	tmp   *os.File
+	hash  hash.Hash
+	size  int64
+	// complete is whether the source was read to the end, failed whether it couldn't be written to the cache
+	complete, failed bool
+}
+
+func (f *sourceCacheFill) Read(p []byte) (int, error) {
+	n, err := f.r.Read(p)
+	if n > 0 && !f.failed {
+		f.hash.Write(p[:n])
+		f.size += int64(n)
+		if _, writeErr := f.tmp.Write(p[:n]); writeErr != nil {
+			log.LogNoRequestID("failed to write source cache file", "err", writeErr)
+			f.failed = true
+		}
+	}
+	if err == io.EOF {
+		f.complete = true
+	}
+	return n, err
+}
+
+func (f *sourceCacheFill) Close() error {
+	err := f.r.Close()
+	closeErr := f.tmp.Close()
+	if f.complete && !f.failed && closeErr == nil {
+		f.cache.add(f.url, hex.EncodeToString(f.hash.Sum(nil)), f.tmp.Name(), f.size)
+	} else {
+		_ = os.Remove(f.tmp.Name())
+	}
+	return err
+}
+
+func (c *SourceCache) add(sourceURL, sum, tmpPath string, size int64) {
+	if size > c.maxBytes {
+		_ = os.Remove(tmpPath)
+		return
+	}
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if blob, ok := c.blobs[sum]; ok {
+		_ = os.Remove(tmpPath)
+		blob.lastUsed = now
+	} else {
+		if err := os.Rename(tmpPath, c.blobPath(sum)); err != nil {
+			log.LogNoRequestID("failed to add file to source cache", "err", err)
+			_ = os.Remove(tmpPath)
+			return
+		}
+		c.blobs[sum] = &sourceCacheBlob{size: size, lastUsed: now}
+		c.size += size
+	}
+
+	key := sourceCacheKey(sourceURL)
+	if err := os.WriteFile(c.urlPath(key), []byte(sum), 0644); err != nil {
+		log.LogNoRequestID("failed to index file in source cache", "err", err)
+	} else {
+		c.urls[key] = sourceCacheURL{sum: sum, added: now}
+	}
+	c.evict()
+}
+
+// evict removes the least recently used files until the cache fits its size, with the URLs that point to them. It's
+// called with the lock held.
+func (c *SourceCache) evict() {
+	for c.size > c.maxBytes {
+		var oldest string
+		for sum, blob := range c.blobs {
+			if oldest == "" || blob.lastUsed.Before(c.blobs[oldest].lastUsed) {
+				oldest = sum
+			}
+		}
+		if oldest == "" {
+			break
+		}
+		_ = os.Remove(c.blobPath(oldest))
+		c.size -= c.blobs[oldest].size
+		delete(c.blobs, oldest)
+		for key, entry := range c.urls {
+			if entry.sum == oldest {
+				_ = os.Remove(c.urlPath(key))
+				delete(c.urls, key)
+			}
+		}
+		metrics.Metrics.SourceCacheEvictions.Inc()
+	}
+	metrics.Metrics.SourceCacheBytes.Set(float64(c.size))
+}
+
+func (c *SourceCache) blobPath(sum string) string {
+	return filepath.Join(c.dir, "blobs", sum)
+}
+
+func (c *SourceCache) urlPath(key string) string {
+	return filepath.Join(c.dir, "urls", key)
+}
+
+// sourceCacheKey is the name of a URL's entry, a hash so that credentials in the URL aren't written to disk as they are
+func sourceCacheKey(sourceURL string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(sourceURL)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,125 @@
+package clients
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func fillSourceCache(t *testing.T, c *SourceCache, sourceURL, content string) {
+	rc := c.Fill(sourceURL, io.NopCloser(strings.NewReader(content)))
+	_, err := io.Copy(io.Discard, rc)
+	require.NoError(t, err)
+	require.NoError(t, rc.Close())
+}
+
+func readSourceCache(t *testing.T, c *SourceCache, sourceURL string) (string, bool) {
+	rc, ok := c.Open(sourceURL)
+	if !ok {
+		return "", false
+	}
+	defer rc.Close()
+	b, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	return string(b), true
+}
+
+func TestSourceCacheStoresContentOnce(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewSourceCache(dir, 1024, time.Hour)
+	require.NoError(t, err)
+
+	_, ok := c.Open("https://example.com/a.mp4")
+	require.False(t, ok)
+
+	fillSourceCache(t, c, "https://example.com/a.mp4", "source")
+	fillSourceCache(t, c, "https://mirror.example.com/a.mp4", "source")
+	content, ok := readSourceCache(t, c, "https://example.com/a.mp4")
+	require.True(t, ok)
+	require.Equal(t, "source", content)
+	content, ok = readSourceCache(t, c, "https://mirror.example.com/a.mp4")
+	require.True(t, ok)
+	require.Equal(t, "source", content)
+
+	blobs, err := os.ReadDir(filepath.Join(dir, "blobs"))
+	require.NoError(t, err)
+	require.Len(t, blobs, 1)
+
+	// the entries are picked up by the next run
+	c, err = NewSourceCache(dir, 1024, time.Hour)
+	require.NoError(t, err)
+	content, ok = readSourceCache(t, c, "https://example.com/a.mp4")
+	require.True(t, ok)
+	require.Equal(t, "source", content)
+}
+
+func TestSourceCacheSkipsPartialReads(t *testing.T) {
+	c, err := NewSourceCache(t.TempDir(), 1024, time.Hour)
+	require.NoError(t, err)
+
+	rc := c.Fill("https://example.com/a.mp4", io.NopCloser(strings.NewReader("source")))
+	_, err = rc.Read(make([]byte, 3))
+	require.NoError(t, err)
+	require.NoError(t, rc.Close())
+
+	_, ok := c.Open("https://example.com/a.mp4")
+	require.False(t, ok)
+}
+
+func TestSourceCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c, err := NewSourceCache(t.TempDir(), 10, time.Hour)
+	require.NoError(t, err)
+
+	fillSourceCache(t, c, "https://example.com/a.mp4", "aaaa")
+	fillSourceCache(t, c, "https://example.com/b.mp4", "bbbb")
+	_, ok := readSourceCache(t, c, "https://example.com/a.mp4")
+	require.True(t, ok)
+	fillSourceCache(t, c, "https://example.com/c.mp4", "cccc")
+
+	_, ok = readSourceCache(t, c, "https://example.com/a.mp4")
+	require.True(t, ok)
+	_, ok = readSourceCache(t, c, "https://example.com/b.mp4")
+	require.False(t, ok, "the least recently used source should have been evicted")
+	_, ok = readSourceCache(t, c, "https://example.com/c.mp4")
+	require.True(t, ok)
+
+	fillSourceCache(t, c, "https://example.com/large.mp4", "larger than the cache")
+	_, ok = readSourceCache(t, c, "https://example.com/large.mp4")
+	require.False(t, ok)
+}
+
+func TestSourceCacheExpiresURLs(t *testing.T) {
+	c, err := NewSourceCache(t.TempDir(), 1024, time.Millisecond)
+	require.NoError(t, err)
+
+	fillSourceCache(t, c, "https://example.com/a.mp4", "source")
+	time.Sleep(5 * time.Millisecond)
+	_, ok := c.Open("https://example.com/a.mp4")
+	require.False(t, ok)
+}
+
+func TestCopyFileFromSourceCache(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewSourceCache(filepath.Join(dir, "cache"), 1024, time.Hour)
+	require.NoError(t, err)
+	source := filepath.Join(dir, "source.mp4")
+	require.NoError(t, os.WriteFile(source, []byte("source"), 0644))
+
+	_, err = copyFile(context.Background(), source, filepath.Join(dir, "first"), "source.mp4", "requestID", nil, c, nil)
+	require.NoError(t, err)
+	// the source is gone, so it can only be copied from the cache
+	require.NoError(t, os.Remove(source))
+	size, err := copyFile(context.Background(), source, filepath.Join(dir, "second"), "source.mp4", "requestID", nil, c, nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(6), size)
+
+	copied, err := os.ReadFile(filepath.Join(dir, "second", "source.mp4"))
+	require.NoError(t, err)
+	require.Equal(t, "source", string(copied))
+}
@@ -0,0 +1,96 @@
+package clients
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// SegmentSpillThreshold is the size above which the segments of a transcode are written to a temporary file rather
+// than kept in memory, set with -segment-spill-threshold. 0 keeps every segment in memory.
+var SegmentSpillThreshold int64 = 4 * 1024 * 1024
+
+// SpillBuffer holds a segment in memory until it's larger than the SegmentSpillThreshold, and in a temporary file from
+// then on, so that nodes transcoding many segments at once don't hold all of them in memory. It's written once, then
+// read any number of times, e.g. by the retries of an upload, and must be closed to remove its file.
+type SpillBuffer struct {
+	threshold int64
+	mem       bytes.Buffer
+	file      *os.File
+	size      int64
+}
+
+func NewSpillBuffer() *SpillBuffer {
+	return &SpillBuffer{threshold: SegmentSpillThreshold}
+}
+
+func (b *SpillBuffer) Write(p []byte) (int, error) {
+	if b.file == nil && b.threshold > 0 && b.size+int64(len(p)) > b.threshold {
+		if err := b.spill(); err != nil {
+			return 0, err
+		}
+	}
+	var n int
+	var err error
+	if b.file != nil {
+		n, err = b.file.Write(p)
+	} else {
+		n, err = b.mem.Write(p)
+	}
+	b.size += int64(n)
+	return n, err
+}
+
+// spill moves the segment from memory to a temporary file
+func (b *SpillBuffer) spill() error {
+	f, err := os.CreateTemp("", "segment-")
+	if err != nil {
+		return fmt.Errorf("failed to create segment spill file: %w", err)
+	}
+	if _, err := f.Write(b.mem.Bytes()); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return fmt.Errorf("failed to write segment spill file: %w", err)
+	}
+	b.file = f
+	b.mem = bytes.Buffer{}
+	return nil
+}
+
+// Len is the size of the segment
+func (b *SpillBuffer) Len() int {
+	return int(b.size)
+}
+
+// Spilled is whether the segment was written to a file
+func (b *SpillBuffer) Spilled() bool {
+	return b.file != nil
+}
+
+// NewReader returns a reader of the segment from its start, independent of the other readers
+func (b *SpillBuffer) NewReader() io.ReadSeeker {
+	if b.file != nil {
+		return io.NewSectionReader(b.file, 0, b.size)
+	}
+	return bytes.NewReader(b.mem.Bytes())
+}
+
+// Bytes returns the segment, read back from its file when it was spilled
+func (b *SpillBuffer) Bytes() ([]byte, error) {
+	if b.file == nil {
+		return b.mem.Bytes(), nil
+	}
+	return io.ReadAll(b.NewReader())
+}
+
+// Close removes the file of a spilled segment
+func (b *SpillBuffer) Close() error {
+	if b == nil || b.file == nil {
+		return nil
+	}
+	b.file.Close()
+	err := os.Remove(b.file.Name())
+	b.file, b.size = nil, 0
+	return err
+}
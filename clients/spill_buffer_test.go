@@ -0,0 +1,54 @@
+package clients
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpillBufferKeepsSmallSegmentsInMemory(t *testing.T) {
+	b := &SpillBuffer{threshold: 10}
+	_, err := b.Write([]byte("segment"))
+	require.NoError(t, err)
+	require.False(t, b.Spilled())
+	require.Equal(t, 7, b.Len())
+
+	content, err := io.ReadAll(b.NewReader())
+	require.NoError(t, err)
+	require.Equal(t, "segment", string(content))
+	require.NoError(t, b.Close())
+}
+
+func TestSpillBufferSpillsLargeSegmentsToDisk(t *testing.T) {
+	b := &SpillBuffer{threshold: 10}
+	_, err := b.Write([]byte("segment "))
+	require.NoError(t, err)
+	_, err = b.Write([]byte("spilled to disk"))
+	require.NoError(t, err)
+	require.True(t, b.Spilled())
+	require.Equal(t, 23, b.Len())
+	path := b.file.Name()
+
+	// every reader starts from the beginning, e.g. for the retries of an upload
+	for i := 0; i < 2; i++ {
+		content, err := io.ReadAll(b.NewReader())
+		require.NoError(t, err)
+		require.Equal(t, "segment spilled to disk", string(content))
+	}
+	content, err := b.Bytes()
+	require.NoError(t, err)
+	require.Equal(t, "segment spilled to disk", string(content))
+
+	require.NoError(t, b.Close())
+	_, err = os.Stat(path)
+	require.True(t, os.IsNotExist(err), "the spill file should be removed")
+}
+
+func TestSpillBufferWithoutThreshold(t *testing.T) {
+	b := &SpillBuffer{}
+	_, err := b.Write(make([]byte, 1024*1024))
+	require.NoError(t, err)
+	require.False(t, b.Spilled())
+}
@@ -0,0 +1,180 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/livepeer/catalyst-api/secrets"
+)
+
+// PrefixCredentialsFile is the prefix of the -storage-credentials sources read from a file, e.g. a mounted secret
+// that's rotated in place
+const PrefixCredentialsFile = "file:"
+
+// StorageCredentials are the access key and secret of an S3 compatible object store
+type StorageCredentials struct {
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+}
+
+// CredentialsProvider returns the current credentials of an object store. It's called again on every refresh, so
+// that credentials rotated at the source are picked up without a restart.
+type CredentialsProvider interface {
+	Retrieve(ctx context.Context) (StorageCredentials, error)
+}
+
+// NewCredentialsProvider returns the provider of a -storage-credentials source: a file:/path or a secret manager
+// reference, holding either KEY:SECRET or a JSON {access_key_id, secret_access_key}
+func NewCredentialsProvider(source string, resolver *secrets.Resolver) (CredentialsProvider, error) {
+	switch {
+	case strings.HasPrefix(source, PrefixCredentialsFile):
+		return fileCredentialsProvider{path: strings.TrimPrefix(source, PrefixCredentialsFile)}, nil
+	case secrets.IsReference(source):
+		return secretCredentialsProvider{resolver: resolver, ref: source}, nil
+	default:
+		return nil, fmt.Errorf("unknown credentials source, should start with %s, %s or %s", PrefixCredentialsFile, secrets.PrefixAWSSecretsManager, secrets.PrefixVault)
+	}
+}
+
+type fileCredentialsProvider struct {
+	path string
+}
+
+func (p fileCredentialsProvider) Retrieve(ctx context.Context) (StorageCredentials, error) {
+	b, err := os.ReadFile(p.path)
+	if err != nil {
+		return StorageCredentials{}, err
+	}
+	return parseStorageCredentials(string(b))
+}
+
+type secretCredentialsProvider struct {
+	resolver *secrets.Resolver
+	ref      string
+}
+
+func (p secretCredentialsProvider) Retrieve(ctx context.Context) (StorageCredentials, error) {
+	secret, err := p.resolver.Resolve(ctx, p.ref)
+	if err != nil {
+		return StorageCredentials{}, err
+	}
+	return parseStorageCredentials(secret)
+}
+
+func parseStorageCredentials(s string) (StorageCredentials, error) {
+	s = strings.TrimSpace(s)
+	var creds StorageCredentials
+	if strings.HasPrefix(s, "{") {
+		if err := json.Unmarshal([]byte(s), &creds); err != nil {
+			return StorageCredentials{}, fmt.Errorf("invalid credentials JSON: %w", err)
+		}
+	} else {
+		creds.AccessKeyID, creds.SecretAccessKey, _ = strings.Cut(s, ":")
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return StorageCredentials{}, errors.New("credentials should have an access key and a secret")
+	}
+	return creds, nil
+}
+
+// StorageCredentialsStore keeps the current credentials of the object stores configured with -storage-credentials,
+// by URL prefix, and adds them to the OS URLs that don't have any before they're handed to the storage drivers
+type StorageCredentialsStore struct {
+	mu        sync.RWMutex
+	providers map[string]CredentialsProvider
+	creds     map[string]StorageCredentials
+}
+
+// DefaultStorageCredentials is used by the object store client
+var DefaultStorageCredentials = &StorageCredentialsStore{}
+
+// SetProviders sets the credentials providers by URL prefix, without credentials. Their credentials are only used
+// once they're retrieved, see Refresh.
+func (s *StorageCredentialsStore) SetProviders(providers map[string]CredentialsProvider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.providers = providers
+	s.creds = map[string]StorageCredentials{}
+}
+
+// Refresh retrieves the credentials of every provider. A provider that fails keeps its previous credentials, since
+// they're usually still valid for a while after a rotation.
+func (s *StorageCredentialsStore) Refresh(ctx context.Context) error {
+	s.mu.RLock()
+	providers := s.providers
+	s.mu.RUnlock()
+
+	var errs []error
+	for prefix, provider := range providers {
+		creds, err := provider.Retrieve(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error retrieving the credentials of %s: %w", prefix, err))
+			continue
+		}
+		log.RedactSecret(creds.SecretAccessKey)
+		s.mu.Lock()
+		if previous, ok := s.creds[prefix]; ok && previous != creds {
+			log.LogNoRequestID("storage credentials rotated", "prefix", prefix, "access_key_id", creds.AccessKeyID)
+		}
+		s.creds[prefix] = creds
+		s.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// Poll refreshes the credentials every interval until the context is done
+func (s *StorageCredentialsStore) Poll(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := s.Refresh(ctx); err != nil {
+			log.LogNoRequestID("error refreshing storage credentials, keeping the previous ones", "err", err)
+		}
+	}
+}
+
+// Apply returns an S3 or B2 OS URL with the current credentials of the longest prefix it matches, when it doesn't
+// have credentials of its own. Other URLs are returned as they are.
+func (s *StorageCredentialsStore) Apply(osURL string) string {
+	u, err := url.Parse(osURL)
+	if err != nil || u.User != nil {
+		return osURL
+	}
+	switch u.Scheme {
+	case "s3", "s3+http", "s3+https", "b2":
+	default:
+		return osURL
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var match string
+	for prefix := range s.creds {
+		if strings.HasPrefix(osURL, prefix) && len(prefix) > len(match) {
+			match = prefix
+		}
+	}
+	if match == "" {
+		return osURL
+	}
+	creds := s.creds[match]
+	u.User = url.UserPassword(creds.AccessKeyID, creds.SecretAccessKey)
+	return u.String()
+}
+
+func withStorageCredentials(osURL string) string {
+	return DefaultStorageCredentials.Apply(osURL)
+}
@@ -21,7 +21,13 @@ type TranscodeJobArgs struct {
 	// Input File info used to by transcoder provider(s) to set transcode options
 	InputFileInfo video.InputVideo
 	Profiles      []video.EncodedProfile
-	GenerateMP4   bool
+	// DefaultProfiles is the ladder used when no profiles are requested, the global default if nil
+	DefaultProfiles []video.EncodedProfile
+	// LadderConstraints limit the ladder generated from the DefaultProfiles, if any
+	LadderConstraints *video.LadderConstraints
+	// AudioOptions change the audio of every rendition, if any
+	AudioOptions *video.AudioOptions
+	GenerateMP4  bool
 
 	// Collect size of an asset
 	CollectSourceSize        func(size int64)
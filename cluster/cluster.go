@@ -15,17 +15,29 @@ import (
 	"github.com/hashicorp/memberlist"
 	"github.com/hashicorp/serf/serf"
 	"github.com/livepeer/catalyst-api/config"
+	"github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/events"
 	"github.com/livepeer/catalyst-api/metrics"
 )
 
 const serfClusterInternalEventBuffer = 100000
 
+const (
+	serfUserEventSizeLimit = 1024
+	// Room left for Serf's own encoding of the user event message when splitting payloads into chunks
+	serfUserEventEncodingOverhead = 64
+)
+
+// drainTag is set on a member that is shutting down, so that load balancers stop sending new streams to it
+const drainTag = "drain"
+
 type Cluster interface {
 	Start(ctx context.Context) error
 	MembersFiltered(filter map[string]string, status, name string) ([]Member, error)
 	MemberChan() chan []Member
 	EventChan() <-chan serf.UserEvent
 	BroadcastEvent(serf.UserEvent) error
+	Drain() error
 }
 
 type ClusterImpl struct {
@@ -41,6 +53,8 @@ type ClusterImpl struct {
 	eventCh chan serf.UserEvent
 	// membersCh is an internal channel to update the current membership list
 	memberCh chan []Member
+	// reassembler puts back together user event payloads that were too large to be sent in a single event
+	reassembler *events.Reassembler
 }
 
 type Member struct {
@@ -58,6 +72,8 @@ func NewCluster(config *config.Cli) Cluster {
 		serfCh:   make(chan serf.Event, serfClusterInternalEventBuffer),
 		memberCh: make(chan []Member),
 		eventCh:  make(chan serf.UserEvent, config.SerfQueueSize),
+
+		reassembler: events.NewReassembler(),
 	}
 	return &c
 }
@@ -112,7 +128,7 @@ func (c *ClusterImpl) Start(ctx context.Context) error {
 	memberlistConfig.SecretKey = encryptBytes
 	memberlistConfig.LogOutput = serfLogger{}
 	serfConfig := serf.DefaultConfig()
-	serfConfig.UserEventSizeLimit = 1024
+	serfConfig.UserEventSizeLimit = serfUserEventSizeLimit
 	serfConfig.MemberlistConfig = memberlistConfig
 	serfConfig.NodeName = c.config.NodeName
 	serfConfig.Tags = c.config.Tags
@@ -169,7 +185,7 @@ func (c *ClusterImpl) retryJoin(ctx context.Context) {
 		jitter := time.Duration(rand.Int63n(int64(backoff)))
 		sleepTime := backoff + jitter
 
-		fmt.Printf("Retrying in %v...\n", sleepTime)
+		glog.Infof("Retrying joining Serf cluster in %v", sleepTime)
 
 		sleepCtx, cancel := context.WithTimeout(ctx, sleepTime)
 		defer cancel()
@@ -190,6 +206,18 @@ func (c *ClusterImpl) MembersFiltered(filter map[string]string, status, name str
 	return FilterMembers(toClusterMembers(c.serf.Members()), filter, status, name)
 }
 
+// ExcludeDraining filters out the members that are shutting down and shouldn't receive new streams
+func ExcludeDraining(members []Member) []Member {
+	var nodes []Member
+	for _, member := range members {
+		if member.Tags[drainTag] == "true" {
+			continue
+		}
+		nodes = append(nodes, member)
+	}
+	return nodes
+}
+
 func toClusterMembers(members []serf.Member) []Member {
 	var nodes []Member
 	for _, member := range members {
@@ -236,8 +264,43 @@ func (c *ClusterImpl) EventChan() <-chan serf.UserEvent {
 	return c.eventCh
 }
 
+// BroadcastEvent sends a user event to all the members. Payloads exceeding the Serf size limit are split into
+// multiple events, which are reassembled by the receiving members before being passed on to EventChan().
 func (c *ClusterImpl) BroadcastEvent(event serf.UserEvent) error {
-	return c.serf.UserEvent(event.Name, event.Payload, event.Coalesce)
+	if c.serf == nil {
+		return fmt.Errorf("serf not initialized")
+	}
+	if len(event.Name)+len(event.Payload) <= serfUserEventSizeLimit-serfUserEventEncodingOverhead {
+		return c.serf.UserEvent(event.Name, event.Payload, event.Coalesce)
+	}
+
+	// Chunk names need to be unique, otherwise Serf would coalesce them
+	chunkName := func(i int) string { return fmt.Sprintf("%s-chunk-%d", event.Name, i) }
+	chunks, err := events.Chunk(event.Payload, serfUserEventSizeLimit-serfUserEventEncodingOverhead-len(chunkName(255)))
+	if err != nil {
+		// The payload is too large to ever be sent, so there's no point retrying
+		return errors.Unretriable(err)
+	}
+	for i, chunk := range chunks {
+		if err := c.serf.UserEvent(chunkName(i), chunk, false); err != nil {
+			return fmt.Errorf("error sending chunk %d/%d of user event %s: %w", i+1, len(chunks), event.Name, err)
+		}
+	}
+	return nil
+}
+
+// Drain tags the local member as draining. The tag change is propagated to all the other members
+// as a member update event, which in turn removes this node from their load balancers.
+func (c *ClusterImpl) Drain() error {
+	if c.serf == nil {
+		return fmt.Errorf("serf not initialized")
+	}
+	tags := make(map[string]string, len(c.config.Tags)+1)
+	for k, v := range c.config.Tags {
+		tags[k] = v
+	}
+	tags[drainTag] = "true"
+	return c.serf.SetTags(tags)
 }
 
 func (c *ClusterImpl) handleEvents(ctx context.Context) error {
@@ -254,6 +317,17 @@ func (c *ClusterImpl) handleEvents(ctx context.Context) error {
 
 				switch evt := e.(type) {
 				case serf.UserEvent:
+					if events.IsChunk(evt.Payload) {
+						payload, complete, err := c.reassembler.Add(evt.Payload)
+						if err != nil {
+							glog.Errorf("Error reassembling UserEvent %s: %s", evt.Name, err)
+							continue
+						}
+						if !complete {
+							continue
+						}
+						evt.Payload = payload
+					}
 					select {
 					case <-ctx.Done():
 						return
@@ -295,6 +369,6 @@ func (c *ClusterImpl) handleEvents(ctx context.Context) error {
 			return err
 		}
 
-		c.memberCh <- members
+		c.memberCh <- ExcludeDraining(members)
 	}
 }
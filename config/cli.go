@@ -12,78 +12,127 @@ import (
 	"time"
 
 	"github.com/golang/glog"
+	"github.com/livepeer/catalyst-api/video"
+	"github.com/peterbourgon/ff/v3"
 )
 
 type Cli struct {
-	HTTPAddress               string
-	HTTPInternalAddress       string
-	ClusterAddress            string
-	ClusterAdvertiseAddress   string
-	MistEnabled               bool
-	MistTriggerSetup          bool
-	MistHost                  string
-	MistUser                  string
-	MistPassword              string
-	MistPrometheus            string
-	Mode                      string
-	MistPort                  int
-	MistConnectTimeout        time.Duration
-	MistStreamSource          string
-	MistHardcodedBroadcasters string
-	MistScrapeMetrics         bool
-	MistBaseStreamName        string
-	MistLoadBalancerPort      int
-	MistLoadBalancerTemplate  string
-	MistCleanup               bool
-	LogSysUsage               bool
-	AMQPURL                   string
-	OwnRegion                 string
-	OwnRegionTagAdjust        int
-	APIToken                  string
-	APIServer                 string
-	SourceOutput              string
-	PrivateBucketURLs         []*url.URL
-	ExternalTranscoder        string
-	VodPipelineStrategy       string
-	MetricsDBConnectionString string
-	NodeStatsConnectionString string
-	NodeStatsMaxConnections   int
-	ImportIPFSGatewayURLs     []*url.URL
-	ImportArweaveGatewayURLs  []*url.URL
-	NodeName                  string
-	BalancerArgs              []string
-	NodeHost                  string
-	NodeLatitude              float64
-	NodeLongitude             float64
-	RedirectPrefixes          []string
-	Tags                      map[string]string
-	RetryJoin                 []string
-	EncryptKey                string
-	VodDecryptPublicKey       string
-	VodDecryptPrivateKey      string
-	StorageFallbackURLs       map[string]string
-	GateURL                   string
-	DataURL                   string
-	StreamHealthHookURL       string
-	BroadcasterURL            string
-	SourcePlaybackHosts       map[string]string
-	DefaultQuality            int
-	MaxBitrateFactor          float64
-	BlockedJWTs               []string
-	EnableAnalytics           string
-	KafkaBootstrapServers     string
-	KafkaUser                 string
-	KafkaPassword             string
-	AnalyticsKafkaTopic       string
-	UserEndKafkaTopic         string
-	SerfMembersEndpoint       string
-	EventsEndpoint            string
-	CatalystApiURL            string
+	HTTPAddress                string
+	HTTPInternalAddress        string
+	HTTPTLS                    TLSConfig
+	HTTPInternalTLS            TLSConfig
+	GRPCAddress                string
+	CORS                       CORSConfig
+	JWTAuth                    JWTAuthConfig
+	APIKeys                    APIKeysConfig
+	RateLimit                  RateLimitConfig
+	HTTPLimits                 HTTPLimitsConfig
+	FeatureFlags               FeatureFlagsConfig
+	ClusterAddress             string
+	ClusterAdvertiseAddress    string
+	MistEnabled                bool
+	MistTriggerSetup           bool
+	MistHost                   string
+	MistUser                   string
+	MistPassword               string
+	MistPrometheus             string
+	Mode                       string
+	MistPort                   int
+	MistConnectTimeout         time.Duration
+	MistStreamSource           string
+	MistHardcodedBroadcasters  string
+	MistScrapeMetrics          bool
+	MistBaseStreamName         string
+	MistLoadBalancerPort       int
+	MistLoadBalancerTemplate   string
+	MistCleanup                bool
+	LogSysUsage                bool
+	AMQPURL                    string
+	OwnRegion                  string
+	OwnRegionTagAdjust         int
+	APIToken                   string
+	APIServer                  string
+	SourceOutput               string
+	PrivateBucketURLs          []*url.URL
+	ExternalTranscoder         string
+	VodPipelineStrategy        string
+	MetricsDBConnectionString  string
+	NodeStatsConnectionString  string
+	NodeStatsMaxConnections    int
+	ImportIPFSGatewayURLs      []*url.URL
+	ImportArweaveGatewayURLs   []*url.URL
+	NodeName                   string
+	BalancerArgs               []string
+	NodeHost                   string
+	NodeLatitude               float64
+	NodeLongitude              float64
+	RedirectPrefixes           []string
+	Tags                       map[string]string
+	RetryJoin                  []string
+	EncryptKey                 string
+	VodDecryptPublicKey        string
+	VodDecryptPrivateKey       string
+	VodDecryptPreviousKeys     []string
+	VodDecryptX25519PrivateKey string
+	VodDecryptKeyProvider      string
+	VodDecryptKMSRegion        string
+	VodDecryptKMSKeyIDs        []string
+	VaultAddr                  string
+	VaultToken                 string
+	VaultTransitMount          string
+	VaultTransitKey            string
+	SecretsManagerRegion       string
+	EffectiveConfig            *EffectiveConfig
+	Tenants                    map[string]Tenant
+	VodDecryptKeyCacheTTL      time.Duration
+	PlaybackSigningSecret      string
+	PlaybackSigningKey         string
+	PlaybackVerificationKey    string
+	RequireSignedPlayback      bool
+	HLSKeySecret               string
+	SRTPassphraseSecret        string
+	StreamKeyAuth              bool
+	StorageFallbackURLs        map[string]string
+	OutputBackupURLs           map[string]string
+	StorageCredentials         map[string]string
+	StorageCredentialsRefresh  time.Duration
+	SourceCacheDir             string
+	SourceCacheSize            int64
+	SourceCacheTTL             time.Duration
+	GateURL                    string
+	DataURL                    string
+	StreamHealthHookURL        string
+	BroadcasterURL             string
+	SourcePlaybackHosts        map[string]string
+	DefaultQuality             int
+	MaxBitrateFactor           float64
+	BlockedJWTs                []string
+	EnableAnalytics            string
+	KafkaBootstrapServers      string
+	KafkaUser                  string
+	KafkaPassword              string
+	AnalyticsKafkaTopic        string
+	UserEndKafkaTopic          string
+	SessionExportKafkaTopic    string
+	SessionExportURL           string
+	SerfMembersEndpoint        string
+	EventsEndpoint             string
+	EventsReplayEndpoint       string
+	EventsReplayWindow         time.Duration
+	FederationPeers            []*url.URL
+	FederationEvents           []string
+	CatalystApiURL             string
 
 	// mapping playbackId to value between 0.0 to 100.0
 	CdnRedirectPlaybackPct             map[string]float64
 	CdnRedirectPrefix                  *url.URL
 	CdnRedirectPrefixCatalystSubdomain bool
+	CdnRedirectPlaybackIDsURL          string
+	CdnRedirectRefreshInterval         time.Duration
+
+	// mapping playbackId to its max concurrent viewers
+	ViewerLimits         map[string]int
+	ViewerLimitPolicyURL string
 
 	C2PAPrivateKeyPath string
 	C2PACertsPath      string
@@ -96,9 +145,138 @@ type Cli struct {
 	SerfEventBuffer                 int
 	SerfMaxQueueDepth               int
 
+	ShutdownGracePeriod  time.Duration
+	ShutdownDrainTimeout time.Duration
+
+	LogFormat string
+
+	MistHTTPPort           int
+	MistSRTPort            int
+	LiveThumbnailsURL      *url.URL
+	LiveThumbnailsInterval time.Duration
+	RecordingURL           string
+	LLHLS                  bool
+	LLHLSPartDuration      time.Duration
+	IdleStreamTimeout      time.Duration
+
 	LBReplaceHostMatch   string
 	LBReplaceHostPercent int
 	LBReplaceHostList    []string
+
+	// Only set from a -config file
+	TranscodeProfiles []video.EncodedProfile
+	Callbacks         CallbackPolicy
+
+	// flagSources is where each flag was set from by ParseFlags
+	flagSources *flagSources
+}
+
+// TLSConfig is how an HTTP listener serves TLS, either from certificate files or with certificates obtained from
+// Let's Encrypt for AutocertDomains. The listener serves plaintext HTTP if neither is configured.
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+
+	AutocertDomains  []string
+	AutocertCacheDir string
+	AutocertEmail    string
+	// AutocertHTTPAddress is where the ACME HTTP-01 challenges are answered, other requests are redirected to
+	// HTTPS. Without it only the TLS-ALPN-01 challenge is available, which requires the listener to be on port 443.
+	AutocertHTTPAddress string
+}
+
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != "" || t.KeyFile != "" || len(t.AutocertDomains) > 0
+}
+
+func (t TLSConfig) Validate() error {
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return fmt.Errorf("both a certificate and a key file are required")
+	}
+	if t.CertFile != "" && len(t.AutocertDomains) > 0 {
+		return fmt.Errorf("certificate files and autocert are mutually exclusive")
+	}
+	if len(t.AutocertDomains) > 0 && t.AutocertCacheDir == "" {
+		return fmt.Errorf("autocert needs a cache directory, so that certificates aren't requested on every restart")
+	}
+	return nil
+}
+
+// CORSConfig is which cross-origin browser requests are allowed to the public API routes
+type CORSConfig struct {
+	// AllowedOrigins are exact origins, "*" for any origin or "*.example.com" for any subdomain. CORS is
+	// disabled if empty.
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	MaxAge         time.Duration
+}
+
+// JWTAuthConfig is how bearer JWTs for the API are validated. Only the static -api-token is accepted if JWKSURL
+// is empty.
+type JWTAuthConfig struct {
+	Issuer   string
+	Audience string
+	JWKSURL  string
+	// JWKSRefreshInterval is how often the signing keys are refetched, they're also refetched when a token is
+	// signed with an unknown key
+	JWKSRefreshInterval time.Duration
+}
+
+func (j JWTAuthConfig) Enabled() bool {
+	return j.JWKSURL != ""
+}
+
+// APIKeysConfig is where the API keys, each with their own role, are read from
+type APIKeysConfig struct {
+	File            string
+	RefreshInterval time.Duration
+}
+
+// RateLimitConfig is the limits applied to each API caller, a zero value disables the limit
+type RateLimitConfig struct {
+	RequestsPerSecond float64
+	Burst             int
+	MaxConcurrentJobs int
+}
+
+// The groups of API routes whose body limit can be set on its own with HTTPLimitsConfig.RouteMaxBodyBytes
+const (
+	BodyLimitRouteAnalytics = "analytics"
+	BodyLimitRouteWHIP      = "whip"
+	BodyLimitRouteVOD       = "vod"
+)
+
+var bodyLimitRoutes = []string{BodyLimitRouteAnalytics, BodyLimitRouteWHIP, BodyLimitRouteVOD}
+
+// HTTPLimitsConfig is the timeouts and sizes enforced by the HTTP servers, a zero value disables the limit.
+// MaxBodyBytes only applies to the API routes, not to playback or the segments uploaded by ffmpeg.
+type HTTPLimitsConfig struct {
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	MaxBodyBytes      int64
+	// RouteMaxBodyBytes overrides MaxBodyBytes for the groups of routes named by the BodyLimitRoute constants
+	RouteMaxBodyBytes map[string]int
+}
+
+// MaxBodyBytesFor is the body limit of the given group of routes
+func (l HTTPLimitsConfig) MaxBodyBytesFor(route string) int64 {
+	if maxBytes, ok := l.RouteMaxBodyBytes[route]; ok {
+		return int64(maxBytes)
+	}
+	return l.MaxBodyBytes
+}
+
+// FeatureFlagsConfig is where the feature flags are seeded from, they can also be overridden with the admin API
+type FeatureFlagsConfig struct {
+	// Flags maps flag names to the percent of keys they're enabled for
+	Flags map[string]float64
+	// RemoteURL is a flag service returning a JSON list of flags, which override Flags. Disabled if empty.
+	RemoteURL       string
+	RefreshInterval time.Duration
 }
 
 // Return our own URL for callback trigger purposes
@@ -110,9 +288,28 @@ func (cli *Cli) OwnInternalURL() string {
 		host = "127.0.0.1"
 	}
 	addr := net.JoinHostPort(host, port)
+	if cli.HTTPInternalTLS.Enabled() {
+		return fmt.Sprintf("https://%s", addr)
+	}
 	return fmt.Sprintf("http://%s", addr)
 }
 
+// HLSKeySecretBytes returns the secret HLS output keys are derived from, nil if not configured
+func (cli *Cli) HLSKeySecretBytes() ([]byte, error) {
+	if cli.HLSKeySecret == "" {
+		return nil, nil
+	}
+	return base64.StdEncoding.DecodeString(cli.HLSKeySecret)
+}
+
+// SRTPassphraseSecretBytes returns the secret SRT ingest passphrases are derived from, nil if not configured
+func (cli *Cli) SRTPassphraseSecretBytes() ([]byte, error) {
+	if cli.SRTPassphraseSecret == "" {
+		return nil, nil
+	}
+	return base64.StdEncoding.DecodeString(cli.SRTPassphraseSecret)
+}
+
 // EncryptBytes returns the encryption key configured.
 func (cli *Cli) EncryptBytes() ([]byte, error) {
 	return base64.StdEncoding.DecodeString(cli.EncryptKey)
@@ -172,6 +369,40 @@ func (cli *Cli) ParseLegacyEnv() {
 	}
 }
 
+// EnvVarPrefix is the prefix of the environment variables that set the flags, e.g. CATALYST_API_HTTP_ADDR for
+// -http-addr and CATALYST_API_NO_MIST for -no-mist
+const EnvVarPrefix = "CATALYST_API"
+
+// ParseFlags sets the flags from the environment, then the command line and then the -config file, each taking
+// precedence over the previous one. The secret flags are then read from their -<flag>-file, if set. Where each flag
+// is set from is recorded for the EffectiveConfig. The reloader is nil if there's no config file.
+func ParseFlags(fs *flag.FlagSet, args []string, cli *Cli) (*ConfigFileReloader, error) {
+	registerSecretFileFlags(fs)
+	cli.flagSources = recordFlagSources(fs)
+	// the command line is parsed first to tell the flags it sets apart from the ones set by the environment, which
+	// is only read for the flags that aren't set yet
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	cli.flagSources.current = SourceEnv
+	if err := ff.Parse(fs, fs.Args(), ff.WithEnvVarPrefix(EnvVarPrefix)); err != nil {
+		return nil, err
+	}
+	cli.flagSources.current = SourceConfigFile
+	var reloader *ConfigFileReloader
+	if configFile := fs.Lookup("config"); configFile != nil && configFile.Value.String() != "" {
+		var err error
+		reloader, err = LoadConfigFile(fs, configFile.Value.String(), cli)
+		if err != nil {
+			return nil, fmt.Errorf("error loading config file %s: %w", configFile.Value.String(), err)
+		}
+	}
+	if err := loadSecretFiles(fs); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
 // still a string, but validates the provided value is some kind of coherent host:port
 func AddrFlag(fs *flag.FlagSet, dest *string, name, value, usage string) {
 	*dest = value
@@ -224,6 +455,7 @@ func parseURLs(s string, dest *[]*url.URL) error {
 // handles -foo "value1 value2 value3"
 func SpaceSliceFlag(fs *flag.FlagSet, dest *[]string, name string, value []string, usage string) {
 	*dest = value
+	flagFormats[name] = flagFormat{listSeparator: " "}
 	fs.Func(name, usage, func(s string) error {
 		split := strings.Split(s, " ")
 		if len(split) == 1 && split[0] == "" {
@@ -252,6 +484,7 @@ func CommaSliceFlag(fs *flag.FlagSet, dest *[]string, name string, value []strin
 // handles -foo=value1:10.3,value2:99.9,value3:0.1,value4:100,value5:0
 func CommaWithPctSliceFlag(fs *flag.FlagSet, dest *map[string]float64, name string, value map[string]float64, usage string) {
 	*dest = value
+	flagFormats[name] = flagFormat{listSeparator: ",", valueSeparator: ":"}
 	fs.Func(name, usage, func(s string) error {
 		elements := strings.Split(s, ",")
 		if len(elements) == 0 || (len(elements) == 1 && elements[0] == "") {
@@ -279,6 +512,27 @@ func CommaWithPctSliceFlag(fs *flag.FlagSet, dest *map[string]float64, name stri
 	})
 }
 
+// handles -foo=key1=10,key2=20 with positive integer values
+func CommaIntMapFlag(fs *flag.FlagSet, dest *map[string]int, name string, value map[string]int, usage string) {
+	*dest = value
+	fs.Func(name, usage, func(s string) error {
+		pairs, err := parseCommaMap(s)
+		if err != nil {
+			return err
+		}
+		output := make(map[string]int, len(pairs))
+		for k, v := range pairs {
+			i, err := strconv.Atoi(v)
+			if err != nil || i <= 0 {
+				return fmt.Errorf("invalid value for %s - should be a positive integer, got %s", k, v)
+			}
+			output[k] = i
+		}
+		*dest = output
+		return nil
+	})
+}
+
 func parseCommaMap(s string) (map[string]string, error) {
 	output := map[string]string{}
 	if s == "" {
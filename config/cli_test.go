@@ -13,6 +13,20 @@ func TestOwnInternalURL(t *testing.T) {
 
 	cli = Cli{HTTPInternalAddress: "1.1.1.1:50"}
 	require.Equal(t, cli.OwnInternalURL(), "http://1.1.1.1:50")
+
+	cli.HTTPInternalTLS = TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem"}
+	require.Equal(t, cli.OwnInternalURL(), "https://1.1.1.1:50")
+}
+
+func TestTLSConfigValidate(t *testing.T) {
+	require.NoError(t, TLSConfig{}.Validate())
+	require.False(t, TLSConfig{}.Enabled())
+	require.NoError(t, TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem"}.Validate())
+	require.NoError(t, TLSConfig{AutocertDomains: []string{"example.com"}, AutocertCacheDir: "/tmp/certs"}.Validate())
+
+	require.Error(t, TLSConfig{CertFile: "cert.pem"}.Validate())
+	require.Error(t, TLSConfig{AutocertDomains: []string{"example.com"}}.Validate())
+	require.Error(t, TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem", AutocertDomains: []string{"example.com"}, AutocertCacheDir: "/tmp/certs"}.Validate())
 }
 
 func TestAddrFlag(t *testing.T) {
@@ -104,6 +118,32 @@ func TestCommaWithPctSliceFlag(t *testing.T) {
 	require.Equal(t, err2, nil)
 }
 
+func TestCommaIntMapFlag(t *testing.T) {
+	fs := flag.NewFlagSet("cli-test", flag.ContinueOnError)
+	var multi, keepDefault, empty map[string]int
+	CommaIntMapFlag(fs, &multi, "multi", map[string]int{}, "")
+	CommaIntMapFlag(fs, &keepDefault, "default", map[string]int{"one": 1}, "")
+	CommaIntMapFlag(fs, &empty, "empty", map[string]int{"one": 1}, "")
+
+	err := fs.Parse([]string{
+		"-multi=one=1000,two=50",
+		"-empty=",
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"one": 1000, "two": 50}, multi)
+	require.Equal(t, map[string]int{"one": 1}, keepDefault)
+	require.Equal(t, map[string]int{}, empty)
+
+	require.ErrorContains(t, fs.Parse([]string{"-multi=one=lots"}), "invalid value for one - should be a positive integer")
+	require.ErrorContains(t, fs.Parse([]string{"-multi=one=0"}), "invalid value for one - should be a positive integer")
+}
+
+func TestMaxBodyBytesFor(t *testing.T) {
+	limits := HTTPLimitsConfig{MaxBodyBytes: 1 << 20, RouteMaxBodyBytes: map[string]int{BodyLimitRouteAnalytics: 65536}}
+	require.Equal(t, int64(65536), limits.MaxBodyBytesFor(BodyLimitRouteAnalytics))
+	require.Equal(t, int64(1<<20), limits.MaxBodyBytesFor(BodyLimitRouteVOD))
+}
+
 func TestCommaMap(t *testing.T) {
 	fs := flag.NewFlagSet("cli-test", flag.PanicOnError)
 	var single, multi, keepDefault, setEmpty map[string]string
@@ -159,3 +199,39 @@ func TestInvertedBool(t *testing.T) {
 	require.Equal(t, falseFlag.String(), "false")
 	require.Equal(t, nilFlag.String(), "")
 }
+
+func TestParseFlagsPrecedence(t *testing.T) {
+	t.Setenv("CATALYST_API_MIST_HOST", "env.example.com")
+	t.Setenv("CATALYST_API_MIST_PORT", "1000")
+	t.Setenv("CATALYST_API_REDIRECT_PREFIXES", "env")
+	t.Setenv("CATALYST_API_NO_MIST", "true")
+
+	var cli Cli
+	fs := newConfigFileFlags(&cli)
+	fs.String("config", "", "")
+	reloader, err := ParseFlags(fs, []string{"-mist-host=flag.example.com", "-mist-port=2000"}, &cli)
+	require.NoError(t, err)
+	require.Nil(t, reloader)
+	require.Equal(t, "flag.example.com", cli.MistHost)
+	require.Equal(t, 2000, cli.MistPort)
+	require.Equal(t, []string{"env"}, cli.RedirectPrefixes)
+	require.False(t, cli.MistEnabled)
+
+	cli = Cli{}
+	fs = newConfigFileFlags(&cli)
+	fs.String("config", "", "")
+	t.Setenv("CATALYST_API_CONFIG", writeConfigFile(t, "catalyst-api.yaml", "mist-port: 3000"))
+	reloader, err = ParseFlags(fs, []string{"-mist-host=flag.example.com", "-mist-port=2000"}, &cli)
+	require.NoError(t, err)
+	require.NotNil(t, reloader)
+	require.Equal(t, "flag.example.com", cli.MistHost)
+	require.Equal(t, 3000, cli.MistPort, "the config file takes precedence over the command line")
+	require.Equal(t, []string{"env"}, cli.RedirectPrefixes)
+
+	t.Setenv("CATALYST_API_CONFIG", writeConfigFile(t, "catalyst-api.yaml", "mist-port: abc"))
+	cli = Cli{}
+	fs = newConfigFileFlags(&cli)
+	fs.String("config", "", "")
+	_, err = ParseFlags(fs, nil, &cli)
+	require.ErrorContains(t, err, "error loading config file")
+}
@@ -44,6 +44,14 @@ const MaxInputFileSizeBytes = 30 * 1024 * 1024 * 1024 // 30 GiB
 
 var TranscodingParallelJobs int = 2
 
+// Sources at least this long are segmented in ParallelSegmentingWorkers parts at the same time. Disabled if 0
+var ParallelSegmentingMinDuration time.Duration
+var ParallelSegmentingWorkers int = 4
+
+// Segmenting a source is given SegmentingMinTimeout plus SegmentingTimeoutFactor times its duration to finish
+var SegmentingMinTimeout = 10 * time.Minute
+var SegmentingTimeoutFactor = 1.0
+
 var TranscodingParallelSleep time.Duration = 10 * time.Second
 
 var DownloadOSURLRetries uint64 = 10
@@ -54,4 +62,6 @@ var ImportArweaveGatewayURLs []*url.URL
 
 var StorageFallbackURLs map[string]string
 
+var OutputBackupURLs map[string]string
+
 var HTTPInternalAddress string
@@ -0,0 +1,316 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/livepeer/catalyst-api/video"
+	"github.com/peterbourgon/ff/v3"
+	"sigs.k8s.io/yaml"
+)
+
+// Sections of a YAML or TOML config file that don't map onto flags
+const (
+	sectionTranscodeProfiles = "transcode_profiles"
+	sectionCDNRedirects      = "cdn_redirects"
+	sectionCallbacks         = "callbacks"
+	sectionTenants           = "tenants"
+)
+
+// CallbackPolicy is how the periodic transcode status callbacks are sent
+type CallbackPolicy struct {
+	Interval     time.Duration
+	RetryMax     int
+	RetryWaitMin time.Duration
+	RetryWaitMax time.Duration
+	Timeout      time.Duration
+}
+
+var DefaultCallbackPolicy = CallbackPolicy{
+	Interval:     15 * time.Second,
+	RetryMax:     2,
+	RetryWaitMin: 200 * time.Millisecond,
+	RetryWaitMax: 1 * time.Second,
+	Timeout:      5 * time.Second,
+}
+
+// CDNRedirect is an entry of the cdn_redirects section, the same as an entry of -cdn-redirect-playback-ids
+type CDNRedirect struct {
+	PlaybackID string `json:"playback_id"`
+	// Percent of the traffic redirected, all of it if unset
+	Percent *float64 `json:"percent"`
+}
+
+func (r CDNRedirect) percent() (float64, error) {
+	percent := 100.0
+	if r.Percent != nil {
+		percent = *r.Percent
+	}
+	if r.PlaybackID == "" || math.IsNaN(percent) || percent < 0 || percent > 100 {
+		return 0, fmt.Errorf("invalid CDN redirect %q - needs a playback ID and a percentage between 0.0 and 100.0", r.PlaybackID)
+	}
+	return percent, nil
+}
+
+// ParseCDNRedirects reads a JSON list of CDNRedirect, the format of the cdn_redirects section, into the playback IDs
+// and percentages of -cdn-redirect-playback-ids
+func ParseCDNRedirects(data []byte) (map[string]float64, error) {
+	var redirects []CDNRedirect
+	if err := json.Unmarshal(data, &redirects); err != nil {
+		return nil, fmt.Errorf("invalid CDN redirects: %w", err)
+	}
+	pcts := make(map[string]float64, len(redirects))
+	for _, redirect := range redirects {
+		percent, err := redirect.percent()
+		if err != nil {
+			return nil, err
+		}
+		pcts[redirect.PlaybackID] = percent
+	}
+	return pcts, nil
+}
+
+// callbackPolicySection overrides the fields of the callback policy that are set in the file
+type callbackPolicySection struct {
+	Interval     *string `json:"interval"`
+	RetryMax     *int    `json:"retry_max"`
+	RetryWaitMin *string `json:"retry_wait_min"`
+	RetryWaitMax *string `json:"retry_wait_max"`
+	Timeout      *string `json:"timeout"`
+}
+
+type configFileSections struct {
+	TranscodeProfiles []video.EncodedProfile   `json:"transcode_profiles"`
+	CDNRedirects      []CDNRedirect            `json:"cdn_redirects"`
+	Callbacks         *callbackPolicySection   `json:"callbacks"`
+	Tenants           map[string]tenantSection `json:"tenants"`
+}
+
+// flagFormats is how lists and maps from a config file are joined for the flags that don't take comma separated
+// values and key=value pairs
+var flagFormats = map[string]flagFormat{}
+
+type flagFormat struct {
+	listSeparator  string
+	valueSeparator string
+}
+
+// LoadConfigFile sets the flags and the structured settings of cli from the -config file, which take precedence
+// over the command line and the environment. The format is picked from the extension: YAML (.yaml, .yml), TOML
+// (.toml) or otherwise the plain "flag value" lines.
+func LoadConfigFile(fs *flag.FlagSet, path string, cli *Cli) (*ConfigFileReloader, error) {
+	reloader := &ConfigFileReloader{
+		path:    path,
+		flags:   fs,
+		startup: reloadableFromFlags(fs, cli),
+	}
+	setFlag := func(name, value string) error {
+		if fs.Lookup(name) == nil {
+			return fmt.Errorf("config file flag %q not defined", name)
+		}
+		if err := fs.Set(name, value); err != nil {
+			return fmt.Errorf("invalid config file value for -%s: %w", name, err)
+		}
+		return nil
+	}
+	if err := parseConfigFile(path, setFlag, cli); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+// parseConfigFile calls setFlag with each flag of the config file, after applying its structured sections to cli
+func parseConfigFile(path string, setFlag func(name, value string) error, cli *Cli) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+
+	var values map[string]any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		_, err = toml.Decode(string(data), &values)
+	default:
+		return ff.PlainParser(bytes.NewReader(data), setFlag)
+	}
+	if err != nil {
+		return fmt.Errorf("error parsing config file: %w", err)
+	}
+
+	sections := map[string]any{}
+	for _, section := range []string{sectionTranscodeProfiles, sectionCDNRedirects, sectionCallbacks, sectionTenants} {
+		if v, ok := values[section]; ok {
+			sections[section] = v
+			delete(values, section)
+		}
+	}
+	if err := cli.applyConfigFileSections(sections); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value, err := flagValue(name, values[name])
+		if err != nil {
+			return err
+		}
+		if err := setFlag(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flagValue formats a value from a YAML or TOML file the way it would be passed to the flag
+func flagValue(name string, value any) (string, error) {
+	format, ok := flagFormats[name]
+	if !ok {
+		format = flagFormat{listSeparator: ",", valueSeparator: "="}
+	}
+	switch v := value.(type) {
+	case []any:
+		elements := make([]string, len(v))
+		for i, element := range v {
+			s, ok := scalarFlagValue(element)
+			if !ok {
+				return "", fmt.Errorf("unsupported config file value for -%s, lists can only contain strings, numbers and booleans", name)
+			}
+			elements[i] = s
+		}
+		return strings.Join(elements, format.listSeparator), nil
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		pairs := make([]string, len(keys))
+		for i, k := range keys {
+			s, ok := scalarFlagValue(v[k])
+			if !ok {
+				return "", fmt.Errorf("unsupported config file value for -%s, maps can only contain strings, numbers and booleans", name)
+			}
+			pairs[i] = k + format.valueSeparator + s
+		}
+		return strings.Join(pairs, ","), nil
+	}
+	s, ok := scalarFlagValue(value)
+	if !ok {
+		return "", fmt.Errorf("unsupported config file value for -%s", name)
+	}
+	return s, nil
+}
+
+func scalarFlagValue(value any) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", true
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case float64:
+		// YAML numbers are decoded as floats, formatted without an exponent so that they can be parsed as ints
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+func (cli *Cli) applyConfigFileSections(sections map[string]any) error {
+	if len(sections) == 0 {
+		return nil
+	}
+	// round trip through JSON so that the sections are decoded the same way from YAML and TOML, with the same
+	// field names as the API
+	data, err := json.Marshal(sections)
+	if err != nil {
+		return fmt.Errorf("error reading config file sections: %w", err)
+	}
+	var parsed configFileSections
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&parsed); err != nil {
+		return fmt.Errorf("error parsing config file sections: %w", err)
+	}
+
+	if err := video.ValidateLadder(parsed.TranscodeProfiles); err != nil {
+		return fmt.Errorf("invalid transcode_profiles: %w", err)
+	}
+	if parsed.TranscodeProfiles != nil {
+		cli.TranscodeProfiles = parsed.TranscodeProfiles
+	}
+
+	for _, redirect := range parsed.CDNRedirects {
+		percent, err := redirect.percent()
+		if err != nil {
+			return err
+		}
+		if cli.CdnRedirectPlaybackPct == nil {
+			cli.CdnRedirectPlaybackPct = map[string]float64{}
+		}
+		cli.CdnRedirectPlaybackPct[redirect.PlaybackID] = percent
+	}
+
+	if parsed.Callbacks != nil {
+		if err := parsed.Callbacks.apply(&cli.Callbacks); err != nil {
+			return fmt.Errorf("invalid callbacks: %w", err)
+		}
+	}
+
+	if parsed.Tenants != nil {
+		if cli.Tenants, err = parsed.tenants(cli.Callbacks); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply overrides the fields of the policy that are set in the section
+func (c *callbackPolicySection) apply(policy *CallbackPolicy) error {
+	for _, d := range []struct {
+		name  string
+		value *string
+		dest  *time.Duration
+	}{
+		{"interval", c.Interval, &policy.Interval},
+		{"retry_wait_min", c.RetryWaitMin, &policy.RetryWaitMin},
+		{"retry_wait_max", c.RetryWaitMax, &policy.RetryWaitMax},
+		{"timeout", c.Timeout, &policy.Timeout},
+	} {
+		if d.value == nil {
+			continue
+		}
+		var err error
+		if *d.dest, err = time.ParseDuration(*d.value); err != nil || *d.dest <= 0 {
+			return fmt.Errorf("%s %q - should be a positive duration, e.g. 500ms", d.name, *d.value)
+		}
+	}
+	if c.RetryMax != nil {
+		if *c.RetryMax < 0 {
+			return fmt.Errorf("retry_max %d - should not be negative", *c.RetryMax)
+		}
+		policy.RetryMax = *c.RetryMax
+	}
+	if policy.RetryWaitMin > policy.RetryWaitMax {
+		return fmt.Errorf("retry_wait_min should not be longer than retry_wait_max")
+	}
+	return nil
+}
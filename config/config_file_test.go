@@ -0,0 +1,173 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/livepeer/catalyst-api/video"
+	"github.com/stretchr/testify/require"
+)
+
+func newConfigFileFlags(cli *Cli) *flag.FlagSet {
+	fs := flag.NewFlagSet("cli-test", flag.ContinueOnError)
+	fs.StringVar(&cli.MistHost, "mist-host", "127.0.0.1", "")
+	fs.IntVar(&cli.MistPort, "mist-port", 4242, "")
+	InvertedBoolFlag(fs, &cli.MistEnabled, "mist", true, "")
+	SpaceSliceFlag(fs, &cli.BalancerArgs, "balancer-args", []string{}, "")
+	CommaSliceFlag(fs, &cli.RedirectPrefixes, "redirect-prefixes", []string{}, "")
+	CommaMapFlag(fs, &cli.Tags, "tags", map[string]string{"node": "media"}, "")
+	CommaWithPctSliceFlag(fs, &cli.CdnRedirectPlaybackPct, "cdn-redirect-playback-ids", map[string]float64{}, "")
+	cli.Callbacks = DefaultCallbackPolicy
+	return fs
+}
+
+func writeConfigFile(t *testing.T, name, contents string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0600))
+	return path
+}
+
+func TestLoadConfigFileYAML(t *testing.T) {
+	var cli Cli
+	fs := newConfigFileFlags(&cli)
+	require.NoError(t, fs.Parse([]string{"-mist-host=mist.example.com"}))
+
+	path := writeConfigFile(t, "catalyst-api.yaml", `
+mist-host: file.example.com
+mist-port: 1000000
+no-mist: true
+balancer-args: [-P, "10"]
+redirect-prefixes: [video, videorec]
+tags:
+  node: media
+  region: fra
+cdn-redirect-playback-ids:
+  abc: 0.5
+transcode_profiles:
+  - name: 1080p0
+    width: 1920
+    height: 1080
+    bitrate: 6000000
+  - name: 480p0
+    width: 854
+    height: 480
+    bitrate: 1500000
+    quality: 23
+cdn_redirects:
+  - playback_id: abc
+    percent: 20
+  - playback_id: def
+callbacks:
+  interval: 5s
+  retry_max: 4
+  timeout: 10s
+`)
+	_, err := LoadConfigFile(fs, path, &cli)
+	require.NoError(t, err)
+
+	require.Equal(t, "file.example.com", cli.MistHost, "the config file takes precedence over the command line")
+	require.Equal(t, 1000000, cli.MistPort)
+	require.False(t, cli.MistEnabled)
+	require.Equal(t, []string{"-P", "10"}, cli.BalancerArgs)
+	require.Equal(t, []string{"video", "videorec"}, cli.RedirectPrefixes)
+	require.Equal(t, map[string]string{"node": "media", "region": "fra"}, cli.Tags)
+	require.Equal(t, map[string]float64{"abc": 0.5, "def": 100}, cli.CdnRedirectPlaybackPct)
+	require.Equal(t, []video.EncodedProfile{
+		{Name: "1080p0", Width: 1920, Height: 1080, Bitrate: 6_000_000, Quality: video.DefaultQuality},
+		{Name: "480p0", Width: 854, Height: 480, Bitrate: 1_500_000, Quality: 23},
+	}, cli.TranscodeProfiles)
+	require.Equal(t, CallbackPolicy{
+		Interval:     5 * time.Second,
+		RetryMax:     4,
+		RetryWaitMin: DefaultCallbackPolicy.RetryWaitMin,
+		RetryWaitMax: DefaultCallbackPolicy.RetryWaitMax,
+		Timeout:      10 * time.Second,
+	}, cli.Callbacks)
+}
+
+func TestLoadConfigFileTOML(t *testing.T) {
+	var cli Cli
+	fs := newConfigFileFlags(&cli)
+	require.NoError(t, fs.Parse(nil))
+
+	path := writeConfigFile(t, "catalyst-api.toml", `
+mist-host = "mist.example.com"
+mist-port = 4343
+redirect-prefixes = ["video"]
+
+[tags]
+node = "edge"
+
+[callbacks]
+retry_wait_min = "100ms"
+retry_wait_max = "2s"
+
+[[transcode_profiles]]
+name = "720p0"
+width = 1280
+height = 720
+bitrate = 3000000
+
+[[cdn_redirects]]
+playback_id = "abc"
+percent = 1.5
+`)
+	_, err := LoadConfigFile(fs, path, &cli)
+	require.NoError(t, err)
+
+	require.Equal(t, "mist.example.com", cli.MistHost)
+	require.Equal(t, 4343, cli.MistPort)
+	require.Equal(t, []string{"video"}, cli.RedirectPrefixes)
+	require.Equal(t, map[string]string{"node": "edge"}, cli.Tags)
+	require.Equal(t, map[string]float64{"abc": 1.5}, cli.CdnRedirectPlaybackPct)
+	require.Equal(t, []video.EncodedProfile{
+		{Name: "720p0", Width: 1280, Height: 720, Bitrate: 3_000_000, Quality: video.DefaultQuality},
+	}, cli.TranscodeProfiles)
+	require.Equal(t, 100*time.Millisecond, cli.Callbacks.RetryWaitMin)
+	require.Equal(t, 2*time.Second, cli.Callbacks.RetryWaitMax)
+	require.Equal(t, DefaultCallbackPolicy.Interval, cli.Callbacks.Interval)
+}
+
+func TestLoadConfigFilePlain(t *testing.T) {
+	var cli Cli
+	fs := newConfigFileFlags(&cli)
+	require.NoError(t, fs.Parse(nil))
+
+	path := writeConfigFile(t, "catalyst-api.conf", "mist-host mist.example.com\nbalancer-args -P 10\n")
+	_, err := LoadConfigFile(fs, path, &cli)
+	require.NoError(t, err)
+	require.Equal(t, "mist.example.com", cli.MistHost)
+	require.Equal(t, []string{"-P", "10"}, cli.BalancerArgs)
+	require.Nil(t, cli.TranscodeProfiles)
+}
+
+func TestLoadConfigFileErrors(t *testing.T) {
+	for name, contents := range map[string]string{
+		"unknown flag":          "not-a-flag: 1",
+		"invalid flag value":    "mist-port: abc",
+		"nested flag value":     "tags: {node: {name: media}}",
+		"unknown section field": "callbacks: {retries: 3}",
+		"profile without name":  "transcode_profiles: [{bitrate: 1000000}]",
+		"profile without size":  "transcode_profiles: [{name: 360p0, bitrate: 1000000}]",
+		"unknown encoder":       "transcode_profiles: [{name: 360p0, width: 640, height: 360, bitrate: 1000000, encoder: av1}]",
+		"invalid percentage":    "cdn_redirects: [{playback_id: abc, percent: 101}]",
+		"invalid duration":      "callbacks: {timeout: 5}",
+		"inverted retry waits":  "callbacks: {retry_wait_min: 2s, retry_wait_max: 1s}",
+		"negative retries":      "callbacks: {retry_max: -1}",
+	} {
+		t.Run(name, func(t *testing.T) {
+			var cli Cli
+			fs := newConfigFileFlags(&cli)
+			require.NoError(t, fs.Parse(nil))
+			_, err := LoadConfigFile(fs, writeConfigFile(t, "catalyst-api.yml", contents), &cli)
+			require.Error(t, err)
+		})
+	}
+
+	var cli Cli
+	_, err := LoadConfigFile(newConfigFileFlags(&cli), filepath.Join(t.TempDir(), "missing.yaml"), &cli)
+	require.Error(t, err)
+}
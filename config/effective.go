@@ -0,0 +1,216 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/livepeer/catalyst-api/video"
+)
+
+// Where the value of a flag comes from, in order of precedence
+const (
+	SourceDefault       = "default"
+	SourceEnv           = "env"
+	SourceFlag          = "flag"
+	SourceConfigFile    = "config-file"
+	SourceSecretFile    = "secret-file"
+	SourceSecretManager = "secret-manager"
+	// SourceReload is a value reloaded from the config file on SIGHUP
+	SourceReload = "reload"
+)
+
+// flagSources records the value each flag was set to and where from. The value is needed for the flags defined
+// with fs.Func, whose String() is always empty.
+type flagSources struct {
+	// source of the values being set
+	current string
+	flags   map[string]EffectiveFlag
+}
+
+// sourcedValue wraps the value of a flag to record where it's set from
+type sourcedValue struct {
+	flag.Value
+	name    string
+	sources *flagSources
+}
+
+func (v *sourcedValue) Set(s string) error {
+	return v.setFrom(s, v.sources.current)
+}
+
+func (v *sourcedValue) setFrom(s, source string) error {
+	if err := v.Value.Set(s); err != nil {
+		return err
+	}
+	v.sources.flags[v.name] = EffectiveFlag{Value: s, Source: source}
+	return nil
+}
+
+func (v *sourcedValue) String() string {
+	if v == nil || v.Value == nil {
+		return ""
+	}
+	return v.Value.String()
+}
+
+func (v *sourcedValue) IsBoolFlag() bool {
+	b, ok := v.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// recordFlagSources wraps every flag of fs, so that each value set is recorded in the returned sources
+func recordFlagSources(fs *flag.FlagSet) *flagSources {
+	sources := &flagSources{current: SourceFlag, flags: map[string]EffectiveFlag{}}
+	fs.VisitAll(func(f *flag.Flag) {
+		f.Value = &sourcedValue{Value: f.Value, name: f.Name, sources: sources}
+	})
+	return sources
+}
+
+// setFlagFrom sets a flag, recording the given source if the flag's sources are recorded
+func setFlagFrom(fs *flag.FlagSet, name, value, source string) error {
+	f := fs.Lookup(name)
+	if f == nil {
+		return fmt.Errorf("flag -%s not defined", name)
+	}
+	if v, ok := f.Value.(*sourcedValue); ok {
+		return v.setFrom(value, source)
+	}
+	return f.Value.Set(value)
+}
+
+// EffectiveFlag is the value of a flag in effect and where it comes from
+type EffectiveFlag struct {
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// EffectiveConfig is the config a node is running with, merged from the defaults, the environment, the command line
+// and the config file, with the secrets redacted
+type EffectiveConfig struct {
+	Flags map[string]EffectiveFlag `json:"flags"`
+	// TranscodeProfiles is the default ladder
+	TranscodeProfiles []video.EncodedProfile     `json:"transcode_profiles"`
+	Callbacks         effectiveCallbackPolicy    `json:"callbacks"`
+	Tenants           map[string]effectiveTenant `json:"tenants,omitempty"`
+}
+
+type effectiveCallbackPolicy struct {
+	Interval     string `json:"interval"`
+	RetryMax     int    `json:"retry_max"`
+	RetryWaitMin string `json:"retry_wait_min"`
+	RetryWaitMax string `json:"retry_wait_max"`
+	Timeout      string `json:"timeout"`
+}
+
+type effectiveTenant struct {
+	APIKeys           []string                 `json:"api_keys"`
+	TranscodeProfiles []video.EncodedProfile   `json:"transcode_profiles,omitempty"`
+	OutputURL         string                   `json:"output_url,omitempty"`
+	Callbacks         *effectiveCallbackPolicy `json:"callbacks,omitempty"`
+}
+
+func newEffectiveCallbackPolicy(p CallbackPolicy) effectiveCallbackPolicy {
+	return effectiveCallbackPolicy{
+		Interval:     p.Interval.String(),
+		RetryMax:     p.RetryMax,
+		RetryWaitMin: p.RetryWaitMin.String(),
+		RetryWaitMax: p.RetryWaitMax.String(),
+		Timeout:      p.Timeout.String(),
+	}
+}
+
+// NewEffectiveConfig returns the config in effect once the flags are parsed and the secrets resolved
+func NewEffectiveConfig(fs *flag.FlagSet, cli *Cli) *EffectiveConfig {
+	secretFlags := map[string]bool{}
+	for _, name := range SecretFlags {
+		secretFlags[name] = true
+	}
+	c := &EffectiveConfig{
+		Flags:             map[string]EffectiveFlag{},
+		TranscodeProfiles: cli.TranscodeProfiles,
+		Callbacks:         newEffectiveCallbackPolicy(cli.Callbacks),
+	}
+	if c.TranscodeProfiles == nil {
+		c.TranscodeProfiles = video.DefaultTranscodeProfiles
+	}
+
+	fs.VisitAll(func(f *flag.Flag) {
+		effective := EffectiveFlag{Value: f.Value.String(), Source: SourceDefault}
+		if cli.flagSources != nil {
+			if set, ok := cli.flagSources.flags[f.Name]; ok {
+				effective.Source = set.Source
+				// the flags defined with fs.Func don't print their value
+				if effective.Value == "" {
+					effective.Value = set.Value
+				}
+			}
+		}
+		if secretFlags[f.Name] && effective.Value != "" {
+			effective.Value = "REDACTED"
+		} else {
+			effective.Value = redactURLs(effective.Value)
+		}
+		c.Flags[f.Name] = effective
+	})
+
+	if len(cli.Tenants) > 0 {
+		c.Tenants = map[string]effectiveTenant{}
+	}
+	for name, tenant := range cli.Tenants {
+		t := effectiveTenant{APIKeys: tenant.APIKeys, TranscodeProfiles: tenant.TranscodeProfiles}
+		if tenant.OutputURL != nil {
+			t.OutputURL = tenant.OutputURL.Redacted()
+		}
+		if tenant.Callbacks != nil {
+			policy := newEffectiveCallbackPolicy(*tenant.Callbacks)
+			t.Callbacks = &policy
+		}
+		c.Tenants[name] = t
+	}
+	return c
+}
+
+// redactURLs redacts each URL of a comma separated list, as -private-bucket and the other URL list flags take
+func redactURLs(value string) string {
+	values := strings.Split(value, ",")
+	for i, v := range values {
+		values[i] = log.RedactURL(v)
+	}
+	return strings.Join(values, ",")
+}
+
+// WithReloaded returns the config with the flags reloaded on SIGHUP, if any
+func (c *EffectiveConfig) WithReloaded(r *ReloadableConfig) *EffectiveConfig {
+	if r == nil {
+		return c
+	}
+	next := *c
+	next.Flags = make(map[string]EffectiveFlag, len(c.Flags))
+	for name, f := range c.Flags {
+		next.Flags[name] = f
+	}
+
+	redirects := make([]string, 0, len(r.CdnRedirectPlaybackPct))
+	for playbackID, pct := range r.CdnRedirectPlaybackPct {
+		redirects = append(redirects, playbackID+":"+strconv.FormatFloat(pct, 'f', -1, 64))
+	}
+	sort.Strings(redirects)
+	reloaded := map[string]string{
+		"cdn-redirect-playback-ids": strings.Join(redirects, ","),
+		"gate-blocked-jwts":         strings.Join(r.BlockedJWTs, ","),
+	}
+	if r.Verbosity != "" {
+		reloaded["v"] = r.Verbosity
+	}
+	for name, value := range reloaded {
+		if _, ok := next.Flags[name]; ok {
+			next.Flags[name] = EffectiveFlag{Value: value, Source: SourceReload}
+		}
+	}
+	return &next
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/livepeer/catalyst-api/log"
+)
+
+// ReloadableConfig is the subset of the config that's reloaded from the -config file on SIGHUP, so that it can be
+// changed without restarting a node carrying live streams: -cdn-redirect-playback-ids with the cdn_redirects
+// section, -gate-blocked-jwts and -v
+type ReloadableConfig struct {
+	CdnRedirectPlaybackPct map[string]float64
+	BlockedJWTs            []string
+	// Verbosity is the glog -v level, left unchanged if it isn't set
+	Verbosity string
+}
+
+var reloaded atomic.Pointer[ReloadableConfig]
+
+// Reloaded returns the config from the last reload, nil if it hasn't been reloaded since startup
+func Reloaded() *ReloadableConfig {
+	return reloaded.Load()
+}
+
+// ApplyReloaded makes the reloaded config current. The log verbosity is the only part that can fail to apply, and
+// is changed first so that nothing else changes if it does.
+func ApplyReloaded(r *ReloadableConfig) error {
+	if r.Verbosity != "" {
+		level, err := strconv.Atoi(r.Verbosity)
+		if err != nil {
+			return fmt.Errorf("invalid -v %q: %w", r.Verbosity, err)
+		}
+		if err := log.SetVerbosity(level, ""); err != nil {
+			return err
+		}
+	}
+	reloaded.Store(r)
+	return nil
+}
+
+// ConfigFileReloader re-reads the reloadable subset of the -config file
+type ConfigFileReloader struct {
+	path string
+	// flags are the startup flags, to tell flags that need a restart apart from undefined ones
+	flags *flag.FlagSet
+	// startup is the config from the command line and the environment, which the file is applied over
+	startup ReloadableConfig
+}
+
+func reloadableFromFlags(fs *flag.FlagSet, cli *Cli) ReloadableConfig {
+	r := ReloadableConfig{
+		CdnRedirectPlaybackPct: map[string]float64{},
+		BlockedJWTs:            cli.BlockedJWTs,
+	}
+	for playbackID, pct := range cli.CdnRedirectPlaybackPct {
+		r.CdnRedirectPlaybackPct[playbackID] = pct
+	}
+	if v := fs.Lookup("v"); v != nil {
+		r.Verbosity = v.Value.String()
+	}
+	return r
+}
+
+// Reload parses the config file again over the startup config, returning the new reloadable config without applying
+// it. The whole file is validated, so a reload fails without changing anything if any of it is invalid. Changes to
+// the other flags and sections are ignored until the next restart.
+func (c *ConfigFileReloader) Reload() (*ReloadableConfig, error) {
+	var next Cli
+	var verbosity string
+	fs := flag.NewFlagSet("reload", flag.ContinueOnError)
+	redirects := map[string]float64{}
+	for playbackID, pct := range c.startup.CdnRedirectPlaybackPct {
+		redirects[playbackID] = pct
+	}
+	CommaWithPctSliceFlag(fs, &next.CdnRedirectPlaybackPct, "cdn-redirect-playback-ids", redirects, "")
+	CommaSliceFlag(fs, &next.BlockedJWTs, "gate-blocked-jwts", c.startup.BlockedJWTs, "")
+	fs.StringVar(&verbosity, "v", c.startup.Verbosity, "")
+
+	setFlag := func(name, value string) error {
+		if c.flags.Lookup(name) == nil {
+			return fmt.Errorf("config file flag %q not defined", name)
+		}
+		if fs.Lookup(name) == nil {
+			return nil
+		}
+		if err := fs.Set(name, value); err != nil {
+			return fmt.Errorf("invalid config file value for -%s: %w", name, err)
+		}
+		return nil
+	}
+	if err := parseConfigFile(c.path, setFlag, &next); err != nil {
+		return nil, err
+	}
+
+	if verbosity != "" {
+		if level, err := strconv.Atoi(verbosity); err != nil || level < 0 {
+			return nil, fmt.Errorf("invalid config file value for -v: %q", verbosity)
+		}
+	}
+	return &ReloadableConfig{
+		CdnRedirectPlaybackPct: next.CdnRedirectPlaybackPct,
+		BlockedJWTs:            next.BlockedJWTs,
+		Verbosity:              verbosity,
+	}, nil
+}
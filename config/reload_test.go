@@ -0,0 +1,76 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloadConfigFile(t *testing.T) {
+	var cli Cli
+	fs := newConfigFileFlags(&cli)
+	CommaSliceFlag(fs, &cli.BlockedJWTs, "gate-blocked-jwts", []string{}, "")
+	fs.String("v", "", "")
+	require.NoError(t, fs.Parse([]string{"-gate-blocked-jwts=cmdline", "-cdn-redirect-playback-ids=xyz:1"}))
+
+	path := writeConfigFile(t, "catalyst-api.yaml", `
+mist-port: 1000
+v: 3
+gate-blocked-jwts: [file]
+cdn-redirect-playback-ids: {abc: 50}
+`)
+	reloader, err := LoadConfigFile(fs, path, &cli)
+	require.NoError(t, err)
+	require.Equal(t, []string{"file"}, cli.BlockedJWTs)
+	require.Equal(t, map[string]float64{"abc": 50, "xyz": 1}, cli.CdnRedirectPlaybackPct)
+
+	require.NoError(t, os.WriteFile(path, []byte(`
+mist-port: 2000
+v: 5
+cdn_redirects:
+  - playback_id: def
+  - playback_id: xyz
+    percent: 2
+`), 0600))
+	reloaded, err := reloader.Reload()
+	require.NoError(t, err)
+	require.Equal(t, &ReloadableConfig{
+		CdnRedirectPlaybackPct: map[string]float64{"def": 100, "xyz": 2},
+		BlockedJWTs:            []string{"cmdline"},
+		Verbosity:              "5",
+	}, reloaded, "settings removed from the file go back to the command line values")
+	require.Equal(t, 1000, cli.MistPort, "flags that aren't reloadable need a restart")
+
+	for name, contents := range map[string]string{
+		"invalid verbosity":  "v: debug",
+		"invalid percentage": "cdn_redirects: [{playback_id: abc, percent: 200}]",
+		"invalid section":    "callbacks: {timeout: 5}",
+		"unknown flag":       "not-a-flag: 1",
+		"unparseable":        "cdn-redirect-playback-ids: [",
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, os.WriteFile(path, []byte(contents), 0600))
+			_, err := reloader.Reload()
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestApplyReloaded(t *testing.T) {
+	previousLevel := flag.Lookup("v").Value.String()
+	t.Cleanup(func() {
+		reloaded.Store(nil)
+		require.NoError(t, flag.Lookup("v").Value.Set(previousLevel))
+	})
+
+	require.Nil(t, Reloaded())
+	r := &ReloadableConfig{CdnRedirectPlaybackPct: map[string]float64{"abc": 10}, Verbosity: "7"}
+	require.NoError(t, ApplyReloaded(r))
+	require.Equal(t, r, Reloaded())
+	require.Equal(t, "7", flag.Lookup("v").Value.String())
+
+	require.Error(t, ApplyReloaded(&ReloadableConfig{Verbosity: "-1"}))
+	require.Equal(t, r, Reloaded(), "a failed reload keeps the previous config")
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/livepeer/catalyst-api/secrets"
+)
+
+// SecretFlags can also be read from a file with -<flag>-file, or from a secret manager by setting them to a
+// reference such as aws-sm:prod/catalyst#api_token or vault:secret/data/catalyst#api_token
+var SecretFlags = []string{
+	"api-token",
+	"source-output",
+	"external-transcoder",
+	"metrics-db-connection-string",
+	"node-stats-connection-string",
+	"mist-password",
+	"amqp-url",
+	"encrypt",
+	"catalyst-private-key",
+	"catalyst-x25519-private-key",
+	"catalyst-previous-private-keys",
+	"vault-token",
+	"playback-signing-secret",
+	"playback-signing-key",
+	"hls-key-secret",
+	"srt-passphrase-secret",
+	"recording-url",
+	"kafka-password",
+	"session-export-url",
+	"output-backup-urls",
+}
+
+const secretFileSuffix = "-file"
+
+// registerSecretFileFlags adds a -<flag>-file flag for each secret flag, so that CATALYST_API_<FLAG>_FILE can be
+// used too
+func registerSecretFileFlags(fs *flag.FlagSet) {
+	for _, name := range SecretFlags {
+		if fs.Lookup(name) == nil || fs.Lookup(name+secretFileSuffix) != nil {
+			continue
+		}
+		fs.String(name+secretFileSuffix, "", fmt.Sprintf("File to read -%s from", name))
+	}
+}
+
+// loadSecretFiles sets the secret flags from their -<flag>-file, which takes precedence over the flag itself
+func loadSecretFiles(fs *flag.FlagSet) error {
+	for _, name := range SecretFlags {
+		file := fs.Lookup(name + secretFileSuffix)
+		if file == nil || file.Value.String() == "" {
+			continue
+		}
+		b, err := os.ReadFile(file.Value.String())
+		if err != nil {
+			return fmt.Errorf("error reading -%s: %w", file.Name, err)
+		}
+		if err := setFlagFrom(fs, name, strings.TrimRight(string(b), "\r\n"), SourceSecretFile); err != nil {
+			return fmt.Errorf("invalid -%s: %w", file.Name, err)
+		}
+	}
+	return nil
+}
+
+// ResolveSecrets replaces the secret flags referencing a secret manager with the secret, redacts every secret from
+// the logs and removes the secrets from the environment so that they're only held in memory
+func ResolveSecrets(ctx context.Context, fs *flag.FlagSet, resolver *secrets.Resolver) error {
+	for _, name := range SecretFlags {
+		f := fs.Lookup(name)
+		if f == nil {
+			continue
+		}
+		value := f.Value.String()
+		if secrets.IsReference(value) {
+			secret, err := resolver.Resolve(ctx, value)
+			if err != nil {
+				return fmt.Errorf("invalid -%s: %w", name, err)
+			}
+			if err := setFlagFrom(fs, name, secret, SourceSecretManager); err != nil {
+				return fmt.Errorf("invalid -%s: %w", name, err)
+			}
+			value = secret
+		}
+		if value != f.DefValue {
+			log.RedactSecret(value)
+		}
+		os.Unsetenv(envVarName(name)) // nolint:errcheck
+	}
+	return nil
+}
+
+func envVarName(flagName string) string {
+	return EnvVarPrefix + "_" + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
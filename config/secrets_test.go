@@ -0,0 +1,71 @@
+package config
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/livepeer/catalyst-api/secrets"
+	"github.com/stretchr/testify/require"
+)
+
+func newSecretFlags(cli *Cli) *flag.FlagSet {
+	fs := flag.NewFlagSet("cli-test", flag.ContinueOnError)
+	fs.StringVar(&cli.APIToken, "api-token", "IAmAuthorized", "")
+	fs.StringVar(&cli.MistPassword, "mist-password", "", "")
+	fs.StringVar(&cli.KafkaPassword, "kafka-password", "", "")
+	fs.StringVar(&cli.MistHost, "mist-host", "127.0.0.1", "")
+	return fs
+}
+
+func TestParseFlagsSecretFiles(t *testing.T) {
+	var cli Cli
+	fs := newSecretFlags(&cli)
+	tokenFile := writeConfigFile(t, "api-token", "token-from-file\n")
+	passwordFile := writeConfigFile(t, "mist-password", "password-from-file")
+	t.Setenv("CATALYST_API_MIST_PASSWORD_FILE", passwordFile)
+
+	_, err := ParseFlags(fs, []string{"-api-token=token-from-flag", "-api-token-file=" + tokenFile}, &cli)
+	require.NoError(t, err)
+	require.Equal(t, "token-from-file", cli.APIToken)
+	require.Equal(t, "password-from-file", cli.MistPassword)
+	require.Nil(t, fs.Lookup("mist-host-file"))
+
+	fs = newSecretFlags(&Cli{})
+	_, err = ParseFlags(fs, []string{"-api-token-file=/does/not/exist"}, &Cli{})
+	require.ErrorContains(t, err, "error reading -api-token-file")
+}
+
+func TestResolveSecrets(t *testing.T) {
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "vault-token", r.Header.Get("X-Vault-Token"))
+		require.Equal(t, "/v1/secret/data/catalyst", r.URL.Path)
+		_, _ = w.Write([]byte(`{"data": {"data": {"mist_password": "password-from-vault"}, "metadata": {"version": 1}}}`))
+	}))
+	defer vault.Close()
+
+	var cli Cli
+	fs := newSecretFlags(&cli)
+	t.Setenv("CATALYST_API_MIST_PASSWORD", "vault:secret/data/catalyst#mist_password")
+	_, err := ParseFlags(fs, []string{"-kafka-password=kafka-password"}, &cli)
+	require.NoError(t, err)
+
+	resolver := &secrets.Resolver{VaultAddr: vault.URL, VaultToken: "vault-token"}
+	require.NoError(t, ResolveSecrets(context.Background(), fs, resolver))
+	require.Equal(t, "password-from-vault", cli.MistPassword)
+	require.Equal(t, "kafka-password", cli.KafkaPassword)
+	_, ok := os.LookupEnv("CATALYST_API_MIST_PASSWORD")
+	require.False(t, ok)
+
+	flags := NewEffectiveConfig(fs, &cli).Flags
+	require.Equal(t, EffectiveFlag{Value: "REDACTED", Source: SourceSecretManager}, flags["mist-password"])
+	require.Equal(t, EffectiveFlag{Value: "REDACTED", Source: SourceFlag}, flags["kafka-password"])
+	require.Equal(t, EffectiveFlag{Value: "REDACTED", Source: SourceDefault}, flags["api-token"])
+	require.Equal(t, EffectiveFlag{Value: "127.0.0.1", Source: SourceDefault}, flags["mist-host"])
+
+	require.NoError(t, fs.Set("mist-password", "vault:secret/data/catalyst#missing"))
+	require.ErrorContains(t, ResolveSecrets(context.Background(), fs, resolver), `invalid -mist-password: error reading secret vault:secret/data/catalyst#missing: secret has no key "missing"`)
+}
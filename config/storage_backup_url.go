@@ -1,6 +1,9 @@
 package config
 
-import "strings"
+import (
+	"net/url"
+	"strings"
+)
 
 // GetStorageBackupURL returns the backup URL for the given URL or an empty string if it doesn't exist. The backup URL
 // is found by checking the `StorageFallbackURLs` global config map. If any of the primary URL prefixes (keys in map)
@@ -13,3 +16,21 @@ func GetStorageBackupURL(urlStr string) string {
 	}
 	return ""
 }
+
+// GetOutputBackupURL returns the URL that an upload to the given output URL falls back to, or an empty string if it
+// doesn't have one. The primary prefixes of `OutputBackupURLs` are matched without the credentials of the output URL,
+// since they're usually specific to each request, and the backup URL prefix brings its own.
+func GetOutputBackupURL(urlStr string) string {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return ""
+	}
+	u.User = nil
+	withoutCreds := u.String()
+	for primary, backup := range OutputBackupURLs {
+		if strings.HasPrefix(withoutCreds, primary) {
+			return backup + strings.TrimPrefix(withoutCreds, primary)
+		}
+	}
+	return ""
+}
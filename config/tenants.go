@@ -0,0 +1,116 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/livepeer/catalyst-api/video"
+)
+
+// Tenant overrides the global config for the requests made with its API keys
+type Tenant struct {
+	Name string
+	// APIKeys are the IDs of the tenant's keys from -api-keys-file
+	APIKeys []string
+	// TranscodeProfiles is the default ladder of the tenant's uploads, the global one if nil
+	TranscodeProfiles []video.EncodedProfile
+	// OutputURL is where the tenant's uploads are written when the request has no output locations
+	OutputURL *url.URL
+	// Callbacks is the tenant's callback policy, the global one if nil
+	Callbacks *CallbackPolicy
+	// MaxSourceDuration and MaxSourceBytes limit the sources of the tenant's uploads, checked once they're probed. 0 is
+	// no limit.
+	MaxSourceDuration time.Duration
+	MaxSourceBytes    int64
+}
+
+// tenantSection is an entry of the tenants section of the config file, keyed by the tenant name
+type tenantSection struct {
+	APIKeys           []string               `json:"api_keys"`
+	TranscodeProfiles []video.EncodedProfile `json:"transcode_profiles"`
+	OutputURL         string                 `json:"output_url"`
+	Callbacks         *callbackPolicySection `json:"callbacks"`
+	MaxSourceDuration string                 `json:"max_source_duration"`
+	MaxSourceBytes    int64                  `json:"max_source_bytes"`
+}
+
+// tenants validates the tenants section, the callback policies of the tenants overriding the global one
+func (s configFileSections) tenants(callbacks CallbackPolicy) (map[string]Tenant, error) {
+	names := make([]string, 0, len(s.Tenants))
+	for name := range s.Tenants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tenants := map[string]Tenant{}
+	keyTenants := map[string]string{}
+	for _, name := range names {
+		section := s.Tenants[name]
+		tenant := Tenant{Name: name, APIKeys: section.APIKeys, TranscodeProfiles: section.TranscodeProfiles}
+		if len(section.APIKeys) == 0 {
+			return nil, fmt.Errorf("tenant %q needs api_keys", name)
+		}
+		for _, key := range section.APIKeys {
+			if other, ok := keyTenants[key]; ok {
+				return nil, fmt.Errorf("api key %q belongs to both tenant %q and %q", key, other, name)
+			}
+			keyTenants[key] = name
+		}
+		if err := video.ValidateLadder(tenant.TranscodeProfiles); err != nil {
+			return nil, fmt.Errorf("invalid tenant %q: %w", name, err)
+		}
+		if section.OutputURL != "" {
+			u, err := url.Parse(section.OutputURL)
+			if err != nil || u.Scheme == "" {
+				return nil, fmt.Errorf("invalid tenant %q output_url %q", name, section.OutputURL)
+			}
+			tenant.OutputURL = u
+		}
+		if section.Callbacks != nil {
+			policy := callbacks
+			if err := section.Callbacks.apply(&policy); err != nil {
+				return nil, fmt.Errorf("invalid tenant %q callbacks: %w", name, err)
+			}
+			tenant.Callbacks = &policy
+		}
+		if section.MaxSourceDuration != "" {
+			d, err := time.ParseDuration(section.MaxSourceDuration)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("invalid tenant %q max_source_duration %q - should be a positive duration, e.g. 2h", name, section.MaxSourceDuration)
+			}
+			tenant.MaxSourceDuration = d
+		}
+		if section.MaxSourceBytes < 0 {
+			return nil, fmt.Errorf("invalid tenant %q max_source_bytes %d", name, section.MaxSourceBytes)
+		}
+		tenant.MaxSourceBytes = section.MaxSourceBytes
+		tenants[name] = tenant
+	}
+	return tenants, nil
+}
+
+var tenantsByAPIKey atomic.Pointer[map[string]Tenant]
+
+// SetTenants sets the tenants returned by TenantForAPIKey
+func SetTenants(tenants map[string]Tenant) {
+	byKey := map[string]Tenant{}
+	for _, tenant := range tenants {
+		for _, key := range tenant.APIKeys {
+			byKey[key] = tenant
+		}
+	}
+	tenantsByAPIKey.Store(&byKey)
+}
+
+// TenantForAPIKey returns the tenant of the API key with the given ID, if it belongs to one
+func TenantForAPIKey(keyID string) (Tenant, bool) {
+	byKey := tenantsByAPIKey.Load()
+	if byKey == nil {
+		return Tenant{}, false
+	}
+	tenant, ok := (*byKey)[keyID]
+	return tenant, ok
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/livepeer/catalyst-api/crypto/signedurl"
+)
+
+// Values of -catabalancer that enable it, see balancer.CombinedBalancerEnabled
+var catabalancerModes = []string{"enabled", "background", "playback", "ingest"}
+
+// Validate checks the combinations of flags that would otherwise only fail, or silently do nothing, at runtime. Every
+// problem found is returned, each with a hint on how to fix it.
+func (cli *Cli) Validate() error {
+	var problems []error
+	problem := func(format string, args ...any) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+
+	switch cli.Mode {
+	case "all", "cluster-only", "api-only":
+	default:
+		problem("-mode=%q is unknown: use all, cluster-only or api-only", cli.Mode)
+	}
+
+	if len(cli.CdnRedirectPlaybackPct) > 0 && cli.CdnRedirectPrefix == nil {
+		problem("-cdn-redirect-playback-ids is set without -cdn-redirect-prefix, so nothing would be redirected: set -cdn-redirect-prefix to the CDN URL, e.g. https://externalcdn.livepeer.com/mist/")
+	}
+
+	if cli.CataBalancer != "" {
+		if !slices.Contains(catabalancerModes, cli.CataBalancer) {
+			problem("-catabalancer=%q is unknown: use one of %s, or leave it empty to disable catabalancer", cli.CataBalancer, strings.Join(catabalancerModes, ", "))
+		} else if cli.NodeStatsConnectionString == "" {
+			problem("-catabalancer=%s needs the node stats DB: set -node-stats-connection-string, or leave -catabalancer empty to disable it", cli.CataBalancer)
+		}
+	}
+
+	if cli.Mode == "cluster-only" && cli.CatalystApiURL == "" && !strings.Contains(os.Getenv("HOSTNAME"), "-catalyst-") {
+		problem("-mode=cluster-only can't work out where to send the Serf event callbacks, since $HOSTNAME isn't a <env>-catalyst-<n> pod name: set -catalyst-api-url to the catalyst-api serving this node")
+	}
+
+	if hlsKeySecret, err := cli.HLSKeySecretBytes(); err != nil || (cli.HLSKeySecret != "" && len(hlsKeySecret) < 32) {
+		problem("-hls-key-secret must be a base64 encoded secret of at least 32 bytes: generate one with `openssl rand -base64 32`")
+	}
+
+	for route := range cli.HTTPLimits.RouteMaxBodyBytes {
+		if !slices.Contains(bodyLimitRoutes, route) {
+			problem("-http-route-max-body-bytes has an unknown route %q: use one of %s", route, strings.Join(bodyLimitRoutes, ", "))
+		}
+	}
+
+	if cli.StreamKeyAuth && cli.ShouldMapic() {
+		problem("-stream-key-auth can't be used with -api-server, which authorizes pushes with the Livepeer API: unset one of them")
+	}
+
+	if srtPassphraseSecret, err := cli.SRTPassphraseSecretBytes(); err != nil || (cli.SRTPassphraseSecret != "" && len(srtPassphraseSecret) < 32) {
+		problem("-srt-passphrase-secret must be a base64 encoded secret of at least 32 bytes: generate one with `openssl rand -base64 32`")
+	}
+
+	if cli.EncryptKey != "" {
+		if key, err := cli.EncryptBytes(); err != nil || len(key) != 32 {
+			problem("-encrypt must be a base64 encoded 32 byte key: generate one with `openssl rand -base64 32`")
+		}
+	}
+
+	if cli.RecordingURL != "" {
+		if u, err := url.Parse(cli.RecordingURL); err != nil || u.Scheme == "" {
+			problem("-recording-url must be an object store URL, e.g. s3+https://<key>:<secret>@<host>/<bucket>/recordings")
+		}
+		if cli.Mode == "cluster-only" {
+			problem("-recording-url needs the VOD pipeline to process the recordings, which -mode=cluster-only doesn't run: use -mode=all")
+		}
+	}
+
+	if cli.ViewerLimitPolicyURL != "" {
+		if u, err := url.Parse(cli.ViewerLimitPolicyURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			problem("-viewer-limit-policy-url must be an HTTP(S) URL, e.g. https://policy.example.com/viewer-limits")
+		}
+	}
+	if (len(cli.ViewerLimits) > 0 || cli.ViewerLimitPolicyURL != "") && !cli.MistEnabled {
+		problem("-viewer-limits and -viewer-limit-policy-url count the viewers from Mist, which -no-mist disables: unset one of them")
+	}
+
+	if cli.LLHLS && (cli.LLHLSPartDuration < 100*time.Millisecond || cli.LLHLSPartDuration > 2*time.Second) {
+		problem("-llhls-part-duration must be between 100ms and 2s, e.g. 500ms")
+	}
+
+	if playbackSigner, err := signedurl.FromKeys(cli.PlaybackSigningSecret, cli.PlaybackSigningKey, cli.PlaybackVerificationKey); err != nil {
+		problem("invalid playback signing config: %w", err)
+	} else if playbackSigner == nil && cli.RequireSignedPlayback {
+		problem("-require-signed-playback needs a key to verify the URLs with: set -playback-signing-secret, -playback-signing-key or -playback-verification-key")
+	}
+
+	switch cli.VodDecryptKeyProvider {
+	case "local", "kms", "vault":
+	default:
+		problem("-vod-decrypt-key-provider=%q is unknown: use local, kms or vault", cli.VodDecryptKeyProvider)
+	}
+
+	if cli.CdnRedirectPlaybackIDsURL != "" && cli.CdnRedirectPrefix == nil {
+		problem("-cdn-redirect-playback-ids-url is set without a CDN to redirect to: set -cdn-redirect-prefix")
+	}
+	if cli.CdnRedirectPlaybackIDsURL != "" && cli.CdnRedirectRefreshInterval <= 0 {
+		problem("-cdn-redirect-refresh-interval must be positive when -cdn-redirect-playback-ids-url is set, e.g. 1m")
+	}
+
+	if cli.FeatureFlags.RemoteURL != "" && cli.FeatureFlags.RefreshInterval <= 0 {
+		problem("-feature-flags-refresh-interval must be positive when -feature-flags-url is set, e.g. 1m")
+	}
+	if len(cli.StorageCredentials) > 0 && cli.StorageCredentialsRefresh <= 0 {
+		problem("-storage-credentials-refresh must be positive when -storage-credentials is set, e.g. 5m")
+	}
+	if cli.SourceCacheDir != "" && cli.SourceCacheSize <= 0 {
+		problem("-source-cache-size must be positive when -source-cache-dir is set, e.g. 53687091200 for 50GiB")
+	}
+	if cli.SourceCacheDir != "" && cli.SourceCacheTTL <= 0 {
+		problem("-source-cache-ttl must be positive when -source-cache-dir is set, e.g. 24h")
+	}
+
+	if err := cli.HTTPTLS.Validate(); err != nil {
+		problem("invalid TLS config for -http-addr: %w", err)
+	}
+	if err := cli.HTTPInternalTLS.Validate(); err != nil {
+		problem("invalid TLS config for -http-internal-addr: %w", err)
+	}
+
+	return errors.Join(problems...)
+}
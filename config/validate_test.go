@@ -0,0 +1,100 @@
+package config
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func validCli() Cli {
+	return Cli{Mode: "all", VodDecryptKeyProvider: "local"}
+}
+
+func TestValidate(t *testing.T) {
+	cli := validCli()
+	require.NoError(t, cli.Validate())
+
+	cli.CdnRedirectPlaybackPct = map[string]float64{"abc": 100}
+	cli.CdnRedirectPrefix, _ = url.Parse("https://externalcdn.livepeer.com/mist/")
+	cli.CataBalancer = "enabled"
+	cli.NodeStatsConnectionString = "host=localhost"
+	require.NoError(t, cli.Validate())
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	t.Setenv("HOSTNAME", "localhost")
+	cli := validCli()
+	cli.Mode = "cluster-only"
+	cli.CdnRedirectPlaybackPct = map[string]float64{"abc": 100}
+	cli.CataBalancer = "enabled"
+	cli.HLSKeySecret = "dG9vIHNob3J0"
+	cli.HTTPLimits.RouteMaxBodyBytes = map[string]int{"uploads": 1024}
+	cli.SRTPassphraseSecret = "not base64"
+	cli.StreamKeyAuth = true
+	cli.APIServer = "https://livepeer.studio"
+	cli.EncryptKey = "not base64"
+	cli.RecordingURL = "/recordings"
+	cli.LLHLS = true
+	cli.LLHLSPartDuration = 5 * time.Second
+	cli.RequireSignedPlayback = true
+	cli.VodDecryptKeyProvider = "hsm"
+	cli.HTTPTLS = TLSConfig{CertFile: "cert.pem"}
+	cli.FeatureFlags.RemoteURL = "http://flags.example.com"
+	cli.CdnRedirectPlaybackIDsURL = "http://redirects.example.com"
+	cli.ViewerLimitPolicyURL = "policy.example.com"
+	cli.StorageCredentials = map[string]string{"s3+https://storage.example.com/vod": "file:/secrets/vod"}
+	cli.SourceCacheDir = "/var/cache/catalyst"
+	cli.MistEnabled = false
+
+	err := cli.Validate()
+	require.Error(t, err)
+	for _, problem := range []string{
+		"set -cdn-redirect-prefix",
+		"set -node-stats-connection-string",
+		"set -catalyst-api-url",
+		"-hls-key-secret must be",
+		"-http-route-max-body-bytes has an unknown route \"uploads\"",
+		"-srt-passphrase-secret must be",
+		"-stream-key-auth can't be used with -api-server",
+		"-encrypt must be",
+		"-recording-url must be an object store URL",
+		"-recording-url needs the VOD pipeline",
+		"-llhls-part-duration must be between",
+		"-require-signed-playback needs a key",
+		"-vod-decrypt-key-provider=\"hsm\" is unknown",
+		"invalid TLS config for -http-addr",
+		"-feature-flags-refresh-interval must be positive",
+		"-cdn-redirect-playback-ids-url is set without a CDN",
+		"-cdn-redirect-refresh-interval must be positive",
+		"-storage-credentials-refresh must be positive",
+		"-source-cache-size must be positive",
+		"-source-cache-ttl must be positive",
+		"-viewer-limit-policy-url must be an HTTP(S) URL",
+		"-viewer-limits and -viewer-limit-policy-url count the viewers from Mist",
+	} {
+		require.ErrorContains(t, err, problem)
+	}
+
+	cli = validCli()
+	cli.Mode = "edge"
+	cli.CataBalancer = "on"
+	err = cli.Validate()
+	require.ErrorContains(t, err, "-mode=\"edge\" is unknown")
+	require.ErrorContains(t, err, "-catabalancer=\"on\" is unknown")
+	require.NotContains(t, err.Error(), "-node-stats-connection-string")
+}
+
+func TestValidateClusterOnlyCatalystAPIURL(t *testing.T) {
+	cli := validCli()
+	cli.Mode = "cluster-only"
+
+	t.Setenv("HOSTNAME", "staging-catalyst-0")
+	require.NoError(t, cli.Validate())
+
+	t.Setenv("HOSTNAME", "localhost")
+	require.Error(t, cli.Validate())
+	cli.CatalystApiURL = "http://catalyst-api:7979"
+	require.NoError(t, cli.Validate())
+}
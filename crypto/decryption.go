@@ -2,14 +2,14 @@ package crypto
 
 import (
 	"bufio"
+	"context"
 	"crypto/aes"
 	"crypto/cipher"
-	"crypto/rand"
 	"crypto/rsa"
-	"crypto/sha256"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -18,9 +18,42 @@ import (
 	"github.com/golang/glog"
 )
 
+var (
+	ErrNoDecryptionKey = errors.New("no private key configured for decryption")
+	ErrKeyUnwrap       = errors.New("error decrypting key")
+	ErrInvalidPadding  = errors.New("bad input PKCS#7 padding")
+)
+
+// FailureReason classifies decryption errors for metrics, returning an empty string for errors that didn't
+// come from decrypting, e.g. failures reading the source
+func FailureReason(err error) string {
+	switch {
+	case errors.Is(err, ErrNoDecryptionKey):
+		return "no_key"
+	case errors.Is(err, ErrUnknownKeyID):
+		return "unknown_key_id"
+	case errors.Is(err, ErrKeyUnwrap):
+		return "key_unwrap"
+	case errors.Is(err, ErrAuthenticationFailed):
+		return "authentication_failed"
+	case errors.Is(err, ErrInvalidPadding):
+		return "invalid_padding"
+	}
+	return ""
+}
+
 type DecryptionKeys struct {
-	DecryptKey   *rsa.PrivateKey
+	Provider     KeyProvider
 	EncryptedKey string
+	// Algorithm the input was encrypted with, AlgorithmAESCBC if empty
+	Algorithm string
+	// KeyID of the keypair the key was wrapped for, if empty each of the provider's keys are tried
+	KeyID string
+	// KeyWrapping is how the AES key was shared with catalyst, KeyWrappingRSAOAEP if empty
+	KeyWrapping string
+	// EphemeralPublicKey is the uploader's base64 encoded X25519 public key when using KeyWrappingX25519
+	EphemeralPublicKey string
+	X25519             *X25519Key
 }
 
 func LoadPrivateKey(privateKeyBase64 string) (*rsa.PrivateKey, error) {
@@ -45,24 +78,19 @@ func LoadPrivateKey(privateKeyBase64 string) (*rsa.PrivateKey, error) {
 func ValidateKeyPair(pub string, privkey rsa.PrivateKey) (bool, error) {
 	pubkey, err := base64.StdEncoding.DecodeString(pub)
 	if err != nil {
-		glog.Fatalf("Error decoding base64 encoded key: %v", err)
-		return false, err
+		return false, fmt.Errorf("error decoding base64 encoded public key: %w", err)
 	}
 	block, _ := pem.Decode(pubkey)
 	if block == nil {
-		glog.Fatalf("failed to parse PEM block containing the public key")
-		err := fmt.Errorf("failed to parse PEM block containing the public key")
-		return false, err
+		return false, fmt.Errorf("failed to parse PEM block containing the public key")
 	}
 
 	publicKey, err := x509.ParsePKCS1PublicKey(block.Bytes)
 	if err != nil {
-		glog.Fatalf("Error parsing vod decrypt public key: %v", err)
-		return false, err
+		return false, fmt.Errorf("error parsing vod decrypt public key: %w", err)
 	}
 	if !publicKey.Equal(privkey.Public()) {
-		glog.Fatalf("Public key does not match private key")
-		return false, err
+		return false, fmt.Errorf("public key does not match private key")
 	}
 	return true, nil
 }
@@ -82,17 +110,33 @@ func DecryptAESCBC(reader io.Reader, privateKey *rsa.PrivateKey, encryptedKeyFil
 }
 
 func DecryptAESCBCWithIV(reader io.ReadCloser, privateKey *rsa.PrivateKey, encryptedKeyB64 string, iv []byte) (io.ReadCloser, error) {
+	key, err := unwrapKey(context.Background(), NewPrivateKeyProvider(privateKey), "", encryptedKeyB64)
+	if err != nil {
+		return nil, err
+	}
 
-	encryptedKey, err := base64.StdEncoding.DecodeString(encryptedKeyB64)
+	block, err := aes.NewCipher(key)
 	if err != nil {
-		glog.Errorf("Error decoding base64 encoded key: %v", err)
+		return nil, fmt.Errorf("error creating cipher: %w", err)
 	}
 
-	// Decrypt the key with the RSA private key
-	key, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, privateKey, encryptedKey, nil)
+	return struct {
+		io.Reader
+		io.Closer
+	}{newCBCReader(reader, cipher.NewCBCDecrypter(block, iv)), reader}, nil
+}
+
+// DecryptReader returns a reader that decrypts the input incrementally as it is read, so that sources of any
+// size can be decrypted without buffering them in memory or on disk. For AES-CBC the first block of the input
+// is the IV, see gcmReader for the AES-GCM input format.
+func (k DecryptionKeys) DecryptReader(ctx context.Context, reader io.Reader) (io.Reader, error) {
+	if k.Algorithm != "" && k.Algorithm != AlgorithmAESCBC && k.Algorithm != AlgorithmAESGCM {
+		return nil, fmt.Errorf("unsupported encryption algorithm %q", k.Algorithm)
+	}
 
+	key, err := k.key(ctx)
 	if err != nil {
-		glog.Errorf("Error decrypting key: %v", err)
+		return nil, err
 	}
 
 	block, err := aes.NewCipher(key)
@@ -100,76 +144,138 @@ func DecryptAESCBCWithIV(reader io.ReadCloser, privateKey *rsa.PrivateKey, encry
 		return nil, fmt.Errorf("error creating cipher: %w", err)
 	}
 
-	decrypter := cipher.NewCBCDecrypter(block, iv)
-	pipeReader, pipeWriter := io.Pipe()
+	if k.Algorithm == AlgorithmAESGCM {
+		return newGCMReader(reader, block)
+	}
 
-	go func() {
-		defer reader.Close()
-		defer pipeWriter.Close()
+	iv := make([]byte, block.BlockSize())
+	if _, err := io.ReadFull(reader, iv); err != nil {
+		return nil, fmt.Errorf("error reading iv from input: %w", err)
+	}
 
-		if err := decryptReaderTo(reader, pipeWriter, decrypter); err != nil {
-			pipeWriter.CloseWithError(err)
+	return newCBCReader(reader, cipher.NewCBCDecrypter(block, iv)), nil
+}
+
+// key returns the AES key of the input according to the key wrapping scheme
+func (k DecryptionKeys) key(ctx context.Context) ([]byte, error) {
+	switch k.KeyWrapping {
+	case "", KeyWrappingRSAOAEP:
+		return unwrapKey(ctx, k.Provider, k.KeyID, k.EncryptedKey)
+	case KeyWrappingX25519:
+		if k.X25519 == nil {
+			return nil, fmt.Errorf("%w: x25519 key wrapping is not enabled", ErrNoDecryptionKey)
 		}
-	}()
+		if k.KeyID != "" && k.KeyID != k.X25519.KeyID() {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownKeyID, k.KeyID)
+		}
+		ephemeralPublicKey, err := base64.StdEncoding.DecodeString(k.EphemeralPublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("%w: error decoding base64 encoded ephemeral public key: %w", ErrKeyUnwrap, err)
+		}
+		key, err := k.X25519.DeriveKey(ephemeralPublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrKeyUnwrap, err)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported key wrapping %q", k.KeyWrapping)
+	}
+}
+
+// unwrapKey decrypts the base64 encoded AES key with the provider's RSA private key
+func unwrapKey(ctx context.Context, provider KeyProvider, keyID, encryptedKeyB64 string) ([]byte, error) {
+	if provider == nil {
+		return nil, ErrNoDecryptionKey
+	}
+
+	encryptedKey, err := base64.StdEncoding.DecodeString(encryptedKeyB64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: error decoding base64 encoded key: %w", ErrKeyUnwrap, err)
+	}
 
-	return pipeReader, nil
+	key, err := provider.UnwrapKey(ctx, keyID, encryptedKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrKeyUnwrap, err)
+	}
+	return key, nil
+}
+
+// cbcReader decrypts a CBC stream a chunk at a time as it is read, removing the PKCS#7 padding from the end
+type cbcReader struct {
+	reader    *bufio.Reader
+	decrypter cipher.BlockMode
+	buffer    []byte
+	// decrypted data that hasn't been read yet
+	pending []byte
+	err     error
 }
 
-func decryptReaderTo(readerRaw io.Reader, writer io.Writer, decrypter cipher.BlockMode) (err error) {
+func newCBCReader(reader io.Reader, decrypter cipher.BlockMode) *cbcReader {
+	buffer := make([]byte, 256*decrypter.BlockSize())
+	return &cbcReader{
+		reader:    bufio.NewReaderSize(reader, 2*len(buffer)),
+		decrypter: decrypter,
+		buffer:    buffer,
+	}
+}
+
+func (c *cbcReader) Read(p []byte) (int, error) {
+	for len(c.pending) == 0 {
+		if c.err != nil {
+			return 0, c.err
+		}
+		c.pending, c.err = c.decryptChunk()
+	}
+	n := copy(p, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+func (c *cbcReader) decryptChunk() (chunk []byte, err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			err = fmt.Errorf("panic: %v", r)
+			chunk, err = nil, fmt.Errorf("panic: %v", r)
 		}
 	}()
 
-	blockSize := decrypter.BlockSize()
-	buffer := make([]byte, 256*blockSize)
-	reader := bufio.NewReaderSize(readerRaw, 2*len(buffer))
-
-	for {
-		n, err := io.ReadFull(reader, buffer)
-		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
-			// unexpected EOF is returned when input ends before the buffer size
-			return err
-		} else if n == 0 {
-			break
-		}
-
-		chunk := buffer[:n]
+	blockSize := c.decrypter.BlockSize()
+	n, err := io.ReadFull(c.reader, c.buffer)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		// unexpected EOF is returned when input ends before the buffer size
+		return nil, err
+	} else if n == 0 {
+		return nil, io.EOF
+	}
 
-		// we add some dummy bytes in the end to make a full block and still try to
-		// decrypt. this is non standard and can only ever happen on the last chunk.
-		needsFakePadding := n%blockSize != 0
-		if needsFakePadding {
-			glog.Warningf("Input is not a multiple of AES block size, not padded with PKCS#7")
-			fakePaddingSize := blockSize - (n % blockSize)
-			chunk = buffer[:n+fakePaddingSize]
-		}
+	chunk = c.buffer[:n]
 
-		decrypter.CryptBlocks(chunk, chunk)
+	// we add some dummy bytes in the end to make a full block and still try to
+	// decrypt. this is non standard and can only ever happen on the last chunk.
+	needsFakePadding := n%blockSize != 0
+	if needsFakePadding {
+		glog.Warningf("Input is not a multiple of AES block size, not padded with PKCS#7")
+		fakePaddingSize := blockSize - (n % blockSize)
+		chunk = c.buffer[:n+fakePaddingSize]
+	}
 
-		if needsFakePadding {
-			// remove the fake padding
-			chunk = chunk[:n]
-		} else if _, peekErr := reader.Peek(1); peekErr == io.EOF {
-			// this means we're on the last chunk, so handle padding
-			lastBlock := chunk[len(chunk)-blockSize:]
+	c.decrypter.CryptBlocks(chunk, chunk)
 
-			unpadded, err := pkcs7.Unpad(lastBlock)
-			if err != nil {
-				return fmt.Errorf("bad input PKCS#7 padding: %w", err)
-			}
+	if needsFakePadding {
+		// remove the fake padding
+		return chunk[:n], io.EOF
+	} else if _, peekErr := c.reader.Peek(1); peekErr == io.EOF {
+		// this means we're on the last chunk, so handle padding
+		lastBlock := chunk[len(chunk)-blockSize:]
 
-			padSize := len(lastBlock) - len(unpadded)
-			chunk = chunk[:len(chunk)-padSize]
+		unpadded, err := pkcs7.Unpad(lastBlock)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidPadding, err)
 		}
 
-		if _, err := writer.Write(chunk); err != nil {
-			return err
-		}
+		padSize := len(lastBlock) - len(unpadded)
+		return chunk[:len(chunk)-padSize], io.EOF
 	}
-
-	return nil
+	return chunk, nil
 }
 
 func ConvertToSpki(pemB64PublicKey string) (string, error) {
@@ -0,0 +1,175 @@
+package crypto
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/d1str0/pkcs7"
+	"github.com/stretchr/testify/require"
+)
+
+// encrypt returns the plaintext encrypted with a new AES key in CBC mode, prefixed with the IV, along with the
+// keys needed to decrypt it
+func encrypt(t *testing.T, plaintext []byte) ([]byte, DecryptionKeys) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	key := make([]byte, 32)
+	_, err = rand.Read(key)
+	require.NoError(t, err)
+	encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, &privateKey.PublicKey, key, nil)
+	require.NoError(t, err)
+
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	iv := make([]byte, aes.BlockSize)
+	_, err = rand.Read(iv)
+	require.NoError(t, err)
+	padded, err := pkcs7.Pad(plaintext, aes.BlockSize)
+	require.NoError(t, err)
+	ciphertext := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, padded)
+
+	return append(iv, ciphertext...), DecryptionKeys{
+		Provider:     NewPrivateKeyProvider(privateKey),
+		EncryptedKey: base64.StdEncoding.EncodeToString(encryptedKey),
+	}
+}
+
+func TestDecryptReader(t *testing.T) {
+	// cover inputs smaller than a block, exactly a chunk and spanning several chunks
+	for _, size := range []int{0, 5, aes.BlockSize, 256 * aes.BlockSize, 10*256*aes.BlockSize + 7} {
+		plaintext := make([]byte, size)
+		_, err := rand.Read(plaintext)
+		require.NoError(t, err)
+
+		ciphertext, keys := encrypt(t, plaintext)
+		reader, err := keys.DecryptReader(context.Background(), bytes.NewReader(ciphertext))
+		require.NoError(t, err)
+
+		decrypted, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.Equal(t, plaintext, decrypted, "size %d", size)
+	}
+}
+
+func TestDecryptReaderBadPadding(t *testing.T) {
+	ciphertext, keys := encrypt(t, []byte("some plaintext"))
+	// corrupting the block before the last changes the padding bytes of the last block
+	ciphertext[len(ciphertext)-aes.BlockSize-1] ^= 0xff
+
+	reader, err := keys.DecryptReader(context.Background(), bytes.NewReader(ciphertext))
+	require.NoError(t, err)
+	_, err = io.ReadAll(reader)
+	require.ErrorIs(t, err, ErrInvalidPadding)
+	require.Equal(t, "invalid_padding", FailureReason(err))
+}
+
+func TestDecryptReaderBadKey(t *testing.T) {
+	ciphertext, keys := encrypt(t, []byte("some plaintext"))
+	keys.EncryptedKey = base64.StdEncoding.EncodeToString([]byte("not a key"))
+
+	_, err := keys.DecryptReader(context.Background(), bytes.NewReader(ciphertext))
+	require.ErrorContains(t, err, "error decrypting key")
+	require.Equal(t, "key_unwrap", FailureReason(err))
+
+	keys.Provider = nil
+	_, err = keys.DecryptReader(context.Background(), bytes.NewReader(ciphertext))
+	require.Equal(t, "no_key", FailureReason(err))
+	require.Equal(t, "", FailureReason(io.ErrUnexpectedEOF))
+}
+
+func TestValidateKeyPair(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	valid, err := ValidateKeyPair(EncodePublicKey(&privateKey.PublicKey), *privateKey)
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	// mismatches and malformed keys are returned rather than exiting the process
+	valid, err = ValidateKeyPair(EncodePublicKey(&otherKey.PublicKey), *privateKey)
+	require.ErrorContains(t, err, "does not match")
+	require.False(t, valid)
+
+	_, err = ValidateKeyPair("not base64!", *privateKey)
+	require.Error(t, err)
+	_, err = ValidateKeyPair(base64.StdEncoding.EncodeToString([]byte("not pem")), *privateKey)
+	require.ErrorContains(t, err, "PEM block")
+}
+
+// encryptGCM seals the plaintext in chunks as described on gcmReader
+func encryptGCM(t *testing.T, plaintext []byte) ([]byte, DecryptionKeys) {
+	_, keys := encrypt(t, nil)
+	keys.Algorithm = AlgorithmAESGCM
+	key, err := unwrapKey(context.Background(), keys.Provider, "", keys.EncryptedKey)
+	require.NoError(t, err)
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	aead, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+
+	nonce := make([]byte, aead.NonceSize())
+	_, err = rand.Read(nonce)
+	require.NoError(t, err)
+	out := append([]byte{}, nonce...)
+	for i := uint32(0); ; i++ {
+		end := min(len(plaintext), GCMChunkSize)
+		final := byte(0)
+		if end == len(plaintext) {
+			final = 1
+		}
+		chunkNonce := append([]byte{}, nonce...)
+		binary.BigEndian.PutUint32(chunkNonce[8:], binary.BigEndian.Uint32(nonce[8:])^i)
+		out = aead.Seal(out, chunkNonce, plaintext[:end], []byte{final})
+		plaintext = plaintext[end:]
+		if final == 1 {
+			return out, keys
+		}
+	}
+}
+
+func TestDecryptReaderGCM(t *testing.T) {
+	for _, size := range []int{0, 5, GCMChunkSize, 3*GCMChunkSize + 7} {
+		plaintext := make([]byte, size)
+		_, err := rand.Read(plaintext)
+		require.NoError(t, err)
+
+		ciphertext, keys := encryptGCM(t, plaintext)
+		reader, err := keys.DecryptReader(context.Background(), bytes.NewReader(ciphertext))
+		require.NoError(t, err)
+
+		decrypted, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.Equal(t, plaintext, decrypted, "size %d", size)
+	}
+}
+
+func TestDecryptReaderGCMTampered(t *testing.T) {
+	plaintext := make([]byte, 2*GCMChunkSize+100)
+	ciphertext, keys := encryptGCM(t, plaintext)
+	chunkLen := GCMChunkSize + 16
+
+	tampered := append([]byte{}, ciphertext...)
+	tampered[20] ^= 1
+	// truncating at a chunk boundary must also be detected
+	truncated := ciphertext[:12+2*chunkLen]
+
+	for _, input := range [][]byte{tampered, truncated, ciphertext[:12]} {
+		reader, err := keys.DecryptReader(context.Background(), bytes.NewReader(input))
+		require.NoError(t, err)
+		_, err = io.ReadAll(reader)
+		require.ErrorIs(t, err, ErrAuthenticationFailed)
+	}
+}
@@ -0,0 +1,109 @@
+package crypto
+
+import (
+	"bufio"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	AlgorithmAESCBC = "aes-cbc"
+	AlgorithmAESGCM = "aes-gcm"
+
+	// GCMChunkSize is the size of the plaintext sealed in each chunk of an AES-GCM input
+	GCMChunkSize = 64 * 1024
+)
+
+// SupportedAlgorithms are the algorithms inputs can be encrypted with
+var SupportedAlgorithms = []string{AlgorithmAESCBC, AlgorithmAESGCM}
+
+// ErrAuthenticationFailed is returned when an AES-GCM input fails authentication, meaning it was modified,
+// truncated or encrypted with a different key
+var ErrAuthenticationFailed = errors.New("input failed authentication, it may have been tampered with")
+
+// gcmReader decrypts and authenticates an AES-GCM input a chunk at a time, so that only authenticated data is
+// ever returned without needing to hold the whole input in memory.
+//
+// The input is a 12 byte nonce followed by chunks of GCMChunkSize bytes of plaintext, each sealed separately
+// with its tag appended. The nonce for each chunk is the input nonce with its last 4 bytes XORed with the
+// big-endian chunk index, and the additional data is a single byte set to 1 for the last chunk and 0 for the
+// others so that truncation is detected. An empty input is a single sealed chunk with no plaintext.
+type gcmReader struct {
+	reader  *bufio.Reader
+	aead    cipher.AEAD
+	nonce   []byte
+	counter uint32
+	buffer  []byte
+	// decrypted data that hasn't been read yet
+	pending []byte
+	err     error
+}
+
+func newGCMReader(reader io.Reader, block cipher.Block) (*gcmReader, error) {
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("error creating gcm cipher: %w", err)
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(reader, nonce); err != nil {
+		return nil, fmt.Errorf("error reading nonce from input: %w", err)
+	}
+
+	buffer := make([]byte, GCMChunkSize+aead.Overhead())
+	return &gcmReader{
+		reader: bufio.NewReaderSize(reader, 2*len(buffer)),
+		aead:   aead,
+		nonce:  nonce,
+		buffer: buffer,
+	}, nil
+}
+
+func (g *gcmReader) Read(p []byte) (int, error) {
+	for len(g.pending) == 0 {
+		if g.err != nil {
+			return 0, g.err
+		}
+		g.pending, g.err = g.openChunk()
+	}
+	n := copy(p, g.pending)
+	g.pending = g.pending[n:]
+	return n, nil
+}
+
+func (g *gcmReader) openChunk() ([]byte, error) {
+	n, err := io.ReadFull(g.reader, g.buffer)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	if n < g.aead.Overhead() {
+		// the input ended without the final chunk
+		return nil, ErrAuthenticationFailed
+	}
+
+	_, peekErr := g.reader.Peek(1)
+	final := peekErr == io.EOF
+
+	nonce := make([]byte, len(g.nonce))
+	copy(nonce, g.nonce)
+	counter := binary.BigEndian.Uint32(nonce[len(nonce)-4:]) ^ g.counter
+	binary.BigEndian.PutUint32(nonce[len(nonce)-4:], counter)
+	g.counter++
+
+	additionalData := []byte{0}
+	if final {
+		additionalData[0] = 1
+	}
+
+	chunk, err := g.aead.Open(g.buffer[:0], nonce, g.buffer[:n], additionalData)
+	if err != nil {
+		return nil, ErrAuthenticationFailed
+	}
+	if final {
+		return chunk, io.EOF
+	}
+	return chunk, nil
+}
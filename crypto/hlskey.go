@@ -0,0 +1,56 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+
+	"github.com/d1str0/pkcs7"
+)
+
+// HLSKeySize is the size of the AES-128 keys HLS outputs are encrypted with
+const HLSKeySize = 16
+
+// DeriveHLSKey derives the AES-128 key for an asset's HLS outputs from a master secret, so that per-asset keys
+// don't need to be stored and can be served by any node
+func DeriveHLSKey(secret []byte, playbackID string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("hls-key:" + playbackID))
+	return mac.Sum(nil)[:HLSKeySize]
+}
+
+// EncryptHLSSegment encrypts a segment of a playlist with an #EXT-X-KEY of METHOD=AES-128 and no IV, which players
+// decrypt with AES-128-CBC and PKCS#7 padding, using the segment's media sequence number as the IV
+func EncryptHLSSegment(key []byte, sequence uint64, r io.Reader, w io.Writer) error {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	iv := make([]byte, aes.BlockSize)
+	binary.BigEndian.PutUint64(iv[aes.BlockSize-8:], sequence)
+	encrypter := cipher.NewCBCEncrypter(block, iv)
+
+	buffer := make([]byte, 256*aes.BlockSize)
+	for {
+		n, err := io.ReadFull(r, buffer)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			// the last chunk is always padded, by a whole block if it's already a multiple of the block size
+			padded, err := pkcs7.Pad(buffer[:n], aes.BlockSize)
+			if err != nil {
+				return err
+			}
+			encrypter.CryptBlocks(padded, padded)
+			_, err = w.Write(padded)
+			return err
+		} else if err != nil {
+			return err
+		}
+		encrypter.CryptBlocks(buffer, buffer)
+		if _, err := w.Write(buffer); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"testing"
+
+	"github.com/d1str0/pkcs7"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptHLSSegment(t *testing.T) {
+	key := DeriveHLSKey([]byte("secret"), "abc123")
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+
+	// shorter than a block, a multiple of the chunk size and longer than a chunk
+	for _, size := range []int{0, 5, 256 * aes.BlockSize, 256*aes.BlockSize + 100} {
+		segment := bytes.Repeat([]byte{0x47}, size)
+		var encrypted bytes.Buffer
+		require.NoError(t, EncryptHLSSegment(key, 3, bytes.NewReader(segment), &encrypted))
+		require.Zero(t, encrypted.Len()%aes.BlockSize)
+
+		// decrypt as a player would, with the media sequence number as the IV
+		iv := make([]byte, aes.BlockSize)
+		iv[aes.BlockSize-1] = 3
+		decrypted := encrypted.Bytes()
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, decrypted)
+		unpadded, err := pkcs7.Unpad(decrypted)
+		require.NoError(t, err)
+		require.Equal(t, segment, unpadded)
+	}
+}
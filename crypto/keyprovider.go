@@ -0,0 +1,208 @@
+package crypto
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrUnknownKeyID is returned when an input was encrypted for a key that the provider doesn't have
+var ErrUnknownKeyID = errors.New("unknown encryption key ID")
+
+// KeyProvider unwraps the AES keys that inputs are encrypted with. The keys are wrapped with RSA-OAEP (SHA-256)
+// using the public key of the catalyst node, so a provider holds, or has access to, the matching private key.
+type KeyProvider interface {
+	// UnwrapKey unwraps the key with the private key identified by keyID, see KeyID. Each of the provider's keys
+	// is tried if keyID is empty.
+	UnwrapKey(ctx context.Context, keyID string, encryptedKey []byte) ([]byte, error)
+	// PublicKey returns the public key uploaders should wrap their keys with
+	PublicKey(ctx context.Context) (*rsa.PublicKey, error)
+	// PublicKeys returns the public keys of all the keys that are accepted for decryption, the current one first
+	PublicKeys(ctx context.Context) ([]*rsa.PublicKey, error)
+}
+
+// PrivateKeyProvider unwraps keys with a private key held in memory, e.g. loaded from a flag
+type PrivateKeyProvider struct {
+	privateKey *rsa.PrivateKey
+}
+
+func NewPrivateKeyProvider(privateKey *rsa.PrivateKey) *PrivateKeyProvider {
+	return &PrivateKeyProvider{privateKey: privateKey}
+}
+
+func (p *PrivateKeyProvider) UnwrapKey(_ context.Context, keyID string, encryptedKey []byte) ([]byte, error) {
+	if keyID != "" && keyID != KeyID(&p.privateKey.PublicKey) {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKeyID, keyID)
+	}
+	return rsa.DecryptOAEP(sha256.New(), rand.Reader, p.privateKey, encryptedKey, nil)
+}
+
+func (p *PrivateKeyProvider) PublicKey(_ context.Context) (*rsa.PublicKey, error) {
+	return &p.privateKey.PublicKey, nil
+}
+
+func (p *PrivateKeyProvider) PublicKeys(_ context.Context) ([]*rsa.PublicKey, error) {
+	return []*rsa.PublicKey{&p.privateKey.PublicKey}, nil
+}
+
+// KeyRing unwraps keys with any of a set of providers, so that the keypair can be rotated while inputs
+// encrypted for the previous keys are still being uploaded. The first provider holds the current key.
+type KeyRing struct {
+	providers []KeyProvider
+}
+
+func NewKeyRing(providers ...KeyProvider) *KeyRing {
+	return &KeyRing{providers: providers}
+}
+
+func (k *KeyRing) UnwrapKey(ctx context.Context, keyID string, encryptedKey []byte) ([]byte, error) {
+	var errs []error
+	for _, provider := range k.providers {
+		key, err := provider.UnwrapKey(ctx, keyID, encryptedKey)
+		if err == nil {
+			return key, nil
+		}
+		if !errors.Is(err, ErrUnknownKeyID) {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKeyID, keyID)
+	}
+	return nil, errors.Join(errs...)
+}
+
+func (k *KeyRing) PublicKey(ctx context.Context) (*rsa.PublicKey, error) {
+	if len(k.providers) == 0 {
+		return nil, fmt.Errorf("no keys in key ring")
+	}
+	return k.providers[0].PublicKey(ctx)
+}
+
+func (k *KeyRing) PublicKeys(ctx context.Context) ([]*rsa.PublicKey, error) {
+	var publicKeys []*rsa.PublicKey
+	for _, provider := range k.providers {
+		keys, err := provider.PublicKeys(ctx)
+		if err != nil {
+			return nil, err
+		}
+		publicKeys = append(publicKeys, keys...)
+	}
+	return publicKeys, nil
+}
+
+// CachingKeyProvider caches unwrapped keys, since every file of an HLS source is decrypted with the same key and
+// remote providers are rate limited and slow to call
+type CachingKeyProvider struct {
+	provider KeyProvider
+	ttl      time.Duration
+
+	mu   sync.Mutex
+	keys map[[sha256.Size]byte]cachedKey
+}
+
+type cachedKey struct {
+	key     []byte
+	expires time.Time
+}
+
+func NewCachingKeyProvider(provider KeyProvider, ttl time.Duration) *CachingKeyProvider {
+	return &CachingKeyProvider{
+		provider: provider,
+		ttl:      ttl,
+		keys:     map[[sha256.Size]byte]cachedKey{},
+	}
+}
+
+func (c *CachingKeyProvider) UnwrapKey(ctx context.Context, keyID string, encryptedKey []byte) ([]byte, error) {
+	id := sha256.Sum256(append([]byte(keyID+":"), encryptedKey...))
+	now := time.Now()
+
+	c.mu.Lock()
+	cached, ok := c.keys[id]
+	// drop any expired keys while we hold the lock so that the cache doesn't grow unbounded
+	for k, v := range c.keys {
+		if now.After(v.expires) {
+			delete(c.keys, k)
+		}
+	}
+	c.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.key, nil
+	}
+
+	key, err := c.provider.UnwrapKey(ctx, keyID, encryptedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.keys[id] = cachedKey{key: key, expires: now.Add(c.ttl)}
+	c.mu.Unlock()
+	return key, nil
+}
+
+func (c *CachingKeyProvider) PublicKey(ctx context.Context) (*rsa.PublicKey, error) {
+	return c.provider.PublicKey(ctx)
+}
+
+func (c *CachingKeyProvider) PublicKeys(ctx context.Context) ([]*rsa.PublicKey, error) {
+	return c.provider.PublicKeys(ctx)
+}
+
+// KeyID identifies a public key, and so the keypair, that an input was encrypted for. It is the first 16 hex
+// characters of the SHA-256 of the DER encoded SubjectPublicKeyInfo, so uploaders can compute it themselves.
+func KeyID(publicKey *rsa.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:8])
+}
+
+// EncodePublicKey encodes the public key in the format of the -catalyst-public-key flag, a base64 encoded PKCS#1 PEM
+func EncodePublicKey(publicKey *rsa.PublicKey) string {
+	return base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PUBLIC KEY",
+		Bytes: x509.MarshalPKCS1PublicKey(publicKey),
+	}))
+}
+
+// ParsePublicKey parses a public key in the format of the -catalyst-public-key flag
+func ParsePublicKey(publicKeyBase64 string) (*rsa.PublicKey, error) {
+	publicKey, err := base64.StdEncoding.DecodeString(publicKeyBase64)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding public key: %w", err)
+	}
+	block, _ := pem.Decode(publicKey)
+	if block == nil {
+		return nil, fmt.Errorf("failed to parse PEM block containing the public key")
+	}
+	return x509.ParsePKCS1PublicKey(block.Bytes)
+}
+
+// parsePKIXPublicKey parses a DER or PEM encoded SubjectPublicKeyInfo RSA public key, as returned by KMS and Vault
+func parsePKIXPublicKey(data []byte) (*rsa.PublicKey, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+	pub, err := x509.ParsePKIXPublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing public key: %w", err)
+	}
+	rsaPub, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key is %T, not RSA", pub)
+	}
+	return rsaPub, nil
+}
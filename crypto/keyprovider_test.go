@@ -0,0 +1,226 @@
+package crypto
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/stretchr/testify/require"
+)
+
+type countingKeyProvider struct {
+	KeyProvider
+	calls int
+}
+
+func (c *countingKeyProvider) UnwrapKey(ctx context.Context, keyID string, encryptedKey []byte) ([]byte, error) {
+	c.calls++
+	return c.KeyProvider.UnwrapKey(ctx, keyID, encryptedKey)
+}
+
+func wrapKey(t *testing.T, publicKey *rsa.PublicKey, key []byte) []byte {
+	encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, publicKey, key, nil)
+	require.NoError(t, err)
+	return encryptedKey
+}
+
+func TestCachingKeyProvider(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	counting := &countingKeyProvider{KeyProvider: NewPrivateKeyProvider(privateKey)}
+	provider := NewCachingKeyProvider(counting, time.Minute)
+
+	encryptedKey := wrapKey(t, &privateKey.PublicKey, []byte("0123456789abcdef"))
+	for i := 0; i < 3; i++ {
+		key, err := provider.UnwrapKey(context.Background(), "", encryptedKey)
+		require.NoError(t, err)
+		require.Equal(t, []byte("0123456789abcdef"), key)
+	}
+	require.Equal(t, 1, counting.calls)
+
+	// expired keys are unwrapped again
+	counting.calls = 0
+	provider = NewCachingKeyProvider(counting, 0)
+	for i := 0; i < 2; i++ {
+		_, err = provider.UnwrapKey(context.Background(), "", encryptedKey)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 2, counting.calls)
+}
+
+func TestPublicKeyRoundTrip(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	publicKey, err := ParsePublicKey(EncodePublicKey(&privateKey.PublicKey))
+	require.NoError(t, err)
+	require.True(t, publicKey.Equal(&privateKey.PublicKey))
+}
+
+// fakeVault serves the transit engine API for a key with the given versions
+func fakeVault(t *testing.T, versions []*rsa.PrivateKey, minDecryptionVersion int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "test-token", r.Header.Get("X-Vault-Token"))
+		switch r.URL.Path {
+		case "/v1/transit/keys/catalyst":
+			keys := map[string]interface{}{}
+			for i, key := range versions {
+				der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+				require.NoError(t, err)
+				keys[fmt.Sprint(i+1)] = map[string]string{
+					"public_key": string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+				}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{
+				"latest_version":         len(versions),
+				"min_decryption_version": minDecryptionVersion,
+				"keys":                   keys,
+			}})
+		case "/v1/transit/decrypt/catalyst":
+			var req struct {
+				Ciphertext string `json:"ciphertext"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			var version int
+			var ciphertext string
+			_, err := fmt.Sscanf(req.Ciphertext, "vault:v%d:%s", &version, &ciphertext)
+			require.NoError(t, err)
+			encryptedKey, err := base64.StdEncoding.DecodeString(ciphertext)
+			require.NoError(t, err)
+			plaintext, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, versions[version-1], encryptedKey, nil)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]string{
+				"plaintext": base64.StdEncoding.EncodeToString(plaintext),
+			}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestVaultKeyProvider(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	server := fakeVault(t, []*rsa.PrivateKey{oldKey, newKey}, 1)
+	defer server.Close()
+
+	provider, err := NewVaultKeyProvider(server.URL, "test-token", "transit", "catalyst")
+	require.NoError(t, err)
+
+	publicKey, err := provider.PublicKey(context.Background())
+	require.NoError(t, err)
+	require.True(t, publicKey.Equal(&newKey.PublicKey))
+
+	// keys wrapped with the current and the previous version can both be unwrapped
+	for _, key := range []*rsa.PrivateKey{newKey, oldKey} {
+		unwrapped, err := provider.UnwrapKey(context.Background(), "", wrapKey(t, &key.PublicKey, []byte("0123456789abcdef")))
+		require.NoError(t, err)
+		require.Equal(t, []byte("0123456789abcdef"), unwrapped)
+	}
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, err = provider.UnwrapKey(context.Background(), "", wrapKey(t, &otherKey.PublicKey, []byte("0123456789abcdef")))
+	require.ErrorContains(t, err, "vault key catalyst version 1")
+}
+
+type fakeKMS struct {
+	keys map[string]*rsa.PrivateKey
+}
+
+func (f *fakeKMS) DecryptWithContext(_ aws.Context, in *kms.DecryptInput, _ ...request.Option) (*kms.DecryptOutput, error) {
+	if *in.EncryptionAlgorithm != kms.EncryptionAlgorithmSpecRsaesOaepSha256 {
+		return nil, fmt.Errorf("unexpected algorithm %s", *in.EncryptionAlgorithm)
+	}
+	plaintext, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, f.keys[*in.KeyId], in.CiphertextBlob, nil)
+	if err != nil {
+		return nil, fmt.Errorf("InvalidCiphertextException")
+	}
+	return &kms.DecryptOutput{Plaintext: plaintext}, nil
+}
+
+func (f *fakeKMS) GetPublicKeyWithContext(_ aws.Context, in *kms.GetPublicKeyInput, _ ...request.Option) (*kms.GetPublicKeyOutput, error) {
+	der, err := x509.MarshalPKIXPublicKey(&f.keys[*in.KeyId].PublicKey)
+	return &kms.GetPublicKeyOutput{PublicKey: der}, err
+}
+
+func TestKMSKeyProvider(t *testing.T) {
+	current, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	previous, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	provider := &KMSKeyProvider{
+		client: &fakeKMS{keys: map[string]*rsa.PrivateKey{"current": current, "previous": previous}},
+		keyIDs: []string{"current", "previous"},
+	}
+
+	publicKey, err := provider.PublicKey(context.Background())
+	require.NoError(t, err)
+	require.True(t, publicKey.Equal(&current.PublicKey))
+
+	for _, key := range []*rsa.PrivateKey{current, previous} {
+		unwrapped, err := provider.UnwrapKey(context.Background(), "", wrapKey(t, &key.PublicKey, []byte("0123456789abcdef")))
+		require.NoError(t, err)
+		require.Equal(t, []byte("0123456789abcdef"), unwrapped)
+	}
+}
+
+func TestKeyRing(t *testing.T) {
+	current, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	previous, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ring := NewKeyRing(NewPrivateKeyProvider(current), NewPrivateKeyProvider(previous))
+
+	publicKey, err := ring.PublicKey(context.Background())
+	require.NoError(t, err)
+	require.True(t, publicKey.Equal(&current.PublicKey))
+
+	for _, key := range []*rsa.PrivateKey{current, previous} {
+		encryptedKey := wrapKey(t, &key.PublicKey, []byte("0123456789abcdef"))
+		for _, keyID := range []string{"", KeyID(&key.PublicKey)} {
+			unwrapped, err := ring.UnwrapKey(context.Background(), keyID, encryptedKey)
+			require.NoError(t, err)
+			require.Equal(t, []byte("0123456789abcdef"), unwrapped)
+		}
+	}
+
+	_, err = ring.UnwrapKey(context.Background(), "0123456789abcdef", wrapKey(t, &current.PublicKey, []byte("0123456789abcdef")))
+	require.ErrorIs(t, err, ErrUnknownKeyID)
+}
+
+func TestVaultKeyProviderKeyID(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	server := fakeVault(t, []*rsa.PrivateKey{oldKey, newKey}, 1)
+	defer server.Close()
+	provider, err := NewVaultKeyProvider(server.URL, "test-token", "transit", "catalyst")
+	require.NoError(t, err)
+
+	unwrapped, err := provider.UnwrapKey(context.Background(), KeyID(&oldKey.PublicKey), wrapKey(t, &oldKey.PublicKey, []byte("0123456789abcdef")))
+	require.NoError(t, err)
+	require.Equal(t, []byte("0123456789abcdef"), unwrapped)
+
+	_, err = provider.UnwrapKey(context.Background(), "0123456789abcdef", wrapKey(t, &oldKey.PublicKey, []byte("0123456789abcdef")))
+	require.ErrorIs(t, err, ErrUnknownKeyID)
+}
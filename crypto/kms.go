@@ -0,0 +1,110 @@
+package crypto
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+)
+
+type KMSClient interface {
+	DecryptWithContext(aws.Context, *kms.DecryptInput, ...request.Option) (*kms.DecryptOutput, error)
+	GetPublicKeyWithContext(aws.Context, *kms.GetPublicKeyInput, ...request.Option) (*kms.GetPublicKeyOutput, error)
+}
+
+// KMSKeyProvider unwraps keys with an asymmetric RSA key held in AWS KMS, so the private key never leaves KMS.
+// To rotate, create a new key and list it first: keys are tried in order so inputs wrapped with the previous
+// keys can still be decrypted, and the public key of the first one is advertised to uploaders.
+type KMSKeyProvider struct {
+	client KMSClient
+	keyIDs []string
+
+	mu sync.Mutex
+	// the KeyID of each KMS key's public key, which never changes for a KMS key
+	fingerprints map[string]string
+}
+
+// NewKMSKeyProvider creates a provider for the given key IDs or aliases, using the default AWS credential chain
+func NewKMSKeyProvider(region string, keyIDs []string) (*KMSKeyProvider, error) {
+	if len(keyIDs) == 0 {
+		return nil, fmt.Errorf("no KMS key IDs configured")
+	}
+	sess, err := session.NewSession(aws.NewConfig().WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("error creating AWS session: %w", err)
+	}
+	return &KMSKeyProvider{client: kms.New(sess), keyIDs: keyIDs, fingerprints: map[string]string{}}, nil
+}
+
+func (k *KMSKeyProvider) UnwrapKey(ctx context.Context, id string, encryptedKey []byte) ([]byte, error) {
+	var errs []error
+	for _, keyID := range k.keyIDs {
+		if id != "" {
+			fingerprint, err := k.fingerprint(ctx, keyID)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if fingerprint != id {
+				continue
+			}
+		}
+		out, err := k.client.DecryptWithContext(ctx, &kms.DecryptInput{
+			KeyId:               aws.String(keyID),
+			CiphertextBlob:      encryptedKey,
+			EncryptionAlgorithm: aws.String(kms.EncryptionAlgorithmSpecRsaesOaepSha256),
+		})
+		if err == nil {
+			return out.Plaintext, nil
+		}
+		errs = append(errs, fmt.Errorf("kms key %s: %w", keyID, err))
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKeyID, id)
+	}
+	return nil, errors.Join(errs...)
+}
+
+func (k *KMSKeyProvider) fingerprint(ctx context.Context, keyID string) (string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if fingerprint, ok := k.fingerprints[keyID]; ok {
+		return fingerprint, nil
+	}
+	publicKey, err := k.publicKey(ctx, keyID)
+	if err != nil {
+		return "", err
+	}
+	k.fingerprints[keyID] = KeyID(publicKey)
+	return k.fingerprints[keyID], nil
+}
+
+func (k *KMSKeyProvider) PublicKey(ctx context.Context) (*rsa.PublicKey, error) {
+	return k.publicKey(ctx, k.keyIDs[0])
+}
+
+func (k *KMSKeyProvider) PublicKeys(ctx context.Context) ([]*rsa.PublicKey, error) {
+	var publicKeys []*rsa.PublicKey
+	for _, keyID := range k.keyIDs {
+		publicKey, err := k.publicKey(ctx, keyID)
+		if err != nil {
+			return nil, err
+		}
+		publicKeys = append(publicKeys, publicKey)
+	}
+	return publicKeys, nil
+}
+
+func (k *KMSKeyProvider) publicKey(ctx context.Context, keyID string) (*rsa.PublicKey, error) {
+	out, err := k.client.GetPublicKeyWithContext(ctx, &kms.GetPublicKeyInput{KeyId: aws.String(keyID)})
+	if err != nil {
+		return nil, fmt.Errorf("error getting public key of kms key %s: %w", keyID, err)
+	}
+	return parsePKIXPublicKey(out.PublicKey)
+}
@@ -0,0 +1,158 @@
+// Package signedurl generates and verifies signed, expiring playback URLs. The signature covers the path and
+// query of the URL, so signed URLs remain valid when redirected to another host.
+package signedurl
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	// ExpiryParam is the query parameter holding the unix time the URL expires at
+	ExpiryParam = "exp"
+	// SignatureParam is the query parameter holding the base64url encoded signature
+	SignatureParam = "sig"
+)
+
+var (
+	ErrMissingSignature = errors.New("url is not signed")
+	ErrExpired          = errors.New("signed url has expired")
+	ErrInvalidSignature = errors.New("invalid url signature")
+)
+
+// Signer signs and verifies URLs with either a shared HMAC-SHA256 secret or an Ed25519 keypair. A Signer with only
+// an Ed25519 public key can verify but not sign, which lets playback nodes check URLs without holding the private key.
+type Signer struct {
+	hmacSecret []byte
+	privateKey ed25519.PrivateKey
+	publicKey  ed25519.PublicKey
+}
+
+func NewHMACSigner(secret []byte) *Signer {
+	return &Signer{hmacSecret: secret}
+}
+
+func NewEd25519Signer(privateKey ed25519.PrivateKey) *Signer {
+	return &Signer{privateKey: privateKey, publicKey: privateKey.Public().(ed25519.PublicKey)}
+}
+
+func NewEd25519Verifier(publicKey ed25519.PublicKey) *Signer {
+	return &Signer{publicKey: publicKey}
+}
+
+// FromKeys creates a signer from the base64 encoded keys configured with flags: an HMAC secret, an Ed25519
+// private key (or its 32 byte seed) or an Ed25519 public key, in that order of preference. Returns nil if none are set.
+func FromKeys(hmacSecret, ed25519PrivateKey, ed25519PublicKey string) (*Signer, error) {
+	switch {
+	case hmacSecret != "":
+		secret, err := base64.StdEncoding.DecodeString(hmacSecret)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding playback signing secret: %w", err)
+		}
+		if len(secret) < 32 {
+			return nil, fmt.Errorf("playback signing secret must be at least 32 bytes")
+		}
+		return NewHMACSigner(secret), nil
+	case ed25519PrivateKey != "":
+		key, err := base64.StdEncoding.DecodeString(ed25519PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding playback signing key: %w", err)
+		}
+		switch len(key) {
+		case ed25519.SeedSize:
+			return NewEd25519Signer(ed25519.NewKeyFromSeed(key)), nil
+		case ed25519.PrivateKeySize:
+			return NewEd25519Signer(key), nil
+		}
+		return nil, fmt.Errorf("playback signing key must be a %d byte Ed25519 seed or %d byte private key", ed25519.SeedSize, ed25519.PrivateKeySize)
+	case ed25519PublicKey != "":
+		key, err := base64.StdEncoding.DecodeString(ed25519PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding playback verification key: %w", err)
+		}
+		if len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("playback verification key must be a %d byte Ed25519 public key", ed25519.PublicKeySize)
+		}
+		return NewEd25519Verifier(key), nil
+	}
+	return nil, nil
+}
+
+// CanSign returns whether the signer has the key material to sign URLs, rather than just verify them
+func (s *Signer) CanSign() bool {
+	return s.hmacSecret != nil || s.privateKey != nil
+}
+
+// Sign returns a copy of the URL that is valid until expires
+func (s *Signer) Sign(u *url.URL, expires time.Time) (*url.URL, error) {
+	if !s.CanSign() {
+		return nil, fmt.Errorf("no key configured to sign urls with")
+	}
+	signed := *u
+	query := signed.Query()
+	query.Del(SignatureParam)
+	query.Set(ExpiryParam, strconv.FormatInt(expires.Unix(), 10))
+	signed.RawQuery = query.Encode()
+
+	var sig []byte
+	if s.hmacSecret != nil {
+		sig = s.hmac(message(&signed))
+	} else {
+		sig = ed25519.Sign(s.privateKey, message(&signed))
+	}
+	query.Set(SignatureParam, base64.RawURLEncoding.EncodeToString(sig))
+	signed.RawQuery = query.Encode()
+	return &signed, nil
+}
+
+// Verify checks that the URL was signed by us and hasn't expired
+func (s *Signer) Verify(u *url.URL, now time.Time) error {
+	query := u.Query()
+	if query.Get(SignatureParam) == "" {
+		return ErrMissingSignature
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(query.Get(SignatureParam))
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	var valid bool
+	if s.hmacSecret != nil {
+		valid = hmac.Equal(sig, s.hmac(message(u)))
+	} else {
+		valid = ed25519.Verify(s.publicKey, message(u), sig)
+	}
+	if !valid {
+		return ErrInvalidSignature
+	}
+
+	// only trust the expiry once we know it hasn't been tampered with
+	expires, err := strconv.ParseInt(query.Get(ExpiryParam), 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if now.Unix() > expires {
+		return ErrExpired
+	}
+	return nil
+}
+
+func (s *Signer) hmac(msg []byte) []byte {
+	mac := hmac.New(sha256.New, s.hmacSecret)
+	mac.Write(msg)
+	return mac.Sum(nil)
+}
+
+// message is what is signed: the path and the sorted query parameters other than the signature
+func message(u *url.URL) []byte {
+	query := u.Query()
+	query.Del(SignatureParam)
+	return []byte(u.EscapedPath() + "?" + query.Encode())
+}
@@ -0,0 +1,82 @@
+package signedurl
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	now := time.Now()
+
+	for name, signer := range map[string]*Signer{
+		"hmac":    NewHMACSigner([]byte("0123456789abcdef0123456789abcdef")),
+		"ed25519": NewEd25519Signer(privateKey),
+	} {
+		t.Run(name, func(t *testing.T) {
+			u, err := url.Parse("https://example.com/hls/video+abc123/index.m3u8?foo=bar")
+			require.NoError(t, err)
+			signed, err := signer.Sign(u, now.Add(time.Hour))
+			require.NoError(t, err)
+			require.Equal(t, "bar", signed.Query().Get("foo"))
+
+			require.NoError(t, signer.Verify(signed, now))
+			require.ErrorIs(t, signer.Verify(signed, now.Add(2*time.Hour)), ErrExpired)
+			require.ErrorIs(t, signer.Verify(u, now), ErrMissingSignature)
+
+			// the signature is valid on another host but not for another path or query
+			moved := *signed
+			moved.Host = "other.example.com"
+			require.NoError(t, signer.Verify(&moved, now))
+
+			tampered := *signed
+			tampered.Path = "/hls/video+other/index.m3u8"
+			require.ErrorIs(t, signer.Verify(&tampered, now), ErrInvalidSignature)
+
+			query := signed.Query()
+			query.Set(ExpiryParam, "9999999999")
+			tampered = *signed
+			tampered.RawQuery = query.Encode()
+			require.ErrorIs(t, signer.Verify(&tampered, now), ErrInvalidSignature)
+		})
+	}
+}
+
+func TestVerifierCannotSign(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	verifier := NewEd25519Verifier(publicKey)
+	require.False(t, verifier.CanSign())
+
+	u := &url.URL{Path: "/hls/video+abc123/index.m3u8"}
+	_, err = verifier.Sign(u, time.Now().Add(time.Hour))
+	require.Error(t, err)
+
+	signed, err := NewEd25519Signer(privateKey).Sign(u, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, verifier.Verify(signed, time.Now()))
+}
+
+func TestFromKeys(t *testing.T) {
+	signer, err := FromKeys("", "", "")
+	require.NoError(t, err)
+	require.Nil(t, signer)
+
+	_, err = FromKeys(base64.StdEncoding.EncodeToString([]byte("too short")), "", "")
+	require.Error(t, err)
+
+	seed := make([]byte, ed25519.SeedSize)
+	signer, err = FromKeys("", base64.StdEncoding.EncodeToString(seed), "")
+	require.NoError(t, err)
+	require.True(t, signer.CanSign())
+
+	_, err = FromKeys("", "", base64.StdEncoding.EncodeToString([]byte("not a key")))
+	require.Error(t, err)
+}
@@ -0,0 +1,19 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// SRTPassphraseLength is the length of the derived SRT passphrases, SRT accepts 10 to 79 characters
+const SRTPassphraseLength = 32
+
+// DeriveSRTPassphrase derives the passphrase encoders publish a stream key over SRT with from a master secret, so
+// that any node can provision the ingest without the passphrases being stored. Rotating the stream key rotates the
+// passphrase too.
+func DeriveSRTPassphrase(secret []byte, streamKey string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("srt-passphrase:" + streamKey))
+	return hex.EncodeToString(mac.Sum(nil))[:SRTPassphraseLength]
+}
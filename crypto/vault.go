@@ -0,0 +1,178 @@
+package crypto
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// how long the key versions read from Vault are used before checking for a rotation
+const vaultKeyRefreshInterval = 5 * time.Minute
+
+// VaultKeyProvider unwraps keys with an RSA key in a HashiCorp Vault transit secrets engine. Rotating the key in
+// Vault is picked up automatically: inputs are decrypted with each version from the latest down to the minimum
+// decryption version, and the latest version's public key is advertised to uploaders.
+type VaultKeyProvider struct {
+	addr    string
+	token   string
+	mount   string
+	keyName string
+	client  *http.Client
+
+	mu          sync.Mutex
+	key         *vaultKey
+	keyOutdated time.Time
+}
+
+type vaultKey struct {
+	LatestVersion        int `json:"latest_version"`
+	MinDecryptionVersion int `json:"min_decryption_version"`
+	Keys                 map[string]struct {
+		PublicKey string `json:"public_key"`
+	} `json:"keys"`
+}
+
+func NewVaultKeyProvider(addr, token, mount, keyName string) (*VaultKeyProvider, error) {
+	if addr == "" || keyName == "" {
+		return nil, fmt.Errorf("vault address and transit key name must be set")
+	}
+	return &VaultKeyProvider{
+		addr:    strings.TrimSuffix(addr, "/"),
+		token:   token,
+		mount:   strings.Trim(mount, "/"),
+		keyName: keyName,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (v *VaultKeyProvider) UnwrapKey(ctx context.Context, keyID string, encryptedKey []byte) ([]byte, error) {
+	key, err := v.getKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for version := key.LatestVersion; version >= max(1, key.MinDecryptionVersion); version-- {
+		if keyID != "" {
+			publicKey, err := key.publicKey(version)
+			if err != nil || KeyID(publicKey) != keyID {
+				continue
+			}
+		}
+		var resp struct {
+			Data struct {
+				Plaintext string `json:"plaintext"`
+			} `json:"data"`
+		}
+		err := v.call(ctx, http.MethodPost, "decrypt/"+v.keyName, map[string]string{
+			"ciphertext": fmt.Sprintf("vault:v%d:%s", version, base64.StdEncoding.EncodeToString(encryptedKey)),
+		}, &resp)
+		if err == nil {
+			return base64.StdEncoding.DecodeString(resp.Data.Plaintext)
+		}
+		errs = append(errs, fmt.Errorf("vault key %s version %d: %w", v.keyName, version, err))
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKeyID, keyID)
+	}
+	return nil, errors.Join(errs...)
+}
+
+func (v *VaultKeyProvider) PublicKey(ctx context.Context) (*rsa.PublicKey, error) {
+	key, err := v.getKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := key.publicKey(key.LatestVersion)
+	if err != nil {
+		return nil, fmt.Errorf("vault key %s: %w", v.keyName, err)
+	}
+	return publicKey, nil
+}
+
+func (v *VaultKeyProvider) PublicKeys(ctx context.Context) ([]*rsa.PublicKey, error) {
+	key, err := v.getKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var publicKeys []*rsa.PublicKey
+	for version := key.LatestVersion; version >= max(1, key.MinDecryptionVersion); version-- {
+		publicKey, err := key.publicKey(version)
+		if err != nil {
+			return nil, fmt.Errorf("vault key %s: %w", v.keyName, err)
+		}
+		publicKeys = append(publicKeys, publicKey)
+	}
+	return publicKeys, nil
+}
+
+func (k *vaultKey) publicKey(version int) (*rsa.PublicKey, error) {
+	v, ok := k.Keys[strconv.Itoa(version)]
+	if !ok || v.PublicKey == "" {
+		return nil, fmt.Errorf("no public key for version %d, it must be an RSA key", version)
+	}
+	return parsePKIXPublicKey([]byte(v.PublicKey))
+}
+
+// getKey returns the versions of the transit key, refreshing them periodically to pick up rotations
+func (v *VaultKeyProvider) getKey(ctx context.Context) (*vaultKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.key != nil && time.Now().Before(v.keyOutdated) {
+		return v.key, nil
+	}
+
+	var resp struct {
+		Data vaultKey `json:"data"`
+	}
+	if err := v.call(ctx, http.MethodGet, "keys/"+v.keyName, nil, &resp); err != nil {
+		if v.key != nil {
+			// keep using the versions we know about rather than failing if vault is briefly unavailable
+			return v.key, nil
+		}
+		return nil, fmt.Errorf("error reading vault key %s: %w", v.keyName, err)
+	}
+	v.key = &resp.Data
+	v.keyOutdated = time.Now().Add(vaultKeyRefreshInterval)
+	return v.key, nil
+}
+
+func (v *VaultKeyProvider) call(ctx context.Context, method, path string, body, result interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("%s/v1/%s/%s", v.addr, v.mount, path), reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("vault returned status %d: %s", resp.StatusCode, respBody)
+	}
+	return json.Unmarshal(respBody, result)
+}
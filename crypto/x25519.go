@@ -0,0 +1,78 @@
+package crypto
+
+import (
+	"crypto/ecdh"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// KeyWrappingRSAOAEP is the default scheme, the AES key is encrypted with RSA-OAEP (SHA-256) for the catalyst public key
+	KeyWrappingRSAOAEP = "rsa-oaep"
+	// KeyWrappingX25519 derives the AES key from an X25519 key agreement between an ephemeral key generated by the
+	// uploader and the catalyst X25519 key, see X25519Key.DeriveKey
+	KeyWrappingX25519 = "x25519"
+
+	x25519HKDFInfo = "catalyst-vod-encryption"
+	// x25519KeySize is the size of the derived AES key, inputs are always encrypted with AES-256
+	x25519KeySize = 32
+)
+
+// X25519Key is the static X25519 keypair of the catalyst node used to agree on the keys of encrypted uploads
+type X25519Key struct {
+	privateKey *ecdh.PrivateKey
+}
+
+// LoadX25519PrivateKey loads a base64 encoded 32 byte X25519 private key
+func LoadX25519PrivateKey(privateKeyBase64 string) (*X25519Key, error) {
+	privateKey, err := base64.StdEncoding.DecodeString(privateKeyBase64)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding x25519 private key: %w", err)
+	}
+	key, err := ecdh.X25519().NewPrivateKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing x25519 private key: %w", err)
+	}
+	return &X25519Key{privateKey: key}, nil
+}
+
+func NewX25519Key(privateKey *ecdh.PrivateKey) *X25519Key {
+	return &X25519Key{privateKey: privateKey}
+}
+
+// PublicKey returns the raw 32 byte public key uploaders agree keys with
+func (k *X25519Key) PublicKey() []byte {
+	return k.privateKey.PublicKey().Bytes()
+}
+
+// KeyID identifies the X25519 key in the same way as KeyID does for RSA keys, from the SHA-256 of the public key
+func (k *X25519Key) KeyID() string {
+	sum := sha256.Sum256(k.PublicKey())
+	return hex.EncodeToString(sum[:8])
+}
+
+// DeriveKey derives the AES-256 key of an input from the uploader's ephemeral public key. The key is the first
+// 32 bytes of HKDF-SHA256 over the X25519 shared secret, with the ephemeral public key followed by the
+// catalyst public key as the salt and "catalyst-vod-encryption" as the info.
+func (k *X25519Key) DeriveKey(ephemeralPublicKey []byte) ([]byte, error) {
+	peer, err := ecdh.X25519().NewPublicKey(ephemeralPublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ephemeral public key: %w", err)
+	}
+	shared, err := k.privateKey.ECDH(peer)
+	if err != nil {
+		return nil, fmt.Errorf("error agreeing key: %w", err)
+	}
+
+	salt := append(append([]byte{}, ephemeralPublicKey...), k.PublicKey()...)
+	key := make([]byte, x25519KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, salt, []byte(x25519HKDFInfo)), key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
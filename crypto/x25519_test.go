@@ -0,0 +1,111 @@
+package crypto
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"testing"
+
+	"github.com/d1str0/pkcs7"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/hkdf"
+)
+
+// encryptX25519 encrypts the plaintext with AES-CBC the way an uploader would, deriving the key from a new
+// ephemeral key and the catalyst public key
+func encryptX25519(t *testing.T, catalystPublicKey, plaintext []byte) ([]byte, string) {
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	peer, err := ecdh.X25519().NewPublicKey(catalystPublicKey)
+	require.NoError(t, err)
+	shared, err := ephemeral.ECDH(peer)
+	require.NoError(t, err)
+
+	salt := append(ephemeral.PublicKey().Bytes(), catalystPublicKey...)
+	key := make([]byte, 32)
+	_, err = io.ReadFull(hkdf.New(sha256.New, shared, salt, []byte("catalyst-vod-encryption")), key)
+	require.NoError(t, err)
+
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	iv := make([]byte, aes.BlockSize)
+	_, err = rand.Read(iv)
+	require.NoError(t, err)
+	padded, err := pkcs7.Pad(plaintext, aes.BlockSize)
+	require.NoError(t, err)
+	ciphertext := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, padded)
+
+	return append(iv, ciphertext...), base64.StdEncoding.EncodeToString(ephemeral.PublicKey().Bytes())
+}
+
+func newX25519Key(t *testing.T) *X25519Key {
+	privateKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	key, err := LoadX25519PrivateKey(base64.StdEncoding.EncodeToString(privateKey.Bytes()))
+	require.NoError(t, err)
+	return key
+}
+
+func TestDecryptReaderX25519(t *testing.T) {
+	key := newX25519Key(t)
+	plaintext := []byte("some plaintext spanning more than a single AES block")
+	ciphertext, ephemeralPublicKey := encryptX25519(t, key.PublicKey(), plaintext)
+
+	keys := DecryptionKeys{
+		KeyWrapping:        KeyWrappingX25519,
+		EphemeralPublicKey: ephemeralPublicKey,
+		KeyID:              key.KeyID(),
+		X25519:             key,
+	}
+	reader, err := keys.DecryptReader(context.Background(), bytes.NewReader(ciphertext))
+	require.NoError(t, err)
+	decrypted, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Equal(t, plaintext, decrypted)
+
+	// a different catalyst key agrees on a different AES key
+	keys.X25519, keys.KeyID = newX25519Key(t), ""
+	reader, err = keys.DecryptReader(context.Background(), bytes.NewReader(ciphertext))
+	require.NoError(t, err)
+	_, err = io.ReadAll(reader)
+	require.ErrorIs(t, err, ErrInvalidPadding)
+}
+
+func TestDecryptReaderX25519Errors(t *testing.T) {
+	key := newX25519Key(t)
+	_, ephemeralPublicKey := encryptX25519(t, key.PublicKey(), []byte("plaintext"))
+
+	keys := DecryptionKeys{KeyWrapping: KeyWrappingX25519, EphemeralPublicKey: ephemeralPublicKey}
+	_, err := keys.DecryptReader(context.Background(), bytes.NewReader(nil))
+	require.ErrorIs(t, err, ErrNoDecryptionKey)
+
+	keys.X25519, keys.KeyID = key, "0123456789abcdef"
+	_, err = keys.DecryptReader(context.Background(), bytes.NewReader(nil))
+	require.ErrorIs(t, err, ErrUnknownKeyID)
+
+	keys.KeyID, keys.EphemeralPublicKey = "", base64.StdEncoding.EncodeToString([]byte("too short"))
+	_, err = keys.DecryptReader(context.Background(), bytes.NewReader(nil))
+	require.ErrorContains(t, err, "invalid ephemeral public key")
+
+	keys.KeyWrapping = "unknown"
+	_, err = keys.DecryptReader(context.Background(), bytes.NewReader(nil))
+	require.ErrorContains(t, err, "unsupported key wrapping")
+}
+
+func TestLoadX25519PrivateKey(t *testing.T) {
+	_, err := LoadX25519PrivateKey("not base64!")
+	require.Error(t, err)
+	_, err = LoadX25519PrivateKey(base64.StdEncoding.EncodeToString([]byte("short")))
+	require.Error(t, err)
+
+	key := newX25519Key(t)
+	require.Len(t, key.PublicKey(), 32)
+	require.Len(t, key.KeyID(), 16)
+}
@@ -0,0 +1,120 @@
+package dvr
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/livepeer/catalyst-api/clients"
+	"github.com/livepeer/catalyst-api/events"
+	"github.com/livepeer/catalyst-api/log"
+)
+
+// MaxWindow bounds the live buffer Mist keeps in memory for each stream
+const MaxWindow = 12 * time.Hour
+
+// Manager applies the DVR window of each stream to its Mist stream config, so that the HLS playlists of the stream
+// cover the whole window. The windows are kept in memory and set on every node with the DVR event, since the stream
+// can be ingested on any of them.
+type Manager struct {
+	mist           clients.MistAPIClient
+	baseStreamName string
+	streamSource   string
+	// playbackHost is the hostname the playback URLs are served from, redirecting to the node the stream is on
+	playbackHost string
+
+	mu      sync.Mutex
+	windows map[string]time.Duration // playback ID to window
+}
+
+func NewManager(mist clients.MistAPIClient, baseStreamName, streamSource, playbackHost string) *Manager {
+	return &Manager{
+		mist:           mist,
+		baseStreamName: baseStreamName,
+		streamSource:   streamSource,
+		playbackHost:   playbackHost,
+		windows:        map[string]time.Duration{},
+	}
+}
+
+// ValidateSettings checks the settings before they're broadcast to the cluster
+func ValidateSettings(s events.DVRSettings) error {
+	if s.WindowSecs < 0 {
+		return fmt.Errorf("window_secs can't be negative")
+	}
+	if window := time.Duration(s.WindowSecs) * time.Second; window > MaxWindow {
+		return fmt.Errorf("window_secs can't be more than %d", int64(MaxWindow.Seconds()))
+	}
+	return nil
+}
+
+// Apply sets the DVR window of a stream, a zero window restoring Mist's default
+func (m *Manager) Apply(playbackID string, s events.DVRSettings) error {
+	if err := ValidateSettings(s); err != nil {
+		return err
+	}
+	streamName := m.streamName(playbackID)
+	window := time.Duration(s.WindowSecs) * time.Second
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	previous, ok := m.windows[playbackID]
+	if window == 0 {
+		if !ok {
+			return nil
+		}
+		delete(m.windows, playbackID)
+		if err := m.mist.AddStream(streamName, m.streamSource); err != nil {
+			return fmt.Errorf("error resetting DVR window: %w", err)
+		}
+		log.LogNoRequestID("DVR window reset", log.KeyStream, streamName)
+		return nil
+	}
+	if ok && previous == window {
+		return nil
+	}
+	if err := m.mist.AddStreamWithDVR(streamName, m.streamSource, window); err != nil {
+		return fmt.Errorf("error setting DVR window: %w", err)
+	}
+	m.windows[playbackID] = window
+	log.LogNoRequestID("DVR window set", log.KeyStream, streamName, "window", window)
+	return nil
+}
+
+// Window returns the DVR window of a stream, 0 if Mist's default is used
+func (m *Manager) Window(playbackID string) time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.windows[playbackID]
+}
+
+// ManifestURL is the HLS playlist covering the DVR window of a stream
+func (m *Manager) ManifestURL(playbackID string) string {
+	return fmt.Sprintf("https://%s/hls/%s/index.m3u8", m.playbackHost, m.streamName(playbackID))
+}
+
+// MistClient wraps a Mist client so that the streams it adds, e.g. for WHIP and SRT ingest, keep their DVR window
+func (m *Manager) MistClient(mist clients.MistAPIClient) clients.MistAPIClient {
+	return &mistClient{MistAPIClient: mist, m: m}
+}
+
+type mistClient struct {
+	clients.MistAPIClient
+	m *Manager
+}
+
+func (c *mistClient) AddStream(streamName, sourceUrl string) error {
+	var window time.Duration
+	if playbackID, ok := strings.CutPrefix(streamName, c.m.baseStreamName+"+"); ok {
+		window = c.m.Window(playbackID)
+	}
+	if window == 0 {
+		return c.MistAPIClient.AddStream(streamName, sourceUrl)
+	}
+	return c.MistAPIClient.AddStreamWithDVR(streamName, sourceUrl, window)
+}
+
+func (m *Manager) streamName(playbackID string) string {
+	return m.baseStreamName + "+" + playbackID
+}
@@ -0,0 +1,43 @@
+package dvr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/livepeer/catalyst-api/events"
+	mockmistclient "github.com/livepeer/catalyst-api/mocks/clients"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSettings(t *testing.T) {
+	require.NoError(t, ValidateSettings(events.DVRSettings{}))
+	require.NoError(t, ValidateSettings(events.DVRSettings{WindowSecs: 7200}))
+	require.Error(t, ValidateSettings(events.DVRSettings{WindowSecs: -1}))
+	require.Error(t, ValidateSettings(events.DVRSettings{WindowSecs: int64(MaxWindow.Seconds()) + 1}))
+}
+
+func TestManager(t *testing.T) {
+	mist := mockmistclient.NewMockMistAPIClient(gomock.NewController(t))
+	m := NewManager(mist, "video", "push://", "playback.example.com")
+	require.Equal(t, "https://playback.example.com/hls/video+abc123/index.m3u8", m.ManifestURL("abc123"))
+
+	// resetting a stream without a window is a no-op
+	require.NoError(t, m.Apply("abc123", events.DVRSettings{}))
+
+	mist.EXPECT().AddStreamWithDVR("video+abc123", "push://", 2*time.Hour).Return(nil)
+	require.NoError(t, m.Apply("abc123", events.DVRSettings{WindowSecs: 7200}))
+	require.NoError(t, m.Apply("abc123", events.DVRSettings{WindowSecs: 7200}), "the same window isn't applied twice")
+	require.Equal(t, 2*time.Hour, m.Window("abc123"))
+
+	// streams added for ingest keep their window
+	client := m.MistClient(mist)
+	mist.EXPECT().AddStreamWithDVR("video+abc123", "push://?passphrase=x", 2*time.Hour).Return(nil)
+	require.NoError(t, client.AddStream("video+abc123", "push://?passphrase=x"))
+	mist.EXPECT().AddStream("video+other", "push://").Return(nil)
+	require.NoError(t, client.AddStream("video+other", "push://"))
+
+	mist.EXPECT().AddStream("video+abc123", "push://").Return(nil)
+	require.NoError(t, m.Apply("abc123", events.DVRSettings{WindowSecs: 0}))
+	require.Zero(t, m.Window("abc123"))
+}
@@ -16,6 +16,22 @@ type APIError struct {
 	Err    error  `json:"-"`
 }
 
+// NewAPIError returns an error to be written with the given status by WriteHTTPAPIError
+func NewAPIError(msg string, status int, err error) APIError {
+	return APIError{msg, status, err}
+}
+
+func (e APIError) Error() string {
+	if e.Err == nil {
+		return e.Msg
+	}
+	return fmt.Sprintf("%s: %s", e.Msg, e.Err)
+}
+
+func (e APIError) Unwrap() error {
+	return e.Err
+}
+
 func writeHttpError(w http.ResponseWriter, msg string, status int, err error) APIError {
 	w.WriteHeader(status)
 
@@ -35,11 +51,50 @@ func writeHttpError(w http.ResponseWriter, msg string, status int, err error) AP
 	return APIError{msg, status, err}
 }
 
+// WriteHTTPErrorWithRetry writes an HTTP error that also tells the caller whether it's safe to retry the request
+func WriteHTTPErrorWithRetry(w http.ResponseWriter, msg string, status int, err error, retryable bool) APIError {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	var errorDetail string
+	if err != nil {
+		switch status {
+		case http.StatusInternalServerError, http.StatusServiceUnavailable:
+			log.LogNoRequestID("returning HTTP error", "status", status, "http_error_msg", msg, "err", err)
+		default:
+			errorDetail = err.Error()
+		}
+	}
+
+	body := map[string]any{"error": msg, "error_detail": errorDetail, "retryable": retryable}
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.LogNoRequestID("error writing HTTP error", "http_error_msg", msg, "error", err)
+	}
+	return APIError{msg, status, err}
+}
+
+// WriteHTTPAPIError writes an error returned by the handler logic shared with the gRPC API, with the status of an
+// APIError and a 500 for any other error. Service unavailable errors are retryable.
+func WriteHTTPAPIError(w http.ResponseWriter, err error) APIError {
+	var apiErr APIError
+	if !errors.As(err, &apiErr) {
+		return WriteHTTPInternalServerError(w, "Internal error", err)
+	}
+	if apiErr.Status == http.StatusServiceUnavailable {
+		return WriteHTTPErrorWithRetry(w, apiErr.Msg, apiErr.Status, apiErr.Err, true)
+	}
+	return writeHttpError(w, apiErr.Msg, apiErr.Status, apiErr.Err)
+}
+
 // HTTP Errors
 func WriteHTTPUnauthorized(w http.ResponseWriter, msg string, err error) APIError {
 	return writeHttpError(w, msg, http.StatusUnauthorized, err)
 }
 
+func WriteHTTPForbidden(w http.ResponseWriter, msg string, err error) APIError {
+	return writeHttpError(w, msg, http.StatusForbidden, err)
+}
+
 func WriteHTTPBadRequest(w http.ResponseWriter, msg string, err error) APIError {
 	return writeHttpError(w, msg, http.StatusBadRequest, err)
 }
@@ -48,10 +103,18 @@ func WriteHTTPUnsupportedMediaType(w http.ResponseWriter, msg string, err error)
 	return writeHttpError(w, msg, http.StatusUnsupportedMediaType, err)
 }
 
+func WriteHTTPRequestEntityTooLarge(w http.ResponseWriter, msg string, err error) APIError {
+	return writeHttpError(w, msg, http.StatusRequestEntityTooLarge, err)
+}
+
 func WriteHTTPNotFound(w http.ResponseWriter, msg string, err error) APIError {
 	return writeHttpError(w, msg, http.StatusNotFound, err)
 }
 
+func WriteHTTPConflict(w http.ResponseWriter, msg string, err error) APIError {
+	return writeHttpError(w, msg, http.StatusConflict, err)
+}
+
 func WriteHTTPInternalServerError(w http.ResponseWriter, msg string, err error) APIError {
 	return writeHttpError(w, msg, http.StatusInternalServerError, err)
 }
@@ -106,6 +169,79 @@ func IsObjectNotFound(err error) bool {
 	return errors.As(err, &ObjectNotFoundError{})
 }
 
+// Reasons for VOD jobs failing, for the pipeline_failures_total metric
+const (
+	FailureSourceDownload     = "source_download"
+	FailureInvalidSource      = "invalid_source"
+	FailureSegmenting         = "segmenting_error"
+	FailureBroadcaster        = "broadcaster_error"
+	FailureExternalTranscoder = "external_transcoder_error"
+	FailureUpload             = "upload_error"
+	FailureCallback           = "callback_error"
+	FailureOther              = "other"
+)
+
+type failureReasonError struct {
+	reason string
+	error
+}
+
+// WithFailureReason records why a VOD job failed. The reason recorded closest to the cause is kept, so errors that
+// already have one are returned as they are.
+func WithFailureReason(reason string, err error) error {
+	if err == nil || FailureReason(err) != FailureOther {
+		return err
+	}
+	return failureReasonError{reason: reason, error: err}
+}
+
+// FailureReason returns the reason recorded with WithFailureReason, or FailureOther if there's none
+func FailureReason(err error) string {
+	var f failureReasonError
+	if errors.As(err, &f) {
+		return f.reason
+	}
+	return FailureOther
+}
+
+func (e failureReasonError) Unwrap() error {
+	return e.error
+}
+
+// Codes of the errors that callers can act on, sent as the error_code of the error callbacks of VOD jobs
+const (
+	CodeSourceTooLong   = "source_too_long"
+	CodeSourceTooLarge  = "source_too_large"
+	CodeSourceTruncated = "source_truncated"
+	CodeSourceCorrupt   = "source_corrupt"
+)
+
+type codedError struct {
+	code string
+	error
+}
+
+// WithCode attaches a code to an error, for the callers of VOD jobs to tell failures apart without parsing messages
+func WithCode(code string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return codedError{code: code, error: err}
+}
+
+// Code returns the code attached with WithCode, or an empty string if there's none
+func Code(err error) string {
+	var c codedError
+	if errors.As(err, &c) {
+		return c.code
+	}
+	return ""
+}
+
+func (e codedError) Unwrap() error {
+	return e.error
+}
+
 var (
 	UnauthorisedError = errors.New("UnauthorisedError")
 	InvalidJWT        = errors.New("InvalidJWTError")
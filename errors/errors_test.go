@@ -23,3 +23,31 @@ func TestUnretriable(t *testing.T) {
 	var permErr *backoff.PermanentError
 	require.True(t, errors.As(err, &permErr))
 }
+
+func TestFailureReason(t *testing.T) {
+	require.Equal(t, FailureOther, FailureReason(fmt.Errorf("bar")))
+	require.Equal(t, FailureOther, FailureReason(nil))
+	require.Nil(t, WithFailureReason(FailureUpload, nil))
+
+	err := fmt.Errorf("transcoding failed: %w", WithFailureReason(FailureUpload, fmt.Errorf("bar")))
+	require.Equal(t, FailureUpload, FailureReason(err))
+
+	// the reason closest to the cause is kept
+	err = WithFailureReason(FailureBroadcaster, err)
+	require.Equal(t, FailureUpload, FailureReason(err))
+
+	err = Unretriable(WithFailureReason(FailureInvalidSource, fmt.Errorf("bar")))
+	require.True(t, IsUnretriable(err))
+	require.Equal(t, FailureInvalidSource, FailureReason(err))
+}
+
+func TestCode(t *testing.T) {
+	require.Equal(t, "", Code(fmt.Errorf("bar")))
+	require.Nil(t, WithCode(CodeSourceTooLong, nil))
+
+	err := Unretriable(WithFailureReason(FailureInvalidSource, WithCode(CodeSourceTooLong, fmt.Errorf("bar"))))
+	err = fmt.Errorf("error copying input to storage: %w", err)
+	require.Equal(t, CodeSourceTooLong, Code(err))
+	require.Equal(t, FailureInvalidSource, FailureReason(err))
+	require.Equal(t, "error copying input to storage: bar", err.Error())
+}
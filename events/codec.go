@@ -0,0 +1,180 @@
+package events
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Serf user event payloads are either plain JSON (always starting with '{') or prefixed with one of these version bytes
+const (
+	payloadVersionGzip  byte = 0x01
+	payloadVersionChunk byte = 0x02
+)
+
+const (
+	// Payloads smaller than this aren't worth compressing
+	compressionThreshold = 256
+
+	// version byte + message ID + chunk index + chunk count
+	chunkHeaderSize = 1 + 8 + 1 + 1
+	maxChunks       = 255
+
+	// How long to wait for the remaining chunks of a payload before dropping it
+	chunkTimeout = time.Minute
+)
+
+// Marshal serialises an event into a payload suitable for a Serf user event, compressing it if it's large
+func Marshal(e Event) ([]byte, error) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	return Encode(payload)
+}
+
+// Encode compresses a JSON payload if it's large enough to benefit from it
+func Encode(payload []byte) ([]byte, error) {
+	if len(payload) < compressionThreshold {
+		return payload, nil
+	}
+	var buf bytes.Buffer
+	buf.WriteByte(payloadVersionGzip)
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(payload); err != nil {
+		return nil, fmt.Errorf("error compressing event payload: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("error compressing event payload: %w", err)
+	}
+	// Not worth it if compression didn't make the payload smaller
+	if buf.Len() >= len(payload) {
+		return payload, nil
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode reverses Encode, returning the plain JSON payload
+func Decode(payload []byte) ([]byte, error) {
+	if len(payload) == 0 {
+		return payload, nil
+	}
+	switch payload[0] {
+	case payloadVersionGzip:
+		r, err := gzip.NewReader(bytes.NewReader(payload[1:]))
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing event payload: %w", err)
+		}
+		defer r.Close()
+		decoded, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing event payload: %w", err)
+		}
+		return decoded, nil
+	case payloadVersionChunk:
+		return nil, fmt.Errorf("unable to decode event payload, chunks must be reassembled first")
+	}
+	return payload, nil
+}
+
+// IsChunk returns whether the payload is one of the parts of a payload split by Chunk
+func IsChunk(payload []byte) bool {
+	return len(payload) > 0 && payload[0] == payloadVersionChunk
+}
+
+// Chunk splits a payload into parts of at most maxSize bytes, each tagged so that they can be put back together by a Reassembler
+func Chunk(payload []byte, maxSize int) ([][]byte, error) {
+	dataSize := maxSize - chunkHeaderSize
+	if dataSize <= 0 {
+		return nil, fmt.Errorf("chunk size %d too small", maxSize)
+	}
+	count := (len(payload) + dataSize - 1) / dataSize
+	if count > maxChunks {
+		return nil, fmt.Errorf("payload of %d bytes needs %d chunks, more than the maximum of %d", len(payload), count, maxChunks)
+	}
+
+	var messageID [8]byte
+	if _, err := rand.Read(messageID[:]); err != nil {
+		return nil, fmt.Errorf("error generating chunk message ID: %w", err)
+	}
+
+	chunks := make([][]byte, 0, count)
+	for i := 0; i < count; i++ {
+		end := (i + 1) * dataSize
+		if end > len(payload) {
+			end = len(payload)
+		}
+		chunk := make([]byte, 0, chunkHeaderSize+end-i*dataSize)
+		chunk = append(chunk, payloadVersionChunk)
+		chunk = append(chunk, messageID[:]...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, payload[i*dataSize:end]...)
+		chunks = append(chunks, chunk)
+	}
+	return chunks, nil
+}
+
+type partialPayload struct {
+	chunks    [][]byte
+	received  int
+	firstSeen time.Time
+}
+
+// Reassembler collects the chunks produced by Chunk until a full payload has been received
+type Reassembler struct {
+	mu       sync.Mutex
+	payloads map[uint64]*partialPayload
+}
+
+func NewReassembler() *Reassembler {
+	return &Reassembler{payloads: map[uint64]*partialPayload{}}
+}
+
+// Add stores a chunk and returns the full payload once all of its chunks have been received
+func (r *Reassembler) Add(chunk []byte) ([]byte, bool, error) {
+	if !IsChunk(chunk) || len(chunk) < chunkHeaderSize {
+		return nil, false, fmt.Errorf("invalid event payload chunk")
+	}
+	messageID := binary.BigEndian.Uint64(chunk[1:9])
+	index, count := int(chunk[9]), int(chunk[10])
+	if count == 0 || index >= count {
+		return nil, false, fmt.Errorf("invalid event payload chunk index=%d count=%d", index, count)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.evictStale()
+
+	p, ok := r.payloads[messageID]
+	if !ok {
+		p = &partialPayload{chunks: make([][]byte, count), firstSeen: time.Now()}
+		r.payloads[messageID] = p
+	}
+	if len(p.chunks) != count {
+		return nil, false, fmt.Errorf("inconsistent chunk count for event payload, got %d expected %d", count, len(p.chunks))
+	}
+	if p.chunks[index] == nil {
+		p.chunks[index] = chunk[chunkHeaderSize:]
+		p.received++
+	}
+	if p.received < count {
+		return nil, false, nil
+	}
+
+	delete(r.payloads, messageID)
+	return bytes.Join(p.chunks, nil), true, nil
+}
+
+func (r *Reassembler) evictStale() {
+	for id, p := range r.payloads {
+		if time.Since(p.firstSeen) > chunkTimeout {
+			delete(r.payloads, id)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestItLeavesSmallPayloadsUncompressed(t *testing.T) {
+	payload, err := Marshal(&NukeEvent{Resource: "nuke", PlaybackID: "abc123"})
+	require.NoError(t, err)
+	require.Equal(t, `{"resource":"nuke","playback_id":"abc123"}`, string(payload))
+}
+
+func TestItCompressesLargePayloads(t *testing.T) {
+	playbackID := strings.Repeat("abc123", 200)
+	payload, err := Marshal(&StreamEvent{Resource: "stream", PlaybackID: playbackID})
+	require.NoError(t, err)
+	require.Equal(t, payloadVersionGzip, payload[0])
+	require.Less(t, len(payload), len(playbackID))
+
+	e, err := Unmarshal(payload)
+	require.NoError(t, err)
+	event, ok := e.(*StreamEvent)
+	require.True(t, ok)
+	require.Equal(t, playbackID, event.PlaybackID)
+}
+
+func TestItFailsCorruptCompressedPayloads(t *testing.T) {
+	_, err := Unmarshal([]byte{payloadVersionGzip, 1, 2, 3})
+	require.Error(t, err)
+}
+
+func TestItChunksAndReassemblesPayloads(t *testing.T) {
+	payload, err := json.Marshal(map[string]string{"resource": "stream", "playback_id": strings.Repeat("x", 1000)})
+	require.NoError(t, err)
+
+	chunks, err := Chunk(payload, 100)
+	require.NoError(t, err)
+	require.Len(t, chunks, 12)
+	for _, chunk := range chunks {
+		require.True(t, IsChunk(chunk))
+		require.LessOrEqual(t, len(chunk), 100)
+	}
+
+	// Chunks can arrive in any order and be duplicated
+	r := NewReassembler()
+	for i := len(chunks) - 1; i > 0; i-- {
+		_, complete, err := r.Add(chunks[i])
+		require.NoError(t, err)
+		require.False(t, complete)
+	}
+	_, complete, err := r.Add(chunks[1])
+	require.NoError(t, err)
+	require.False(t, complete)
+
+	reassembled, complete, err := r.Add(chunks[0])
+	require.NoError(t, err)
+	require.True(t, complete)
+	require.True(t, bytes.Equal(payload, reassembled))
+	require.Empty(t, r.payloads)
+}
+
+func TestItFailsToChunkOversizedPayloads(t *testing.T) {
+	_, err := Chunk(make([]byte, 1000), chunkHeaderSize)
+	require.Error(t, err)
+
+	_, err = Chunk(make([]byte, 1000), chunkHeaderSize+1)
+	require.Error(t, err)
+}
+
+func TestItFailsToUnmarshalChunks(t *testing.T) {
+	chunks, err := Chunk([]byte(`{"resource": "nuke", "playback_id": "abc123"}`), 20)
+	require.NoError(t, err)
+	_, err = Unmarshal(chunks[0])
+	require.Error(t, err)
+}
@@ -8,11 +8,18 @@ import (
 const streamEventResource = "stream"
 const nukeEventResource = "nuke"
 const stopSessionsEventResource = "stopSessions"
+const recordingEventResource = "recording"
+const dvrEventResource = "dvr"
+const metadataEventResource = "metadata"
+
+// IDHeader carries the idempotency ID of an event sent to /api/events
+const IDHeader = "X-Event-ID"
 
 type Event interface{}
 
 type GenericEvent struct {
 	Resource string `json:"resource"`
+	Version  int    `json:"version,omitempty"`
 }
 
 type StreamEvent struct {
@@ -20,44 +27,108 @@ type StreamEvent struct {
 	PlaybackID string `json:"playback_id"`
 }
 
+// NewStreamEvent returns the payload of a stream event, refreshing the stream on every node of the cluster
+func NewStreamEvent(playbackID string) ([]byte, error) {
+	return json.Marshal(StreamEvent{Resource: streamEventResource, PlaybackID: playbackID})
+}
+
 type NukeEvent struct {
 	Resource   string `json:"resource"`
 	PlaybackID string `json:"playback_id"`
+	// BroadcastAt is when a replayed event was first broadcast, in Unix milliseconds
+	BroadcastAt int64 `json:"broadcast_at,omitempty"`
+}
+
+// NewNukeEvent returns the payload of a nuke event, to be broadcast to the cluster
+func NewNukeEvent(playbackID string) ([]byte, error) {
+	return json.Marshal(NukeEvent{Resource: nukeEventResource, PlaybackID: playbackID})
 }
 
 type StopSessionsEvent struct {
 	Resource   string `json:"resource"`
 	PlaybackID string `json:"playback_id"`
+	// BroadcastAt is when a replayed event was first broadcast, in Unix milliseconds
+	BroadcastAt int64 `json:"broadcast_at,omitempty"`
+}
+
+// RecordingEvent turns the recording of a stream's sessions on or off
+type RecordingEvent struct {
+	Resource   string            `json:"resource"`
+	PlaybackID string            `json:"playback_id"`
+	Recording  RecordingSettings `json:"recording"`
+}
+
+type RecordingSettings struct {
+	Enabled bool `json:"enabled"`
+	// OutputURL is where the transcoded recordings are written to, under the ID of each session
+	OutputURL string `json:"output_url,omitempty"`
+	// CallbackURL receives the status callbacks of the recordings' VOD jobs
+	CallbackURL string `json:"callback_url,omitempty"`
+}
+
+// NewRecordingEvent returns the payload of a recording event, to be broadcast to the cluster
+func NewRecordingEvent(playbackID string, settings RecordingSettings) ([]byte, error) {
+	return json.Marshal(RecordingEvent{Resource: recordingEventResource, PlaybackID: playbackID, Recording: settings})
+}
+
+// DVREvent sets how much of a live stream is kept for playback
+type DVREvent struct {
+	Resource   string      `json:"resource"`
+	PlaybackID string      `json:"playback_id"`
+	DVR        DVRSettings `json:"dvr"`
+}
+
+type DVRSettings struct {
+	// WindowSecs is the length of the live buffer, Mist's default if 0
+	WindowSecs int64 `json:"window_secs"`
+}
+
+// NewDVREvent returns the payload of a DVR event, to be broadcast to the cluster
+func NewDVREvent(playbackID string, settings DVRSettings) ([]byte, error) {
+	return json.Marshal(DVREvent{Resource: dvrEventResource, PlaybackID: playbackID, DVR: settings})
+}
+
+// MetadataEvent sets the Mist settings of a live stream given as its metadata
+type MetadataEvent struct {
+	Resource   string         `json:"resource"`
+	PlaybackID string         `json:"playback_id"`
+	Metadata   StreamMetadata `json:"metadata"`
+}
+
+type StreamMetadata struct {
+	// SegmentSizeMs is the target duration of the stream's segments, Mist's default if 0
+	SegmentSizeMs int64 `json:"segment_size_ms,omitempty"`
+	// VideoTracks and AudioTracks are Mist track selectors, e.g. maxbps or 720p, for the viewers that don't select
+	// the tracks themselves
+	VideoTracks string   `json:"video_tracks,omitempty"`
+	AudioTracks string   `json:"audio_tracks,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// NewMetadataEvent returns the payload of a metadata event, to be broadcast to the cluster
+func NewMetadataEvent(playbackID string, metadata StreamMetadata) ([]byte, error) {
+	return json.Marshal(MetadataEvent{Resource: metadataEventResource, PlaybackID: playbackID, Metadata: metadata})
 }
 
 func Unmarshal(payload []byte) (Event, error) {
+	payload, err := Decode(payload)
+	if err != nil {
+		recordDecodeFailure("", decodeFailureInvalidPayload)
+		return nil, err
+	}
 	var generic GenericEvent
-	err := json.Unmarshal(payload, &generic)
+	err = json.Unmarshal(payload, &generic)
+	if err != nil {
+		recordDecodeFailure("", decodeFailureInvalidPayload)
+		return nil, err
+	}
+	event, err := newEventForVersion(generic.Resource, generic.Version)
 	if err != nil {
 		return nil, err
 	}
-	switch generic.Resource {
-	case streamEventResource:
-		event := &StreamEvent{}
-		err := json.Unmarshal(payload, event)
-		if err != nil {
-			return nil, err
-		}
-		return event, nil
-	case nukeEventResource:
-		event := &NukeEvent{}
-		err := json.Unmarshal(payload, event)
-		if err != nil {
-			return nil, err
-		}
-		return event, nil
-	case stopSessionsEventResource:
-		event := &StopSessionsEvent{}
-		err := json.Unmarshal(payload, event)
-		if err != nil {
-			return nil, err
-		}
-		return event, nil
+	if err := json.Unmarshal(payload, event); err != nil {
+		recordDecodeFailure(generic.Resource, decodeFailureInvalidPayload)
+		return nil, fmt.Errorf("unable to unmarshal event resource '%s' version %d: %w", generic.Resource, generic.Version, err)
 	}
-	return nil, fmt.Errorf("unable to unmarshal event, unknown resource '%s'", generic.Resource)
+	return event, nil
 }
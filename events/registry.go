@@ -0,0 +1,118 @@
+package events
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/livepeer/catalyst-api/metrics"
+)
+
+// Events without a "version" field are treated as the first version of their schema
+const defaultVersion = 1
+
+const (
+	decodeFailureUnknownResource    = "unknown_resource"
+	decodeFailureUnsupportedVersion = "unsupported_version"
+	decodeFailureInvalidPayload     = "invalid_payload"
+)
+
+// registry maps event resources to the constructors of the event types for each supported schema version
+var registry = struct {
+	sync.RWMutex
+	schemas map[string]map[int]func() Event
+}{schemas: map[string]map[int]func() Event{}}
+
+func init() {
+	Register(streamEventResource, 1, func() Event { return &StreamEvent{} })
+	Register(nukeEventResource, 1, func() Event { return &NukeEvent{} })
+	Register(stopSessionsEventResource, 1, func() Event { return &StopSessionsEvent{} })
+	Register(recordingEventResource, 1, func() Event { return &RecordingEvent{} })
+	Register(dvrEventResource, 1, func() Event { return &DVREvent{} })
+	Register(metadataEventResource, 1, func() Event { return &MetadataEvent{} })
+}
+
+// Register adds a schema version for an event resource. newEvent must return a pointer for the payload to be decoded into.
+func Register(resource string, version int, newEvent func() Event) {
+	registry.Lock()
+	defer registry.Unlock()
+	if registry.schemas[resource] == nil {
+		registry.schemas[resource] = map[int]func() Event{}
+	}
+	registry.schemas[resource][version] = newEvent
+}
+
+// SupportedVersions returns the schema versions this node can decode for each event resource, so that
+// senders can pick a version understood by the whole cluster
+func SupportedVersions() map[string][]int {
+	registry.RLock()
+	defer registry.RUnlock()
+	supported := map[string][]int{}
+	for resource, versions := range registry.schemas {
+		for version := range versions {
+			supported[resource] = append(supported[resource], version)
+		}
+		sort.Ints(supported[resource])
+	}
+	return supported
+}
+
+// IsSupported returns whether this node has a schema for the given resource and version
+func IsSupported(resource string, version int) bool {
+	if version == 0 {
+		version = defaultVersion
+	}
+	registry.RLock()
+	defer registry.RUnlock()
+	_, ok := registry.schemas[resource][version]
+	return ok
+}
+
+// newEventForVersion returns an event to decode the payload into. Events from a newer schema version than
+// we know about (e.g. sent by an upgraded node during a rollout) are decoded with our latest schema,
+// ignoring any fields we don't know about.
+func newEventForVersion(resource string, version int) (Event, error) {
+	if version == 0 {
+		version = defaultVersion
+	}
+	registry.RLock()
+	versions, ok := registry.schemas[resource]
+	registry.RUnlock()
+	if !ok {
+		recordDecodeFailure(resource, decodeFailureUnknownResource)
+		return nil, fmt.Errorf("unable to unmarshal event, unknown resource '%s'", resource)
+	}
+	if newEvent, ok := versions[version]; ok {
+		return newEvent(), nil
+	}
+
+	latest := 0
+	for v := range versions {
+		if v > latest {
+			latest = v
+		}
+	}
+	if version < latest {
+		recordDecodeFailure(resource, decodeFailureUnsupportedVersion)
+		return nil, fmt.Errorf("unable to unmarshal event, unsupported version %d for resource '%s'", version, resource)
+	}
+	glog.V(5).Infof("decoding event resource=%s version=%d with older schema version=%d", resource, version, latest)
+	metrics.Metrics.UserEventNewerVersionCount.WithLabelValues(resource, strconv.Itoa(version)).Inc()
+	return versions[latest](), nil
+}
+
+// unknownResourceLabel replaces the resources we don't have a schema for in metric labels, so that
+// arbitrary payloads can't create new series
+const unknownResourceLabel = "unknown"
+
+func recordDecodeFailure(resource, reason string) {
+	registry.RLock()
+	_, known := registry.schemas[resource]
+	registry.RUnlock()
+	if !known {
+		resource = unknownResourceLabel
+	}
+	metrics.Metrics.UserEventDecodeFailureCount.WithLabelValues(resource, reason).Inc()
+}
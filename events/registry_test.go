@@ -0,0 +1,68 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/livepeer/catalyst-api/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type nukeEventV2 struct {
+	Resource   string `json:"resource"`
+	Version    int    `json:"version"`
+	PlaybackID string `json:"playback_id"`
+	Reason     string `json:"reason"`
+}
+
+func TestItDecodesExplicitVersions(t *testing.T) {
+	e, err := Unmarshal([]byte(`{"resource": "nuke", "version": 1, "playback_id": "abc123"}`))
+	require.NoError(t, err)
+	require.Equal(t, "abc123", e.(*NukeEvent).PlaybackID)
+}
+
+func TestItDecodesNewerVersionsWithLatestKnownSchema(t *testing.T) {
+	before := testutil.ToFloat64(metrics.Metrics.UserEventNewerVersionCount.WithLabelValues("stream", "3"))
+
+	e, err := Unmarshal([]byte(`{"resource": "stream", "version": 3, "playback_id": "abc123", "new_field": {"a": 1}}`))
+	require.NoError(t, err)
+	event, ok := e.(*StreamEvent)
+	require.True(t, ok)
+	require.Equal(t, "abc123", event.PlaybackID)
+
+	require.Equal(t, before+1, testutil.ToFloat64(metrics.Metrics.UserEventNewerVersionCount.WithLabelValues("stream", "3")))
+}
+
+func TestItUsesRegisteredVersions(t *testing.T) {
+	t.Cleanup(func() {
+		registry.Lock()
+		defer registry.Unlock()
+		delete(registry.schemas, "nukeV2Test")
+	})
+	Register("nukeV2Test", 1, func() Event { return &NukeEvent{} })
+	Register("nukeV2Test", 2, func() Event { return &nukeEventV2{} })
+	require.True(t, IsSupported("nukeV2Test", 2))
+	require.Equal(t, []int{1, 2}, SupportedVersions()["nukeV2Test"])
+
+	e, err := Unmarshal([]byte(`{"resource": "nukeV2Test", "version": 2, "playback_id": "abc123", "reason": "abuse"}`))
+	require.NoError(t, err)
+	require.Equal(t, "abuse", e.(*nukeEventV2).Reason)
+
+	e, err = Unmarshal([]byte(`{"resource": "nukeV2Test", "playback_id": "abc123"}`))
+	require.NoError(t, err)
+	require.Equal(t, "abc123", e.(*NukeEvent).PlaybackID)
+}
+
+func TestItCountsUndecodableEvents(t *testing.T) {
+	unknown := metrics.Metrics.UserEventDecodeFailureCount.WithLabelValues(unknownResourceLabel, decodeFailureUnknownResource)
+	invalid := metrics.Metrics.UserEventDecodeFailureCount.WithLabelValues("nuke", decodeFailureInvalidPayload)
+	unknownBefore, invalidBefore := testutil.ToFloat64(unknown), testutil.ToFloat64(invalid)
+
+	_, err := Unmarshal([]byte(`{"resource": "not-real-thing"}`))
+	require.Error(t, err)
+	_, err = Unmarshal([]byte(`{"resource": "nuke", "playback_id": 5.5}`))
+	require.Error(t, err)
+
+	require.Equal(t, unknownBefore+1, testutil.ToFloat64(unknown))
+	require.Equal(t, invalidBefore+1, testutil.ToFloat64(invalid))
+}
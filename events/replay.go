@@ -0,0 +1,80 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/livepeer/catalyst-api/audit"
+)
+
+// Events that change the state of a node and so need to be replayed to nodes joining the cluster after they were broadcast
+var replayableResources = map[string]bool{
+	nukeEventResource:         true,
+	stopSessionsEventResource: true,
+}
+
+func IsReplayable(resource string) bool {
+	return replayableResources[resource]
+}
+
+// ReplayedEvent is a recent event served to the nodes joining the cluster
+type ReplayedEvent struct {
+	Resource   string `json:"resource"`
+	PlaybackID string `json:"playback_id"`
+	// BroadcastAt lets the joining node skip the events older than the current session of the stream
+	BroadcastAt int64 `json:"broadcast_at"`
+}
+
+// RecentEvents returns the state-changing events successfully broadcast within the window, oldest first. They're
+// filtered in the query, so that the other events broadcast in the window can't push them past its limit.
+func RecentEvents(ctx context.Context, auditLog audit.Log, window time.Duration) ([]audit.Record, error) {
+	resources := make([]string, 0, len(replayableResources))
+	for resource := range replayableResources {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+	records, err := auditLog.Query(ctx, audit.Filter{
+		Action:    audit.ActionClusterEvent,
+		Outcome:   audit.OutcomeSucceeded,
+		Resources: resources,
+		Since:     time.Now().Add(-window),
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.Reverse(records)
+	return records, nil
+}
+
+// FetchRecentEvents retrieves the raw payloads of the recent events served by another node's /api/events/recent endpoint
+func FetchRecentEvents(ctx context.Context, endpoint string) ([]json.RawMessage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching recent events: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading recent events: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching recent events, status=%d body=%s", resp.StatusCode, body)
+	}
+
+	var payloads []json.RawMessage
+	if err := json.Unmarshal(body, &payloads); err != nil {
+		return nil, fmt.Errorf("error unmarshalling recent events: %w", err)
+	}
+	return payloads, nil
+}
@@ -0,0 +1,75 @@
+package events
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/livepeer/catalyst-api/audit"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecentEventsOnlyReturnsReplayableEventsInWindow(t *testing.T) {
+	ctx := context.Background()
+	auditLog := audit.NewMemoryLog(10)
+	now := time.Now()
+	event := func(ts time.Time, resource, playbackID, outcome string) audit.Record {
+		return audit.Record{Timestamp: ts, Action: audit.ActionClusterEvent, Resource: resource, PlaybackID: playbackID, Outcome: outcome}
+	}
+	require.NoError(t, auditLog.Record(ctx, event(now.Add(-time.Hour), "nuke", "too-old", audit.OutcomeSucceeded)))
+	require.NoError(t, auditLog.Record(ctx, event(now.Add(-2*time.Minute), "nuke", "first", audit.OutcomeSucceeded)))
+	require.NoError(t, auditLog.Record(ctx, event(now.Add(-time.Minute), "stream", "refresh", audit.OutcomeSucceeded)))
+	require.NoError(t, auditLog.Record(ctx, event(now.Add(-time.Minute), "nuke", "failed", audit.OutcomeFailed)))
+	// the API calls are recorded in the same log
+	require.NoError(t, auditLog.Record(ctx, audit.Record{Timestamp: now.Add(-time.Minute), Action: audit.ActionStreamNuke, Outcome: audit.OutcomeSucceeded}))
+	require.NoError(t, auditLog.Record(ctx, event(now, "stopSessions", "second", audit.OutcomeSucceeded)))
+
+	records, err := RecentEvents(ctx, auditLog, 10*time.Minute)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.Equal(t, "first", records[0].PlaybackID)
+	require.Equal(t, "second", records[1].PlaybackID)
+}
+
+func TestRecentEventsAreNotPushedOutByOtherEvents(t *testing.T) {
+	ctx := context.Background()
+	auditLog := audit.NewMemoryLog(2 * audit.MaxQueryLimit)
+	now := time.Now()
+	require.NoError(t, auditLog.Record(ctx, audit.Record{Timestamp: now.Add(-2 * time.Minute), Action: audit.ActionClusterEvent, Resource: "nuke", PlaybackID: "nuked", Outcome: audit.OutcomeSucceeded}))
+	// more stream refreshes than a query returns
+	for i := 0; i < audit.MaxQueryLimit+100; i++ {
+		require.NoError(t, auditLog.Record(ctx, audit.Record{Timestamp: now.Add(-time.Minute), Action: audit.ActionClusterEvent, Resource: "stream", PlaybackID: "refreshed", Outcome: audit.OutcomeSucceeded}))
+	}
+
+	records, err := RecentEvents(ctx, auditLog, 10*time.Minute)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, "nuked", records[0].PlaybackID)
+}
+
+func TestFetchRecentEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"resource": "nuke", "playback_id": "abc123"}]`))
+	}))
+	defer server.Close()
+
+	payloads, err := FetchRecentEvents(context.Background(), server.URL)
+	require.NoError(t, err)
+	require.Len(t, payloads, 1)
+
+	e, err := Unmarshal(payloads[0])
+	require.NoError(t, err)
+	require.Equal(t, "abc123", e.(*NukeEvent).PlaybackID)
+}
+
+func TestFetchRecentEventsFailsOnErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	_, err := FetchRecentEvents(context.Background(), server.URL)
+	require.Error(t, err)
+}
@@ -0,0 +1,181 @@
+package featureflags
+
+import (
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// Features that can be toggled at runtime
+const (
+	// CatabalancerPlayback routes playback through catabalancer instead of MistUtilLoad, bucketed by playback ID
+	CatabalancerPlayback = "catabalancer-playback"
+	// CatabalancerIngest routes ingest source lookups through catabalancer instead of MistUtilLoad, bucketed by stream
+	CatabalancerIngest = "catabalancer-ingest"
+	// VODFallbackExternal retries failed VOD jobs on the external transcoder, bucketed by request ID
+	VODFallbackExternal = "vod-fallback-external"
+)
+
+// Where a flag was set, later sources take precedence
+const (
+	SourceConfig = "config"
+	SourceRemote = "remote"
+	SourceAdmin  = "admin"
+)
+
+// Flag is whether a feature is enabled. It's enabled for Percent of the keys, e.g. playback IDs, with each key
+// always getting the same result, and always enabled on the Nodes listed.
+type Flag struct {
+	Name    string   `json:"name"`
+	Percent float64  `json:"percent"`
+	Nodes   []string `json:"nodes,omitempty"`
+	Source  string   `json:"source,omitempty"`
+}
+
+func (f Flag) Validate() error {
+	if f.Name == "" {
+		return fmt.Errorf("flag name is required")
+	}
+	if f.Percent < 0 || f.Percent > 100 {
+		return fmt.Errorf("percent of flag %s should be between 0.0 and 100.0, got %v", f.Name, f.Percent)
+	}
+	return nil
+}
+
+// Registry holds the flags from each source. The flags from the config are overridden by the remote flag service,
+// which is overridden by the admin API.
+type Registry struct {
+	mu       sync.RWMutex
+	nodeName string
+	sources  map[string]map[string]Flag
+}
+
+func NewRegistry() *Registry {
+	return &Registry{sources: map[string]map[string]Flag{
+		SourceConfig: {},
+		SourceRemote: {},
+		SourceAdmin:  {},
+	}}
+}
+
+// Default is the registry consulted by the handlers and the pipeline
+var Default = NewRegistry()
+
+// Enabled checks a flag of the Default registry
+func Enabled(name, key string) bool {
+	return Default.Enabled(name, key)
+}
+
+// SetNodeName sets the name of this node, matched against the Nodes of the flags
+func (r *Registry) SetNodeName(nodeName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nodeName = nodeName
+}
+
+// Enabled returns whether the feature is enabled on this node for the key, false for unknown flags
+func (r *Registry) Enabled(name, key string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	f, ok := r.lookup(name)
+	if !ok {
+		return false
+	}
+	if r.nodeName != "" && slices.Contains(f.Nodes, r.nodeName) {
+		return true
+	}
+	return bucket(name, key) < f.Percent
+}
+
+// bucket maps a key to [0, 100), hashed with the flag name so that the same keys don't get every feature first
+func bucket(name, key string) float64 {
+	h := fnv.New32a()
+	h.Write([]byte(name + "/" + key)) // nolint:errcheck
+	return float64(h.Sum32()%10000) / 100
+}
+
+func (r *Registry) lookup(name string) (Flag, bool) {
+	for _, source := range []string{SourceAdmin, SourceRemote, SourceConfig} {
+		if f, ok := r.sources[source][name]; ok {
+			return f, true
+		}
+	}
+	return Flag{}, false
+}
+
+// SetConfig replaces the flags seeded from the config, as a map of flag name to percent
+func (r *Registry) SetConfig(flags map[string]float64) error {
+	config := map[string]Flag{}
+	for name, percent := range flags {
+		config[name] = Flag{Name: name, Percent: percent}
+	}
+	return r.replace(SourceConfig, config)
+}
+
+// SetRemote replaces the flags from the remote flag service
+func (r *Registry) SetRemote(flags []Flag) error {
+	remote := map[string]Flag{}
+	for _, f := range flags {
+		remote[f.Name] = f
+	}
+	return r.replace(SourceRemote, remote)
+}
+
+func (r *Registry) replace(source string, flags map[string]Flag) error {
+	for name, f := range flags {
+		if err := f.Validate(); err != nil {
+			return err
+		}
+		f.Source = source
+		flags[name] = f
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sources[source] = flags
+	return nil
+}
+
+// Override sets a flag from the admin API, taking precedence over the other sources until it's cleared or the
+// node restarts
+func (r *Registry) Override(f Flag) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	f.Source = SourceAdmin
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sources[SourceAdmin][f.Name] = f
+	return nil
+}
+
+// ClearOverride removes a flag set from the admin API, returning false if there wasn't one
+func (r *Registry) ClearOverride(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.sources[SourceAdmin][name]
+	delete(r.sources[SourceAdmin], name)
+	return ok
+}
+
+// List returns the flags in effect, sorted by name
+func (r *Registry) List() []Flag {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := map[string]bool{}
+	for _, flags := range r.sources {
+		for name := range flags {
+			names[name] = true
+		}
+	}
+	list := make([]Flag, 0, len(names))
+	for name := range names {
+		f, _ := r.lookup(name)
+		list = append(list, f)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
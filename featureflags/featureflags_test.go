@@ -0,0 +1,92 @@
+package featureflags
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnabledPercent(t *testing.T) {
+	r := NewRegistry()
+	require.False(t, r.Enabled("unknown", "abc"))
+
+	require.NoError(t, r.SetConfig(map[string]float64{"on": 100, "off": 0, "some": 25}))
+	enabled := 0
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("playback-%d", i)
+		require.True(t, r.Enabled("on", key))
+		require.False(t, r.Enabled("off", key))
+		if r.Enabled("some", key) {
+			enabled++
+		}
+		require.Equal(t, r.Enabled("some", key), r.Enabled("some", key), "a key always gets the same result")
+	}
+	require.InDelta(t, 250, enabled, 50)
+}
+
+func TestEnabledNodes(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, r.SetRemote([]Flag{{Name: "canary", Percent: 0, Nodes: []string{"node-a"}}}))
+	require.False(t, r.Enabled("canary", "abc"))
+
+	r.SetNodeName("node-b")
+	require.False(t, r.Enabled("canary", "abc"))
+	r.SetNodeName("node-a")
+	require.True(t, r.Enabled("canary", "abc"))
+}
+
+func TestSourcePrecedence(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, r.SetConfig(map[string]float64{"a": 100, "b": 100, "c": 100}))
+	require.NoError(t, r.SetRemote([]Flag{{Name: "b", Percent: 0}, {Name: "c", Percent: 0}}))
+	require.NoError(t, r.Override(Flag{Name: "c", Percent: 100}))
+
+	require.Equal(t, []Flag{
+		{Name: "a", Percent: 100, Source: SourceConfig},
+		{Name: "b", Percent: 0, Source: SourceRemote},
+		{Name: "c", Percent: 100, Source: SourceAdmin},
+	}, r.List())
+
+	require.True(t, r.ClearOverride("c"))
+	require.False(t, r.ClearOverride("c"))
+	require.False(t, r.Enabled("c", "abc"))
+}
+
+func TestInvalidFlags(t *testing.T) {
+	r := NewRegistry()
+	require.Error(t, r.SetConfig(map[string]float64{"a": 101}))
+	require.Error(t, r.SetRemote([]Flag{{Name: "", Percent: 10}}))
+	require.Error(t, r.Override(Flag{Name: "a", Percent: -1}))
+	require.Empty(t, r.List())
+}
+
+func TestPollRemote(t *testing.T) {
+	var fail atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"name": "catabalancer-playback", "percent": 100}]`))
+	}))
+	defer server.Close()
+
+	r := NewRegistry()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.PollRemote(ctx, server.URL, 10*time.Millisecond)
+
+	require.Eventually(t, func() bool {
+		return r.Enabled(CatabalancerPlayback, "abc")
+	}, time.Second, 5*time.Millisecond)
+
+	fail.Store(true)
+	time.Sleep(50 * time.Millisecond)
+	require.True(t, r.Enabled(CatabalancerPlayback, "abc"), "the previous flags are kept while the service is unavailable")
+}
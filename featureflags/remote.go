@@ -0,0 +1,56 @@
+package featureflags
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/livepeer/catalyst-api/log"
+)
+
+const remoteFetchTimeout = 10 * time.Second
+
+// PollRemote fetches the flags from a remote flag service every interval until the context is done. The service
+// returns a JSON list of flags. The flags last fetched are kept while the service is unavailable.
+func (r *Registry) PollRemote(ctx context.Context, url string, interval time.Duration) {
+	client := &http.Client{Timeout: remoteFetchTimeout}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := r.fetchRemote(ctx, client, url); err != nil {
+			log.LogNoRequestID("error fetching feature flags, keeping the previous ones", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Registry) fetchRemote(ctx context.Context, client *http.Client, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("feature flag service returned HTTP %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var flags []Flag
+	if err := json.Unmarshal(body, &flags); err != nil {
+		return fmt.Errorf("invalid feature flags: %w", err)
+	}
+	return r.SetRemote(flags)
+}
@@ -0,0 +1,111 @@
+package federation
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/livepeer/catalyst-api/events"
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/livepeer/catalyst-api/requests"
+)
+
+// FederatedHeader is set on events forwarded from another region, with the name of the origin region.
+// Events carrying it are only broadcast in the receiving region's cluster and never forwarded again.
+const FederatedHeader = "X-Catalyst-Federated-From"
+
+// DefaultResources are the event resources forwarded across regions when not configured otherwise
+var DefaultResources = []string{"stream", "nuke", "stopSessions"}
+
+// Forwarder bridges the Serf clusters of multiple regions by forwarding events received by this
+// region's /api/events endpoint to the /api/events endpoints of the other regions
+type Forwarder struct {
+	ownRegion string
+	peers     []*url.URL
+	resources map[string]bool
+	client    *http.Client
+}
+
+func NewForwarder(ownRegion string, peers []*url.URL, resources []string) *Forwarder {
+	client := retryablehttp.NewClient()
+	client.RetryMax = 5                    // Attempt request a maximum of this+1 times
+	client.RetryWaitMin = 1 * time.Second  // Wait at least this long between retries
+	client.RetryWaitMax = 10 * time.Second // Wait at most this long between retries (exponential backoff)
+	client.HTTPClient = &http.Client{
+		Timeout: 5 * time.Second, // Give up on requests that take more than this long
+	}
+	client.Logger = log.NewRetryableHTTPLogger()
+
+	r := map[string]bool{}
+	for _, resource := range resources {
+		r[resource] = true
+	}
+	return &Forwarder{
+		ownRegion: ownRegion,
+		peers:     peers,
+		resources: r,
+		client:    client.StandardClient(),
+	}
+}
+
+// ShouldForward returns whether an event should be sent to the other regions. Events that were
+// themselves forwarded from another region are not, so that they don't loop between regions.
+func (f *Forwarder) ShouldForward(req *http.Request, resource string) bool {
+	return f.ShouldForwardFrom(req.Header.Get(FederatedHeader), resource)
+}
+
+// ShouldForwardFrom is ShouldForward for events received outside of an HTTP request, originRegion being empty
+// unless the event was forwarded from another region
+func (f *Forwarder) ShouldForwardFrom(originRegion, resource string) bool {
+	if f == nil || len(f.peers) == 0 {
+		return false
+	}
+	return originRegion == "" && f.resources[resource]
+}
+
+// Forward sends the event payload to all the other regions in the background. The authorization header of the
+// original request is passed on, for regions that require authorization for events, as is its request ID.
+func (f *Forwarder) Forward(ctx context.Context, eventID, resource, authorization string, payload []byte) {
+	requestID := log.RequestID(ctx)
+	for _, peer := range f.peers {
+		go func(peer *url.URL) {
+			if err := f.send(peer, eventID, requestID, authorization, payload); err != nil {
+				glog.Errorf("error forwarding event to region peer=%s resource=%s err=%s", log.RedactURL(peer.String()), resource, err)
+			}
+		}(peer)
+	}
+}
+
+func (f *Forwarder) send(peer *url.URL, eventID, requestID, authorization string, payload []byte) error {
+	req, err := http.NewRequest(http.MethodPost, peer.String(), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(FederatedHeader, f.ownRegion)
+	// Lets the peer region ignore the event if our request gets retried after it was already broadcast there
+	req.Header.Set(events.IDHeader, eventID)
+	if requestID != "" {
+		req.Header.Set(requests.RequestIDHeader, requestID)
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status=%d body=%s", resp.StatusCode, body)
+	}
+	return nil
+}
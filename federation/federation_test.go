@@ -0,0 +1,69 @@
+package federation
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/livepeer/catalyst-api/events"
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItOnlyForwardsSelectedLocalEvents(t *testing.T) {
+	peer, err := url.Parse("http://region-b/api/events")
+	require.NoError(t, err)
+	f := NewForwarder("region-a", []*url.URL{peer}, []string{"nuke"})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/events", nil)
+	require.True(t, f.ShouldForward(req, "nuke"))
+	require.False(t, f.ShouldForward(req, "stream"))
+
+	req.Header.Set(FederatedHeader, "region-c")
+	require.False(t, f.ShouldForward(req, "nuke"))
+
+	require.False(t, NewForwarder("region-a", nil, []string{"nuke"}).ShouldForward(req, "nuke"))
+
+	var nilForwarder *Forwarder
+	require.False(t, nilForwarder.ShouldForward(req, "nuke"))
+}
+
+func TestItForwardsEventsToAllPeers(t *testing.T) {
+	received := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "region-a", r.Header.Get(FederatedHeader))
+		require.Equal(t, "event-id", r.Header.Get(events.IDHeader))
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		require.Equal(t, "req-1", r.Header.Get("X-Request-ID"))
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		received <- r.URL.Path + " " + string(body)
+	}))
+	defer server.Close()
+
+	peerB, err := url.Parse(server.URL + "/b/api/events")
+	require.NoError(t, err)
+	peerC, err := url.Parse(server.URL + "/c/api/events")
+	require.NoError(t, err)
+
+	f := NewForwarder("region-a", []*url.URL{peerB, peerC}, DefaultResources)
+	f.Forward(log.WithRequestID(context.Background(), "req-1"), "event-id", "nuke", "Bearer token", []byte(`{"resource": "nuke", "playback_id": "abc123"}`))
+
+	var got []string
+	for i := 0; i < 2; i++ {
+		select {
+		case r := <-received:
+			got = append(got, r)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "timed out waiting for forwarded event")
+		}
+	}
+	require.ElementsMatch(t, []string{
+		`/b/api/events {"resource": "nuke", "playback_id": "abc123"}`,
+		`/c/api/events {"resource": "nuke", "playback_id": "abc123"}`,
+	}, got)
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/livepeer/catalyst-api/log"
 	mistapiconnector "github.com/livepeer/catalyst-api/mapic"
 	"github.com/livepeer/catalyst-api/metrics"
+	"github.com/livepeer/catalyst-api/viewerlimit"
 	"github.com/pquerna/cachecontrol/cacheobject"
 )
 
@@ -35,6 +36,8 @@ type AccessControlHandlersCollection struct {
 	gateClient  GateAPICaller
 	dataClient  DataAPICaller
 	blockedJWTs []string
+	// ViewerLimits rejects new viewers of the streams at their max concurrent viewers, nil if none are limited
+	ViewerLimits *viewerlimit.Limiter
 }
 
 type PlaybackAccessControlEntry struct {
@@ -188,13 +191,27 @@ func (ac *AccessControlHandlersCollection) HandleUserNew(ctx context.Context, pa
 	}
 
 	if playbackAccessControlAllowed {
-		return true, nil
+		return ac.checkStreamViewerLimit(ctx, playbackID, payload), nil
 	}
 
 	log.LogCtx(ctx, "Playback access control denied")
 	return false, nil
 }
 
+// checkStreamViewerLimit is used to limit the concurrent viewers of a stream, e.g. for ticketed events. Replication of
+// the stream between nodes isn't a viewer, so isn't limited.
+func (ac *AccessControlHandlersCollection) checkStreamViewerLimit(ctx context.Context, playbackID string, payload *misttriggers.UserNewPayload) bool {
+	if ac.ViewerLimits == nil || payload.Protocol == "DTSC" {
+		return true
+	}
+	allowed, viewers, limit := ac.ViewerLimits.Allow(ctx, playbackID)
+	if !allowed {
+		log.LogCtx(ctx, "Stream viewer limit reached", "viewers", viewers, "limit", limit)
+		metrics.Metrics.ViewerLimitRejections.WithLabelValues(playbackID, "user_new").Inc()
+	}
+	return allowed
+}
+
 func (ac *AccessControlHandlersCollection) IsAuthorized(ctx context.Context, playbackID string, payload *misttriggers.UserNewPayload) (allowed bool, err error) {
 
 	if payload.Origin == "null" && payload.Referer == "" {
@@ -272,7 +289,11 @@ func (ac *AccessControlHandlersCollection) isAuthorized(ctx context.Context, pla
 		}
 		cacheKey = "accessKey_" + hashCacheKey
 	} else if jwt != "" {
-		for _, blocked := range ac.blockedJWTs {
+		blockedJWTs := ac.blockedJWTs
+		if reloaded := config.Reloaded(); reloaded != nil {
+			blockedJWTs = reloaded.BlockedJWTs
+		}
+		for _, blocked := range blockedJWTs {
 			if jwt == blocked {
 				log.LogCtx(ctx, "blocking JWT", "jwt", jwt)
 				return false, nil
@@ -418,7 +439,7 @@ func (ac *AccessControlHandlersCollection) GetPlaybackAccessControlInfo(ctx cont
 func (ac *AccessControlHandlersCollection) ProduceHashCacheKey(cachePayload PlaybackAccessControlRequest) (string, error) {
 	jsonData, err := json.Marshal(cachePayload)
 	if err != nil {
-		fmt.Println("Error marshalling JSON:", err)
+		log.LogNoRequestID("Error marshalling access control cache key", "err", err)
 		return "", err
 	}
 
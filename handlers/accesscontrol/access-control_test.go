@@ -11,6 +11,7 @@ import (
 
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/livepeer/catalyst-api/handlers/misttriggers"
+	"github.com/livepeer/catalyst-api/viewerlimit"
 	"github.com/stretchr/testify/require"
 )
 
@@ -312,3 +313,24 @@ func craftToken(sk, publicKey, playbackID string, expiration time.Time) (string,
 
 	return ss, nil
 }
+
+type stubViewerCounter int
+
+func (c stubViewerCounter) Viewers(context.Context, string) (int, error) {
+	return int(c), nil
+}
+
+func TestStreamViewerLimit(t *testing.T) {
+	ac := AccessControlHandlersCollection{}
+	viewer := &misttriggers.UserNewPayload{StreamName: plusPlaybackID, Protocol: "HLS"}
+	require.True(t, ac.checkStreamViewerLimit(context.Background(), playbackID, viewer))
+
+	ac.ViewerLimits = viewerlimit.NewLimiter(map[string]int{playbackID: 5}, "", stubViewerCounter(5))
+	require.False(t, ac.checkStreamViewerLimit(context.Background(), playbackID, viewer))
+	// replication to other nodes isn't limited
+	replication := &misttriggers.UserNewPayload{StreamName: plusPlaybackID, Protocol: "DTSC"}
+	require.True(t, ac.checkStreamViewerLimit(context.Background(), playbackID, replication))
+
+	ac.ViewerLimits = viewerlimit.NewLimiter(map[string]int{playbackID: 5}, "", stubViewerCounter(4))
+	require.True(t, ac.checkStreamViewerLimit(context.Background(), playbackID, viewer))
+}
@@ -6,18 +6,25 @@ import (
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/livepeer/catalyst-api/config"
+	"github.com/livepeer/catalyst-api/crypto"
 )
 
 type EncryptionHandlersCollection struct {
 	publicKey     string
 	spkiPublicKey string
+	keyID         string
 	nodeName      string
 }
 
 func NewEncryptionHandlersCollection(cli config.Cli, spkiPublicKey string) *EncryptionHandlersCollection {
+	var keyID string
+	if publicKey, err := crypto.ParsePublicKey(cli.VodDecryptPublicKey); err == nil {
+		keyID = crypto.KeyID(publicKey)
+	}
 	return &EncryptionHandlersCollection{
 		publicKey:     cli.VodDecryptPublicKey,
 		spkiPublicKey: spkiPublicKey,
+		keyID:         keyID,
 		nodeName:      cli.NodeName,
 	}
 }
@@ -30,6 +37,7 @@ func (ec *EncryptionHandlersCollection) PublicKeyHandler() httprouter.Handle {
 		responseData := map[string]string{
 			"public_key":      ec.publicKey,
 			"spki_public_key": ec.spkiPublicKey,
+			"key_id":          ec.keyID,
 			"node_name":       ec.nodeName,
 		}
 
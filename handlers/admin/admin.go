@@ -2,16 +2,49 @@ package admin
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/audit"
+	"github.com/livepeer/catalyst-api/clients"
 	"github.com/livepeer/catalyst-api/cluster"
+	"github.com/livepeer/catalyst-api/config"
+	"github.com/livepeer/catalyst-api/crypto/signedurl"
 	"github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/log"
+	mistapiconnector "github.com/livepeer/catalyst-api/mapic"
+	"github.com/livepeer/catalyst-api/streamkeys"
 )
 
 // Admin handlers. To be replaced by signed events and GraphQL queries when we get there.
 type AdminHandlersCollection struct {
-	Cluster cluster.Cluster
+	Cluster        cluster.Cluster
+	AuditLog       audit.Log
+	PlaybackSigner *signedurl.Signer
+	StreamKeys     streamkeys.Store
+	// Config is the config the node started with, with the secrets redacted
+	Config *config.EffectiveConfig
+	Mist   clients.MistAPIClient
+	// StreamCache is mapic's cache of the Livepeer streams, nil when mapic isn't running
+	StreamCache mistapiconnector.IStreamCache
+}
+
+// maximum lifetime of a signed playback URL
+const maxPlaybackURLExpiry = 7 * 24 * time.Hour
+
+type SignPlaybackURLRequest struct {
+	URL string `json:"url"`
+	// Seconds until the URL expires, defaults to an hour
+	ExpiresIn int64 `json:"expires_in,omitempty"`
+}
+
+type SignPlaybackURLResponse struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
 
 func (c *AdminHandlersCollection) MembersHandler() httprouter.Handle {
@@ -30,3 +63,195 @@ func (c *AdminHandlersCollection) MembersHandler() httprouter.Handle {
 		w.Write(b) // nolint:errcheck
 	}
 }
+
+// ConfigHandler dumps the config in effect on this node, merged from the defaults, the environment, the command line,
+// the config file and the last reload, with the secrets redacted and where each flag was set from
+func (c *AdminHandlersCollection) ConfigHandler() httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		if c.Config == nil {
+			errors.WriteHTTPNotFound(w, "Config not available", nil)
+			return
+		}
+		b, err := json.Marshal(c.Config.WithReloaded(config.Reloaded()))
+		if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Could not marshal config", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b) // nolint:errcheck
+	}
+}
+
+// EventsHandler lists the events broadcast to the cluster, most recent first.
+// Supports filtering by resource, playback_id, outcome, since and until (RFC3339) and limit.
+func (c *AdminHandlersCollection) EventsHandler() httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		filter, err := parseAuditFilter(r)
+		if err != nil {
+			errors.WriteHTTPBadRequest(w, "Invalid query parameters", err)
+			return
+		}
+		records, err := c.AuditLog.Query(r.Context(), filter)
+		if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Could not query event audit log", err)
+			return
+		}
+		b, err := json.Marshal(records)
+		if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Could not marshal event audit log", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b) // nolint:errcheck
+	}
+}
+
+// APIAuditHandler lists the state-changing API calls, most recent first.
+// Supports filtering by caller, action, outcome, request_id, since and until (RFC3339) and limit.
+func (c *AdminHandlersCollection) APIAuditHandler() httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		query := r.URL.Query()
+		filter := audit.Filter{
+			Caller:    query.Get("caller"),
+			Action:    query.Get("action"),
+			Outcome:   query.Get("outcome"),
+			RequestID: query.Get("request_id"),
+		}
+		var err error
+		filter.Since, filter.Until, filter.Limit, err = parseTimeRange(r)
+		if err != nil {
+			errors.WriteHTTPBadRequest(w, "Invalid query parameters", err)
+			return
+		}
+		records, err := c.AuditLog.Query(r.Context(), filter)
+		if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Could not query API audit log", err)
+			return
+		}
+		b, err := json.Marshal(records)
+		if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Could not marshal API audit log", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b) // nolint:errcheck
+	}
+}
+
+// SignPlaybackURLHandler generates signed playback URLs, which are checked by the redirect handler for gated playback
+func (c *AdminHandlersCollection) SignPlaybackURLHandler() httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		if c.PlaybackSigner == nil || !c.PlaybackSigner.CanSign() {
+			errors.WriteHTTPNotFound(w, "Playback URL signing is not configured", nil)
+			return
+		}
+
+		var req SignPlaybackURLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			errors.WriteHTTPBadRequest(w, "Invalid request payload", err)
+			return
+		}
+		u, err := url.Parse(req.URL)
+		if err != nil || u.Path == "" {
+			errors.WriteHTTPBadRequest(w, "Invalid playback URL", err)
+			return
+		}
+		expiresIn := time.Duration(req.ExpiresIn) * time.Second
+		if req.ExpiresIn == 0 {
+			expiresIn = time.Hour
+		}
+		if expiresIn <= 0 || expiresIn > maxPlaybackURLExpiry {
+			errors.WriteHTTPBadRequest(w, fmt.Sprintf("expires_in must be between 1 and %d seconds", int(maxPlaybackURLExpiry.Seconds())), nil)
+			return
+		}
+
+		expiresAt := time.Now().Add(expiresIn).Truncate(time.Second)
+		signed, err := c.PlaybackSigner.Sign(u, expiresAt)
+		if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Could not sign playback URL", err)
+			return
+		}
+		b, err := json.Marshal(SignPlaybackURLResponse{URL: signed.String(), ExpiresAt: expiresAt.UTC()})
+		if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Could not marshal signed playback URL", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b) // nolint:errcheck
+	}
+}
+
+func parseAuditFilter(r *http.Request) (audit.Filter, error) {
+	query := r.URL.Query()
+	filter := audit.Filter{
+		Action:     audit.ActionClusterEvent,
+		Resource:   query.Get("resource"),
+		PlaybackID: query.Get("playback_id"),
+		Outcome:    query.Get("outcome"),
+	}
+	var err error
+	filter.Since, filter.Until, filter.Limit, err = parseTimeRange(r)
+	return filter, err
+}
+
+// parseTimeRange parses the since, until and limit query parameters shared by the audit log queries
+func parseTimeRange(r *http.Request) (since, until time.Time, limit int, err error) {
+	query := r.URL.Query()
+	if s := query.Get("since"); s != "" {
+		if since, err = time.Parse(time.RFC3339, s); err != nil {
+			return since, until, limit, fmt.Errorf("invalid 'since' parameter: %w", err)
+		}
+	}
+	if u := query.Get("until"); u != "" {
+		if until, err = time.Parse(time.RFC3339, u); err != nil {
+			return since, until, limit, fmt.Errorf("invalid 'until' parameter: %w", err)
+		}
+	}
+	if l := query.Get("limit"); l != "" {
+		if limit, err = strconv.Atoi(l); err != nil || limit <= 0 {
+			return since, until, limit, fmt.Errorf("invalid 'limit' parameter: %s", l)
+		}
+	}
+	return since, until, limit, nil
+}
+
+type LogLevelRequest struct {
+	Level *int `json:"level"`
+	// Per-file levels in the glog -vmodule format, e.g. "coordinator=8,transcode=8"
+	VModule string `json:"vmodule,omitempty"`
+}
+
+type LogLevelResponse struct {
+	Level   string `json:"level"`
+	VModule string `json:"vmodule"`
+}
+
+// LogLevelHandler changes the log verbosity at runtime, e.g. to debug a stuck job without restarting the node and
+// losing its state. The new level isn't persisted, so a restart goes back to the -v flag.
+func (c *AdminHandlersCollection) LogLevelHandler() httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		var req LogLevelRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			errors.WriteHTTPBadRequest(w, "Invalid request payload", err)
+			return
+		}
+		if req.Level == nil {
+			errors.WriteHTTPBadRequest(w, "level is required", nil)
+			return
+		}
+		if err := log.SetVerbosity(*req.Level, req.VModule); err != nil {
+			errors.WriteHTTPBadRequest(w, "Invalid log level", err)
+			return
+		}
+		level, vmodule := log.Verbosity()
+		log.LogNoRequestID("log level changed", "level", level, "vmodule", vmodule)
+
+		b, err := json.Marshal(LogLevelResponse{Level: level, VModule: vmodule})
+		if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Could not marshal log level", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b) // nolint:errcheck
+	}
+}
@@ -0,0 +1,69 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/featureflags"
+	"github.com/livepeer/catalyst-api/log"
+)
+
+type FeatureFlagRequest struct {
+	Percent *float64 `json:"percent"`
+	// Nodes the feature is enabled on regardless of the percent
+	Nodes []string `json:"nodes,omitempty"`
+}
+
+// FeatureFlagsHandler lists the feature flags in effect on this node and where each was set
+func (c *AdminHandlersCollection) FeatureFlagsHandler() httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		writeFeatureFlags(w)
+	}
+}
+
+// SetFeatureFlagHandler overrides a feature flag on this node until it's cleared or the node restarts
+func (c *AdminHandlersCollection) SetFeatureFlagHandler() httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		var req FeatureFlagRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			errors.WriteHTTPBadRequest(w, "Invalid request payload", err)
+			return
+		}
+		if req.Percent == nil {
+			errors.WriteHTTPBadRequest(w, "percent is required", nil)
+			return
+		}
+		flag := featureflags.Flag{Name: ps.ByName("name"), Percent: *req.Percent, Nodes: req.Nodes}
+		if err := featureflags.Default.Override(flag); err != nil {
+			errors.WriteHTTPBadRequest(w, "Invalid feature flag", err)
+			return
+		}
+		log.LogNoRequestID("feature flag overridden", "flag", flag.Name, "percent", flag.Percent, "nodes", flag.Nodes)
+		writeFeatureFlags(w)
+	}
+}
+
+// ClearFeatureFlagHandler removes the override of a feature flag, going back to the remote or config value
+func (c *AdminHandlersCollection) ClearFeatureFlagHandler() httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		name := ps.ByName("name")
+		if !featureflags.Default.ClearOverride(name) {
+			errors.WriteHTTPNotFound(w, "Feature flag isn't overridden", nil)
+			return
+		}
+		log.LogNoRequestID("feature flag override cleared", "flag", name)
+		writeFeatureFlags(w)
+	}
+}
+
+func writeFeatureFlags(w http.ResponseWriter) {
+	b, err := json.Marshal(featureflags.Default.List())
+	if err != nil {
+		errors.WriteHTTPInternalServerError(w, "Could not marshal feature flags", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b) // nolint:errcheck
+}
@@ -0,0 +1,90 @@
+package admin
+
+import (
+	"encoding/json"
+	errs "errors"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/livepeer/catalyst-api/streamkeys"
+)
+
+type CreateStreamKeyRequest struct {
+	StreamName string `json:"stream_name"`
+}
+
+// CreateStreamKeyHandler issues a key for a stream, which is only returned in the response
+func (c *AdminHandlersCollection) CreateStreamKeyHandler() httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		var req CreateStreamKeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			errors.WriteHTTPBadRequest(w, "Invalid request payload", err)
+			return
+		}
+		if err := streamkeys.ValidateStreamName(req.StreamName); err != nil {
+			errors.WriteHTTPBadRequest(w, "Invalid stream name", err)
+			return
+		}
+		key, err := c.StreamKeys.Create(r.Context(), req.StreamName)
+		if errs.Is(err, streamkeys.ErrExists) {
+			errors.WriteHTTPConflict(w, "Stream already has a key, rotate it instead", nil)
+			return
+		}
+		if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Could not create stream key", err)
+			return
+		}
+		log.LogNoRequestID("stream key created", "stream", key.StreamName)
+		writeStreamKey(w, http.StatusCreated, key)
+	}
+}
+
+// RotateStreamKeyHandler replaces the key of a stream, pushes with the previous key are rejected from then on
+func (c *AdminHandlersCollection) RotateStreamKeyHandler() httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		key, err := c.StreamKeys.Rotate(r.Context(), ps.ByName("streamName"))
+		if errs.Is(err, streamkeys.ErrNotFound) {
+			errors.WriteHTTPNotFound(w, "Stream has no key", nil)
+			return
+		}
+		if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Could not rotate stream key", err)
+			return
+		}
+		log.LogNoRequestID("stream key rotated", "stream", key.StreamName)
+		writeStreamKey(w, http.StatusOK, key)
+	}
+}
+
+// RevokeStreamKeyHandler deletes the key of a stream. Pushes that already started carry on until they end.
+func (c *AdminHandlersCollection) RevokeStreamKeyHandler() httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		streamName := ps.ByName("streamName")
+		err := c.StreamKeys.Revoke(r.Context(), streamName)
+		if errs.Is(err, streamkeys.ErrNotFound) {
+			errors.WriteHTTPNotFound(w, "Stream has no key", nil)
+			return
+		}
+		if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Could not revoke stream key", err)
+			return
+		}
+		log.LogNoRequestID("stream key revoked", "stream", streamName)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func writeStreamKey(w http.ResponseWriter, status int, key streamkeys.Key) {
+	b, err := json.Marshal(key)
+	if err != nil {
+		errors.WriteHTTPInternalServerError(w, "Could not marshal stream key", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	// the key is only ever returned here
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	w.Write(b) // nolint:errcheck
+}
@@ -0,0 +1,78 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/clients"
+	"github.com/livepeer/catalyst-api/errors"
+	mistapiconnector "github.com/livepeer/catalyst-api/mapic"
+)
+
+// Types of the streams on a node
+const (
+	StreamTypeIngest   = "ingest"
+	StreamTypePlayback = "playback"
+)
+
+// StreamSummary is a stream active in Mist on this node. The source isn't included since the URLs of pulled
+// streams can carry credentials.
+type StreamSummary struct {
+	Name       string `json:"name"`
+	PlaybackID string `json:"playback_id,omitempty"`
+	// Type is ingest for the streams ingested on this node, playback for the ones pulled from another node to serve
+	// the viewers here
+	Type       string `json:"type"`
+	Viewers    int    `json:"viewers"`
+	UptimeSecs int64  `json:"uptime_secs"`
+	// StreamID and StreamName of the Livepeer stream, when mapic knows it
+	StreamID   string `json:"stream_id,omitempty"`
+	StreamName string `json:"stream_name,omitempty"`
+}
+
+// StreamsHandler lists the streams active in Mist on this node, with what mapic knows about them
+func (c *AdminHandlersCollection) StreamsHandler() httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		state, err := c.Mist.GetState()
+		if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Could not get Mist state", err)
+			return
+		}
+		b, err := json.Marshal(streamSummaries(state, c.StreamCache))
+		if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Could not marshal list of streams", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b) // nolint:errcheck
+	}
+}
+
+func streamSummaries(state clients.MistState, streamCache mistapiconnector.IStreamCache) []StreamSummary {
+	streams := []StreamSummary{}
+	for name := range state.ActiveStreams {
+		s := StreamSummary{Name: name, Type: StreamTypePlayback}
+		if state.IsIngestStream(name) {
+			s.Type = StreamTypeIngest
+		}
+		if stats := state.StreamsStats[name]; stats != nil {
+			s.Viewers = stats.Clients
+			// the media time of a live stream starts when it goes live
+			s.UptimeSecs = stats.MediaTimeMs / 1000
+		}
+		if _, playbackID, ok := strings.Cut(name, "+"); ok {
+			s.PlaybackID = playbackID
+			if streamCache != nil {
+				if stream := streamCache.GetCachedStream(playbackID); stream != nil {
+					s.StreamID, s.StreamName = stream.ID, stream.Name
+				}
+			}
+		}
+		streams = append(streams, s)
+	}
+	sort.Slice(streams, func(i, j int) bool { return streams[i].Name < streams[j].Name })
+	return streams
+}
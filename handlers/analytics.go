@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/livepeer/catalyst-api/metrics"
+	"github.com/livepeer/catalyst-api/pprof"
 	"github.com/ua-parser/uap-go/uaparser"
 
 	"github.com/golang/glog"
@@ -106,6 +107,7 @@ func (c *AnalyticsHandlersCollection) Log() httprouter.Handle {
 
 	dataCh := make(chan analytics.LogData, LogChannelBufferSize)
 	c.logProcessor.Start(dataCh)
+	pprof.RegisterQueue("analytics_logs", func() int { return len(dataCh) })
 
 	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 		log, err := parseAnalyticsLog(r, schema)
@@ -0,0 +1,257 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/livepeer/catalyst-api/config"
+	"github.com/livepeer/catalyst-api/handlers/misttriggers"
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/livepeer/catalyst-api/metrics"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	sessionBatchSize   = 500
+	sessionSendTimeout = 30 * time.Second
+)
+
+// SessionRecord is a playback session that ended on this node, as exported to the data warehouse
+type SessionRecord struct {
+	SessionID       string   `json:"session_id"`
+	PlaybackID      string   `json:"playback_id"`
+	StreamName      string   `json:"stream_name"`
+	Protocol        string   `json:"protocol"`
+	IPAddress       string   `json:"ip_address"`
+	Node            string   `json:"node"`
+	NodeLatitude    float64  `json:"node_latitude"`
+	NodeLongitude   float64  `json:"node_longitude"`
+	StartedAtMs     int64    `json:"started_at_ms"`
+	EndedAtMs       int64    `json:"ended_at_ms"`
+	DurationSecs    int64    `json:"duration_s"`
+	DownloadedBytes int64    `json:"downloaded_bytes"`
+	UploadedBytes   int64    `json:"uploaded_bytes"`
+	Tags            []string `json:"tags,omitempty"`
+}
+
+// SessionSink receives the batches of session records
+type SessionSink interface {
+	Send(ctx context.Context, records []SessionRecord) error
+}
+
+// NewSessionSink returns the sink configured for session records, an HTTP batch endpoint or a Kafka topic, nil if
+// neither is configured
+func NewSessionSink(cli config.Cli) SessionSink {
+	if cli.SessionExportURL != "" {
+		return NewHTTPSessionSink(cli.SessionExportURL)
+	}
+	if cli.SessionExportKafkaTopic != "" {
+		if cli.KafkaBootstrapServers == "" || cli.KafkaUser == "" || cli.KafkaPassword == "" {
+			glog.Warning("Invalid Kafka configuration for playback session records, not exporting them")
+			return nil
+		}
+		return &KafkaSessionSink{writer: newWriter(cli.KafkaBootstrapServers, cli.KafkaUser, cli.KafkaPassword, cli.SessionExportKafkaTopic)}
+	}
+	return nil
+}
+
+// KafkaSessionSink writes each session record as a message keyed by its session ID
+type KafkaSessionSink struct {
+	writer *kafka.Writer
+}
+
+func (s *KafkaSessionSink) Send(ctx context.Context, records []SessionRecord) error {
+	defer logWriteMetrics(s.writer)
+
+	var msgs []kafka.Message
+	for _, r := range records {
+		key, err := json.Marshal(KafkaKey{SessionID: r.SessionID})
+		if err != nil {
+			return fmt.Errorf("cannot create Kafka key: %w", err)
+		}
+		value, err := json.Marshal(r)
+		if err != nil {
+			return fmt.Errorf("cannot create Kafka value: %w", err)
+		}
+		msgs = append(msgs, kafka.Message{Key: key, Value: value})
+	}
+	return s.writer.WriteMessages(ctx, msgs...)
+}
+
+// HTTPSessionSink POSTs each batch of session records to an endpoint as a JSON array
+type HTTPSessionSink struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPSessionSink(url string) *HTTPSessionSink {
+	client := retryablehttp.NewClient()
+	client.RetryMax = 3                    // Attempt request a maximum of this+1 times
+	client.RetryWaitMin = 1 * time.Second  // Wait at least this long between retries
+	client.RetryWaitMax = 10 * time.Second // Wait at most this long between retries (exponential backoff)
+	client.HTTPClient = &http.Client{
+		Timeout: 10 * time.Second, // Give up on requests that take more than this long
+	}
+	client.Logger = log.NewRetryableHTTPLogger()
+
+	return &HTTPSessionSink{url: url, client: client.StandardClient()}
+}
+
+func (s *HTTPSessionSink) Send(ctx context.Context, records []SessionRecord) error {
+	body, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("session export endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SessionExporter assembles a record of each playback session from its USER_END trigger, and sends them to the sink
+// in batches
+type SessionExporter struct {
+	sink          SessionSink
+	node          string
+	nodeLatitude  float64
+	nodeLongitude float64
+
+	recordsCh chan SessionRecord
+	records   []SessionRecord
+}
+
+func NewSessionExporter(sink SessionSink, node string, nodeLatitude, nodeLongitude float64) *SessionExporter {
+	e := &SessionExporter{
+		sink:          sink,
+		node:          node,
+		nodeLatitude:  nodeLatitude,
+		nodeLongitude: nodeLongitude,
+		recordsCh:     make(chan SessionRecord, channelBufferSize),
+	}
+	e.startLoop()
+	return e
+}
+
+// HandleUserEnd queues the record of a playback session. The sessions of ingests, pushes and other internal traffic
+// aren't exported.
+func (e *SessionExporter) HandleUserEnd(ctx context.Context, payload *misttriggers.UserEndPayload) error {
+	record, ok := e.toSessionRecord(payload, time.Now())
+	if !ok {
+		return nil
+	}
+	select {
+	case e.recordsCh <- record:
+		// sent async
+	default:
+		metrics.Metrics.AnalyticsMetrics.SessionRecords.WithLabelValues("dropped").Inc()
+		log.LogCtx(ctx, "error exporting playback session, too many records in the buffer", "session_id", record.SessionID)
+	}
+	return nil
+}
+
+func (e *SessionExporter) toSessionRecord(payload *misttriggers.UserEndPayload, endedAt time.Time) (SessionRecord, bool) {
+	var protocol string
+	for i := len(payload.Protocols) - 1; i >= 0; i-- {
+		if p := payload.Protocols[i]; p != "" && !strings.HasPrefix(p, "INPUT") && !strings.HasPrefix(p, "OUTPUT") {
+			protocol = p
+			break
+		}
+	}
+	if protocol == "" || len(payload.StreamNames) == 0 {
+		return SessionRecord{}, false
+	}
+
+	streamName := payload.StreamNames[len(payload.StreamNames)-1]
+	playbackID := streamName
+	if _, after, ok := strings.Cut(streamName, "+"); ok {
+		playbackID = after
+	}
+	var ip string
+	if len(payload.IPs) > 0 {
+		ip = payload.IPs[len(payload.IPs)-1]
+	}
+	var tags []string
+	for _, tag := range payload.Tags {
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	// Mist gives the counts as strings, the ones that don't parse are left as 0
+	duration, _ := strconv.ParseInt(payload.TimeActiveSecs, 10, 64)
+	downloaded, _ := strconv.ParseInt(payload.DownloadedBytes, 10, 64)
+	uploaded, _ := strconv.ParseInt(payload.UploadedBytes, 10, 64)
+
+	sessionID := payload.SessionID
+	if sessionID == "" {
+		sessionID = payload.TriggerID
+	}
+	return SessionRecord{
+		SessionID:       sessionID,
+		PlaybackID:      playbackID,
+		StreamName:      streamName,
+		Protocol:        protocol,
+		IPAddress:       ip,
+		Node:            e.node,
+		NodeLatitude:    e.nodeLatitude,
+		NodeLongitude:   e.nodeLongitude,
+		StartedAtMs:     endedAt.Add(-time.Duration(duration) * time.Second).UnixMilli(),
+		EndedAtMs:       endedAt.UnixMilli(),
+		DurationSecs:    duration,
+		DownloadedBytes: downloaded,
+		UploadedBytes:   uploaded,
+		Tags:            tags,
+	}, true
+}
+
+func (e *SessionExporter) startLoop() {
+	t := time.NewTicker(sendInterval)
+	go func() {
+		for {
+			select {
+			case r := <-e.recordsCh:
+				e.records = append(e.records, r)
+				if len(e.records) >= sessionBatchSize {
+					e.sendRecords()
+				}
+			case <-t.C:
+				e.sendRecords()
+			}
+		}
+	}()
+}
+
+func (e *SessionExporter) sendRecords() {
+	if len(e.records) == 0 {
+		return
+	}
+	records := e.records
+	e.records = nil
+
+	ctx, cancel := context.WithTimeout(context.Background(), sessionSendTimeout)
+	defer cancel()
+	if err := e.sink.Send(ctx, records); err != nil {
+		// as with the USER_END events, the records are dropped rather than piling up in memory
+		metrics.Metrics.AnalyticsMetrics.SessionRecords.WithLabelValues("failed").Add(float64(len(records)))
+		glog.Errorf("error exporting playback sessions, the records are lost, count=%d err=%v", len(records), err)
+		return
+	}
+	metrics.Metrics.AnalyticsMetrics.SessionRecords.WithLabelValues("exported").Add(float64(len(records)))
+}
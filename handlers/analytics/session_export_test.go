@@ -0,0 +1,114 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/livepeer/catalyst-api/handlers/misttriggers"
+	"github.com/stretchr/testify/require"
+)
+
+type stubSessionSink struct {
+	mu      sync.Mutex
+	records []SessionRecord
+}
+
+func (s *stubSessionSink) Send(_ context.Context, records []SessionRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, records...)
+	return nil
+}
+
+func (s *stubSessionSink) sent() []SessionRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.records
+}
+
+func TestItAssemblesSessionRecords(t *testing.T) {
+	e := &SessionExporter{node: "node-0", nodeLatitude: 51.5, nodeLongitude: -0.1}
+	endedAt := time.UnixMilli(1700000100000)
+
+	record, ok := e.toSessionRecord(&misttriggers.UserEndPayload{
+		TriggerID:       "trigger-id",
+		SessionID:       "session-id",
+		StreamNames:     []string{"video+abc123"},
+		Protocols:       []string{"HLS"},
+		IPs:             []string{"1.2.3.4"},
+		TimeActiveSecs:  "100",
+		DownloadedBytes: "123456",
+		UploadedBytes:   "789",
+		Tags:            []string{"[tag]", ""},
+	}, endedAt)
+	require.True(t, ok)
+	require.Equal(t, SessionRecord{
+		SessionID:       "session-id",
+		PlaybackID:      "abc123",
+		StreamName:      "video+abc123",
+		Protocol:        "HLS",
+		IPAddress:       "1.2.3.4",
+		Node:            "node-0",
+		NodeLatitude:    51.5,
+		NodeLongitude:   -0.1,
+		StartedAtMs:     1700000000000,
+		EndedAtMs:       1700000100000,
+		DurationSecs:    100,
+		DownloadedBytes: 123456,
+		UploadedBytes:   789,
+		Tags:            []string{"[tag]"},
+	}, record)
+
+	// ingests and pushes aren't playback sessions
+	_, ok = e.toSessionRecord(&misttriggers.UserEndPayload{StreamNames: []string{"video+abc123"}, Protocols: []string{"INPUT:RTMP"}}, endedAt)
+	require.False(t, ok)
+	_, ok = e.toSessionRecord(&misttriggers.UserEndPayload{StreamNames: []string{"video+abc123"}, Protocols: []string{"OUTPUT:Livepeer"}}, endedAt)
+	require.False(t, ok)
+}
+
+func TestItExportsSessionRecordsInBatches(t *testing.T) {
+	sink := &stubSessionSink{}
+	e := NewSessionExporter(sink, "node-0", 0, 0)
+
+	for _, id := range []string{"session-1", "session-2"} {
+		require.NoError(t, e.HandleUserEnd(context.Background(), &misttriggers.UserEndPayload{
+			SessionID:   id,
+			StreamNames: []string{"video+abc123"},
+			Protocols:   []string{"WebRTC"},
+		}))
+	}
+	require.NoError(t, e.HandleUserEnd(context.Background(), &misttriggers.UserEndPayload{
+		SessionID:   "session-3",
+		StreamNames: []string{"video+abc123"},
+		Protocols:   []string{"INPUT:RTMP"},
+	}))
+
+	require.Eventually(t, func() bool { return len(sink.sent()) == 2 }, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, "session-1", sink.sent()[0].SessionID)
+	require.Equal(t, "session-2", sink.sent()[1].SessionID)
+}
+
+func TestItPostsSessionRecords(t *testing.T) {
+	var received []SessionRecord
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer server.Close()
+
+	sink := NewHTTPSessionSink(server.URL)
+	require.NoError(t, sink.Send(context.Background(), []SessionRecord{{SessionID: "session-1"}, {SessionID: "session-2"}}))
+	require.Len(t, received, 2)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer failing.Close()
+	require.Error(t, NewHTTPSessionSink(failing.URL).Send(context.Background(), []SessionRecord{{SessionID: "session-1"}}))
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/liveclip"
+	"github.com/livepeer/catalyst-api/log"
+)
+
+// LiveClipRequest is the /api/clip payload. The clip is processed as a /api/v2/vod job, with the live stream's
+// buffer as the source.
+type LiveClipRequest struct {
+	ExternalID  string               `json:"external_id,omitempty"`
+	CallbackURL string               `json:"callback_url"`
+	Outputs     UploadVODOutputsV2   `json:"outputs"`
+	Transcode   UploadVODTranscodeV2 `json:"transcode,omitempty"`
+	Clip        LiveClip             `json:"clip"`
+}
+
+// LiveClip is the part of a live stream to clip, either between StartTime and EndTime or the last LastSecs
+type LiveClip struct {
+	UploadVODClipV2
+	LastSecs int64 `json:"last_secs,omitempty"`
+}
+
+type LiveClipHandlers struct {
+	VOD     *CatalystAPIHandlersCollection
+	Clipper *liveclip.Clipper
+}
+
+// Clip starts a VOD job clipping the buffer of a live stream on this node, returning its request ID
+func (h *LiveClipHandlers) Clip() httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		var r LiveClipRequest
+		if !HasContentType(req, "application/json") {
+			errors.WriteHTTPUnsupportedMediaType(w, "Requires application/json content type", nil)
+			return
+		} else if err := json.NewDecoder(req.Body).Decode(&r); err != nil {
+			errors.WriteHTTPBadRequest(w, "Invalid request payload", err)
+			return
+		} else if !playbackIDRegex.MatchString(r.Clip.PlaybackID) {
+			errors.WriteHTTPBadRequest(w, "Invalid playback ID", nil)
+			return
+		}
+		startTime, endTime, err := liveclip.TimeRange(time.Now(), r.Clip.StartTime, r.Clip.EndTime, r.Clip.LastSecs)
+		if err != nil {
+			errors.WriteHTTPBadRequest(w, "Invalid Clipping Request", err)
+			return
+		}
+		sourceURL, err := h.Clipper.SourceURL(r.Clip.PlaybackID)
+		if err != nil {
+			errors.WriteHTTPNotFound(w, "Stream not found", err)
+			return
+		}
+
+		requestID, err := h.VOD.StartUploadVODV2(req.Context(), UploadVODRequestV2{
+			ExternalID:  r.ExternalID,
+			Source:      UploadVODSourceV2{URL: sourceURL},
+			CallbackURL: r.CallbackURL,
+			Outputs:     r.Outputs,
+			Transcode:   r.Transcode,
+			Clip: &UploadVODClipV2{
+				URL:        r.Clip.URL,
+				PlaybackID: r.Clip.PlaybackID,
+				StartTime:  startTime,
+				EndTime:    endTime,
+			},
+		})
+		if err != nil {
+			errors.WriteHTTPAPIError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(UploadVODResponse{RequestID: requestID}); err != nil {
+			log.LogError(requestID, "Failed to write a /api/clip HTTP API response", err)
+		}
+	}
+}
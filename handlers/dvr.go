@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/dvr"
+	"github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/events"
+)
+
+type DVRResponse struct {
+	WindowSecs  int64  `json:"window_secs"`
+	ManifestURL string `json:"manifest_url"`
+}
+
+// DVRHandlers set how much of a live stream is kept for playback. The window is broadcast to the cluster as a DVR
+// event, since the stream can be ingested on any node.
+type DVRHandlers struct {
+	Events *EventsHandlersCollection
+	DVR    *dvr.Manager
+}
+
+// SetDVR sets the DVR window of a stream, returning the ID of the event broadcast
+func (h *DVRHandlers) SetDVR() httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
+		playbackID := params.ByName("playbackID")
+		if !playbackIDRegex.MatchString(playbackID) {
+			errors.WriteHTTPBadRequest(w, "Invalid playback ID", nil)
+			return
+		}
+		var settings events.DVRSettings
+		if !HasContentType(req, "application/json") {
+			errors.WriteHTTPUnsupportedMediaType(w, "Requires application/json content type", nil)
+			return
+		} else if err := json.NewDecoder(req.Body).Decode(&settings); err != nil {
+			errors.WriteHTTPBadRequest(w, "Invalid request payload", err)
+			return
+		} else if err := dvr.ValidateSettings(settings); err != nil {
+			errors.WriteHTTPBadRequest(w, "Invalid DVR settings", err)
+			return
+		}
+
+		payload, err := events.NewDVREvent(playbackID, settings)
+		if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Cannot encode DVR event", err)
+			return
+		}
+		eventID, err := h.Events.Broadcast(req.Context(), BroadcastRequest{
+			Payload:       payload,
+			EventID:       req.Header.Get(events.IDHeader),
+			Requester:     getRequester(req),
+			Authorization: req.Header.Get("Authorization"),
+		})
+		if err != nil {
+			errors.WriteHTTPAPIError(w, err)
+			return
+		}
+		writeEventResponse(w, eventID)
+	}
+}
+
+// GetDVR returns the DVR window this node has for a stream and the playlist covering it
+func (h *DVRHandlers) GetDVR() httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
+		playbackID := params.ByName("playbackID")
+		if !playbackIDRegex.MatchString(playbackID) {
+			errors.WriteHTTPBadRequest(w, "Invalid playback ID", nil)
+			return
+		}
+		b, err := json.Marshal(DVRResponse{
+			WindowSecs:  int64(h.DVR.Window(playbackID).Seconds()),
+			ManifestURL: h.DVR.ManifestURL(playbackID),
+		})
+		if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Cannot marshal DVR settings", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b) // nolint:errcheck
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/crypto"
+	"github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/log"
+)
+
+// KeyWrappingAlgorithm is how uploaders must encrypt the AES key of their source with a public key
+const KeyWrappingAlgorithm = "RSA-OAEP-SHA256"
+
+type EncryptionKeysResponse struct {
+	// ID of the key uploaders should encrypt with, to be passed as encryption.key_id in the upload request
+	CurrentKeyID string          `json:"current_key_id"`
+	Keys         []EncryptionKey `json:"keys"`
+	Algorithms   []string        `json:"algorithms"`
+	KeyWrapping  string          `json:"key_wrapping"`
+	// X25519 is set when uploads can also use the x25519 key wrapping, see crypto.X25519Key.DeriveKey
+	X25519 *X25519PublicKey `json:"x25519,omitempty"`
+}
+
+type X25519PublicKey struct {
+	KeyID string `json:"key_id"`
+	// Base64 encoded raw 32 byte public key
+	PublicKey string `json:"public_key"`
+}
+
+type EncryptionKey struct {
+	KeyID string `json:"key_id"`
+	// Base64 encoded PKCS#1 PEM and SPKI public key, in the same formats as /api/pubkey
+	PublicKey     string `json:"public_key"`
+	SPKIPublicKey string `json:"spki_public_key"`
+	Current       bool   `json:"current"`
+}
+
+// EncryptionKeys lists the public keys that sources can be encrypted for, so that uploaders can discover the
+// current key and the formats we accept without it being distributed out of band
+func (d *CatalystAPIHandlersCollection) EncryptionKeys() httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		if d.VODEngine == nil || (d.VODEngine.VodDecryptKeys == nil && d.VODEngine.VodX25519Key == nil) {
+			errors.WriteHTTPNotFound(w, "Encrypted uploads are not enabled", nil)
+			return
+		}
+
+		var publicKeys []*rsa.PublicKey
+		if d.VODEngine.VodDecryptKeys != nil {
+			var err error
+			publicKeys, err = d.VODEngine.VodDecryptKeys.PublicKeys(req.Context())
+			if err != nil {
+				errors.WriteHTTPInternalServerError(w, "Cannot get encryption keys", err)
+				return
+			}
+		}
+
+		resp := EncryptionKeysResponse{
+			Keys:        []EncryptionKey{},
+			Algorithms:  crypto.SupportedAlgorithms,
+			KeyWrapping: KeyWrappingAlgorithm,
+		}
+		if x25519 := d.VODEngine.VodX25519Key; x25519 != nil {
+			resp.X25519 = &X25519PublicKey{
+				KeyID:     x25519.KeyID(),
+				PublicKey: base64.StdEncoding.EncodeToString(x25519.PublicKey()),
+			}
+		}
+		for i, publicKey := range publicKeys {
+			encoded := crypto.EncodePublicKey(publicKey)
+			spki, err := crypto.ConvertToSpki(encoded)
+			if err != nil {
+				errors.WriteHTTPInternalServerError(w, "Cannot encode encryption key", err)
+				return
+			}
+			keyID := crypto.KeyID(publicKey)
+			if i == 0 {
+				resp.CurrentKeyID = keyID
+			}
+			resp.Keys = append(resp.Keys, EncryptionKey{
+				KeyID:         keyID,
+				PublicKey:     encoded,
+				SPKIPublicKey: spki,
+				Current:       i == 0,
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		// keys are rotated rarely, but clients shouldn't hold on to a retired key for long
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			log.LogNoRequestID("Failed to write HTTP response for " + req.URL.RawPath)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/crypto"
+	"github.com/livepeer/catalyst-api/pipeline"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptionKeysHandler(t *testing.T) {
+	current, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	previous, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	catalystApiHandlers := CatalystAPIHandlersCollection{VODEngine: &pipeline.Coordinator{
+		VodDecryptKeys: crypto.NewKeyRing(crypto.NewPrivateKeyProvider(current), crypto.NewPrivateKeyProvider(previous)),
+	}}
+	router := httprouter.New()
+	router.GET("/api/vod/encryption-key", catalystApiHandlers.EncryptionKeys())
+
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/vod/encryption-key", nil)
+	router.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "public, max-age=300", rr.Header().Get("Cache-Control"))
+
+	var resp EncryptionKeysResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, crypto.KeyID(&current.PublicKey), resp.CurrentKeyID)
+	require.Equal(t, []string{"aes-cbc", "aes-gcm"}, resp.Algorithms)
+	require.Equal(t, "RSA-OAEP-SHA256", resp.KeyWrapping)
+	require.Len(t, resp.Keys, 2)
+	require.True(t, resp.Keys[0].Current)
+	require.False(t, resp.Keys[1].Current)
+	require.Equal(t, crypto.KeyID(&previous.PublicKey), resp.Keys[1].KeyID)
+
+	publicKey, err := crypto.ParsePublicKey(resp.Keys[1].PublicKey)
+	require.NoError(t, err)
+	require.True(t, publicKey.Equal(&previous.PublicKey))
+}
+
+func TestEncryptionKeysHandlerDisabled(t *testing.T) {
+	catalystApiHandlers := CatalystAPIHandlersCollection{VODEngine: &pipeline.Coordinator{}}
+	router := httprouter.New()
+	router.GET("/api/vod/encryption-key", catalystApiHandlers.EncryptionKeys())
+
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/vod/encryption-key", nil)
+	router.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestEncryptionKeysHandlerX25519(t *testing.T) {
+	privateKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	x25519Key := crypto.NewX25519Key(privateKey)
+
+	catalystApiHandlers := CatalystAPIHandlersCollection{VODEngine: &pipeline.Coordinator{VodX25519Key: x25519Key}}
+	router := httprouter.New()
+	router.GET("/api/vod/encryption-key", catalystApiHandlers.EncryptionKeys())
+
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/vod/encryption-key", nil)
+	router.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp EncryptionKeysResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Empty(t, resp.Keys)
+	require.NotNil(t, resp.X25519)
+	require.Equal(t, x25519Key.KeyID(), resp.X25519.KeyID)
+	require.Equal(t, base64.StdEncoding.EncodeToString(privateKey.PublicKey().Bytes()), resp.X25519.PublicKey)
+}
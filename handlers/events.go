@@ -1,83 +1,286 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	errors2 "errors"
 	"fmt"
+	"github.com/cenkalti/backoff/v4"
 	"github.com/golang/glog"
 	"github.com/hashicorp/serf/serf"
 	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/audit"
 	"github.com/livepeer/catalyst-api/balancer"
 	"github.com/livepeer/catalyst-api/cluster"
+	"github.com/livepeer/catalyst-api/config"
+	"github.com/livepeer/catalyst-api/dvr"
 	"github.com/livepeer/catalyst-api/errors"
 	"github.com/livepeer/catalyst-api/events"
+	"github.com/livepeer/catalyst-api/federation"
+	"github.com/livepeer/catalyst-api/liveprofiles"
+	"github.com/livepeer/catalyst-api/log"
 	mistapiconnector "github.com/livepeer/catalyst-api/mapic"
+	"github.com/livepeer/catalyst-api/recording"
+	"github.com/livepeer/catalyst-api/streammeta"
+	"github.com/patrickmn/go-cache"
 	"github.com/xeipuuv/gojsonschema"
 	"io"
 	"net/http"
+	"time"
 )
 
 type EventsHandlersCollection struct {
 	cluster cluster.Cluster
 
-	mapic mistapiconnector.IMac
-	bal   balancer.Balancer
+	mapic    mistapiconnector.IMac
+	bal      balancer.Balancer
+	auditLog audit.Log
 
+	federation     *federation.Forwarder
 	eventsEndpoint string
+
+	// IDs of the events recently broadcast, to avoid broadcasting retried requests twice
+	broadcastIDs *cache.Cache
+
+	// Recorder applies the recording events, nil if recording isn't configured on this node
+	Recorder *recording.Recorder
+	// DVR applies the DVR events, nil without Mist
+	DVR *dvr.Manager
+	// Metadata applies the metadata events, nil without Mist
+	Metadata *streammeta.Manager
+	// LiveProfiles reloads the live transcode profiles of the refreshed streams, nil without Mist
+	LiveProfiles *liveprofiles.Manager
+}
+
+// BroadcastRetryBackoff is how transient failures to broadcast an event to the cluster are retried
+func BroadcastRetryBackoff() backoff.BackOff {
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = 100 * time.Millisecond
+	b.MaxInterval = time.Second
+	return backoff.WithMaxRetries(b, 3)
 }
 
 type Event struct {
 	Resource   string `json:"resource"`
 	PlaybackID string `json:"playback_id"`
+	Version    int    `json:"version,omitempty"`
 }
 
-func NewEventsHandlersCollection(cluster cluster.Cluster, mapic mistapiconnector.IMac, bal balancer.Balancer, eventsEndpoint string) *EventsHandlersCollection {
+func NewEventsHandlersCollection(cluster cluster.Cluster, mapic mistapiconnector.IMac, bal balancer.Balancer, auditLog audit.Log, federation *federation.Forwarder, eventsEndpoint string) *EventsHandlersCollection {
 	return &EventsHandlersCollection{
 		cluster:        cluster,
 		mapic:          mapic,
 		bal:            bal,
+		auditLog:       auditLog,
+		federation:     federation,
 		eventsEndpoint: eventsEndpoint,
+		broadcastIDs:   cache.New(10*time.Minute, 10*time.Minute),
 	}
 }
 
+// RecentBroadcastCount returns the number of recently broadcast event IDs kept to deduplicate retries
+func (d *EventsHandlersCollection) RecentBroadcastCount() int {
+	return d.broadcastIDs.ItemCount()
+}
+
 // Events is a handler called by Catalyst API which forwards events from Studio API.
 // Used to, e.g., refresh a stream or nuke a stream.
 // This event is then propagated to all Serf nodes and then forwarded to catalyst-api and handled by ReceiveUserEvent().
 func (d *EventsHandlersCollection) Events() httprouter.Handle {
-	schema := inputSchemasCompiled["Event"]
 	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 		payload, err := io.ReadAll(req.Body)
 		if err != nil {
 			errors.WriteHTTPInternalServerError(w, "Cannot read payload", err)
 			return
 		}
-		result, err := schema.Validate(gojsonschema.NewBytesLoader(payload))
+
+		eventID, err := d.Broadcast(req.Context(), BroadcastRequest{
+			Payload:       payload,
+			EventID:       req.Header.Get(events.IDHeader),
+			Requester:     getRequester(req),
+			Authorization: req.Header.Get("Authorization"),
+			FederatedFrom: req.Header.Get(federation.FederatedHeader),
+		})
 		if err != nil {
-			errors.WriteHTTPBadRequest(w, "Cannot validate payload", err)
-			return
-		}
-		if !result.Valid() {
-			errors.WriteHTTPBadRequest(w, "Invalid request payload", fmt.Errorf("%s", result.Errors()))
-			return
-		}
-		var event Event
-		if err := json.Unmarshal(payload, &event); err != nil {
-			errors.WriteHTTPBadRequest(w, "Invalid request payload", err)
+			var apiErr errors.APIError
+			errors2.As(err, &apiErr)
+			switch apiErr.Status {
+			case http.StatusServiceUnavailable:
+				w.Header().Set("Retry-After", "5")
+				errors.WriteHTTPErrorWithRetry(w, apiErr.Msg, apiErr.Status, apiErr.Err, true)
+			case http.StatusInternalServerError:
+				errors.WriteHTTPErrorWithRetry(w, apiErr.Msg, apiErr.Status, apiErr.Err, false)
+			default:
+				errors.WriteHTTPAPIError(w, err)
+			}
 			return
 		}
+		writeEventResponse(w, eventID)
+	}
+}
+
+// BroadcastRequest is an event received by the HTTP or gRPC API to broadcast to the cluster
+type BroadcastRequest struct {
+	// Payload is the event as JSON, validated against the Event schema
+	Payload []byte
+	// EventID identifies retried requests, so that the event isn't broadcast twice. Generated if empty.
+	EventID string
+	// Requester is recorded as the caller of the event in the audit log
+	Requester string
+	// Authorization is passed on when the event is forwarded to other regions
+	Authorization string
+	// FederatedFrom is the region the event was forwarded from, empty if it wasn't
+	FederatedFrom string
+}
 
-		err = d.cluster.BroadcastEvent(serf.UserEvent{
+// Broadcast validates the event and propagates it to all the nodes of the cluster and the other regions,
+// returning the event ID. The errors are errors.APIError, service unavailable ones being retryable.
+func (d *EventsHandlersCollection) Broadcast(ctx context.Context, r BroadcastRequest) (string, error) {
+	result, err := inputSchemasCompiled["Event"].Validate(gojsonschema.NewBytesLoader(r.Payload))
+	if err != nil {
+		return "", errors.NewAPIError("Cannot validate payload", http.StatusBadRequest, err)
+	}
+	if !result.Valid() {
+		return "", errors.NewAPIError("Invalid request payload", http.StatusBadRequest, fmt.Errorf("%s", result.Errors()))
+	}
+	var event Event
+	if err := json.Unmarshal(r.Payload, &event); err != nil {
+		return "", errors.NewAPIError("Invalid request payload", http.StatusBadRequest, err)
+	}
+	if !events.IsSupported(event.Resource, event.Version) {
+		return "", errors.NewAPIError("Unsupported event version", http.StatusBadRequest, fmt.Errorf("version %d of resource '%s' not supported, supported versions: %v", event.Version, event.Resource, events.SupportedVersions()[event.Resource]))
+	}
+
+	eventID := r.EventID
+	if eventID == "" {
+		eventID = config.RandomTrailer(16)
+	}
+	if _, broadcast := d.broadcastIDs.Get(eventID); broadcast {
+		glog.Infof("event already broadcast, skipping eventID=%s resource=%s playbackID=%s", eventID, event.Resource, event.PlaybackID)
+		return eventID, nil
+	}
+
+	encoded, err := events.Encode(r.Payload)
+	if err != nil {
+		return "", errors.NewAPIError("Cannot encode event", http.StatusInternalServerError, err)
+	}
+	err = backoff.Retry(func() error {
+		err := d.cluster.BroadcastEvent(serf.UserEvent{
 			Name:     fmt.Sprintf("%s-%s", event.Resource, event.PlaybackID),
-			Payload:  payload,
+			Payload:  encoded,
 			Coalesce: true,
 		})
+		if err != nil && !errors.IsUnretriable(err) {
+			glog.Warningf("error broadcasting event, retrying eventID=%s err=%s", eventID, err)
+		}
+		return err
+	}, BroadcastRetryBackoff())
+	d.recordAudit(ctx, r.Requester, event, err)
+
+	if err != nil {
+		if errors.IsUnretriable(err) {
+			return "", errors.NewAPIError("Cannot process event", http.StatusInternalServerError, err)
+		}
+		return "", errors.NewAPIError("Cannot process event, temporarily unavailable", http.StatusServiceUnavailable, err)
+	}
+	d.broadcastIDs.SetDefault(eventID, true)
+
+	if d.federation.ShouldForwardFrom(r.FederatedFrom, event.Resource) {
+		d.federation.Forward(ctx, eventID, event.Resource, r.Authorization, r.Payload)
+	}
+	return eventID, nil
+}
+
+func writeEventResponse(w http.ResponseWriter, eventID string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(events.IDHeader, eventID)
+	json.NewEncoder(w).Encode(map[string]string{"event_id": eventID}) // nolint:errcheck
+}
+
+// EventVersions lists the schema versions supported for each event resource, so that callers can
+// negotiate which version of an event to send
+func (d *EventsHandlersCollection) EventVersions() httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		b, err := json.Marshal(events.SupportedVersions())
+		if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Cannot marshal event versions", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b) // nolint:errcheck
+	}
+}
 
+// RecentEvents serves the state-changing events broadcast within the replay window, so that nodes joining
+// the cluster afterwards can catch up on them.
+func (d *EventsHandlersCollection) RecentEvents(window time.Duration) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		records, err := events.RecentEvents(req.Context(), d.auditLog, window)
+		if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Cannot read recent events", err)
+			return
+		}
+		recent := []events.ReplayedEvent{}
+		for _, r := range records {
+			recent = append(recent, events.ReplayedEvent{Resource: r.Resource, PlaybackID: r.PlaybackID, BroadcastAt: r.Timestamp.UnixMilli()})
+		}
+		b, err := json.Marshal(recent)
 		if err != nil {
-			errors.WriteHTTPInternalServerError(w, "Cannot process event", err)
+			errors.WriteHTTPInternalServerError(w, "Cannot marshal recent events", err)
 			return
 		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b) // nolint:errcheck
 	}
 }
 
+// recordAudit persists who sent an accepted event and whether it was broadcast to the cluster
+func (d *EventsHandlersCollection) recordAudit(ctx context.Context, requester string, event Event, broadcastErr error) {
+	if d.auditLog == nil {
+		return
+	}
+	record := audit.Record{
+		Timestamp:  time.Now().UTC(),
+		RequestID:  log.RequestID(ctx),
+		Caller:     requester,
+		Action:     audit.ActionClusterEvent,
+		Outcome:    audit.OutcomeSucceeded,
+		Resource:   event.Resource,
+		PlaybackID: event.PlaybackID,
+	}
+	if broadcastErr != nil {
+		record.Outcome = audit.OutcomeFailed
+		record.Error = broadcastErr.Error()
+	}
+	if err := d.auditLog.Record(ctx, record); err != nil {
+		glog.Errorf("error recording event audit log resource=%s playbackID=%s err=%s", event.Resource, event.PlaybackID, err)
+	}
+}
+
+// getRequester returns the authenticated caller, or the client's address when events don't require authorization.
+// X-Forwarded-For isn't used since callers could forge it.
+func getRequester(r *http.Request) string {
+	if caller := Caller(r.Context()); caller != "" {
+		return caller
+	}
+	return getIP(r)
+}
+
+// restartedSince reports whether a replayed event predates the current session of the stream, which was then
+// started again after the event and mustn't be stopped by it
+func (c *EventsHandlersCollection) restartedSince(playbackID string, broadcastAt int64) bool {
+	if broadcastAt == 0 {
+		// not replayed
+		return false
+	}
+	startedAt, ok := c.mapic.StreamStartedAt(playbackID)
+	if !ok || !time.UnixMilli(broadcastAt).Before(startedAt) {
+		return false
+	}
+	glog.Infof("skipping replayed event older than the current session playbackID=%s broadcastAt=%d startedAt=%s", playbackID, broadcastAt, startedAt)
+	return true
+}
+
 // ReceiveUserEvent is a handler to receive Serf events from Catalyst.
 // The idea is that:
 // 1. Studio API sends an event to Catalyst (received by Events() handler)
@@ -99,15 +302,58 @@ func (c *EventsHandlersCollection) ReceiveUserEvent() httprouter.Handle {
 		switch event := e.(type) {
 		case *events.StreamEvent:
 			glog.V(5).Infof("received serf StreamEvent: %v", event.PlaybackID)
-			c.mapic.RefreshStreamIfNeeded(event.PlaybackID)
+			if c.mapic != nil {
+				c.mapic.RefreshStreamIfNeeded(event.PlaybackID)
+			}
+			if c.LiveProfiles != nil {
+				if err := c.LiveProfiles.Refresh(r.Context(), event.PlaybackID); err != nil {
+					glog.Errorf("error refreshing live transcode profiles playbackID=%s err=%s", event.PlaybackID, err)
+				}
+			}
 		case *events.NukeEvent:
 			glog.V(5).Infof("received serf NukeEvent: %v", event.PlaybackID)
+			if c.restartedSince(event.PlaybackID, event.BroadcastAt) {
+				return
+			}
 			c.mapic.NukeStream(event.PlaybackID)
 			return
 		case *events.StopSessionsEvent:
 			glog.V(5).Infof("received serf StopSessionsEvent: %v", event.PlaybackID)
+			if c.restartedSince(event.PlaybackID, event.BroadcastAt) {
+				return
+			}
 			c.mapic.StopSessions(event.PlaybackID)
 			return
+		case *events.RecordingEvent:
+			glog.V(5).Infof("received serf RecordingEvent: %v enabled=%v", event.PlaybackID, event.Recording.Enabled)
+			if c.Recorder == nil {
+				glog.Warningf("ignoring recording event, -recording-url isn't set on this node playbackID=%s", event.PlaybackID)
+				return
+			}
+			if err := c.Recorder.Apply(event.PlaybackID, event.Recording); err != nil {
+				glog.Errorf("error applying recording event playbackID=%s err=%s", event.PlaybackID, err)
+			}
+			return
+		case *events.DVREvent:
+			glog.V(5).Infof("received serf DVREvent: %v window=%ds", event.PlaybackID, event.DVR.WindowSecs)
+			if c.DVR == nil {
+				glog.Warningf("ignoring DVR event, Mist isn't enabled on this node playbackID=%s", event.PlaybackID)
+				return
+			}
+			if err := c.DVR.Apply(event.PlaybackID, event.DVR); err != nil {
+				glog.Errorf("error applying DVR event playbackID=%s err=%s", event.PlaybackID, err)
+			}
+			return
+		case *events.MetadataEvent:
+			glog.V(5).Infof("received serf MetadataEvent: %v metadata=%+v", event.PlaybackID, event.Metadata)
+			if c.Metadata == nil {
+				glog.Warningf("ignoring metadata event, Mist isn't enabled on this node playbackID=%s", event.PlaybackID)
+				return
+			}
+			if err := c.Metadata.Apply(event.PlaybackID, event.Metadata); err != nil {
+				glog.Errorf("error applying metadata event playbackID=%s err=%s", event.PlaybackID, err)
+			}
+			return
 		default:
 			glog.Errorf("unsupported serf event: %v", e)
 		}
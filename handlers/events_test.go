@@ -1,16 +1,23 @@
 package handlers
 
 import (
+	"encoding/json"
+	"fmt"
 	"github.com/golang/mock/gomock"
 	"github.com/hashicorp/serf/serf"
 	"github.com/julienschmidt/httprouter"
+	catErrs "github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/events"
+	mockmistclient "github.com/livepeer/catalyst-api/mocks/clients"
 	mockcluster "github.com/livepeer/catalyst-api/mocks/cluster"
 	mock_mistapiconnector "github.com/livepeer/catalyst-api/mocks/mistapiconnector"
+	"github.com/livepeer/catalyst-api/recording"
 	"github.com/stretchr/testify/require"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestEventHandler(t *testing.T) {
@@ -55,6 +62,22 @@ func TestEventHandler(t *testing.T) {
 			}`,
 			wantHttpCode: 400,
 		},
+		{
+			requestBody: `{
+				"resource": "stream",
+				"playback_id": "123456789",
+				"version": 1
+			}`,
+			wantHttpCode: 200,
+		},
+		{
+			requestBody: `{
+				"resource": "stream",
+				"playback_id": "123456789",
+				"version": 2
+			}`,
+			wantHttpCode: 400,
+		},
 	}
 
 	ctrl := gomock.NewController(t)
@@ -63,7 +86,7 @@ func TestEventHandler(t *testing.T) {
 		return nil
 	}).AnyTimes()
 
-	catalystApiHandlers := NewEventsHandlersCollection(mc, nil, nil, "")
+	catalystApiHandlers := NewEventsHandlersCollection(mc, nil, nil, nil, nil, "")
 	router := httprouter.New()
 	router.POST("/events", catalystApiHandlers.Events())
 
@@ -109,12 +132,23 @@ func TestReceiveUserEventHandler(t *testing.T) {
 			}`,
 			functionCalled: "StopSessions",
 		},
+		{
+			name: "Recording",
+			requestBody: `{
+				"resource": "recording",
+				"playback_id": "123456789",
+				"recording": {"enabled": true, "output_url": "s3://bucket/out"}
+			}`,
+			functionCalled: "PushAutoAdd",
+		},
 	}
 
 	ctrl := gomock.NewController(t)
 	mac := mock_mistapiconnector.NewMockIMac(ctrl)
+	mist := mockmistclient.NewMockMistAPIClient(ctrl)
 
-	catalystApiHandlers := NewEventsHandlersCollection(nil, mac, nil, "")
+	catalystApiHandlers := NewEventsHandlersCollection(nil, mac, nil, nil, nil, "")
+	catalystApiHandlers.Recorder = recording.NewRecorder(mist, nil, "video", "s3://bucket/recordings")
 	router := httprouter.New()
 	router.POST("/receiveUserEvent", catalystApiHandlers.ReceiveUserEvent())
 
@@ -127,6 +161,8 @@ func TestReceiveUserEventHandler(t *testing.T) {
 				mac.EXPECT().NukeStream(playbackId).Times(1)
 			case "StopSessions":
 				mac.EXPECT().StopSessions(playbackId).Times(1)
+			case "PushAutoAdd":
+				mist.EXPECT().PushAutoAdd("video+"+playbackId, gomock.Any()).Times(1)
 			}
 
 			req, _ := http.NewRequest("POST", "/receiveUserEvent", strings.NewReader(tt.requestBody))
@@ -137,3 +173,121 @@ func TestReceiveUserEventHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestReceiveUserEventSkipsReplayedEventsOlderThanSession(t *testing.T) {
+	require := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	mac := mock_mistapiconnector.NewMockIMac(ctrl)
+	router := httprouter.New()
+	router.POST("/receiveUserEvent", NewEventsHandlersCollection(nil, mac, nil, nil, nil, "").ReceiveUserEvent())
+
+	startedAt := time.Now()
+	mac.EXPECT().StreamStartedAt("123456789").Return(startedAt, true).Times(2)
+
+	// Nuked before the stream was restarted
+	body := fmt.Sprintf(`{"resource": "nuke", "playback_id": "123456789", "broadcast_at": %d}`, startedAt.Add(-time.Minute).UnixMilli())
+	req, _ := http.NewRequest("POST", "/receiveUserEvent", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	require.Equal(200, rr.Result().StatusCode)
+
+	// Nuked during the current session
+	mac.EXPECT().NukeStream("123456789").Times(1)
+	body = fmt.Sprintf(`{"resource": "nuke", "playback_id": "123456789", "broadcast_at": %d}`, startedAt.Add(time.Second).UnixMilli())
+	req, _ = http.NewRequest("POST", "/receiveUserEvent", strings.NewReader(body))
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	require.Equal(200, rr.Result().StatusCode)
+}
+
+func TestEventHandlerRetriesTransientBroadcastFailures(t *testing.T) {
+	require := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	mc := mockcluster.NewMockCluster(ctrl)
+	calls := 0
+	mc.EXPECT().BroadcastEvent(gomock.Any()).DoAndReturn(func(event serf.UserEvent) error {
+		calls++
+		if calls < 3 {
+			return fmt.Errorf("transient failure")
+		}
+		return nil
+	}).Times(3)
+
+	router := httprouter.New()
+	router.POST("/events", NewEventsHandlersCollection(mc, nil, nil, nil, nil, "").Events())
+
+	req, _ := http.NewRequest("POST", "/events", strings.NewReader(`{"resource": "nuke", "playback_id": "123456789"}`))
+	req.Header.Set(events.IDHeader, "event-1")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	require.Equal(200, rr.Result().StatusCode)
+	require.JSONEq(`{"event_id": "event-1"}`, rr.Body.String())
+
+	// Retrying the same event doesn't broadcast it again
+	req, _ = http.NewRequest("POST", "/events", strings.NewReader(`{"resource": "nuke", "playback_id": "123456789"}`))
+	req.Header.Set(events.IDHeader, "event-1")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	require.Equal(200, rr.Result().StatusCode)
+	require.Equal(3, calls)
+}
+
+func TestEventHandlerSurfacesWhetherRetryIsSafe(t *testing.T) {
+	require := require.New(t)
+
+	tests := []struct {
+		name          string
+		broadcastErr  error
+		wantCalls     int
+		wantHttpCode  int
+		wantRetryable bool
+	}{
+		{
+			name:          "transient",
+			broadcastErr:  fmt.Errorf("transient failure"),
+			wantCalls:     4,
+			wantHttpCode:  503,
+			wantRetryable: true,
+		},
+		{
+			name:          "permanent",
+			broadcastErr:  catErrs.Unretriable(fmt.Errorf("payload too large")),
+			wantCalls:     1,
+			wantHttpCode:  500,
+			wantRetryable: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mc := mockcluster.NewMockCluster(ctrl)
+			mc.EXPECT().BroadcastEvent(gomock.Any()).Return(tt.broadcastErr).Times(tt.wantCalls)
+
+			router := httprouter.New()
+			router.POST("/events", NewEventsHandlersCollection(mc, nil, nil, nil, nil, "").Events())
+
+			req, _ := http.NewRequest("POST", "/events", strings.NewReader(`{"resource": "nuke", "playback_id": "123456789"}`))
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			require.Equal(tt.wantHttpCode, rr.Result().StatusCode)
+
+			var body map[string]any
+			require.NoError(json.Unmarshal(rr.Body.Bytes(), &body))
+			require.Equal(tt.wantRetryable, body["retryable"])
+		})
+	}
+}
+
+func TestGetRequester(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/events", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "1.2.3.4")
+	// the forwarded address can be forged, so it's never recorded
+	require.Equal(t, "10.0.0.1", getRequester(req))
+
+	req = req.WithContext(WithCaller(req.Context(), "jwt:studio"))
+	require.Equal(t, "jwt:studio", getRequester(req))
+}
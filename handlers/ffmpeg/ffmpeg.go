@@ -22,6 +22,9 @@ type HandlersCollection struct {
 	VODEngine *pipeline.Coordinator
 }
 
+// generateThumbsFromManifest is replaced in tests, which don't have ffmpeg
+var generateThumbsFromManifest = thumbnails.GenerateThumbsFromManifest
+
 // FFMPEG is called with something like the following:
 //
 //	ffmpeg -re -i SomeFile.mp4 -f hls -method PUT http://localhost:1234/<request id>/out.m3u8
@@ -42,6 +45,9 @@ func (h *HandlersCollection) NewFile() httprouter.Handle {
 		var (
 			content []byte
 			err     error
+			// with an interval, only some segments get a thumbnail, picked from the durations in the final manifest
+			thumbsFromManifest = job.ThumbnailOptions.WithDefaults().Interval > 0
+			manifestClosed     bool
 		)
 		reg := regexp.MustCompile(`[^/]+.m3u8$`)
 		// job.SegmentingTargetURL comes in the format the Mist wants, looking like:
@@ -73,6 +79,7 @@ func (h *HandlersCollection) NewFile() httprouter.Handle {
 
 				mediaPl.MediaType = m3u8.VOD
 				content = mediaPl.Encode().Bytes()
+				manifestClosed = true
 			} else {
 				// should never happen but useful to at least see a log line if it ever did
 				log.Log(job.RequestID, "media playlist not found")
@@ -86,10 +93,13 @@ func (h *HandlersCollection) NewFile() httprouter.Handle {
 			}
 
 			go func() {
-				if job.ThumbnailsTargetURL == nil {
+				if job.ThumbnailsTargetURL == nil || thumbsFromManifest {
 					return
 				}
-				if err := thumbnails.GenerateThumb(filename, content, job.ThumbnailsTargetURL, 0); err != nil {
+				job.ThumbnailProgress.Add(1)
+				err := thumbnails.GenerateThumb(filename, content, job.ThumbnailsTargetURL, 0, job.ThumbnailOptions)
+				job.ThumbnailProgress.Done(filename, err)
+				if err != nil {
 					log.LogError(job.RequestID, "generate thumb failed", err, "in", path.Join(targetURLBase, filename), "out", job.ThumbnailsTargetURL)
 				}
 			}()
@@ -105,5 +115,15 @@ func (h *HandlersCollection) NewFile() httprouter.Handle {
 			errors.WriteHTTPInternalServerError(w, "Error uploading segment", err)
 			return
 		}
+
+		if manifestClosed && thumbsFromManifest && job.ThumbnailsTargetURL != nil {
+			manifestURL := targetURLBase + filename
+			go func() {
+				err := generateThumbsFromManifest(job.RequestID, manifestURL, job.ThumbnailsTargetURL, job.ThumbnailOptions, job.ThumbnailProgress)
+				if err != nil {
+					log.LogError(job.RequestID, "generate thumbs failed", err, "in", manifestURL, "out", job.ThumbnailsTargetURL)
+				}
+			}()
+		}
 	}
 }
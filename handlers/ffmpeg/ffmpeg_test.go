@@ -9,10 +9,12 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/grafov/m3u8"
 	"github.com/julienschmidt/httprouter"
 	"github.com/livepeer/catalyst-api/pipeline"
+	"github.com/livepeer/catalyst-api/thumbnails"
 	"github.com/stretchr/testify/require"
 )
 
@@ -159,3 +161,50 @@ uri
 		})
 	}
 }
+
+func TestItGeneratesThumbsAtTheIntervalFromTheFinalManifest(t *testing.T) {
+	tempDir := t.TempDir()
+	thumbsURL, err := url.Parse("file://" + filepath.Join(tempDir, "thumbs"))
+	require.NoError(t, err)
+
+	manifests := make(chan string, 2)
+	defer func(f func(string, string, *url.URL, thumbnails.Options, *thumbnails.Progress) error) {
+		generateThumbsFromManifest = f
+	}(generateThumbsFromManifest)
+	generateThumbsFromManifest = func(requestID, input string, output *url.URL, opts thumbnails.Options, progress *thumbnails.Progress) error {
+		require.Equal(t, 30.0, opts.Interval)
+		manifests <- input
+		return nil
+	}
+
+	h := HandlersCollection{
+		VODEngine: pipeline.NewStubCoordinator(),
+	}
+	job := &pipeline.JobInfo{
+		StreamName:          "exampleStreamName",
+		SegmentingTargetURL: "file://" + filepath.Join(tempDir, "index.m3u8"),
+	}
+	job.ThumbnailsTargetURL = thumbsURL
+	job.ThumbnailOptions = thumbnails.Options{Interval: 30}
+	h.VODEngine.Jobs.Store("exampleStreamName", job)
+
+	put := func(filename, body string) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPut, "/api/ffmpeg/exampleStreamName/"+filename, strings.NewReader(body))
+		h.NewFile()(w, r, []httprouter.Param{{Key: "id", Value: "exampleStreamName"}, {Key: "filename", Value: filename}})
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	// Segments don't get a thumbnail each, since they might not be at the interval
+	put("index0.ts", "segment")
+	put("index.m3u8", (&m3u8.MediaPlaylist{TargetDuration: 10}).Encode().String())
+	require.Empty(t, manifests)
+
+	put("index.m3u8", (&m3u8.MediaPlaylist{TargetDuration: 10, Closed: true}).Encode().String())
+	select {
+	case manifest := <-manifests:
+		require.Equal(t, "file://"+filepath.Join(tempDir, "index.m3u8"), manifest)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "thumbnails weren't generated from the final manifest")
+	}
+}
@@ -0,0 +1,107 @@
+package geolocation
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/livepeer/catalyst-api/config"
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/livepeer/catalyst-api/metrics"
+)
+
+const cdnRedirectsFetchTimeout = 10 * time.Second
+
+// CDNRedirectList is the list of playback IDs redirected to the CDN, fetched from -cdn-redirect-playback-ids-url
+type CDNRedirectList struct {
+	redirects atomic.Pointer[map[string]float64]
+	// lastRefresh is when the list was last fetched or found unchanged, in Unix nanoseconds
+	lastRefresh atomic.Int64
+
+	mu   sync.Mutex
+	etag string
+}
+
+// RemoteCDNRedirects takes precedence over -cdn-redirect-playback-ids and the config file once it's fetched
+var RemoteCDNRedirects = &CDNRedirectList{}
+
+// Redirects returns the playback IDs and the percentage of their traffic to redirect, false if the list was never
+// fetched
+func (l *CDNRedirectList) Redirects() (map[string]float64, bool) {
+	redirects := l.redirects.Load()
+	if redirects == nil {
+		return nil, false
+	}
+	return *redirects, true
+}
+
+// Poll fetches the list every interval until the context is done. The URL returns a JSON list of
+// {playback_id, percent}, the format of the cdn_redirects config file section. The list last fetched is kept while
+// the URL is unavailable.
+func (l *CDNRedirectList) Poll(ctx context.Context, url string, interval time.Duration) {
+	client := &http.Client{Timeout: cdnRedirectsFetchTimeout}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := l.fetch(ctx, client, url); err != nil {
+			log.LogNoRequestID("error fetching CDN redirects, keeping the previous ones", "err", err)
+		}
+		l.updateStaleness()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (l *CDNRedirectList) fetch(ctx context.Context, client *http.Client, url string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if l.etag != "" {
+		req.Header.Set("If-None-Match", l.etag)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		l.lastRefresh.Store(time.Now().UnixNano())
+		return nil
+	case http.StatusOK:
+	default:
+		return fmt.Errorf("CDN redirect list returned HTTP %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	redirects, err := config.ParseCDNRedirects(body)
+	if err != nil {
+		return err
+	}
+	if l.redirects.Swap(&redirects) == nil {
+		log.LogNoRequestID("fetched CDN redirects", "count", len(redirects))
+	}
+	l.etag = resp.Header.Get("ETag")
+	l.lastRefresh.Store(time.Now().UnixNano())
+	return nil
+}
+
+func (l *CDNRedirectList) updateStaleness() {
+	if lastRefresh := l.lastRefresh.Load(); lastRefresh != 0 {
+		metrics.Metrics.CDNRedirectListAge.Set(time.Since(time.Unix(0, lastRefresh)).Seconds())
+	}
+}
@@ -0,0 +1,87 @@
+package geolocation
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCDNRedirectListFetch(t *testing.T) {
+	body := `[{"playback_id": "abc", "percent": 20}, {"playback_id": "def"}]`
+	etag := `"v1"`
+	status := http.StatusOK
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	var l CDNRedirectList
+	_, ok := l.Redirects()
+	require.False(t, ok)
+
+	ctx := context.Background()
+	require.NoError(t, l.fetch(ctx, server.Client(), server.URL))
+	redirects, ok := l.Redirects()
+	require.True(t, ok)
+	require.Equal(t, map[string]float64{"abc": 20, "def": 100}, redirects)
+	firstRefresh := l.lastRefresh.Load()
+
+	// unchanged, the refresh time moves on
+	time.Sleep(time.Millisecond)
+	require.NoError(t, l.fetch(ctx, server.Client(), server.URL))
+	require.Greater(t, l.lastRefresh.Load(), firstRefresh)
+	redirects, _ = l.Redirects()
+	require.Len(t, redirects, 2)
+
+	// changed
+	body, etag = `[{"playback_id": "ghi", "percent": 1}]`, `"v2"`
+	require.NoError(t, l.fetch(ctx, server.Client(), server.URL))
+	redirects, _ = l.Redirects()
+	require.Equal(t, map[string]float64{"ghi": 1}, redirects)
+
+	// the previous list is kept on errors
+	lastRefresh := l.lastRefresh.Load()
+	etag = `"v3"`
+	body = `[{"playback_id": "ghi", "percent": 101}]`
+	require.ErrorContains(t, l.fetch(ctx, server.Client(), server.URL), "invalid CDN redirect")
+	status = http.StatusInternalServerError
+	require.ErrorContains(t, l.fetch(ctx, server.Client(), server.URL), "HTTP 500")
+	redirects, _ = l.Redirects()
+	require.Equal(t, map[string]float64{"ghi": 1}, redirects)
+	require.Equal(t, lastRefresh, l.lastRefresh.Load())
+	require.Equal(t, 5, requests)
+}
+
+func TestCdnRedirectRemoteList(t *testing.T) {
+	n := mockHandlers(t)
+	n.Config.NodeHost = closestNodeAddr
+	n.Config.CdnRedirectPrefix, _ = url.Parse("https://external-cdn.com/mist")
+	n.Config.CdnRedirectPlaybackPct = map[string]float64{CdnRedirectedPlaybackID: 100}
+
+	remote := map[string]float64{CdnRedirectedPlaybackID: 0}
+	RemoteCDNRedirects.redirects.Store(&remote)
+	defer RemoteCDNRedirects.redirects.Store(nil)
+
+	// the remote list takes precedence over the flag
+	requireReq(t, fmt.Sprintf("/hls/%s/index.m3u8", CdnRedirectedPlaybackID)).
+		result(n).
+		hasStatus(http.StatusTemporaryRedirect).
+		hasHeader("Location", fmt.Sprintf("http://%s/hls/%s/index.m3u8", closestNodeAddr, CdnRedirectedPlaybackID))
+}
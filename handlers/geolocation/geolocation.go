@@ -22,9 +22,12 @@ import (
 	"github.com/livepeer/catalyst-api/balancer"
 	"github.com/livepeer/catalyst-api/cluster"
 	"github.com/livepeer/catalyst-api/config"
+	"github.com/livepeer/catalyst-api/crypto/signedurl"
+	catErrs "github.com/livepeer/catalyst-api/errors"
 	"github.com/livepeer/catalyst-api/handlers/misttriggers"
 	mistapiconnector "github.com/livepeer/catalyst-api/mapic"
 	"github.com/livepeer/catalyst-api/metrics"
+	"github.com/livepeer/catalyst-api/viewerlimit"
 	"github.com/livepeer/go-api-client"
 )
 
@@ -33,6 +36,8 @@ const (
 	streamSourceRetryInterval         = 1 * time.Second
 	streamSourceMaxWrongRegionRetries = 3
 	lockPullLeaseTimeout              = 1 * time.Minute
+	// viewerLimitRetryAfterSecs is when viewers turned away by a viewer limit can try again
+	viewerLimitRetryAfterSecs = 30
 )
 
 var errPullWrongRegion = errors.New("failed to pull stream, wrong region")
@@ -73,10 +78,17 @@ type GeolocationHandlersCollection struct {
 	LapiCached          *mistapiconnector.ApiClientCached
 	streamPullRateLimit *streamPullRateLimit
 	serfMembersEndpoint string
+	// verifies signed playback URLs, nil if playback URLs aren't signed
+	playbackSigner *signedurl.Signer
+	// ViewerLimits turns new viewers away from the streams at their max concurrent viewers, nil if none are limited
+	ViewerLimits *viewerlimit.Limiter
 }
 
 func NewGeolocationHandlersCollection(balancer balancer.Balancer, config config.Cli, lapi *api.Client, serfMembersEndpoint string) *GeolocationHandlersCollection {
+	// the keys are validated on startup
+	playbackSigner, _ := signedurl.FromKeys(config.PlaybackSigningSecret, config.PlaybackSigningKey, config.PlaybackVerificationKey)
 	return &GeolocationHandlersCollection{
+		playbackSigner:      playbackSigner,
 		Balancer:            balancer,
 		Config:              config,
 		Lapi:                lapi,
@@ -94,6 +106,9 @@ func (c *GeolocationHandlersCollection) RedirectHandler() httprouter.Handle {
 
 	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
 		host := r.Host
+		if !c.checkPlaybackSignature(w, r) {
+			return
+		}
 		pathType, prefix, playbackID, pathTmpl := parsePlaybackID(r.URL.Path)
 		redirectPrefixes := c.Config.RedirectPrefixes
 		isStudioReq := false
@@ -116,8 +131,26 @@ func (c *GeolocationHandlersCollection) RedirectHandler() httprouter.Handle {
 			isStudioReq = true
 		}
 
-		if c.Config.CdnRedirectPrefix != nil && (pathType == "hls" || pathType == "webrtc") {
-			cdnPercentage, toBeRedirected := c.Config.CdnRedirectPlaybackPct[playbackID]
+		// LL-HLS players make blocking playlist reloads, answered by Mist once the requested part is available. These
+		// go straight to the node and their redirects mustn't be cached, as the next reload asks for a later part.
+		isLLHLS := pathType == "hls" && isLLHLSRequest(query)
+		if isLLHLS {
+			w.Header().Set("Cache-Control", "no-cache")
+		}
+
+		if !c.checkViewerLimit(w, r, pathType, playbackID, isStudioReq || isLLHLS) {
+			return
+		}
+
+		if c.Config.CdnRedirectPrefix != nil && (pathType == "hls" || pathType == "webrtc") && !isLLHLS {
+			cdnRedirects := c.Config.CdnRedirectPlaybackPct
+			if reloaded := config.Reloaded(); reloaded != nil {
+				cdnRedirects = reloaded.CdnRedirectPlaybackPct
+			}
+			if remote, ok := RemoteCDNRedirects.Redirects(); ok {
+				cdnRedirects = remote
+			}
+			cdnPercentage, toBeRedirected := cdnRedirects[playbackID]
 			if toBeRedirected && cdnPercentage > rand.Float64()*100 {
 				if pathType == "webrtc" {
 					// For webRTC streams on the `CdnRedirectPlaybackIDs` list we return `406`
@@ -218,6 +251,42 @@ func (c *GeolocationHandlersCollection) RedirectHandler() httprouter.Handle {
 	}
 }
 
+// checkPlaybackSignature verifies the signature of signed playback URLs, writing an error response and returning
+// false if the request should be rejected
+func (c *GeolocationHandlersCollection) checkPlaybackSignature(w http.ResponseWriter, r *http.Request) bool {
+	if c.playbackSigner == nil {
+		return true
+	}
+	err := c.playbackSigner.Verify(r.URL, time.Now())
+	if err == nil || (errors.Is(err, signedurl.ErrMissingSignature) && !c.Config.RequireSignedPlayback) {
+		return true
+	}
+	glog.V(6).Infof("rejected playback url=%s err=%s", r.URL.Path, err)
+	if errors.Is(err, signedurl.ErrMissingSignature) {
+		w.WriteHeader(http.StatusUnauthorized)
+	} else {
+		w.WriteHeader(http.StatusForbidden)
+	}
+	return false
+}
+
+// checkViewerLimit writes a 429 and returns false once a stream has reached its max concurrent viewers. The studio
+// requests and the LL-HLS reloads of viewers already watching are exempt.
+func (c *GeolocationHandlersCollection) checkViewerLimit(w http.ResponseWriter, r *http.Request, pathType, playbackID string, exempt bool) bool {
+	if c.ViewerLimits == nil || pathType == "" || exempt {
+		return true
+	}
+	allowed, viewers, limit := c.ViewerLimits.Allow(r.Context(), playbackID)
+	if allowed {
+		return true
+	}
+	glog.Infof("Viewer limit reached for playbackID=%s viewers=%d limit=%d", playbackID, viewers, limit)
+	metrics.Metrics.ViewerLimitRejections.WithLabelValues(playbackID, "redirect").Inc()
+	w.Header().Set("Retry-After", strconv.Itoa(viewerLimitRetryAfterSecs))
+	catErrs.WriteHTTPErrorWithRetry(w, fmt.Sprintf("Viewer limit of %d reached for this stream", limit), http.StatusTooManyRequests, nil, true)
+	return false
+}
+
 // Given a dtsc:// or https:// url, resolve the proper address of the node via serf tags
 func (c *GeolocationHandlersCollection) resolveNodeURL(streamURL string) (string, error) {
 	u, err := url.Parse(streamURL)
@@ -534,6 +603,11 @@ func parsePlaybackID(path string) (string, string, string, string) {
 	return "", "", "", ""
 }
 
+// isLLHLSRequest reports whether the query has the LL-HLS delivery directives of a playlist request
+func isLLHLSRequest(query url.Values) bool {
+	return query.Has("_HLS_msn") || query.Has("_HLS_part") || query.Has("_HLS_skip")
+}
+
 func protocol(r *http.Request) string {
 	if r.Header.Get("X-Forwarded-Proto") == "https" {
 		return "https"
@@ -16,8 +16,10 @@ import (
 	"github.com/julienschmidt/httprouter"
 	"github.com/livepeer/catalyst-api/cluster"
 	"github.com/livepeer/catalyst-api/config"
+	"github.com/livepeer/catalyst-api/crypto/signedurl"
 	"github.com/livepeer/catalyst-api/metrics"
 	mockbalancer "github.com/livepeer/catalyst-api/mocks/balancer"
+	"github.com/livepeer/catalyst-api/viewerlimit"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -530,6 +532,62 @@ func TestCdnRedirectHLS(t *testing.T) {
 		hasHeader("Location", fmt.Sprintf("http://%s/hls/%s/index.m3u8", closestNodeAddr, CdnRedirectedPlaybackID))
 }
 
+func TestRedirectHandlerLLHLS(t *testing.T) {
+	n := mockHandlers(t)
+	n.Config.CdnRedirectPrefix, _ = url.Parse("https://external-cdn.com/mist")
+	n.Config.CdnRedirectPlaybackPct = map[string]float64{playbackID: 100}
+
+	// blocking reloads aren't redirected to the CDN and keep their delivery directives
+	query := "?_HLS_msn=42&_HLS_part=3"
+	requireReq(t, fmt.Sprintf("/hls/%s/index.m3u8%s", playbackID, query)).
+		result(n).
+		hasStatus(http.StatusTemporaryRedirect).
+		hasHeader("Location", getHLSURLs("http", closestNodeAddr, query)...).
+		hasHeader("Cache-Control", "no-cache")
+
+	requireReq(t, fmt.Sprintf("/hls/%s/index.m3u8", playbackID)).
+		result(n).
+		hasStatus(http.StatusTemporaryRedirect).
+		hasHeader("Location", fmt.Sprintf("http://external-cdn.com/mist/hls/video+%s/index.m3u8", playbackID)).
+		hasHeader("Cache-Control", "")
+}
+
+type stubViewerCounter int
+
+func (c stubViewerCounter) Viewers(context.Context, string) (int, error) {
+	return int(c), nil
+}
+
+func TestRedirectHandlerViewerLimit(t *testing.T) {
+	n := mockHandlers(t)
+	n.ViewerLimits = viewerlimit.NewLimiter(map[string]int{playbackID: 10}, "", stubViewerCounter(10))
+
+	requireReq(t, fmt.Sprintf("/hls/%s/index.m3u8", playbackID)).
+		result(n).
+		hasStatus(http.StatusTooManyRequests).
+		hasHeader("Retry-After", "30")
+
+	// the reloads of the viewers already watching aren't turned away
+	query := "?_HLS_msn=42&_HLS_part=3"
+	requireReq(t, fmt.Sprintf("/hls/%s/index.m3u8%s", playbackID, query)).
+		result(n).
+		hasStatus(http.StatusTemporaryRedirect).
+		hasHeader("Location", getHLSURLs("http", closestNodeAddr, query)...)
+
+	n.ViewerLimits = viewerlimit.NewLimiter(map[string]int{playbackID: 10}, "", stubViewerCounter(9))
+	requireReq(t, fmt.Sprintf("/hls/%s/index.m3u8", playbackID)).
+		result(n).
+		hasStatus(http.StatusTemporaryRedirect).
+		hasHeader("Location", getHLSURLs("http", closestNodeAddr, "")...)
+}
+
+func TestIsLLHLSRequest(t *testing.T) {
+	require.True(t, isLLHLSRequest(url.Values{"_HLS_msn": {"42"}}))
+	require.True(t, isLLHLSRequest(url.Values{"_HLS_msn": {"42"}, "_HLS_part": {"3"}}))
+	require.True(t, isLLHLSRequest(url.Values{"_HLS_skip": {"YES"}}))
+	require.False(t, isLLHLSRequest(url.Values{"mTrack": {"0"}, "iMsn": {"4"}}))
+}
+
 func TestCdnRedirectHLSUnknownPlaybackId(t *testing.T) {
 	n := mockHandlers(t)
 	n.Config.NodeHost = closestNodeAddr
@@ -614,3 +672,34 @@ func TestStreamPullRateLimit(t *testing.T) {
 	time.Sleep(2 * time.Second)
 	require.False(rateLimit.shouldLimit(playbackID1))
 }
+
+func TestRedirectHandlerSignedPlayback(t *testing.T) {
+	n := mockHandlers(t)
+	n.playbackSigner = signedurl.NewHMACSigner([]byte("0123456789abcdef0123456789abcdef"))
+	path := fmt.Sprintf("/hls/%s/index.m3u8", playbackID)
+
+	signed, err := n.playbackSigner.Sign(&url.URL{Path: path}, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	requireReq(t, signed.String()).
+		result(n).
+		hasStatus(http.StatusTemporaryRedirect)
+
+	expired, err := n.playbackSigner.Sign(&url.URL{Path: path}, time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	requireReq(t, expired.String()).
+		result(n).
+		hasStatus(http.StatusForbidden)
+
+	requireReq(t, signed.String()+"&lat=1").
+		result(n).
+		hasStatus(http.StatusForbidden)
+
+	// unsigned URLs are only rejected when signatures are required
+	requireReq(t, path).
+		result(n).
+		hasStatus(http.StatusTemporaryRedirect)
+	n.Config.RequireSignedPlayback = true
+	requireReq(t, path).
+		result(n).
+		hasStatus(http.StatusUnauthorized)
+}
@@ -0,0 +1,162 @@
+package geolocation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/clients"
+	"github.com/livepeer/catalyst-api/crypto"
+	"github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/handlers/misttriggers"
+)
+
+// SRTIngest is where an encoder should publish a stream key to over SRT
+type SRTIngest struct {
+	URL        string `json:"url"`
+	Host       string `json:"host"`
+	Port       int    `json:"port"`
+	StreamID   string `json:"stream_id"`
+	Passphrase string `json:"passphrase,omitempty"`
+}
+
+// SRTIngestHandler tells encoders which node and port to publish a stream key to over SRT. Requests for another host
+// are redirected to the node picked by the balancer, which checks the stream key in the same way as an RTMP push,
+// with the PUSH_REWRITE trigger handlers, and provisions the Mist stream the push is rewritten to. If
+// -srt-passphrase-secret is set the stream is provisioned with a passphrase derived from the stream key, which the
+// encoder has to publish with.
+func (c *GeolocationHandlersCollection) SRTIngestHandler(broker misttriggers.TriggerBroker, mist clients.MistAPIClient) httprouter.Handle {
+	// the secret is validated on startup
+	passphraseSecret, _ := c.Config.SRTPassphraseSecretBytes()
+
+	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		streamKey := params.ByName("streamKey")
+
+		if r.Host != c.Config.NodeName {
+			c.redirectSRTIngest(w, r, streamKey)
+			return
+		}
+
+		ingest := SRTIngest{StreamID: streamKey}
+		pushURL := &url.URL{
+			Scheme:   "srt",
+			Host:     net.JoinHostPort(c.Config.NodeName, strconv.Itoa(c.Config.MistSRTPort)),
+			RawQuery: url.Values{"streamid": {streamKey}}.Encode(),
+		}
+		clientIP, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			clientIP = r.RemoteAddr
+		}
+		streamName, err := broker.TriggerPushRewrite(r.Context(), &misttriggers.PushRewritePayload{
+			FullURL:    pushURL.String(),
+			URL:        pushURL,
+			Hostname:   clientIP,
+			StreamName: streamKey,
+		})
+		if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Cannot check stream key", err)
+			return
+		}
+		if streamName == "" {
+			glog.V(6).Info("rejected SRT ingest with an invalid stream key")
+			errors.WriteHTTPForbidden(w, "Invalid stream key", nil)
+			return
+		}
+
+		// The stream is added even if it's named after a wildcard base stream (e.g. video+<playbackID>), the config of
+		// the exact stream name takes precedence so that it can carry the passphrase
+		source := c.Config.MistStreamSource
+		if passphraseSecret != nil {
+			ingest.Passphrase = crypto.DeriveSRTPassphrase(passphraseSecret, streamKey)
+			source = withQueryParam(source, "passphrase", ingest.Passphrase)
+		}
+		if err := mist.AddStream(streamName, source); err != nil {
+			errors.WriteHTTPInternalServerError(w, "Cannot create Mist stream", err)
+			return
+		}
+
+		ingest.Host, ingest.Port = c.srtAddress()
+		query := url.Values{"streamid": {streamKey}}
+		if ingest.Passphrase != "" {
+			query.Set("passphrase", ingest.Passphrase)
+		}
+		ingest.URL = (&url.URL{
+			Scheme:   "srt",
+			Host:     net.JoinHostPort(ingest.Host, strconv.Itoa(ingest.Port)),
+			RawQuery: query.Encode(),
+		}).String()
+		glog.Infof("provisioned SRT ingest stream=%s host=%s port=%d", streamName, ingest.Host, ingest.Port)
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(ingest); err != nil {
+			glog.Errorf("failed to write SRT ingest response stream=%s err=%s", streamName, err)
+		}
+	}
+}
+
+// srtAddress is the address encoders reach this node's SRT ingest on. Nodes behind NAT advertise their public
+// address with an srt Serf tag, e.g. srt://203.0.113.7:8889
+func (c *GeolocationHandlersCollection) srtAddress() (string, int) {
+	member, err := c.clusterMember(map[string]string{}, "alive", c.Config.NodeName)
+	if err != nil {
+		glog.Warningf("failed to look up own serf member, advertising the default SRT address err=%s", err)
+		return c.Config.NodeName, c.Config.MistSRTPort
+	}
+	if tag, ok := member.Tags["srt"]; ok {
+		u, err := url.Parse(tag)
+		if err == nil && u.Hostname() != "" {
+			port, err := strconv.Atoi(u.Port())
+			if err != nil {
+				port = c.Config.MistSRTPort
+			}
+			return u.Hostname(), port
+		}
+		glog.Errorf("node has unparsable tag!! nodeName=%s protocol=srt tag=%s", c.Config.NodeName, tag)
+	}
+	return c.Config.NodeName, c.Config.MistSRTPort
+}
+
+// redirectSRTIngest redirects to the same endpoint on the node the stream key should be published to
+func (c *GeolocationHandlersCollection) redirectSRTIngest(w http.ResponseWriter, r *http.Request, streamKey string) {
+	query := r.URL.Query()
+	lat, lon := query.Get("lat"), query.Get("lon")
+	if !isValidGPSCoord(lat, lon) {
+		lat, lon = r.Header.Get("X-Latitude"), r.Header.Get("X-Longitude")
+		if !isValidGPSCoord(lat, lon) {
+			lat, lon = "", ""
+		}
+	}
+
+	// The stream isn't live yet, so this picks the closest node with capacity
+	bestNode, _, err := c.Balancer.GetBestNode(context.Background(), c.Config.RedirectPrefixes, streamKey, lat, lon, "", false, false)
+	if err != nil {
+		glog.Errorf("failed to find a node for SRT ingest err=%s", err)
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	rURL := fmt.Sprintf("%s://%s%s?%s", protocol(r), bestNode, r.URL.Path, r.URL.RawQuery)
+	rURL, err = c.resolveNodeURL(rURL)
+	if err != nil {
+		glog.Errorf("failed to resolve node URL for SRT ingest node=%s err=%s", bestNode, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	glog.V(6).Infof("SRT ingest redirect from=%s to=%s", r.URL.Path, rURL)
+	http.Redirect(w, r, rURL, http.StatusTemporaryRedirect)
+}
+
+// withQueryParam adds a query parameter to a Mist stream source such as push://, which url.URL can't round trip
+func withQueryParam(source, key, value string) string {
+	sep := "?"
+	if strings.Contains(source, "?") {
+		sep = "&"
+	}
+	return source + sep + url.Values{key: {value}}.Encode()
+}
@@ -0,0 +1,69 @@
+package geolocation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/crypto"
+	"github.com/livepeer/catalyst-api/handlers/misttriggers"
+	mockmistclient "github.com/livepeer/catalyst-api/mocks/clients"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSRTIngestHandler(t *testing.T) {
+	geo := mockHandlers(t)
+	geo.Config.NodeName = closestNodeAddr
+	geo.Config.MistSRTPort = 8889
+	geo.Config.MistStreamSource = "push://"
+	geo.Config.SRTPassphraseSecret = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	secret, err := geo.Config.SRTPassphraseSecretBytes()
+	require.NoError(t, err)
+
+	broker := misttriggers.NewTriggerBroker()
+	broker.OnPushRewrite(func(ctx context.Context, p *misttriggers.PushRewritePayload) (string, error) {
+		require.Equal(t, "srt", p.URL.Scheme)
+		require.Equal(t, p.StreamName, p.URL.Query().Get("streamid"))
+		if p.StreamName == "key" {
+			return "video+" + playbackID, nil
+		}
+		return "", nil
+	})
+	mist := mockmistclient.NewMockMistAPIClient(gomock.NewController(t))
+	passphrase := crypto.DeriveSRTPassphrase(secret, "key")
+	mist.EXPECT().AddStream("video+"+playbackID, "push://?passphrase="+passphrase).Return(nil)
+	handler := geo.SRTIngestHandler(broker, mist)
+
+	srt := func(host, streamKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "http://"+host+"/srt/"+streamKey, nil)
+		rr := httptest.NewRecorder()
+		handler(rr, req, httprouter.Params{{Key: "streamKey", Value: streamKey}})
+		return rr
+	}
+
+	// the balancer picks the node for requests to the global hostname
+	geo.Config.NodeName = "other-node"
+	rr := srt("catalyst.example.com", playbackID)
+	require.Equal(t, http.StatusTemporaryRedirect, rr.Code)
+	require.Equal(t, "http://someurl.com/srt/"+playbackID, rr.Header().Get("Location"))
+	geo.Config.NodeName = closestNodeAddr
+
+	rr = srt(closestNodeAddr, "key")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var ingest SRTIngest
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &ingest))
+	require.Equal(t, SRTIngest{
+		URL:        "srt://someurl.com:8889?passphrase=" + passphrase + "&streamid=key",
+		Host:       closestNodeAddr,
+		Port:       8889,
+		StreamID:   "key",
+		Passphrase: passphrase,
+	}, ingest)
+	require.Len(t, passphrase, crypto.SRTPassphraseLength)
+
+	require.Equal(t, http.StatusForbidden, srt(closestNodeAddr, "unknown-key").Code)
+}
@@ -1,7 +1,38 @@
 package handlers
 
-import "github.com/livepeer/catalyst-api/pipeline"
+import (
+	"context"
+	"strings"
+
+	"github.com/livepeer/catalyst-api/config"
+	"github.com/livepeer/catalyst-api/pipeline"
+)
 
 type CatalystAPIHandlersCollection struct {
 	VODEngine *pipeline.Coordinator
 }
+
+type callerKey struct{}
+
+// APIKeyCallerPrefix prefixes the ID of the API key a request was authorized with, to make up its caller
+const APIKeyCallerPrefix = "key:"
+
+// WithCaller records who the request was authorized for, the API key or JWT subject, for per-caller limits
+func WithCaller(ctx context.Context, caller string) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// Caller returns the caller set by WithCaller, or an empty string for unauthenticated requests
+func Caller(ctx context.Context) string {
+	caller, _ := ctx.Value(callerKey{}).(string)
+	return caller
+}
+
+// Tenant returns the tenant of the API key the request was authorized with, if it belongs to one
+func Tenant(ctx context.Context) (config.Tenant, bool) {
+	keyID, ok := strings.CutPrefix(Caller(ctx), APIKeyCallerPrefix)
+	if !ok {
+		return config.Tenant{}, false
+	}
+	return config.TenantForAPIKey(keyID)
+}
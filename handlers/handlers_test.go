@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -110,6 +111,18 @@ func TestInvalidPayloadVODUploadHandler(t *testing.T) {
 			"callback_url": "http://localhost/callback",
 			"output_locations": [ { "type": "object_store", "url": "x://}]:&7@localhost/" } ]
 		}`),
+		// azblob:// output without the account key
+		[]byte(`{
+			"url": "http://localhost/input",
+			"callback_url": "http://localhost/callback",
+			"output_locations": [ { "type": "object_store", "url": "azblob://account@container/output", "outputs": { "hls": "enabled" } } ]
+		}`),
+		// b2:// output without credentials
+		[]byte(`{
+			"url": "http://localhost/input",
+			"callback_url": "http://localhost/callback",
+			"output_locations": [ { "type": "object_store", "url": "b2://us-west-004/bucket/output", "outputs": { "hls": "enabled" } } ]
+		}`),
 		// invalid output_location type
 		[]byte(`{
 			"url": "http://localhost/input",
@@ -122,6 +135,20 @@ func TestInvalidPayloadVODUploadHandler(t *testing.T) {
 			"callback_url": "http://localhost/callback",
 			"output_locations": [ { "type": "pinata", "pinata_access_key": "" } ]
 		}`),
+		// x25519 key wrapping without the ephemeral public key
+		[]byte(`{
+			"url": "http://localhost/input",
+			"callback_url": "http://localhost/callback",
+			"output_locations": [ { "type": "object_store", "url": "memory://localhost/output.m3u8", "outputs": { "hls": "enabled" } } ],
+			"encryption": { "key_wrapping": "x25519", "encrypted_key": "abc" }
+		}`),
+		// default key wrapping without the encrypted key
+		[]byte(`{
+			"url": "http://localhost/input",
+			"callback_url": "http://localhost/callback",
+			"output_locations": [ { "type": "object_store", "url": "memory://localhost/output.m3u8", "outputs": { "hls": "enabled" } } ],
+			"encryption": { "ephemeral_public_key": "abc" }
+		}`),
 		// none of outputs enabled: hls or mp4
 		[]byte(`{
 			"url": "http://localhost/input",
@@ -169,3 +196,21 @@ func TestWrongContentTypeVODUploadHandler(t *testing.T) {
 	require.Equal(415, rr.Result().StatusCode)
 	require.JSONEq(rr.Body.String(), `{"error": "Requires application/json content type", "error_detail":""}`)
 }
+
+func TestVODUploadHandlerRejectsWhileShuttingDown(t *testing.T) {
+	require := require.New(t)
+
+	vodEngine := pipeline.NewStubCoordinator()
+	require.NoError(vodEngine.Shutdown(context.Background()))
+	catalystApiHandlers := CatalystAPIHandlersCollection{VODEngine: vodEngine}
+
+	router := httprouter.New()
+	router.POST("/api/vod", catalystApiHandlers.UploadVOD())
+	req, _ := http.NewRequest("POST", "/api/vod", bytes.NewBuffer([]byte(`{}`)))
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	require.Equal(http.StatusServiceUnavailable, rr.Result().StatusCode)
+	require.JSONEq(`{"error": "Node is shutting down", "error_detail": "", "retryable": true}`, rr.Body.String())
+}
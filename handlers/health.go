@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/clients"
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/livepeer/catalyst-api/pipeline"
+)
+
+const (
+	healthCheckTimeout = 5 * time.Second
+	// objectStoreCheckInterval limits how often the object storage credentials are checked, since each check is
+	// an upload and probes come in every few seconds
+	objectStoreCheckInterval = time.Minute
+)
+
+// HealthCheck checks one of the dependencies of the node
+type HealthCheck struct {
+	Name string
+	// Liveness checks are also run by /healthz, so should only fail when restarting the process would help
+	Liveness bool
+	Check    func(ctx context.Context) error
+}
+
+// DependencyStatus leaves out the error, which is logged instead since it can include internal addresses
+type DependencyStatus struct {
+	Status     string `json:"status"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+type HealthStatusResponse struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+}
+
+type HealthHandlersCollection struct {
+	checks []HealthCheck
+}
+
+func NewHealthHandlersCollection(checks ...HealthCheck) *HealthHandlersCollection {
+	return &HealthHandlersCollection{checks: checks}
+}
+
+// Healthz returns an HTTP 200 if the liveness checks pass, for k8s liveness probes
+func (h *HealthHandlersCollection) Healthz() httprouter.Handle {
+	return h.handle(true)
+}
+
+// Readyz returns an HTTP 200 if all the dependencies are available, for load balancers and k8s readiness probes
+func (h *HealthHandlersCollection) Readyz() httprouter.Handle {
+	return h.handle(false)
+}
+
+func (h *HealthHandlersCollection) handle(livenessOnly bool) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		var checks []HealthCheck
+		for _, c := range h.checks {
+			if c.Liveness || !livenessOnly {
+				checks = append(checks, c)
+			}
+		}
+		resp := runHealthChecks(req.Context(), checks)
+
+		w.Header().Set("Content-Type", "application/json")
+		if resp.Status != "ok" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			log.LogNoRequestID("Failed to write HTTP response for " + req.URL.RawPath)
+		}
+	}
+}
+
+// runHealthChecks runs the checks concurrently, failing the ones that don't return within the timeout
+func runHealthChecks(ctx context.Context, checks []HealthCheck) HealthStatusResponse {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	resp := HealthStatusResponse{Status: "ok", Dependencies: map[string]DependencyStatus{}}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c HealthCheck) {
+			defer wg.Done()
+			start := time.Now()
+			// buffered so that checks which ignore the context don't leak after the timeout
+			result := make(chan error, 1)
+			go func() { result <- c.Check(ctx) }()
+
+			var err error
+			select {
+			case err = <-result:
+			case <-ctx.Done():
+				err = fmt.Errorf("timed out after %s", healthCheckTimeout)
+			}
+
+			status := DependencyStatus{Status: "ok", DurationMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				status.Status = "failing"
+				log.LogNoRequestID("health check failing", "dependency", c.Name, "err", err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			resp.Dependencies[c.Name] = status
+			if err != nil {
+				resp.Status = "failing"
+			}
+		}(c)
+	}
+	wg.Wait()
+	return resp
+}
+
+// MistHealthCheck checks that the Mist API is reachable
+func MistHealthCheck(mist clients.MistAPIClient) HealthCheck {
+	return HealthCheck{
+		Name: "mist",
+		Check: func(context.Context) error {
+			_, err := mist.GetState()
+			return err
+		},
+	}
+}
+
+// DBHealthCheck checks the connectivity to a Postgres DB
+func DBHealthCheck(name string, db *sql.DB) HealthCheck {
+	return HealthCheck{
+		Name:  name,
+		Check: db.PingContext,
+	}
+}
+
+// CallbackLoopHealthCheck checks that the periodic transcode status callbacks are still being sent
+func CallbackLoopHealthCheck(statusClient *clients.PeriodicCallbackClient) HealthCheck {
+	return HealthCheck{
+		Name:     "callback_loop",
+		Liveness: true,
+		Check: func(context.Context) error {
+			return statusClient.CheckLoop()
+		},
+	}
+}
+
+// ObjectStoreHealthCheck checks the credentials of an object storage URL by writing to it. The result is reused
+// for a minute.
+func ObjectStoreHealthCheck(name, osURL string) HealthCheck {
+	var (
+		mu        sync.Mutex
+		lastCheck time.Time
+		lastErr   error
+	)
+	return HealthCheck{
+		Name: name,
+		Check: func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if time.Since(lastCheck) < objectStoreCheckInterval {
+				return lastErr
+			}
+			lastErr = clients.UploadToOSURL(osURL, ".healthcheck", strings.NewReader(time.Now().UTC().Format(time.RFC3339)), healthCheckTimeout)
+			lastCheck = time.Now()
+			return lastErr
+		},
+	}
+}
+
+// VODEngineHealthCheck fails once the node is draining its VOD jobs, so that it stops receiving new ones
+func VODEngineHealthCheck(vodEngine *pipeline.Coordinator) HealthCheck {
+	return HealthCheck{
+		Name: "vod_engine",
+		Check: func(context.Context) error {
+			if vodEngine.ShuttingDown() {
+				return fmt.Errorf("shutting down")
+			}
+			return nil
+		},
+	}
+}
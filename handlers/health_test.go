@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/livepeer/catalyst-api/pipeline"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthAndReadiness(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	failing := func(context.Context) error { return fmt.Errorf("connection refused") }
+	h := NewHealthHandlersCollection(
+		HealthCheck{Name: "callback_loop", Liveness: true, Check: ok},
+		HealthCheck{Name: "metrics_db", Check: failing},
+	)
+
+	rr := httptest.NewRecorder()
+	h.Healthz()(rr, httptest.NewRequest(http.MethodGet, "/healthz", nil), nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var resp HealthStatusResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, "ok", resp.Status)
+	require.Len(t, resp.Dependencies, 1)
+	require.Equal(t, "ok", resp.Dependencies["callback_loop"].Status)
+
+	rr = httptest.NewRecorder()
+	h.Readyz()(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil), nil)
+	require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, "failing", resp.Status)
+	require.Equal(t, "ok", resp.Dependencies["callback_loop"].Status)
+	require.Equal(t, "failing", resp.Dependencies["metrics_db"].Status)
+	// the error details are only logged
+	require.NotContains(t, rr.Body.String(), "connection refused")
+}
+
+func TestVODEngineHealthCheckFailsWhileShuttingDown(t *testing.T) {
+	vodEngine := pipeline.NewStubCoordinator()
+	check := VODEngineHealthCheck(vodEngine)
+	require.NoError(t, check.Check(context.Background()))
+
+	require.NoError(t, vodEngine.Shutdown(context.Background()))
+	require.Error(t, check.Check(context.Background()))
+}
+
+func TestObjectStoreHealthCheckReusesResult(t *testing.T) {
+	check := ObjectStoreHealthCheck("source_output", "invalid://bucket")
+	err := check.Check(context.Background())
+	require.Error(t, err)
+	// not checked again within the interval
+	require.Same(t, err, check.Check(context.Background()))
+}
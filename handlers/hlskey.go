@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/crypto"
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/livepeer/catalyst-api/requests"
+)
+
+// how long players may cache a key for, the key of an asset never changes
+const hlsKeyMaxAge = 3600
+
+// HLSKeyHandler serves the AES-128 keys of encrypted HLS outputs, referenced by the #EXT-X-KEY URI of the
+// playlists. It should be wrapped in the gating check so that keys are only served with a valid playback token.
+type HLSKeyHandler struct {
+	secret []byte
+}
+
+func NewHLSKeyHandler(secret []byte) *HLSKeyHandler {
+	return &HLSKeyHandler{secret: secret}
+}
+
+func (h *HLSKeyHandler) Handle(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
+	key := crypto.DeriveHLSKey(h.secret, params.ByName("playbackID"))
+
+	w.Header().Set("content-type", "application/octet-stream")
+	w.Header().Set("content-length", strconv.Itoa(len(key)))
+	// private so that shared caches don't serve the key to viewers who haven't been authorized
+	w.Header().Set("cache-control", "private, max-age="+strconv.Itoa(hlsKeyMaxAge))
+	w.WriteHeader(http.StatusOK)
+	if req.Method == http.MethodHead {
+		return
+	}
+	if _, err := w.Write(key); err != nil {
+		log.LogError(requests.GetRequestId(req), "failed to write hls key", err)
+	}
+}
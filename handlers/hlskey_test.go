@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHLSKeyHandler(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	router := httprouter.New()
+	router.GET("/asset/keys/:playbackID", NewHLSKeyHandler(secret).Handle)
+
+	keys := map[string][]byte{}
+	for _, playbackID := range []string{"abc123", "def456"} {
+		rr := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/asset/keys/"+playbackID, nil)
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, "private, max-age=3600", rr.Header().Get("cache-control"))
+		require.Equal(t, "application/octet-stream", rr.Header().Get("content-type"))
+		require.Len(t, rr.Body.Bytes(), crypto.HLSKeySize)
+		require.Equal(t, crypto.DeriveHLSKey(secret, playbackID), rr.Body.Bytes())
+		keys[playbackID] = rr.Body.Bytes()
+	}
+	require.NotEqual(t, keys["abc123"], keys["def456"])
+}
@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/pipeline"
+)
+
+// JobStatus returns the progress of a VOD job in flight on this node
+func (d *CatalystAPIHandlersCollection) JobStatus(requestID string) (pipeline.JobStatus, error) {
+	status, ok := d.VODEngine.JobStatus(requestID)
+	if !ok {
+		return status, errors.NewAPIError("Job not found", http.StatusNotFound, fmt.Errorf("no job in flight with request ID %q", requestID))
+	}
+	return status, nil
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"encoding/json"
+	errs "errors"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/events"
+	"github.com/livepeer/catalyst-api/liveprofiles"
+	"github.com/livepeer/catalyst-api/video"
+)
+
+type LiveProfilesRequest struct {
+	Profiles []video.EncodedProfile `json:"profiles"`
+}
+
+// LiveProfilesHandlers set the live transcoding ladder of a stream. Once stored, a stream event is broadcast to the
+// cluster so that the ingest node applies it to the stream if it's live.
+type LiveProfilesHandlers struct {
+	Events       *EventsHandlersCollection
+	LiveProfiles *liveprofiles.Manager
+}
+
+// SetProfiles sets the ladder of a stream, returning the ID of the event broadcast
+func (h *LiveProfilesHandlers) SetProfiles() httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
+		playbackID := params.ByName("playbackID")
+		if !playbackIDRegex.MatchString(playbackID) {
+			errors.WriteHTTPBadRequest(w, "Invalid playback ID", nil)
+			return
+		}
+		var body LiveProfilesRequest
+		if !HasContentType(req, "application/json") {
+			errors.WriteHTTPUnsupportedMediaType(w, "Requires application/json content type", nil)
+			return
+		} else if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			errors.WriteHTTPBadRequest(w, "Invalid request payload", err)
+			return
+		} else if err := liveprofiles.ValidateProfiles(body.Profiles); err != nil {
+			errors.WriteHTTPBadRequest(w, "Invalid live transcode profiles", err)
+			return
+		}
+		if err := h.LiveProfiles.Set(req.Context(), playbackID, body.Profiles); err != nil {
+			errors.WriteHTTPInternalServerError(w, "Cannot set live transcode profiles", err)
+			return
+		}
+		h.refresh(w, req, playbackID)
+	}
+}
+
+// GetProfiles returns the ladder set for a stream
+func (h *LiveProfilesHandlers) GetProfiles() httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
+		playbackID := params.ByName("playbackID")
+		if !playbackIDRegex.MatchString(playbackID) {
+			errors.WriteHTTPBadRequest(w, "Invalid playback ID", nil)
+			return
+		}
+		profiles, err := h.LiveProfiles.Get(req.Context(), playbackID)
+		if errs.Is(err, liveprofiles.ErrNotFound) {
+			errors.WriteHTTPNotFound(w, "Stream has no live transcode profiles", err)
+			return
+		} else if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Cannot get live transcode profiles", err)
+			return
+		}
+		b, err := json.Marshal(LiveProfilesRequest{Profiles: profiles})
+		if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Cannot marshal live transcode profiles", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b) // nolint:errcheck
+	}
+}
+
+// DeleteProfiles removes the ladder of a stream, returning the ID of the event broadcast
+func (h *LiveProfilesHandlers) DeleteProfiles() httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
+		playbackID := params.ByName("playbackID")
+		if !playbackIDRegex.MatchString(playbackID) {
+			errors.WriteHTTPBadRequest(w, "Invalid playback ID", nil)
+			return
+		}
+		err := h.LiveProfiles.Delete(req.Context(), playbackID)
+		if errs.Is(err, liveprofiles.ErrNotFound) {
+			errors.WriteHTTPNotFound(w, "Stream has no live transcode profiles", err)
+			return
+		} else if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Cannot delete live transcode profiles", err)
+			return
+		}
+		h.refresh(w, req, playbackID)
+	}
+}
+
+func (h *LiveProfilesHandlers) refresh(w http.ResponseWriter, req *http.Request, playbackID string) {
+	payload, err := events.NewStreamEvent(playbackID)
+	if err != nil {
+		errors.WriteHTTPInternalServerError(w, "Cannot encode stream event", err)
+		return
+	}
+	eventID, err := h.Events.Broadcast(req.Context(), BroadcastRequest{
+		Payload:       payload,
+		EventID:       req.Header.Get(events.IDHeader),
+		Requester:     getRequester(req),
+		Authorization: req.Header.Get("Authorization"),
+	})
+	if err != nil {
+		errors.WriteHTTPAPIError(w, err)
+		return
+	}
+	writeEventResponse(w, eventID)
+}
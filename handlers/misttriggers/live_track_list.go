@@ -6,9 +6,9 @@ import (
 	"fmt"
 	"net/http"
 
-	"github.com/golang/glog"
 	"github.com/livepeer/catalyst-api/clients"
 	"github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/log"
 )
 
 type LiveTrackListPayload struct {
@@ -47,13 +47,14 @@ func ParseLiveTrackListPayload(payload MistTriggerBody) (LiveTrackListPayload, e
 func (d *MistCallbackHandlersCollection) TriggerLiveTrackList(ctx context.Context, w http.ResponseWriter, req *http.Request, payload MistTriggerBody) {
 	body, err := ParseLiveTrackListPayload(payload)
 	if err != nil {
-		glog.Infof("Error parsing LIVE_TRACK_LIST payload error=%q payload=%q", err, string(payload))
+		log.LogCtx(ctx, "Error parsing LIVE_TRACK_LIST payload", "err", err, "payload", string(payload))
 		errors.WriteHTTPBadRequest(w, "Error parsing LIVE_TRACK_LIST payload", err)
 		return
 	}
+	ctx = log.WithLogValues(ctx, log.KeyStream, body.StreamName)
 	err = d.broker.TriggerLiveTrackList(ctx, &body)
 	if err != nil {
-		glog.Infof("Error handling LIVE_TRACK_LIST payload error=%q payload=%q", err, string(payload))
+		log.LogCtx(ctx, "Error handling LIVE_TRACK_LIST payload", "err", err, "payload", string(payload))
 		errors.WriteHTTPInternalServerError(w, "Error handling LIVE_TRACK_LIST payload", err)
 		return
 	}
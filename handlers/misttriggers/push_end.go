@@ -7,9 +7,9 @@ import (
 	"net/http"
 	"strconv"
 
-	"github.com/golang/glog"
 	"github.com/livepeer/catalyst-api/clients"
 	"github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/log"
 )
 
 type PushEndPayload struct {
@@ -65,9 +65,10 @@ func (d *MistCallbackHandlersCollection) TriggerPushEnd(ctx context.Context, w h
 		errors.WriteHTTPBadRequest(w, "Error parsing PUSH_END payload", err)
 		return
 	}
+	ctx = log.WithLogValues(ctx, log.KeyStream, payload.StreamName)
 	err = d.broker.TriggerPushEnd(ctx, &payload)
 	if err != nil {
-		glog.Infof("Error handling PUSH_END payload error=%q payload=%q", err, string(body))
+		log.LogCtx(ctx, "Error handling PUSH_END payload", "err", err, "payload", string(body))
 		errors.WriteHTTPInternalServerError(w, "Error handling PUSH_END payload", err)
 		return
 	}
@@ -5,8 +5,8 @@ import (
 	"fmt"
 	"net/http"
 
-	"github.com/golang/glog"
 	"github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/log"
 )
 
 type PushOutStartPayload struct {
@@ -35,13 +35,14 @@ func ParsePushOutStartPayload(body MistTriggerBody) (PushOutStartPayload, error)
 func (d *MistCallbackHandlersCollection) TriggerPushOutStart(ctx context.Context, w http.ResponseWriter, req *http.Request, body MistTriggerBody) {
 	payload, err := ParsePushOutStartPayload(body)
 	if err != nil {
-		glog.Infof("Error parsing PUSH_OUT_START payload error=%q payload=%q", err, string(body))
+		log.LogCtx(ctx, "Error parsing PUSH_OUT_START payload", "err", err, "payload", string(body))
 		errors.WriteHTTPBadRequest(w, "Error parsing PUSH_OUT_START payload", err)
 		return
 	}
+	ctx = log.WithLogValues(ctx, log.KeyStream, payload.StreamName)
 	resp, err := d.broker.TriggerPushOutStart(ctx, &payload)
 	if err != nil {
-		glog.Infof("Error handling PUSH_OUT_START payload error=%q payload=%q", err, string(body))
+		log.LogCtx(ctx, "Error handling PUSH_OUT_START payload", "err", err, "payload", string(body))
 		errors.WriteHTTPInternalServerError(w, "Error handling PUSH_OUT_START payload", err)
 		return
 	}
@@ -6,8 +6,8 @@ import (
 	"net/http"
 	"net/url"
 
-	"github.com/golang/glog"
 	"github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/log"
 )
 
 type PushRewritePayload struct {
@@ -39,13 +39,14 @@ func ParsePushRewritePayload(payload MistTriggerBody) (PushRewritePayload, error
 func (d *MistCallbackHandlersCollection) TriggerPushRewrite(ctx context.Context, w http.ResponseWriter, req *http.Request, body MistTriggerBody) {
 	payload, err := ParsePushRewritePayload(body)
 	if err != nil {
-		glog.Infof("Error parsing PUSH_REWRITE payload error=%q payload=%q", err, string(body))
+		log.LogCtx(ctx, "Error parsing PUSH_REWRITE payload", "err", err, "payload", string(body))
 		errors.WriteHTTPBadRequest(w, "Error parsing PUSH_REWRITE payload", err)
 		return
 	}
+	ctx = log.WithLogValues(ctx, log.KeyStream, payload.StreamName)
 	resp, err := d.broker.TriggerPushRewrite(ctx, &payload)
 	if err != nil {
-		glog.Infof("Error handling PUSH_REWRITE payload error=%q payload=%q", err, string(body))
+		log.LogCtx(ctx, "Error handling PUSH_REWRITE payload", "err", err, "payload", string(body))
 		errors.WriteHTTPInternalServerError(w, "Error handling PUSH_REWRITE payload", err)
 		return
 	}
@@ -9,7 +9,6 @@ import (
 	"net/http"
 	"time"
 
-	"github.com/golang/glog"
 	"github.com/hashicorp/go-retryablehttp"
 	"github.com/livepeer/catalyst-api/errors"
 	"github.com/livepeer/catalyst-api/log"
@@ -46,18 +45,19 @@ func (d *MistCallbackHandlersCollection) TriggerStreamBuffer(ctx context.Context
 
 	body, err := ParseStreamBufferPayload(payload)
 	if err != nil {
-		glog.Infof("Error parsing STREAM_BUFFER payload error=%q payload=%q", err, string(payload))
+		log.LogCtx(ctx, "Error parsing STREAM_BUFFER payload", "err", err, "payload", string(payload))
 		errors.WriteHTTPBadRequest(w, "Error parsing STREAM_BUFFER payload", err)
 		return
 	}
 
 	rawBody, _ := json.Marshal(body)
+	ctx = log.WithLogValues(ctx, log.KeyStream, body.StreamName)
 	go d.broker.TriggerStreamBuffer(ctx, body)
 	if d.cli.StreamHealthHookURL == "" {
-		glog.Infof("Stream health hook URL not set, skipping trigger sessionId=%q payload=%s", sessionID, rawBody)
+		log.LogCtx(ctx, "Stream health hook URL not set, skipping trigger", "session_id", sessionID, "payload", string(rawBody))
 		return
 	}
-	glog.Infof("Got STREAM_BUFFER trigger sessionId=%q payload=%s", sessionID, rawBody)
+	log.LogCtx(ctx, "Got STREAM_BUFFER trigger", "session_id", sessionID, "payload", string(rawBody))
 
 	streamHealth := StreamHealthPayload{
 		StreamName: body.StreamName,
@@ -75,7 +75,7 @@ func (d *MistCallbackHandlersCollection) TriggerStreamBuffer(ctx context.Context
 
 	err = d.PostStreamHealthPayload(streamHealth)
 	if err != nil {
-		glog.Infof("Error pushing STREAM_HEALTH payload error=%q payload=%s", err, rawBody)
+		log.LogCtx(ctx, "Error pushing STREAM_HEALTH payload", "err", err, "payload", string(rawBody))
 		errors.WriteHTTPInternalServerError(w, "Error pushing STREAM_HEALTH payload", err)
 		return
 	}
@@ -117,7 +117,7 @@ func (d *MistCallbackHandlersCollection) PostStreamHealthPayload(payload StreamH
 		if err != nil {
 			return fmt.Errorf("error reading stream health hook response: %w", err)
 		}
-		glog.Warningf("Error pushing stream health to hook status=%d body=%q", resp.StatusCode, respBody)
+		log.LogNoRequestID("Error pushing stream health to hook", log.KeyStream, payload.StreamName, "status", resp.StatusCode, "body", string(respBody))
 	}
 
 	return nil
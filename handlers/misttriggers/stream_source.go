@@ -5,8 +5,8 @@ import (
 	"fmt"
 	"net/http"
 
-	"github.com/golang/glog"
 	"github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/log"
 )
 
 type StreamSourcePayload struct {
@@ -27,13 +27,14 @@ func ParseStreamSourcePayload(payload MistTriggerBody) (StreamSourcePayload, err
 func (d *MistCallbackHandlersCollection) TriggerStreamSource(ctx context.Context, w http.ResponseWriter, req *http.Request, body MistTriggerBody) {
 	payload, err := ParseStreamSourcePayload(body)
 	if err != nil {
-		glog.Infof("Error parsing STREAM_SOURCE payload error=%q payload=%q", err, string(body))
+		log.LogCtx(ctx, "Error parsing STREAM_SOURCE payload", "err", err, "payload", string(body))
 		errors.WriteHTTPBadRequest(w, "Error parsing STREAM_SOURCE payload", err)
 		return
 	}
+	ctx = log.WithLogValues(ctx, log.KeyStream, payload.StreamName)
 	resp, err := d.broker.TriggerStreamSource(ctx, &payload)
 	if err != nil {
-		glog.Infof("Error handling STREAM_SOURCE payload error=%q payload=%q", err, string(body))
+		log.LogCtx(ctx, "Error handling STREAM_SOURCE payload", "err", err, "payload", string(body))
 		errors.WriteHTTPInternalServerError(w, "Error handling STREAM_SOURCE payload", err)
 		return
 	}
@@ -5,8 +5,8 @@ import (
 	"fmt"
 	"sync"
 
-	"github.com/golang/glog"
 	"github.com/livepeer/catalyst-api/clients"
+	"github.com/livepeer/catalyst-api/log"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -104,7 +104,7 @@ func (b *triggerBroker) OnStreamBuffer(cb func(context.Context, *StreamBufferPay
 func (b *triggerBroker) TriggerStreamBuffer(ctx context.Context, payload *StreamBufferPayload) {
 	_, err := b.streamBufferFuncs.Trigger(ctx, payload)
 	if err != nil {
-		glog.Errorf("error handling STREAM_BUFFER trigger: %s", err)
+		log.LogCtx(ctx, "error handling STREAM_BUFFER trigger", "err", err)
 	}
 }
 
@@ -157,7 +157,7 @@ func (b *triggerBroker) OnUserEnd(cb func(context.Context, *UserEndPayload) erro
 func (b *triggerBroker) TriggerUserEnd(ctx context.Context, payload *UserEndPayload) {
 	_, err := b.userEndFuncs.Trigger(ctx, payload)
 	if err != nil {
-		glog.Errorf("error handling USER_END trigger: %s", err)
+		log.LogCtx(ctx, "error handling USER_END trigger", "err", err)
 	}
 }
 
@@ -52,7 +52,7 @@ func (d *MistCallbackHandlersCollection) Trigger() httprouter.Handle {
 			mistVersion = req.UserAgent()
 		}
 		ctx := log.WithLogValues(context.Background(),
-			"request_id", requestID,
+			log.KeyRequestID, requestID,
 			"trigger_name", triggerName,
 			"mist_version", mistVersion,
 		)
@@ -6,7 +6,7 @@ import (
 	"net/http"
 	"strings"
 
-	"github.com/golang/glog"
+	"github.com/livepeer/catalyst-api/log"
 )
 
 // We only pass these on to the analytics pipeline, so leave as strings for now
@@ -52,8 +52,6 @@ type UserEndPayload struct {
 // comma-separated list of seconds spend connected to each stream, same order as stream list, string
 // the session ID, string
 func ParseUserEndPayload(payload MistTriggerBody, TriggerID string) (UserEndPayload, error) {
-	glog.Infof("Received USER_END Mist trigger payload=%q", payload)
-
 	lines := payload.Lines()
 	if len(lines) != 12 {
 		return UserEndPayload{}, fmt.Errorf("expected 12 lines in USER_NEW payload but got lines=%d payload=%s", len(lines), payload)
@@ -77,9 +75,10 @@ func ParseUserEndPayload(payload MistTriggerBody, TriggerID string) (UserEndPayl
 }
 
 func (d *MistCallbackHandlersCollection) TriggerUserEnd(ctx context.Context, w http.ResponseWriter, req *http.Request, body MistTriggerBody) {
+	log.LogCtx(ctx, "Received USER_END Mist trigger", "payload", string(body))
 	payload, err := ParseUserEndPayload(body, req.Header.Get("X-Trigger-UUID"))
 	if err != nil {
-		glog.Infof("Error parsing USER_END payload error=%q payload=%q", err, string(body))
+		log.LogCtx(ctx, "Error parsing USER_END payload", "err", err, "payload", string(body))
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
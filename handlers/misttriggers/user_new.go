@@ -6,7 +6,6 @@ import (
 	"net/http"
 	"net/url"
 
-	"github.com/golang/glog"
 	"github.com/livepeer/catalyst-api/log"
 )
 
@@ -113,9 +112,10 @@ func (d *MistCallbackHandlersCollection) TriggerUserNew(ctx context.Context, w h
 		w.Write([]byte("false")) // nolint:errcheck
 		return
 	}
+	ctx = log.WithLogValues(ctx, log.KeyStream, payload.StreamName)
 	resp, err := d.broker.TriggerUserNew(ctx, &payload)
 	if err != nil {
-		glog.Infof("Error handling USER_NEW payload error=%q payload=%q", err, string(body))
+		log.LogCtx(ctx, "Error handling USER_NEW payload", "err", err, "payload", string(body))
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte("false")) // nolint:errcheck
 		return
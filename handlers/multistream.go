@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	errs "errors"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/livepeer/catalyst-api/multistream"
+)
+
+var playbackIDRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+type MultistreamRequest struct {
+	Targets []multistream.Target `json:"targets"`
+}
+
+type MultistreamResponse struct {
+	Targets []multistream.TargetStatus `json:"targets"`
+}
+
+// MultistreamHandlers register and report on the RTMP(S) and SRT targets the live streams ingested on this node are
+// restreamed to
+type MultistreamHandlers struct {
+	Manager *multistream.Manager
+}
+
+// AddTargets registers restream targets for a stream, returning their IDs and initial status
+func (h *MultistreamHandlers) AddTargets() httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
+		playbackID := params.ByName("playbackID")
+		if !playbackIDRegex.MatchString(playbackID) {
+			errors.WriteHTTPBadRequest(w, "Invalid playback ID", nil)
+			return
+		}
+		var body MultistreamRequest
+		if !HasContentType(req, "application/json") {
+			errors.WriteHTTPUnsupportedMediaType(w, "Requires application/json content type", nil)
+			return
+		} else if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			errors.WriteHTTPBadRequest(w, "Invalid request payload", err)
+			return
+		} else if len(body.Targets) == 0 {
+			errors.WriteHTTPBadRequest(w, "Invalid request payload", fmt.Errorf("no targets"))
+			return
+		}
+		for _, t := range body.Targets {
+			if err := t.Validate(); err != nil {
+				errors.WriteHTTPBadRequest(w, "Invalid restream target", err)
+				return
+			}
+		}
+
+		added, err := h.Manager.Add(playbackID, body.Targets)
+		if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Could not add restream targets", err)
+			return
+		}
+		log.LogNoRequestID("restream targets added", "playback_id", playbackID, "count", len(added))
+		writeMultistreamResponse(w, http.StatusCreated, added)
+	}
+}
+
+// TargetsStatus reports the status of each restream target of a stream
+func (h *MultistreamHandlers) TargetsStatus() httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
+		statuses, err := h.Manager.Status(params.ByName("playbackID"))
+		if errs.Is(err, multistream.ErrNotFound) {
+			errors.WriteHTTPNotFound(w, "Stream has no restream targets", nil)
+			return
+		}
+		if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Could not get restream targets", err)
+			return
+		}
+		writeMultistreamResponse(w, http.StatusOK, statuses)
+	}
+}
+
+// RemoveTarget stops restreaming a stream to a target
+func (h *MultistreamHandlers) RemoveTarget() httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
+		playbackID, targetID := params.ByName("playbackID"), params.ByName("targetID")
+		err := h.Manager.Remove(playbackID, targetID)
+		if errs.Is(err, multistream.ErrNotFound) {
+			errors.WriteHTTPNotFound(w, "Restream target not found", nil)
+			return
+		}
+		if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Could not remove restream target", err)
+			return
+		}
+		log.LogNoRequestID("restream target removed", "playback_id", playbackID, "target", targetID)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func writeMultistreamResponse(w http.ResponseWriter, status int, targets []multistream.TargetStatus) {
+	b, err := json.Marshal(MultistreamResponse{Targets: targets})
+	if err != nil {
+		errors.WriteHTTPInternalServerError(w, "Could not marshal restream targets", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(b) // nolint:errcheck
+}
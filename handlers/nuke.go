@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/clients"
+	"github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/events"
+	"github.com/livepeer/catalyst-api/log"
+)
+
+// Results of nuking a stream on a node
+const (
+	NukeStatusNuked  = "nuked"
+	NukeStatusFailed = "failed"
+	// NukeStatusBroadcast is the status of the other nodes, which don't report whether they nuked the stream
+	NukeStatusBroadcast = "broadcast"
+)
+
+type NukeNodeResult struct {
+	Node   string `json:"node"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type NukeResponse struct {
+	PlaybackID string           `json:"playback_id"`
+	EventID    string           `json:"event_id,omitempty"`
+	Nodes      []NukeNodeResult `json:"nodes"`
+}
+
+// NukeHandlers nuke a stream on this node directly, rather than only with a nuke event, optionally broadcasting the
+// event to nuke it on the rest of the cluster too
+type NukeHandlers struct {
+	Events         *EventsHandlersCollection
+	Mist           clients.MistAPIClient
+	NodeName       string
+	BaseStreamName string
+}
+
+// NukeStream nukes a stream on this node, and on every node with ?broadcast=true
+func (h *NukeHandlers) NukeStream() httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
+		playbackID := params.ByName("playbackID")
+		if !playbackIDRegex.MatchString(playbackID) {
+			errors.WriteHTTPBadRequest(w, "Invalid playback ID", nil)
+			return
+		}
+
+		resp := NukeResponse{PlaybackID: playbackID}
+		local := NukeNodeResult{Node: h.NodeName, Status: NukeStatusNuked}
+		streamName := h.BaseStreamName + "+" + playbackID
+		if err := h.Mist.NukeStream(streamName); err != nil {
+			log.LogNoRequestID("error nuking stream", log.KeyStream, streamName, "err", err)
+			local.Status, local.Error = NukeStatusFailed, err.Error()
+		}
+		resp.Nodes = append(resp.Nodes, local)
+
+		if req.URL.Query().Get("broadcast") == "true" {
+			payload, err := events.NewNukeEvent(playbackID)
+			if err != nil {
+				errors.WriteHTTPInternalServerError(w, "Cannot encode nuke event", err)
+				return
+			}
+			resp.EventID, err = h.Events.Broadcast(req.Context(), BroadcastRequest{
+				Payload:       payload,
+				EventID:       req.Header.Get(events.IDHeader),
+				Requester:     getRequester(req),
+				Authorization: req.Header.Get("Authorization"),
+			})
+			if err != nil {
+				errors.WriteHTTPAPIError(w, err)
+				return
+			}
+			members, err := h.Events.cluster.MembersFiltered(map[string]string{}, "alive", "")
+			if err != nil {
+				log.LogNoRequestID("error listing the nodes the nuke event was broadcast to", "err", err)
+			}
+			for _, m := range members {
+				if m.Name != h.NodeName {
+					resp.Nodes = append(resp.Nodes, NukeNodeResult{Node: m.Name, Status: NukeStatusBroadcast})
+				}
+			}
+		}
+
+		b, err := json.Marshal(resp)
+		if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Cannot marshal nuke results", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if resp.EventID != "" {
+			w.Header().Set(events.IDHeader, resp.EventID)
+		}
+		if local.Status == NukeStatusFailed {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+		w.Write(b) // nolint:errcheck
+	}
+}
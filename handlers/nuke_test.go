@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/serf/serf"
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/cluster"
+	mockmistclient "github.com/livepeer/catalyst-api/mocks/clients"
+	mockcluster "github.com/livepeer/catalyst-api/mocks/cluster"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNukeStream(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mc := mockcluster.NewMockCluster(ctrl)
+	mist := mockmistclient.NewMockMistAPIClient(ctrl)
+	h := &NukeHandlers{
+		Events:         NewEventsHandlersCollection(mc, nil, nil, nil, nil, ""),
+		Mist:           mist,
+		NodeName:       "node-0",
+		BaseStreamName: "video",
+	}
+	router := httprouter.New()
+	router.DELETE("/admin/stream/:playbackID", h.NukeStream())
+	nuke := func(path string) (int, NukeResponse) {
+		rr := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", path, nil)
+		router.ServeHTTP(rr, req)
+		var resp NukeResponse
+		_ = json.Unmarshal(rr.Body.Bytes(), &resp)
+		return rr.Code, resp
+	}
+
+	// only nuked locally
+	mist.EXPECT().NukeStream("video+abc123").Return(nil)
+	code, resp := nuke("/admin/stream/abc123")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, []NukeNodeResult{{Node: "node-0", Status: NukeStatusNuked}}, resp.Nodes)
+	require.Empty(t, resp.EventID)
+
+	mist.EXPECT().NukeStream("video+abc123").Return(fmt.Errorf("mist is down"))
+	code, resp = nuke("/admin/stream/abc123")
+	require.Equal(t, http.StatusBadGateway, code)
+	require.Equal(t, []NukeNodeResult{{Node: "node-0", Status: NukeStatusFailed, Error: "mist is down"}}, resp.Nodes)
+
+	mist.EXPECT().NukeStream("video+abc123").Return(nil)
+	mc.EXPECT().BroadcastEvent(gomock.Any()).DoAndReturn(func(event serf.UserEvent) error {
+		require.Equal(t, "nuke-abc123", event.Name)
+		return nil
+	})
+	mc.EXPECT().MembersFiltered(map[string]string{}, "alive", "").Return([]cluster.Member{{Name: "node-0"}, {Name: "node-1"}}, nil)
+	code, resp = nuke("/admin/stream/abc123?broadcast=true")
+	require.Equal(t, http.StatusOK, code)
+	require.NotEmpty(t, resp.EventID)
+	require.Equal(t, []NukeNodeResult{
+		{Node: "node-0", Status: NukeStatusNuked},
+		{Node: "node-1", Status: NukeStatusBroadcast},
+	}, resp.Nodes)
+
+	code, _ = nuke("/admin/stream/abc$123")
+	require.Equal(t, http.StatusBadRequest, code)
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/events"
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/livepeer/catalyst-api/recording"
+)
+
+// RecordingHandlers turn the recording of live streams on and off. The settings are broadcast to the cluster as a
+// recording event, since the stream can be ingested on any node.
+type RecordingHandlers struct {
+	Events   *EventsHandlersCollection
+	Recorder *recording.Recorder
+}
+
+// SetRecording enables or disables recording for a stream, returning the ID of the event broadcast
+func (h *RecordingHandlers) SetRecording() httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
+		playbackID := params.ByName("playbackID")
+		if !playbackIDRegex.MatchString(playbackID) {
+			errors.WriteHTTPBadRequest(w, "Invalid playback ID", nil)
+			return
+		}
+		var settings events.RecordingSettings
+		if !HasContentType(req, "application/json") {
+			errors.WriteHTTPUnsupportedMediaType(w, "Requires application/json content type", nil)
+			return
+		} else if err := json.NewDecoder(req.Body).Decode(&settings); err != nil {
+			errors.WriteHTTPBadRequest(w, "Invalid request payload", err)
+			return
+		} else if err := recording.ValidateSettings(settings); err != nil {
+			errors.WriteHTTPBadRequest(w, "Invalid recording settings", err)
+			return
+		}
+
+		payload, err := events.NewRecordingEvent(playbackID, settings)
+		if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Cannot encode recording event", err)
+			return
+		}
+		eventID, err := h.Events.Broadcast(req.Context(), BroadcastRequest{
+			Payload:       payload,
+			EventID:       req.Header.Get(events.IDHeader),
+			Requester:     getRequester(req),
+			Authorization: req.Header.Get("Authorization"),
+		})
+		if err != nil {
+			errors.WriteHTTPAPIError(w, err)
+			return
+		}
+		writeEventResponse(w, eventID)
+	}
+}
+
+// GetRecording returns the recording settings this node has for a stream, without the output location's credentials
+func (h *RecordingHandlers) GetRecording() httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
+		settings := h.Recorder.Settings(params.ByName("playbackID"))
+		if settings.OutputURL != "" {
+			settings.OutputURL = log.RedactURL(settings.OutputURL)
+		}
+		b, err := json.Marshal(settings)
+		if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Cannot marshal recording settings", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b) // nolint:errcheck
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/errors"
+	mistapiconnector "github.com/livepeer/catalyst-api/mapic"
+)
+
+// Scopes of the viewer sessions to stop
+const (
+	SessionScopeAll       = "all"
+	SessionScopeProtocol  = "protocol"
+	SessionScopeOlderThan = "older_than"
+)
+
+type StopSessionsRequest struct {
+	Scope         string `json:"scope"`
+	Protocol      string `json:"protocol,omitempty"`
+	OlderThanSecs int64  `json:"older_than_secs,omitempty"`
+}
+
+type StopSessionsResponse struct {
+	PlaybackID string `json:"playback_id"`
+	Stopped    int    `json:"stopped"`
+}
+
+// StopSessionsHandlers stop some of the viewer sessions of a stream on this node, rather than all of them on its
+// ingest node as the stop sessions event does
+type StopSessionsHandlers struct {
+	Mapic mistapiconnector.IMac
+}
+
+// StopSessions stops the viewer sessions of a stream in the scope requested
+func (h *StopSessionsHandlers) StopSessions() httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
+		playbackID := params.ByName("playbackID")
+		if !playbackIDRegex.MatchString(playbackID) {
+			errors.WriteHTTPBadRequest(w, "Invalid playback ID", nil)
+			return
+		}
+		var body StopSessionsRequest
+		if !HasContentType(req, "application/json") {
+			errors.WriteHTTPUnsupportedMediaType(w, "Requires application/json content type", nil)
+			return
+		} else if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			errors.WriteHTTPBadRequest(w, "Invalid request payload", err)
+			return
+		}
+		scope, err := sessionScope(body)
+		if err != nil {
+			errors.WriteHTTPBadRequest(w, "Invalid scope", err)
+			return
+		}
+
+		stopped, err := h.Mapic.StopSessionsScoped(playbackID, scope)
+		if err != nil {
+			errors.WriteHTTPErrorWithRetry(w, "Cannot stop sessions", http.StatusBadGateway, err, true)
+			return
+		}
+		b, err := json.Marshal(StopSessionsResponse{PlaybackID: playbackID, Stopped: stopped})
+		if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Cannot marshal stopped sessions", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b) // nolint:errcheck
+	}
+}
+
+func sessionScope(req StopSessionsRequest) (mistapiconnector.SessionScope, error) {
+	switch req.Scope {
+	case SessionScopeAll:
+		return mistapiconnector.SessionScope{}, nil
+	case SessionScopeProtocol:
+		if req.Protocol == "" {
+			return mistapiconnector.SessionScope{}, fmt.Errorf("protocol is required for the %s scope", req.Scope)
+		}
+		return mistapiconnector.SessionScope{Protocol: req.Protocol}, nil
+	case SessionScopeOlderThan:
+		if req.OlderThanSecs <= 0 {
+			return mistapiconnector.SessionScope{}, fmt.Errorf("older_than_secs must be positive for the %s scope", req.Scope)
+		}
+		return mistapiconnector.SessionScope{OlderThan: time.Duration(req.OlderThanSecs) * time.Second}, nil
+	}
+	return mistapiconnector.SessionScope{}, fmt.Errorf("unknown scope %q, expected %s, %s or %s", req.Scope, SessionScopeAll, SessionScopeProtocol, SessionScopeOlderThan)
+}
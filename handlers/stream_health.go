@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"encoding/json"
+	errs "errors"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/streamhealth"
+)
+
+// StreamHealthHandlers report on the health of the live streams on this node, for dashboards
+type StreamHealthHandlers struct {
+	Tracker *streamhealth.Tracker
+}
+
+// StreamHealth returns the health document of a stream
+func (h *StreamHealthHandlers) StreamHealth() httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
+		playbackID := params.ByName("playbackID")
+		if !playbackIDRegex.MatchString(playbackID) {
+			errors.WriteHTTPBadRequest(w, "Invalid playback ID", nil)
+			return
+		}
+		health, err := h.Tracker.Health(playbackID)
+		if errs.Is(err, streamhealth.ErrNotFound) {
+			errors.WriteHTTPNotFound(w, "Stream not found", err)
+			return
+		}
+		b, err := json.Marshal(health)
+		if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Cannot marshal stream health", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b) // nolint:errcheck
+	}
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/events"
+	"github.com/livepeer/catalyst-api/streammeta"
+)
+
+// StreamMetadataHandlers attach metadata to a stream that is translated into its Mist stream settings. The metadata
+// is broadcast to the cluster as a metadata event, since the stream can be ingested on any node.
+type StreamMetadataHandlers struct {
+	Events   *EventsHandlersCollection
+	Metadata *streammeta.Manager
+}
+
+// SetMetadata sets the metadata of a stream, returning the ID of the event broadcast
+func (h *StreamMetadataHandlers) SetMetadata() httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
+		playbackID := params.ByName("playbackID")
+		if !playbackIDRegex.MatchString(playbackID) {
+			errors.WriteHTTPBadRequest(w, "Invalid playback ID", nil)
+			return
+		}
+		var metadata events.StreamMetadata
+		if !HasContentType(req, "application/json") {
+			errors.WriteHTTPUnsupportedMediaType(w, "Requires application/json content type", nil)
+			return
+		} else if err := json.NewDecoder(req.Body).Decode(&metadata); err != nil {
+			errors.WriteHTTPBadRequest(w, "Invalid request payload", err)
+			return
+		} else if err := streammeta.ValidateMetadata(metadata); err != nil {
+			errors.WriteHTTPBadRequest(w, "Invalid stream metadata", err)
+			return
+		}
+
+		payload, err := events.NewMetadataEvent(playbackID, metadata)
+		if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Cannot encode metadata event", err)
+			return
+		}
+		eventID, err := h.Events.Broadcast(req.Context(), BroadcastRequest{
+			Payload:       payload,
+			EventID:       req.Header.Get(events.IDHeader),
+			Requester:     getRequester(req),
+			Authorization: req.Header.Get("Authorization"),
+		})
+		if err != nil {
+			errors.WriteHTTPAPIError(w, err)
+			return
+		}
+		writeEventResponse(w, eventID)
+	}
+}
+
+// GetMetadata returns the metadata this node has for a stream
+func (h *StreamMetadataHandlers) GetMetadata() httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
+		playbackID := params.ByName("playbackID")
+		if !playbackIDRegex.MatchString(playbackID) {
+			errors.WriteHTTPBadRequest(w, "Invalid playback ID", nil)
+			return
+		}
+		b, err := json.Marshal(h.Metadata.Metadata(playbackID))
+		if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Cannot marshal stream metadata", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b) // nolint:errcheck
+	}
+}
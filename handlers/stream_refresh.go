@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"encoding/json"
+	errs "errors"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/errors"
+	mistapiconnector "github.com/livepeer/catalyst-api/mapic"
+	"github.com/livepeer/go-api-client"
+)
+
+// StreamRefreshHandlers refresh a stream from the Livepeer API on this node, reporting what changed, where the refresh
+// event only refreshes it in the background
+type StreamRefreshHandlers struct {
+	Mapic mistapiconnector.IMac
+}
+
+// RefreshStream refreshes a stream and returns the settings that changed
+func (h *StreamRefreshHandlers) RefreshStream() httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
+		playbackID := params.ByName("playbackID")
+		if !playbackIDRegex.MatchString(playbackID) {
+			errors.WriteHTTPBadRequest(w, "Invalid playback ID", nil)
+			return
+		}
+		refresh, err := h.Mapic.RefreshStream(playbackID)
+		if errs.Is(err, api.ErrNotExists) {
+			errors.WriteHTTPNotFound(w, "Stream not found", err)
+			return
+		} else if err != nil {
+			errors.WriteHTTPErrorWithRetry(w, "Cannot refresh stream", http.StatusBadGateway, err, true)
+			return
+		}
+		b, err := json.Marshal(refresh)
+		if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Cannot marshal stream refresh", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b) // nolint:errcheck
+	}
+}
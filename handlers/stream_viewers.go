@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/clients"
+	"github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/patrickmn/go-cache"
+)
+
+// viewersCacheTTL is how long the viewer counts of a stream are reused for, since dashboards poll them
+const viewersCacheTTL = 5 * time.Second
+
+// NodeViewerCounter counts the viewers of a stream on each node of the cluster, i.e. the catabalancer
+type NodeViewerCounter interface {
+	StreamViewers(ctx context.Context, playbackID string) (map[string]int, error)
+}
+
+type StreamViewers struct {
+	PlaybackID string        `json:"playback_id"`
+	Viewers    int           `json:"viewers"`
+	Nodes      []NodeViewers `json:"nodes"`
+}
+
+type NodeViewers struct {
+	Node    string `json:"node"`
+	Viewers int    `json:"viewers"`
+	// Protocols breaks the viewers down by protocol, only known for this node
+	Protocols map[string]int `json:"protocols,omitempty"`
+}
+
+// StreamViewersHandlers count the viewers of a stream, per protocol on this node from its Mist sessions and per node
+// from the node stats of the catabalancer
+type StreamViewersHandlers struct {
+	Mist clients.MistAPIClient
+	// Nodes is nil when the catabalancer is disabled, only this node's viewers are counted then
+	Nodes          NodeViewerCounter
+	NodeName       string
+	BaseStreamName string
+
+	cache *cache.Cache
+}
+
+func NewStreamViewersHandlers(mist clients.MistAPIClient, nodes NodeViewerCounter, nodeName, baseStreamName string) *StreamViewersHandlers {
+	return &StreamViewersHandlers{
+		Mist:           mist,
+		Nodes:          nodes,
+		NodeName:       nodeName,
+		BaseStreamName: baseStreamName,
+		cache:          cache.New(viewersCacheTTL, time.Minute),
+	}
+}
+
+// StreamViewers returns the current viewer counts of a stream
+func (h *StreamViewersHandlers) StreamViewers() httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
+		playbackID := params.ByName("playbackID")
+		if !playbackIDRegex.MatchString(playbackID) {
+			errors.WriteHTTPBadRequest(w, "Invalid playback ID", nil)
+			return
+		}
+		viewers, err := h.viewers(req.Context(), playbackID)
+		if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Could not count the stream viewers", err)
+			return
+		}
+		b, err := json.Marshal(viewers)
+		if err != nil {
+			errors.WriteHTTPInternalServerError(w, "Cannot marshal stream viewers", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b) // nolint:errcheck
+	}
+}
+
+// Viewers returns the number of viewers of a stream across the cluster, from the same cache as StreamViewers
+func (h *StreamViewersHandlers) Viewers(ctx context.Context, playbackID string) (int, error) {
+	viewers, err := h.viewers(ctx, playbackID)
+	if err != nil {
+		return 0, err
+	}
+	return viewers.Viewers, nil
+}
+
+func (h *StreamViewersHandlers) viewers(ctx context.Context, playbackID string) (StreamViewers, error) {
+	if cached, ok := h.cache.Get(playbackID); ok {
+		return cached.(StreamViewers), nil
+	}
+
+	sessions, err := h.Mist.GetSessions(h.BaseStreamName + "+" + playbackID)
+	if err != nil {
+		return StreamViewers{}, err
+	}
+	local := NodeViewers{Node: h.NodeName, Protocols: make(map[string]int)}
+	for _, session := range sessions {
+		if session.IsViewer() {
+			local.Viewers++
+			local.Protocols[session.Protocol]++
+		}
+	}
+	nodes := []NodeViewers{local}
+
+	if h.Nodes != nil {
+		counts, err := h.Nodes.StreamViewers(ctx, playbackID)
+		if err != nil {
+			// the other nodes are left out rather than failing the viewers that are known
+			log.LogNoRequestID("failed to get the stream viewers of the cluster", "playback_id", playbackID, "err", err)
+		}
+		for node, count := range counts {
+			// this node's count is fresher than the one it last sent to the catabalancer
+			if node != h.NodeName {
+				nodes = append(nodes, NodeViewers{Node: node, Viewers: count})
+			}
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Node < nodes[j].Node })
+
+	viewers := StreamViewers{PlaybackID: playbackID, Nodes: nodes}
+	for _, node := range nodes {
+		viewers.Viewers += node.Viewers
+	}
+	h.cache.SetDefault(playbackID, viewers)
+	return viewers, nil
+}
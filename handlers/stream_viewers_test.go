@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/clients"
+	mockmistclient "github.com/livepeer/catalyst-api/mocks/clients"
+	"github.com/stretchr/testify/require"
+)
+
+type stubNodeViewers struct {
+	viewers map[string]int
+	err     error
+}
+
+func (s stubNodeViewers) StreamViewers(_ context.Context, _ string) (map[string]int, error) {
+	return s.viewers, s.err
+}
+
+func TestStreamViewers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mist := mockmistclient.NewMockMistAPIClient(ctrl)
+	h := NewStreamViewersHandlers(mist, stubNodeViewers{viewers: map[string]int{"node-0": 1, "node-1": 4}}, "node-0", "video")
+	router := httprouter.New()
+	router.GET("/api/stream/:playbackID/viewers", h.StreamViewers())
+	get := func(path string) (int, StreamViewers) {
+		rr := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(rr, req)
+		var resp StreamViewers
+		_ = json.Unmarshal(rr.Body.Bytes(), &resp)
+		return rr.Code, resp
+	}
+
+	// only called once, the second request is served from the cache
+	mist.EXPECT().GetSessions("video+abc123").Return([]clients.MistSession{
+		{ID: "1", Protocol: "HLS"},
+		{ID: "2", Protocol: "HLS"},
+		{ID: "3", Protocol: "WebRTC"},
+		{ID: "4", Protocol: "INPUT:RTMP"},
+		{ID: "5", Protocol: "OUTPUT:livepeer"},
+	}, nil)
+	expected := StreamViewers{
+		PlaybackID: "abc123",
+		Viewers:    7,
+		Nodes: []NodeViewers{
+			{Node: "node-0", Viewers: 3, Protocols: map[string]int{"HLS": 2, "WebRTC": 1}},
+			{Node: "node-1", Viewers: 4},
+		},
+	}
+	for i := 0; i < 2; i++ {
+		code, resp := get("/api/stream/abc123/viewers")
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, expected, resp)
+	}
+
+	// the other nodes are left out when the cluster counts aren't available
+	h.Nodes = stubNodeViewers{err: fmt.Errorf("db is down")}
+	mist.EXPECT().GetSessions("video+def456").Return([]clients.MistSession{{ID: "1", Protocol: "HLS"}}, nil)
+	code, resp := get("/api/stream/def456/viewers")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, 1, resp.Viewers)
+	require.Len(t, resp.Nodes, 1)
+
+	mist.EXPECT().GetSessions("video+ghi789").Return(nil, fmt.Errorf("mist is down"))
+	code, _ = get("/api/stream/ghi789/viewers")
+	require.Equal(t, http.StatusInternalServerError, code)
+
+	code, _ = get("/api/stream/abc$123/viewers")
+	require.Equal(t, http.StatusBadRequest, code)
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/clients"
+	"github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/livepeer/catalyst-api/thumbnails"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+type RegenerateThumbnailsRequest struct {
+	// Output location of an existing asset, containing its HLS master playlist (index.m3u8)
+	OutputURL string `json:"output_url"`
+	// Where to write the thumbnails to, defaults to the output location
+	ThumbnailsURL    string             `json:"thumbnails_url,omitempty"`
+	ThumbnailOptions thumbnails.Options `json:"thumbnail_options,omitempty"`
+}
+
+// RegenerateThumbnails re-runs thumbnail generation for an asset that has already been transcoded, e.g. one
+// transcoded before thumbnails existed or to change the thumbnail options. Thumbnails are generated in the background.
+func (d *CatalystAPIHandlersCollection) RegenerateThumbnails() httprouter.Handle {
+	schema := inputSchemasCompiled["RegenerateThumbnails"]
+
+	return func(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
+		requestID := params.ByName("requestID")
+
+		var regenerateRequest RegenerateThumbnailsRequest
+		if !HasContentType(req, "application/json") {
+			errors.WriteHTTPUnsupportedMediaType(w, "Requires application/json content type", nil)
+			return
+		} else if payload, err := io.ReadAll(req.Body); err != nil {
+			errors.WriteHTTPInternalServerError(w, "Cannot read payload", err)
+			return
+		} else if result, err := schema.Validate(gojsonschema.NewBytesLoader(payload)); err != nil {
+			errors.WriteHTTPInternalServerError(w, "Cannot validate payload", err)
+			return
+		} else if !result.Valid() {
+			errors.WriteHTTPBadRequest(w, "Invalid request payload", fmt.Errorf("%s", result.Errors()))
+			return
+		} else if err := json.Unmarshal(payload, &regenerateRequest); err != nil {
+			errors.WriteHTTPBadRequest(w, "Invalid request payload", err)
+			return
+		}
+		if err := regenerateRequest.ThumbnailOptions.Validate(); err != nil {
+			errors.WriteHTTPBadRequest(w, "Invalid request payload", err)
+			return
+		}
+
+		outputURL, err := url.Parse(regenerateRequest.OutputURL)
+		if err != nil {
+			errors.WriteHTTPBadRequest(w, "Invalid output URL", err)
+			return
+		}
+		thumbsURL := outputURL
+		if regenerateRequest.ThumbnailsURL != "" {
+			thumbsURL, err = url.Parse(regenerateRequest.ThumbnailsURL)
+			if err != nil {
+				errors.WriteHTTPBadRequest(w, "Invalid thumbnails URL", err)
+				return
+			}
+		}
+		if err := checkWritePermission(requestID, "", thumbsURL); err != nil {
+			errors.WriteHTTPInternalServerError(w, "Internal error", err)
+			return
+		}
+
+		log.Log(requestID, "Received thumbnail regeneration request", "output", outputURL.Redacted(), "thumbnails", thumbsURL.Redacted())
+		go regenerateThumbnails(requestID, outputURL, thumbsURL, regenerateRequest.ThumbnailOptions)
+
+		w.WriteHeader(http.StatusAccepted)
+		if err := json.NewEncoder(w).Encode(UploadVODResponse{RequestID: requestID}); err != nil {
+			log.LogError(requestID, "Failed to write a thumbnail regeneration response", err)
+		}
+	}
+}
+
+// regenerateThumbnails is replaced in tests, so that no generation is left running in the background
+var regenerateThumbnails = func(requestID string, outputURL, thumbsURL *url.URL, opts thumbnails.Options) {
+	renditionURL, err := clients.GetFirstRenditionURL(requestID, outputURL.JoinPath("index.m3u8"))
+	if err != nil {
+		log.LogError(requestID, "failed to get rendition URL for thumbnail regeneration", err)
+		return
+	}
+	if err := thumbnails.GenerateThumbsAndVTT(requestID, renditionURL.String(), thumbsURL, opts, nil); err != nil {
+		log.LogError(requestID, "thumbnail regeneration failed", err, "in", renditionURL.Redacted(), "out", thumbsURL.Redacted())
+		return
+	}
+	log.Log(requestID, "thumbnail regeneration succeeded", "out", thumbsURL.Redacted())
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/thumbnails"
+	"github.com/livepeer/go-tools/drivers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegenerateThumbnailsHandler(t *testing.T) {
+	drivers.Testing = true
+	regenerated := make(chan string, 1)
+	defer func(f func(string, *url.URL, *url.URL, thumbnails.Options)) { regenerateThumbnails = f }(regenerateThumbnails)
+	regenerateThumbnails = func(requestID string, outputURL, thumbsURL *url.URL, opts thumbnails.Options) {
+		regenerated <- requestID
+	}
+
+	catalystApiHandlers := CatalystAPIHandlersCollection{}
+	router := httprouter.New()
+	router.POST("/api/vod", catalystApiHandlers.UploadVOD())
+	router.POST("/api/vod/:requestID/thumbnails", catalystApiHandlers.RegenerateThumbnails())
+
+	tests := []struct {
+		name    string
+		payload string
+		status  int
+	}{
+		{
+			name:    "missing output URL",
+			payload: `{"thumbnails_url": "memory://localhost/thumbs"}`,
+			status:  http.StatusBadRequest,
+		},
+		{
+			name:    "invalid options",
+			payload: `{"output_url": "memory://localhost/output", "thumbnail_options": {"resolution": "1:1"}}`,
+			status:  http.StatusBadRequest,
+		},
+		{
+			name:    "unknown format",
+			payload: `{"output_url": "memory://localhost/output", "thumbnail_options": {"format": "gif"}}`,
+			status:  http.StatusBadRequest,
+		},
+		{
+			name:    "valid request",
+			payload: `{"output_url": "memory://localhost/output", "thumbnail_options": {"resolution": "854:480", "interval": 10}}`,
+			status:  http.StatusAccepted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/api/vod/abc123/thumbnails", bytes.NewBufferString(tt.payload))
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			require.Equal(t, tt.status, rr.Code, rr.Body.String())
+			if tt.status == http.StatusAccepted {
+				require.JSONEq(t, `{"request_id": "abc123"}`, rr.Body.String())
+				select {
+				case requestID := <-regenerated:
+					require.Equal(t, "abc123", requestID)
+				case <-time.After(5 * time.Second):
+					require.Fail(t, "thumbnails weren't regenerated")
+				}
+			}
+		})
+	}
+}
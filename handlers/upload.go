@@ -1,8 +1,8 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
-	errors2 "errors"
 	"fmt"
 	"io"
 	"mime"
@@ -19,6 +19,7 @@ import (
 	"github.com/livepeer/catalyst-api/log"
 	"github.com/livepeer/catalyst-api/metrics"
 	"github.com/livepeer/catalyst-api/pipeline"
+	"github.com/livepeer/catalyst-api/thumbnails"
 	"github.com/livepeer/catalyst-api/video"
 	"github.com/xeipuuv/gojsonschema"
 )
@@ -40,19 +41,25 @@ type UploadVODRequestOutputLocation struct {
 }
 
 type UploadVODRequest struct {
-	ExternalID      string                           `json:"external_id,omitempty"`
-	Url             string                           `json:"url"`
-	CallbackUrl     string                           `json:"callback_url"`
-	OutputLocations []UploadVODRequestOutputLocation `json:"output_locations,omitempty"`
-	AccessToken     string                           `json:"accessToken"`
-	TranscodeAPIUrl string                           `json:"transcodeAPIUrl"`
-	Encryption      *pipeline.EncryptionPayload      `json:"encryption,omitempty"`
-	C2PA            bool                             `json:"c2pa,omitempty"`
+	ExternalID       string                           `json:"external_id,omitempty"`
+	Url              string                           `json:"url"`
+	CallbackUrl      string                           `json:"callback_url"`
+	OutputLocations  []UploadVODRequestOutputLocation `json:"output_locations,omitempty"`
+	AccessToken      string                           `json:"accessToken"`
+	TranscodeAPIUrl  string                           `json:"transcodeAPIUrl"`
+	Encryption       *pipeline.EncryptionPayload      `json:"encryption,omitempty"`
+	C2PA             bool                             `json:"c2pa,omitempty"`
+	ThumbnailOptions thumbnails.Options               `json:"thumbnail_options,omitempty"`
+	SignedURLTTLSecs int64                            `json:"signed_url_ttl_secs,omitempty"`
 
 	// Forwarded to transcoding stage:
 	TargetSegmentSizeSecs int64                  `json:"target_segment_size_secs"`
 	Profiles              []video.EncodedProfile `json:"profiles"`
 	PipelineStrategy      pipeline.Strategy      `json:"pipeline_strategy"`
+	// LadderConstraints limit the ladder generated when no profiles are given
+	LadderConstraints *video.LadderConstraints `json:"ladder_constraints,omitempty"`
+	// Audio changes the audio of every rendition
+	Audio *video.AudioOptions `json:"audio,omitempty"`
 
 	// Forwarded to clipping stage:
 	ClipStrategy video.ClipStrategy `json:"clip_strategy"`
@@ -82,16 +89,20 @@ func HasContentType(r *http.Request, mimetype string) bool {
 }
 
 func (r UploadVODRequest) IsProfileValid() bool {
+	return isProfileValid(r.Profiles)
+}
+
+func isProfileValid(profiles []video.EncodedProfile) bool {
 	// an empty profile is valid and tells us to use the default ABR ladder
-	if len(r.Profiles) == 0 {
+	if len(profiles) == 0 {
 		return true
 	}
 
 	// a special case where only the bitrate is set which tells us to
 	// generate a profile that matches the input video's specs with the
 	// user specified target bitrate
-	if len(r.Profiles) == 1 {
-		profile := r.Profiles[0]
+	if len(profiles) == 1 {
+		profile := profiles[0]
 		if profile.Width == 0 && profile.Height == 0 {
 			return profile.Bitrate > 0
 		}
@@ -99,7 +110,7 @@ func (r UploadVODRequest) IsProfileValid() bool {
 
 	// verify that width/height/bitrate is provided in cases where the
 	// user wants to use their own transcode profile
-	for _, profile := range r.Profiles {
+	for _, profile := range profiles {
 		if profile.Width == 0 || profile.Height == 0 || profile.Bitrate == 0 {
 			return false
 		}
@@ -113,9 +124,10 @@ func (r UploadVODRequest) IsClippingRequest() bool {
 }
 
 func (r UploadVODRequest) ValidateClippingRequest() error {
-	startTime := r.ClipStrategy.StartTime
-	endTime := r.ClipStrategy.EndTime
+	return validateClipTimes(r.ClipStrategy.StartTime, r.ClipStrategy.EndTime)
+}
 
+func validateClipTimes(startTime, endTime int64) error {
 	if startTime < 0 {
 		return fmt.Errorf("clip start time %d cannot be less than 0", startTime)
 	}
@@ -195,6 +207,10 @@ func (d *CatalystAPIHandlersCollection) UploadVOD() httprouter.Handle {
 func (d *CatalystAPIHandlersCollection) handleUploadVOD(w http.ResponseWriter, req *http.Request, schema *gojsonschema.Schema) (bool, errors.APIError) {
 	var uploadVODRequest UploadVODRequest
 
+	// checked before reading the payload too, so that clients can move on to another node sooner
+	if d.VODEngine.ShuttingDown() {
+		return false, errors.WriteHTTPErrorWithRetry(w, "Node is shutting down", http.StatusServiceUnavailable, nil, true)
+	}
 	if !HasContentType(req, "application/json") {
 		return false, errors.WriteHTTPUnsupportedMediaType(w, "Requires application/json content type", nil)
 	} else if payload, err := io.ReadAll(req.Body); err != nil {
@@ -207,115 +223,11 @@ func (d *CatalystAPIHandlersCollection) handleUploadVOD(w http.ResponseWriter, r
 		return false, errors.WriteHTTPBadRequest(w, "Invalid request payload", err)
 	}
 
-	// Generate a Request ID that will be used throughout all logging
-	var requestID = config.RandomTrailer(8)
-	log.AddContext(requestID, "source", uploadVODRequest.Url, "external_id", uploadVODRequest.ExternalID)
-
-	if err := CheckSourceURLValid(uploadVODRequest.Url); err != nil {
-		return false, errors.WriteHTTPBadRequest(w, "Invalid request payload", err)
-	}
-
-	if !uploadVODRequest.IsProfileValid() {
-		return false, errors.WriteHTTPBadRequest(w, "Invalid request payload", fmt.Errorf("invalid transcode profile requested"))
-	}
-
-	// If the segment size isn't being overridden then use the default
-	if uploadVODRequest.TargetSegmentSizeSecs <= 0 {
-		uploadVODRequest.TargetSegmentSizeSecs = config.DefaultSegmentSizeSecs
-	}
-	// Check that the override isn't too big
-	if uploadVODRequest.TargetSegmentSizeSecs >= config.MaxSegmentSizeSecs {
-		uploadVODRequest.TargetSegmentSizeSecs = config.MaxSegmentSizeSecs
-	}
-	log.AddContext(requestID, "target_segment_size_secs", uploadVODRequest.TargetSegmentSizeSecs)
-
-	// Check if this is a clipping request
-	var clipTargetURL *url.URL
-	var err error
-	if uploadVODRequest.IsClippingRequest() {
-		if err := uploadVODRequest.ValidateClippingRequest(); err != nil {
-			return false, errors.WriteHTTPBadRequest(w, "Invalid Clipping Request", err)
-		}
-
-		clipTargetOutput := uploadVODRequest.getTargetOutput(func(o UploadVODRequestOutputLocationOutputs) string {
-			return o.Clip
-		})
-		clipTargetURL, err = toTargetURL(clipTargetOutput, requestID)
-		if err != nil {
-			return false, errors.WriteHTTPBadRequest(w, "Invalid request payload", err)
-		}
-		if clipTargetURL == nil {
-			return false, errors.WriteHTTPBadRequest(w, "Invalid request payload", fmt.Errorf("clip output location not specified"))
-		}
-		uploadVODRequest.ClipStrategy.Enabled = true
-	}
-
-	// Get target locatons for HLS, MP4, FMP4 outputs
-	hlsTargetOutput := uploadVODRequest.getTargetOutput(func(o UploadVODRequestOutputLocationOutputs) string {
-		return o.HLS
-	})
-	hlsTargetURL, err := toTargetURL(hlsTargetOutput, requestID)
+	requestID, err := d.StartUploadVOD(req.Context(), uploadVODRequest)
 	if err != nil {
-		return false, errors.WriteHTTPBadRequest(w, "Invalid request payload", err)
-	}
-	mp4TargetOutput, mp4OnlyShort := uploadVODRequest.getTargetMp4Output()
-	mp4TargetURL, err := toTargetURL(mp4TargetOutput, requestID)
-	if err != nil {
-		return false, errors.WriteHTTPBadRequest(w, "Invalid request payload", err)
-	}
-	fragMp4TargetOutput := uploadVODRequest.getTargetOutput(func(o UploadVODRequestOutputLocationOutputs) string {
-		return o.FragmentedMP4
-	})
-	fragMp4TargetURL, err := toTargetURL(fragMp4TargetOutput, requestID)
-	if err != nil {
-		return false, errors.WriteHTTPBadRequest(w, "Invalid request payload", err)
-	}
-	if hlsTargetURL == nil && mp4TargetURL == nil && fragMp4TargetURL == nil {
-		return false, errors.WriteHTTPBadRequest(w, "Invalid request payload", errors2.New("none of output enabled: hls or mp4 or f-mp4"))
-	}
-	thumbsTargetOutput := uploadVODRequest.getTargetOutput(func(o UploadVODRequestOutputLocationOutputs) string {
-		return o.Thumbnails
-	})
-	thumbsTargetURL, err := toTargetURL(thumbsTargetOutput, requestID)
-	if err != nil {
-		return false, errors.WriteHTTPBadRequest(w, "Invalid request payload", err)
+		return false, errors.WriteHTTPAPIError(w, err)
 	}
 
-	// Verify pipeline strategy
-	if strat := uploadVODRequest.PipelineStrategy; strat != "" && !strat.IsValid() {
-		return false, errors.WriteHTTPBadRequest(w, "Invalid request payload", fmt.Errorf("invalid value provided for pipeline strategy: %q", uploadVODRequest.PipelineStrategy))
-	}
-
-	if err = checkWritePermission(requestID, uploadVODRequest.ExternalID, hlsTargetURL, mp4TargetURL, fragMp4TargetURL, clipTargetURL, thumbsTargetURL); err != nil {
-		return false, errors.WriteHTTPInternalServerError(w, "Internal error", err)
-	}
-
-	log.Log(requestID, "Received VOD Upload request", "pipeline_strategy", uploadVODRequest.PipelineStrategy, "num_profiles", len(uploadVODRequest.Profiles), "hlsTargetURL", hlsTargetURL)
-
-	// Once we're happy with the request, do the rest of the Segmenting stage asynchronously to allow us to
-	// from the API call and free up the HTTP connection
-	d.VODEngine.StartUploadJob(pipeline.UploadJobPayload{
-		SourceFile:            uploadVODRequest.Url,
-		CallbackURL:           uploadVODRequest.CallbackUrl,
-		HlsTargetURL:          hlsTargetURL,
-		Mp4TargetURL:          mp4TargetURL,
-		FragMp4TargetURL:      fragMp4TargetURL,
-		ClipTargetURL:         clipTargetURL,
-		ThumbnailsTargetURL:   thumbsTargetURL,
-		Mp4OnlyShort:          mp4OnlyShort,
-		AccessToken:           uploadVODRequest.AccessToken,
-		TranscodeAPIUrl:       uploadVODRequest.TranscodeAPIUrl,
-		RequestID:             requestID,
-		ExternalID:            uploadVODRequest.ExternalID,
-		Profiles:              uploadVODRequest.Profiles,
-		PipelineStrategy:      uploadVODRequest.PipelineStrategy,
-		TargetSegmentSizeSecs: uploadVODRequest.TargetSegmentSizeSecs,
-		Encryption:            uploadVODRequest.Encryption,
-		SourceCopy:            uploadVODRequest.getSourceCopyEnabled(),
-		ClipStrategy:          uploadVODRequest.ClipStrategy,
-		C2PA:                  uploadVODRequest.C2PA,
-	})
-
 	respBytes, err := json.Marshal(UploadVODResponse{RequestID: requestID})
 	if err != nil {
 		log.LogError(requestID, "Failed to build a /upload HTTP API response", err)
@@ -330,9 +242,19 @@ func (d *CatalystAPIHandlersCollection) handleUploadVOD(w http.ResponseWriter, r
 	return true, errors.APIError{}
 }
 
+// StartUploadVOD validates the request and starts the VOD job, returning its request ID. The errors are
+// errors.APIError, so that the HTTP and gRPC APIs return the same statuses.
+func (d *CatalystAPIHandlersCollection) StartUploadVOD(ctx context.Context, uploadVODRequest UploadVODRequest) (string, error) {
+	return d.StartUploadVODV2(ctx, uploadVODRequest.ToV2())
+}
+
 func toTargetURL(ol UploadVODRequestOutputLocation, reqID string) (*url.URL, error) {
-	if ol.URL != "" {
-		tURL, err := url.Parse(ol.URL)
+	return parseTargetURL(ol.URL, reqID)
+}
+
+func parseTargetURL(targetURL, reqID string) (*url.URL, error) {
+	if targetURL != "" {
+		tURL, err := url.Parse(targetURL)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,388 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	errors2 "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/clients"
+	"github.com/livepeer/catalyst-api/config"
+	"github.com/livepeer/catalyst-api/crypto"
+	"github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/livepeer/catalyst-api/metrics"
+	"github.com/livepeer/catalyst-api/pipeline"
+	"github.com/livepeer/catalyst-api/thumbnails"
+	"github.com/livepeer/catalyst-api/video"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// UploadVODRequestV2 is the /api/v2/vod payload. Each output is configured explicitly, rather than by flags on a
+// list of output locations, and clipping and encryption have their own sections.
+type UploadVODRequestV2 struct {
+	ExternalID  string               `json:"external_id,omitempty"`
+	Source      UploadVODSourceV2    `json:"source"`
+	CallbackURL string               `json:"callback_url"`
+	Outputs     UploadVODOutputsV2   `json:"outputs"`
+	Transcode   UploadVODTranscodeV2 `json:"transcode,omitempty"`
+	Clip        *UploadVODClipV2     `json:"clip,omitempty"`
+	C2PA        bool                 `json:"c2pa,omitempty"`
+	Studio      UploadVODStudioV2    `json:"studio,omitempty"`
+}
+
+type UploadVODSourceV2 struct {
+	URL string `json:"url"`
+	// Encryption of the source, decrypted when it's copied in
+	Encryption *pipeline.EncryptionPayload `json:"encryption,omitempty"`
+}
+
+type UploadVODOutputsV2 struct {
+	HLS           *UploadVODHLSOutputV2        `json:"hls,omitempty"`
+	MP4           *UploadVODMP4OutputV2        `json:"mp4,omitempty"`
+	FragmentedMP4 *UploadVODOutputV2           `json:"fragmented_mp4,omitempty"`
+	Thumbnails    *UploadVODThumbnailsOutputV2 `json:"thumbnails,omitempty"`
+	// SourceCopy stores a copy of the source next to the HLS output
+	SourceCopy bool `json:"source_copy,omitempty"`
+	// SignedURLTTLSecs adds signed URLs of the manifest and MP4s that expire after it to the completion callback, for
+	// outputs written to a private bucket
+	SignedURLTTLSecs int64 `json:"signed_url_ttl_secs,omitempty"`
+	// Naming templates the paths of the segments, playlists and MP4s
+	Naming *video.OutputNaming `json:"naming,omitempty"`
+}
+
+type UploadVODOutputV2 struct {
+	URL string `json:"url"`
+}
+
+type UploadVODHLSOutputV2 struct {
+	URL string `json:"url"`
+	// Encryption encrypts the segments with the AES-128 key of the asset, served by the key server
+	Encryption *video.HLSEncryption `json:"encryption,omitempty"`
+}
+
+type UploadVODMP4OutputV2 struct {
+	URL string `json:"url"`
+	// OnlyShort only generates MP4s for short sources
+	OnlyShort bool `json:"only_short,omitempty"`
+}
+
+type UploadVODThumbnailsOutputV2 struct {
+	URL     string             `json:"url"`
+	Options thumbnails.Options `json:"options,omitempty"`
+}
+
+type UploadVODTranscodeV2 struct {
+	Profiles              []video.EncodedProfile `json:"profiles,omitempty"`
+	TargetSegmentSizeSecs int64                  `json:"target_segment_size_secs,omitempty"`
+	PipelineStrategy      pipeline.Strategy      `json:"pipeline_strategy,omitempty"`
+	// LadderConstraints limit the ladder generated when no profiles are given
+	LadderConstraints *video.LadderConstraints `json:"ladder_constraints,omitempty"`
+	// Audio changes the audio of every rendition
+	Audio *video.AudioOptions `json:"audio,omitempty"`
+}
+
+// UploadVODClipV2 clips the source, an HLS playlist, to the time range before processing it
+type UploadVODClipV2 struct {
+	URL        string `json:"url"`
+	PlaybackID string `json:"playback_id"`
+	// StartTime and EndTime in milliseconds
+	StartTime int64 `json:"start_time"`
+	EndTime   int64 `json:"end_time"`
+}
+
+// UploadVODStudioV2 is the Studio API access used by the external transcoding pipeline
+type UploadVODStudioV2 struct {
+	AccessToken     string `json:"access_token,omitempty"`
+	TranscodeAPIURL string `json:"transcode_api_url,omitempty"`
+}
+
+// ToV2 maps the /api/vod payload onto the /api/v2/vod one, so that both are processed the same way
+func (r UploadVODRequest) ToV2() UploadVODRequestV2 {
+	v2 := UploadVODRequestV2{
+		ExternalID:  r.ExternalID,
+		Source:      UploadVODSourceV2{URL: r.Url, Encryption: r.Encryption},
+		CallbackURL: r.CallbackUrl,
+		Transcode: UploadVODTranscodeV2{
+			Profiles:              r.Profiles,
+			TargetSegmentSizeSecs: r.TargetSegmentSizeSecs,
+			PipelineStrategy:      r.PipelineStrategy,
+			LadderConstraints:     r.LadderConstraints,
+			Audio:                 r.Audio,
+		},
+		C2PA:   r.C2PA,
+		Studio: UploadVODStudioV2{AccessToken: r.AccessToken, TranscodeAPIURL: r.TranscodeAPIUrl},
+	}
+
+	if o := r.getTargetOutput(func(o UploadVODRequestOutputLocationOutputs) string { return o.HLS }); o.URL != "" {
+		v2.Outputs.HLS = &UploadVODHLSOutputV2{URL: o.URL}
+	}
+	if o, onlyShort := r.getTargetMp4Output(); o.URL != "" {
+		v2.Outputs.MP4 = &UploadVODMP4OutputV2{URL: o.URL, OnlyShort: onlyShort}
+	}
+	if o := r.getTargetOutput(func(o UploadVODRequestOutputLocationOutputs) string { return o.FragmentedMP4 }); o.URL != "" {
+		v2.Outputs.FragmentedMP4 = &UploadVODOutputV2{URL: o.URL}
+	}
+	// the thumbnail options were validated even without a thumbnails output
+	o := r.getTargetOutput(func(o UploadVODRequestOutputLocationOutputs) string { return o.Thumbnails })
+	if o.URL != "" || r.ThumbnailOptions != (thumbnails.Options{}) {
+		v2.Outputs.Thumbnails = &UploadVODThumbnailsOutputV2{URL: o.URL, Options: r.ThumbnailOptions}
+	}
+	v2.Outputs.SourceCopy = r.getSourceCopyEnabled()
+	v2.Outputs.SignedURLTTLSecs = r.SignedURLTTLSecs
+
+	if r.IsClippingRequest() {
+		o := r.getTargetOutput(func(o UploadVODRequestOutputLocationOutputs) string { return o.Clip })
+		v2.Clip = &UploadVODClipV2{
+			URL:        o.URL,
+			PlaybackID: r.ClipStrategy.PlaybackID,
+			StartTime:  r.ClipStrategy.StartTime,
+			EndTime:    r.ClipStrategy.EndTime,
+		}
+	}
+	return v2
+}
+
+func (d *CatalystAPIHandlersCollection) UploadVODV2() httprouter.Handle {
+	schema := inputSchemasCompiled["UploadVODV2"]
+	m := metrics.Metrics
+
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		m.UploadVODRequestCount.Inc()
+
+		startTime := time.Now()
+		success, apiError := d.handleUploadVODV2(w, req, schema)
+
+		status := http.StatusOK
+		if !success {
+			status = apiError.Status
+		}
+		m.UploadVODRequestDurationSec.
+			WithLabelValues(strconv.FormatBool(success), fmt.Sprint(status), config.Version).
+			Observe(time.Since(startTime).Seconds())
+	}
+}
+
+func (d *CatalystAPIHandlersCollection) handleUploadVODV2(w http.ResponseWriter, req *http.Request, schema *gojsonschema.Schema) (bool, errors.APIError) {
+	var uploadVODRequest UploadVODRequestV2
+
+	if d.VODEngine.ShuttingDown() {
+		return false, errors.WriteHTTPErrorWithRetry(w, "Node is shutting down", http.StatusServiceUnavailable, nil, true)
+	}
+	if !HasContentType(req, "application/json") {
+		return false, errors.WriteHTTPUnsupportedMediaType(w, "Requires application/json content type", nil)
+	} else if payload, err := io.ReadAll(req.Body); err != nil {
+		return false, errors.WriteHTTPInternalServerError(w, "Cannot read payload", err)
+	} else if result, err := schema.Validate(gojsonschema.NewBytesLoader(payload)); err != nil {
+		return false, errors.WriteHTTPInternalServerError(w, "Cannot validate payload", err)
+	} else if !result.Valid() {
+		return false, errors.WriteHTTPBadRequest(w, "Invalid request payload", fmt.Errorf("%s", result.Errors()))
+	} else if err := json.Unmarshal(payload, &uploadVODRequest); err != nil {
+		return false, errors.WriteHTTPBadRequest(w, "Invalid request payload", err)
+	}
+
+	requestID, err := d.StartUploadVODV2(req.Context(), uploadVODRequest)
+	if err != nil {
+		return false, errors.WriteHTTPAPIError(w, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(UploadVODResponse{RequestID: requestID}); err != nil {
+		log.LogError(requestID, "Failed to write a /api/v2/vod HTTP API response", err)
+		return false, errors.WriteHTTPInternalServerError(w, "Failed writing response", err)
+	}
+	return true, errors.APIError{}
+}
+
+// StartUploadVODV2 validates the request and starts the VOD job, returning its request ID. The errors are
+// errors.APIError, so that the HTTP and gRPC APIs return the same statuses.
+func (d *CatalystAPIHandlersCollection) StartUploadVODV2(ctx context.Context, r UploadVODRequestV2) (string, error) {
+	if d.VODEngine.ShuttingDown() {
+		return "", errors.NewAPIError("Node is shutting down", http.StatusServiceUnavailable, nil)
+	}
+
+	// Use the ID of the API call as the job's Request ID, so that its logs are correlated with the call, unless it
+	// was already used by another job, e.g. when a caller retries with the same X-Request-ID
+	requestID := log.RequestID(ctx)
+	if _, exists := d.VODEngine.JobStatus(requestID); requestID == "" || exists {
+		requestID = config.RandomTrailer(8)
+	}
+	log.AddContext(requestID, "source", r.Source.URL, "external_id", r.ExternalID)
+	tenant, ok := Tenant(ctx)
+	if ok {
+		log.AddContext(requestID, "tenant", tenant.Name)
+	}
+
+	if err := CheckSourceURLValid(r.Source.URL); err != nil {
+		return "", errors.NewAPIError("Invalid request payload", http.StatusBadRequest, err)
+	}
+
+	if !isProfileValid(r.Transcode.Profiles) {
+		return "", errors.NewAPIError("Invalid request payload", http.StatusBadRequest, fmt.Errorf("invalid transcode profile requested"))
+	}
+	if err := r.Transcode.LadderConstraints.Validate(); err != nil {
+		return "", errors.NewAPIError("Invalid request payload", http.StatusBadRequest, err)
+	}
+	if err := r.Transcode.Audio.Validate(); err != nil {
+		return "", errors.NewAPIError("Invalid request payload", http.StatusBadRequest, err)
+	}
+	if err := r.Outputs.Naming.Validate(); err != nil {
+		return "", errors.NewAPIError("Invalid request payload", http.StatusBadRequest, err)
+	}
+	// the audio options are applied when the source is segmented, which HLS sources and clips aren't
+	if sourceURL, err := url.Parse(r.Source.URL); r.Transcode.Audio != nil && (r.Clip != nil || err == nil && clients.IsHLSInput(sourceURL)) {
+		return "", errors.NewAPIError("Invalid request payload", http.StatusBadRequest, fmt.Errorf("audio options are not supported for HLS sources and clips"))
+	}
+
+	// If the segment size isn't being overridden then use the default
+	targetSegmentSizeSecs := r.Transcode.TargetSegmentSizeSecs
+	if targetSegmentSizeSecs <= 0 {
+		targetSegmentSizeSecs = config.DefaultSegmentSizeSecs
+	}
+	// Check that the override isn't too big
+	if targetSegmentSizeSecs >= config.MaxSegmentSizeSecs {
+		targetSegmentSizeSecs = config.MaxSegmentSizeSecs
+	}
+	log.AddContext(requestID, "target_segment_size_secs", targetSegmentSizeSecs)
+
+	// Check if this is a clipping request
+	var clipStrategy video.ClipStrategy
+	var clipTargetURL *url.URL
+	var err error
+	if r.Clip != nil && r.Clip.PlaybackID != "" {
+		if err := validateClipTimes(r.Clip.StartTime, r.Clip.EndTime); err != nil {
+			return "", errors.NewAPIError("Invalid Clipping Request", http.StatusBadRequest, err)
+		}
+		clipTargetURL, err = parseTargetURL(r.Clip.URL, requestID)
+		if err != nil {
+			return "", errors.NewAPIError("Invalid request payload", http.StatusBadRequest, err)
+		}
+		if clipTargetURL == nil {
+			return "", errors.NewAPIError("Invalid request payload", http.StatusBadRequest, fmt.Errorf("clip output location not specified"))
+		}
+		clipStrategy = video.ClipStrategy{
+			Enabled:    true,
+			StartTime:  r.Clip.StartTime,
+			EndTime:    r.Clip.EndTime,
+			PlaybackID: r.Clip.PlaybackID,
+		}
+	}
+
+	// Get target locations for HLS, MP4, FMP4 outputs
+	var hlsTargetURL, mp4TargetURL, fragMp4TargetURL, thumbsTargetURL *url.URL
+	var mp4OnlyShort bool
+	var thumbnailOptions thumbnails.Options
+	var hlsEncryption *video.HLSEncryption
+	if o := r.Outputs.HLS; o != nil {
+		if hlsTargetURL, err = parseTargetURL(o.URL, requestID); err != nil {
+			return "", errors.NewAPIError("Invalid request payload", http.StatusBadRequest, err)
+		}
+		hlsEncryption = o.Encryption
+	}
+	if o := r.Outputs.MP4; o != nil {
+		if mp4TargetURL, err = parseTargetURL(o.URL, requestID); err != nil {
+			return "", errors.NewAPIError("Invalid request payload", http.StatusBadRequest, err)
+		}
+		mp4OnlyShort = o.OnlyShort
+	}
+	if o := r.Outputs.FragmentedMP4; o != nil {
+		if fragMp4TargetURL, err = parseTargetURL(o.URL, requestID); err != nil {
+			return "", errors.NewAPIError("Invalid request payload", http.StatusBadRequest, err)
+		}
+	}
+	// tenants with an output bucket can leave out the outputs, the HLS output is then written under the request ID
+	if hlsTargetURL == nil && mp4TargetURL == nil && fragMp4TargetURL == nil && tenant.OutputURL != nil {
+		hlsTargetURL = tenant.OutputURL.JoinPath(requestID)
+	}
+	if hlsTargetURL == nil && mp4TargetURL == nil && fragMp4TargetURL == nil {
+		return "", errors.NewAPIError("Invalid request payload", http.StatusBadRequest, errors2.New("none of output enabled: hls or mp4 or f-mp4"))
+	}
+	if r.Outputs.SourceCopy && hlsTargetURL == nil {
+		return "", errors.NewAPIError("Invalid request payload", http.StatusBadRequest, errors2.New("source copy requires an hls output"))
+	}
+	if hlsEncryption != nil {
+		if hlsTargetURL == nil {
+			return "", errors.NewAPIError("Invalid request payload", http.StatusBadRequest, errors2.New("hls encryption requires an hls output"))
+		}
+		if d.VODEngine.HLSKeySecret == nil {
+			return "", errors.NewAPIError("Invalid request payload", http.StatusBadRequest, errors2.New("hls encryption is not enabled on this node"))
+		}
+		// the source copy and the MP4s would be readable without the key
+		if r.Outputs.SourceCopy || mp4TargetURL != nil || fragMp4TargetURL != nil {
+			return "", errors.NewAPIError("Invalid request payload", http.StatusBadRequest, errors2.New("hls encryption can't be combined with source copy or mp4 outputs"))
+		}
+		hlsEncryption = &video.HLSEncryption{
+			PlaybackID: hlsEncryption.PlaybackID,
+			KeyURI:     hlsEncryption.KeyURI,
+			Key:        crypto.DeriveHLSKey(d.VODEngine.HLSKeySecret, hlsEncryption.PlaybackID),
+		}
+	}
+	if o := r.Outputs.Thumbnails; o != nil {
+		if thumbsTargetURL, err = parseTargetURL(o.URL, requestID); err != nil {
+			return "", errors.NewAPIError("Invalid request payload", http.StatusBadRequest, err)
+		}
+		if err := o.Options.Validate(); err != nil {
+			return "", errors.NewAPIError("Invalid request payload", http.StatusBadRequest, err)
+		}
+		thumbnailOptions = o.Options
+	}
+
+	signedURLTTL := time.Duration(r.Outputs.SignedURLTTLSecs) * time.Second
+	if signedURLTTL < 0 || signedURLTTL > clients.MaxSignedURLTTL {
+		return "", errors.NewAPIError("Invalid request payload", http.StatusBadRequest, fmt.Errorf("signed URL TTL must be at most %s", clients.MaxSignedURLTTL))
+	}
+
+	// Verify pipeline strategy
+	if strat := r.Transcode.PipelineStrategy; strat != "" && !strat.IsValid() {
+		return "", errors.NewAPIError("Invalid request payload", http.StatusBadRequest, fmt.Errorf("invalid value provided for pipeline strategy: %q", strat))
+	}
+
+	if err = checkWritePermission(requestID, r.ExternalID, hlsTargetURL, mp4TargetURL, fragMp4TargetURL, clipTargetURL, thumbsTargetURL); err != nil {
+		return "", errors.NewAPIError("Internal error", http.StatusInternalServerError, err)
+	}
+
+	log.Log(requestID, "Received VOD Upload request", "pipeline_strategy", r.Transcode.PipelineStrategy, "num_profiles", len(r.Transcode.Profiles), "hlsTargetURL", hlsTargetURL)
+
+	// Once we're happy with the request, do the rest of the Segmenting stage asynchronously to allow us to
+	// from the API call and free up the HTTP connection
+	d.VODEngine.StartUploadJob(pipeline.UploadJobPayload{
+		SourceFile:            r.Source.URL,
+		CallbackURL:           r.CallbackURL,
+		HlsTargetURL:          hlsTargetURL,
+		Mp4TargetURL:          mp4TargetURL,
+		FragMp4TargetURL:      fragMp4TargetURL,
+		ClipTargetURL:         clipTargetURL,
+		ThumbnailsTargetURL:   thumbsTargetURL,
+		ThumbnailOptions:      thumbnailOptions,
+		Mp4OnlyShort:          mp4OnlyShort,
+		AccessToken:           r.Studio.AccessToken,
+		TranscodeAPIUrl:       r.Studio.TranscodeAPIURL,
+		RequestID:             requestID,
+		ExternalID:            r.ExternalID,
+		Profiles:              r.Transcode.Profiles,
+		DefaultProfiles:       tenant.TranscodeProfiles,
+		LadderConstraints:     r.Transcode.LadderConstraints,
+		AudioOptions:          r.Transcode.Audio,
+		OutputNaming:          r.Outputs.Naming,
+		HLSEncryption:         hlsEncryption,
+		PipelineStrategy:      r.Transcode.PipelineStrategy,
+		TargetSegmentSizeSecs: targetSegmentSizeSecs,
+		Encryption:            r.Source.Encryption,
+		SourceCopy:            r.Outputs.SourceCopy,
+		ClipStrategy:          clipStrategy,
+		C2PA:                  r.C2PA,
+		SignedURLTTL:          signedURLTTL,
+		Caller:                Caller(ctx),
+		Tenant:                tenant.Name,
+		MaxSourceDuration:     tenant.MaxSourceDuration,
+		MaxSourceBytes:        tenant.MaxSourceBytes,
+	})
+
+	return requestID, nil
+}
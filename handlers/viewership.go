@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/livepeer/catalyst-api/streamhealth"
+)
+
+// viewershipInterval is how often the viewership of a stream is pushed, in line with the viewer counts cache
+const viewershipInterval = viewersCacheTTL
+
+// ViewershipUpdate is pushed to the clients of the viewership stream on every interval
+type ViewershipUpdate struct {
+	StreamViewers
+	// Health is nil when the stream isn't known on this node or stream health isn't tracked
+	Health *streamhealth.Health `json:"health,omitempty"`
+}
+
+// ViewershipHandlers push the viewer counts and health of a stream to dashboards as Server-Sent Events, rather than
+// having them poll the viewers and health endpoints
+type ViewershipHandlers struct {
+	Viewers *StreamViewersHandlers
+	// Health is nil when the health of the streams isn't tracked on this node
+	Health   *streamhealth.Tracker
+	Interval time.Duration
+}
+
+func NewViewershipHandlers(viewers *StreamViewersHandlers, health *streamhealth.Tracker) *ViewershipHandlers {
+	return &ViewershipHandlers{Viewers: viewers, Health: health, Interval: viewershipInterval}
+}
+
+// Viewership streams a viewership event with the stream's current viewers and health until the client disconnects
+func (h *ViewershipHandlers) Viewership() httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
+		playbackID := params.ByName("playbackID")
+		if !playbackIDRegex.MatchString(playbackID) {
+			errors.WriteHTTPBadRequest(w, "Invalid playback ID", nil)
+			return
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			errors.WriteHTTPInternalServerError(w, "Streaming is not supported", nil)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		ticker := time.NewTicker(h.Interval)
+		defer ticker.Stop()
+		for {
+			if err := h.writeUpdate(w, req, playbackID); err != nil {
+				log.LogCtx(req.Context(), "failed to write viewership update", "playback_id", playbackID, "err", err)
+				return
+			}
+			flusher.Flush()
+			select {
+			case <-req.Context().Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}
+}
+
+func (h *ViewershipHandlers) writeUpdate(w http.ResponseWriter, req *http.Request, playbackID string) error {
+	viewers, err := h.Viewers.viewers(req.Context(), playbackID)
+	if err != nil {
+		// skip this update, the counts are usually back by the next one
+		log.LogCtx(req.Context(), "failed to count the stream viewers", "playback_id", playbackID, "err", err)
+		return nil
+	}
+	update := ViewershipUpdate{StreamViewers: viewers}
+	if h.Health != nil {
+		if health, err := h.Health.Health(playbackID); err == nil {
+			update.Health = &health
+		}
+	}
+	b, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: viewership\ndata: %s\n\n", b)
+	return err
+}
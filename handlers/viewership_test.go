@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/clients"
+	mockmistclient "github.com/livepeer/catalyst-api/mocks/clients"
+	"github.com/stretchr/testify/require"
+)
+
+func TestViewership(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mist := mockmistclient.NewMockMistAPIClient(ctrl)
+	viewers := NewStreamViewersHandlers(mist, nil, "node-0", "video")
+	h := &ViewershipHandlers{Viewers: viewers, Interval: 10 * time.Millisecond}
+	router := httprouter.New()
+	router.GET("/api/stream/:playbackID/viewership", h.Viewership())
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	mist.EXPECT().GetSessions("video+abc123").Return([]clients.MistSession{{ID: "1", Protocol: "HLS"}}, nil).MinTimes(1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL+"/api/stream/abc123/viewership", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// the updates keep coming, served from the viewer counts cache
+	scanner := bufio.NewScanner(resp.Body)
+	var updates []ViewershipUpdate
+	for len(updates) < 2 && scanner.Scan() {
+		line := scanner.Text()
+		if data, ok := strings.CutPrefix(line, "data: "); ok {
+			var update ViewershipUpdate
+			require.NoError(t, json.Unmarshal([]byte(data), &update))
+			updates = append(updates, update)
+		} else if line != "" {
+			require.Equal(t, "event: viewership", line)
+		}
+	}
+	require.Len(t, updates, 2)
+	for _, update := range updates {
+		require.Equal(t, "abc123", update.PlaybackID)
+		require.Equal(t, 1, update.Viewers)
+		require.Nil(t, update.Health)
+	}
+
+	rr := httptest.NewRecorder()
+	badReq, _ := http.NewRequest("GET", "/api/stream/abc$123/viewership", nil)
+	router.ServeHTTP(rr, badReq)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
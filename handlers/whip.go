@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/clients"
+	"github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/handlers/misttriggers"
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/livepeer/catalyst-api/requests"
+)
+
+const sdpContentType = "application/sdp"
+
+// WHIPHandler accepts WebRTC contributions from browsers with WHIP (https://www.rfc-editor.org/rfc/rfc9725).
+// The stream key is checked in the same way as an RTMP push, by the PUSH_REWRITE trigger handlers, and the SDP offer
+// is then passed on to Mist's WebRTC output for the stream it was rewritten to.
+type WHIPHandler struct {
+	broker misttriggers.TriggerBroker
+	mist   clients.MistAPIClient
+	// mistHTTPURL is the base URL of Mist's HTTP output, e.g. http://127.0.0.1:8080
+	mistHTTPURL  string
+	streamSource string
+	client       *http.Client
+}
+
+func NewWHIPHandler(broker misttriggers.TriggerBroker, mist clients.MistAPIClient, mistHTTPURL, streamSource string) *WHIPHandler {
+	return &WHIPHandler{
+		broker:       broker,
+		mist:         mist,
+		mistHTTPURL:  mistHTTPURL,
+		streamSource: streamSource,
+		client:       &http.Client{Timeout: clients.MistClientTimeout},
+	}
+}
+
+func (h *WHIPHandler) Handle(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
+	requestID := requests.GetRequestId(req)
+	streamKey := params.ByName("streamKey")
+
+	if mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type")); err != nil || mediaType != sdpContentType {
+		errors.WriteHTTPUnsupportedMediaType(w, "WHIP offers must be "+sdpContentType, err)
+		return
+	}
+	offer, err := io.ReadAll(req.Body)
+	if err != nil {
+		errors.WriteHTTPBadRequest(w, "Cannot read SDP offer", err)
+		return
+	}
+	if len(offer) == 0 {
+		errors.WriteHTTPBadRequest(w, "Empty SDP offer", nil)
+		return
+	}
+
+	clientIP, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		clientIP = req.RemoteAddr
+	}
+	pushURL := &url.URL{Scheme: "whip", Host: req.Host, Path: req.URL.Path}
+	streamName, err := h.broker.TriggerPushRewrite(req.Context(), &misttriggers.PushRewritePayload{
+		FullURL:    pushURL.String(),
+		URL:        pushURL,
+		Hostname:   clientIP,
+		StreamName: streamKey,
+	})
+	if err != nil {
+		errors.WriteHTTPInternalServerError(w, "Cannot check stream key", err)
+		return
+	}
+	if streamName == "" {
+		log.Log(requestID, "rejected WHIP push with an invalid stream key")
+		errors.WriteHTTPForbidden(w, "Invalid stream key", nil)
+		return
+	}
+
+	// Streams named after a wildcard base stream (e.g. video+<playbackID>) are created by Mist from the base stream's
+	// config, other streams need adding before they can be pushed to
+	if !strings.Contains(streamName, "+") {
+		if err := h.mist.AddStream(streamName, h.streamSource); err != nil {
+			errors.WriteHTTPInternalServerError(w, "Cannot create Mist stream", err)
+			return
+		}
+	}
+
+	answer, err := h.sendOffer(req, streamName, offer)
+	if err != nil {
+		errors.WriteHTTPInternalServerError(w, "Cannot send SDP offer to Mist", err)
+		return
+	}
+	defer answer.Body.Close()
+	if answer.StatusCode != http.StatusOK && answer.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(answer.Body)
+		errors.WriteHTTPInternalServerError(w, "Mist rejected the SDP offer", fmt.Errorf("mist returned HTTP %d: %s", answer.StatusCode, body))
+		return
+	}
+	log.Log(requestID, "started WHIP push", "stream", streamName)
+
+	w.Header().Set("Content-Type", sdpContentType)
+	w.Header().Set("Location", req.URL.Path)
+	if etag := answer.Header.Get("ETag"); etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	w.WriteHeader(http.StatusCreated)
+	if _, err := io.Copy(w, answer.Body); err != nil {
+		log.LogError(requestID, "failed to write WHIP answer", err)
+	}
+}
+
+func (h *WHIPHandler) sendOffer(req *http.Request, streamName string, offer []byte) (*http.Response, error) {
+	mistReq, err := http.NewRequestWithContext(req.Context(), http.MethodPost, h.mistHTTPURL+"/webrtc/"+url.PathEscape(streamName), bytes.NewReader(offer))
+	if err != nil {
+		return nil, err
+	}
+	mistReq.Header.Set("Content-Type", sdpContentType)
+	return h.client.Do(mistReq)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/clients"
+	"github.com/livepeer/catalyst-api/handlers/misttriggers"
+	"github.com/stretchr/testify/require"
+)
+
+type whipMistClient struct {
+	clients.MistAPIClient
+	added map[string]string
+}
+
+func (m *whipMistClient) AddStream(streamName, sourceUrl string) error {
+	m.added[streamName] = sourceUrl
+	return nil
+}
+
+func TestWHIPHandler(t *testing.T) {
+	var offers []string
+	mistServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "application/sdp", r.Header.Get("Content-Type"))
+		offer, _ := io.ReadAll(r.Body)
+		offers = append(offers, r.URL.Path+" "+string(offer))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("v=0 answer"))
+	}))
+	defer mistServer.Close()
+
+	broker := misttriggers.NewTriggerBroker()
+	broker.OnPushRewrite(func(ctx context.Context, p *misttriggers.PushRewritePayload) (string, error) {
+		require.Equal(t, "whip", p.URL.Scheme)
+		switch p.StreamName {
+		case "wildcard-key":
+			return "video+abc123", nil
+		case "key":
+			return "abc123", nil
+		}
+		return "", nil
+	})
+	mist := &whipMistClient{added: map[string]string{}}
+	router := httprouter.New()
+	router.POST("/webrtc/whip/:streamKey", NewWHIPHandler(broker, mist, mistServer.URL, "push://").Handle)
+
+	whip := func(streamKey, contentType string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/webrtc/whip/"+streamKey, strings.NewReader("v=0 offer"))
+		req.Header.Set("Content-Type", contentType)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := whip("wildcard-key", "application/sdp")
+	require.Equal(t, http.StatusCreated, rr.Code)
+	require.Equal(t, "v=0 answer", rr.Body.String())
+	require.Equal(t, "application/sdp", rr.Header().Get("Content-Type"))
+	require.Equal(t, "/webrtc/whip/wildcard-key", rr.Header().Get("Location"))
+	require.Empty(t, mist.added, "wildcard streams are created by Mist")
+
+	rr = whip("key", "application/sdp")
+	require.Equal(t, http.StatusCreated, rr.Code)
+	require.Equal(t, map[string]string{"abc123": "push://"}, mist.added)
+	require.Equal(t, []string{"/webrtc/video+abc123 v=0 offer", "/webrtc/abc123 v=0 offer"}, offers)
+
+	require.Equal(t, http.StatusForbidden, whip("unknown-key", "application/sdp").Code)
+	require.Equal(t, http.StatusUnsupportedMediaType, whip("key", "application/json").Code)
+	require.Len(t, offers, 2)
+}
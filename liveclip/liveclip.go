@@ -0,0 +1,85 @@
+package liveclip
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/livepeer/catalyst-api/clients"
+)
+
+// Clipper finds the HLS playlist of a live stream's buffer on this node, so that part of it can be clipped by the
+// VOD pipeline. Only the node ingesting the stream has its whole DVR window, other nodes only have what they've
+// buffered since they started pulling it.
+type Clipper struct {
+	mist           clients.MistAPIClient
+	mistHTTPURL    string
+	baseStreamName string
+}
+
+// NewClipper creates a clipper reading streams from the Mist HTTP output at mistHTTPURL, e.g. http://127.0.0.1:8080
+func NewClipper(mist clients.MistAPIClient, mistHTTPURL, baseStreamName string) *Clipper {
+	return &Clipper{
+		mist:           mist,
+		mistHTTPURL:    strings.TrimSuffix(mistHTTPURL, "/"),
+		baseStreamName: baseStreamName,
+	}
+}
+
+// SourceURL returns the media playlist of the highest bitrate video and audio tracks of a live stream
+func (c *Clipper) SourceURL(playbackID string) (string, error) {
+	streamName := c.baseStreamName + "+" + playbackID
+	info, err := c.mist.GetStreamInfo(streamName)
+	if err != nil {
+		return "", fmt.Errorf("error getting stream info: %w", err)
+	}
+	tracks := selectTracks(info.Meta.Tracks)
+	if tracks == "" {
+		return "", fmt.Errorf("stream %s has no video or audio tracks", streamName)
+	}
+	return fmt.Sprintf("%s/hls/%s/%s/index.m3u8", c.mistHTTPURL, streamName, tracks), nil
+}
+
+// selectTracks returns the Mist HLS path selecting the highest bitrate video and audio tracks, e.g. 1_2
+func selectTracks(tracks map[string]clients.MistStreamInfoTrack) string {
+	var video, audio *clients.MistStreamInfoTrack
+	for _, t := range tracks {
+		t := t
+		switch t.Type {
+		case "video":
+			if video == nil || t.Bps > video.Bps {
+				video = &t
+			}
+		case "audio":
+			if audio == nil || t.Bps > audio.Bps {
+				audio = &t
+			}
+		}
+	}
+	var idx []string
+	for _, t := range []*clients.MistStreamInfoTrack{video, audio} {
+		if t != nil {
+			idx = append(idx, fmt.Sprint(t.Idx))
+		}
+	}
+	return strings.Join(idx, "_")
+}
+
+// TimeRange returns the range to clip in unix milliseconds, either set explicitly or as the last lastSecs of the stream
+func TimeRange(now time.Time, startTime, endTime, lastSecs int64) (int64, int64, error) {
+	if lastSecs < 0 {
+		return 0, 0, errors.New("last_secs can't be negative")
+	}
+	if lastSecs == 0 {
+		if startTime == 0 && endTime == 0 {
+			return 0, 0, errors.New("either start_time and end_time or last_secs must be set")
+		}
+		return startTime, endTime, nil
+	}
+	if startTime != 0 || endTime != 0 {
+		return 0, 0, errors.New("last_secs can't be set with start_time and end_time")
+	}
+	end := now.UnixMilli()
+	return end - lastSecs*1000, end, nil
+}
@@ -0,0 +1,63 @@
+package liveclip
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/livepeer/catalyst-api/clients"
+	mockmistclient "github.com/livepeer/catalyst-api/mocks/clients"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceURL(t *testing.T) {
+	mist := mockmistclient.NewMockMistAPIClient(gomock.NewController(t))
+	c := NewClipper(mist, "http://127.0.0.1:8080/", "video")
+
+	mist.EXPECT().GetStreamInfo("video+abc123").Return(clients.MistStreamInfo{
+		Meta: clients.MistStreamInfoMetadata{Tracks: map[string]clients.MistStreamInfoTrack{
+			"video_H264_1280x720_30fps_0":  {Type: "video", Idx: 0, Bps: 500000},
+			"video_H264_1920x1080_30fps_1": {Type: "video", Idx: 1, Bps: 1000000},
+			"audio_AAC_2ch_48000hz_2":      {Type: "audio", Idx: 2, Bps: 16000},
+			"meta_JSON_3":                  {Type: "meta", Idx: 3},
+		}},
+	}, nil)
+	u, err := c.SourceURL("abc123")
+	require.NoError(t, err)
+	require.Equal(t, "http://127.0.0.1:8080/hls/video+abc123/1_2/index.m3u8", u)
+
+	mist.EXPECT().GetStreamInfo("video+audioonly").Return(clients.MistStreamInfo{
+		Meta: clients.MistStreamInfoMetadata{Tracks: map[string]clients.MistStreamInfoTrack{
+			"audio_AAC_2ch_48000hz_0": {Type: "audio", Idx: 0, Bps: 16000},
+		}},
+	}, nil)
+	u, err = c.SourceURL("audioonly")
+	require.NoError(t, err)
+	require.Equal(t, "http://127.0.0.1:8080/hls/video+audioonly/0/index.m3u8", u)
+
+	mist.EXPECT().GetStreamInfo("video+offline").Return(clients.MistStreamInfo{}, fmt.Errorf("Stream is offline"))
+	_, err = c.SourceURL("offline")
+	require.Error(t, err)
+}
+
+func TestTimeRange(t *testing.T) {
+	now := time.UnixMilli(1700000060000)
+
+	start, end, err := TimeRange(now, 0, 0, 30)
+	require.NoError(t, err)
+	require.Equal(t, int64(1700000030000), start)
+	require.Equal(t, int64(1700000060000), end)
+
+	start, end, err = TimeRange(now, 1700000000000, 1700000010000, 0)
+	require.NoError(t, err)
+	require.Equal(t, int64(1700000000000), start)
+	require.Equal(t, int64(1700000010000), end)
+
+	_, _, err = TimeRange(now, 0, 0, 0)
+	require.Error(t, err)
+	_, _, err = TimeRange(now, 0, 0, -1)
+	require.Error(t, err)
+	_, _, err = TimeRange(now, 1700000000000, 1700000010000, 30)
+	require.Error(t, err)
+}
@@ -0,0 +1,267 @@
+package liveprofiles
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/livepeer/catalyst-api/clients"
+	"github.com/livepeer/catalyst-api/handlers/misttriggers"
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/livepeer/catalyst-api/video"
+)
+
+// livepeerProcess is the Mist process transcoding the live streams with the broadcasters
+const livepeerProcess = "Livepeer"
+
+// Manager applies the live transcoding ladder of each stream to the Livepeer process of its Mist stream config, in
+// place of the ladder of the wildcard stream it's based on. The ladders are loaded from the store when the streams
+// start on their ingest node and when they're refreshed, so that premium streams can get more renditions.
+type Manager struct {
+	store          Store
+	mist           clients.MistAPIClient
+	baseStreamName string
+	streamSource   string
+
+	// Mist is the client the ladders are applied through, wrapped by the DVR and metadata managers so that the
+	// streams keep their other settings. The client the Manager was created with is used if nil.
+	Mist clients.MistAPIClient
+
+	mu       sync.Mutex
+	profiles map[string][]video.EncodedProfile // playback ID to ladder, for the streams started on this node
+	started  map[string]bool                   // stream names the ladder was applied to since they started
+}
+
+func NewManager(store Store, mist clients.MistAPIClient, baseStreamName, streamSource string) *Manager {
+	return &Manager{
+		store:          store,
+		mist:           mist,
+		baseStreamName: baseStreamName,
+		streamSource:   streamSource,
+		profiles:       map[string][]video.EncodedProfile{},
+		started:        map[string]bool{},
+	}
+}
+
+// ValidateProfiles checks a ladder before it's stored
+func ValidateProfiles(profiles []video.EncodedProfile) error {
+	if len(profiles) == 0 {
+		return fmt.Errorf("the ladder needs at least one profile")
+	}
+	return video.ValidateLadder(profiles)
+}
+
+// Get returns the ladder set for a stream, ErrNotFound if it uses the ladder of its Mist config
+func (m *Manager) Get(ctx context.Context, playbackID string) ([]video.EncodedProfile, error) {
+	return m.store.Get(ctx, playbackID)
+}
+
+// Set stores the ladder of a stream, applied to the stream when it's next started or refreshed
+func (m *Manager) Set(ctx context.Context, playbackID string, profiles []video.EncodedProfile) error {
+	if err := ValidateProfiles(profiles); err != nil {
+		return err
+	}
+	return m.store.Set(ctx, playbackID, profiles)
+}
+
+// Delete removes the ladder of a stream, which gets the ladder of its Mist config back when it's next started or
+// refreshed
+func (m *Manager) Delete(ctx context.Context, playbackID string) error {
+	return m.store.Delete(ctx, playbackID)
+}
+
+// Refresh reloads the ladder of a stream from the store, applying it if the stream is ingested on this node
+func (m *Manager) Refresh(ctx context.Context, playbackID string) error {
+	profiles, err := m.load(ctx, playbackID)
+	if err != nil {
+		return err
+	}
+	streamName := m.streamName(playbackID)
+	state, err := m.mist.GetState()
+	if err != nil {
+		return fmt.Errorf("error getting Mist state: %w", err)
+	}
+	if _, ok := state.ActiveStreams[streamName]; !ok || !state.IsIngestStream(streamName) {
+		return nil
+	}
+	return m.apply(streamName, profiles)
+}
+
+// HandleStreamBuffer applies the ladder of the streams as they start on their ingest node
+func (m *Manager) HandleStreamBuffer(ctx context.Context, payload *misttriggers.StreamBufferPayload) error {
+	playbackID, ok := m.playbackID(payload.StreamName)
+	if !ok {
+		return nil
+	}
+	m.mu.Lock()
+	if payload.IsEmpty() {
+		delete(m.started, payload.StreamName)
+		delete(m.profiles, playbackID)
+	}
+	started := m.started[payload.StreamName]
+	m.mu.Unlock()
+	if started || !payload.IsFull() {
+		return nil
+	}
+
+	state, err := m.mist.GetState()
+	if err != nil {
+		return fmt.Errorf("error getting Mist state: %w", err)
+	}
+	if !state.IsIngestStream(payload.StreamName) {
+		// the playback nodes serve the renditions transcoded on the ingest node
+		return nil
+	}
+	m.mu.Lock()
+	m.started[payload.StreamName] = true
+	m.mu.Unlock()
+	profiles, err := m.load(ctx, playbackID)
+	if err != nil || profiles == nil {
+		return err
+	}
+	return m.apply(payload.StreamName, profiles)
+}
+
+// MistClient wraps a Mist client so that the streams it adds keep their ladder
+func (m *Manager) MistClient(mist clients.MistAPIClient) clients.MistAPIClient {
+	return &mistClient{MistAPIClient: mist, m: m}
+}
+
+type mistClient struct {
+	clients.MistAPIClient
+	m *Manager
+}
+
+func (c *mistClient) AddStream(streamName, sourceUrl string) error {
+	if _, ok := c.m.playbackID(streamName); !ok {
+		return c.MistAPIClient.AddStream(streamName, sourceUrl)
+	}
+	return c.AddStreamWithSettings(streamName, clients.Stream{Source: sourceUrl})
+}
+
+func (c *mistClient) AddStreamWithDVR(streamName, sourceUrl string, dvrWindow time.Duration) error {
+	if _, ok := c.m.playbackID(streamName); !ok {
+		return c.MistAPIClient.AddStreamWithDVR(streamName, sourceUrl, dvrWindow)
+	}
+	return c.AddStreamWithSettings(streamName, clients.Stream{Source: sourceUrl, DVR: dvrWindow.Milliseconds()})
+}
+
+func (c *mistClient) AddStreamWithSettings(streamName string, stream clients.Stream) error {
+	if playbackID, ok := c.m.playbackID(streamName); ok {
+		c.m.mu.Lock()
+		profiles := c.m.profiles[playbackID]
+		c.m.mu.Unlock()
+		processes, err := c.m.processes(profiles)
+		if err != nil {
+			return err
+		}
+		stream.Processes = processes
+	}
+	return c.MistAPIClient.AddStreamWithSettings(streamName, stream)
+}
+
+func (m *Manager) load(ctx context.Context, playbackID string) ([]video.EncodedProfile, error) {
+	profiles, err := m.store.Get(ctx, playbackID)
+	if errors.Is(err, ErrNotFound) {
+		profiles = nil
+	} else if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if profiles == nil {
+		delete(m.profiles, playbackID)
+	} else {
+		m.profiles[playbackID] = profiles
+	}
+	return profiles, nil
+}
+
+// apply re-adds the stream to the Mist config, keeping its source, with the processes of the wildcard stream
+// transcoding it with its own ladder
+func (m *Manager) apply(streamName string, profiles []video.EncodedProfile) error {
+	streams, err := m.mist.GetStreams()
+	if err != nil {
+		return fmt.Errorf("error getting Mist streams: %w", err)
+	}
+	source := m.streamSource
+	if stream, ok := streams[streamName]; ok && stream.Source != "" {
+		source = stream.Source
+	}
+	mist := m.Mist
+	if mist == nil {
+		mist = m.MistClient(m.mist)
+	}
+	if err := mist.AddStream(streamName, source); err != nil {
+		return fmt.Errorf("error applying live transcode profiles: %w", err)
+	}
+	log.LogNoRequestID("live transcode profiles applied", log.KeyStream, streamName, "profiles", len(profiles))
+	return nil
+}
+
+// processes returns the processes of the wildcard stream, with the ladder of the Livepeer process replaced by
+// profiles if set
+func (m *Manager) processes(profiles []video.EncodedProfile) ([]map[string]interface{}, error) {
+	streams, err := m.mist.GetStreams()
+	if err != nil {
+		return nil, fmt.Errorf("error getting Mist streams: %w", err)
+	}
+	base := streams[m.baseStreamName].Processes
+	if profiles == nil {
+		return base, nil
+	}
+
+	var processes []map[string]interface{}
+	hasLivepeer := false
+	for _, p := range base {
+		process := map[string]interface{}{}
+		for k, v := range p {
+			process[k] = v
+		}
+		if process["process"] == livepeerProcess {
+			process["target_profiles"] = targetProfiles(profiles)
+			hasLivepeer = true
+		}
+		processes = append(processes, process)
+	}
+	if !hasLivepeer {
+		processes = append(processes, map[string]interface{}{"process": livepeerProcess, "target_profiles": targetProfiles(profiles)})
+	}
+	return processes, nil
+}
+
+// targetProfiles is the ladder in the format of the Livepeer process config
+func targetProfiles(profiles []video.EncodedProfile) []map[string]interface{} {
+	var targets []map[string]interface{}
+	for _, p := range profiles {
+		target := map[string]interface{}{
+			"name":    p.Name,
+			"width":   p.Width,
+			"height":  p.Height,
+			"bitrate": p.Bitrate,
+			"fps":     p.FPS,
+		}
+		if p.FPSDen != 0 {
+			target["fpsDen"] = p.FPSDen
+		}
+		if p.Profile != "" {
+			target["profile"] = p.Profile
+		}
+		if p.GOP != "" {
+			target["gop"] = p.GOP
+		}
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+func (m *Manager) playbackID(streamName string) (string, bool) {
+	return strings.CutPrefix(streamName, m.baseStreamName+"+")
+}
+
+func (m *Manager) streamName(playbackID string) string {
+	return m.baseStreamName + "+" + playbackID
+}
@@ -0,0 +1,132 @@
+package liveprofiles
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang/mock/gomock"
+	"github.com/livepeer/catalyst-api/clients"
+	"github.com/livepeer/catalyst-api/handlers/misttriggers"
+	mockmistclient "github.com/livepeer/catalyst-api/mocks/clients"
+	"github.com/livepeer/catalyst-api/video"
+	"github.com/stretchr/testify/require"
+)
+
+var premium = []video.EncodedProfile{
+	{Name: "360p0", Width: 640, Height: 360, Bitrate: 1_000_000, FPS: 30},
+	{Name: "720p0", Width: 1280, Height: 720, Bitrate: 3_000_000, FPS: 30},
+	{Name: "1080p0", Width: 1920, Height: 1080, Bitrate: 6_000_000, FPS: 30, Profile: "H264High"},
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	_, err := store.Get(ctx, "abc123")
+	require.ErrorIs(t, err, ErrNotFound)
+	require.NoError(t, store.Set(ctx, "abc123", premium))
+	profiles, err := store.Get(ctx, "abc123")
+	require.NoError(t, err)
+	require.Equal(t, premium, profiles)
+	require.NoError(t, store.Delete(ctx, "abc123"))
+	require.ErrorIs(t, store.Delete(ctx, "abc123"), ErrNotFound)
+}
+
+func TestDBStore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+	store := NewStore(db)
+
+	mock.ExpectExec(`insert into "live_transcode_profiles"`).
+		WithArgs("abc123", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	require.NoError(t, store.Set(ctx, "abc123", premium[:1]))
+
+	mock.ExpectQuery(`select "profiles" from "live_transcode_profiles" where "playback_id" = \$1`).
+		WithArgs("abc123").
+		WillReturnRows(sqlmock.NewRows([]string{"profiles"}).AddRow(`[{"name":"360p0","width":640,"height":360,"bitrate":1000000,"fps":30}]`))
+	profiles, err := store.Get(ctx, "abc123")
+	require.NoError(t, err)
+	require.Equal(t, premium[:1], profiles)
+
+	mock.ExpectQuery(`select "profiles" from "live_transcode_profiles"`).
+		WithArgs("other").
+		WillReturnRows(sqlmock.NewRows([]string{"profiles"}))
+	_, err = store.Get(ctx, "other")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	mock.ExpectExec(`delete from "live_transcode_profiles" where "playback_id" = \$1`).
+		WithArgs("abc123").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	require.ErrorIs(t, store.Delete(ctx, "abc123"), ErrNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestValidateProfiles(t *testing.T) {
+	require.NoError(t, ValidateProfiles(premium))
+	require.Error(t, ValidateProfiles(nil))
+	require.Error(t, ValidateProfiles([]video.EncodedProfile{{Name: "360p0", Bitrate: 1_000_000}}))
+}
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+	mist := mockmistclient.NewMockMistAPIClient(gomock.NewController(t))
+	store := NewMemoryStore()
+	m := NewManager(store, mist, "video", "push://")
+
+	streams := map[string]clients.Stream{
+		"video": {Source: "push://", Processes: []map[string]interface{}{
+			{"process": "Livepeer", "target_profiles": []interface{}{"360p0"}, "leastlive": "1"},
+			{"process": "AV", "codec": "opus"},
+		}},
+	}
+	ingest := clients.MistState{ActiveStreams: map[string]*clients.ActiveStream{"video+abc123": {Source: "push://"}}}
+	mist.EXPECT().GetStreams().Return(streams, nil).AnyTimes()
+	mist.EXPECT().GetState().Return(ingest, nil).AnyTimes()
+
+	// streams without a ladder aren't touched when they start
+	full := &misttriggers.StreamBufferPayload{StreamName: "video+abc123", State: "FULL"}
+	require.NoError(t, m.HandleStreamBuffer(ctx, full))
+
+	require.NoError(t, m.Set(ctx, "abc123", premium))
+	require.NoError(t, m.HandleStreamBuffer(ctx, full), "the stream has already started")
+	expected := clients.Stream{Source: "push://", Processes: []map[string]interface{}{
+		{"process": "Livepeer", "target_profiles": targetProfiles(premium), "leastlive": "1"},
+		{"process": "AV", "codec": "opus"},
+	}}
+	mist.EXPECT().AddStreamWithSettings("video+abc123", expected).Return(nil)
+	require.NoError(t, m.Refresh(ctx, "abc123"))
+
+	// the ladder is applied when the stream starts again
+	require.NoError(t, m.HandleStreamBuffer(ctx, &misttriggers.StreamBufferPayload{StreamName: "video+abc123", State: "EMPTY"}))
+	mist.EXPECT().AddStreamWithSettings("video+abc123", expected).Return(nil)
+	require.NoError(t, m.HandleStreamBuffer(ctx, full))
+
+	// the streams added for ingest keep their ladder
+	expected.Source = "push://?passphrase=x"
+	mist.EXPECT().AddStreamWithSettings("video+abc123", expected).Return(nil)
+	require.NoError(t, m.MistClient(mist).AddStream("video+abc123", "push://?passphrase=x"))
+
+	// deleting the ladder restores the one of the wildcard stream
+	require.NoError(t, m.Delete(ctx, "abc123"))
+	mist.EXPECT().AddStreamWithSettings("video+abc123", clients.Stream{Source: "push://", Processes: streams["video"].Processes}).Return(nil)
+	require.NoError(t, m.Refresh(ctx, "abc123"))
+
+	// streams pulled for playback are transcoded on their ingest node
+	playback := &misttriggers.StreamBufferPayload{StreamName: "video+other", State: "FULL"}
+	require.NoError(t, store.Set(ctx, "other", premium))
+	require.NoError(t, m.HandleStreamBuffer(ctx, playback))
+}
+
+func TestCreateTable(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec(`create table if not exists "live_transcode_profiles"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	require.NoError(t, CreateTable(context.Background(), db))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,132 @@
+package liveprofiles
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/livepeer/catalyst-api/video"
+)
+
+const tableName = "live_transcode_profiles"
+
+var ErrNotFound = errors.New("stream has no live transcode profiles")
+
+// Store holds the live transcoding ladder set for each stream, keyed by playback ID
+type Store interface {
+	Get(ctx context.Context, playbackID string) ([]video.EncodedProfile, error)
+	Set(ctx context.Context, playbackID string, profiles []video.EncodedProfile) error
+	// Delete removes the ladder of a stream, so that it's transcoded with the ladder of its Mist config again
+	Delete(ctx context.Context, playbackID string) error
+}
+
+// NewStore returns a store persisted to Postgres if a DB is configured, otherwise falls back to an in-memory store
+// that only covers the lifetime of this process and this node
+func NewStore(db *sql.DB) Store {
+	if db != nil {
+		return &dbStore{db: db}
+	}
+	return NewMemoryStore()
+}
+
+// CreateTable creates the table of the live transcode profiles in the metrics DB if it doesn't exist
+func CreateTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `create table if not exists "`+tableName+`" (
+		"playback_id"   text primary key,
+		"profiles"      text not null,
+		"updated_at_ms" bigint not null
+	)`)
+	if err != nil {
+		return fmt.Errorf("error creating live transcode profiles table: %w", err)
+	}
+	return nil
+}
+
+type dbStore struct {
+	db *sql.DB
+}
+
+func (s *dbStore) Get(ctx context.Context, playbackID string) ([]video.EncodedProfile, error) {
+	var data string
+	err := s.db.QueryRowContext(ctx, `select "profiles" from "`+tableName+`" where "playback_id" = $1`, playbackID).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting live transcode profiles: %w", err)
+	}
+	var profiles []video.EncodedProfile
+	if err := json.Unmarshal([]byte(data), &profiles); err != nil {
+		return nil, fmt.Errorf("error parsing live transcode profiles: %w", err)
+	}
+	return profiles, nil
+}
+
+func (s *dbStore) Set(ctx context.Context, playbackID string, profiles []video.EncodedProfile) error {
+	data, err := json.Marshal(profiles)
+	if err != nil {
+		return fmt.Errorf("error encoding live transcode profiles: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx,
+		`insert into "`+tableName+`"("playback_id", "profiles", "updated_at_ms") values($1, $2, $3) on conflict ("playback_id") do update set "profiles" = $2, "updated_at_ms" = $3`,
+		playbackID, string(data), time.Now().UnixMilli(),
+	)
+	if err != nil {
+		return fmt.Errorf("error setting live transcode profiles: %w", err)
+	}
+	return nil
+}
+
+func (s *dbStore) Delete(ctx context.Context, playbackID string) error {
+	res, err := s.db.ExecContext(ctx, `delete from "`+tableName+`" where "playback_id" = $1`, playbackID)
+	if err != nil {
+		return fmt.Errorf("error deleting live transcode profiles: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("error deleting live transcode profiles: %w", err)
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// MemoryStore keeps the ladders in memory, so they're lost on restart and only applied by this node
+type MemoryStore struct {
+	mu       sync.Mutex
+	profiles map[string][]video.EncodedProfile
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{profiles: map[string][]video.EncodedProfile{}}
+}
+
+func (m *MemoryStore) Get(_ context.Context, playbackID string) ([]video.EncodedProfile, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	profiles, ok := m.profiles[playbackID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return profiles, nil
+}
+
+func (m *MemoryStore) Set(_ context.Context, playbackID string, profiles []video.EncodedProfile) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.profiles[playbackID] = profiles
+	return nil
+}
+
+func (m *MemoryStore) Delete(_ context.Context, playbackID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.profiles[playbackID]; !ok {
+		return ErrNotFound
+	}
+	delete(m.profiles, playbackID)
+	return nil
+}
@@ -42,15 +42,6 @@ func V(level glog.Level) *VerboseLogger {
 	return &VerboseLogger{level: level}
 }
 
-func (m metadata) Flat() []any {
-	out := []any{}
-	for k, v := range m {
-		out = append(out, k)
-		out = append(out, v)
-	}
-	return out
-}
-
 // Return a new context, adding in the provided values to the logging metadata
 func WithLogValues(ctx context.Context, args ...string) context.Context {
 	oldMetadata, _ := ctx.Value(clogContextKey).(metadata)
@@ -71,17 +62,32 @@ func WithLogValues(ctx context.Context, args ...string) context.Context {
 	return context.WithValue(ctx, clogContextKey, newMetadata)
 }
 
+// WithRequestID returns a new context carrying the request ID, which LogCtx adds to every log line
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return WithLogValues(ctx, KeyRequestID, requestID)
+}
+
+// RequestID returns the request ID of the context, or an empty string if it has none
+func RequestID(ctx context.Context) string {
+	meta, _ := ctx.Value(clogContextKey).(metadata)
+	requestID, _ := meta[KeyRequestID].(string)
+	return requestID
+}
+
 // Actual log handler; the others have wrappers to properly handle stack depth
 func (v *VerboseLogger) logCtx(ctx context.Context, message string, args ...any) {
 	if !glog.V(v.level) {
 		return
 	}
-	var requestID string
 	meta, _ := ctx.Value(clogContextKey).(metadata)
-	if meta != nil {
-		requestID, _ = meta["request_id"].(string)
+	requestID, _ := meta[KeyRequestID].(string)
+	allArgs := []any{}
+	for k, v := range meta {
+		// the request's logger already adds the request ID
+		if k != KeyRequestID {
+			allArgs = append(allArgs, k, v)
+		}
 	}
-	allArgs := append([]any{}, meta.Flat()...)
 	allArgs = append(allArgs, args...)
 	allArgs = append(allArgs, "caller", caller(3))
 	if requestID == "" {
@@ -3,6 +3,7 @@ package log
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"io"
 	"testing"
 
@@ -54,6 +55,30 @@ func TestContextLog(t *testing.T) {
 	require.Contains(t, line["caller"], "log/clog_test.go")
 }
 
+func TestJSONLog(t *testing.T) {
+	var b bytes.Buffer
+	original := logDestination
+	logDestination = &b
+	defer func() { logDestination = original }()
+	require.NoError(t, SetFormat(FormatJSON))
+	defer func() { _ = SetFormat(FormatLogfmt) }()
+
+	ctx := WithRequestID(context.TODO(), "json_request")
+	ctx = WithLogValues(ctx, KeyStream, "video+abc")
+	LogCtx(ctx, "json message", KeyStage, "transcoding")
+	var line map[string]string
+	require.NoError(t, json.Unmarshal(b.Bytes(), &line))
+	require.Len(t, line, 6)
+	require.NotEmpty(t, line["ts"])
+	require.Equal(t, "json message", line["msg"])
+	require.Equal(t, "json_request", line[KeyRequestID])
+	require.Equal(t, "video+abc", line[KeyStream])
+	require.Equal(t, "transcoding", line[KeyStage])
+	require.Contains(t, line["caller"], "log/clog_test.go")
+
+	require.Error(t, SetFormat("xml"))
+}
+
 func TestVerboseLogging(t *testing.T) {
 	var b bytes.Buffer
 	original := logDestination
@@ -1,6 +1,7 @@
 package log
 
 import (
+	"fmt"
 	"io"
 	"net/url"
 	"os"
@@ -14,6 +15,32 @@ import (
 var loggerCache *cache.Cache
 var default_logger_cache_expiry = 6 * time.Hour
 
+// Formats of the log lines
+const (
+	FormatLogfmt = "logfmt"
+	FormatJSON   = "json"
+)
+
+// Keys of the fields that are logged the same way across packages, so log lines can be searched by them
+const (
+	KeyRequestID = "request_id"
+	KeyStream    = "stream"
+	KeyStage     = "stage"
+)
+
+var logFormat = FormatLogfmt
+
+// SetFormat sets the format of the log lines. It should be called before anything is logged, since loggers already
+// cached for a request keep their format.
+func SetFormat(format string) error {
+	switch format {
+	case FormatLogfmt, FormatJSON:
+		logFormat = format
+		return nil
+	}
+	return fmt.Errorf("unknown log format %q, should be %s or %s", format, FormatLogfmt, FormatJSON)
+}
+
 func init() {
 	loggerCache = cache.New(default_logger_cache_expiry, 10*time.Minute)
 }
@@ -28,6 +55,11 @@ func AddContext(requestID string, keyvals ...interface{}) {
 	}
 }
 
+// CacheSize returns the number of request loggers cached
+func CacheSize() int {
+	return loggerCache.ItemCount()
+}
+
 func Log(requestID string, message string, keyvals ...interface{}) {
 	_ = kitlog.With(getLogger(requestID), "msg", message).Log(keyvals...)
 }
@@ -50,10 +82,10 @@ func getLogger(requestID string) kitlog.Logger {
 		return logger.(kitlog.Logger)
 	}
 
-	newLogger := kitlog.With(newLogger(), "request_id", requestID)
+	newLogger := kitlog.With(newLogger(), KeyRequestID, requestID)
 	err := loggerCache.Add(requestID, newLogger, default_logger_cache_expiry)
 	if err != nil {
-		_ = newLogger.Log("msg", "error adding logger to cache", "request_id", requestID, "err", err.Error())
+		_ = newLogger.Log("msg", "error adding logger to cache", KeyRequestID, requestID, "err", err.Error())
 	}
 	return newLogger
 }
@@ -61,7 +93,12 @@ func getLogger(requestID string) kitlog.Logger {
 var logDestination io.Writer = os.Stderr
 
 func newLogger() kitlog.Logger {
-	newLogger := kitlog.NewLogfmtLogger(kitlog.NewSyncWriter(logDestination))
+	var newLogger kitlog.Logger
+	if logFormat == FormatJSON {
+		newLogger = kitlog.NewJSONLogger(kitlog.NewSyncWriter(redactingWriter{logDestination}))
+	} else {
+		newLogger = kitlog.NewLogfmtLogger(kitlog.NewSyncWriter(redactingWriter{logDestination}))
+	}
 	return kitlog.With(newLogger, "ts", kitlog.DefaultTimestampUTC)
 }
 
@@ -106,15 +143,25 @@ func RedactLogs(str, delim string) string {
 	return strings.Join(redactedstr[:], delim)
 }
 
+// RedactURL hides the password of URLs with credentials, whatever their scheme, e.g. the account keys of azblob:// and
+// b2:// output URLs. Anything else is returned as is.
 func RedactURL(str string) string {
 	strLower := strings.ToLower(str)
-	if !strings.HasPrefix(strLower, "http") && !strings.HasPrefix(strLower, "s3") {
+	isHTTPOrS3 := strings.HasPrefix(strLower, "http") || strings.HasPrefix(strLower, "s3")
+	if !isHTTPOrS3 && !strings.Contains(str, "://") {
 		return str
 	}
 
 	u, err := url.Parse(str)
 	if err != nil {
-		return "REDACTED"
+		// an unparsable URL could still contain credentials
+		if isHTTPOrS3 || strings.Contains(str, "@") {
+			return "REDACTED"
+		}
+		return str
+	}
+	if !isHTTPOrS3 && u.User == nil {
+		return str
 	}
 	return u.Redacted()
 }
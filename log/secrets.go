@@ -0,0 +1,51 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"sync/atomic"
+)
+
+// Secrets shorter than this aren't redacted, since they'd also match unrelated words in the log lines
+const minRedactedSecretLength = 8
+
+var redactedSecrets atomic.Pointer[[][]byte]
+
+// RedactSecret replaces the secret with REDACTED wherever it's logged, e.g. an API token or a DB connection string
+// that ends up in an error. glog lines aren't redacted.
+func RedactSecret(secret string) {
+	if len(secret) < minRedactedSecretLength {
+		return
+	}
+	for {
+		current := redactedSecrets.Load()
+		var secrets [][]byte
+		if current != nil {
+			secrets = append(secrets, *current...)
+		}
+		secrets = append(secrets, []byte(secret))
+		if redactedSecrets.CompareAndSwap(current, &secrets) {
+			return
+		}
+	}
+}
+
+// redactingWriter redacts the secrets from each log line, which is written in a single call
+type redactingWriter struct {
+	w io.Writer
+}
+
+func (r redactingWriter) Write(p []byte) (int, error) {
+	secrets := redactedSecrets.Load()
+	if secrets == nil {
+		return r.w.Write(p)
+	}
+	redacted := p
+	for _, secret := range *secrets {
+		redacted = bytes.ReplaceAll(redacted, secret, []byte("REDACTED"))
+	}
+	if _, err := r.w.Write(redacted); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,27 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactSecret(t *testing.T) {
+	var b bytes.Buffer
+	original := logDestination
+	logDestination = &b
+	defer func() {
+		logDestination = original
+		redactedSecrets.Store(nil)
+	}()
+
+	RedactSecret("short")
+	RedactSecret("s3cr3t-api-token")
+	LogCtx(context.TODO(), "request failed", "token", "s3cr3t-api-token", "err", "invalid token s3cr3t-api-token", "short", "short")
+	require.NotContains(t, b.String(), "s3cr3t-api-token")
+	require.Contains(t, b.String(), "token=REDACTED")
+	require.Contains(t, b.String(), `err="invalid token REDACTED"`)
+	require.Contains(t, b.String(), "short=short")
+}
@@ -0,0 +1,43 @@
+package log
+
+import (
+	"flag"
+	"fmt"
+)
+
+// SetVerbosity changes the glog -v level, which also gates LogCtx lines logged with V(). An empty vmodule leaves the
+// per-file levels unchanged.
+func SetVerbosity(level int, vmodule string) error {
+	if level < 0 {
+		return fmt.Errorf("log level must not be negative, got %d", level)
+	}
+	if err := setFlag("v", fmt.Sprintf("%d", level)); err != nil {
+		return err
+	}
+	if vmodule != "" {
+		return setFlag("vmodule", vmodule)
+	}
+	return nil
+}
+
+// Verbosity returns the current glog -v and -vmodule values
+func Verbosity() (level string, vmodule string) {
+	if f := flag.Lookup("v"); f != nil {
+		level = f.Value.String()
+	}
+	if f := flag.Lookup("vmodule"); f != nil {
+		vmodule = f.Value.String()
+	}
+	return level, vmodule
+}
+
+func setFlag(name, value string) error {
+	f := flag.Lookup(name)
+	if f == nil {
+		return fmt.Errorf("flag -%s is not registered", name)
+	}
+	if err := f.Value.Set(value); err != nil {
+		return fmt.Errorf("invalid -%s %q: %w", name, value, err)
+	}
+	return nil
+}
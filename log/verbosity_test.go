@@ -0,0 +1,28 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/golang/glog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetVerbosity(t *testing.T) {
+	defer func() { _ = SetVerbosity(int(defaultLogLevel), "") }()
+
+	require.NoError(t, SetVerbosity(7, ""))
+	level, _ := Verbosity()
+	require.Equal(t, "7", level)
+	require.True(t, bool(glog.V(7)))
+	require.False(t, bool(glog.V(8)))
+
+	require.NoError(t, SetVerbosity(2, "verbosity_test=9"))
+	level, vmodule := Verbosity()
+	require.Equal(t, "2", level)
+	require.Equal(t, "verbosity_test=9", vmodule)
+	require.True(t, bool(glog.V(9)))
+
+	require.Error(t, SetVerbosity(-1, ""))
+	require.Error(t, SetVerbosity(3, "not a vmodule"))
+	require.NoError(t, SetVerbosity(int(defaultLogLevel), "verbosity_test=0"))
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"reflect"
 	"regexp"
 	"strings"
 	"sync"
@@ -44,12 +45,39 @@ type (
 		MetricsHandler() http.Handler
 		MistMetricsHandler() http.Handler
 		RefreshStreamIfNeeded(playbackID string)
+		RefreshStream(playbackID string) (*StreamRefresh, error)
 		NukeStream(playbackID string)
 		InvalidateAllSessions(playbackID string)
 		StopSessions(playbackID string)
+		StopSessionsScoped(playbackID string, scope SessionScope) (int, error)
+		StreamStartedAt(playbackID string) (time.Time, bool)
 		IStreamCache
 	}
 
+	// SessionScope selects the viewer sessions to stop, all of them when empty
+	SessionScope struct {
+		// Protocol only stops the sessions of a Mist protocol, e.g. HLS or WebRTC
+		Protocol string
+		// OlderThan only stops the sessions connected for longer
+		OlderThan time.Duration
+	}
+
+	// StreamRefresh is what changed in a stream when it was refreshed from the Livepeer API
+	StreamRefresh struct {
+		StreamID string `json:"stream_id"`
+		// Loaded is set when the stream wasn't in memory, so there was nothing to compare it with
+		Loaded bool `json:"loaded"`
+		// Changes are the old and new values of the settings that changed, keyed by setting
+		Changes map[string]StreamChange `json:"changes"`
+		// Nuked is set when the stream was nuked for being deleted or suspended
+		Nuked bool `json:"nuked"`
+	}
+
+	StreamChange struct {
+		Old interface{} `json:"old"`
+		New interface{} `json:"new"`
+	}
+
 	IStreamCache interface {
 		GetCachedStream(playbackID string) *api.Stream
 	}
@@ -195,10 +223,60 @@ func (mc *mac) RefreshStreamIfNeeded(playbackID string) {
 	mc.reconcileSingleStream(si)
 }
 
+// RefreshStream refreshes a stream from the Livepeer API whether or not it's in memory, unlike
+// RefreshStreamIfNeeded, and returns what changed
+func (mc *mac) RefreshStream(playbackID string) (*StreamRefresh, error) {
+	old := mc.GetCachedStream(playbackID)
+	si, err := mc.refreshStream(playbackID)
+	if err != nil {
+		return nil, err
+	}
+	mc.reconcileSingleStream(si)
+
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	refresh := &StreamRefresh{
+		StreamID: si.stream.ID,
+		Loaded:   old == nil,
+		Changes:  map[string]StreamChange{},
+		Nuked:    si.stream.Deleted || si.stream.Suspended,
+	}
+	if old == nil {
+		return refresh, nil
+	}
+	settings := []struct {
+		name     string
+		old, new interface{}
+	}{
+		{"profiles", old.Profiles, si.stream.Profiles},
+		{"record", old.Record, si.stream.Record},
+		{"multistream_targets", old.Multistream.Targets, si.stream.Multistream.Targets},
+		{"suspended", old.Suspended, si.stream.Suspended},
+		{"deleted", old.Deleted, si.stream.Deleted},
+	}
+	for _, s := range settings {
+		if !reflect.DeepEqual(s.old, s.new) {
+			refresh.Changes[s.name] = StreamChange{Old: s.old, New: s.new}
+		}
+	}
+	return refresh, nil
+}
+
 func (mc *mac) NukeStream(playbackID string) {
 	mc.nukeAllStreamNames(playbackID)
 }
 
+// StreamStartedAt returns when the current session of a stream ingested by this node started
+func (mc *mac) StreamStartedAt(playbackID string) (time.Time, bool) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	info, ok := mc.streamInfo[playbackID]
+	if !ok {
+		return time.Time{}, false
+	}
+	return info.startedAt, true
+}
+
 func (mc *mac) StopSessions(playbackID string) {
 	mistState, err := mc.mist.GetState()
 	if err != nil {
@@ -223,6 +301,36 @@ func (mc *mac) StopSessions(playbackID string) {
 	}
 }
 
+// StopSessionsScoped disconnects the viewers of a stream on this node selected by scope, leaving its ingest and the
+// other viewers connected, and returns the number of sessions stopped
+func (mc *mac) StopSessionsScoped(playbackID string, scope SessionScope) (int, error) {
+	streamName := mc.wildcardPlaybackID(&api.Stream{PlaybackID: playbackID})
+	sessions, err := mc.mist.GetSessions(streamName)
+	if err != nil {
+		return 0, fmt.Errorf("error listing sessions of %s: %w", streamName, err)
+	}
+
+	var sessionIDs []string
+	for _, s := range sessions {
+		if !s.IsViewer() {
+			continue
+		}
+		if scope.Protocol != "" && !strings.EqualFold(s.Protocol, scope.Protocol) {
+			continue
+		}
+		if time.Duration(s.ConnectedSecs)*time.Second < scope.OlderThan {
+			continue
+		}
+		sessionIDs = append(sessionIDs, s.ID)
+	}
+
+	glog.V(7).Infof("calling mist StopSessionIDs playbackId=%s streamName=%s sessions=%d", playbackID, streamName, len(sessionIDs))
+	if err := mc.mist.StopSessionIDs(sessionIDs); err != nil {
+		return 0, fmt.Errorf("error stopping sessions of %s: %w", streamName, err)
+	}
+	return len(sessionIDs), nil
+}
+
 func (mc *mac) InvalidateAllSessions(playbackID string) {
 	mc.invalidateAllSessions(playbackID)
 }
@@ -2,6 +2,7 @@ package mistapiconnector
 
 import (
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/livepeer/catalyst-api/clients"
@@ -259,3 +260,74 @@ func TestReconcileStreams(t *testing.T) {
 	}
 	require.ElementsMatch(t, expectedNuked, recodedNuked)
 }
+
+func TestStopSessionsScoped(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mm := mockmistclient.NewMockMistAPIClient(ctrl)
+	mc := mac{
+		mist:           mm,
+		baseStreamName: "video",
+		config:         &config.Cli{},
+	}
+	sessions := []clients.MistSession{
+		{ID: "ingest", Protocol: "INPUT:RTMP", ConnectedSecs: 3600},
+		{ID: "hls-new", Protocol: "HLS", ConnectedSecs: 60},
+		{ID: "hls-old", Protocol: "HLS", ConnectedSecs: 1800},
+		{ID: "webrtc-old", Protocol: "WebRTC", ConnectedSecs: 1800},
+	}
+	mm.EXPECT().GetSessions("video+abc123").Return(sessions, nil).Times(3)
+
+	// the ingest is never stopped
+	mm.EXPECT().StopSessionIDs([]string{"hls-new", "hls-old", "webrtc-old"}).Return(nil)
+	stopped, err := mc.StopSessionsScoped("abc123", SessionScope{})
+	require.NoError(t, err)
+	require.Equal(t, 3, stopped)
+
+	mm.EXPECT().StopSessionIDs([]string{"hls-new", "hls-old"}).Return(nil)
+	stopped, err = mc.StopSessionsScoped("abc123", SessionScope{Protocol: "hls"})
+	require.NoError(t, err)
+	require.Equal(t, 2, stopped)
+
+	mm.EXPECT().StopSessionIDs([]string{"hls-old", "webrtc-old"}).Return(nil)
+	stopped, err = mc.StopSessionsScoped("abc123", SessionScope{OlderThan: 10 * time.Minute})
+	require.NoError(t, err)
+	require.Equal(t, 2, stopped)
+}
+
+func TestRefreshStream(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mm := mockmistclient.NewMockMistAPIClient(ctrl)
+	lapiCached := &ApiClientCached{streamCache: map[string]entry{}, ttl: time.Minute}
+	mc := mac{
+		mist:           mm,
+		baseStreamName: "video",
+		config:         &config.Cli{},
+		lapiCached:     lapiCached,
+		streamInfo:     map[string]*streamInfo{},
+	}
+	setStream := func(stream *api.Stream) {
+		lapiCached.streamCache[stream.PlaybackID] = entry{stream: stream, updateAt: time.Now()}
+	}
+
+	setStream(&api.Stream{ID: "123", PlaybackID: "abc123"})
+	refresh, err := mc.RefreshStream("abc123")
+	require.NoError(t, err)
+	require.Equal(t, &StreamRefresh{StreamID: "123", Loaded: true, Changes: map[string]StreamChange{}}, refresh)
+
+	profiles := []api.Profile{{Name: "360p0", Width: 640, Height: 360, Bitrate: 1000000}}
+	setStream(&api.Stream{ID: "123", PlaybackID: "abc123", Record: true, Profiles: profiles})
+	refresh, err = mc.RefreshStream("abc123")
+	require.NoError(t, err)
+	require.Equal(t, map[string]StreamChange{
+		"record":   {Old: false, New: true},
+		"profiles": {Old: []api.Profile(nil), New: profiles},
+	}, refresh.Changes)
+	require.False(t, refresh.Nuked)
+
+	setStream(&api.Stream{ID: "123", PlaybackID: "abc123", Record: true, Profiles: profiles, Suspended: true})
+	mm.EXPECT().NukeStream("video+abc123").Return(nil).Times(2)
+	refresh, err = mc.RefreshStream("abc123")
+	require.NoError(t, err)
+	require.Equal(t, map[string]StreamChange{"suspended": {Old: false, New: true}}, refresh.Changes)
+	require.True(t, refresh.Nuked)
+}
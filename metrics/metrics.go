@@ -12,6 +12,15 @@ type ClientMetrics struct {
 	RequestDuration *prometheus.HistogramVec
 }
 
+// ObjectStoreMetrics are labelled by storage provider, e.g. aws or storj, to tell slow storage apart from slow
+// transcoding
+type ObjectStoreMetrics struct {
+	Duration *prometheus.HistogramVec
+	Bytes    *prometheus.CounterVec
+	Errors   *prometheus.CounterVec
+	Retries  *prometheus.CounterVec
+}
+
 type VODPipelineMetrics struct {
 	Count              *prometheus.CounterVec
 	Duration           *prometheus.SummaryVec
@@ -19,6 +28,7 @@ type VODPipelineMetrics struct {
 	TranscodedSegments *prometheus.CounterVec
 	SourceBytes        *prometheus.SummaryVec
 	SourceDuration     *prometheus.SummaryVec
+	Failures           *prometheus.CounterVec
 }
 
 type AnalyticsMetrics struct {
@@ -31,19 +41,31 @@ type AnalyticsMetrics struct {
 	KafkaWriteMessages      prometheus.Counter
 	KafkaWriteRetries       prometheus.Counter
 	KafkaWriteAvgTime       prometheus.Summary
+	SessionRecords          *prometheus.CounterVec
 }
 
 type CatalystAPIMetrics struct {
 	Version                           *prometheus.CounterVec
 	UploadVODRequestCount             prometheus.Counter
 	UploadVODRequestDurationSec       *prometheus.SummaryVec
+	VODDecryptionFailureCount         *prometheus.CounterVec
+	RateLimitedRequestCount           *prometheus.CounterVec
+	DeprecatedAPIRequestCount         *prometheus.CounterVec
 	TranscodeSegmentDurationSec       prometheus.Histogram
 	PlaybackRequestDurationSec        *prometheus.SummaryVec
 	CDNRedirectCount                  *prometheus.CounterVec
 	CDNRedirectWebRTC406              *prometheus.CounterVec
+	ViewerLimitRejections             *prometheus.CounterVec
+	CDNRedirectListAge                prometheus.Gauge
+	HLSKeyRequestCount                *prometheus.CounterVec
+	MultistreamTargets                *prometheus.GaugeVec
+	IdleStreams                       prometheus.Gauge
+	IdleStreamsReapedCount            *prometheus.CounterVec
 	UserEventBufferSize               prometheus.Gauge
 	MemberEventBufferSize             prometheus.Gauge
 	SerfEventBufferSize               prometheus.Gauge
+	UserEventDecodeFailureCount       *prometheus.CounterVec
+	UserEventNewerVersionCount        *prometheus.CounterVec
 	AccessControlRequestCount         *prometheus.CounterVec
 	AccessControlRequestDurationSec   *prometheus.SummaryVec
 	CatabalancerRequestDurationSec    *prometheus.HistogramVec
@@ -53,10 +75,20 @@ type CatalystAPIMetrics struct {
 	JobsInFlight         prometheus.Gauge
 	HTTPRequestsInFlight prometheus.Gauge
 
+	ThumbnailQueueDepth   prometheus.Gauge
+	ThumbnailsInFlight    prometheus.Gauge
+	ThumbnailQueueWaitSec prometheus.Histogram
+	ThumbnailDurationSec  *prometheus.HistogramVec
+
+	SourceCacheRequests  *prometheus.CounterVec
+	SourceCacheBytes     prometheus.Gauge
+	SourceCacheEvictions prometheus.Counter
+
 	TranscodingStatusUpdate ClientMetrics
 	BroadcasterClient       ClientMetrics
 	MistClient              ClientMetrics
 	ObjectStoreClient       ClientMetrics
+	ObjectStore             ObjectStoreMetrics
 
 	VODPipelineMetrics VODPipelineMetrics
 
@@ -82,6 +114,36 @@ func NewMetrics() *CatalystAPIMetrics {
 			Name: "http_requests_in_flight",
 			Help: "A count of the http requests in flight",
 		}),
+		ThumbnailQueueDepth: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "thumbnail_queue_depth",
+			Help: "A count of the thumbnails waiting for a free slot in the thumbnail worker pool",
+		}),
+		ThumbnailsInFlight: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "thumbnails_in_flight",
+			Help: "A count of the thumbnails currently being generated",
+		}),
+		ThumbnailQueueWaitSec: promauto.NewHistogram(prometheus.HistogramOpts{
+			Name:    "thumbnail_queue_wait_duration",
+			Help:    "Time spent waiting for a free slot in the thumbnail worker pool",
+			Buckets: []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 120},
+		}),
+		ThumbnailDurationSec: promauto.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "thumbnail_duration",
+			Help:    "Time taken by ffmpeg to generate thumbnails, by type",
+			Buckets: []float64{.1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+		}, []string{"type", "success"}),
+		SourceCacheRequests: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "source_cache_requests",
+			Help: "Lookups of VOD sources in the local source cache, by result: hit or miss",
+		}, []string{"result"}),
+		SourceCacheBytes: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "source_cache_bytes",
+			Help: "Size of the files in the local source cache",
+		}),
+		SourceCacheEvictions: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "source_cache_evictions",
+			Help: "Files evicted from the local source cache to keep it within its size",
+		}),
 		UserEventBufferSize: promauto.NewGauge(prometheus.GaugeOpts{
 			Name: "user_event_buffer_size",
 			Help: "A count of the user events currently held in the buffer",
@@ -94,6 +156,14 @@ func NewMetrics() *CatalystAPIMetrics {
 			Name: "serf_event_buffer_size",
 			Help: "A count of the serf events currently held in the buffer",
 		}),
+		UserEventDecodeFailureCount: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "user_event_decode_failure_count",
+			Help: "A count of the user events that couldn't be decoded, by resource and reason",
+		}, []string{"resource", "reason"}),
+		UserEventNewerVersionCount: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "user_event_newer_version_count",
+			Help: "A count of the user events with a newer schema version than supported, decoded with the latest supported schema",
+		}, []string{"resource", "version"}),
 
 		// /api/vod request metrics
 		UploadVODRequestCount: promauto.NewCounter(prometheus.CounterOpts{
@@ -121,6 +191,42 @@ func NewMetrics() *CatalystAPIMetrics {
 			Name: "cdn_redirect_webrtc_406",
 			Help: "Number of WebRTC requests rejected with HTTP 406 because of playback should be seved from external CDN",
 		}, []string{"playbackID"}),
+		ViewerLimitRejections: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "viewer_limit_rejections",
+			Help: "Number of viewers turned away because the stream reached its max concurrent viewers, by where the limit was enforced: redirect or user_new",
+		}, []string{"playbackID", "stage"}),
+		CDNRedirectListAge: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "cdn_redirect_list_age_seconds",
+			Help: "Time since the CDN redirect list was last fetched from -cdn-redirect-playback-ids-url or found unchanged",
+		}),
+		VODDecryptionFailureCount: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "vod_decryption_failure_count",
+			Help: "Number of encrypted sources that failed to decrypt, broken up by reason",
+		}, []string{"reason"}),
+		RateLimitedRequestCount: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "rate_limited_request_count",
+			Help: "Number of API requests rejected for exceeding the caller's request rate or concurrent job limit, broken up by limit",
+		}, []string{"limit"}),
+		DeprecatedAPIRequestCount: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "deprecated_api_request_count",
+			Help: "Number of requests to deprecated API routes, to know when the callers have migrated off them",
+		}, []string{"path"}),
+		HLSKeyRequestCount: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "hls_key_request_count",
+			Help: "Number of requests for the HLS encryption keys of the assets, broken up by status code",
+		}, []string{"status_code"}),
+		MultistreamTargets: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "multistream_targets",
+			Help: "Number of restream targets registered with /api/stream/:playbackID/multistream, broken up by state",
+		}, []string{"state"}),
+		IdleStreams: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "idle_streams",
+			Help: "Number of streams in the Mist config with no input and no viewers",
+		}),
+		IdleStreamsReapedCount: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "idle_streams_reaped_count",
+			Help: "Number of streams deleted from Mist after being idle for -idle-stream-timeout, broken up by whether deleting them succeeded",
+		}, []string{"success"}),
 		AccessControlRequestCount: promauto.NewCounterVec(prometheus.CounterOpts{
 			Name: "access_control_request_count",
 			Help: "The total number of access control requests",
@@ -210,6 +316,26 @@ func NewMetrics() *CatalystAPIMetrics {
 			}, []string{"host", "operation", "bucket"}),
 		},
 
+		ObjectStore: ObjectStoreMetrics{
+			Duration: promauto.NewHistogramVec(prometheus.HistogramOpts{
+				Name:    "object_store_operation_duration_seconds",
+				Help:    "Time taken by object store operations, until the first byte for reads, by provider, operation and error class",
+				Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 120},
+			}, []string{"provider", "operation", "error_class"}),
+			Bytes: promauto.NewCounterVec(prometheus.CounterOpts{
+				Name: "object_store_bytes_total",
+				Help: "Bytes read from and written to object stores, by provider and operation",
+			}, []string{"provider", "operation"}),
+			Errors: promauto.NewCounterVec(prometheus.CounterOpts{
+				Name: "object_store_errors_total",
+				Help: "Failed object store operations by provider, operation and error class, e.g. timeout or not_found",
+			}, []string{"provider", "operation", "error_class"}),
+			Retries: promauto.NewCounterVec(prometheus.CounterOpts{
+				Name: "object_store_retries_total",
+				Help: "Object store operations retried after a failure, by operation",
+			}, []string{"operation"}),
+		},
+
 		VODPipelineMetrics: VODPipelineMetrics{
 			Count: promauto.NewCounterVec(prometheus.CounterOpts{
 				Name: "vod_count",
@@ -235,6 +361,10 @@ func NewMetrics() *CatalystAPIMetrics {
 				Name: "vod_source_duration",
 				Help: "Duration of the source asset",
 			}, vodLabels),
+			Failures: promauto.NewCounterVec(prometheus.CounterOpts{
+				Name: "pipeline_failures_total",
+				Help: "Number of failed VOD jobs by the stage they failed at and the reason, e.g. upload_error",
+			}, []string{"stage", "reason"}),
 		},
 
 		AnalyticsMetrics: AnalyticsMetrics{
@@ -270,6 +400,10 @@ func NewMetrics() *CatalystAPIMetrics {
 				Name: "kafka_write_avg_time",
 				Help: "Average time taken to write to Kafka",
 			}),
+			SessionRecords: promauto.NewCounterVec(prometheus.CounterOpts{
+				Name: "playback_session_records",
+				Help: "Number of playback session records by result: exported, failed or dropped",
+			}, []string{"result"}),
 		},
 	}
 
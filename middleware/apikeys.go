@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/livepeer/catalyst-api/log"
+)
+
+type APIKeyRole string
+
+const (
+	RoleReadOnly APIKeyRole = "read-only"
+	RoleSubmit   APIKeyRole = "submit"
+	RoleAdmin    APIKeyRole = "admin"
+)
+
+var roleScopes = map[APIKeyRole][]string{
+	RoleReadOnly: {ScopeRead},
+	RoleSubmit:   {ScopeRead, ScopeVODWrite, ScopeEventsWrite, ScopeStreamsWrite},
+	RoleAdmin:    {ScopeRead, ScopeVODWrite, ScopeEventsWrite, ScopeStreamsWrite, ScopeAdmin},
+}
+
+func (r APIKeyRole) HasScope(scope string) bool {
+	if scope == "" {
+		_, ok := roleScopes[r]
+		return ok
+	}
+	for _, s := range roleScopes[r] {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKey is an entry of the API keys file. Only the SHA-256 of the key is stored, so that the file doesn't hold
+// any secrets. Revoked keys can be kept in the file to make the rejections explicit in the logs.
+type APIKey struct {
+	ID        string     `json:"id"`
+	KeySHA256 string     `json:"key_sha256"`
+	Role      APIKeyRole `json:"role"`
+	Revoked   bool       `json:"revoked,omitempty"`
+}
+
+// LoadAPIKeys reads the API keys file, a JSON list of APIKey
+func LoadAPIKeys(path string) (map[string]APIKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading api keys: %w", err)
+	}
+	var list []APIKey
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("error parsing api keys: %w", err)
+	}
+
+	keys := make(map[string]APIKey, len(list))
+	for _, key := range list {
+		if _, ok := roleScopes[key.Role]; !ok {
+			return nil, fmt.Errorf("api key %q has unknown role %q", key.ID, key.Role)
+		}
+		hash := strings.ToLower(key.KeySHA256)
+		if b, err := hex.DecodeString(hash); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("api key %q has an invalid key_sha256", key.ID)
+		}
+		if _, ok := keys[hash]; ok {
+			return nil, fmt.Errorf("api key %q is a duplicate", key.ID)
+		}
+		keys[hash] = key
+	}
+	return keys, nil
+}
+
+// apiKeyStore reloads the API keys file periodically, so that keys can be added and revoked without a restart
+type apiKeyStore struct {
+	path            string
+	refreshInterval time.Duration
+
+	mu       sync.Mutex
+	keys     map[string]APIKey
+	loadedAt time.Time
+}
+
+func (s *apiKeyStore) lookup(token string) (APIKey, bool) {
+	if s == nil {
+		return APIKey{}, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.keys == nil || (s.refreshInterval > 0 && time.Since(s.loadedAt) > s.refreshInterval) {
+		s.loadedAt = time.Now()
+		keys, err := LoadAPIKeys(s.path)
+		if err != nil {
+			// keep the previous keys rather than locking everyone out over a bad edit
+			log.LogNoRequestID("Failed to reload API keys", "path", s.path, "err", err)
+		} else {
+			s.keys = keys
+		}
+	}
+
+	hash := sha256.Sum256([]byte(token))
+	key, ok := s.keys[hex.EncodeToString(hash[:])]
+	return key, ok
+}
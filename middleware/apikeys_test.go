@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/config"
+	"github.com/livepeer/catalyst-api/handlers"
+	"github.com/stretchr/testify/require"
+)
+
+func writeAPIKeys(t *testing.T, path string, keys []APIKey) {
+	data, err := json.Marshal(keys)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0600))
+}
+
+func keyHash(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+func TestAuthorizerAPIKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api-keys.json")
+	writeAPIKeys(t, path, []APIKey{
+		{ID: "dashboard", KeySHA256: keyHash("read-key"), Role: RoleReadOnly},
+		{ID: "studio", KeySHA256: keyHash("submit-key"), Role: RoleSubmit},
+		{ID: "ops", KeySHA256: keyHash("admin-key"), Role: RoleAdmin},
+		{ID: "leaked", KeySHA256: keyHash("revoked-key"), Role: RoleAdmin, Revoked: true},
+	})
+
+	// refreshed on every request
+	authorizer := NewAuthorizer("static-token", config.JWTAuthConfig{}, config.APIKeysConfig{File: path, RefreshInterval: 1})
+	var caller string
+	ok := func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		caller = handlers.Caller(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}
+	request := func(scope, token string) int {
+		req, _ := http.NewRequest("POST", "/api/vod", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		authorizer.Authorize(scope, ok)(rr, req, nil)
+		return rr.Code
+	}
+
+	require.Equal(t, http.StatusOK, request(ScopeRead, "read-key"))
+	require.Equal(t, http.StatusForbidden, request(ScopeVODWrite, "read-key"))
+	require.Equal(t, http.StatusOK, request(ScopeVODWrite, "submit-key"))
+	require.Equal(t, "key:studio", caller)
+	require.Equal(t, http.StatusForbidden, request(ScopeAdmin, "submit-key"))
+	require.Equal(t, http.StatusOK, request(ScopeAdmin, "admin-key"))
+	require.Equal(t, http.StatusOK, request(ScopeAdmin, "static-token"))
+	require.Equal(t, StaticTokenCaller, caller)
+	require.Equal(t, http.StatusUnauthorized, request(ScopeRead, "revoked-key"))
+	require.Equal(t, http.StatusUnauthorized, request(ScopeRead, "unknown-key"))
+
+	// revoking a key takes effect without affecting the others
+	writeAPIKeys(t, path, []APIKey{
+		{ID: "studio", KeySHA256: keyHash("submit-key"), Role: RoleSubmit, Revoked: true},
+		{ID: "ops", KeySHA256: keyHash("admin-key"), Role: RoleAdmin},
+	})
+	require.Equal(t, http.StatusUnauthorized, request(ScopeVODWrite, "submit-key"))
+	require.Equal(t, http.StatusUnauthorized, request(ScopeRead, "read-key"))
+	require.Equal(t, http.StatusOK, request(ScopeAdmin, "admin-key"))
+
+	// a broken file keeps the previous keys
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0600))
+	require.Equal(t, http.StatusOK, request(ScopeAdmin, "admin-key"))
+}
+
+func TestLoadAPIKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api-keys.json")
+
+	writeAPIKeys(t, path, []APIKey{{ID: "a", KeySHA256: keyHash("a"), Role: "superuser"}})
+	_, err := LoadAPIKeys(path)
+	require.ErrorContains(t, err, "unknown role")
+
+	writeAPIKeys(t, path, []APIKey{{ID: "a", KeySHA256: "not-a-hash", Role: RoleAdmin}})
+	_, err = LoadAPIKeys(path)
+	require.ErrorContains(t, err, "invalid key_sha256")
+
+	writeAPIKeys(t, path, []APIKey{{ID: "a", KeySHA256: keyHash("a"), Role: RoleAdmin}, {ID: "b", KeySHA256: keyHash("a"), Role: RoleSubmit}})
+	_, err = LoadAPIKeys(path)
+	require.ErrorContains(t, err, "duplicate")
+
+	_, err = LoadAPIKeys(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/audit"
+	"github.com/livepeer/catalyst-api/handlers"
+	"github.com/livepeer/catalyst-api/log"
+)
+
+// Audit records the call to the audit log once it's been handled, with the caller set by the authorization
+// middleware, so it has to be wrapped by it. The body is buffered to hash it, so like LimitBody it should only be
+// used on routes that read the whole body anyway.
+func Audit(auditLog audit.Log, action string) func(httprouter.Handle) httprouter.Handle {
+	return func(next httprouter.Handle) httprouter.Handle {
+		return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+			// the body has already been read by LimitBody on the audited routes, so this can't fail
+			payload, _ := io.ReadAll(r.Body)
+			r.Body = io.NopCloser(bytes.NewReader(payload))
+			hash := sha256.Sum256(payload)
+
+			wrapped := wrapResponseWriter(w)
+			next(wrapped, r, ps)
+			status := wrapped.status
+			if !wrapped.wroteHeader {
+				status = http.StatusOK
+			}
+
+			RecordAudit(r.Context(), auditLog, audit.Record{
+				Timestamp:     time.Now().UTC(),
+				RequestID:     log.RequestID(r.Context()),
+				Caller:        handlers.Caller(r.Context()),
+				RemoteAddr:    remoteAddr(r),
+				Action:        action,
+				Method:        r.Method,
+				Path:          r.URL.Path,
+				PayloadSHA256: hex.EncodeToString(hash[:]),
+				Status:        status,
+				Outcome:       audit.OutcomeOf(status),
+			})
+		}
+	}
+}
+
+// RecordAudit writes the record to the audit log, logging the failures since the call has already been handled.
+// The record is still written if the caller has disconnected.
+func RecordAudit(ctx context.Context, auditLog audit.Log, record audit.Record) {
+	if err := auditLog.Record(context.WithoutCancel(ctx), record); err != nil {
+		log.LogCtx(ctx, "error recording API audit log", "action", record.Action, "caller", record.Caller, "err", err)
+	}
+}
+
+func remoteAddr(r *http.Request) string {
+	if forwardedFor := r.Header.Get("X-Forwarded-For"); forwardedFor != "" {
+		return forwardedFor
+	}
+	return r.RemoteAddr
+}
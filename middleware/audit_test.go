@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/audit"
+	"github.com/livepeer/catalyst-api/config"
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAudit(t *testing.T) {
+	auditLog := audit.NewMemoryLog(10)
+	authorizer := NewAuthorizer("secret", config.JWTAuthConfig{}, config.APIKeysConfig{})
+	handler := authorizer.Authorize(ScopeVODWrite, Audit(auditLog, audit.ActionVODSubmit)(func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		// the body is still readable by the handler
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		if string(body) == "invalid" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+
+	for _, payload := range []string{`{"url": "http://localhost/input.mp4"}`, "invalid"} {
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/vod", strings.NewReader(payload))
+		req = req.WithContext(log.WithRequestID(req.Context(), "req-"+payload[:1]))
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set("X-Forwarded-For", "1.2.3.4")
+		handler(httptest.NewRecorder(), req, nil)
+	}
+
+	// unauthorized calls are rejected before the audit middleware
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/vod", strings.NewReader("{}"))
+	handler(httptest.NewRecorder(), req, nil)
+
+	records, err := auditLog.Query(context.Background(), audit.Filter{})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	require.Equal(t, "req-i", records[0].RequestID)
+	require.Equal(t, http.StatusBadRequest, records[0].Status)
+	require.Equal(t, audit.OutcomeFailed, records[0].Outcome)
+
+	require.Equal(t, "req-{", records[1].RequestID)
+	require.Equal(t, StaticTokenCaller, records[1].Caller)
+	require.Equal(t, "1.2.3.4", records[1].RemoteAddr)
+	require.Equal(t, audit.ActionVODSubmit, records[1].Action)
+	require.Equal(t, http.MethodPost, records[1].Method)
+	require.Equal(t, "/api/v2/vod", records[1].Path)
+	require.Equal(t, "3fb1b569691dccbca642622c7d01d52780fcffcd7eb76931a7fbb45ba7c6e019", records[1].PayloadSHA256)
+	require.Equal(t, http.StatusOK, records[1].Status)
+	require.Equal(t, audit.OutcomeSucceeded, records[1].Outcome)
+	require.NotEqual(t, records[0].PayloadSHA256, records[1].PayloadSHA256)
+}
@@ -1,29 +1,119 @@
 package middleware
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/config"
 	"github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/handlers"
+	"github.com/livepeer/catalyst-api/log"
 )
 
+// Scopes required by the API routes, carried by JWTs in the space separated "scope" claim and granted to API keys
+// by their role
+const (
+	ScopeRead         = "read"
+	ScopeVODWrite     = "vod:write"
+	ScopeEventsWrite  = "events:write"
+	ScopeStreamsWrite = "streams:write"
+	ScopeAdmin        = "admin"
+)
+
+// StaticTokenCaller identifies requests authorized with the static API token
+const StaticTokenCaller = "api-token"
+
+// Authorizer checks bearer tokens against the static API token, which grants every scope, the API keys and, if
+// configured, validates JWTs signed with the keys from a JWKS endpoint
+type Authorizer struct {
+	apiToken string
+	apiKeys  *apiKeyStore
+	cfg      config.JWTAuthConfig
+	jwks     *jwksCache
+}
+
+func NewAuthorizer(apiToken string, cfg config.JWTAuthConfig, apiKeysCfg config.APIKeysConfig) *Authorizer {
+	a := &Authorizer{apiToken: apiToken, cfg: cfg}
+	if cfg.Enabled() {
+		a.jwks = &jwksCache{url: cfg.JWKSURL, refreshInterval: cfg.JWKSRefreshInterval, client: &http.Client{Timeout: 10 * time.Second}}
+	}
+	if apiKeysCfg.File != "" {
+		a.apiKeys = &apiKeyStore{path: apiKeysCfg.File, refreshInterval: apiKeysCfg.RefreshInterval}
+	}
+	return a
+}
+
 func IsAuthorized(apiToken string, next httprouter.Handle) httprouter.Handle {
-	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-		authHeader := r.Header.Get("Authorization")
+	return NewAuthorizer(apiToken, config.JWTAuthConfig{}, config.APIKeysConfig{}).Authorize("", next)
+}
 
-		if authHeader == "" {
-			errors.WriteHTTPUnauthorized(w, "No authorization header", nil)
+// JWTEnabled is whether JWTs are accepted in addition to the static API token
+func (a *Authorizer) JWTEnabled() bool {
+	return a.jwks != nil
+}
+
+// Authorize requires a token with the given scope
+func (a *Authorizer) Authorize(scope string, next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		caller, err := a.Check(r.Context(), r.Header.Get("Authorization"), scope)
+		if err != nil {
+			errors.WriteHTTPAPIError(w, err)
 			return
 		}
+		next(w, r.WithContext(handlers.WithCaller(r.Context(), caller)), ps)
+	}
+}
 
-		token := strings.TrimPrefix(authHeader, "Bearer ")
+// Check validates the authorization header value for the given scope, returning the caller it was issued to. The
+// errors are errors.APIError, so that the HTTP and gRPC APIs return the same statuses.
+func (a *Authorizer) Check(ctx context.Context, authorization, scope string) (string, error) {
+	if authorization == "" {
+		return "", errors.NewAPIError("No authorization header", http.StatusUnauthorized, nil)
+	}
 
-		if token != apiToken {
-			errors.WriteHTTPUnauthorized(w, "Invalid Token", nil)
-			return
+	token := strings.TrimPrefix(authorization, "Bearer ")
+	if a.apiToken != "" && token == a.apiToken {
+		return StaticTokenCaller, nil
+	}
+
+	if key, ok := a.apiKeys.lookup(token); ok {
+		if key.Revoked {
+			return "", errors.NewAPIError("Revoked Token", http.StatusUnauthorized, nil)
+		}
+		if !key.Role.HasScope(scope) {
+			return "", errors.NewAPIError("Token is missing the required scope", http.StatusForbidden, fmt.Errorf("api key %q with role %q can't use scope %q", key.ID, key.Role, scope))
 		}
+		return handlers.APIKeyCallerPrefix + key.ID, nil
+	}
+
+	if !a.JWTEnabled() {
+		return "", errors.NewAPIError("Invalid Token", http.StatusUnauthorized, nil)
+	}
+	claims, err := a.validateJWT(ctx, token)
+	if err != nil {
+		log.LogNoRequestID("JWT validation failed", "err", err)
+		return "", errors.NewAPIError("Invalid Token", http.StatusUnauthorized, nil)
+	}
+	if !claims.HasScope(scope) {
+		return "", errors.NewAPIError("Token is missing the required scope", http.StatusForbidden, fmt.Errorf("scope %q required", scope))
+	}
+	return "jwt:" + claims.Subject, nil
+}
+
+// Required is whether authorization is enforced by AuthorizeIfConfigured
+func (a *Authorizer) Required() bool {
+	return a.JWTEnabled() || a.apiKeys != nil
+}
 
-		next(w, r, ps)
+// AuthorizeIfConfigured only requires authorization when JWTs or API keys are configured, for routes that were
+// historically unauthenticated so that existing deployments keep working
+func (a *Authorizer) AuthorizeIfConfigured(scope string, next httprouter.Handle) httprouter.Handle {
+	if !a.Required() {
+		return next
 	}
+	return a.Authorize(scope, next)
 }
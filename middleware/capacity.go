@@ -22,53 +22,63 @@ type CapacityMiddleware struct {
 
 func (c *CapacityMiddleware) HasCapacity(vodEngine *pipeline.Coordinator, next httprouter.Handle) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-		var vodJobCount, clipJobCount int
-
 		// Keep a gauge of HTTP requests in flight
 		metrics.Metrics.HTTPRequestsInFlight.Add(1)
 		defer metrics.Metrics.HTTPRequestsInFlight.Add(-1)
 
-		// Get total in-progress VOD jobs (i.e. clipping and regular vod jobs)
-		allVodJobs := vodEngine.Jobs.GetJobs()
-
-		// Get total in-progress clipping VOD jobs
-		for _, v := range allVodJobs {
-			if v.ClipStrategy.Enabled {
-				clipJobCount++
-			} else {
-				vodJobCount++
-			}
-		}
-
 		// Get this current request's job type (i.e. clipping or regular-vod request)
 		isClip, err := isClipRequest(r)
 		if err != nil {
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
-		// Compare limits for clipping vs regular-vod jobs
-		if isClip {
-			inFlightClipReqs := c.clipRequestsInFlight.Add(1)
-			defer c.clipRequestsInFlight.Add(-1)
-
-			if clipJobCount+int(inFlightClipReqs) >= config.MaxInFlightClipJobs {
-				w.WriteHeader(http.StatusTooManyRequests)
-				return
-			}
-		} else {
-			inFlightVodReqs := c.vodRequestsInFlight.Add(1)
-			defer c.vodRequestsInFlight.Add(-1)
-
-			if vodJobCount+int(inFlightVodReqs) >= config.MaxInFlightJobs {
-				w.WriteHeader(http.StatusTooManyRequests)
-				return
-			}
+		release, ok := c.Acquire(vodEngine, isClip)
+		if !ok {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
 		}
+		defer release()
 
 		next(w, r, ps)
 	}
 }
 
+// Acquire reserves capacity for a clipping or regular VOD job, for the APIs not served by the HTTP router. It
+// returns false if the node is at capacity, otherwise release has to be called once the job is started.
+func (c *CapacityMiddleware) Acquire(vodEngine *pipeline.Coordinator, isClip bool) (release func(), ok bool) {
+	var vodJobCount, clipJobCount int
+
+	// Get total in-progress VOD jobs (i.e. clipping and regular vod jobs)
+	allVodJobs := vodEngine.Jobs.GetJobs()
+
+	// Get total in-progress clipping VOD jobs
+	for _, v := range allVodJobs {
+		if v.ClipStrategy.Enabled {
+			clipJobCount++
+		} else {
+			vodJobCount++
+		}
+	}
+
+	// Compare limits for clipping vs regular-vod jobs
+	if isClip {
+		inFlightClipReqs := c.clipRequestsInFlight.Add(1)
+		release = func() { c.clipRequestsInFlight.Add(-1) }
+		if clipJobCount+int(inFlightClipReqs) >= config.MaxInFlightClipJobs {
+			release()
+			return nil, false
+		}
+		return release, true
+	}
+	inFlightVodReqs := c.vodRequestsInFlight.Add(1)
+	release = func() { c.vodRequestsInFlight.Add(-1) }
+	if vodJobCount+int(inFlightVodReqs) >= config.MaxInFlightJobs {
+		release()
+		return nil, false
+	}
+	return release, true
+}
+
 func isClipRequest(r *http.Request) (bool, error) {
 
 	if r == nil {
@@ -83,8 +93,12 @@ func isClipRequest(r *http.Request) (bool, error) {
 	// Read request body to buf so that r.Body can be reset
 	tee := io.TeeReader(r.Body, &buf)
 
+	// the fields that make clipping requests in both the /api/vod and /api/v2/vod payloads
 	decoder := json.NewDecoder(tee)
-	b := handlers.UploadVODRequest{}
+	b := struct {
+		ClipStrategy *handlers.UploadVODClipV2 `json:"clip_strategy"`
+		Clip         *handlers.UploadVODClipV2 `json:"clip"`
+	}{}
 	err := decoder.Decode(&b)
 	if err != nil {
 		return false, err
@@ -94,7 +108,7 @@ func isClipRequest(r *http.Request) (bool, error) {
 	r.Body = io.NopCloser(&buf)
 
 	// Check if current request is a clipping request
-	if b.ClipStrategy.PlaybackID != "" {
+	if (b.ClipStrategy != nil && b.ClipStrategy.PlaybackID != "") || (b.Clip != nil && b.Clip.PlaybackID != "") {
 		return true, nil
 	}
 	return false, nil
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -216,3 +217,23 @@ func TestItTakesIntoAccountInFlightHTTPRequests(t *testing.T) {
 	// Confirm the handler didn't let too many requests through
 	require.Equal(t, 100-config.MaxInFlightJobs+1, rejectedRequestCount)
 }
+
+func TestIsClipRequestDetectsBothPayloadVersions(t *testing.T) {
+	for body, expected := range map[string]bool{
+		`{"url": "http://localhost/input", "clip_strategy": {"playback_id": "abc"}}`:    true,
+		`{"source": {"url": "http://localhost/input"}, "clip": {"playback_id": "abc"}}`: true,
+		`{"source": {"url": "http://localhost/input"}, "clip": {}}`:                     false,
+		`{"url": "http://localhost/input"}`:                                             false,
+	} {
+		req, err := http.NewRequest("POST", "/api/vod", bytes.NewBufferString(body))
+		require.NoError(t, err)
+		isClip, err := isClipRequest(req)
+		require.NoError(t, err)
+		require.Equal(t, expected, isClip, body)
+
+		// the body is still readable by the next handler
+		b, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		require.Equal(t, body, string(b))
+	}
+}
@@ -0,0 +1,182 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// compressMinSize is the smallest response worth compressing, smaller ones can grow from the encoding overhead
+const compressMinSize = 1024
+
+// Compress gzip or deflate encodes responses for clients that accept it, skipping content types that are
+// already compressed
+func Compress() func(httprouter.Handle) httprouter.Handle {
+	return func(next httprouter.Handle) httprouter.Handle {
+		return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead {
+				next(w, r, ps)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, encoding: encoding}
+			defer cw.Close()
+			next(cw, r, ps)
+		}
+	}
+}
+
+// negotiateEncoding picks gzip or deflate from the Accept-Encoding header, preferring gzip when the q-values tie
+func negotiateEncoding(acceptEncoding string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if name != "gzip" && name != "deflate" || q <= 0 {
+			continue
+		}
+		if q > bestQ || (q == bestQ && name == "gzip") {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+func isCompressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		// unknown content will be sniffed by net/http, which is only ever text or already compressed formats
+		return contentType == ""
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		mediaType == "application/json",
+		strings.HasSuffix(mediaType, "+json"),
+		mediaType == "application/xml",
+		strings.HasSuffix(mediaType, "+xml"),
+		mediaType == "application/javascript",
+		mediaType == "application/vnd.apple.mpegurl",
+		mediaType == "application/x-mpegurl",
+		mediaType == "application/dash+xml":
+		return true
+	}
+	return false
+}
+
+// compressWriter buffers the start of the response to decide whether it's worth compressing, based on its size
+// and content type, before anything is written to the client
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+
+	status  int
+	buf     bytes.Buffer
+	decided bool
+	encoder io.WriteCloser
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.status == 0 {
+		cw.status = status
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	if !cw.decided {
+		cw.buf.Write(p)
+		if cw.buf.Len() < compressMinSize {
+			return len(p), nil
+		}
+		if err := cw.decide(); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if cw.encoder != nil {
+		return cw.encoder.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// decide writes the headers and the buffered start of the response, compressed if it's worthwhile
+func (cw *compressWriter) decide() error {
+	cw.decided = true
+	h := cw.Header()
+	if h.Get("Content-Type") == "" && cw.buf.Len() > 0 {
+		h.Set("Content-Type", http.DetectContentType(cw.buf.Bytes()))
+	}
+
+	compress := cw.buf.Len() >= compressMinSize &&
+		h.Get("Content-Encoding") == "" &&
+		cw.status != http.StatusNoContent && cw.status != http.StatusNotModified &&
+		isCompressible(h.Get("Content-Type"))
+	if compress {
+		h.Set("Content-Encoding", cw.encoding)
+		h.Del("Content-Length")
+		if cw.encoding == "gzip" {
+			cw.encoder = gzip.NewWriter(cw.ResponseWriter)
+		} else {
+			// only errors for invalid levels
+			cw.encoder, _ = flate.NewWriter(cw.ResponseWriter, flate.DefaultCompression)
+		}
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+	if cw.buf.Len() == 0 {
+		return nil
+	}
+	var err error
+	if cw.encoder != nil {
+		_, err = cw.encoder.Write(cw.buf.Bytes())
+	} else {
+		_, err = cw.ResponseWriter.Write(cw.buf.Bytes())
+	}
+	cw.buf.Reset()
+	return err
+}
+
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		if cw.status == 0 {
+			cw.status = http.StatusOK
+		}
+		_ = cw.decide()
+	}
+	if f, ok := cw.encoder.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (cw *compressWriter) Close() {
+	if !cw.decided {
+		if cw.status == 0 {
+			// nothing was written, leave the implicit 200 to net/http
+			return
+		}
+		_ = cw.decide()
+	}
+	if cw.encoder != nil {
+		_ = cw.encoder.Close()
+	}
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	require.Equal(t, "", negotiateEncoding(""))
+	require.Equal(t, "gzip", negotiateEncoding("gzip, deflate, br"))
+	require.Equal(t, "deflate", negotiateEncoding("deflate"))
+	require.Equal(t, "deflate", negotiateEncoding("gzip;q=0.5, deflate"))
+	require.Equal(t, "deflate", negotiateEncoding("gzip;q=0, deflate"))
+	require.Equal(t, "", negotiateEncoding("br, identity"))
+}
+
+func TestCompress(t *testing.T) {
+	largeJSON := "[" + strings.Repeat(`{"name":"node"},`, 200) + "{}]"
+	serve := func(contentType, body, acceptEncoding string) *httptest.ResponseRecorder {
+		handler := Compress()(func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+			if contentType != "" {
+				w.Header().Set("Content-Type", contentType)
+			}
+			w.WriteHeader(http.StatusOK)
+			_, _ = io.WriteString(w, body)
+		})
+		req := httptest.NewRequest(http.MethodGet, "/admin/members", nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		rr := httptest.NewRecorder()
+		handler(rr, req, nil)
+		return rr
+	}
+
+	rr := serve("application/json", largeJSON, "gzip")
+	require.Equal(t, "gzip", rr.Header().Get("Content-Encoding"))
+	require.Equal(t, "Accept-Encoding", rr.Header().Get("Vary"))
+	require.Less(t, rr.Body.Len(), len(largeJSON))
+	gz, err := gzip.NewReader(rr.Body)
+	require.NoError(t, err)
+	body, err := io.ReadAll(gz)
+	require.NoError(t, err)
+	require.Equal(t, largeJSON, string(body))
+
+	rr = serve("application/json", largeJSON, "deflate")
+	require.Equal(t, "deflate", rr.Header().Get("Content-Encoding"))
+	body, err = io.ReadAll(flate.NewReader(rr.Body))
+	require.NoError(t, err)
+	require.Equal(t, largeJSON, string(body))
+
+	// not accepted by the client
+	rr = serve("application/json", largeJSON, "")
+	require.Empty(t, rr.Header().Get("Content-Encoding"))
+	require.Equal(t, largeJSON, rr.Body.String())
+
+	// too small to be worth it
+	rr = serve("application/json", `{"ok":true}`, "gzip")
+	require.Empty(t, rr.Header().Get("Content-Encoding"))
+	require.Equal(t, `{"ok":true}`, rr.Body.String())
+
+	// already compressed content
+	video := strings.Repeat("x", 4096)
+	rr = serve("video/mp4", video, "gzip")
+	require.Empty(t, rr.Header().Get("Content-Encoding"))
+	require.Equal(t, video, rr.Body.String())
+}
@@ -2,8 +2,12 @@ package middleware
 
 import (
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/config"
 )
 
 func AllowCORS() func(httprouter.Handle) httprouter.Handle {
@@ -34,3 +38,60 @@ func AllowCORS() func(httprouter.Handle) httprouter.Handle {
 		return handler
 	}
 }
+
+// NewCORS handles cross-origin requests from the configured origins, answering preflight requests without
+// calling the next handler so that they don't need to be authorized. Requests from other origins are passed
+// through without CORS headers, and the browser blocks them.
+func NewCORS(cfg config.CORSConfig) func(httprouter.Handle) httprouter.Handle {
+	allowedMethods := strings.Join(cfg.AllowedMethods, ", ")
+	allowedHeaders := strings.Join(cfg.AllowedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
+	return func(next httprouter.Handle) httprouter.Handle {
+		return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+			origin := r.Header.Get("Origin")
+			w.Header().Add("Vary", "Origin")
+			isPreflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			if origin == "" || !isOriginAllowed(cfg.AllowedOrigins, origin) {
+				if isPreflight {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				next(w, r, ps)
+				return
+			}
+
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Expose-Headers", "Location")
+			if !isPreflight {
+				next(w, r, ps)
+				return
+			}
+
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			w.Header().Set("Access-Control-Allow-Methods", allowedMethods)
+			w.Header().Set("Access-Control-Allow-Headers", allowedHeaders)
+			if cfg.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", maxAge)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
+
+func isOriginAllowed(allowedOrigins []string, origin string) bool {
+	for _, allowed := range allowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+		// *.example.com matches any subdomain of example.com over any scheme
+		if suffix, ok := strings.CutPrefix(allowed, "*"); ok && strings.HasPrefix(suffix, ".") {
+			if u, err := url.Parse(origin); err == nil && strings.HasSuffix(strings.ToLower(u.Hostname()), strings.ToLower(suffix)) {
+				return true
+			}
+		}
+	}
+	return false
+}
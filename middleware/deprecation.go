@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/handlers"
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/livepeer/catalyst-api/metrics"
+)
+
+// Deprecated marks the responses of a route superseded by the successor route with the Deprecation (RFC 9745) and
+// Link headers, and counts its use. The callers still using it are logged, it goes after the authorization middleware,
+// which sets the caller.
+func Deprecated(since time.Time, successor string) func(httprouter.Handle) httprouter.Handle {
+	deprecation := fmt.Sprintf("@%d", since.Unix())
+	link := fmt.Sprintf("<%s>; rel=\"successor-version\"", successor)
+	return func(next httprouter.Handle) httprouter.Handle {
+		return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+			w.Header().Set("Deprecation", deprecation)
+			w.Header().Add("Link", link)
+			metrics.Metrics.DeprecatedAPIRequestCount.WithLabelValues(r.URL.Path).Inc()
+			log.LogNoRequestID("Deprecated API request", "request_id", log.RequestID(r.Context()), "path", r.URL.Path, "caller", handlers.Caller(r.Context()))
+			next(w, r, ps)
+		}
+	}
+}
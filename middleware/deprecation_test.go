@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeprecated(t *testing.T) {
+	var nextCalled bool
+	handler := Deprecated(time.Date(2026, time.October, 15, 0, 0, 0, 0, time.UTC), "/api/v2/vod")(func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		nextCalled = true
+	})
+
+	counter := metrics.Metrics.DeprecatedAPIRequestCount.WithLabelValues("/api/vod")
+	before := testutil.ToFloat64(counter)
+
+	rr := httptest.NewRecorder()
+	handler(rr, httptest.NewRequest(http.MethodPost, "/api/vod", nil), nil)
+	require.True(t, nextCalled)
+	require.Equal(t, "@1792022400", rr.Header().Get("Deprecation"))
+	require.Equal(t, `</api/v2/vod>; rel="successor-version"`, rr.Header().Get("Link"))
+	require.Equal(t, before+1, testutil.ToFloat64(counter))
+}
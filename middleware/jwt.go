@@ -0,0 +1,212 @@
+package middleware
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/livepeer/catalyst-api/log"
+)
+
+// minJWKSRefetchInterval limits how often the keys are refetched for tokens signed with unknown keys
+const minJWKSRefetchInterval = time.Minute
+
+type APIClaims struct {
+	jwt.RegisteredClaims
+	Scope string `json:"scope"`
+}
+
+func (c *APIClaims) HasScope(scope string) bool {
+	for _, s := range strings.Fields(c.Scope) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Authorizer) validateJWT(ctx context.Context, tokenString string) (*APIClaims, error) {
+	claims := &APIClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS, *jwt.SigningMethodECDSA, *jwt.SigningMethodEd25519:
+		default:
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		return a.jwks.key(ctx, kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.ExpiresAt == nil {
+		return nil, fmt.Errorf("token has no expiry")
+	}
+	if !claims.VerifyIssuer(a.cfg.Issuer, a.cfg.Issuer != "") {
+		return nil, fmt.Errorf("unexpected issuer %q", claims.Issuer)
+	}
+	if !claims.VerifyAudience(a.cfg.Audience, a.cfg.Audience != "") {
+		return nil, fmt.Errorf("unexpected audience %v", claims.Audience)
+	}
+	return claims, nil
+}
+
+// jwksCache holds the public keys from the JWKS endpoint by key ID
+type jwksCache struct {
+	url             string
+	refreshInterval time.Duration
+	client          *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+func (c *jwksCache) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stale := c.keys == nil || (c.refreshInterval > 0 && time.Since(c.fetchedAt) > c.refreshInterval)
+	_, known := c.keys[kid]
+	if stale || (!known && time.Since(c.fetchedAt) > minJWKSRefetchInterval) {
+		if err := c.fetch(ctx); err != nil {
+			// keep using the previous keys if the endpoint is temporarily unavailable
+			if c.keys == nil {
+				return nil, err
+			}
+			log.LogNoRequestID("Failed to refresh JWKS", "url", c.url, "err", err)
+		}
+	}
+
+	if key, ok := c.keys[kid]; ok {
+		return key, nil
+	}
+	// tokens without a key ID are allowed when there's a single key
+	if kid == "" && len(c.keys) == 1 {
+		for _, key := range c.keys {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (c *jwksCache) fetch(ctx context.Context) error {
+	// the fetch time is updated even on failure, so that a down endpoint isn't hammered on every request
+	c.fetchedAt = time.Now()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error fetching JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error fetching JWKS: status %d", resp.StatusCode)
+	}
+
+	var jwks struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
+		return fmt.Errorf("error decoding JWKS: %w", err)
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, k := range jwks.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			log.LogNoRequestID("Skipping invalid JWKS key", "kid", k.Kid, "err", err)
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	c.keys = keys
+	return nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("point is not on curve %s", k.Crv)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
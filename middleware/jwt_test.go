@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/config"
+	"github.com/stretchr/testify/require"
+)
+
+func jwksServer(t *testing.T, keys map[string]any) (*httptest.Server, *atomic.Int32) {
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		var jwks []map[string]string
+		for kid, key := range keys {
+			switch key := key.(type) {
+			case *rsa.PublicKey:
+				jwks = append(jwks, map[string]string{
+					"kty": "RSA", "kid": kid, "use": "sig",
+					"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+					"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+				})
+			case *ecdsa.PublicKey:
+				jwks = append(jwks, map[string]string{
+					"kty": "EC", "kid": kid, "crv": "P-256",
+					"x": base64.RawURLEncoding.EncodeToString(key.X.Bytes()),
+					"y": base64.RawURLEncoding.EncodeToString(key.Y.Bytes()),
+				})
+			}
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]any{"keys": jwks}))
+	}))
+	t.Cleanup(server.Close)
+	return server, &fetches
+}
+
+func signJWT(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func TestAuthorizerJWT(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	server, fetches := jwksServer(t, map[string]any{"rsa-key": &rsaKey.PublicKey, "ec-key": &ecKey.PublicKey})
+
+	authorizer := NewAuthorizer("static-token", config.JWTAuthConfig{
+		Issuer:              "https://auth.livepeer.studio",
+		Audience:            "catalyst-api",
+		JWKSURL:             server.URL,
+		JWKSRefreshInterval: time.Hour,
+	}, config.APIKeysConfig{})
+	router := httprouter.New()
+	router.POST("/api/vod", authorizer.Authorize(ScopeVODWrite, func(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	request := func(token string) int {
+		req, _ := http.NewRequest("POST", "/api/vod", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	claims := func(scope string) jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":   "https://auth.livepeer.studio",
+			"aud":   "catalyst-api",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"scope": scope,
+		}
+	}
+
+	require.Equal(t, http.StatusOK, request("static-token"))
+	require.Equal(t, http.StatusOK, request(signJWT(t, jwt.SigningMethodRS256, rsaKey, "rsa-key", claims("events:write vod:write"))))
+	require.Equal(t, http.StatusOK, request(signJWT(t, jwt.SigningMethodES256, ecKey, "ec-key", claims("vod:write"))))
+	require.Equal(t, http.StatusForbidden, request(signJWT(t, jwt.SigningMethodRS256, rsaKey, "rsa-key", claims("events:write"))))
+	require.Equal(t, http.StatusUnauthorized, request("not a token"))
+
+	expired := claims("vod:write")
+	expired["exp"] = time.Now().Add(-time.Minute).Unix()
+	require.Equal(t, http.StatusUnauthorized, request(signJWT(t, jwt.SigningMethodRS256, rsaKey, "rsa-key", expired)))
+
+	noExpiry := claims("vod:write")
+	delete(noExpiry, "exp")
+	require.Equal(t, http.StatusUnauthorized, request(signJWT(t, jwt.SigningMethodRS256, rsaKey, "rsa-key", noExpiry)))
+
+	wrongIssuer := claims("vod:write")
+	wrongIssuer["iss"] = "https://evil.example.com"
+	require.Equal(t, http.StatusUnauthorized, request(signJWT(t, jwt.SigningMethodRS256, rsaKey, "rsa-key", wrongIssuer)))
+
+	wrongAudience := claims("vod:write")
+	wrongAudience["aud"] = "another-api"
+	require.Equal(t, http.StatusUnauthorized, request(signJWT(t, jwt.SigningMethodRS256, rsaKey, "rsa-key", wrongAudience)))
+
+	// the keys are cached, an unknown key only triggers a refetch once the minimum interval has passed
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnauthorized, request(signJWT(t, jwt.SigningMethodRS256, otherKey, "rsa-key", claims("vod:write"))))
+	require.Equal(t, http.StatusUnauthorized, request(signJWT(t, jwt.SigningMethodRS256, otherKey, "other-key", claims("vod:write"))))
+	require.Equal(t, int32(1), fetches.Load())
+}
+
+func TestAuthorizerStaticTokenOnly(t *testing.T) {
+	authorizer := NewAuthorizer("static-token", config.JWTAuthConfig{}, config.APIKeysConfig{})
+	require.False(t, authorizer.JWTEnabled())
+
+	called := false
+	next := func(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) { called = true }
+
+	// routes that were unauthenticated stay so until JWTs are configured
+	req, _ := http.NewRequest("POST", "/api/events", nil)
+	authorizer.AuthorizeIfConfigured(ScopeEventsWrite, next)(httptest.NewRecorder(), req, nil)
+	require.True(t, called)
+
+	rr := httptest.NewRecorder()
+	req.Header.Set("Authorization", "Bearer some.jwt.token")
+	authorizer.Authorize(ScopeEventsWrite, next)(rr, req, nil)
+	require.Equal(t, http.StatusUnauthorized, rr.Code)
+}
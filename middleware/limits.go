@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	catErrs "github.com/livepeer/catalyst-api/errors"
+)
+
+// LimitBody rejects request bodies larger than maxBytes with a 413. The body is buffered, so it should only be
+// used on routes that read the whole body anyway. Unlimited if maxBytes is 0.
+func LimitBody(maxBytes int64) func(httprouter.Handle) httprouter.Handle {
+	return func(next httprouter.Handle) httprouter.Handle {
+		if maxBytes <= 0 {
+			return next
+		}
+		return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+			if r.ContentLength > maxBytes {
+				catErrs.WriteHTTPRequestEntityTooLarge(w, "Request body too large", fmt.Errorf("content length %d exceeds the limit of %d bytes", r.ContentLength, maxBytes))
+				return
+			}
+
+			// chunked requests don't have a content length, so the body has to be read to know its size
+			payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBytes))
+			if err != nil {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					catErrs.WriteHTTPRequestEntityTooLarge(w, "Request body too large", err)
+				} else {
+					catErrs.WriteHTTPBadRequest(w, "Cannot read payload", err)
+				}
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(payload))
+			next(w, r, ps)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitBody(t *testing.T) {
+	var received string
+	handler := LimitBody(10)(func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		received = string(body)
+	})
+
+	rr := httptest.NewRecorder()
+	handler(rr, httptest.NewRequest(http.MethodPost, "/api/vod", strings.NewReader("0123456789")), nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "0123456789", received)
+
+	rr = httptest.NewRecorder()
+	handler(rr, httptest.NewRequest(http.MethodPost, "/api/vod", strings.NewReader("0123456789a")), nil)
+	require.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+
+	// without a content length, e.g. chunked uploads
+	received = ""
+	req := httptest.NewRequest(http.MethodPost, "/api/vod", io.MultiReader(strings.NewReader("01234"), strings.NewReader("56789a")))
+	req.ContentLength = -1
+	rr = httptest.NewRecorder()
+	handler(rr, req, nil)
+	require.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	require.Empty(t, received)
+}
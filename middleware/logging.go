@@ -40,6 +40,21 @@ func (rw *responseWriter) Flush() {
 	flusher.Flush()
 }
 
+// CountRequests counts the requests by their response status code
+func CountRequests(metric *prometheus.CounterVec) func(httprouter.Handle) httprouter.Handle {
+	return func(next httprouter.Handle) httprouter.Handle {
+		return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+			wrapped := wrapResponseWriter(w)
+			next(wrapped, r, ps)
+			status := wrapped.status
+			if !wrapped.wroteHeader {
+				status = http.StatusOK
+			}
+			metric.WithLabelValues(fmt.Sprint(status)).Inc()
+		}
+	}
+}
+
 func LogAndMetrics(metric *prometheus.SummaryVec) func(httprouter.Handle) httprouter.Handle {
 	return logRequest(metric)
 }
@@ -57,7 +72,7 @@ func logRequest(metric *prometheus.SummaryVec) func(httprouter.Handle) httproute
 			defer func() {
 				if err := recover(); err != nil {
 					errors.WriteHTTPInternalServerError(wrapped, "Internal Server Error", nil)
-					log.LogNoRequestID("returning HTTP 500", "err", err, "trace", debug.Stack())
+					log.LogNoRequestID("returning HTTP 500", "request_id", log.RequestID(r.Context()), "err", err, "trace", debug.Stack())
 				}
 			}()
 
@@ -66,6 +81,7 @@ func logRequest(metric *prometheus.SummaryVec) func(httprouter.Handle) httproute
 
 			if glog.V(4) || wrapped.status >= 400 {
 				log.LogNoRequestID("received HTTP request",
+					"request_id", log.RequestID(r.Context()),
 					"remote", r.RemoteAddr,
 					"proto", r.Proto,
 					"method", r.Method,
@@ -4,9 +4,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/config"
 	"github.com/livepeer/catalyst-api/handlers"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -39,3 +43,86 @@ func TestWrongKey(t *testing.T) {
 	require.Equal(rr.Code, 401, "should return 401")
 	require.JSONEq(rr.Body.String(), `{"error":"Invalid Token", "error_detail":""}`)
 }
+
+func TestCountRequests(t *testing.T) {
+	require := require.New(t)
+
+	metric := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_request_count"}, []string{"status_code"})
+	router := httprouter.New()
+	router.GET("/keys/:playbackID", CountRequests(metric)(func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		if ps.ByName("playbackID") == "denied" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("key"))
+	}))
+
+	for _, path := range []string{"/keys/abc", "/keys/abc", "/keys/denied"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	require.Equal(2.0, testutil.ToFloat64(metric.WithLabelValues("200")))
+	require.Equal(1.0, testutil.ToFloat64(metric.WithLabelValues("401")))
+}
+
+func TestCORS(t *testing.T) {
+	require := require.New(t)
+
+	withCORS := NewCORS(config.CORSConfig{
+		AllowedOrigins: []string{"https://uploader.example.com", "*.livepeer.studio"},
+		AllowedMethods: []string{"GET", "POST", "OPTIONS"},
+		AllowedHeaders: []string{"Authorization", "Content-Type"},
+		MaxAge:         10 * time.Minute,
+	})
+	router := httprouter.New()
+	router.POST("/api/vod", withCORS(IsAuthorized("IAmAuthorized", (&handlers.CatalystAPIHandlersCollection{}).Ok())))
+	router.OPTIONS("/api/vod", withCORS(func(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	request := func(method, origin string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, "/api/vod", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", "POST")
+			req.Header.Set("Access-Control-Request-Headers", "authorization, content-type")
+		} else {
+			req.Header.Set("Authorization", "Bearer IAmAuthorized")
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// preflight is answered without authorization
+	rr := request(http.MethodOptions, "https://uploader.example.com")
+	require.Equal(http.StatusNoContent, rr.Code)
+	require.Equal("https://uploader.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+	require.Equal("GET, POST, OPTIONS", rr.Header().Get("Access-Control-Allow-Methods"))
+	require.Equal("Authorization, Content-Type", rr.Header().Get("Access-Control-Allow-Headers"))
+	require.Equal("600", rr.Header().Get("Access-Control-Max-Age"))
+
+	rr = request(http.MethodOptions, "https://app.livepeer.studio")
+	require.Equal(http.StatusNoContent, rr.Code)
+	require.Equal("https://app.livepeer.studio", rr.Header().Get("Access-Control-Allow-Origin"))
+
+	rr = request(http.MethodOptions, "https://evil.example.com")
+	require.Equal(http.StatusForbidden, rr.Code)
+	require.Empty(rr.Header().Get("Access-Control-Allow-Origin"))
+
+	rr = request(http.MethodPost, "https://uploader.example.com")
+	require.Equal(http.StatusOK, rr.Code)
+	require.Equal("https://uploader.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+	require.Empty(rr.Header().Get("Access-Control-Allow-Methods"))
+
+	// non-browser and disallowed requests still reach the handler, just without CORS headers
+	rr = request(http.MethodPost, "")
+	require.Equal(http.StatusOK, rr.Code)
+	require.Empty(rr.Header().Get("Access-Control-Allow-Origin"))
+	rr = request(http.MethodPost, "https://evil.example.com")
+	require.Equal(http.StatusOK, rr.Code)
+	require.Empty(rr.Header().Get("Access-Control-Allow-Origin"))
+}
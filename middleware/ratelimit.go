@@ -0,0 +1,180 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/config"
+	"github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/handlers"
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/livepeer/catalyst-api/metrics"
+	"github.com/livepeer/catalyst-api/pipeline"
+)
+
+// jobsRetryAfter is how long callers over their concurrent job limit are asked to wait, jobs take minutes
+const jobsRetryAfter = 60 * time.Second
+
+// sweepInterval is how often the state of the idle callers is dropped, so that it doesn't grow with every caller seen
+const sweepInterval = time.Minute
+
+// RateLimiter limits the request rate and the concurrent VOD jobs of each caller, as set by the Authorizer, so
+// that a misbehaving integration can't starve the others
+type RateLimiter struct {
+	cfg config.RateLimitConfig
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	// requests past the job limit check that haven't been stored as jobs yet, by caller
+	pendingJobs map[string]*atomic.Int64
+	lastSweep   time.Time
+}
+
+func NewRateLimiter(cfg config.RateLimitConfig) *RateLimiter {
+	return &RateLimiter{
+		cfg:         cfg,
+		buckets:     map[string]*tokenBucket{},
+		pendingJobs: map[string]*atomic.Int64{},
+		lastSweep:   time.Now(),
+	}
+}
+
+// Limit enforces the request rate and, if vodEngine isn't nil, the concurrent job limit for the caller
+func (l *RateLimiter) Limit(vodEngine *pipeline.Coordinator, next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		release, err := l.Acquire(vodEngine, handlers.Caller(r.Context()))
+		if err != nil {
+			w.Header().Set("Retry-After", strconv.Itoa(err.RetryAfterSecs()))
+			errors.WriteHTTPErrorWithRetry(w, "Rate limit exceeded", http.StatusTooManyRequests, nil, true)
+			return
+		}
+		defer release()
+
+		next(w, r, ps)
+	}
+}
+
+// RateLimitError is the limit a caller is over and how long they should wait before retrying
+type RateLimitError struct {
+	Limit      string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s limit exceeded", e.Limit)
+}
+
+func (e *RateLimitError) RetryAfterSecs() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+// Acquire applies the limits of Limit to a request of the caller, for the APIs not served by the HTTP router.
+// release has to be called once the request is handled if the caller is within their limits.
+func (l *RateLimiter) Acquire(vodEngine *pipeline.Coordinator, caller string) (release func(), err *RateLimitError) {
+	if l.cfg.RequestsPerSecond > 0 {
+		if wait := l.take(caller, time.Now()); wait > 0 {
+			return nil, rateLimited(caller, "rate", wait)
+		}
+	}
+
+	if vodEngine == nil || l.cfg.MaxConcurrentJobs <= 0 {
+		return func() {}, nil
+	}
+	pending, inFlight := l.addPending(caller)
+	release = func() { pending.Add(-1) }
+
+	for _, job := range vodEngine.Jobs.GetJobs() {
+		if job.Caller == caller {
+			inFlight++
+		}
+	}
+	if inFlight > int64(l.cfg.MaxConcurrentJobs) {
+		release()
+		return nil, rateLimited(caller, "concurrent_jobs", jobsRetryAfter)
+	}
+	return release, nil
+}
+
+func rateLimited(caller, limit string, retryAfter time.Duration) *RateLimitError {
+	// the callers are logged rather than a metric label, as there's one per API key and JWT subject
+	log.LogNoRequestID("Rate limited request", "caller", caller, "limit", limit)
+	metrics.Metrics.RateLimitedRequestCount.WithLabelValues(limit).Inc()
+	return &RateLimitError{Limit: limit, RetryAfter: retryAfter}
+}
+
+// take removes a token from the caller's bucket, returning how long until one is available if there's none left
+func (l *RateLimiter) take(caller string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+	b, ok := l.buckets[caller]
+	if !ok {
+		burst := float64(max(l.cfg.Burst, 1))
+		b = &tokenBucket{rate: l.cfg.RequestsPerSecond, burst: burst, tokens: burst, last: now}
+		l.buckets[caller] = b
+	}
+	return b.take(now)
+}
+
+// addPending counts a request of the caller past the job limit check, returning the caller's counter and its new value
+func (l *RateLimiter) addPending(caller string) (*atomic.Int64, int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(time.Now())
+	p, ok := l.pendingJobs[caller]
+	if !ok {
+		p = &atomic.Int64{}
+		l.pendingJobs[caller] = p
+	}
+	// incremented under the lock so that the sweep can't drop the counter in between
+	return p, p.Add(1)
+}
+
+// sweep drops the buckets that have refilled, which are the same as new ones, and the callers without pending
+// requests, at most every sweepInterval. l.mu must be held.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for caller, b := range l.buckets {
+		if b.full(now) {
+			delete(l.buckets, caller)
+		}
+	}
+	for caller, p := range l.pendingJobs {
+		if p.Load() == 0 {
+			delete(l.pendingJobs, caller)
+		}
+	}
+}
+
+// tokenBucket is guarded by RateLimiter.mu
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// take removes a token, returning how long until one is available if there's none left
+func (b *tokenBucket) take(now time.Time) time.Duration {
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	}
+	b.tokens--
+	return 0
+}
+
+// full is whether the bucket has refilled to its burst by now
+func (b *tokenBucket) full(now time.Time) bool {
+	return b.tokens+now.Sub(b.last).Seconds()*b.rate >= b.burst
+}
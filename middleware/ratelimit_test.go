@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/livepeer/catalyst-api/config"
+	"github.com/livepeer/catalyst-api/handlers"
+	"github.com/livepeer/catalyst-api/metrics"
+	"github.com/livepeer/catalyst-api/pipeline"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+	b := &tokenBucket{rate: 2, burst: 2, tokens: 2, last: now}
+
+	require.Zero(t, b.take(now))
+	require.Zero(t, b.take(now))
+	require.Equal(t, 500*time.Millisecond, b.take(now))
+	// tokens refill at the rate, up to the burst
+	require.Zero(t, b.take(now.Add(500*time.Millisecond)))
+	require.Zero(t, b.take(now.Add(10*time.Second)))
+	require.Zero(t, b.take(now.Add(10*time.Second)))
+	require.NotZero(t, b.take(now.Add(10*time.Second)))
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter := NewRateLimiter(config.RateLimitConfig{RequestsPerSecond: 0.001, Burst: 2})
+	handler := limiter.Limit(nil, func(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+		w.WriteHeader(http.StatusOK)
+	})
+	request := func(caller string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/vod", nil)
+		req = req.WithContext(handlers.WithCaller(req.Context(), caller))
+		rr := httptest.NewRecorder()
+		handler(rr, req, nil)
+		return rr
+	}
+
+	before := testutil.ToFloat64(metrics.Metrics.RateLimitedRequestCount.WithLabelValues("rate"))
+	require.Equal(t, http.StatusOK, request("key:noisy").Code)
+	require.Equal(t, http.StatusOK, request("key:noisy").Code)
+	rr := request("key:noisy")
+	require.Equal(t, http.StatusTooManyRequests, rr.Code)
+	require.Equal(t, "1000", rr.Header().Get("Retry-After"))
+	require.JSONEq(t, `{"error": "Rate limit exceeded", "error_detail": "", "retryable": true}`, rr.Body.String())
+	require.Equal(t, before+1, testutil.ToFloat64(metrics.Metrics.RateLimitedRequestCount.WithLabelValues("rate")))
+
+	// other callers have their own limit
+	require.Equal(t, http.StatusOK, request("key:quiet").Code)
+}
+
+func TestRateLimiterSweepsIdleCallers(t *testing.T) {
+	limiter := NewRateLimiter(config.RateLimitConfig{RequestsPerSecond: 1, Burst: 2})
+	now := time.Now()
+	require.Zero(t, limiter.take("key:idle", now))
+	require.Zero(t, limiter.take("key:busy", now))
+	require.Zero(t, limiter.take("key:busy", now))
+	pending, _ := limiter.addPending("key:busy")
+	_, _ = limiter.addPending("key:done")
+	limiter.pendingJobs["key:done"].Add(-1)
+
+	// the buckets that refilled and the callers without pending requests are dropped after the sweep interval
+	later := now.Add(sweepInterval)
+	limiter.buckets["key:busy"].last = later
+	limiter.mu.Lock()
+	limiter.sweep(later)
+	limiter.mu.Unlock()
+	require.NotContains(t, limiter.buckets, "key:idle")
+	require.Contains(t, limiter.buckets, "key:busy")
+	require.NotContains(t, limiter.pendingJobs, "key:done")
+	require.Same(t, pending, limiter.pendingJobs["key:busy"])
+}
+
+func TestRateLimiterConcurrentJobs(t *testing.T) {
+	vodEngine := pipeline.NewStubCoordinator()
+	vodEngine.Jobs.Store("job-1", &pipeline.JobInfo{UploadJobPayload: pipeline.UploadJobPayload{Caller: "key:busy"}})
+	vodEngine.Jobs.Store("job-2", &pipeline.JobInfo{UploadJobPayload: pipeline.UploadJobPayload{Caller: "key:busy"}})
+	vodEngine.Jobs.Store("job-3", &pipeline.JobInfo{UploadJobPayload: pipeline.UploadJobPayload{Caller: "key:other"}})
+
+	limiter := NewRateLimiter(config.RateLimitConfig{MaxConcurrentJobs: 2})
+	handler := limiter.Limit(vodEngine, func(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+		w.WriteHeader(http.StatusOK)
+	})
+	request := func(caller string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/vod", nil)
+		req = req.WithContext(handlers.WithCaller(req.Context(), caller))
+		rr := httptest.NewRecorder()
+		handler(rr, req, nil)
+		return rr
+	}
+
+	rr := request("key:busy")
+	require.Equal(t, http.StatusTooManyRequests, rr.Code)
+	require.Equal(t, "60", rr.Header().Get("Retry-After"))
+	require.Equal(t, http.StatusOK, request("key:other").Code)
+
+	vodEngine.Jobs.Remove("job-1")
+	require.Equal(t, http.StatusOK, request("key:busy").Code)
+}
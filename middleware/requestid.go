@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+	"regexp"
+
+	"github.com/livepeer/catalyst-api/config"
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/livepeer/catalyst-api/requests"
+)
+
+// validRequestID limits the accepted request IDs to ones that are safe to use in stream names, paths and logs
+var validRequestID = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// RequestID attaches the X-Request-ID of the request, or a new one when it's missing or invalid, to the request
+// context and echoes it back in the response, so that all the logs and outbound calls of the request are correlated
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := AcceptRequestID(r.Header.Get(requests.RequestIDHeader))
+		w.Header().Set(requests.RequestIDHeader, requestID)
+		next.ServeHTTP(w, r.WithContext(log.WithRequestID(r.Context(), requestID)))
+	})
+}
+
+// AcceptRequestID returns the request ID sent by the caller if it's valid, or a new one otherwise
+func AcceptRequestID(requestID string) string {
+	if !validRequestID.MatchString(requestID) {
+		return config.RandomTrailer(8)
+	}
+	return requestID
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/livepeer/catalyst-api/requests"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	var received string
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = log.RequestID(r.Context())
+		require.Equal(t, received, requests.GetRequestId(r))
+	}))
+
+	// the ID sent by the caller is used
+	req := httptest.NewRequest(http.MethodGet, "/ok", nil)
+	req.Header.Set("X-Request-ID", "abc-123_DEF")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, "abc-123_DEF", received)
+	require.Equal(t, "abc-123_DEF", rr.Header().Get("X-Request-ID"))
+
+	// otherwise a new one is generated
+	for _, requestID := range []string{"", "has spaces", "../../etc", string(make([]byte, 65))} {
+		req = httptest.NewRequest(http.MethodGet, "/ok", nil)
+		req.Header.Set("X-Request-ID", requestID)
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		require.NotEmpty(t, received)
+		require.NotEqual(t, requestID, received)
+		require.Equal(t, received, rr.Header().Get("X-Request-ID"))
+	}
+}
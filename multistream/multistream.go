@@ -0,0 +1,289 @@
+package multistream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/livepeer/catalyst-api/clients"
+	"github.com/livepeer/catalyst-api/config"
+	"github.com/livepeer/catalyst-api/handlers/misttriggers"
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/livepeer/catalyst-api/metrics"
+)
+
+// States of a restream target
+const (
+	// StateIdle is a target of a stream that isn't live on this node
+	StateIdle = "idle"
+	// StateConnecting is a target that Mist hasn't started pushing to yet
+	StateConnecting = "connecting"
+	// StateActive is a target that Mist is pushing to
+	StateActive = "active"
+	// StateFailed is a target whose push ended while the stream is still live, Mist retries it
+	StateFailed = "failed"
+)
+
+var states = []string{StateIdle, StateConnecting, StateActive, StateFailed}
+
+var ErrNotFound = errors.New("restream target not found")
+
+var targetSchemes = []string{"rtmp", "rtmps", "srt"}
+
+// Target is an RTMP(S) or SRT destination a stream is restreamed to
+type Target struct {
+	Name string `json:"name,omitempty"`
+	URL  string `json:"url"`
+}
+
+// Validate checks the target can be pushed to by Mist
+func (t Target) Validate() error {
+	u, err := url.Parse(t.URL)
+	if err != nil {
+		return fmt.Errorf("invalid target url: %w", err)
+	}
+	for _, scheme := range targetSchemes {
+		if strings.EqualFold(u.Scheme, scheme) {
+			if u.Host == "" {
+				return fmt.Errorf("target url has no host")
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported target url scheme %q, use one of %s", u.Scheme, strings.Join(targetSchemes, ", "))
+}
+
+// TargetStatus is the health of a restream target. The target URL isn't included since it usually carries the
+// stream key of the destination.
+type TargetStatus struct {
+	ID            string     `json:"id"`
+	Name          string     `json:"name,omitempty"`
+	State         string     `json:"state"`
+	ActiveSeconds int64      `json:"active_seconds,omitempty"`
+	Bytes         int64      `json:"bytes,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+	LastErrorAt   *time.Time `json:"last_error_at,omitempty"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+type target struct {
+	Target
+	status TargetStatus
+}
+
+// Manager restreams the streams ingested on this node to the targets registered for them, with a Mist AUTO_PUSH for
+// each target so that Mist starts the push whenever the stream goes live. The targets are kept in memory, so they
+// have to be registered on the node ingesting the stream and again after a restart.
+type Manager struct {
+	mist           clients.MistAPIClient
+	baseStreamName string
+	interval       time.Duration
+
+	mu      sync.Mutex
+	streams map[string]map[string]*target // playback ID to target ID to target
+}
+
+func NewManager(mist clients.MistAPIClient, baseStreamName string, interval time.Duration) *Manager {
+	return &Manager{
+		mist:           mist,
+		baseStreamName: baseStreamName,
+		interval:       interval,
+		streams:        map[string]map[string]*target{},
+	}
+}
+
+// Start refreshes the status of the targets and re-adds the pushes Mist lost, e.g. when it restarted
+func (m *Manager) Start(ctx context.Context) error {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			m.reconcile()
+		}
+	}
+}
+
+// Add registers restream targets for a stream and starts pushing to them as soon as the stream is live
+func (m *Manager) Add(playbackID string, targets []Target) ([]TargetStatus, error) {
+	for _, t := range targets {
+		if err := t.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	streamName := m.streamName(playbackID)
+	var added []TargetStatus
+	for _, t := range targets {
+		if err := m.mist.PushAutoAdd(streamName, t.URL); err != nil {
+			return added, fmt.Errorf("error adding mist push for target %q: %w", t.Name, err)
+		}
+		tgt := &target{Target: t, status: TargetStatus{
+			ID:        config.RandomTrailer(12),
+			Name:      t.Name,
+			State:     StateIdle,
+			UpdatedAt: time.Now().UTC(),
+		}}
+		m.mu.Lock()
+		if m.streams[playbackID] == nil {
+			m.streams[playbackID] = map[string]*target{}
+		}
+		m.streams[playbackID][tgt.status.ID] = tgt
+		m.mu.Unlock()
+		added = append(added, tgt.status)
+	}
+	m.reconcile()
+	return m.statuses(playbackID, added), nil
+}
+
+// Remove stops restreaming a stream to a target
+func (m *Manager) Remove(playbackID, targetID string) error {
+	m.mu.Lock()
+	tgt, ok := m.streams[playbackID][targetID]
+	if ok {
+		delete(m.streams[playbackID], targetID)
+		if len(m.streams[playbackID]) == 0 {
+			delete(m.streams, playbackID)
+		}
+	}
+	m.mu.Unlock()
+	if !ok {
+		return ErrNotFound
+	}
+
+	streamName := m.streamName(playbackID)
+	if err := m.mist.PushAutoRemove([]interface{}{streamName, tgt.URL}); err != nil {
+		return fmt.Errorf("error removing mist push for target %s: %w", targetID, err)
+	}
+	// removing the AUTO_PUSH doesn't stop a running push
+	state, err := m.mist.GetState()
+	if err != nil {
+		return fmt.Errorf("error getting mist state: %w", err)
+	}
+	for _, push := range state.PushList {
+		if push.Stream == streamName && push.OriginalURL == tgt.URL {
+			if err := m.mist.PushStop(push.ID); err != nil {
+				return fmt.Errorf("error stopping mist push for target %s: %w", targetID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Status returns the status of each target of a stream
+func (m *Manager) Status(playbackID string) ([]TargetStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	targets, ok := m.streams[playbackID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	statuses := []TargetStatus{}
+	for _, t := range targets {
+		statuses = append(statuses, t.status)
+	}
+	return statuses, nil
+}
+
+// HandlePushEnd records why the push to a target ended, Mist's AUTO_PUSH restarts it
+func (m *Manager) HandlePushEnd(ctx context.Context, payload *misttriggers.PushEndPayload) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for playbackID, targets := range m.streams {
+		if m.streamName(playbackID) != payload.StreamName {
+			continue
+		}
+		for _, t := range targets {
+			if t.URL != payload.Destination {
+				continue
+			}
+			now := time.Now().UTC()
+			t.status.State = StateFailed
+			t.status.LastError = payload.Last10LogLines
+			t.status.LastErrorAt = &now
+			t.status.UpdatedAt = now
+			log.LogCtx(ctx, "restream target push ended", "target", t.status.ID, "log", payload.Last10LogLines)
+		}
+	}
+	return nil
+}
+
+func (m *Manager) reconcile() {
+	state, err := m.mist.GetState()
+	if err != nil {
+		log.LogNoRequestID("multistream failed to get mist state", "err", err)
+		return
+	}
+
+	autoPushes := map[[2]string]bool{}
+	for _, p := range state.PushAutoList {
+		autoPushes[[2]string{p.Stream, p.Target}] = true
+	}
+	pushes := map[[2]string]*clients.MistPush{}
+	for _, p := range state.PushList {
+		pushes[[2]string{p.Stream, p.OriginalURL}] = p
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	counts := map[string]int{}
+	now := time.Now().UTC()
+	for playbackID, targets := range m.streams {
+		streamName := m.streamName(playbackID)
+		_, live := state.ActiveStreams[streamName]
+		for _, t := range targets {
+			key := [2]string{streamName, t.URL}
+			if !autoPushes[key] {
+				log.LogNoRequestID("re-adding missing mist push for restream target", log.KeyStream, streamName, "target", t.status.ID)
+				if err := m.mist.PushAutoAdd(streamName, t.URL); err != nil {
+					log.LogNoRequestID("failed to re-add mist push for restream target", log.KeyStream, streamName, "target", t.status.ID, "err", err)
+				}
+			}
+
+			previous := t.status.State
+			push, pushing := pushes[key]
+			switch {
+			case pushing:
+				t.status.State = StateActive
+				if push.Stats != nil {
+					t.status.ActiveSeconds = push.Stats.ActiveSeconds
+					t.status.Bytes = push.Stats.Bytes
+				}
+			case !live:
+				t.status.State = StateIdle
+				t.status.ActiveSeconds, t.status.Bytes = 0, 0
+			case previous != StateFailed:
+				t.status.State = StateConnecting
+			}
+			if t.status.State != previous || pushing {
+				t.status.UpdatedAt = now
+			}
+			counts[t.status.State]++
+		}
+	}
+	for _, s := range states {
+		metrics.Metrics.MultistreamTargets.WithLabelValues(s).Set(float64(counts[s]))
+	}
+}
+
+// statuses returns the current status of the given targets
+func (m *Manager) statuses(playbackID string, targets []TargetStatus) []TargetStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []TargetStatus
+	for _, t := range targets {
+		if current, ok := m.streams[playbackID][t.ID]; ok {
+			res = append(res, current.status)
+		}
+	}
+	return res
+}
+
+func (m *Manager) streamName(playbackID string) string {
+	return m.baseStreamName + "+" + playbackID
+}
@@ -0,0 +1,105 @@
+package multistream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/livepeer/catalyst-api/clients"
+	"github.com/livepeer/catalyst-api/handlers/misttriggers"
+	mockmistclient "github.com/livepeer/catalyst-api/mocks/clients"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	playbackID = "abc123"
+	streamName = "video+abc123"
+	targetURL  = "rtmp://a.rtmp.youtube.com/live2/secret-key"
+)
+
+func TestTargetValidate(t *testing.T) {
+	require.NoError(t, Target{URL: "rtmp://a.rtmp.youtube.com/live2/key"}.Validate())
+	require.NoError(t, Target{URL: "rtmps://live-api-s.facebook.com:443/rtmp/key"}.Validate())
+	require.NoError(t, Target{URL: "srt://ingest.example.com:9999?streamid=key"}.Validate())
+	require.Error(t, Target{URL: "https://example.com/live"}.Validate())
+	require.Error(t, Target{URL: "rtmp:///live/key"}.Validate())
+	require.Error(t, Target{URL: ""}.Validate())
+}
+
+func TestManagerLifecycle(t *testing.T) {
+	mist := mockmistclient.NewMockMistAPIClient(gomock.NewController(t))
+	m := NewManager(mist, "video", time.Minute)
+
+	_, err := m.Status(playbackID)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	// the stream isn't live yet
+	mist.EXPECT().PushAutoAdd(streamName, targetURL).Return(nil)
+	mist.EXPECT().GetState().Return(clients.MistState{
+		PushAutoList: []*clients.MistPushAuto{{Stream: streamName, Target: targetURL}},
+	}, nil)
+	added, err := m.Add(playbackID, []Target{{Name: "youtube", URL: targetURL}})
+	require.NoError(t, err)
+	require.Len(t, added, 1)
+	require.Equal(t, "youtube", added[0].Name)
+	require.Equal(t, StateIdle, added[0].State)
+	targetID := added[0].ID
+
+	// live, but Mist hasn't started the push
+	mist.EXPECT().GetState().Return(clients.MistState{
+		ActiveStreams: map[string]*clients.ActiveStream{streamName: {}},
+		PushAutoList:  []*clients.MistPushAuto{{Stream: streamName, Target: targetURL}},
+	}, nil)
+	m.reconcile()
+	statuses, err := m.Status(playbackID)
+	require.NoError(t, err)
+	require.Equal(t, StateConnecting, statuses[0].State)
+
+	// pushing, after Mist restarted and lost the AUTO_PUSH
+	mist.EXPECT().GetState().Return(clients.MistState{
+		ActiveStreams: map[string]*clients.ActiveStream{streamName: {}},
+		PushList: []*clients.MistPush{{ID: 7, Stream: streamName, OriginalURL: targetURL, Stats: &clients.MistPushStats{
+			ActiveSeconds: 12,
+			Bytes:         3456,
+		}}},
+	}, nil)
+	mist.EXPECT().PushAutoAdd(streamName, targetURL).Return(nil)
+	m.reconcile()
+	statuses, err = m.Status(playbackID)
+	require.NoError(t, err)
+	require.Equal(t, StateActive, statuses[0].State)
+	require.Equal(t, int64(12), statuses[0].ActiveSeconds)
+	require.Equal(t, int64(3456), statuses[0].Bytes)
+
+	require.NoError(t, m.HandlePushEnd(context.Background(), &misttriggers.PushEndPayload{
+		StreamName:     streamName,
+		Destination:    targetURL,
+		Last10LogLines: "connection refused",
+	}))
+	statuses, err = m.Status(playbackID)
+	require.NoError(t, err)
+	require.Equal(t, StateFailed, statuses[0].State)
+	require.Equal(t, "connection refused", statuses[0].LastError)
+	require.NotNil(t, statuses[0].LastErrorAt)
+
+	require.ErrorIs(t, m.Remove(playbackID, "unknown"), ErrNotFound)
+	mist.EXPECT().PushAutoRemove([]interface{}{streamName, targetURL}).Return(nil)
+	mist.EXPECT().GetState().Return(clients.MistState{
+		PushList: []*clients.MistPush{{ID: 7, Stream: streamName, OriginalURL: targetURL}},
+	}, nil)
+	mist.EXPECT().PushStop(int64(7)).Return(nil)
+	require.NoError(t, m.Remove(playbackID, targetID))
+	_, err = m.Status(playbackID)
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestManagerRejectsInvalidTargets(t *testing.T) {
+	mist := mockmistclient.NewMockMistAPIClient(gomock.NewController(t))
+	m := NewManager(mist, "video", time.Minute)
+
+	_, err := m.Add(playbackID, []Target{{URL: targetURL}, {URL: "http://example.com"}})
+	require.Error(t, err)
+	_, err = m.Status(playbackID)
+	require.ErrorIs(t, err, ErrNotFound, "no targets are added if any is invalid")
+}
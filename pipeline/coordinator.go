@@ -1,7 +1,7 @@
 package pipeline
 
 import (
-	"crypto/rsa"
+	"context"
 	"database/sql"
 	"fmt"
 	"math"
@@ -12,6 +12,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
@@ -22,8 +23,10 @@ import (
 	"github.com/livepeer/catalyst-api/config"
 	"github.com/livepeer/catalyst-api/crypto"
 	"github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/featureflags"
 	"github.com/livepeer/catalyst-api/log"
 	"github.com/livepeer/catalyst-api/metrics"
+	"github.com/livepeer/catalyst-api/thumbnails"
 	"github.com/livepeer/catalyst-api/video"
 )
 
@@ -64,6 +67,7 @@ type UploadJobPayload struct {
 	FragMp4TargetURL      *url.URL
 	ClipTargetURL         *url.URL
 	ThumbnailsTargetURL   *url.URL
+	ThumbnailOptions      thumbnails.Options
 	Mp4OnlyShort          bool
 	AccessToken           string
 	TranscodeAPIUrl       string
@@ -71,6 +75,16 @@ type UploadJobPayload struct {
 	RequestID             string
 	ExternalID            string
 	Profiles              []video.EncodedProfile
+	// DefaultProfiles is the ladder used when no profiles are requested, the global default if nil
+	DefaultProfiles []video.EncodedProfile
+	// LadderConstraints limit the ladder generated when no profiles are requested, if any
+	LadderConstraints *video.LadderConstraints
+	// AudioOptions change the audio of every rendition, if any
+	AudioOptions *video.AudioOptions
+	// OutputNaming templates the paths of the outputs, the default layout if nil
+	OutputNaming *video.OutputNaming
+	// HLSEncryption encrypts the segments of the HLS output, if any
+	HLSEncryption         *video.HLSEncryption
 	PipelineStrategy      Strategy
 	TargetSegmentSizeSecs int64
 	GenerateMP4           bool
@@ -79,10 +93,28 @@ type UploadJobPayload struct {
 	SourceCopy            bool
 	ClipStrategy          video.ClipStrategy
 	C2PA                  bool
+	// SignedURLTTL adds signed URLs of the outputs that expire after it to the completion callback, when positive
+	SignedURLTTL time.Duration
+	// Caller is the API key or JWT subject that submitted the job, for per-caller limits
+	Caller string
+	// Tenant the caller belongs to, if any, whose callback policy is used
+	Tenant string
+	// MaxSourceDuration and MaxSourceBytes are the tenant's limits on the source, checked once it's probed. 0 is no
+	// limit.
+	MaxSourceDuration time.Duration
+	MaxSourceBytes    int64
 }
 
 type EncryptionPayload struct {
-	EncryptedKey string `json:"encrypted_key"`
+	EncryptedKey string `json:"encrypted_key,omitempty"`
+	// Algorithm the source was encrypted with, aes-cbc (the default) or aes-gcm
+	Algorithm string `json:"algorithm,omitempty"`
+	// KeyID of the catalyst public key the key was encrypted with, as returned by /api/pubkey
+	KeyID string `json:"key_id,omitempty"`
+	// KeyWrapping is how the key is shared with catalyst, rsa-oaep (the default) to use encrypted_key or x25519
+	// to derive it from ephemeral_public_key
+	KeyWrapping        string `json:"key_wrapping,omitempty"`
+	EphemeralPublicKey string `json:"ephemeral_public_key,omitempty"`
 }
 
 // UploadJobResult is the object returned by the successful execution of an
@@ -100,9 +132,16 @@ type JobInfo struct {
 	StreamName string
 	// this is only set&used internally in the mist pipeline
 	SegmentingTargetURL string
+	// tracks thumbnail generation to report in status callbacks, nil if thumbnails weren't requested
+	ThumbnailProgress *thumbnails.Progress
 
 	statusClient clients.TranscodeStatusClient
 
+	// the last progress reported, guarded separately as mu is held while the job's handlers run
+	progressMu      sync.Mutex
+	stage           clients.TranscodeStatus
+	completionRatio float64
+
 	SourcePlaybackDone time.Time
 	DownloadDone       time.Time
 	SegmentingDone     time.Time
@@ -131,6 +170,9 @@ type JobInfo struct {
 	SignedSourceURL       string
 	LivepeerSupported     bool
 	C2PA                  *c2pa.C2PA
+
+	// releaseBandwidth lifts the job's bandwidth caps once it's finished, see clients.LimitJobBandwidth
+	releaseBandwidth func()
 }
 
 // PipelineInfo represents the state of an individual pipeline, i.e. ffmpeg or mediaconvert
@@ -146,7 +188,12 @@ type PipelineInfo struct {
 }
 
 func (j *JobInfo) ReportProgress(stage clients.TranscodeStatus, completionRatio float64) {
+	j.progressMu.Lock()
+	j.stage, j.completionRatio = stage, completionRatio
+	j.progressMu.Unlock()
+
 	tsm := clients.NewTranscodeStatusProgress(j.CallbackURL, j.RequestID, stage, completionRatio)
+	tsm.Thumbnails = j.ThumbnailProgress.Status()
 	// Ignore errors, send the progress next time
 	_ = j.statusClient.SendTranscodeStatus(tsm)
 }
@@ -164,15 +211,23 @@ type Coordinator struct {
 
 	pipeFfmpeg, pipeExternal Handler
 
-	Jobs                 *cache.Cache[*JobInfo]
-	MetricsDB            *sql.DB
-	InputCopy            clients.InputCopier
-	VodDecryptPrivateKey *rsa.PrivateKey
-	SourceOutputURL      *url.URL
-	C2PA                 *c2pa.C2PA
+	Jobs           *cache.Cache[*JobInfo]
+	MetricsDB      *sql.DB
+	InputCopy      clients.InputCopier
+	VodDecryptKeys crypto.KeyProvider
+	// VodX25519Key is used for inputs with the x25519 key wrapping, optional
+	VodX25519Key    *crypto.X25519Key
+	SourceOutputURL *url.URL
+	C2PA            *c2pa.C2PA
+	// TenantStatusClients send the callbacks of the tenants with their own callback policy
+	TenantStatusClients map[string]clients.TranscodeStatusClient
+	// HLSKeySecret is what the keys of encrypted HLS outputs are derived from, encryption is disabled if nil
+	HLSKeySecret []byte
+
+	shuttingDown atomic.Bool
 }
 
-func NewCoordinator(strategy Strategy, sourceOutputURL, extTranscoderURL string, statusClient clients.TranscodeStatusClient, metricsDB *sql.DB, VodDecryptPrivateKey *rsa.PrivateKey, broadcasterURL string, sourcePlaybackHosts map[string]string, c2pa *c2pa.C2PA) (*Coordinator, error) {
+func NewCoordinator(strategy Strategy, sourceOutputURL, extTranscoderURL string, statusClient clients.TranscodeStatusClient, metricsDB *sql.DB, vodDecryptKeys crypto.KeyProvider, broadcasterURL string, sourcePlaybackHosts map[string]string, c2pa *c2pa.C2PA) (*Coordinator, error) {
 	if !strategy.IsValid() {
 		return nil, fmt.Errorf("invalid strategy: %s", strategy)
 	}
@@ -206,13 +261,13 @@ func NewCoordinator(strategy Strategy, sourceOutputURL, extTranscoderURL string,
 			probe:               video.Probe{},
 			sourcePlaybackHosts: sourcePlaybackHosts,
 		},
-		pipeExternal:         &external{extTranscoder},
-		Jobs:                 cache.New[*JobInfo](),
-		MetricsDB:            metricsDB,
-		InputCopy:            clients.NewInputCopy(),
-		VodDecryptPrivateKey: VodDecryptPrivateKey,
-		SourceOutputURL:      sourceOutput,
-		C2PA:                 c2pa,
+		pipeExternal:    &external{extTranscoder},
+		Jobs:            cache.New[*JobInfo](),
+		MetricsDB:       metricsDB,
+		InputCopy:       clients.NewInputCopy(),
+		VodDecryptKeys:  vodDecryptKeys,
+		SourceOutputURL: sourceOutput,
+		C2PA:            c2pa,
 	}, nil
 }
 
@@ -252,10 +307,14 @@ func NewStubCoordinatorOpts(strategy Strategy, statusClient clients.TranscodeSta
 // handles processing the response and triggering a fallback if appropriate.
 func (c *Coordinator) StartUploadJob(p UploadJobPayload) {
 	streamName := config.SegmentingStreamName(p.RequestID)
-	log.AddContext(p.RequestID, "stream_name", streamName)
+	log.AddContext(p.RequestID, log.KeyStream, streamName)
+	statusClient := c.statusClient
+	if tenantClient, ok := c.TenantStatusClients[p.Tenant]; ok {
+		statusClient = tenantClient
+	}
 	si := &JobInfo{
 		UploadJobPayload: p,
-		statusClient:     c.statusClient,
+		statusClient:     statusClient,
 		StreamName:       streamName,
 
 		numProfiles:    len(p.Profiles),
@@ -265,6 +324,12 @@ func (c *Coordinator) StartUploadJob(p UploadJobPayload) {
 			state:     "segmenting",
 		},
 	}
+	if p.ThumbnailsTargetURL != nil {
+		si.ThumbnailProgress = thumbnails.NewProgress()
+	}
+	// the source is either copied to the job's transfer location, or to its HLS output with SourceCopy
+	sourceURL, _ := url.Parse(p.SourceFile)
+	si.releaseBandwidth = clients.LimitJobBandwidth(sourceURL, c.SourceOutputURL.JoinPath(p.RequestID), p.HlsTargetURL, p.Mp4TargetURL, p.FragMp4TargetURL, p.ThumbnailsTargetURL, p.ClipTargetURL)
 	si.ReportProgress(clients.TranscodeStatusPreparing, 0)
 	c.Jobs.Store(streamName, si)
 	log.Log(si.RequestID, "Wrote to jobs cache")
@@ -280,8 +345,14 @@ func (c *Coordinator) StartUploadJob(p UploadJobPayload) {
 
 		if p.Encryption != nil {
 			decryptor = &crypto.DecryptionKeys{
-				DecryptKey:   c.VodDecryptPrivateKey,
+				Provider:     c.VodDecryptKeys,
 				EncryptedKey: p.Encryption.EncryptedKey,
+				Algorithm:    p.Encryption.Algorithm,
+				KeyID:        p.Encryption.KeyID,
+
+				KeyWrapping:        p.Encryption.KeyWrapping,
+				EphemeralPublicKey: p.Encryption.EphemeralPublicKey,
+				X25519:             c.VodX25519Key,
 			}
 		}
 
@@ -324,8 +395,16 @@ func (c *Coordinator) StartUploadJob(p UploadJobPayload) {
 			osTransferURL = p.HlsTargetURL.JoinPath("video")
 		}
 
-		inputVideoProbe, signedNewSourceURL, err := c.InputCopy.CopyInputToS3(p.RequestID, sourceURL, osTransferURL, decryptor)
+		// the copy is the start of the preparing stage, which the pipelines report from 0.3
+		copyProgress := func(completionRatio float64) {
+			si.ReportProgress(clients.TranscodeStatusPreparing, 0.3*completionRatio)
+		}
+		inputVideoProbe, signedNewSourceURL, err := c.InputCopy.CopyInputToS3(p.RequestID, sourceURL, osTransferURL, decryptor, copyProgress)
 		if err != nil {
+			return nil, errors.WithFailureReason(errors.FailureSourceDownload, fmt.Errorf("error copying input to storage: %w", err))
+		}
+
+		if err := checkSourceLimits(p, inputVideoProbe); err != nil {
 			return nil, fmt.Errorf("error copying input to storage: %w", err)
 		}
 
@@ -353,6 +432,23 @@ func (c *Coordinator) StartUploadJob(p UploadJobPayload) {
 	})
 }
 
+// checkSourceLimits fails jobs whose source is longer or larger than the tenant allows, before any of it is transcoded
+func checkSourceLimits(p UploadJobPayload, iv video.InputVideo) error {
+	duration := iv.Duration
+	if videoTrack, err := iv.GetTrack(video.TrackTypeVideo); err == nil && videoTrack.DurationSec > duration {
+		duration = videoTrack.DurationSec
+	}
+	if p.MaxSourceDuration > 0 && duration > p.MaxSourceDuration.Seconds() {
+		err := fmt.Errorf("source duration %.1fs is above the limit of %s", duration, p.MaxSourceDuration)
+		return errors.Unretriable(errors.WithFailureReason(errors.FailureInvalidSource, errors.WithCode(errors.CodeSourceTooLong, err)))
+	}
+	if p.MaxSourceBytes > 0 && iv.SizeBytes > p.MaxSourceBytes {
+		err := fmt.Errorf("source size %d bytes is above the limit of %d bytes", iv.SizeBytes, p.MaxSourceBytes)
+		return errors.Unretriable(errors.WithFailureReason(errors.FailureInvalidSource, errors.WithCode(errors.CodeSourceTooLarge, err)))
+	}
+	return nil
+}
+
 func checkClipResolution(p UploadJobPayload, inputVideoProbe *video.InputVideo, originalSource *url.URL) {
 	// HACK: sometimes probing the clip manifest results in zero height and width, probe the original manifest instead to get this info
 	if !p.ClipStrategy.Enabled {
@@ -415,6 +511,8 @@ func (c *Coordinator) startUploadJob(p *JobInfo) {
 	strategy := c.strategy
 	if p.PipelineStrategy.IsValid() {
 		strategy = p.PipelineStrategy
+	} else if strategy == StrategyCatalystFfmpegDominance && c.hasExternalTranscoder() && featureflags.Enabled(featureflags.VODFallbackExternal, p.RequestID) {
+		strategy = StrategyFallbackExternal
 	}
 	p.LivepeerSupported, strategy = checkLivepeerCompatible(p.RequestID, strategy, p.InputFileInfo)
 	log.AddContext(p.RequestID, "strategy", strategy)
@@ -439,6 +537,11 @@ func (c *Coordinator) startUploadJob(p *JobInfo) {
 	}
 }
 
+func (c *Coordinator) hasExternalTranscoder() bool {
+	ext, ok := c.pipeExternal.(*external)
+	return ok && ext.transcoder != nil
+}
+
 // checkLivepeerCompatible checks if the input codecs are compatible with our Livepeer pipeline and overrides the pipeline strategy
 // to external if they are incompatible
 func checkLivepeerCompatible(requestID string, strategy Strategy, iv video.InputVideo) (bool, Strategy) {
@@ -578,6 +681,66 @@ func (c *Coordinator) startOneUploadJob(si *JobInfo, handler Handler, hasFallbac
 	return si.result
 }
 
+// JobStatus is a snapshot of the progress of an in-flight job
+type JobStatus struct {
+	RequestID        string
+	ExternalID       string
+	PipelineStrategy Strategy
+	Stage            clients.TranscodeStatus
+	// CompletionRatio of the current stage, from 0 to 1
+	CompletionRatio float64
+}
+
+// JobStatus returns the progress of a job, false if it isn't in flight on this node. Finished jobs are removed
+// from the jobs cache, so their outcome is only reported by the status callbacks.
+func (c *Coordinator) JobStatus(requestID string) (JobStatus, bool) {
+	job := c.Jobs.Get(config.SegmentingStreamName(requestID))
+	if job == nil {
+		return JobStatus{}, false
+	}
+	job.progressMu.Lock()
+	defer job.progressMu.Unlock()
+	return JobStatus{
+		RequestID:        job.RequestID,
+		ExternalID:       job.ExternalID,
+		PipelineStrategy: job.PipelineStrategy,
+		Stage:            job.stage,
+		CompletionRatio:  job.completionRatio,
+	}, true
+}
+
+// ShuttingDown is whether Shutdown has been called, new jobs shouldn't be accepted once it has
+func (c *Coordinator) ShuttingDown() bool {
+	return c.shuttingDown.Load()
+}
+
+// Shutdown waits for the in-flight jobs to finish. Jobs still running when the context is done are failed with a
+// retriable error callback, so that they can be resubmitted to another node instead of being left hanging.
+func (c *Coordinator) Shutdown(ctx context.Context) error {
+	c.shuttingDown.Store(true)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		jobs := c.Jobs.GetJobs()
+		if len(jobs) == 0 {
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			for _, job := range jobs {
+				log.Log(job.RequestID, "Failing job interrupted by shutdown")
+				tsm := clients.NewTranscodeStatusError(job.CallbackURL, job.RequestID, "job interrupted by node shutdown", false)
+				if err := job.statusClient.SendTranscodeStatus(tsm); err != nil {
+					log.LogError(job.RequestID, "failed sending shutdown callback", err)
+				}
+			}
+			return fmt.Errorf("%d jobs still in flight: %w", len(jobs), ctx.Err())
+		}
+	}
+}
+
 // runHandlerAsync starts a background go-routine to run the handler function
 // safely. It locks on the JobInfo object to allow safe mutations inside the
 // handler. It also handles panics and errors, turning them into a transcode
@@ -610,11 +773,25 @@ func (c *Coordinator) finishJob(job *JobInfo, out *HandlerOutput, err error) {
 			callbackURL = ""
 		}
 		tsm = clients.NewTranscodeStatusError(callbackURL, job.RequestID, err.Error(), errors.IsUnretriable(err))
+		tsm.ErrorCode = errors.Code(err)
 		job.state = "failed"
 	} else {
 		tsm = clients.NewTranscodeStatusCompleted(job.CallbackURL, job.RequestID, out.Result.InputVideo, out.Result.Outputs)
 		job.state = "completed"
 	}
+	tsm.Thumbnails = job.ThumbnailProgress.Status()
+	if err == nil {
+		tsm.Checksums = clients.WriteChecksumManifests(job.RequestID, job.HlsTargetURL, job.Mp4TargetURL, job.FragMp4TargetURL)
+		if job.SignedURLTTL > 0 {
+			clients.SignOutputs(job.RequestID, tsm.Outputs, job.SignedURLTTL)
+		}
+	} else {
+		// the outputs of a failed job are redone by its retry or fallback
+		for _, u := range []*url.URL{job.HlsTargetURL, job.Mp4TargetURL, job.FragMp4TargetURL} {
+			clients.TakeChecksums(u)
+		}
+	}
+	tsm.BackupOutputs = clients.TakeBackupOutputs(job.HlsTargetURL, job.Mp4TargetURL, job.FragMp4TargetURL)
 	err2 := job.statusClient.SendTranscodeStatus(tsm)
 	if err2 != nil {
 		log.LogError(tsm.RequestID, "failed sending finalize callback, job state set to 'failed'", err2)
@@ -623,7 +800,21 @@ func (c *Coordinator) finishJob(job *JobInfo, out *HandlerOutput, err error) {
 
 	// Automatically delete jobs after an error or result
 	success := err == nil && err2 == nil
+	if !success {
+		reason := errors.FailureReason(err)
+		if err == nil {
+			reason = errors.FailureCallback
+		}
+		job.progressMu.Lock()
+		stage := job.stage
+		job.progressMu.Unlock()
+		metrics.Metrics.VODPipelineMetrics.Failures.WithLabelValues(stage.String(), reason).Inc()
+	}
 	c.Jobs.Remove(job.StreamName)
+	// the fallback pipeline of a failed job transfers under the same URLs
+	if job.releaseBandwidth != nil && (err == nil || !job.hasFallback) {
+		job.releaseBandwidth()
+	}
 	log.Log(job.RequestID, "Finished job and deleted from job cache", "success", success)
 	metrics.Metrics.JobsInFlight.Set(float64(len(c.Jobs.GetKeys())))
 
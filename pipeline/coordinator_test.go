@@ -1,6 +1,7 @@
 package pipeline
 
 import (
+	"context"
 	"crypto/md5"
 	"errors"
 	"fmt"
@@ -18,8 +19,11 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/livepeer/catalyst-api/clients"
 	"github.com/livepeer/catalyst-api/config"
+	catErrs "github.com/livepeer/catalyst-api/errors"
+	"github.com/livepeer/catalyst-api/metrics"
 	"github.com/livepeer/catalyst-api/video"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -386,12 +390,17 @@ func Test_EmptyFile(t *testing.T) {
 
 func Test_ProbeErrors(t *testing.T) {
 	tests := []struct {
-		name        string
-		fps         float64
-		assetType   string
-		size        int64
-		probeErr    error
-		expectedErr string
+		name         string
+		fps          float64
+		assetType    string
+		size         int64
+		duration     float64
+		probeErr     error
+		allowlist    video.InputAllowlist
+		maxDuration  time.Duration
+		maxBytes     int64
+		expectedErr  string
+		expectedCode string
 	}{
 		{
 			name:        "valid",
@@ -417,6 +426,39 @@ func Test_ProbeErrors(t *testing.T) {
 			probeErr:    errors.New("probe failed"),
 			expectedErr: "error copying input to storage: error probing MP4 input file from S3: probe failed",
 		},
+		{
+			name:         "missing moov atom",
+			probeErr:     errors.New("error running ffprobe [moov atom not found] exit status 1"),
+			expectedErr:  "error copying input to storage: corrupt source: the MP4 file is incomplete, its index (moov atom) is missing. This usually means the upload didn't finish, or the recording was stopped before it was finalised - upload the complete file again",
+			expectedCode: "source_truncated",
+		},
+		{
+			name:        "codec not allowed",
+			allowlist:   video.InputAllowlist{VideoCodecs: []string{"hevc"}},
+			expectedErr: `error copying input to storage: unsupported input: video codec "h264" is not allowed, expected one of hevc`,
+		},
+		{
+			name:         "duration above the tenant limit",
+			duration:     7200,
+			maxDuration:  time.Hour,
+			expectedErr:  "error copying input to storage: source duration 7200.0s is above the limit of 1h0m0s",
+			expectedCode: "source_too_long",
+		},
+		{
+			name:         "size above the tenant limit",
+			size:         2000,
+			maxBytes:     1000,
+			expectedErr:  "error copying input to storage: source size 2000 bytes is above the limit of 1000 bytes",
+			expectedCode: "source_too_large",
+		},
+		{
+			name:        "within the tenant limits",
+			duration:    60,
+			size:        1000,
+			maxDuration: time.Hour,
+			maxBytes:    1000,
+			expectedErr: "",
+		},
 	}
 
 	for _, tt := range tests {
@@ -427,21 +469,27 @@ func Test_ProbeErrors(t *testing.T) {
 			defer cleanup()
 			coord.InputCopy = &clients.InputCopy{
 				Probe: stubFFprobe{
-					FPS:  tt.fps,
-					Type: tt.assetType,
-					Size: tt.size,
-					Err:  tt.probeErr,
+					FPS:      tt.fps,
+					Type:     tt.assetType,
+					Size:     tt.size,
+					Duration: tt.duration,
+					Err:      tt.probeErr,
 				},
 			}
 			coord.SourceOutputURL = transferDir
+			video.SourceAllowlist = tt.allowlist
+			defer func() { video.SourceAllowlist = video.InputAllowlist{} }()
 
 			job := testJob
 			job.SourceFile = "file://" + inputFile.Name()
+			job.MaxSourceDuration = tt.maxDuration
+			job.MaxSourceBytes = tt.maxBytes
 			coord.StartUploadJob(job)
 			requireReceive(t, callbacks, 1*time.Second) // discard initial TranscodeStatusPreparing message
 			msg := requireReceive(t, callbacks, 1*time.Second)
 
 			require.Equal(t, tt.expectedErr, msg.Error)
+			require.Equal(t, tt.expectedCode, msg.ErrorCode)
 		})
 	}
 }
@@ -510,6 +558,79 @@ func allFailingHandler(t *testing.T) Handler {
 	}
 }
 
+func TestCoordinatorShutdown(t *testing.T) {
+	callbackHandler, callbacks := callbacksRecorder()
+	coord := NewStubCoordinatorOpts("", callbackHandler, nil, nil)
+
+	require.False(t, coord.ShuttingDown())
+	require.NoError(t, coord.Shutdown(context.Background()))
+	require.True(t, coord.ShuttingDown())
+
+	// a job that doesn't finish before the drain timeout gets a retriable error callback
+	coord.Jobs.Store("stream", &JobInfo{
+		UploadJobPayload: UploadJobPayload{RequestID: "req-id", CallbackURL: "http://localhost/callback"},
+		statusClient:     callbackHandler,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, coord.Shutdown(ctx), context.DeadlineExceeded)
+
+	msg := requireReceive(t, callbacks, 1*time.Second)
+	require.Equal(t, "req-id", msg.RequestID)
+	require.Equal(t, clients.TranscodeStatusError, msg.Status)
+	require.False(t, msg.Unretriable)
+}
+
+func TestCoordinatorJobStatus(t *testing.T) {
+	callbackHandler, _ := callbacksRecorder()
+	coord := NewStubCoordinatorOpts("", callbackHandler, nil, nil)
+
+	_, ok := coord.JobStatus("req-id")
+	require.False(t, ok)
+
+	job := &JobInfo{
+		UploadJobPayload: UploadJobPayload{RequestID: "req-id", ExternalID: "ext-id"},
+		statusClient:     callbackHandler,
+	}
+	coord.Jobs.Store(config.SegmentingStreamName("req-id"), job)
+	job.ReportProgress(clients.TranscodeStatusTranscoding, 0.5)
+
+	status, ok := coord.JobStatus("req-id")
+	require.True(t, ok)
+	require.Equal(t, JobStatus{
+		RequestID:       "req-id",
+		ExternalID:      "ext-id",
+		Stage:           clients.TranscodeStatusTranscoding,
+		CompletionRatio: 0.5,
+	}, status)
+}
+
+func TestCoordinatorCountsFailuresByStageAndReason(t *testing.T) {
+	callbackHandler, _ := callbacksRecorder()
+	coord := NewStubCoordinatorOpts("", callbackHandler, nil, nil)
+
+	newJob := func() *JobInfo {
+		job := &JobInfo{
+			UploadJobPayload: UploadJobPayload{RequestID: "req-id"},
+			statusClient:     callbackHandler,
+			PipelineInfo:     PipelineInfo{result: make(chan bool, 1)},
+		}
+		job.ReportProgress(clients.TranscodeStatusTranscoding, 0.5)
+		return job
+	}
+	failures := func(reason string) float64 {
+		return testutil.ToFloat64(metrics.Metrics.VODPipelineMetrics.Failures.WithLabelValues("transcoding", reason))
+	}
+
+	before := failures(catErrs.FailureUpload)
+	coord.finishJob(newJob(), nil, fmt.Errorf("transcoding failed: %w", catErrs.WithFailureReason(catErrs.FailureUpload, errors.New("S3 is down"))))
+	require.Equal(t, before+1, failures(catErrs.FailureUpload))
+
+	before = failures(catErrs.FailureOther)
+	coord.finishJob(newJob(), nil, errors.New("unexpected"))
+	require.Equal(t, before+1, failures(catErrs.FailureOther))
+}
+
 func callbacksRecorder() (clients.TranscodeStatusClient, <-chan clients.TranscodeStatusMessage) {
 	callbacks := make(chan clients.TranscodeStatusMessage, 10)
 	handler := func(msg clients.TranscodeStatusMessage) error {
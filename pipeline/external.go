@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/livepeer/catalyst-api/clients"
+	"github.com/livepeer/catalyst-api/errors"
 )
 
 type external struct {
@@ -23,6 +24,14 @@ func (e *external) HandleStartUploadJob(job *JobInfo) (*HandlerOutput, error) {
 	if e == nil || e.transcoder == nil {
 		return nil, fmt.Errorf("no external transcoder configured")
 	}
+	// the external transcoder names its outputs itself
+	if job.OutputNaming != nil {
+		return nil, errors.Unretriable(fmt.Errorf("output naming is not supported by the external transcoder"))
+	}
+	// nor can it encrypt the segments with our keys
+	if job.HLSEncryption != nil {
+		return nil, errors.Unretriable(fmt.Errorf("hls encryption is not supported by the external transcoder"))
+	}
 
 	sourceFileUrl, err := url.Parse(job.SignedSourceURL)
 	if err != nil {
@@ -38,6 +47,9 @@ func (e *external) HandleStartUploadJob(job *JobInfo) (*HandlerOutput, error) {
 		HLSOutputLocation: job.HlsTargetURL,
 		MP4OutputLocation: job.Mp4TargetURL,
 		Profiles:          job.Profiles,
+		DefaultProfiles:   job.DefaultProfiles,
+		LadderConstraints: job.LadderConstraints,
+		AudioOptions:      job.AudioOptions,
 		GenerateMP4:       job.GenerateMP4,
 		ReportProgress: func(progress float64) {
 			job.ReportProgress(clients.TranscodeStatusTranscoding, progress)
@@ -51,11 +63,15 @@ func (e *external) HandleStartUploadJob(job *JobInfo) (*HandlerOutput, error) {
 		InputFileInfo: job.InputFileInfo,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("external transcoder error: %w", err)
+		return nil, errors.WithFailureReason(errors.FailureExternalTranscoder, fmt.Errorf("external transcoder error: %w", err))
 	}
 	job.TranscodingDone = time.Now()
 
-	generateThumbs(job)
+	preview, poster := generateThumbs(job)
+	for i := range outputVideos {
+		outputVideos[i].Preview = preview
+		outputVideos[i].Poster = poster
+	}
 
 	return &HandlerOutput{
 		Result: &UploadJobResult{
@@ -65,22 +81,33 @@ func (e *external) HandleStartUploadJob(job *JobInfo) (*HandlerOutput, error) {
 	}, nil
 }
 
-func generateThumbs(job *JobInfo) {
+// generateThumbs returns the locations of the animated preview and poster, if they were generated
+func generateThumbs(job *JobInfo) (string, string) {
 	if job.ThumbnailsTargetURL == nil {
-		return
+		return "", ""
 	}
 
 	manifestUrl, err := clients.GetFirstRenditionURL(job.RequestID, job.HlsTargetURL.JoinPath("index.m3u8"))
 	if err != nil {
 		log.LogError(job.RequestID, "failed to get rendition URL for mediaconvert thumbs", err)
-		return
+		return "", ""
 	}
 
 	log.Log(job.RequestID, "generating thumbs for mediaconvert", "manifest", manifestUrl.Redacted())
 	manifest := manifestUrl.String()
-	err = thumbnails.GenerateThumbsAndVTT(job.RequestID, manifest, job.ThumbnailsTargetURL)
+	err = thumbnails.GenerateThumbsAndVTT(job.RequestID, manifest, job.ThumbnailsTargetURL, job.ThumbnailOptions, job.ThumbnailProgress)
 	if err != nil {
 		log.LogError(job.RequestID, "mediaconvert thumbs failed", err, "in", manifest, "out", job.ThumbnailsTargetURL)
-		return
+		return "", ""
+	}
+
+	preview, err := thumbnails.GeneratePreview(job.RequestID, manifest, job.ThumbnailsTargetURL, job.ThumbnailOptions)
+	if err != nil {
+		log.LogError(job.RequestID, "mediaconvert animated preview failed", err, "in", manifest, "out", job.ThumbnailsTargetURL)
+	}
+	poster, err := thumbnails.GeneratePoster(job.RequestID, manifest, job.ThumbnailsTargetURL, job.ThumbnailOptions)
+	if err != nil {
+		log.LogError(job.RequestID, "mediaconvert poster failed", err, "in", manifest, "out", job.ThumbnailsTargetURL)
 	}
+	return preview, poster
 }
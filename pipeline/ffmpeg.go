@@ -3,10 +3,13 @@ package pipeline
 import (
 	"context"
 	"fmt"
+	"math"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,11 +17,13 @@ import (
 	"github.com/grafov/m3u8"
 	"github.com/livepeer/catalyst-api/clients"
 	"github.com/livepeer/catalyst-api/config"
+	"github.com/livepeer/catalyst-api/errors"
 	"github.com/livepeer/catalyst-api/log"
 	"github.com/livepeer/catalyst-api/thumbnails"
 	"github.com/livepeer/catalyst-api/transcode"
 	"github.com/livepeer/catalyst-api/video"
 	"github.com/livepeer/go-tools/drivers"
+	"golang.org/x/sync/errgroup"
 )
 
 const LocalSourceFilePattern = "sourcevideo*.mp4"
@@ -70,6 +75,10 @@ func (f *ffmpeg) HandleStartUploadJob(job *JobInfo) (*HandlerOutput, error) {
 			defer os.Remove(localSourceTmp)
 		}
 	} else {
+		// the audio options are applied when the source is segmented
+		if job.AudioOptions != nil {
+			return nil, errors.Unretriable(fmt.Errorf("audio options are not supported for HLS sources"))
+		}
 		job.SegmentingTargetURL = job.SourceFile
 
 		// don't generate thumbs for very long recordings since it involves downloading segments
@@ -80,14 +89,15 @@ func (f *ffmpeg) HandleStartUploadJob(job *JobInfo) (*HandlerOutput, error) {
 			if job.ThumbnailsTargetURL == nil {
 				return
 			}
-			err := thumbnails.GenerateThumbsFromManifest(job.RequestID, job.SegmentingTargetURL, job.ThumbnailsTargetURL)
+			err := thumbnails.GenerateThumbsFromManifest(job.RequestID, job.SegmentingTargetURL, job.ThumbnailsTargetURL, job.ThumbnailOptions, job.ThumbnailProgress)
 			if err != nil {
 				log.LogError(job.RequestID, "generate thumbs failed", err, "in", job.SegmentingTargetURL, "out", job.ThumbnailsTargetURL)
 			}
 		}()
 	}
 	job.SegmentingDone = time.Now()
-	if job.HlsTargetURL != nil {
+	// the source playback would make an encrypted asset playable without the key
+	if job.HlsTargetURL != nil && job.HLSEncryption == nil {
 		f.sendSourcePlayback(job)
 	}
 	job.ReportProgress(clients.TranscodeStatusPreparingCompleted, 1)
@@ -101,6 +111,11 @@ func (f *ffmpeg) HandleStartUploadJob(job *JobInfo) (*HandlerOutput, error) {
 		AccessToken:       job.AccessToken,
 		TranscodeAPIUrl:   job.TranscodeAPIUrl,
 		Profiles:          job.Profiles,
+		DefaultProfiles:   job.DefaultProfiles,
+		LadderConstraints: job.LadderConstraints,
+		AudioOptions:      job.AudioOptions,
+		OutputNaming:      job.OutputNaming,
+		HLSEncryption:     job.HLSEncryption,
 		SourceManifestURL: job.SegmentingTargetURL,
 		SourceOutputURL:   sourceOutputURL.String(),
 		HlsTargetURL:      toStr(job.HlsTargetURL),
@@ -114,6 +129,9 @@ func (f *ffmpeg) HandleStartUploadJob(job *JobInfo) (*HandlerOutput, error) {
 		LocalSourceTmp:    localSourceTmp,
 	}
 
+	// the analysis of the source that isn't kept on the job
+	sourceVideo, _ := job.InputFileInfo.GetTrack(video.TrackTypeVideo)
+	sourceAudio, _ := job.InputFileInfo.GetTrack(video.TrackTypeAudio)
 	inputInfo := video.InputVideo{
 		Format:    job.InputFileInfo.Format,
 		Duration:  job.InputFileInfo.Duration,
@@ -127,9 +145,17 @@ func (f *ffmpeg) HandleStartUploadJob(job *JobInfo) (*HandlerOutput, error) {
 				DurationSec:  job.InputFileInfo.Duration,
 				StartTimeSec: job.sourceVideoStartTimeSec,
 				VideoTrack: video.VideoTrack{
-					Width:  job.sourceWidth,
-					Height: job.sourceHeight,
-					FPS:    job.sourceFPS,
+					Width:          job.sourceWidth,
+					Height:         job.sourceHeight,
+					FPS:            job.sourceFPS,
+					ColorBitDepth:  sourceVideo.ColorBitDepth,
+					ColorSpace:     sourceVideo.ColorSpace,
+					ColorPrimaries: sourceVideo.ColorPrimaries,
+					ColorTransfer:  sourceVideo.ColorTransfer,
+					ColorRange:     sourceVideo.ColorRange,
+					HDR:            sourceVideo.HDR,
+					VFR:            sourceVideo.VFR,
+					ClosedCaptions: sourceVideo.ClosedCaptions,
 				},
 			},
 			// Audio Track
@@ -140,14 +166,21 @@ func (f *ffmpeg) HandleStartUploadJob(job *JobInfo) (*HandlerOutput, error) {
 				DurationSec:  job.InputFileInfo.Duration,
 				StartTimeSec: job.sourceAudioStartTimeSec,
 				AudioTrack: video.AudioTrack{
-					Channels:   job.sourceChannels,
-					SampleRate: job.sourceSampleRate,
-					SampleBits: job.sourceSampleBits,
+					Channels:      job.sourceChannels,
+					SampleRate:    job.sourceSampleRate,
+					SampleBits:    job.sourceSampleBits,
+					ChannelLayout: sourceAudio.ChannelLayout,
 				},
 			},
 		},
 	}
 
+	for _, track := range job.InputFileInfo.Tracks {
+		if track.Type == video.TrackTypeSubtitle {
+			inputInfo.Tracks = append(inputInfo.Tracks, track)
+		}
+	}
+
 	job.state = "transcoding"
 
 	sourceManifest, err := clients.DownloadRenditionManifest(transcodeRequest.RequestID, transcodeRequest.SourceManifestURL)
@@ -157,6 +190,10 @@ func (f *ffmpeg) HandleStartUploadJob(job *JobInfo) (*HandlerOutput, error) {
 
 	sourceSegments := sourceManifest.GetAllSegments()
 	job.sourceSegments = len(sourceSegments)
+	err = checkSourceSegments(job, sourceManifest)
+	if err != nil {
+		return nil, err
+	}
 	err = f.probeSourceSegments(job, sourceSegments)
 	if err != nil {
 		return nil, err
@@ -170,11 +207,26 @@ func (f *ffmpeg) HandleStartUploadJob(job *JobInfo) (*HandlerOutput, error) {
 
 	// wait for thumbs background process
 	if job.ThumbnailsTargetURL != nil {
-		err := thumbnails.GenerateThumbsVTT(job.RequestID, job.SegmentingTargetURL, job.ThumbnailsTargetURL)
+		err := thumbnails.GenerateThumbsVTT(job.RequestID, job.SegmentingTargetURL, job.ThumbnailsTargetURL, job.ThumbnailOptions)
 		if err != nil {
 			log.LogError(job.RequestID, "waiting for thumbs failed", err, "out", job.ThumbnailsTargetURL)
 		} else {
 			log.Log(job.RequestID, "waiting for thumbs succeeded", "out", job.ThumbnailsTargetURL)
+			if err := thumbnails.GenerateSpritesVTT(job.RequestID, job.SegmentingTargetURL, job.ThumbnailsTargetURL, job.ThumbnailOptions); err != nil {
+				log.LogError(job.RequestID, "generating thumbnail sprites failed", err, "out", job.ThumbnailsTargetURL)
+			}
+			preview, err := thumbnails.GeneratePreview(job.RequestID, job.SegmentingTargetURL, job.ThumbnailsTargetURL, job.ThumbnailOptions)
+			if err != nil {
+				log.LogError(job.RequestID, "generating animated preview failed", err, "out", job.ThumbnailsTargetURL)
+			}
+			poster, err := thumbnails.GeneratePoster(job.RequestID, job.SegmentingTargetURL, job.ThumbnailsTargetURL, job.ThumbnailOptions)
+			if err != nil {
+				log.LogError(job.RequestID, "generating poster failed", err, "out", job.ThumbnailsTargetURL)
+			}
+			for i := range outputs {
+				outputs[i].Preview = preview
+				outputs[i].Poster = poster
+			}
 		}
 	}
 
@@ -330,11 +382,88 @@ func (f *ffmpeg) probeSourceSegment(requestID string, seg *m3u8.MediaSegment, so
 	// check for audio issues https://linear.app/livepeer/issue/VID-287/audio-missing-after-segmenting
 	_, err = f.probe.ProbeFile(requestID, probeURL, "-loglevel", "warning")
 	if err != nil && strings.Contains(err.Error(), "no TS found at start of file, duration not set") {
-		return fmt.Errorf("probe failed with audio issues for segment %s: %w", u, err)
+		return errors.WithFailureReason(errors.FailureInvalidSource, fmt.Errorf("probe failed with audio issues for segment %s: %w", u, err))
 	}
 	return nil
 }
 
+// sourceSegmentNumber is the number the segment muxer gives the source segments, e.g. index12.ts
+var sourceSegmentNumber = regexp.MustCompile(`(\d+)\.ts$`)
+
+// sourceDurationTolerance is how much shorter than the probed source the segments can be, as a fraction of its duration
+const sourceDurationTolerance = 0.05
+
+const sourceSegmentCheckConcurrency = 8
+
+// checkSourceSegments verifies the segments of a source we segmented before they're transcoded: that they're numbered
+// without gaps, add up to the probed duration of the source and can all be downloaded. Segmenting that lost segments
+// would otherwise produce a VOD that's silently shorter than its source.
+func checkSourceSegments(job *JobInfo, sourceManifest m3u8.MediaPlaylist) error {
+	if job.InputFileInfo.Format == "hls" {
+		return nil
+	}
+	segments := sourceManifest.GetAllSegments()
+	if len(segments) == 0 {
+		return segmentingError(fmt.Errorf("the source manifest has no segments"))
+	}
+
+	var totalDuration float64
+	for i, seg := range segments {
+		if m := sourceSegmentNumber.FindStringSubmatch(seg.URI); m != nil && m[1] != strconv.Itoa(i) {
+			return segmentingError(fmt.Errorf("source segment %d is missing, found %s in its place", i, seg.URI))
+		}
+		if seg.Duration <= 0 {
+			return segmentingError(fmt.Errorf("source segment %s has no duration", seg.URI))
+		}
+		totalDuration += seg.Duration
+	}
+	if expected := job.InputFileInfo.Duration; expected > 0 {
+		tolerance := math.Max(float64(sourceManifest.TargetDuration), expected*sourceDurationTolerance)
+		if expected-totalDuration > tolerance {
+			return segmentingError(fmt.Errorf("source segments cover %.1fs of the %.1fs source", totalDuration, expected))
+		}
+	}
+
+	eg := errgroup.Group{}
+	eg.SetLimit(sourceSegmentCheckConcurrency)
+	for _, seg := range segments {
+		seg := seg
+		eg.Go(func() error {
+			return checkSourceSegmentDownload(job.SegmentingTargetURL, seg)
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return segmentingError(err)
+	}
+	return nil
+}
+
+func checkSourceSegmentDownload(sourceManifestURL string, seg *m3u8.MediaSegment) error {
+	u, err := clients.ManifestURLToSegmentURL(sourceManifestURL, seg.URI)
+	if err != nil {
+		return fmt.Errorf("error checking source segments: %w", err)
+	}
+	return backoff.Retry(func() error {
+		rc, err := clients.GetOSURL(u.String(), "")
+		if err != nil {
+			err = fmt.Errorf("source segment %s can't be downloaded: %w", seg.URI, err)
+			if errors.IsObjectNotFound(err) {
+				return backoff.Permanent(err)
+			}
+			return err
+		}
+		defer rc.Body.Close()
+		if rc.Size != nil && *rc.Size == 0 {
+			return backoff.Permanent(fmt.Errorf("source segment %s is empty", seg.URI))
+		}
+		return nil
+	}, retries(3))
+}
+
+func segmentingError(err error) error {
+	return errors.WithFailureReason(errors.FailureSegmenting, fmt.Errorf("source segments failed validation: %w", err))
+}
+
 func copyFileToLocalTmpAndSegment(job *JobInfo) (string, error) {
 	// Create a temporary local file to write to
 	localSourceFile, err := os.CreateTemp(os.TempDir(), LocalSourceFilePattern)
@@ -368,13 +497,51 @@ func copyFileToLocalTmpAndSegment(job *JobInfo) (string, error) {
 	}
 
 	destinationURL := fmt.Sprintf("%s/api/ffmpeg/%s/index.m3u8", internalAddress, job.StreamName)
-	if err := video.Segment(localSourceFile.Name(), destinationURL, job.TargetSegmentSizeSecs); err != nil {
+	timeout := segmentingTimeout(job.InputFileInfo.Duration)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if segmentInParallel(job) {
+		err := video.SegmentParallel(ctx, localSourceFile.Name(), destinationURL, job.TargetSegmentSizeSecs, job.AudioOptions, job.InputFileInfo.Duration, config.ParallelSegmentingWorkers)
+		if err == nil {
+			return localSourceFile.Name(), nil
+		}
+		if ctx.Err() != nil {
+			return localSourceFile.Name(), checkTimedOutSegmenting(job, timeout)
+		}
+		log.LogError(job.RequestID, "parallel segmenting failed, segmenting serially", err)
+	}
+	if err := video.Segment(ctx, localSourceFile.Name(), destinationURL, job.TargetSegmentSizeSecs, job.AudioOptions); err != nil {
+		if ctx.Err() != nil {
+			return localSourceFile.Name(), checkTimedOutSegmenting(job, timeout)
+		}
 		return "", err
 	}
 
 	return localSourceFile.Name(), nil
 }
 
+// segmentingTimeout is how long segmenting a source of the given duration can take before the segmenter is killed
+func segmentingTimeout(durationSecs float64) time.Duration {
+	return config.SegmentingMinTimeout + time.Duration(durationSecs*config.SegmentingTimeoutFactor*float64(time.Second))
+}
+
+// checkTimedOutSegmenting is called once the segmenter has been killed for not finishing in time. It can hang after
+// the closed manifest of the segments was written, in which case the job carries on with them, otherwise it fails.
+func checkTimedOutSegmenting(job *JobInfo, timeout time.Duration) error {
+	manifest, err := clients.DownloadRenditionManifest(job.RequestID, job.SegmentingTargetURL)
+	if err == nil && manifest.Closed && len(manifest.GetAllSegments()) > 0 {
+		log.Log(job.RequestID, "segmenting timed out after every segment was written, carrying on", "timeout", timeout)
+		return nil
+	}
+	return errors.WithFailureReason(errors.FailureSegmenting, fmt.Errorf("segmenting did not finish within %s", timeout))
+}
+
+// segmentInParallel is whether the source is long enough to be segmented in parts at the same time
+func segmentInParallel(job *JobInfo) bool {
+	return config.ParallelSegmentingMinDuration > 0 && config.ParallelSegmentingWorkers > 1 &&
+		job.InputFileInfo.Duration >= config.ParallelSegmentingMinDuration.Seconds()
+}
+
 func cleanUpLocalTmpFiles(dir string, filenamePattern string, maxAge time.Duration) error {
 	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
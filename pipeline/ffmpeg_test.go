@@ -3,11 +3,14 @@ package pipeline
 import (
 	"net/url"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/grafov/m3u8"
 	"github.com/livepeer/catalyst-api/clients"
+	"github.com/livepeer/catalyst-api/config"
+	"github.com/livepeer/catalyst-api/errors"
 	"github.com/livepeer/catalyst-api/video"
 	"github.com/stretchr/testify/require"
 )
@@ -260,3 +263,88 @@ func Test_probeSegments(t *testing.T) {
 	_ = f.probeSourceSegments(job, []*m3u8.MediaSegment{{URI: "0.ts"}, {URI: "1.ts"}, {URI: "2.ts"}, {URI: "3.ts"}, {URI: "4.ts"}, {URI: "5.ts"}})
 	require.Equal(t, []string{"/0.ts", "/0.ts", "/1.ts", "/1.ts", "/4.ts", "/4.ts", "/5.ts", "/5.ts"}, probe.probedUrls)
 }
+
+func Test_checkSourceSegments(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"index0.ts", "index1.ts", "index2.ts"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("segment"), 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index3.ts"), nil, 0644))
+	job := &JobInfo{
+		UploadJobPayload: UploadJobPayload{
+			RequestID:     "requestID",
+			InputFileInfo: video.InputVideo{Format: "mp4", Duration: 30},
+		},
+		SegmentingTargetURL: "file://" + filepath.Join(dir, "index.m3u8"),
+	}
+	manifest := func(uris ...string) m3u8.MediaPlaylist {
+		playlist, err := m3u8.NewMediaPlaylist(0, uint(len(uris)))
+		require.NoError(t, err)
+		for _, uri := range uris {
+			require.NoError(t, playlist.Append(uri, 10, ""))
+		}
+		playlist.TargetDuration = 10
+		return *playlist
+	}
+
+	require.NoError(t, checkSourceSegments(job, manifest("index0.ts", "index1.ts", "index2.ts")))
+
+	for _, tc := range []struct {
+		name     string
+		manifest m3u8.MediaPlaylist
+		error    string
+	}{
+		{"no segments", manifest(), "has no segments"},
+		{"gap", manifest("index0.ts", "index2.ts", "index1.ts"), "source segment 1 is missing"},
+		{"too short", manifest("index0.ts"), "cover 10.0s of the 30.0s source"},
+		{"not uploaded", manifest("index0.ts", "index1.ts", "missing.ts"), "missing.ts can't be downloaded"},
+		{"empty", manifest("index0.ts", "index1.ts", "index2.ts", "index3.ts"), "index3.ts is empty"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkSourceSegments(job, tc.manifest)
+			require.ErrorContains(t, err, tc.error)
+			require.Equal(t, errors.FailureSegmenting, errors.FailureReason(err))
+		})
+	}
+
+	// HLS sources aren't segmented by us
+	job.InputFileInfo.Format = "hls"
+	require.NoError(t, checkSourceSegments(job, manifest()))
+}
+
+func Test_segmentingTimeout(t *testing.T) {
+	require.Equal(t, config.SegmentingMinTimeout, segmentingTimeout(0))
+	require.Equal(t, config.SegmentingMinTimeout+90*time.Second, segmentingTimeout(90))
+}
+
+func Test_checkTimedOutSegmenting(t *testing.T) {
+	dir := t.TempDir()
+	job := &JobInfo{
+		UploadJobPayload:    UploadJobPayload{RequestID: "requestID"},
+		SegmentingTargetURL: "file://" + filepath.Join(dir, "index.m3u8"),
+	}
+	writeManifest := func(closed bool) {
+		playlist, err := m3u8.NewMediaPlaylist(0, 2)
+		require.NoError(t, err)
+		require.NoError(t, playlist.Append("index0.ts", 10, ""))
+		require.NoError(t, playlist.Append("index1.ts", 10, ""))
+		if closed {
+			playlist.Close()
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "index.m3u8"), playlist.Encode().Bytes(), 0644))
+	}
+
+	// the segmenter was killed before writing any manifest
+	err := checkTimedOutSegmenting(job, time.Minute)
+	require.ErrorContains(t, err, "segmenting did not finish within 1m0s")
+	require.Equal(t, errors.FailureSegmenting, errors.FailureReason(err))
+
+	// the segmenter was killed part way through
+	writeManifest(false)
+	err = checkTimedOutSegmenting(job, time.Minute)
+	require.ErrorContains(t, err, "segmenting did not finish within 1m0s")
+
+	// the segmenter hung after writing every segment
+	writeManifest(true)
+	require.NoError(t, checkTimedOutSegmenting(job, time.Minute))
+}
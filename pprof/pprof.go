@@ -3,9 +3,34 @@ package pprof
 import (
 	"fmt"
 	"net/http"
-	_ "net/http/pprof"
+	"net/http/pprof"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
 )
 
+// ListenAndServe serves the pprof endpoints registered on http.DefaultServeMux without authentication, so it only
+// listens on localhost. The admin authenticated Handler is the way to reach them from other hosts.
 func ListenAndServe(port int) error {
-	return fmt.Errorf("pprof listener stopped: %w", http.ListenAndServe(fmt.Sprintf("0.0.0.0:%d", port), nil))
+	return fmt.Errorf("pprof listener stopped: %w", http.ListenAndServe(fmt.Sprintf("127.0.0.1:%d", port), nil))
+}
+
+// Handler serves the pprof endpoints on a /debug/pprof/*profile route, so they can be put behind the same
+// middleware as the other routes rather than a separate listener
+func Handler() httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		switch strings.TrimPrefix(ps.ByName("profile"), "/") {
+		case "cmdline":
+			pprof.Cmdline(w, r)
+		case "profile":
+			pprof.Profile(w, r)
+		case "symbol":
+			pprof.Symbol(w, r)
+		case "trace":
+			pprof.Trace(w, r)
+		default:
+			// the index and the named profiles, e.g. goroutine and heap
+			pprof.Index(w, r)
+		}
+	}
 }
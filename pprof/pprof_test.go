@@ -0,0 +1,46 @@
+package pprof
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	router := httprouter.New()
+	router.GET("/debug/pprof/*profile", Handler())
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Contains(t, rr.Body.String(), "goroutine")
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/pprof/goroutine?debug=1", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Contains(t, rr.Body.String(), "goroutine profile")
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/pprof/cmdline", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestRuntimeStatsHandler(t *testing.T) {
+	RegisterQueue("test_queue", func() int { return 3 })
+	RegisterCache("test_cache", func() int { return 7 })
+
+	rr := httptest.NewRecorder()
+	RuntimeStatsHandler()(rr, httptest.NewRequest(http.MethodGet, "/debug/runtime", nil), nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var stats RuntimeStats
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &stats))
+	require.Greater(t, stats.Goroutines, 0)
+	require.Greater(t, stats.HeapAllocBytes, uint64(0))
+	require.Equal(t, 3, stats.Queues["test_queue"])
+	require.Equal(t, 7, stats.Caches["test_cache"])
+}
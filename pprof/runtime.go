@@ -0,0 +1,81 @@
+package pprof
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var (
+	startTime = time.Now()
+
+	sizesMu sync.RWMutex
+	queues  = map[string]func() int{}
+	caches  = map[string]func() int{}
+)
+
+// RegisterQueue adds the depth of a queue to the runtime stats, replacing any queue of the same name
+func RegisterQueue(name string, depth func() int) {
+	sizesMu.Lock()
+	defer sizesMu.Unlock()
+	queues[name] = depth
+}
+
+// RegisterCache adds the number of entries of a cache to the runtime stats, replacing any cache of the same name
+func RegisterCache(name string, size func() int) {
+	sizesMu.Lock()
+	defer sizesMu.Unlock()
+	caches[name] = size
+}
+
+type RuntimeStats struct {
+	Goroutines     int            `json:"goroutines"`
+	GOMAXPROCS     int            `json:"gomaxprocs"`
+	UptimeSecs     int64          `json:"uptime_secs"`
+	HeapAllocBytes uint64         `json:"heap_alloc_bytes"`
+	HeapObjects    uint64         `json:"heap_objects"`
+	SysBytes       uint64         `json:"sys_bytes"`
+	NumGC          uint32         `json:"num_gc"`
+	LastGC         time.Time      `json:"last_gc"`
+	Queues         map[string]int `json:"queues"`
+	Caches         map[string]int `json:"caches"`
+}
+
+func GetRuntimeStats() RuntimeStats {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	stats := RuntimeStats{
+		Goroutines:     runtime.NumGoroutine(),
+		GOMAXPROCS:     runtime.GOMAXPROCS(0),
+		UptimeSecs:     int64(time.Since(startTime).Seconds()),
+		HeapAllocBytes: mem.HeapAlloc,
+		HeapObjects:    mem.HeapObjects,
+		SysBytes:       mem.Sys,
+		NumGC:          mem.NumGC,
+		LastGC:         time.Unix(0, int64(mem.LastGC)).UTC(),
+		Queues:         map[string]int{},
+		Caches:         map[string]int{},
+	}
+
+	sizesMu.RLock()
+	defer sizesMu.RUnlock()
+	for name, depth := range queues {
+		stats.Queues[name] = depth()
+	}
+	for name, size := range caches {
+		stats.Caches[name] = size()
+	}
+	return stats
+}
+
+// RuntimeStatsHandler returns the goroutine count, memory stats and the sizes of the registered queues and caches
+func RuntimeStatsHandler() httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(GetRuntimeStats())
+	}
+}
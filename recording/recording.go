@@ -0,0 +1,188 @@
+package recording
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/livepeer/catalyst-api/clients"
+	"github.com/livepeer/catalyst-api/config"
+	"github.com/livepeer/catalyst-api/events"
+	"github.com/livepeer/catalyst-api/handlers/misttriggers"
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/livepeer/catalyst-api/pipeline"
+)
+
+// Name of the playlist Mist writes next to each session's source segments
+const recordingManifest = "index.m3u8"
+
+// VODEngine starts the VOD jobs the recordings are processed by, implemented by pipeline.Coordinator
+type VODEngine interface {
+	StartUploadJob(p pipeline.UploadJobPayload)
+}
+
+// Recorder has Mist record each session of the streams recording is enabled for as HLS, with an AUTO_PUSH to the
+// recording URL, and once the session ends runs the recording through the VOD pipeline, delivering the transcoded
+// renditions and thumbnails to the stream's output location with status callbacks like any VOD job.
+// The settings are kept in memory, so every node needs to receive them, which the recording event takes care of.
+type Recorder struct {
+	mist           clients.MistAPIClient
+	vod            VODEngine
+	baseStreamName string
+	// recordingURL is the base URL Mist writes the source segments to, under <playback ID>/<session ID>/
+	recordingURL string
+
+	mu      sync.Mutex
+	streams map[string]events.RecordingSettings // playback ID to settings
+}
+
+func NewRecorder(mist clients.MistAPIClient, vod VODEngine, baseStreamName, recordingURL string) *Recorder {
+	return &Recorder{
+		mist:           mist,
+		vod:            vod,
+		baseStreamName: baseStreamName,
+		recordingURL:   strings.TrimSuffix(recordingURL, "/"),
+		streams:        map[string]events.RecordingSettings{},
+	}
+}
+
+// ValidateSettings checks the settings before they're broadcast to the cluster
+func ValidateSettings(s events.RecordingSettings) error {
+	if !s.Enabled {
+		return nil
+	}
+	if _, err := parseOutputURL(s.OutputURL); err != nil {
+		return err
+	}
+	if s.CallbackURL != "" {
+		u, err := url.Parse(s.CallbackURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("callback url must be an http(s) URL")
+		}
+	}
+	return nil
+}
+
+func parseOutputURL(outputURL string) (*url.URL, error) {
+	if outputURL == "" {
+		return nil, fmt.Errorf("output url is required to enable recording")
+	}
+	u, err := url.Parse(outputURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output url: %w", err)
+	}
+	if u.Scheme == "" {
+		return nil, fmt.Errorf("output url has no scheme")
+	}
+	return u, nil
+}
+
+// Apply turns the recording of a stream on or off. Sessions being recorded when it's turned off aren't processed.
+func (r *Recorder) Apply(playbackID string, settings events.RecordingSettings) error {
+	if err := ValidateSettings(settings); err != nil {
+		return err
+	}
+	streamName := r.streamName(playbackID)
+	target := r.pushTarget(playbackID)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, enabled := r.streams[playbackID]
+	if !settings.Enabled {
+		if !enabled {
+			return nil
+		}
+		delete(r.streams, playbackID)
+		if err := r.mist.PushAutoRemove([]interface{}{streamName, target}); err != nil {
+			return fmt.Errorf("error removing recording push: %w", err)
+		}
+		log.LogNoRequestID("recording disabled", log.KeyStream, streamName)
+		return nil
+	}
+
+	if !enabled {
+		if err := r.mist.PushAutoAdd(streamName, target); err != nil {
+			return fmt.Errorf("error adding recording push: %w", err)
+		}
+		log.LogNoRequestID("recording enabled", log.KeyStream, streamName)
+	}
+	r.streams[playbackID] = settings
+	return nil
+}
+
+// Settings returns the recording settings of a stream, with recording disabled if none were applied
+func (r *Recorder) Settings(playbackID string) events.RecordingSettings {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.streams[playbackID]
+}
+
+// HandlePushEnd starts processing a recording once Mist stops writing it, i.e. when the session ends
+func (r *Recorder) HandlePushEnd(ctx context.Context, payload *misttriggers.PushEndPayload) error {
+	playbackID, ok := strings.CutPrefix(payload.StreamName, r.baseStreamName+"+")
+	if !ok || payload.Destination != r.pushTarget(playbackID) {
+		return nil
+	}
+	r.mu.Lock()
+	settings, enabled := r.streams[playbackID]
+	r.mu.Unlock()
+	if !enabled {
+		log.LogCtx(ctx, "skipping recording of a stream whose recording was disabled", log.KeyStream, payload.StreamName)
+		return nil
+	}
+	if payload.PushStatus != nil && payload.PushStatus.Bytes == 0 {
+		log.LogCtx(ctx, "skipping empty recording", log.KeyStream, payload.StreamName)
+		return nil
+	}
+
+	sourceURL, sessionID, err := recordingSource(payload.ActualDestination)
+	if err != nil {
+		return fmt.Errorf("error finding recording of %s: %w", payload.StreamName, err)
+	}
+	outputURL, err := parseOutputURL(settings.OutputURL)
+	if err != nil {
+		return err
+	}
+	requestID := config.RandomTrailer(8)
+	log.AddContext(requestID, "playback_id", playbackID, "session_id", sessionID)
+	log.Log(requestID, "processing recording", "source", log.RedactURL(sourceURL))
+
+	r.vod.StartUploadJob(pipeline.UploadJobPayload{
+		SourceFile:            sourceURL,
+		CallbackURL:           settings.CallbackURL,
+		HlsTargetURL:          outputURL.JoinPath(sessionID),
+		ThumbnailsTargetURL:   outputURL.JoinPath(sessionID, "thumbnails"),
+		RequestID:             requestID,
+		ExternalID:            sessionID,
+		TargetSegmentSizeSecs: config.DefaultSegmentSizeSecs,
+	})
+	return nil
+}
+
+// pushTarget is the Mist push target recording a stream, a new $uuid directory being used for each session
+func (r *Recorder) pushTarget(playbackID string) string {
+	return fmt.Sprintf("%s/%s/$uuid/source/$segmentCounter.ts?m3u8=../%s&split=%d", r.recordingURL, playbackID, recordingManifest, config.DefaultSegmentSizeSecs)
+}
+
+// recordingSource works out the playlist of a recording and its session ID from the push target that Mist filled in
+func recordingSource(actualDestination string) (string, string, error) {
+	u, err := url.Parse(actualDestination)
+	if err != nil {
+		return "", "", err
+	}
+	m3u8 := u.Query().Get("m3u8")
+	if m3u8 == "" {
+		return "", "", fmt.Errorf("push target has no playlist")
+	}
+	u.RawQuery = ""
+	manifestURL := u.ResolveReference(&url.URL{Path: m3u8})
+	sessionID := path.Base(path.Dir(manifestURL.Path))
+	return manifestURL.String(), sessionID, nil
+}
+
+func (r *Recorder) streamName(playbackID string) string {
+	return r.baseStreamName + "+" + playbackID
+}
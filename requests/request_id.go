@@ -1,14 +1,24 @@
 package requests
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/livepeer/catalyst-api/config"
+	"github.com/livepeer/catalyst-api/log"
 )
 
+// RequestIDHeader is accepted on incoming API calls and set on outbound calls, to correlate their logs
+const RequestIDHeader = "X-Request-ID"
+
 const requestIDParam = "requestID"
 
+// GetRequestId returns the request ID set by the request ID middleware, falling back to the request headers or a
+// new ID for requests that didn't go through it
 func GetRequestId(req *http.Request) string {
+	if requestID := log.RequestID(req.Context()); requestID != "" {
+		return requestID
+	}
 	requestID := req.Header.Get(requestIDParam)
 	if requestID != "" {
 		return requestID
@@ -17,3 +27,10 @@ func GetRequestId(req *http.Request) string {
 	req.Header.Set(requestIDParam, requestID)
 	return requestID
 }
+
+// SetRequestIDHeader sets the request ID of the context on an outbound request
+func SetRequestIDHeader(ctx context.Context, req *http.Request) {
+	if requestID := log.RequestID(ctx); requestID != "" {
+		req.Header.Set(RequestIDHeader, requestID)
+	}
+}
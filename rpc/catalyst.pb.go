@@ -0,0 +1,1355 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.31.0
+// 	protoc        v4.24.4
+// source: rpc/catalyst.proto
+
+package rpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Outputs struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Hls           string `protobuf:"bytes,1,opt,name=hls,proto3" json:"hls,omitempty"`
+	Mp4           string `protobuf:"bytes,2,opt,name=mp4,proto3" json:"mp4,omitempty"`
+	FragmentedMp4 string `protobuf:"bytes,3,opt,name=fragmented_mp4,json=fragmentedMp4,proto3" json:"fragmented_mp4,omitempty"`
+	Clip          string `protobuf:"bytes,4,opt,name=clip,proto3" json:"clip,omitempty"`
+	SourceMp4     bool   `protobuf:"varint,5,opt,name=source_mp4,json=sourceMp4,proto3" json:"source_mp4,omitempty"`
+	Thumbnails    string `protobuf:"bytes,6,opt,name=thumbnails,proto3" json:"thumbnails,omitempty"`
+}
+
+func (x *Outputs) Reset() {
+	*x = Outputs{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_catalyst_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Outputs) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Outputs) ProtoMessage() {}
+
+func (x *Outputs) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_catalyst_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Outputs.ProtoReflect.Descriptor instead.
+func (*Outputs) Descriptor() ([]byte, []int) {
+	return file_rpc_catalyst_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Outputs) GetHls() string {
+	if x != nil {
+		return x.Hls
+	}
+	return ""
+}
+
+func (x *Outputs) GetMp4() string {
+	if x != nil {
+		return x.Mp4
+	}
+	return ""
+}
+
+func (x *Outputs) GetFragmentedMp4() string {
+	if x != nil {
+		return x.FragmentedMp4
+	}
+	return ""
+}
+
+func (x *Outputs) GetClip() string {
+	if x != nil {
+		return x.Clip
+	}
+	return ""
+}
+
+func (x *Outputs) GetSourceMp4() bool {
+	if x != nil {
+		return x.SourceMp4
+	}
+	return false
+}
+
+func (x *Outputs) GetThumbnails() string {
+	if x != nil {
+		return x.Thumbnails
+	}
+	return ""
+}
+
+type OutputLocation struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type            string   `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Url             string   `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	PinataAccessKey string   `protobuf:"bytes,3,opt,name=pinata_access_key,json=pinataAccessKey,proto3" json:"pinata_access_key,omitempty"`
+	Outputs         *Outputs `protobuf:"bytes,4,opt,name=outputs,proto3" json:"outputs,omitempty"`
+}
+
+func (x *OutputLocation) Reset() {
+	*x = OutputLocation{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_catalyst_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *OutputLocation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OutputLocation) ProtoMessage() {}
+
+func (x *OutputLocation) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_catalyst_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OutputLocation.ProtoReflect.Descriptor instead.
+func (*OutputLocation) Descriptor() ([]byte, []int) {
+	return file_rpc_catalyst_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *OutputLocation) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *OutputLocation) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *OutputLocation) GetPinataAccessKey() string {
+	if x != nil {
+		return x.PinataAccessKey
+	}
+	return ""
+}
+
+func (x *OutputLocation) GetOutputs() *Outputs {
+	if x != nil {
+		return x.Outputs
+	}
+	return nil
+}
+
+type EncodedProfile struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name         string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Width        int64  `protobuf:"varint,2,opt,name=width,proto3" json:"width,omitempty"`
+	Height       int64  `protobuf:"varint,3,opt,name=height,proto3" json:"height,omitempty"`
+	Bitrate      int64  `protobuf:"varint,4,opt,name=bitrate,proto3" json:"bitrate,omitempty"`
+	Fps          int64  `protobuf:"varint,5,opt,name=fps,proto3" json:"fps,omitempty"`
+	FpsDen       int64  `protobuf:"varint,6,opt,name=fps_den,json=fpsDen,proto3" json:"fps_den,omitempty"`
+	Profile      string `protobuf:"bytes,7,opt,name=profile,proto3" json:"profile,omitempty"`
+	Gop          string `protobuf:"bytes,8,opt,name=gop,proto3" json:"gop,omitempty"`
+	Encoder      string `protobuf:"bytes,9,opt,name=encoder,proto3" json:"encoder,omitempty"`
+	ColorDepth   int64  `protobuf:"varint,10,opt,name=color_depth,json=colorDepth,proto3" json:"color_depth,omitempty"`
+	ChromaFormat int64  `protobuf:"varint,11,opt,name=chroma_format,json=chromaFormat,proto3" json:"chroma_format,omitempty"`
+	Quality      uint32 `protobuf:"varint,12,opt,name=quality,proto3" json:"quality,omitempty"`
+}
+
+func (x *EncodedProfile) Reset() {
+	*x = EncodedProfile{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_catalyst_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EncodedProfile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EncodedProfile) ProtoMessage() {}
+
+func (x *EncodedProfile) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_catalyst_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EncodedProfile.ProtoReflect.Descriptor instead.
+func (*EncodedProfile) Descriptor() ([]byte, []int) {
+	return file_rpc_catalyst_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *EncodedProfile) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *EncodedProfile) GetWidth() int64 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *EncodedProfile) GetHeight() int64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *EncodedProfile) GetBitrate() int64 {
+	if x != nil {
+		return x.Bitrate
+	}
+	return 0
+}
+
+func (x *EncodedProfile) GetFps() int64 {
+	if x != nil {
+		return x.Fps
+	}
+	return 0
+}
+
+func (x *EncodedProfile) GetFpsDen() int64 {
+	if x != nil {
+		return x.FpsDen
+	}
+	return 0
+}
+
+func (x *EncodedProfile) GetProfile() string {
+	if x != nil {
+		return x.Profile
+	}
+	return ""
+}
+
+func (x *EncodedProfile) GetGop() string {
+	if x != nil {
+		return x.Gop
+	}
+	return ""
+}
+
+func (x *EncodedProfile) GetEncoder() string {
+	if x != nil {
+		return x.Encoder
+	}
+	return ""
+}
+
+func (x *EncodedProfile) GetColorDepth() int64 {
+	if x != nil {
+		return x.ColorDepth
+	}
+	return 0
+}
+
+func (x *EncodedProfile) GetChromaFormat() int64 {
+	if x != nil {
+		return x.ChromaFormat
+	}
+	return 0
+}
+
+func (x *EncodedProfile) GetQuality() uint32 {
+	if x != nil {
+		return x.Quality
+	}
+	return 0
+}
+
+type Encryption struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	EncryptedKey       string `protobuf:"bytes,1,opt,name=encrypted_key,json=encryptedKey,proto3" json:"encrypted_key,omitempty"`
+	Algorithm          string `protobuf:"bytes,2,opt,name=algorithm,proto3" json:"algorithm,omitempty"`
+	KeyId              string `protobuf:"bytes,3,opt,name=key_id,json=keyId,proto3" json:"key_id,omitempty"`
+	KeyWrapping        string `protobuf:"bytes,4,opt,name=key_wrapping,json=keyWrapping,proto3" json:"key_wrapping,omitempty"`
+	EphemeralPublicKey string `protobuf:"bytes,5,opt,name=ephemeral_public_key,json=ephemeralPublicKey,proto3" json:"ephemeral_public_key,omitempty"`
+}
+
+func (x *Encryption) Reset() {
+	*x = Encryption{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_catalyst_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Encryption) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Encryption) ProtoMessage() {}
+
+func (x *Encryption) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_catalyst_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Encryption.ProtoReflect.Descriptor instead.
+func (*Encryption) Descriptor() ([]byte, []int) {
+	return file_rpc_catalyst_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Encryption) GetEncryptedKey() string {
+	if x != nil {
+		return x.EncryptedKey
+	}
+	return ""
+}
+
+func (x *Encryption) GetAlgorithm() string {
+	if x != nil {
+		return x.Algorithm
+	}
+	return ""
+}
+
+func (x *Encryption) GetKeyId() string {
+	if x != nil {
+		return x.KeyId
+	}
+	return ""
+}
+
+func (x *Encryption) GetKeyWrapping() string {
+	if x != nil {
+		return x.KeyWrapping
+	}
+	return ""
+}
+
+func (x *Encryption) GetEphemeralPublicKey() string {
+	if x != nil {
+		return x.EphemeralPublicKey
+	}
+	return ""
+}
+
+type ThumbnailOptions struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Resolution  string  `protobuf:"bytes,1,opt,name=resolution,proto3" json:"resolution,omitempty"`
+	Interval    float64 `protobuf:"fixed64,2,opt,name=interval,proto3" json:"interval,omitempty"`
+	Quality     int32   `protobuf:"varint,3,opt,name=quality,proto3" json:"quality,omitempty"`
+	Format      string  `protobuf:"bytes,4,opt,name=format,proto3" json:"format,omitempty"`
+	SkipMissing bool    `protobuf:"varint,5,opt,name=skip_missing,json=skipMissing,proto3" json:"skip_missing,omitempty"`
+}
+
+func (x *ThumbnailOptions) Reset() {
+	*x = ThumbnailOptions{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_catalyst_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ThumbnailOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ThumbnailOptions) ProtoMessage() {}
+
+func (x *ThumbnailOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_catalyst_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ThumbnailOptions.ProtoReflect.Descriptor instead.
+func (*ThumbnailOptions) Descriptor() ([]byte, []int) {
+	return file_rpc_catalyst_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ThumbnailOptions) GetResolution() string {
+	if x != nil {
+		return x.Resolution
+	}
+	return ""
+}
+
+func (x *ThumbnailOptions) GetInterval() float64 {
+	if x != nil {
+		return x.Interval
+	}
+	return 0
+}
+
+func (x *ThumbnailOptions) GetQuality() int32 {
+	if x != nil {
+		return x.Quality
+	}
+	return 0
+}
+
+func (x *ThumbnailOptions) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *ThumbnailOptions) GetSkipMissing() bool {
+	if x != nil {
+		return x.SkipMissing
+	}
+	return false
+}
+
+type ClipStrategy struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	StartTime  int64  `protobuf:"varint,1,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	EndTime    int64  `protobuf:"varint,2,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`
+	PlaybackId string `protobuf:"bytes,3,opt,name=playback_id,json=playbackId,proto3" json:"playback_id,omitempty"`
+}
+
+func (x *ClipStrategy) Reset() {
+	*x = ClipStrategy{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_catalyst_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ClipStrategy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClipStrategy) ProtoMessage() {}
+
+func (x *ClipStrategy) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_catalyst_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClipStrategy.ProtoReflect.Descriptor instead.
+func (*ClipStrategy) Descriptor() ([]byte, []int) {
+	return file_rpc_catalyst_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ClipStrategy) GetStartTime() int64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *ClipStrategy) GetEndTime() int64 {
+	if x != nil {
+		return x.EndTime
+	}
+	return 0
+}
+
+func (x *ClipStrategy) GetPlaybackId() string {
+	if x != nil {
+		return x.PlaybackId
+	}
+	return ""
+}
+
+type SubmitVODRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ExternalId            string            `protobuf:"bytes,1,opt,name=external_id,json=externalId,proto3" json:"external_id,omitempty"`
+	Url                   string            `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	CallbackUrl           string            `protobuf:"bytes,3,opt,name=callback_url,json=callbackUrl,proto3" json:"callback_url,omitempty"`
+	OutputLocations       []*OutputLocation `protobuf:"bytes,4,rep,name=output_locations,json=outputLocations,proto3" json:"output_locations,omitempty"`
+	AccessToken           string            `protobuf:"bytes,5,opt,name=access_token,json=accessToken,proto3" json:"access_token,omitempty"`
+	TranscodeApiUrl       string            `protobuf:"bytes,6,opt,name=transcode_api_url,json=transcodeApiUrl,proto3" json:"transcode_api_url,omitempty"`
+	Encryption            *Encryption       `protobuf:"bytes,7,opt,name=encryption,proto3" json:"encryption,omitempty"`
+	C2Pa                  bool              `protobuf:"varint,8,opt,name=c2pa,proto3" json:"c2pa,omitempty"`
+	ThumbnailOptions      *ThumbnailOptions `protobuf:"bytes,9,opt,name=thumbnail_options,json=thumbnailOptions,proto3" json:"thumbnail_options,omitempty"`
+	TargetSegmentSizeSecs int64             `protobuf:"varint,10,opt,name=target_segment_size_secs,json=targetSegmentSizeSecs,proto3" json:"target_segment_size_secs,omitempty"`
+	Profiles              []*EncodedProfile `protobuf:"bytes,11,rep,name=profiles,proto3" json:"profiles,omitempty"`
+	PipelineStrategy      string            `protobuf:"bytes,12,opt,name=pipeline_strategy,json=pipelineStrategy,proto3" json:"pipeline_strategy,omitempty"`
+	ClipStrategy          *ClipStrategy     `protobuf:"bytes,13,opt,name=clip_strategy,json=clipStrategy,proto3" json:"clip_strategy,omitempty"`
+}
+
+func (x *SubmitVODRequest) Reset() {
+	*x = SubmitVODRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_catalyst_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SubmitVODRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitVODRequest) ProtoMessage() {}
+
+func (x *SubmitVODRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_catalyst_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitVODRequest.ProtoReflect.Descriptor instead.
+func (*SubmitVODRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_catalyst_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SubmitVODRequest) GetExternalId() string {
+	if x != nil {
+		return x.ExternalId
+	}
+	return ""
+}
+
+func (x *SubmitVODRequest) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *SubmitVODRequest) GetCallbackUrl() string {
+	if x != nil {
+		return x.CallbackUrl
+	}
+	return ""
+}
+
+func (x *SubmitVODRequest) GetOutputLocations() []*OutputLocation {
+	if x != nil {
+		return x.OutputLocations
+	}
+	return nil
+}
+
+func (x *SubmitVODRequest) GetAccessToken() string {
+	if x != nil {
+		return x.AccessToken
+	}
+	return ""
+}
+
+func (x *SubmitVODRequest) GetTranscodeApiUrl() string {
+	if x != nil {
+		return x.TranscodeApiUrl
+	}
+	return ""
+}
+
+func (x *SubmitVODRequest) GetEncryption() *Encryption {
+	if x != nil {
+		return x.Encryption
+	}
+	return nil
+}
+
+func (x *SubmitVODRequest) GetC2Pa() bool {
+	if x != nil {
+		return x.C2Pa
+	}
+	return false
+}
+
+func (x *SubmitVODRequest) GetThumbnailOptions() *ThumbnailOptions {
+	if x != nil {
+		return x.ThumbnailOptions
+	}
+	return nil
+}
+
+func (x *SubmitVODRequest) GetTargetSegmentSizeSecs() int64 {
+	if x != nil {
+		return x.TargetSegmentSizeSecs
+	}
+	return 0
+}
+
+func (x *SubmitVODRequest) GetProfiles() []*EncodedProfile {
+	if x != nil {
+		return x.Profiles
+	}
+	return nil
+}
+
+func (x *SubmitVODRequest) GetPipelineStrategy() string {
+	if x != nil {
+		return x.PipelineStrategy
+	}
+	return ""
+}
+
+func (x *SubmitVODRequest) GetClipStrategy() *ClipStrategy {
+	if x != nil {
+		return x.ClipStrategy
+	}
+	return nil
+}
+
+type SubmitVODResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RequestId string `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+}
+
+func (x *SubmitVODResponse) Reset() {
+	*x = SubmitVODResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_catalyst_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SubmitVODResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitVODResponse) ProtoMessage() {}
+
+func (x *SubmitVODResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_catalyst_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitVODResponse.ProtoReflect.Descriptor instead.
+func (*SubmitVODResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_catalyst_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SubmitVODResponse) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+type GetJobStatusRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RequestId string `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+}
+
+func (x *GetJobStatusRequest) Reset() {
+	*x = GetJobStatusRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_catalyst_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetJobStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetJobStatusRequest) ProtoMessage() {}
+
+func (x *GetJobStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_catalyst_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetJobStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetJobStatusRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_catalyst_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetJobStatusRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+type JobStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RequestId        string `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	ExternalId       string `protobuf:"bytes,2,opt,name=external_id,json=externalId,proto3" json:"external_id,omitempty"`
+	PipelineStrategy string `protobuf:"bytes,3,opt,name=pipeline_strategy,json=pipelineStrategy,proto3" json:"pipeline_strategy,omitempty"`
+	// stage is one of preparing, preparing-completed or transcoding, as sent in the status callbacks
+	Stage string `protobuf:"bytes,4,opt,name=stage,proto3" json:"stage,omitempty"`
+	// completion_ratio of the current stage, from 0 to 1
+	CompletionRatio float64 `protobuf:"fixed64,5,opt,name=completion_ratio,json=completionRatio,proto3" json:"completion_ratio,omitempty"`
+}
+
+func (x *JobStatus) Reset() {
+	*x = JobStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_catalyst_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *JobStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobStatus) ProtoMessage() {}
+
+func (x *JobStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_catalyst_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobStatus.ProtoReflect.Descriptor instead.
+func (*JobStatus) Descriptor() ([]byte, []int) {
+	return file_rpc_catalyst_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *JobStatus) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *JobStatus) GetExternalId() string {
+	if x != nil {
+		return x.ExternalId
+	}
+	return ""
+}
+
+func (x *JobStatus) GetPipelineStrategy() string {
+	if x != nil {
+		return x.PipelineStrategy
+	}
+	return ""
+}
+
+func (x *JobStatus) GetStage() string {
+	if x != nil {
+		return x.Stage
+	}
+	return ""
+}
+
+func (x *JobStatus) GetCompletionRatio() float64 {
+	if x != nil {
+		return x.CompletionRatio
+	}
+	return 0
+}
+
+type BroadcastEventRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// event_id identifies retried requests, so that the event isn't broadcast twice. Generated if empty.
+	EventId    string `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	Resource   string `protobuf:"bytes,2,opt,name=resource,proto3" json:"resource,omitempty"`
+	PlaybackId string `protobuf:"bytes,3,opt,name=playback_id,json=playbackId,proto3" json:"playback_id,omitempty"`
+	Version    int32  `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`
+}
+
+func (x *BroadcastEventRequest) Reset() {
+	*x = BroadcastEventRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_catalyst_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BroadcastEventRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BroadcastEventRequest) ProtoMessage() {}
+
+func (x *BroadcastEventRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_catalyst_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BroadcastEventRequest.ProtoReflect.Descriptor instead.
+func (*BroadcastEventRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_catalyst_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *BroadcastEventRequest) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *BroadcastEventRequest) GetResource() string {
+	if x != nil {
+		return x.Resource
+	}
+	return ""
+}
+
+func (x *BroadcastEventRequest) GetPlaybackId() string {
+	if x != nil {
+		return x.PlaybackId
+	}
+	return ""
+}
+
+func (x *BroadcastEventRequest) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type BroadcastEventResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	EventId string `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+}
+
+func (x *BroadcastEventResponse) Reset() {
+	*x = BroadcastEventResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_catalyst_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BroadcastEventResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BroadcastEventResponse) ProtoMessage() {}
+
+func (x *BroadcastEventResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_catalyst_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BroadcastEventResponse.ProtoReflect.Descriptor instead.
+func (*BroadcastEventResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_catalyst_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *BroadcastEventResponse) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+var File_rpc_catalyst_proto protoreflect.FileDescriptor
+
+var file_rpc_catalyst_proto_rawDesc = []byte{
+	0x0a, 0x12, 0x72, 0x70, 0x63, 0x2f, 0x63, 0x61, 0x74, 0x61, 0x6c, 0x79, 0x73, 0x74, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0b, 0x63, 0x61, 0x74, 0x61, 0x6c, 0x79, 0x73, 0x74, 0x2e, 0x76,
+	0x31, 0x22, 0xa7, 0x01, 0x0a, 0x07, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x73, 0x12, 0x10, 0x0a,
+	0x03, 0x68, 0x6c, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x68, 0x6c, 0x73, 0x12,
+	0x10, 0x0a, 0x03, 0x6d, 0x70, 0x34, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6d, 0x70,
+	0x34, 0x12, 0x25, 0x0a, 0x0e, 0x66, 0x72, 0x61, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x65, 0x64, 0x5f,
+	0x6d, 0x70, 0x34, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x66, 0x72, 0x61, 0x67, 0x6d,
+	0x65, 0x6e, 0x74, 0x65, 0x64, 0x4d, 0x70, 0x34, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6c, 0x69, 0x70,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x63, 0x6c, 0x69, 0x70, 0x12, 0x1d, 0x0a, 0x0a,
+	0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f, 0x6d, 0x70, 0x34, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x09, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x4d, 0x70, 0x34, 0x12, 0x1e, 0x0a, 0x0a, 0x74,
+	0x68, 0x75, 0x6d, 0x62, 0x6e, 0x61, 0x69, 0x6c, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0a, 0x74, 0x68, 0x75, 0x6d, 0x62, 0x6e, 0x61, 0x69, 0x6c, 0x73, 0x22, 0x92, 0x01, 0x0a, 0x0e,
+	0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12,
+	0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79,
+	0x70, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x75, 0x72, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x03, 0x75, 0x72, 0x6c, 0x12, 0x2a, 0x0a, 0x11, 0x70, 0x69, 0x6e, 0x61, 0x74, 0x61, 0x5f, 0x61,
+	0x63, 0x63, 0x65, 0x73, 0x73, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0f, 0x70, 0x69, 0x6e, 0x61, 0x74, 0x61, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x4b, 0x65, 0x79,
+	0x12, 0x2e, 0x0a, 0x07, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x14, 0x2e, 0x63, 0x61, 0x74, 0x61, 0x6c, 0x79, 0x73, 0x74, 0x2e, 0x76, 0x31, 0x2e,
+	0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x73, 0x52, 0x07, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x73,
+	0x22, 0xbd, 0x02, 0x0a, 0x0e, 0x45, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x64, 0x50, 0x72, 0x6f, 0x66,
+	0x69, 0x6c, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x12, 0x16, 0x0a,
+	0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x68,
+	0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x62, 0x69, 0x74, 0x72, 0x61, 0x74, 0x65,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x62, 0x69, 0x74, 0x72, 0x61, 0x74, 0x65, 0x12,
+	0x10, 0x0a, 0x03, 0x66, 0x70, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x66, 0x70,
+	0x73, 0x12, 0x17, 0x0a, 0x07, 0x66, 0x70, 0x73, 0x5f, 0x64, 0x65, 0x6e, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x06, 0x66, 0x70, 0x73, 0x44, 0x65, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x72,
+	0x6f, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x70, 0x72, 0x6f,
+	0x66, 0x69, 0x6c, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x67, 0x6f, 0x70, 0x18, 0x08, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x03, 0x67, 0x6f, 0x70, 0x12, 0x18, 0x0a, 0x07, 0x65, 0x6e, 0x63, 0x6f, 0x64, 0x65,
+	0x72, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x65, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x72,
+	0x12, 0x1f, 0x0a, 0x0b, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x5f, 0x64, 0x65, 0x70, 0x74, 0x68, 0x18,
+	0x0a, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x44, 0x65, 0x70, 0x74,
+	0x68, 0x12, 0x23, 0x0a, 0x0d, 0x63, 0x68, 0x72, 0x6f, 0x6d, 0x61, 0x5f, 0x66, 0x6f, 0x72, 0x6d,
+	0x61, 0x74, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x63, 0x68, 0x72, 0x6f, 0x6d, 0x61,
+	0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x71, 0x75, 0x61, 0x6c, 0x69, 0x74,
+	0x79, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x71, 0x75, 0x61, 0x6c, 0x69, 0x74, 0x79,
+	0x22, 0xbb, 0x01, 0x0a, 0x0a, 0x45, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x12,
+	0x23, 0x0a, 0x0d, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x65, 0x64, 0x5f, 0x6b, 0x65, 0x79,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x65,
+	0x64, 0x4b, 0x65, 0x79, 0x12, 0x1c, 0x0a, 0x09, 0x61, 0x6c, 0x67, 0x6f, 0x72, 0x69, 0x74, 0x68,
+	0x6d, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x61, 0x6c, 0x67, 0x6f, 0x72, 0x69, 0x74,
+	0x68, 0x6d, 0x12, 0x15, 0x0a, 0x06, 0x6b, 0x65, 0x79, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x6b, 0x65, 0x79, 0x49, 0x64, 0x12, 0x21, 0x0a, 0x0c, 0x6b, 0x65, 0x79,
+	0x5f, 0x77, 0x72, 0x61, 0x70, 0x70, 0x69, 0x6e, 0x67, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0b, 0x6b, 0x65, 0x79, 0x57, 0x72, 0x61, 0x70, 0x70, 0x69, 0x6e, 0x67, 0x12, 0x30, 0x0a, 0x14,
+	0x65, 0x70, 0x68, 0x65, 0x6d, 0x65, 0x72, 0x61, 0x6c, 0x5f, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63,
+	0x5f, 0x6b, 0x65, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x12, 0x65, 0x70, 0x68, 0x65,
+	0x6d, 0x65, 0x72, 0x61, 0x6c, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x22, 0xa3,
+	0x01, 0x0a, 0x10, 0x54, 0x68, 0x75, 0x6d, 0x62, 0x6e, 0x61, 0x69, 0x6c, 0x4f, 0x70, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x72, 0x65, 0x73, 0x6f, 0x6c, 0x75, 0x74, 0x69, 0x6f,
+	0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x72, 0x65, 0x73, 0x6f, 0x6c, 0x75, 0x74,
+	0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x08, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x12,
+	0x18, 0x0a, 0x07, 0x71, 0x75, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x07, 0x71, 0x75, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x66, 0x6f, 0x72,
+	0x6d, 0x61, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x66, 0x6f, 0x72, 0x6d, 0x61,
+	0x74, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x6b, 0x69, 0x70, 0x5f, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6e,
+	0x67, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x73, 0x6b, 0x69, 0x70, 0x4d, 0x69, 0x73,
+	0x73, 0x69, 0x6e, 0x67, 0x22, 0x69, 0x0a, 0x0c, 0x43, 0x6c, 0x69, 0x70, 0x53, 0x74, 0x72, 0x61,
+	0x74, 0x65, 0x67, 0x79, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x74, 0x69,
+	0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x73, 0x74, 0x61, 0x72, 0x74, 0x54,
+	0x69, 0x6d, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x65, 0x6e, 0x64, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x65, 0x6e, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x1f,
+	0x0a, 0x0b, 0x70, 0x6c, 0x61, 0x79, 0x62, 0x61, 0x63, 0x6b, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0a, 0x70, 0x6c, 0x61, 0x79, 0x62, 0x61, 0x63, 0x6b, 0x49, 0x64, 0x22,
+	0xf7, 0x04, 0x0a, 0x10, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x56, 0x4f, 0x44, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x65, 0x78, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c,
+	0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x65, 0x78, 0x74, 0x65, 0x72,
+	0x6e, 0x61, 0x6c, 0x49, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x75, 0x72, 0x6c, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x03, 0x75, 0x72, 0x6c, 0x12, 0x21, 0x0a, 0x0c, 0x63, 0x61, 0x6c, 0x6c, 0x62,
+	0x61, 0x63, 0x6b, 0x5f, 0x75, 0x72, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x63,
+	0x61, 0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x55, 0x72, 0x6c, 0x12, 0x46, 0x0a, 0x10, 0x6f, 0x75,
+	0x74, 0x70, 0x75, 0x74, 0x5f, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x04,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x63, 0x61, 0x74, 0x61, 0x6c, 0x79, 0x73, 0x74, 0x2e,
+	0x76, 0x31, 0x2e, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x52, 0x0f, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x5f, 0x74, 0x6f, 0x6b,
+	0x65, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73,
+	0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x2a, 0x0a, 0x11, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f,
+	0x64, 0x65, 0x5f, 0x61, 0x70, 0x69, 0x5f, 0x75, 0x72, 0x6c, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0f, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x65, 0x41, 0x70, 0x69, 0x55, 0x72,
+	0x6c, 0x12, 0x37, 0x0a, 0x0a, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x63, 0x61, 0x74, 0x61, 0x6c, 0x79, 0x73, 0x74,
+	0x2e, 0x76, 0x31, 0x2e, 0x45, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0a,
+	0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x32,
+	0x70, 0x61, 0x18, 0x08, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x63, 0x32, 0x70, 0x61, 0x12, 0x4a,
+	0x0a, 0x11, 0x74, 0x68, 0x75, 0x6d, 0x62, 0x6e, 0x61, 0x69, 0x6c, 0x5f, 0x6f, 0x70, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x63, 0x61, 0x74, 0x61,
+	0x6c, 0x79, 0x73, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x68, 0x75, 0x6d, 0x62, 0x6e, 0x61, 0x69,
+	0x6c, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x10, 0x74, 0x68, 0x75, 0x6d, 0x62, 0x6e,
+	0x61, 0x69, 0x6c, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x37, 0x0a, 0x18, 0x74, 0x61,
+	0x72, 0x67, 0x65, 0x74, 0x5f, 0x73, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x73, 0x69, 0x7a,
+	0x65, 0x5f, 0x73, 0x65, 0x63, 0x73, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x03, 0x52, 0x15, 0x74, 0x61,
+	0x72, 0x67, 0x65, 0x74, 0x53, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x53, 0x69, 0x7a, 0x65, 0x53,
+	0x65, 0x63, 0x73, 0x12, 0x37, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x73, 0x18,
+	0x0b, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x63, 0x61, 0x74, 0x61, 0x6c, 0x79, 0x73, 0x74,
+	0x2e, 0x76, 0x31, 0x2e, 0x45, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x64, 0x50, 0x72, 0x6f, 0x66, 0x69,
+	0x6c, 0x65, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x73, 0x12, 0x2b, 0x0a, 0x11,
+	0x70, 0x69, 0x70, 0x65, 0x6c, 0x69, 0x6e, 0x65, 0x5f, 0x73, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67,
+	0x79, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x09, 0x52, 0x10, 0x70, 0x69, 0x70, 0x65, 0x6c, 0x69, 0x6e,
+	0x65, 0x53, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x12, 0x3e, 0x0a, 0x0d, 0x63, 0x6c, 0x69,
+	0x70, 0x5f, 0x73, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x19, 0x2e, 0x63, 0x61, 0x74, 0x61, 0x6c, 0x79, 0x73, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x43,
+	0x6c, 0x69, 0x70, 0x53, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x52, 0x0c, 0x63, 0x6c, 0x69,
+	0x70, 0x53, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x22, 0x32, 0x0a, 0x11, 0x53, 0x75, 0x62,
+	0x6d, 0x69, 0x74, 0x56, 0x4f, 0x44, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1d,
+	0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x22, 0x34, 0x0a,
+	0x13, 0x47, 0x65, 0x74, 0x4a, 0x6f, 0x62, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x49, 0x64, 0x22, 0xb9, 0x01, 0x0a, 0x09, 0x4a, 0x6f, 0x62, 0x53, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x12, 0x1d, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x69, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64,
+	0x12, 0x1f, 0x0a, 0x0b, 0x65, 0x78, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x5f, 0x69, 0x64, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x65, 0x78, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x49,
+	0x64, 0x12, 0x2b, 0x0a, 0x11, 0x70, 0x69, 0x70, 0x65, 0x6c, 0x69, 0x6e, 0x65, 0x5f, 0x73, 0x74,
+	0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x10, 0x70, 0x69,
+	0x70, 0x65, 0x6c, 0x69, 0x6e, 0x65, 0x53, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x12, 0x14,
+	0x0a, 0x05, 0x73, 0x74, 0x61, 0x67, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73,
+	0x74, 0x61, 0x67, 0x65, 0x12, 0x29, 0x0a, 0x10, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x69,
+	0x6f, 0x6e, 0x5f, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x18, 0x05, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0f,
+	0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x61, 0x74, 0x69, 0x6f, 0x22,
+	0x89, 0x01, 0x0a, 0x15, 0x42, 0x72, 0x6f, 0x61, 0x64, 0x63, 0x61, 0x73, 0x74, 0x45, 0x76, 0x65,
+	0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x65, 0x76, 0x65,
+	0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x65, 0x76, 0x65,
+	0x6e, 0x74, 0x49, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x72, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65,
+	0x12, 0x1f, 0x0a, 0x0b, 0x70, 0x6c, 0x61, 0x79, 0x62, 0x61, 0x63, 0x6b, 0x5f, 0x69, 0x64, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x70, 0x6c, 0x61, 0x79, 0x62, 0x61, 0x63, 0x6b, 0x49,
+	0x64, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x33, 0x0a, 0x16, 0x42,
+	0x72, 0x6f, 0x61, 0x64, 0x63, 0x61, 0x73, 0x74, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x5f, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x49, 0x64,
+	0x32, 0xfb, 0x01, 0x0a, 0x08, 0x43, 0x61, 0x74, 0x61, 0x6c, 0x79, 0x73, 0x74, 0x12, 0x4a, 0x0a,
+	0x09, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x56, 0x4f, 0x44, 0x12, 0x1d, 0x2e, 0x63, 0x61, 0x74,
+	0x61, 0x6c, 0x79, 0x73, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x56,
+	0x4f, 0x44, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x63, 0x61, 0x74, 0x61,
+	0x6c, 0x79, 0x73, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x56, 0x4f,
+	0x44, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x48, 0x0a, 0x0c, 0x47, 0x65, 0x74,
+	0x4a, 0x6f, 0x62, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x20, 0x2e, 0x63, 0x61, 0x74, 0x61,
+	0x6c, 0x79, 0x73, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x4a, 0x6f, 0x62, 0x53, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x63, 0x61,
+	0x74, 0x61, 0x6c, 0x79, 0x73, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x4a, 0x6f, 0x62, 0x53, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x12, 0x59, 0x0a, 0x0e, 0x42, 0x72, 0x6f, 0x61, 0x64, 0x63, 0x61, 0x73, 0x74,
+	0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x22, 0x2e, 0x63, 0x61, 0x74, 0x61, 0x6c, 0x79, 0x73, 0x74,
+	0x2e, 0x76, 0x31, 0x2e, 0x42, 0x72, 0x6f, 0x61, 0x64, 0x63, 0x61, 0x73, 0x74, 0x45, 0x76, 0x65,
+	0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x23, 0x2e, 0x63, 0x61, 0x74, 0x61,
+	0x6c, 0x79, 0x73, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x72, 0x6f, 0x61, 0x64, 0x63, 0x61, 0x73,
+	0x74, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x26,
+	0x5a, 0x24, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6c, 0x69, 0x76,
+	0x65, 0x70, 0x65, 0x65, 0x72, 0x2f, 0x63, 0x61, 0x74, 0x61, 0x6c, 0x79, 0x73, 0x74, 0x2d, 0x61,
+	0x70, 0x69, 0x2f, 0x72, 0x70, 0x63, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_rpc_catalyst_proto_rawDescOnce sync.Once
+	file_rpc_catalyst_proto_rawDescData = file_rpc_catalyst_proto_rawDesc
+)
+
+func file_rpc_catalyst_proto_rawDescGZIP() []byte {
+	file_rpc_catalyst_proto_rawDescOnce.Do(func() {
+		file_rpc_catalyst_proto_rawDescData = protoimpl.X.CompressGZIP(file_rpc_catalyst_proto_rawDescData)
+	})
+	return file_rpc_catalyst_proto_rawDescData
+}
+
+var file_rpc_catalyst_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_rpc_catalyst_proto_goTypes = []interface{}{
+	(*Outputs)(nil),                // 0: catalyst.v1.Outputs
+	(*OutputLocation)(nil),         // 1: catalyst.v1.OutputLocation
+	(*EncodedProfile)(nil),         // 2: catalyst.v1.EncodedProfile
+	(*Encryption)(nil),             // 3: catalyst.v1.Encryption
+	(*ThumbnailOptions)(nil),       // 4: catalyst.v1.ThumbnailOptions
+	(*ClipStrategy)(nil),           // 5: catalyst.v1.ClipStrategy
+	(*SubmitVODRequest)(nil),       // 6: catalyst.v1.SubmitVODRequest
+	(*SubmitVODResponse)(nil),      // 7: catalyst.v1.SubmitVODResponse
+	(*GetJobStatusRequest)(nil),    // 8: catalyst.v1.GetJobStatusRequest
+	(*JobStatus)(nil),              // 9: catalyst.v1.JobStatus
+	(*BroadcastEventRequest)(nil),  // 10: catalyst.v1.BroadcastEventRequest
+	(*BroadcastEventResponse)(nil), // 11: catalyst.v1.BroadcastEventResponse
+}
+var file_rpc_catalyst_proto_depIdxs = []int32{
+	0,  // 0: catalyst.v1.OutputLocation.outputs:type_name -> catalyst.v1.Outputs
+	1,  // 1: catalyst.v1.SubmitVODRequest.output_locations:type_name -> catalyst.v1.OutputLocation
+	3,  // 2: catalyst.v1.SubmitVODRequest.encryption:type_name -> catalyst.v1.Encryption
+	4,  // 3: catalyst.v1.SubmitVODRequest.thumbnail_options:type_name -> catalyst.v1.ThumbnailOptions
+	2,  // 4: catalyst.v1.SubmitVODRequest.profiles:type_name -> catalyst.v1.EncodedProfile
+	5,  // 5: catalyst.v1.SubmitVODRequest.clip_strategy:type_name -> catalyst.v1.ClipStrategy
+	6,  // 6: catalyst.v1.Catalyst.SubmitVOD:input_type -> catalyst.v1.SubmitVODRequest
+	8,  // 7: catalyst.v1.Catalyst.GetJobStatus:input_type -> catalyst.v1.GetJobStatusRequest
+	10, // 8: catalyst.v1.Catalyst.BroadcastEvent:input_type -> catalyst.v1.BroadcastEventRequest
+	7,  // 9: catalyst.v1.Catalyst.SubmitVOD:output_type -> catalyst.v1.SubmitVODResponse
+	9,  // 10: catalyst.v1.Catalyst.GetJobStatus:output_type -> catalyst.v1.JobStatus
+	11, // 11: catalyst.v1.Catalyst.BroadcastEvent:output_type -> catalyst.v1.BroadcastEventResponse
+	9,  // [9:12] is the sub-list for method output_type
+	6,  // [6:9] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_rpc_catalyst_proto_init() }
+func file_rpc_catalyst_proto_init() {
+	if File_rpc_catalyst_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_rpc_catalyst_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Outputs); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rpc_catalyst_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*OutputLocation); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rpc_catalyst_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EncodedProfile); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rpc_catalyst_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Encryption); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rpc_catalyst_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ThumbnailOptions); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rpc_catalyst_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ClipStrategy); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rpc_catalyst_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SubmitVODRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rpc_catalyst_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SubmitVODResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rpc_catalyst_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetJobStatusRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rpc_catalyst_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*JobStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rpc_catalyst_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BroadcastEventRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rpc_catalyst_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BroadcastEventResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_rpc_catalyst_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_rpc_catalyst_proto_goTypes,
+		DependencyIndexes: file_rpc_catalyst_proto_depIdxs,
+		MessageInfos:      file_rpc_catalyst_proto_msgTypes,
+	}.Build()
+	File_rpc_catalyst_proto = out.File
+	file_rpc_catalyst_proto_rawDesc = nil
+	file_rpc_catalyst_proto_goTypes = nil
+	file_rpc_catalyst_proto_depIdxs = nil
+}
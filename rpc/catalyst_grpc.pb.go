@@ -0,0 +1,189 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.24.4
+// source: rpc/catalyst.proto
+
+package rpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Catalyst_SubmitVOD_FullMethodName      = "/catalyst.v1.Catalyst/SubmitVOD"
+	Catalyst_GetJobStatus_FullMethodName   = "/catalyst.v1.Catalyst/GetJobStatus"
+	Catalyst_BroadcastEvent_FullMethodName = "/catalyst.v1.Catalyst/BroadcastEvent"
+)
+
+// CatalystClient is the client API for Catalyst service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CatalystClient interface {
+	// SubmitVOD starts a VOD job, like POST /api/vod
+	SubmitVOD(ctx context.Context, in *SubmitVODRequest, opts ...grpc.CallOption) (*SubmitVODResponse, error)
+	// GetJobStatus returns the progress of a VOD job in flight on this node
+	GetJobStatus(ctx context.Context, in *GetJobStatusRequest, opts ...grpc.CallOption) (*JobStatus, error)
+	// BroadcastEvent propagates an event to all the nodes of the cluster, like POST /api/events
+	BroadcastEvent(ctx context.Context, in *BroadcastEventRequest, opts ...grpc.CallOption) (*BroadcastEventResponse, error)
+}
+
+type catalystClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCatalystClient(cc grpc.ClientConnInterface) CatalystClient {
+	return &catalystClient{cc}
+}
+
+func (c *catalystClient) SubmitVOD(ctx context.Context, in *SubmitVODRequest, opts ...grpc.CallOption) (*SubmitVODResponse, error) {
+	out := new(SubmitVODResponse)
+	err := c.cc.Invoke(ctx, Catalyst_SubmitVOD_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *catalystClient) GetJobStatus(ctx context.Context, in *GetJobStatusRequest, opts ...grpc.CallOption) (*JobStatus, error) {
+	out := new(JobStatus)
+	err := c.cc.Invoke(ctx, Catalyst_GetJobStatus_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *catalystClient) BroadcastEvent(ctx context.Context, in *BroadcastEventRequest, opts ...grpc.CallOption) (*BroadcastEventResponse, error) {
+	out := new(BroadcastEventResponse)
+	err := c.cc.Invoke(ctx, Catalyst_BroadcastEvent_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CatalystServer is the server API for Catalyst service.
+// All implementations must embed UnimplementedCatalystServer
+// for forward compatibility
+type CatalystServer interface {
+	// SubmitVOD starts a VOD job, like POST /api/vod
+	SubmitVOD(context.Context, *SubmitVODRequest) (*SubmitVODResponse, error)
+	// GetJobStatus returns the progress of a VOD job in flight on this node
+	GetJobStatus(context.Context, *GetJobStatusRequest) (*JobStatus, error)
+	// BroadcastEvent propagates an event to all the nodes of the cluster, like POST /api/events
+	BroadcastEvent(context.Context, *BroadcastEventRequest) (*BroadcastEventResponse, error)
+	mustEmbedUnimplementedCatalystServer()
+}
+
+// UnimplementedCatalystServer must be embedded to have forward compatible implementations.
+type UnimplementedCatalystServer struct {
+}
+
+func (UnimplementedCatalystServer) SubmitVOD(context.Context, *SubmitVODRequest) (*SubmitVODResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitVOD not implemented")
+}
+func (UnimplementedCatalystServer) GetJobStatus(context.Context, *GetJobStatusRequest) (*JobStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetJobStatus not implemented")
+}
+func (UnimplementedCatalystServer) BroadcastEvent(context.Context, *BroadcastEventRequest) (*BroadcastEventResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BroadcastEvent not implemented")
+}
+func (UnimplementedCatalystServer) mustEmbedUnimplementedCatalystServer() {}
+
+// UnsafeCatalystServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CatalystServer will
+// result in compilation errors.
+type UnsafeCatalystServer interface {
+	mustEmbedUnimplementedCatalystServer()
+}
+
+func RegisterCatalystServer(s grpc.ServiceRegistrar, srv CatalystServer) {
+	s.RegisterService(&Catalyst_ServiceDesc, srv)
+}
+
+func _Catalyst_SubmitVOD_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitVODRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CatalystServer).SubmitVOD(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Catalyst_SubmitVOD_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CatalystServer).SubmitVOD(ctx, req.(*SubmitVODRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Catalyst_GetJobStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetJobStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CatalystServer).GetJobStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Catalyst_GetJobStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CatalystServer).GetJobStatus(ctx, req.(*GetJobStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Catalyst_BroadcastEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BroadcastEventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CatalystServer).BroadcastEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Catalyst_BroadcastEvent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CatalystServer).BroadcastEvent(ctx, req.(*BroadcastEventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Catalyst_ServiceDesc is the grpc.ServiceDesc for Catalyst service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Catalyst_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "catalyst.v1.Catalyst",
+	HandlerType: (*CatalystServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SubmitVOD",
+			Handler:    _Catalyst_SubmitVOD_Handler,
+		},
+		{
+			MethodName: "GetJobStatus",
+			Handler:    _Catalyst_GetJobStatus_Handler,
+		},
+		{
+			MethodName: "BroadcastEvent",
+			Handler:    _Catalyst_BroadcastEvent_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "rpc/catalyst.proto",
+}
@@ -0,0 +1,171 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+)
+
+// Prefixes of the flag values that reference a secret held by a secret manager, rather than the secret itself.
+// An optional #key selects a field of a JSON secret, e.g. aws-sm:prod/catalyst#api_token.
+const (
+	PrefixAWSSecretsManager = "aws-sm:"
+	PrefixVault             = "vault:"
+)
+
+const vaultTimeout = 10 * time.Second
+
+type SecretsManagerClient interface {
+	GetSecretValueWithContext(aws.Context, *secretsmanager.GetSecretValueInput, ...request.Option) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+// IsReference returns whether a flag value references a secret manager
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, PrefixAWSSecretsManager) || strings.HasPrefix(value, PrefixVault)
+}
+
+// Resolver reads the secrets referenced by flag values. The secrets are only kept in memory.
+type Resolver struct {
+	// AWSRegion of AWS Secrets Manager, the default AWS credential chain is used
+	AWSRegion string
+	// VaultAddr and VaultToken are used to read secrets from Vault's KV secrets engine, version 1 or 2
+	VaultAddr  string
+	VaultToken string
+
+	awsOnce   sync.Once
+	awsClient SecretsManagerClient
+	awsErr    error
+
+	httpClient *http.Client
+}
+
+// Resolve returns the secret referenced
+func (r *Resolver) Resolve(ctx context.Context, ref string) (string, error) {
+	var secret string
+	var err error
+	switch {
+	case strings.HasPrefix(ref, PrefixAWSSecretsManager):
+		id, key := splitKey(strings.TrimPrefix(ref, PrefixAWSSecretsManager))
+		secret, err = r.awsSecret(ctx, id)
+		if err == nil {
+			secret, err = selectKey(secret, key)
+		}
+	case strings.HasPrefix(ref, PrefixVault):
+		path, key := splitKey(strings.TrimPrefix(ref, PrefixVault))
+		secret, err = r.vaultSecret(ctx, path, key)
+	default:
+		return "", fmt.Errorf("unknown secret reference, should start with %s or %s", PrefixAWSSecretsManager, PrefixVault)
+	}
+	if err != nil {
+		// the reference itself isn't secret, only where the secret is held
+		return "", fmt.Errorf("error reading secret %s: %w", ref, err)
+	}
+	return secret, nil
+}
+
+func splitKey(ref string) (string, string) {
+	id, key, _ := strings.Cut(ref, "#")
+	return id, key
+}
+
+// selectKey returns a field of a JSON secret, or the whole secret if no key is given
+func selectKey(secret, key string) (string, error) {
+	if key == "" {
+		return secret, nil
+	}
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(secret), &fields); err != nil {
+		return "", fmt.Errorf("secret isn't a JSON object, so it has no key %q", key)
+	}
+	return fieldString(fields, key)
+}
+
+func fieldString(fields map[string]any, key string) (string, error) {
+	value, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("secret has no key %q", key)
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("key %q of the secret isn't a string", key)
+	}
+	return s, nil
+}
+
+func (r *Resolver) awsSecret(ctx context.Context, id string) (string, error) {
+	r.awsOnce.Do(func() {
+		if r.awsClient != nil {
+			return
+		}
+		sess, err := session.NewSession(aws.NewConfig().WithRegion(r.AWSRegion))
+		if err != nil {
+			r.awsErr = fmt.Errorf("error creating AWS session: %w", err)
+			return
+		}
+		r.awsClient = secretsmanager.New(sess)
+	})
+	if r.awsErr != nil {
+		return "", r.awsErr
+	}
+	out, err := r.awsClient.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(id)})
+	if err != nil {
+		return "", err
+	}
+	if out.SecretString != nil {
+		return *out.SecretString, nil
+	}
+	return string(out.SecretBinary), nil
+}
+
+func (r *Resolver) vaultSecret(ctx context.Context, path, key string) (string, error) {
+	if r.VaultAddr == "" || r.VaultToken == "" {
+		return "", fmt.Errorf("-vault-addr and -vault-token must be set to read secrets from vault")
+	}
+	if key == "" {
+		return "", fmt.Errorf("vault secret references need a #key")
+	}
+	if r.httpClient == nil {
+		r.httpClient = &http.Client{Timeout: vaultTimeout}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(r.VaultAddr, "/")+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", r.VaultToken)
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault returned status %d", resp.StatusCode)
+	}
+
+	var secret struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return "", fmt.Errorf("invalid vault response: %w", err)
+	}
+	// version 2 of the KV engine nests the fields under data.data, next to data.metadata
+	if nested, ok := secret.Data["data"].(map[string]any); ok {
+		if _, ok := secret.Data["metadata"]; ok {
+			return fieldString(nested, key)
+		}
+	}
+	return fieldString(secret.Data, key)
+}
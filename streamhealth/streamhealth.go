@@ -0,0 +1,305 @@
+package streamhealth
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/livepeer/catalyst-api/clients"
+	"github.com/livepeer/catalyst-api/handlers/misttriggers"
+	"github.com/livepeer/catalyst-api/multistream"
+)
+
+// Overall statuses of a stream
+const (
+	StatusHealthy   = "healthy"
+	StatusDegraded  = "degraded"
+	StatusUnhealthy = "unhealthy"
+	StatusOffline   = "offline"
+)
+
+const (
+	// maxSamples is how many of the latest ingest bitrate and frame rate samples the stability is computed over
+	maxSamples = 30
+	// minStability is the stability of the ingest bitrate and frame rate below which a stream is degraded
+	minStability = 0.8
+	// maxTranscodeLag is how far the transcoded renditions can be behind the source before a stream is degraded
+	maxTranscodeLag = 5 * time.Second
+)
+
+var ErrNotFound = errors.New("stream not found")
+
+// Health is the health document of a live stream, combining its Mist buffer state, the stability of its ingest, how
+// far behind its transcoded renditions are and the status of its restream targets
+type Health struct {
+	PlaybackID string                     `json:"playback_id"`
+	Status     string                     `json:"status"`
+	Reasons    []string                   `json:"reasons,omitempty"`
+	Buffer     *BufferHealth              `json:"buffer,omitempty"`
+	Ingest     *IngestHealth              `json:"ingest,omitempty"`
+	Transcode  *TranscodeHealth           `json:"transcode,omitempty"`
+	Targets    []multistream.TargetStatus `json:"multistream_targets,omitempty"`
+}
+
+// BufferHealth is the latest STREAM_BUFFER state of the stream, one of FULL, EMPTY, DRY or RECOVER
+type BufferHealth struct {
+	State       string    `json:"state"`
+	Since       time.Time `json:"since"`
+	Issues      string    `json:"issues,omitempty"`
+	HumanIssues []string  `json:"human_issues,omitempty"`
+}
+
+// IngestHealth is the current bitrate and frame rate of the source video track, and how stable they've been from 0
+// (erratic) to 1 (constant)
+type IngestHealth struct {
+	BitrateKbps      int     `json:"bitrate_kbps"`
+	FPS              float64 `json:"fps"`
+	BitrateStability float64 `json:"bitrate_stability"`
+	FPSStability     float64 `json:"fps_stability"`
+}
+
+// TranscodeHealth is how far the most delayed transcoded rendition is behind the source track
+type TranscodeHealth struct {
+	Renditions int   `json:"renditions"`
+	LagMs      int64 `json:"lag_ms"`
+}
+
+type sample struct {
+	kbits int
+	fps   float64
+}
+
+type stream struct {
+	buffer  BufferHealth
+	samples []sample
+}
+
+// Tracker keeps the STREAM_BUFFER state of the streams on this node and builds their health documents
+type Tracker struct {
+	mist           clients.MistAPIClient
+	baseStreamName string
+	// multistream is nil when restreaming isn't managed by this node
+	multistream *multistream.Manager
+
+	mu      sync.Mutex
+	streams map[string]*stream // playback ID to stream
+}
+
+func NewTracker(mist clients.MistAPIClient, baseStreamName string, multistreamManager *multistream.Manager) *Tracker {
+	return &Tracker{
+		mist:           mist,
+		baseStreamName: baseStreamName,
+		multistream:    multistreamManager,
+		streams:        map[string]*stream{},
+	}
+}
+
+// HandleStreamBuffer records the buffer state of a stream and samples the bitrate and frame rate of its source track
+func (t *Tracker) HandleStreamBuffer(ctx context.Context, payload *misttriggers.StreamBufferPayload) error {
+	playbackID, ok := t.playbackID(payload.StreamName)
+	if !ok {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.streams[playbackID]
+	if !ok {
+		s = &stream{}
+		t.streams[playbackID] = s
+	}
+	if s.buffer.State != payload.State {
+		s.buffer.Since = time.Now()
+	}
+	s.buffer.State = payload.State
+	s.buffer.Issues, s.buffer.HumanIssues = "", nil
+	if payload.IsEmpty() {
+		// the next session starts with a fresh ingest
+		s.samples = nil
+		return nil
+	}
+	if d := payload.Details; d != nil {
+		s.buffer.Issues, s.buffer.HumanIssues = d.Issues, d.HumanIssues
+		if smp, ok := sourceSample(d.Tracks); ok {
+			s.addSample(smp)
+		}
+	}
+	return nil
+}
+
+// Health returns the health document of a stream, ErrNotFound if it isn't known on this node. The ingest is sampled on
+// each call as well as on each STREAM_BUFFER trigger, so dashboards polling the health keep its stability up to date.
+func (t *Tracker) Health(playbackID string) (Health, error) {
+	h := Health{PlaybackID: playbackID}
+
+	info, infoErr := t.mist.GetStreamInfo(t.baseStreamName + "+" + playbackID)
+	t.mu.Lock()
+	s, known := t.streams[playbackID]
+	if known {
+		buffer := s.buffer
+		h.Buffer = &buffer
+		if infoErr == nil {
+			if smp, ok := infoSample(info.Meta.Tracks); ok {
+				s.addSample(smp)
+			}
+		}
+		if len(s.samples) > 0 {
+			latest := s.samples[len(s.samples)-1]
+			h.Ingest = &IngestHealth{
+				BitrateKbps:      latest.kbits,
+				FPS:              latest.fps,
+				BitrateStability: stability(s.samples, func(s sample) float64 { return float64(s.kbits) }),
+				FPSStability:     stability(s.samples, func(s sample) float64 { return s.fps }),
+			}
+		}
+	}
+	t.mu.Unlock()
+
+	if infoErr == nil {
+		h.Transcode = transcodeHealth(info.Meta.Tracks)
+	}
+	if t.multistream != nil {
+		if targets, err := t.multistream.Status(playbackID); err == nil {
+			h.Targets = targets
+		}
+	}
+	if !known && infoErr != nil && len(h.Targets) == 0 {
+		return Health{}, ErrNotFound
+	}
+	h.Status, h.Reasons = status(h, infoErr == nil)
+	return h, nil
+}
+
+// Forget drops the state kept for a stream, e.g. once it's deleted from Mist
+func (t *Tracker) Forget(streamName string) {
+	playbackID, ok := t.playbackID(streamName)
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.streams, playbackID)
+}
+
+func status(h Health, online bool) (string, []string) {
+	if !online || h.Buffer == nil || h.Buffer.State == "EMPTY" {
+		return StatusOffline, nil
+	}
+	var reasons []string
+	if h.Buffer.State == "DRY" {
+		reasons = append(reasons, "the buffer is running dry")
+	}
+	if h.Buffer.Issues != "" {
+		reasons = append(reasons, h.Buffer.Issues)
+	}
+	if len(reasons) > 0 {
+		return StatusUnhealthy, reasons
+	}
+	if i := h.Ingest; i != nil {
+		if i.BitrateStability < minStability {
+			reasons = append(reasons, "the ingest bitrate is unstable")
+		}
+		if i.FPSStability < minStability {
+			reasons = append(reasons, "the ingest frame rate is unstable")
+		}
+	}
+	if tr := h.Transcode; tr != nil && time.Duration(tr.LagMs)*time.Millisecond > maxTranscodeLag {
+		reasons = append(reasons, "the transcoded renditions are lagging behind the source")
+	}
+	for _, target := range h.Targets {
+		if target.State == multistream.StateFailed {
+			reasons = append(reasons, "restreaming to "+target.ID+" failed")
+		}
+	}
+	if len(reasons) > 0 {
+		return StatusDegraded, reasons
+	}
+	return StatusHealthy, nil
+}
+
+func (s *stream) addSample(smp sample) {
+	s.samples = append(s.samples, smp)
+	if len(s.samples) > maxSamples {
+		s.samples = s.samples[len(s.samples)-maxSamples:]
+	}
+}
+
+// stability is 1 minus the coefficient of variation of the samples, clamped to [0, 1]
+func stability(samples []sample, value func(sample) float64) float64 {
+	var sum float64
+	for _, s := range samples {
+		sum += value(s)
+	}
+	mean := sum / float64(len(samples))
+	if mean == 0 {
+		return 0
+	}
+	var variance float64
+	for _, s := range samples {
+		variance += math.Pow(value(s)-mean, 2)
+	}
+	cv := math.Sqrt(variance/float64(len(samples))) / mean
+	return math.Max(0, 1-cv)
+}
+
+// sourceSample samples the highest bitrate video track of the STREAM_BUFFER details, the source until it's transcoded
+func sourceSample(tracks map[string]misttriggers.TrackDetails) (sample, bool) {
+	var smp sample
+	found := false
+	for _, t := range tracks {
+		// only video tracks have a resolution
+		if t.Width == 0 {
+			continue
+		}
+		if !found || t.Kbits > smp.kbits {
+			smp = sample{kbits: t.Kbits, fps: float64(t.Fpks) / 1000}
+			found = true
+		}
+	}
+	return smp, found
+}
+
+// infoSample samples the source video track of the stream info
+func infoSample(tracks map[string]clients.MistStreamInfoTrack) (sample, bool) {
+	video := videoTracks(tracks)
+	if len(video) == 0 {
+		return sample{}, false
+	}
+	// Mist reports the bitrate in bytes per second
+	return sample{kbits: video[0].Bps * 8 / 1000, fps: float64(video[0].Fpks) / 1000}, true
+}
+
+// transcodeHealth compares the video tracks added by the transcoder to the source track, which Mist indexes first
+func transcodeHealth(tracks map[string]clients.MistStreamInfoTrack) *TranscodeHealth {
+	video := videoTracks(tracks)
+	if len(video) < 2 {
+		return nil
+	}
+	h := &TranscodeHealth{Renditions: len(video) - 1}
+	for _, rendition := range video[1:] {
+		if lag := video[0].Lastms - rendition.Lastms; lag > h.LagMs {
+			h.LagMs = lag
+		}
+	}
+	return h
+}
+
+// videoTracks returns the video tracks in the order Mist added them
+func videoTracks(tracks map[string]clients.MistStreamInfoTrack) []clients.MistStreamInfoTrack {
+	var video []clients.MistStreamInfoTrack
+	for _, t := range tracks {
+		if t.Type == "video" {
+			video = append(video, t)
+		}
+	}
+	sort.Slice(video, func(i, j int) bool { return video[i].Idx < video[j].Idx })
+	return video
+}
+
+func (t *Tracker) playbackID(streamName string) (string, bool) {
+	playbackID, ok := strings.CutPrefix(streamName, t.baseStreamName+"+")
+	return playbackID, ok && playbackID != ""
+}
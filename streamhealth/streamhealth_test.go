@@ -0,0 +1,81 @@
+package streamhealth
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/livepeer/catalyst-api/clients"
+	"github.com/livepeer/catalyst-api/handlers/misttriggers"
+	mockmistclient "github.com/livepeer/catalyst-api/mocks/clients"
+	"github.com/stretchr/testify/require"
+)
+
+func bufferPayload(state string, kbits, fpks int, issues string) *misttriggers.StreamBufferPayload {
+	return &misttriggers.StreamBufferPayload{
+		StreamName: "video+abc123",
+		State:      state,
+		Details: &misttriggers.MistStreamDetails{
+			Tracks: map[string]misttriggers.TrackDetails{
+				"video_H264_1280x720_30fps_0": {Codec: "H264", Kbits: kbits, Fpks: fpks, Width: 1280, Height: 720},
+				"audio_AAC_2ch_48000hz_1":     {Codec: "AAC", Kbits: 128},
+			},
+			Issues: issues,
+		},
+	}
+}
+
+func TestHealth(t *testing.T) {
+	ctx := context.Background()
+	mist := mockmistclient.NewMockMistAPIClient(gomock.NewController(t))
+	tracker := NewTracker(mist, "video", nil)
+
+	mist.EXPECT().GetStreamInfo("video+unknown").Return(clients.MistStreamInfo{}, fmt.Errorf("Stream is offline"))
+	_, err := tracker.Health("unknown")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	// streams of other base names are ignored
+	require.NoError(t, tracker.HandleStreamBuffer(ctx, &misttriggers.StreamBufferPayload{StreamName: "other+abc123", State: "FULL"}))
+	require.Empty(t, tracker.streams)
+
+	require.NoError(t, tracker.HandleStreamBuffer(ctx, bufferPayload("FULL", 3000, 30000, "")))
+	info := clients.MistStreamInfo{Meta: clients.MistStreamInfoMetadata{Tracks: map[string]clients.MistStreamInfoTrack{
+		"video_H264_1280x720_30fps_0": {Type: "video", Idx: 0, Bps: 375000, Fpks: 30000, Lastms: 60000},
+		"audio_AAC_2ch_48000hz_1":     {Type: "audio", Idx: 1, Bps: 16000, Lastms: 60000},
+		"video_H264_640x360_30fps_2":  {Type: "video", Idx: 2, Bps: 100000, Fpks: 30000, Lastms: 59000},
+	}}}
+	mist.EXPECT().GetStreamInfo("video+abc123").Return(info, nil)
+	h, err := tracker.Health("abc123")
+	require.NoError(t, err)
+	require.Equal(t, StatusHealthy, h.Status)
+	require.Equal(t, "FULL", h.Buffer.State)
+	require.Equal(t, &IngestHealth{BitrateKbps: 3000, FPS: 30, BitrateStability: 1, FPSStability: 1}, h.Ingest)
+	require.Equal(t, &TranscodeHealth{Renditions: 1, LagMs: 1000}, h.Transcode)
+
+	// an erratic frame rate degrades the stream
+	require.NoError(t, tracker.HandleStreamBuffer(ctx, bufferPayload("FULL", 3000, 5000, "")))
+	info.Meta.Tracks["video_H264_640x360_30fps_2"] = clients.MistStreamInfoTrack{Type: "video", Idx: 2, Lastms: 50000}
+	mist.EXPECT().GetStreamInfo("video+abc123").Return(info, nil)
+	h, err = tracker.Health("abc123")
+	require.NoError(t, err)
+	require.Equal(t, StatusDegraded, h.Status)
+	require.Equal(t, []string{
+		"the ingest frame rate is unstable",
+		"the transcoded renditions are lagging behind the source",
+	}, h.Reasons)
+
+	require.NoError(t, tracker.HandleStreamBuffer(ctx, bufferPayload("DRY", 3000, 30000, "High jitter")))
+	mist.EXPECT().GetStreamInfo("video+abc123").Return(info, nil)
+	h, err = tracker.Health("abc123")
+	require.NoError(t, err)
+	require.Equal(t, StatusUnhealthy, h.Status)
+	require.Equal(t, []string{"the buffer is running dry", "High jitter"}, h.Reasons)
+
+	require.NoError(t, tracker.HandleStreamBuffer(ctx, &misttriggers.StreamBufferPayload{StreamName: "video+abc123", State: "EMPTY"}))
+	mist.EXPECT().GetStreamInfo("video+abc123").Return(clients.MistStreamInfo{}, fmt.Errorf("Stream is offline"))
+	h, err = tracker.Health("abc123")
+	require.NoError(t, err)
+	require.Equal(t, StatusOffline, h.Status)
+	require.Nil(t, h.Ingest)
+}
@@ -0,0 +1,253 @@
+package streamkeys
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/livepeer/catalyst-api/handlers/misttriggers"
+	"github.com/livepeer/catalyst-api/log"
+)
+
+const tableName = "stream_keys"
+
+var (
+	ErrNotFound = errors.New("stream key not found")
+	ErrExists   = errors.New("stream already has a key")
+)
+
+// Mist stream names, including the wildcard streams of a base stream, e.g. video+<playbackID>
+var streamNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_.+-]+$`)
+
+// Key is the stream key issued for a stream. Only the stores' callers see the key itself, a SHA-256 of it is stored.
+type Key struct {
+	StreamName string    `json:"stream_name"`
+	Key        string    `json:"key"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Store holds the stream keys that pushes are authorized with, one per stream
+type Store interface {
+	// Create issues a key for a stream, returning ErrExists if the stream already has one
+	Create(ctx context.Context, streamName string) (Key, error)
+	// Rotate replaces the key of a stream, the previous key stops working straight away
+	Rotate(ctx context.Context, streamName string) (Key, error)
+	// Revoke deletes the key of a stream
+	Revoke(ctx context.Context, streamName string) error
+	// Lookup returns the name of the stream a key was issued for
+	Lookup(ctx context.Context, key string) (string, error)
+}
+
+// NewStore returns a store persisted to Postgres if a DB is configured, otherwise falls back to an in-memory store
+// that only covers the lifetime of this process and this node
+func NewStore(db *sql.DB) Store {
+	if db != nil {
+		return &dbStore{db: db}
+	}
+	return NewMemoryStore()
+}
+
+// CreateTable creates the table of the stream keys in the metrics DB if it doesn't exist
+func CreateTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `create table if not exists "`+tableName+`" (
+		"stream_name"   text primary key,
+		"key_sha256"    text unique not null,
+		"created_at_ms" bigint not null
+	)`)
+	if err != nil {
+		return fmt.Errorf("error creating stream keys table: %w", err)
+	}
+	return nil
+}
+
+// ValidateStreamName checks that keys are issued for names Mist accepts
+func ValidateStreamName(streamName string) error {
+	if !streamNameRegex.MatchString(streamName) {
+		return fmt.Errorf("invalid stream name %q: only letters, digits, '_', '.', '+' and '-' are allowed", streamName)
+	}
+	return nil
+}
+
+// HandlePushRewrite answers PUSH_REWRITE triggers with the stream the pushed key was issued for, rejecting pushes
+// with unknown keys
+func HandlePushRewrite(store Store) func(context.Context, *misttriggers.PushRewritePayload) (string, error) {
+	return func(ctx context.Context, payload *misttriggers.PushRewritePayload) (string, error) {
+		streamName, err := store.Lookup(ctx, payload.StreamName)
+		if errors.Is(err, ErrNotFound) {
+			log.LogCtx(ctx, "rejected push with an unknown stream key", "client", payload.Hostname)
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		return streamName, nil
+	}
+}
+
+func newKey(streamName string) (Key, error) {
+	const charset = "abcdefghijklmnopqrstuvwxyz0123456789"
+	var groups []string
+	for i := 0; i < 4; i++ {
+		group := make([]byte, 4)
+		for j := range group {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
+			if err != nil {
+				return Key{}, fmt.Errorf("error generating stream key: %w", err)
+			}
+			group[j] = charset[n.Int64()]
+		}
+		groups = append(groups, string(group))
+	}
+	return Key{StreamName: streamName, Key: strings.Join(groups, "-"), CreatedAt: time.Now().UTC().Truncate(time.Millisecond)}, nil
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+type dbStore struct {
+	db *sql.DB
+}
+
+func (s *dbStore) Create(ctx context.Context, streamName string) (Key, error) {
+	key, err := newKey(streamName)
+	if err != nil {
+		return Key{}, err
+	}
+	res, err := s.db.ExecContext(ctx,
+		`insert into "`+tableName+`"("stream_name", "key_sha256", "created_at_ms") values($1, $2, $3) on conflict ("stream_name") do nothing`,
+		streamName, hashKey(key.Key), key.CreatedAt.UnixMilli(),
+	)
+	if err != nil {
+		return Key{}, fmt.Errorf("error creating stream key: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return Key{}, fmt.Errorf("error creating stream key: %w", err)
+	} else if n == 0 {
+		return Key{}, ErrExists
+	}
+	return key, nil
+}
+
+func (s *dbStore) Rotate(ctx context.Context, streamName string) (Key, error) {
+	key, err := newKey(streamName)
+	if err != nil {
+		return Key{}, err
+	}
+	res, err := s.db.ExecContext(ctx,
+		`update "`+tableName+`" set "key_sha256" = $2, "created_at_ms" = $3 where "stream_name" = $1`,
+		streamName, hashKey(key.Key), key.CreatedAt.UnixMilli(),
+	)
+	if err != nil {
+		return Key{}, fmt.Errorf("error rotating stream key: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return Key{}, fmt.Errorf("error rotating stream key: %w", err)
+	} else if n == 0 {
+		return Key{}, ErrNotFound
+	}
+	return key, nil
+}
+
+func (s *dbStore) Revoke(ctx context.Context, streamName string) error {
+	res, err := s.db.ExecContext(ctx, `delete from "`+tableName+`" where "stream_name" = $1`, streamName)
+	if err != nil {
+		return fmt.Errorf("error revoking stream key: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("error revoking stream key: %w", err)
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *dbStore) Lookup(ctx context.Context, key string) (string, error) {
+	var streamName string
+	err := s.db.QueryRowContext(ctx, `select "stream_name" from "`+tableName+`" where "key_sha256" = $1`, hashKey(key)).Scan(&streamName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("error looking up stream key: %w", err)
+	}
+	return streamName, nil
+}
+
+// MemoryStore keeps the stream keys in memory, so they're lost on restart and only accepted by this node
+type MemoryStore struct {
+	mu      sync.Mutex
+	streams map[string]string // stream name to key hash
+	keys    map[string]string // key hash to stream name
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{streams: map[string]string{}, keys: map[string]string{}}
+}
+
+func (m *MemoryStore) Create(_ context.Context, streamName string) (Key, error) {
+	key, err := newKey(streamName)
+	if err != nil {
+		return Key{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.streams[streamName]; ok {
+		return Key{}, ErrExists
+	}
+	m.set(streamName, hashKey(key.Key))
+	return key, nil
+}
+
+func (m *MemoryStore) Rotate(_ context.Context, streamName string) (Key, error) {
+	key, err := newKey(streamName)
+	if err != nil {
+		return Key{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	previous, ok := m.streams[streamName]
+	if !ok {
+		return Key{}, ErrNotFound
+	}
+	delete(m.keys, previous)
+	m.set(streamName, hashKey(key.Key))
+	return key, nil
+}
+
+func (m *MemoryStore) Revoke(_ context.Context, streamName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	hash, ok := m.streams[streamName]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(m.streams, streamName)
+	delete(m.keys, hash)
+	return nil
+}
+
+func (m *MemoryStore) Lookup(_ context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	streamName, ok := m.keys[hashKey(key)]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return streamName, nil
+}
+
+func (m *MemoryStore) set(streamName, hash string) {
+	m.streams[streamName] = hash
+	m.keys[hash] = streamName
+}
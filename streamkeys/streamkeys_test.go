@@ -0,0 +1,123 @@
+package streamkeys
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/livepeer/catalyst-api/handlers/misttriggers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	key, err := store.Create(ctx, "video+abc123")
+	require.NoError(t, err)
+	require.Regexp(t, `^[a-z0-9]{4}-[a-z0-9]{4}-[a-z0-9]{4}-[a-z0-9]{4}$`, key.Key)
+	_, err = store.Create(ctx, "video+abc123")
+	require.ErrorIs(t, err, ErrExists)
+
+	streamName, err := store.Lookup(ctx, key.Key)
+	require.NoError(t, err)
+	require.Equal(t, "video+abc123", streamName)
+
+	rotated, err := store.Rotate(ctx, "video+abc123")
+	require.NoError(t, err)
+	require.NotEqual(t, key.Key, rotated.Key)
+	_, err = store.Lookup(ctx, key.Key)
+	require.ErrorIs(t, err, ErrNotFound)
+	streamName, err = store.Lookup(ctx, rotated.Key)
+	require.NoError(t, err)
+	require.Equal(t, "video+abc123", streamName)
+
+	require.NoError(t, store.Revoke(ctx, "video+abc123"))
+	_, err = store.Lookup(ctx, rotated.Key)
+	require.ErrorIs(t, err, ErrNotFound)
+	require.ErrorIs(t, store.Revoke(ctx, "video+abc123"), ErrNotFound)
+	_, err = store.Rotate(ctx, "video+abc123")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestDBStore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+	store := NewStore(db)
+
+	mock.ExpectExec(`insert into "stream_keys"`).
+		WithArgs("video+abc123", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	key, err := store.Create(ctx, "video+abc123")
+	require.NoError(t, err)
+
+	mock.ExpectExec(`insert into "stream_keys"`).
+		WithArgs("video+abc123", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	_, err = store.Create(ctx, "video+abc123")
+	require.ErrorIs(t, err, ErrExists)
+
+	mock.ExpectQuery(`select "stream_name" from "stream_keys" where "key_sha256" = \$1`).
+		WithArgs(hashKey(key.Key)).
+		WillReturnRows(sqlmock.NewRows([]string{"stream_name"}).AddRow("video+abc123"))
+	streamName, err := store.Lookup(ctx, key.Key)
+	require.NoError(t, err)
+	require.Equal(t, "video+abc123", streamName)
+
+	mock.ExpectQuery(`select "stream_name" from "stream_keys"`).
+		WithArgs(hashKey("unknown")).
+		WillReturnError(sql.ErrNoRows)
+	_, err = store.Lookup(ctx, "unknown")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	mock.ExpectExec(`update "stream_keys" set "key_sha256" = \$2, "created_at_ms" = \$3 where "stream_name" = \$1`).
+		WithArgs("unknown", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	_, err = store.Rotate(ctx, "unknown")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	mock.ExpectExec(`delete from "stream_keys" where "stream_name" = \$1`).
+		WithArgs("video+abc123").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, store.Revoke(ctx, "video+abc123"))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandlePushRewrite(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	key, err := store.Create(ctx, "video+abc123")
+	require.NoError(t, err)
+	handler := HandlePushRewrite(store)
+
+	pushURL, _ := url.Parse("rtmp://127.0.0.1:1935/live/" + key.Key)
+	streamName, err := handler(ctx, &misttriggers.PushRewritePayload{URL: pushURL, StreamName: key.Key})
+	require.NoError(t, err)
+	require.Equal(t, "video+abc123", streamName)
+
+	streamName, err = handler(ctx, &misttriggers.PushRewritePayload{URL: pushURL, StreamName: "c447-3l8v-1vmz-ej5t"})
+	require.NoError(t, err)
+	require.Empty(t, streamName, "unknown keys are rejected")
+}
+
+func TestValidateStreamName(t *testing.T) {
+	require.NoError(t, ValidateStreamName("video+abc123"))
+	require.NoError(t, ValidateStreamName("my_stream-1.backup"))
+	require.Error(t, ValidateStreamName(""))
+	require.Error(t, ValidateStreamName("../etc"))
+	require.Error(t, ValidateStreamName("a b"))
+}
+
+func TestCreateTable(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec(`create table if not exists "stream_keys"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	require.NoError(t, CreateTable(context.Background(), db))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,173 @@
+package streammeta
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/livepeer/catalyst-api/clients"
+	"github.com/livepeer/catalyst-api/dvr"
+	"github.com/livepeer/catalyst-api/events"
+	"github.com/livepeer/catalyst-api/log"
+)
+
+// Bounds of the segment size, Mist's own default being close to 2s
+const (
+	MinSegmentSize = 500 * time.Millisecond
+	MaxSegmentSize = 20 * time.Second
+)
+
+const maxTags = 16
+
+var (
+	tagRegex           = regexp.MustCompile(`^[a-zA-Z0-9_.:-]{1,64}$`)
+	trackSelectorRegex = regexp.MustCompile(`^[a-zA-Z0-9_,.<>=~!*|-]{0,64}$`)
+)
+
+// Manager applies the metadata of each stream to its Mist stream config, translating it into Mist's stream
+// settings. As with the DVR windows, the metadata is kept in memory and set on every node with the metadata event,
+// since the stream can be ingested on any of them.
+type Manager struct {
+	mist           clients.MistAPIClient
+	baseStreamName string
+	streamSource   string
+
+	// DVR keeps the DVR window of the streams when their metadata is applied, nil without it
+	DVR *dvr.Manager
+
+	mu       sync.Mutex
+	metadata map[string]events.StreamMetadata // playback ID to metadata
+}
+
+func NewManager(mist clients.MistAPIClient, baseStreamName, streamSource string) *Manager {
+	return &Manager{
+		mist:           mist,
+		baseStreamName: baseStreamName,
+		streamSource:   streamSource,
+		metadata:       map[string]events.StreamMetadata{},
+	}
+}
+
+// ValidateMetadata checks the metadata before it's broadcast to the cluster
+func ValidateMetadata(md events.StreamMetadata) error {
+	if md.SegmentSizeMs != 0 {
+		size := time.Duration(md.SegmentSizeMs) * time.Millisecond
+		if size < MinSegmentSize || size > MaxSegmentSize {
+			return fmt.Errorf("segment_size_ms must be between %d and %d", MinSegmentSize.Milliseconds(), MaxSegmentSize.Milliseconds())
+		}
+	}
+	if !trackSelectorRegex.MatchString(md.VideoTracks) {
+		return fmt.Errorf("invalid video_tracks selector %q", md.VideoTracks)
+	}
+	if !trackSelectorRegex.MatchString(md.AudioTracks) {
+		return fmt.Errorf("invalid audio_tracks selector %q", md.AudioTracks)
+	}
+	if len(md.Tags) > maxTags {
+		return fmt.Errorf("a stream can't have more than %d tags", maxTags)
+	}
+	for _, tag := range md.Tags {
+		if !tagRegex.MatchString(tag) {
+			return fmt.Errorf("invalid tag %q", tag)
+		}
+	}
+	return nil
+}
+
+// Apply sets the metadata of a stream, empty metadata restoring Mist's defaults
+func (m *Manager) Apply(playbackID string, md events.StreamMetadata) error {
+	if err := ValidateMetadata(md); err != nil {
+		return err
+	}
+	streamName := m.streamName(playbackID)
+	var window time.Duration
+	if m.DVR != nil {
+		// read before locking, as the DVR manager adds its streams through MistClient
+		window = m.DVR.Window(playbackID)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	previous, ok := m.metadata[playbackID]
+	if ok && reflect.DeepEqual(previous, md) {
+		return nil
+	}
+	if isEmpty(md) && !ok {
+		return nil
+	}
+	if err := m.mist.AddStreamWithSettings(streamName, settings(m.streamSource, window, md)); err != nil {
+		return fmt.Errorf("error setting stream metadata: %w", err)
+	}
+	if isEmpty(md) {
+		delete(m.metadata, playbackID)
+	} else {
+		m.metadata[playbackID] = md
+	}
+	log.LogNoRequestID("stream metadata set", log.KeyStream, streamName, "metadata", fmt.Sprintf("%+v", md))
+	return nil
+}
+
+// Metadata returns the metadata of a stream, empty if Mist's defaults are used
+func (m *Manager) Metadata(playbackID string) events.StreamMetadata {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.metadata[playbackID]
+}
+
+// MistClient wraps a Mist client so that the streams it adds, e.g. for WHIP and SRT ingest or by the DVR manager,
+// keep their metadata
+func (m *Manager) MistClient(mist clients.MistAPIClient) clients.MistAPIClient {
+	return &mistClient{MistAPIClient: mist, m: m}
+}
+
+type mistClient struct {
+	clients.MistAPIClient
+	m *Manager
+}
+
+func (c *mistClient) AddStream(streamName, sourceUrl string) error {
+	return c.AddStreamWithDVR(streamName, sourceUrl, 0)
+}
+
+func (c *mistClient) AddStreamWithDVR(streamName, sourceUrl string, dvrWindow time.Duration) error {
+	md, ok := c.metadata(streamName)
+	if !ok {
+		if dvrWindow == 0 {
+			return c.MistAPIClient.AddStream(streamName, sourceUrl)
+		}
+		return c.MistAPIClient.AddStreamWithDVR(streamName, sourceUrl, dvrWindow)
+	}
+	return c.MistAPIClient.AddStreamWithSettings(streamName, settings(sourceUrl, dvrWindow, md))
+}
+
+func (c *mistClient) metadata(streamName string) (events.StreamMetadata, bool) {
+	playbackID, ok := strings.CutPrefix(streamName, c.m.baseStreamName+"+")
+	if !ok {
+		return events.StreamMetadata{}, false
+	}
+	c.m.mu.Lock()
+	defer c.m.mu.Unlock()
+	md, ok := c.m.metadata[playbackID]
+	return md, ok
+}
+
+func settings(source string, dvrWindow time.Duration, md events.StreamMetadata) clients.Stream {
+	return clients.Stream{
+		Source:      source,
+		DVR:         dvrWindow.Milliseconds(),
+		SegmentSize: md.SegmentSizeMs,
+		Video:       md.VideoTracks,
+		Audio:       md.AudioTracks,
+		Tags:        md.Tags,
+	}
+}
+
+func isEmpty(md events.StreamMetadata) bool {
+	return md.SegmentSizeMs == 0 && md.VideoTracks == "" && md.AudioTracks == "" && len(md.Tags) == 0
+}
+
+func (m *Manager) streamName(playbackID string) string {
+	return m.baseStreamName + "+" + playbackID
+}
@@ -0,0 +1,55 @@
+package streammeta
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/livepeer/catalyst-api/clients"
+	"github.com/livepeer/catalyst-api/dvr"
+	"github.com/livepeer/catalyst-api/events"
+	mockmistclient "github.com/livepeer/catalyst-api/mocks/clients"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateMetadata(t *testing.T) {
+	require.NoError(t, ValidateMetadata(events.StreamMetadata{}))
+	require.NoError(t, ValidateMetadata(events.StreamMetadata{SegmentSizeMs: 2000, VideoTracks: "maxbps", AudioTracks: "eng,maxbps", Tags: []string{"sports", "tier:pro"}}))
+	require.Error(t, ValidateMetadata(events.StreamMetadata{SegmentSizeMs: 100}))
+	require.Error(t, ValidateMetadata(events.StreamMetadata{SegmentSizeMs: 60000}))
+	require.Error(t, ValidateMetadata(events.StreamMetadata{VideoTracks: "max bps"}))
+	require.Error(t, ValidateMetadata(events.StreamMetadata{Tags: []string{""}}))
+	require.Error(t, ValidateMetadata(events.StreamMetadata{Tags: make([]string, maxTags+1)}))
+}
+
+func TestManager(t *testing.T) {
+	mist := mockmistclient.NewMockMistAPIClient(gomock.NewController(t))
+	m := NewManager(mist, "video", "push://")
+
+	// resetting a stream without metadata is a no-op
+	require.NoError(t, m.Apply("abc123", events.StreamMetadata{}))
+
+	md := events.StreamMetadata{SegmentSizeMs: 2000, VideoTracks: "maxbps", Tags: []string{"sports"}}
+	mist.EXPECT().AddStreamWithSettings("video+abc123", clients.Stream{Source: "push://", SegmentSize: 2000, Video: "maxbps", Tags: []string{"sports"}}).Return(nil)
+	require.NoError(t, m.Apply("abc123", md))
+	require.NoError(t, m.Apply("abc123", md), "the same metadata isn't applied twice")
+	require.Equal(t, md, m.Metadata("abc123"))
+
+	// the streams added for ingest and by the DVR manager keep their metadata
+	client := m.MistClient(mist)
+	mist.EXPECT().AddStreamWithSettings("video+abc123", clients.Stream{Source: "push://?passphrase=x", SegmentSize: 2000, Video: "maxbps", Tags: []string{"sports"}}).Return(nil)
+	require.NoError(t, client.AddStream("video+abc123", "push://?passphrase=x"))
+	dvrManager := dvr.NewManager(client, "video", "push://", "playback.example.com")
+	m.DVR = dvrManager
+	mist.EXPECT().AddStreamWithSettings("video+abc123", clients.Stream{Source: "push://", DVR: 7200000, SegmentSize: 2000, Video: "maxbps", Tags: []string{"sports"}}).Return(nil)
+	require.NoError(t, dvrManager.Apply("abc123", events.DVRSettings{WindowSecs: 7200}))
+	mist.EXPECT().AddStream("video+other", "push://").Return(nil)
+	require.NoError(t, client.AddStream("video+other", "push://"))
+
+	// and the metadata keeps the DVR window
+	mist.EXPECT().AddStreamWithSettings("video+abc123", clients.Stream{Source: "push://", DVR: 7200000}).Return(nil)
+	require.NoError(t, m.Apply("abc123", events.StreamMetadata{}))
+	require.Empty(t, m.Metadata("abc123"))
+	mist.EXPECT().AddStreamWithDVR("video+abc123", "push://", 2*time.Hour).Return(nil)
+	require.NoError(t, dvrManager.MistClient(client).AddStream("video+abc123", "push://"))
+}
@@ -0,0 +1,121 @@
+package streamreaper
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/livepeer/catalyst-api/clients"
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/livepeer/catalyst-api/metrics"
+)
+
+type activity struct {
+	mediaTimeMs int64
+	// idleSince is when the stream was last seen with input or viewers
+	idleSince time.Time
+}
+
+// Reaper deletes the streams added to the Mist config, e.g. for WHIP and SRT ingest, that have had no input and no
+// viewers for idleTimeout. The wildcard streams they're based on, whose names have no +, are never deleted.
+type Reaper struct {
+	mist        clients.MistAPIClient
+	idleTimeout time.Duration
+	interval    time.Duration
+
+	mu     sync.Mutex
+	onReap []func(streamName string)
+	// activity is only accessed from reap
+	activity map[string]activity // stream name to activity
+}
+
+func NewReaper(mist clients.MistAPIClient, idleTimeout, interval time.Duration) *Reaper {
+	return &Reaper{
+		mist:        mist,
+		idleTimeout: idleTimeout,
+		interval:    interval,
+		activity:    map[string]activity{},
+	}
+}
+
+// OnReap registers a callback clearing the state kept for a stream once it's deleted
+func (r *Reaper) OnReap(cb func(streamName string)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onReap = append(r.onReap, cb)
+}
+
+// Start checks the streams every interval until ctx is done
+func (r *Reaper) Start(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			r.reap(time.Now())
+		}
+	}
+}
+
+func (r *Reaper) reap(now time.Time) {
+	streams, err := r.mist.GetStreams()
+	if err != nil {
+		log.LogNoRequestID("error listing Mist streams to reap", "err", err)
+		return
+	}
+	state, err := r.mist.GetState()
+	if err != nil {
+		log.LogNoRequestID("error getting Mist state to reap streams", "err", err)
+		return
+	}
+
+	idle := 0
+	for streamName := range r.activity {
+		if _, ok := streams[streamName]; !ok {
+			delete(r.activity, streamName)
+		}
+	}
+	for streamName := range streams {
+		if !strings.Contains(streamName, "+") {
+			continue
+		}
+		var mediaTimeMs int64
+		isActive := false
+		if stats, ok := state.StreamsStats[streamName]; ok && stats != nil {
+			mediaTimeMs = stats.MediaTimeMs
+			_, isLive := state.ActiveStreams[streamName]
+			isActive = isLive && stats.Clients > 0
+		}
+		a, seen := r.activity[streamName]
+		if !seen || isActive || mediaTimeMs != a.mediaTimeMs {
+			// newly seen streams get the whole timeout before they're reaped
+			r.activity[streamName] = activity{mediaTimeMs: mediaTimeMs, idleSince: now}
+			continue
+		}
+		idle++
+		if now.Sub(a.idleSince) < r.idleTimeout {
+			continue
+		}
+
+		err := r.mist.DeleteStream(streamName)
+		metrics.Metrics.IdleStreamsReapedCount.WithLabelValues(strconv.FormatBool(err == nil)).Inc()
+		if err != nil {
+			log.LogNoRequestID("error deleting idle stream", log.KeyStream, streamName, "err", err)
+			continue
+		}
+		log.LogNoRequestID("deleted idle stream", log.KeyStream, streamName, "idle_since", a.idleSince)
+		idle--
+		delete(r.activity, streamName)
+		r.mu.Lock()
+		callbacks := r.onReap
+		r.mu.Unlock()
+		for _, cb := range callbacks {
+			cb(streamName)
+		}
+	}
+	metrics.Metrics.IdleStreams.Set(float64(idle))
+}
@@ -0,0 +1,52 @@
+package streamreaper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/livepeer/catalyst-api/clients"
+	mockmistclient "github.com/livepeer/catalyst-api/mocks/clients"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReap(t *testing.T) {
+	mist := mockmistclient.NewMockMistAPIClient(gomock.NewController(t))
+	r := NewReaper(mist, 10*time.Minute, time.Minute)
+	var reaped []string
+	r.OnReap(func(streamName string) { reaped = append(reaped, streamName) })
+
+	streams := map[string]clients.Stream{
+		"video":         {Source: "push://"},
+		"video+live":    {Source: "push://"},
+		"video+watched": {Source: "push://"},
+		"video+zombie":  {Source: "push://"},
+	}
+	state := func(liveMediaTimeMs int64) clients.MistState {
+		return clients.MistState{
+			ActiveStreams: map[string]*clients.ActiveStream{
+				"video+live":    {Source: "push://"},
+				"video+watched": {Source: "push://"},
+			},
+			StreamsStats: map[string]*clients.MistStreamStats{
+				"video+live":    {Clients: 0, MediaTimeMs: liveMediaTimeMs},
+				"video+watched": {Clients: 3, MediaTimeMs: 1000},
+			},
+		}
+	}
+	mist.EXPECT().GetStreams().Return(streams, nil).Times(3)
+	start := time.Now()
+
+	// the streams get the whole timeout from when they're first seen
+	mist.EXPECT().GetState().Return(state(1000), nil)
+	r.reap(start)
+	mist.EXPECT().GetState().Return(state(2000), nil)
+	r.reap(start.Add(5 * time.Minute))
+	require.Empty(t, reaped)
+
+	mist.EXPECT().GetState().Return(state(3000), nil)
+	mist.EXPECT().DeleteStream("video+zombie").Return(nil)
+	r.reap(start.Add(11 * time.Minute))
+	require.Equal(t, []string{"video+zombie"}, reaped)
+	require.NotContains(t, r.activity, "video+zombie")
+}
@@ -1,6 +1,7 @@
 package steps
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"reflect"
@@ -10,6 +11,9 @@ import (
 
 	"github.com/cucumber/godog"
 	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+	"github.com/livepeer/catalyst-api/audit"
+	"github.com/livepeer/catalyst-api/liveprofiles"
+	"github.com/livepeer/catalyst-api/streamkeys"
 )
 
 const DB_CONNECTION_STRING = "host=127.0.0.1 port=5432 sslmode=disable user=postgres password=postgres dbname=postgres"
@@ -80,6 +84,13 @@ func (s *StepContext) StartDatabase() error {
 	if err != nil {
 		return err
 	}
+
+	// Create the tables the API reads as well as writes, with the DDL it ships
+	for _, create := range []func(context.Context, *sql.DB) error{audit.CreateTable, streamkeys.CreateTable, liveprofiles.CreateTable} {
+		if err := create(context.Background(), metricsDB); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -0,0 +1,148 @@
+package thumbnails
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/livepeer/catalyst-api/clients"
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/livepeer/go-tools/drivers"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	liveThumbFilename = "thumbnail"
+	// give up on reading a keyframe from a stream after this long
+	liveThumbTimeout = 10 * time.Second
+)
+
+// LiveThumbnailer periodically captures a thumbnail from each stream ingested on this node and uploads it
+// to <output>/<playbackID>/thumbnail.<ext>, overwriting the previous one
+type LiveThumbnailer struct {
+	mist           clients.MistAPIClient
+	mistHTTPURL    string
+	baseStreamName string
+	output         *url.URL
+	interval       time.Duration
+	opts           Options
+}
+
+// NewLiveThumbnailer creates a thumbnailer reading streams from the Mist HTTP output at mistHTTPURL, e.g. http://127.0.0.1:8080
+func NewLiveThumbnailer(mist clients.MistAPIClient, mistHTTPURL, baseStreamName string, output *url.URL, interval time.Duration, opts Options) *LiveThumbnailer {
+	return &LiveThumbnailer{
+		mist:           mist,
+		mistHTTPURL:    strings.TrimSuffix(mistHTTPURL, "/"),
+		baseStreamName: baseStreamName,
+		output:         output,
+		interval:       interval,
+		opts:           opts.WithDefaults(),
+	}
+}
+
+func (l *LiveThumbnailer) Start(ctx context.Context) error {
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			l.captureAll(ctx)
+		}
+	}
+}
+
+func (l *LiveThumbnailer) captureAll(ctx context.Context) {
+	state, err := l.mist.GetState()
+	if err != nil {
+		log.LogNoRequestID("live thumbnails failed to get mist state", "err", err)
+		return
+	}
+
+	group, ctx := errgroup.WithContext(ctx)
+	group.SetLimit(5)
+	for streamName, playbackID := range liveStreams(state, l.baseStreamName) {
+		streamName, playbackID := streamName, playbackID
+		group.Go(func() error {
+			if err := l.capture(ctx, streamName, playbackID); err != nil {
+				log.LogNoRequestID("live thumbnail capture failed", log.KeyStream, streamName, "err", err)
+			}
+			return nil
+		})
+	}
+	_ = group.Wait()
+}
+
+// liveStreams returns the playback IDs of the streams being ingested on this node, keyed by Mist stream name
+func liveStreams(state clients.MistState, baseStreamName string) map[string]string {
+	streams := map[string]string{}
+	prefix := baseStreamName + "+"
+	for streamName := range state.ActiveStreams {
+		if !state.IsIngestStream(streamName) || !strings.HasPrefix(streamName, prefix) {
+			continue
+		}
+		if playbackID := strings.TrimPrefix(streamName, prefix); playbackID != "" {
+			streams[streamName] = playbackID
+		}
+	}
+	return streams
+}
+
+// capture takes a thumbnail of a stream, killing ffmpeg when the context is done, e.g. on shutdown
+func (l *LiveThumbnailer) capture(ctx context.Context, streamName, playbackID string) error {
+	tempDir, err := os.MkdirTemp(os.TempDir(), "live-thumb-*")
+	if err != nil {
+		return fmt.Errorf("failed to make temp dir: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	filename := liveThumbFilename + l.opts.ext()
+	thumbOut := filepath.Join(tempDir, filename)
+	outArgs := ffmpeg.KwArgs{
+		"vframes": "1",
+		"vf":      fmt.Sprintf("scale=%s:force_original_aspect_ratio=decrease", l.opts.scale()),
+	}
+	for k, v := range l.opts.encoderArgs() {
+		outArgs[k] = v
+	}
+
+	var ffmpegErr bytes.Buffer
+	err = runInPool("live", func() error {
+		output := ffmpeg.
+			Input(fmt.Sprintf("%s/%s.ts", l.mistHTTPURL, streamName), ffmpeg.KwArgs{
+				"skip_frame": "nokey",
+				"rw_timeout": fmt.Sprintf("%d", liveThumbTimeout.Microseconds()),
+			}).
+			Output(thumbOut, outArgs)
+		output.Context = ctx
+		return output.OverWriteOutput().WithErrorOutput(&ffmpegErr).Run()
+	})
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("error running ffmpeg for live thumbnail [%s]: %w", ffmpegErr.String(), err)
+	}
+
+	outputLocation := l.output.JoinPath(playbackID)
+	return backoff.Retry(func() error {
+		f, err := os.Open(thumbOut)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return clients.UploadToOSURLFields(outputLocation.String(), filename, f, time.Minute, &drivers.FileProperties{
+			ContentType: l.opts.format().contentType,
+			// the thumbnail is replaced every interval so shouldn't be cached for longer
+			CacheControl: fmt.Sprintf("max-age=%d", int(l.interval.Seconds())),
+		})
+	}, backoff.WithContext(backoff.WithMaxRetries(backoff.NewConstantBackOff(time.Second), 2), ctx))
+}
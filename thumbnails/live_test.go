@@ -0,0 +1,26 @@
+package thumbnails
+
+import (
+	"testing"
+
+	"github.com/livepeer/catalyst-api/clients"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLiveStreams(t *testing.T) {
+	state := clients.MistState{
+		ActiveStreams: map[string]*clients.ActiveStream{
+			"video+abc123":          {Source: "push://"},
+			"video+def456":          {Source: "push://INTERNAL_ONLY:rtmp://example.com/live"},
+			"video+playback":        {Source: "push://INTERNAL_ONLY:dtsc://other-node"},
+			"catalyst_vod_request":  {Source: "push://"},
+			"tr_rend_+video+abc123": {Source: "push://"},
+			"video+":                {Source: "push://"},
+			"otherbase+ghi789":      {Source: "push://"},
+		},
+	}
+	require.Equal(t, map[string]string{
+		"video+abc123": "abc123",
+		"video+def456": "def456",
+	}, liveStreams(state, "video"))
+}
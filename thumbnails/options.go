@@ -0,0 +1,238 @@
+package thumbnails
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/grafov/m3u8"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// Defaults used for any option not set on a request, configurable with flags
+var (
+	DefaultResolution  = "640:360"
+	DefaultInterval    float64
+	DefaultQuality     int
+	DefaultFormat      string
+	DefaultSkipMissing bool
+	// DefaultPreviewFormat is the format of the animated previews, webp or gif
+	DefaultPreviewFormat = FormatWebP
+)
+
+const (
+	FormatPNG  = "png"
+	FormatJPEG = "jpeg"
+	FormatWebP = "webp"
+	FormatAVIF = "avif"
+	// FormatGIF is only used for the animated previews
+	FormatGIF = "gif"
+)
+
+// imageFormat is an output format for thumbnails along with the ffmpeg settings used to encode it
+type imageFormat struct {
+	ext         string
+	contentType string
+	// encoderArgs returns the ffmpeg output arguments for a quality between 1 and 100
+	encoderArgs    func(quality int) ffmpeg.KwArgs
+	defaultQuality int
+}
+
+var imageFormats = map[string]imageFormat{
+	FormatPNG: {
+		ext:         ".png",
+		contentType: "image/png",
+		encoderArgs: func(int) ffmpeg.KwArgs { return ffmpeg.KwArgs{} },
+	},
+	FormatJPEG: {
+		ext:         ".jpg",
+		contentType: "image/jpeg",
+		encoderArgs: func(quality int) ffmpeg.KwArgs {
+			return ffmpeg.KwArgs{"q:v": qscale(quality)}
+		},
+		defaultQuality: 75,
+	},
+	FormatWebP: {
+		ext:         ".webp",
+		contentType: "image/webp",
+		encoderArgs: func(quality int) ffmpeg.KwArgs {
+			return ffmpeg.KwArgs{"c:v": "libwebp", "quality": strconv.Itoa(quality)}
+		},
+		defaultQuality: 75,
+	},
+	FormatAVIF: {
+		ext:         ".avif",
+		contentType: "image/avif",
+		encoderArgs: func(quality int) ffmpeg.KwArgs {
+			// libaom's crf runs from 0 (lossless) to 63 (worst)
+			return ffmpeg.KwArgs{"c:v": "libaom-av1", "still-picture": "1", "crf": strconv.Itoa((100 - quality) * 63 / 99), "b:v": "0"}
+		},
+		defaultQuality: 60,
+	},
+}
+
+const (
+	minDimension = 16
+	maxDimension = 3840
+)
+
+// Options control how thumbnails are generated for a job
+type Options struct {
+	// Maximum width and height to scale thumbnails down to, as WIDTH:HEIGHT or WIDTHxHEIGHT
+	Resolution string `json:"resolution,omitempty"`
+	// Seconds between thumbnails. Thumbnails are taken at most once per segment, so intervals
+	// shorter than the segment duration result in one thumbnail per segment.
+	Interval float64 `json:"interval,omitempty"`
+	// Quality of the thumbnails from 1 to 100 for the lossy formats
+	Quality int `json:"quality,omitempty"`
+	// Image format of the thumbnails: png, jpeg, webp or avif. Defaults to jpeg if a quality is set, otherwise png.
+	Format string `json:"format,omitempty"`
+	// Leave thumbnails that couldn't be found out of the VTT, rather than failing, once the wait for them times out
+	SkipMissing bool `json:"skip_missing,omitempty"`
+	// Format of the animated hover preview: webp or gif
+	PreviewFormat string `json:"preview_format,omitempty"`
+}
+
+// WithDefaults returns the options with any unset field filled in from the configured defaults
+func (o Options) WithDefaults() Options {
+	if o.Resolution == "" {
+		o.Resolution = DefaultResolution
+	}
+	if o.Interval == 0 {
+		o.Interval = DefaultInterval
+	}
+	if o.Quality == 0 {
+		o.Quality = DefaultQuality
+	}
+	if o.Format == "" {
+		o.Format = DefaultFormat
+	}
+	o.SkipMissing = o.SkipMissing || DefaultSkipMissing
+	if o.PreviewFormat == "" {
+		o.PreviewFormat = DefaultPreviewFormat
+	}
+	return o
+}
+
+func (o Options) Validate() error {
+	if o.Resolution != "" {
+		if _, _, err := parseResolution(o.Resolution); err != nil {
+			return err
+		}
+	}
+	if o.Interval < 0 || math.IsNaN(o.Interval) || math.IsInf(o.Interval, 0) {
+		return fmt.Errorf("invalid thumbnail interval %v", o.Interval)
+	}
+	if o.Quality < 0 || o.Quality > 100 {
+		return fmt.Errorf("invalid thumbnail quality %d, must be between 1 and 100", o.Quality)
+	}
+	if _, ok := imageFormats[o.Format]; o.Format != "" && !ok {
+		return fmt.Errorf("invalid thumbnail format %q, must be one of png, jpeg, webp or avif", o.Format)
+	}
+	if _, ok := previewFormats[o.PreviewFormat]; o.PreviewFormat != "" && !ok {
+		return fmt.Errorf("invalid preview format %q, must be webp or gif", o.PreviewFormat)
+	}
+	return nil
+}
+
+func (o Options) format() imageFormat {
+	if f, ok := imageFormats[o.Format]; ok {
+		return f
+	}
+	// a quality only makes sense for lossy formats, so keep the behaviour from before formats were configurable
+	if o.Quality > 0 {
+		return imageFormats[FormatJPEG]
+	}
+	return imageFormats[FormatPNG]
+}
+
+func (o Options) ext() string {
+	return o.format().ext
+}
+
+// encoderArgs returns the ffmpeg output arguments to encode thumbnails in the configured format and quality
+func (o Options) encoderArgs() ffmpeg.KwArgs {
+	f := o.format()
+	quality := o.Quality
+	if quality == 0 {
+		quality = f.defaultQuality
+	}
+	return f.encoderArgs(quality)
+}
+
+// scale returns the ffmpeg scale filter arguments for the resolution
+func (o Options) scale() string {
+	w, h, err := parseResolution(o.Resolution)
+	if err != nil {
+		w, h, _ = parseResolution(DefaultResolution)
+	}
+	return fmt.Sprintf("%d:%d", w, h)
+}
+
+// qscale maps a 1-100 quality to ffmpeg's JPEG qscale, which runs from 2 (best) to 31 (worst)
+func qscale(quality int) string {
+	return strconv.Itoa(2 + (100-quality)*29/99)
+}
+
+func parseResolution(resolution string) (int, int, error) {
+	parts := strings.FieldsFunc(resolution, func(r rune) bool { return r == ':' || r == 'x' })
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid thumbnail resolution %q, must be WIDTH:HEIGHT", resolution)
+	}
+	w, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid thumbnail resolution %q: %w", resolution, err)
+	}
+	h, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid thumbnail resolution %q: %w", resolution, err)
+	}
+	if w < minDimension || h < minDimension || w > maxDimension || h > maxDimension {
+		return 0, 0, fmt.Errorf("invalid thumbnail resolution %q, dimensions must be between %d and %d", resolution, minDimension, maxDimension)
+	}
+	return w, h, nil
+}
+
+// thumbCue is a thumbnail and the time range of the video it's shown for
+type thumbCue struct {
+	segment    *m3u8.MediaSegment
+	start, end float64
+}
+
+// coverGaps returns the cues that have a thumbnail, extended to also cover the time of the missing ones next to them
+func coverGaps(cues []thumbCue, missing map[int]bool) []thumbCue {
+	var available []thumbCue
+	var gapStart float64
+	inGap := false
+	for i, cue := range cues {
+		if missing[i] {
+			if len(available) > 0 {
+				available[len(available)-1].end = cue.end
+			} else if !inGap {
+				gapStart, inGap = cue.start, true
+			}
+			continue
+		}
+		if inGap {
+			cue.start, inGap = gapStart, false
+		}
+		available = append(available, cue)
+	}
+	return available
+}
+
+// selectThumbs picks the segments to take thumbnails from so that they're at least interval seconds apart.
+// Each thumbnail is shown until the next one starts.
+func selectThumbs(segments []*m3u8.MediaSegment, interval float64) []thumbCue {
+	var cues []thumbCue
+	var current float64
+	for _, segment := range segments {
+		if len(cues) == 0 || current >= cues[len(cues)-1].start+interval {
+			cues = append(cues, thumbCue{segment: segment, start: current})
+		}
+		current += segment.Duration
+		cues[len(cues)-1].end = current
+	}
+	return cues
+}
@@ -0,0 +1,78 @@
+package thumbnails
+
+import (
+	"testing"
+
+	"github.com/grafov/m3u8"
+	"github.com/stretchr/testify/require"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+func TestOptionsValidate(t *testing.T) {
+	require.NoError(t, Options{}.Validate())
+	require.NoError(t, Options{Resolution: "854:480", Interval: 5, Quality: 80}.Validate())
+	require.NoError(t, Options{Resolution: "320x180"}.Validate())
+	require.NoError(t, Options{PreviewFormat: "gif"}.Validate())
+
+	require.Error(t, Options{Resolution: "854"}.Validate())
+	require.Error(t, Options{Resolution: "abc:480"}.Validate())
+	require.Error(t, Options{Resolution: "8:8"}.Validate())
+	require.Error(t, Options{Resolution: "10000:480"}.Validate())
+	require.Error(t, Options{Interval: -1}.Validate())
+	require.Error(t, Options{Quality: 101}.Validate())
+	require.Error(t, Options{Format: "gif"}.Validate())
+	require.Error(t, Options{PreviewFormat: "png"}.Validate())
+}
+
+func TestOptionsWithDefaults(t *testing.T) {
+	opts := Options{}.WithDefaults()
+	require.Equal(t, "640:360", opts.scale())
+	require.Equal(t, ".png", opts.ext())
+
+	opts = Options{Resolution: "320x180", Quality: 100}.WithDefaults()
+	require.Equal(t, "320:180", opts.scale())
+	require.Equal(t, ".jpg", opts.ext())
+	require.Equal(t, ffmpeg.KwArgs{"q:v": "2"}, opts.encoderArgs())
+	require.Equal(t, "31", qscale(1))
+
+	opts = Options{Format: FormatWebP}.WithDefaults()
+	require.Equal(t, ".webp", opts.ext())
+	require.Equal(t, ffmpeg.KwArgs{"c:v": "libwebp", "quality": "75"}, opts.encoderArgs())
+
+	opts = Options{Format: FormatAVIF, Quality: 100}.WithDefaults()
+	require.Equal(t, ".avif", opts.ext())
+	require.Equal(t, "0", opts.encoderArgs()["crf"])
+
+	opts = Options{Format: FormatPNG, Quality: 50}.WithDefaults()
+	require.Equal(t, ".png", opts.ext())
+	require.Empty(t, opts.encoderArgs())
+}
+
+func TestSelectThumbs(t *testing.T) {
+	var segments []*m3u8.MediaSegment
+	for _, d := range []float64{4, 4, 4, 4, 4, 2.5} {
+		segments = append(segments, &m3u8.MediaSegment{Duration: d})
+	}
+
+	// one thumbnail per segment by default
+	cues := selectThumbs(segments, 0)
+	require.Len(t, cues, 6)
+	require.Equal(t, 20.0, cues[5].start)
+	require.Equal(t, 22.5, cues[5].end)
+
+	cues = selectThumbs(segments, 10)
+	require.Len(t, cues, 2)
+	require.Equal(t, thumbCue{segment: segments[0], start: 0, end: 12}, cues[0])
+	require.Equal(t, thumbCue{segment: segments[3], start: 12, end: 22.5}, cues[1])
+
+	require.Equal(t, "00:00:22.500", vttTimestamp(cues[1].end))
+}
+
+func TestCoverGaps(t *testing.T) {
+	cues := []thumbCue{{start: 0, end: 10}, {start: 10, end: 20}, {start: 20, end: 30}, {start: 30, end: 40}}
+
+	require.Equal(t, cues, coverGaps(cues, nil))
+	require.Equal(t, []thumbCue{{start: 0, end: 20}, {start: 20, end: 40}}, coverGaps(cues, map[int]bool{1: true, 3: true}))
+	require.Equal(t, []thumbCue{{start: 0, end: 30}, {start: 30, end: 40}}, coverGaps(cues, map[int]bool{0: true, 1: true}))
+	require.Empty(t, coverGaps(cues, map[int]bool{0: true, 1: true, 2: true, 3: true}))
+}
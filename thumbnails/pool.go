@@ -0,0 +1,42 @@
+package thumbnails
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/livepeer/catalyst-api/metrics"
+)
+
+// MaxConcurrentThumbs is the maximum number of ffmpeg thumbnail processes run at once across all jobs, so that
+// thumbnail generation can't starve transcoding of CPU on busy nodes
+var MaxConcurrentThumbs = 4
+
+var pool struct {
+	once  sync.Once
+	slots chan struct{}
+}
+
+// runInPool waits for a free slot in the shared worker pool and then runs f, recording how long it took
+func runInPool(thumbType string, f func() error) error {
+	pool.once.Do(func() {
+		pool.slots = make(chan struct{}, max(1, MaxConcurrentThumbs))
+	})
+
+	queued := time.Now()
+	metrics.Metrics.ThumbnailQueueDepth.Inc()
+	pool.slots <- struct{}{}
+	metrics.Metrics.ThumbnailQueueDepth.Dec()
+	metrics.Metrics.ThumbnailQueueWaitSec.Observe(time.Since(queued).Seconds())
+
+	metrics.Metrics.ThumbnailsInFlight.Inc()
+	defer func() {
+		<-pool.slots
+		metrics.Metrics.ThumbnailsInFlight.Dec()
+	}()
+
+	start := time.Now()
+	err := f()
+	metrics.Metrics.ThumbnailDurationSec.WithLabelValues(thumbType, strconv.FormatBool(err == nil)).Observe(time.Since(start).Seconds())
+	return err
+}
@@ -0,0 +1,42 @@
+package thumbnails
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoolLimitsConcurrency(t *testing.T) {
+	var running, maxRunning atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 3*MaxConcurrentThumbs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = runInPool("test", func() error {
+				n := running.Add(1)
+				for {
+					m := maxRunning.Load()
+					if n <= m || maxRunning.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				running.Add(-1)
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+	require.LessOrEqual(t, maxRunning.Load(), int32(MaxConcurrentThumbs))
+	require.Greater(t, maxRunning.Load(), int32(1))
+}
+
+func TestPoolReturnsError(t *testing.T) {
+	err := errors.New("ffmpeg failed")
+	require.ErrorIs(t, runInPool("test", func() error { return err }), err)
+}
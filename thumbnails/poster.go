@@ -0,0 +1,123 @@
+package thumbnails
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/grafov/m3u8"
+	"github.com/livepeer/catalyst-api/clients"
+	"github.com/livepeer/go-tools/drivers"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+const (
+	posterFilename = "poster.jpg"
+	// number of segments sampled across the video to pick the poster from
+	posterSampleSegments = 5
+	// frames per second of the sampled segments considered by the thumbnail filter
+	posterSampleFPS = 1
+)
+
+// GeneratePoster picks a representative frame from across the video with ffmpeg's thumbnail filter, rather than
+// just using the first frame which is often black, and uploads it next to the thumbnails. It returns the
+// location of the poster with any credentials removed.
+func GeneratePoster(requestID, input string, output *url.URL, opts Options) (string, error) {
+	if output == nil {
+		return "", fmt.Errorf("output URL is nil")
+	}
+	opts = opts.WithDefaults()
+
+	mediaPlaylist, err := clients.DownloadRenditionManifest(requestID, input)
+	if err != nil {
+		return "", err
+	}
+	inputURL, err := url.Parse(input)
+	if err != nil {
+		return "", err
+	}
+	segments := posterSegments(mediaPlaylist.GetAllSegments(), posterSampleSegments)
+	if len(segments) == 0 {
+		return "", fmt.Errorf("no segments found for poster")
+	}
+
+	tempDir, err := os.MkdirTemp(os.TempDir(), "poster-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to make temp dir: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// the sampled segments are concatenated so that the filter can compare frames from all of them
+	var source []byte
+	var duration float64
+	for _, segment := range segments {
+		bs, err := downloadSegment(requestID, inputURL, segment)
+		if err != nil {
+			return "", err
+		}
+		source = append(source, bs...)
+		duration += segment.Duration
+	}
+	inFilename := filepath.Join(tempDir, "source.ts")
+	if err := os.WriteFile(inFilename, source, 0644); err != nil {
+		return "", err
+	}
+
+	posterOut := filepath.Join(tempDir, posterFilename)
+	var ffmpegErr bytes.Buffer
+	err = runInPool("poster", func() error {
+		return ffmpeg.
+			Input(inFilename).
+			Output(
+				posterOut,
+				ffmpeg.KwArgs{
+					"vf": fmt.Sprintf(
+						"fps=%d,thumbnail=%d,scale=%s:force_original_aspect_ratio=decrease",
+						posterSampleFPS, max(1, int(duration)*posterSampleFPS), opts.scale(),
+					),
+					"frames:v": "1",
+					"q:v":      "3",
+				},
+			).OverWriteOutput().WithErrorOutput(&ffmpegErr).Run()
+	})
+	if err != nil {
+		return "", fmt.Errorf("error running ffmpeg for poster [%s]: %w", ffmpegErr.String(), err)
+	}
+
+	outputLocation := output.JoinPath(outputDir)
+	err = backoff.Retry(func() error {
+		f, err := os.Open(posterOut)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return clients.UploadToOSURLFields(outputLocation.String(), posterFilename, f, 2*time.Minute, &drivers.FileProperties{ContentType: "image/jpeg"})
+	}, clients.UploadRetryBackoff())
+	if err != nil {
+		return "", fmt.Errorf("failed to upload poster: %w", err)
+	}
+
+	posterURL := outputLocation.JoinPath(posterFilename)
+	posterURL.User = nil
+	return posterURL.String(), nil
+}
+
+// posterSegments picks up to n segments evenly spread across the video. The first segment is skipped when there
+// are others to choose from, since videos often start with a black or title frame.
+func posterSegments(segments []*m3u8.MediaSegment, n int) []*m3u8.MediaSegment {
+	if len(segments) > 1 {
+		segments = segments[1:]
+	}
+	if len(segments) <= n {
+		return segments
+	}
+	picked := make([]*m3u8.MediaSegment, 0, n)
+	for i := 0; i < n; i++ {
+		picked = append(picked, segments[i*len(segments)/n])
+	}
+	return picked
+}
@@ -0,0 +1,27 @@
+package thumbnails
+
+import (
+	"testing"
+
+	"github.com/grafov/m3u8"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPosterSegments(t *testing.T) {
+	var segments []*m3u8.MediaSegment
+	for i := 0; i < 11; i++ {
+		segments = append(segments, &m3u8.MediaSegment{SeqId: uint64(i)})
+	}
+	seqIDs := func(segs []*m3u8.MediaSegment) []uint64 {
+		var ids []uint64
+		for _, s := range segs {
+			ids = append(ids, s.SeqId)
+		}
+		return ids
+	}
+
+	require.Equal(t, []uint64{1, 3, 5, 7, 9}, seqIDs(posterSegments(segments, 5)))
+	require.Equal(t, []uint64{1, 2, 3}, seqIDs(posterSegments(segments[:4], 5)))
+	require.Equal(t, []uint64{0}, seqIDs(posterSegments(segments[:1], 5)))
+	require.Empty(t, posterSegments(nil, 5))
+}
@@ -0,0 +1,139 @@
+package thumbnails
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/livepeer/catalyst-api/clients"
+	"github.com/livepeer/go-tools/drivers"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+const (
+	previewName     = "preview"
+	previewDuration = 3.0
+	previewFPS      = 8
+	previewWidth    = 320
+)
+
+// previewFormat is an output format for the animated previews along with the ffmpeg settings used to encode it
+type previewFormat struct {
+	ext         string
+	contentType string
+	// filter is the video filter applied after the frame rate and size are reduced
+	filter      string
+	encoderArgs ffmpeg.KwArgs
+}
+
+var previewFormats = map[string]previewFormat{
+	FormatWebP: {
+		ext:         ".webp",
+		contentType: "image/webp",
+		encoderArgs: ffmpeg.KwArgs{"c:v": "libwebp", "quality": "60", "loop": "0"},
+	},
+	FormatGIF: {
+		ext:         ".gif",
+		contentType: "image/gif",
+		// GIFs are limited to 256 colours, so generate a palette from the preview's own frames
+		filter:      "split[s0][s1];[s0]palettegen[p];[s1][p]paletteuse",
+		encoderArgs: ffmpeg.KwArgs{"c:v": "gif", "loop": "0"},
+	},
+}
+
+// GeneratePreview creates a short, looping animated WebP or GIF from the start of the video for use in hover cards
+// and uploads it next to the thumbnails. It returns the location of the preview with any credentials removed.
+func GeneratePreview(requestID, input string, output *url.URL, opts Options) (string, error) {
+	if output == nil {
+		return "", fmt.Errorf("output URL is nil")
+	}
+	opts = opts.WithDefaults()
+	format, ok := previewFormats[opts.PreviewFormat]
+	if !ok {
+		return "", fmt.Errorf("invalid preview format %q", opts.PreviewFormat)
+	}
+	previewFilename := previewName + format.ext
+
+	mediaPlaylist, err := clients.DownloadRenditionManifest(requestID, input)
+	if err != nil {
+		return "", err
+	}
+	inputURL, err := url.Parse(input)
+	if err != nil {
+		return "", err
+	}
+
+	tempDir, err := os.MkdirTemp(os.TempDir(), "preview-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to make temp dir: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// TS segments can simply be concatenated, so download just enough of them to cover the preview duration
+	var source []byte
+	var downloaded float64
+	for _, segment := range mediaPlaylist.GetAllSegments() {
+		if downloaded >= previewDuration {
+			break
+		}
+		bs, err := downloadSegment(requestID, inputURL, segment)
+		if err != nil {
+			return "", err
+		}
+		source = append(source, bs...)
+		downloaded += segment.Duration
+	}
+	if len(source) == 0 {
+		return "", fmt.Errorf("no segments found for preview")
+	}
+	inFilename := filepath.Join(tempDir, "source.ts")
+	if err := os.WriteFile(inFilename, source, 0644); err != nil {
+		return "", err
+	}
+
+	previewOut := filepath.Join(tempDir, previewFilename)
+	filter := fmt.Sprintf("fps=%d,scale=%d:-2", previewFPS, previewWidth)
+	if format.filter != "" {
+		filter += "," + format.filter
+	}
+	outArgs := ffmpeg.KwArgs{
+		"t":  strconv.FormatFloat(previewDuration, 'f', -1, 64),
+		"vf": filter,
+		"an": "",
+	}
+	for k, v := range format.encoderArgs {
+		outArgs[k] = v
+	}
+	var ffmpegErr bytes.Buffer
+	err = runInPool("preview", func() error {
+		return ffmpeg.
+			Input(inFilename).
+			Output(previewOut, outArgs).
+			OverWriteOutput().WithErrorOutput(&ffmpegErr).Run()
+	})
+	if err != nil {
+		return "", fmt.Errorf("error running ffmpeg for preview [%s]: %w", ffmpegErr.String(), err)
+	}
+
+	outputLocation := output.JoinPath(outputDir)
+	err = backoff.Retry(func() error {
+		f, err := os.Open(previewOut)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return clients.UploadToOSURLFields(outputLocation.String(), previewFilename, f, 2*time.Minute, &drivers.FileProperties{ContentType: format.contentType})
+	}, clients.UploadRetryBackoff())
+	if err != nil {
+		return "", fmt.Errorf("failed to upload preview: %w", err)
+	}
+
+	previewURL := outputLocation.JoinPath(previewFilename)
+	previewURL.User = nil
+	return previewURL.String(), nil
+}
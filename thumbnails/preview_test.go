@@ -0,0 +1,46 @@
+package thumbnails
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/vansante/go-ffprobe.v2"
+)
+
+func TestGeneratePreview(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+
+	tests := []struct {
+		format     string
+		filename   string
+		formatName string
+	}{
+		{format: "", filename: "preview.webp", formatName: "webp_pipe"},
+		{format: FormatGIF, filename: "preview.gif", formatName: "gif"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			outDir, err := os.MkdirTemp(os.TempDir(), "preview*")
+			require.NoError(t, err)
+			defer os.RemoveAll(outDir)
+			out, err := url.Parse(outDir)
+			require.NoError(t, err)
+
+			preview, err := GeneratePreview("req ID", path.Join(wd, "..", "test/fixtures/tiny.m3u8"), out, Options{PreviewFormat: tt.format})
+			require.NoError(t, err)
+			require.Equal(t, filepath.Join(outDir, "thumbnails", tt.filename), preview)
+
+			data, err := ffprobe.ProbeURL(context.Background(), preview)
+			require.NoError(t, err)
+			require.Equal(t, tt.formatName, data.Format.FormatName)
+			require.NotNil(t, data.FirstVideoStream())
+			require.Equal(t, 320, data.FirstVideoStream().Width)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package thumbnails
+
+import (
+	"sync"
+
+	"github.com/livepeer/catalyst-api/clients"
+)
+
+// Progress tracks the thumbnails generated for a job so that they can be reported in status callbacks.
+// A nil Progress is valid and doesn't track anything.
+type Progress struct {
+	mu        sync.Mutex
+	total     int
+	completed int
+	failed    []clients.ThumbnailFailure
+}
+
+func NewProgress() *Progress {
+	return &Progress{}
+}
+
+// Add records that n more thumbnails are to be generated
+func (p *Progress) Add(n int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.total += n
+}
+
+// Done records the result of generating the thumbnail for a segment
+func (p *Progress) Done(segment string, err error) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil {
+		p.failed = append(p.failed, clients.ThumbnailFailure{Segment: segment, Error: err.Error()})
+		return
+	}
+	p.completed++
+}
+
+// Status returns the progress to include in status callbacks, or nil if no thumbnails were requested
+func (p *Progress) Status() *clients.ThumbnailsStatus {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.total == 0 {
+		return nil
+	}
+	return &clients.ThumbnailsStatus{
+		Total:     p.total,
+		Completed: p.completed,
+		Failed:    append([]clients.ThumbnailFailure(nil), p.failed...),
+	}
+}
@@ -0,0 +1,28 @@
+package thumbnails
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/livepeer/catalyst-api/clients"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgress(t *testing.T) {
+	var nilProgress *Progress
+	nilProgress.Add(1)
+	nilProgress.Done("index0.ts", nil)
+	require.Nil(t, nilProgress.Status())
+
+	p := NewProgress()
+	require.Nil(t, p.Status())
+
+	p.Add(3)
+	p.Done("index0.ts", nil)
+	p.Done("index1.ts", errors.New("error running ffmpeg"))
+	require.Equal(t, &clients.ThumbnailsStatus{
+		Total:     3,
+		Completed: 1,
+		Failed:    []clients.ThumbnailFailure{{Segment: "index1.ts", Error: "error running ffmpeg"}},
+	}, p.Status())
+}
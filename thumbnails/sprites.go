@@ -0,0 +1,164 @@
+package thumbnails
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/livepeer/catalyst-api/clients"
+	"github.com/livepeer/go-tools/drivers"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+const (
+	spritesVttFilename = "sprites.vtt"
+	spriteTileWidth    = 160
+	spriteTileHeight   = 90
+	spriteColumns      = 10
+	spriteRows         = 10
+)
+
+// GenerateSpritesVTT tiles the thumbnails generated for each segment into sprite sheets and writes a VTT whose
+// cues reference the position of each thumbnail within the sheets (#xywh=), as expected by players for scrub
+// previews. The thumbnails must already exist, i.e. this should be called after GenerateThumbsVTT.
+func GenerateSpritesVTT(requestID string, input string, output *url.URL, opts Options) error {
+	if output == nil {
+		return fmt.Errorf("output URL is nil")
+	}
+	opts = opts.WithDefaults()
+
+	mediaPlaylist, err := clients.DownloadRenditionManifest(requestID, input)
+	if err != nil {
+		return err
+	}
+	segmentOffset, err := getSegmentOffset(&mediaPlaylist)
+	if err != nil {
+		return err
+	}
+
+	tempDir, err := os.MkdirTemp(os.TempDir(), "sprites-*")
+	if err != nil {
+		return fmt.Errorf("failed to make temp dir: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+	outputLocation := output.JoinPath(outputDir)
+
+	// download the thumbnails, numbered sequentially so that ffmpeg can read them as an image sequence
+	cues := selectThumbs(mediaPlaylist.GetAllSegments(), opts.Interval)
+	missing := map[int]bool{}
+	for i, cue := range cues {
+		filename, err := thumbFilename(path.Base(cue.segment.URI), segmentOffset, opts.ext())
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(tempDir, fmt.Sprintf("thumb_%05d%s", i-len(missing), opts.ext()))
+		err = backoff.Retry(func() error {
+			return downloadThumb(requestID, outputLocation.JoinPath(filename).String(), dest)
+		}, clients.DownloadRetryBackoff())
+		if err != nil {
+			if !opts.SkipMissing {
+				return fmt.Errorf("failed to download thumb %s: %w", filename, err)
+			}
+			os.Remove(dest)
+			missing[i] = true
+		}
+	}
+	cues = coverGaps(cues, missing)
+	if len(cues) == 0 {
+		return fmt.Errorf("no thumbnails found for sprites")
+	}
+
+	if err := tileSprites(tempDir, opts); err != nil {
+		return err
+	}
+
+	sheets, err := filepath.Glob(filepath.Join(tempDir, "sprite_*.jpg"))
+	if err != nil {
+		return err
+	}
+	for _, sheet := range sheets {
+		sheet := sheet
+		err = backoff.Retry(func() error {
+			f, err := os.Open(sheet)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			return clients.UploadToOSURLFields(outputLocation.String(), filepath.Base(sheet), f, 2*time.Minute, &drivers.FileProperties{ContentType: "image/jpeg"})
+		}, clients.UploadRetryBackoff())
+		if err != nil {
+			return fmt.Errorf("failed to upload sprite sheet %s: %w", filepath.Base(sheet), err)
+		}
+	}
+
+	vttContent := spritesVTT(cues)
+	err = backoff.Retry(func() error {
+		return clients.UploadToOSURLFields(outputLocation.String(), spritesVttFilename, bytes.NewReader(vttContent), time.Minute, &drivers.FileProperties{ContentType: "text/vtt"})
+	}, clients.UploadRetryBackoff())
+	if err != nil {
+		return fmt.Errorf("failed to upload sprites vtt: %w", err)
+	}
+	return nil
+}
+
+func downloadThumb(requestID, thumbURL, dest string) error {
+	rc, err := clients.GetFile(context.Background(), requestID, thumbURL, nil)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	f, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(f, rc)
+	return err
+}
+
+// tileSprites scales the thumbnails in dir down to the tile size and tiles them into sprite_N.jpg sheets
+func tileSprites(dir string, opts Options) error {
+	var ffmpegErr bytes.Buffer
+	outArgs := ffmpeg.KwArgs{
+		"start_number": "0",
+		"vf": fmt.Sprintf(
+			"scale=%[1]d:%[2]d:force_original_aspect_ratio=decrease,pad=%[1]d:%[2]d:(ow-iw)/2:(oh-ih)/2,tile=%[3]dx%[4]d",
+			spriteTileWidth, spriteTileHeight, spriteColumns, spriteRows,
+		),
+	}
+	if opts.Quality > 0 {
+		outArgs["q:v"] = qscale(opts.Quality)
+	}
+	err := runInPool("sprites", func() error {
+		return ffmpeg.
+			Input(filepath.Join(dir, "thumb_%05d"+opts.ext()), ffmpeg.KwArgs{"framerate": "1"}).
+			Output(filepath.Join(dir, "sprite_%d.jpg"), outArgs).OverWriteOutput().WithErrorOutput(&ffmpegErr).Run()
+	})
+	if err != nil {
+		return fmt.Errorf("error running ffmpeg for sprites [%s]: %w", ffmpegErr.String(), err)
+	}
+	return nil
+}
+
+// spritesVTT writes a cue per thumbnail, pointing at its coordinates within the sprite sheets
+func spritesVTT(cues []thumbCue) []byte {
+	builder := &bytes.Buffer{}
+	builder.WriteString("WEBVTT\n\n")
+
+	perSheet := spriteColumns * spriteRows
+	for i, cue := range cues {
+		sheet := i / perSheet
+		x := (i % perSheet % spriteColumns) * spriteTileWidth
+		y := (i % perSheet / spriteColumns) * spriteTileHeight
+		builder.WriteString(fmt.Sprintf("%s --> %s\nsprite_%d.jpg#xywh=%d,%d,%d,%d\n\n", vttTimestamp(cue.start), vttTimestamp(cue.end), sheet, x, y, spriteTileWidth, spriteTileHeight))
+	}
+	return builder.Bytes()
+}
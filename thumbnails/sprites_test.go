@@ -0,0 +1,24 @@
+package thumbnails
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_spritesVTT(t *testing.T) {
+	var thumbs []thumbCue
+	for i := 0; i < 101; i++ {
+		thumbs = append(thumbs, thumbCue{start: float64(i * 2), end: float64(i*2 + 2)})
+	}
+	cues := strings.Split(strings.TrimSpace(string(spritesVTT(thumbs))), "\n\n")
+	require.Equal(t, "WEBVTT", cues[0])
+	require.Len(t, cues, 102)
+
+	require.Equal(t, "00:00:00.000 --> 00:00:02.000\nsprite_0.jpg#xywh=0,0,160,90", cues[1])
+	require.Equal(t, "00:00:02.000 --> 00:00:04.000\nsprite_0.jpg#xywh=160,0,160,90", cues[2])
+	require.Equal(t, "00:00:22.000 --> 00:00:24.000\nsprite_0.jpg#xywh=160,90,160,90", cues[12])
+	require.Equal(t, "00:03:18.000 --> 00:03:20.000\nsprite_0.jpg#xywh=1440,810,160,90", cues[100])
+	require.Equal(t, "00:03:20.000 --> 00:03:22.000\nsprite_1.jpg#xywh=0,0,160,90", cues[101])
+}
@@ -17,18 +17,24 @@ import (
 	"github.com/cenkalti/backoff/v4"
 	"github.com/grafov/m3u8"
 	"github.com/livepeer/catalyst-api/clients"
+	"github.com/livepeer/catalyst-api/log"
 	"github.com/livepeer/go-tools/drivers"
 	ffmpeg "github.com/u2takey/ffmpeg-go"
 	"golang.org/x/sync/errgroup"
 )
 
-const resolution = "640:360"
 const vttFilename = "thumbnails.vtt"
 const outputDir = "thumbnails"
 
-func thumbWaitBackoff() backoff.BackOff {
-	// Wait a maximum of 5 mins for thumbnails to finish
-	return backoff.WithMaxRetries(backoff.NewConstantBackOff(30*time.Second), 10)
+// How often to check for thumbnails that haven't been generated yet, and how long to wait in total for all
+// of a job's thumbnails, configurable with flags
+var (
+	ThumbWaitInterval = 30 * time.Second
+	ThumbWaitTimeout  = 5 * time.Minute
+)
+
+func thumbWaitBackoff(ctx context.Context) backoff.BackOff {
+	return backoff.WithContext(backoff.NewConstantBackOff(ThumbWaitInterval), ctx)
 }
 
 func getSegmentOffset(mediaPlaylist *m3u8.MediaPlaylist) (int64, error) {
@@ -47,7 +53,7 @@ func getSegmentOffset(mediaPlaylist *m3u8.MediaPlaylist) (int64, error) {
 	return segmentOffset, nil
 }
 
-func GenerateThumbsVTT(requestID string, input string, output *url.URL) error {
+func GenerateThumbsVTT(requestID string, input string, output *url.URL, opts Options) error {
 	if output == nil {
 		return fmt.Errorf("output URL is nil")
 	}
@@ -58,7 +64,7 @@ func GenerateThumbsVTT(requestID string, input string, output *url.URL) error {
 		return err
 	}
 
-	const layout = "15:04:05.000"
+	opts = opts.WithDefaults()
 	outputLocation := output.JoinPath(outputDir)
 	builder := &bytes.Buffer{}
 	_, err = builder.WriteString("WEBVTT\n\n")
@@ -70,10 +76,14 @@ func GenerateThumbsVTT(requestID string, input string, output *url.URL) error {
 		return err
 	}
 
-	var currentTime time.Time
-	// loop through each segment, generate a vtt entry for it
-	for _, segment := range mediaPlaylist.GetAllSegments() {
-		filename, err := thumbFilename(path.Base(segment.URI), segmentOffset)
+	// the thumbnails are generated in parallel, so wait for all of them at once rather than for each in turn
+	ctx, cancel := context.WithTimeout(context.Background(), ThumbWaitTimeout)
+	defer cancel()
+
+	cues := selectThumbs(mediaPlaylist.GetAllSegments(), opts.Interval)
+	missing := map[int]bool{}
+	for i, cue := range cues {
+		filename, err := thumbFilename(path.Base(cue.segment.URI), segmentOffset, opts.ext())
 		if err != nil {
 			return err
 		}
@@ -84,15 +94,27 @@ func GenerateThumbsVTT(requestID string, input string, output *url.URL) error {
 				rc.Close()
 			}
 			return err
-		}, thumbWaitBackoff())
+		}, thumbWaitBackoff(ctx))
 		if err != nil {
-			return fmt.Errorf("failed to find thumb %s: %w", filename, err)
+			if !opts.SkipMissing {
+				return fmt.Errorf("failed to find thumb %s: %w", filename, err)
+			}
+			log.Log(requestID, "skipping missing thumbnail", "filename", filename, "err", err)
+			missing[i] = true
 		}
+	}
+	available := coverGaps(cues, missing)
+	if len(available) == 0 {
+		return fmt.Errorf("no thumbnails found")
+	}
 
-		start := currentTime.Format(layout)
-		currentTime = currentTime.Add(time.Duration(segment.Duration) * time.Second)
-		end := currentTime.Format(layout)
-		_, err = builder.WriteString(fmt.Sprintf("%s --> %s\n%s\n\n", start, end, filename))
+	// loop through each thumbnail, generate a vtt entry for it
+	for _, cue := range available {
+		filename, err := thumbFilename(path.Base(cue.segment.URI), segmentOffset, opts.ext())
+		if err != nil {
+			return err
+		}
+		_, err = builder.WriteString(fmt.Sprintf("%s --> %s\n%s\n\n", vttTimestamp(cue.start), vttTimestamp(cue.end), filename))
 		if err != nil {
 			return err
 		}
@@ -109,10 +131,11 @@ func GenerateThumbsVTT(requestID string, input string, output *url.URL) error {
 	return nil
 }
 
-func GenerateThumb(segmentURI string, input []byte, output *url.URL, segmentOffset int64) error {
+func GenerateThumb(segmentURI string, input []byte, output *url.URL, segmentOffset int64, opts Options) error {
 	if output == nil {
 		return fmt.Errorf("output URL is nil")
 	}
+	opts = opts.WithDefaults()
 
 	tempDir, err := os.MkdirTemp(os.TempDir(), "thumbs-*")
 	if err != nil {
@@ -126,13 +149,13 @@ func GenerateThumb(segmentURI string, input []byte, output *url.URL, segmentOffs
 		return err
 	}
 
-	filename, err := thumbFilename(segmentURI, segmentOffset)
+	filename, err := thumbFilename(segmentURI, segmentOffset, opts.ext())
 	if err != nil {
 		return err
 	}
 
 	thumbOut := path.Join(tempDir, filename)
-	if err := processSegment(inFilename, thumbOut); err != nil {
+	if err := processSegment(inFilename, thumbOut, opts); err != nil {
 		return err
 	}
 
@@ -143,7 +166,7 @@ func GenerateThumb(segmentURI string, input []byte, output *url.URL, segmentOffs
 			return err
 		}
 		defer fileReader.Close()
-		err = clients.UploadToOSURL(outputLocation.String(), path.Base(thumbOut), fileReader, 2*time.Minute)
+		err = clients.UploadToOSURLFields(outputLocation.String(), path.Base(thumbOut), fileReader, 2*time.Minute, &drivers.FileProperties{ContentType: opts.format().contentType})
 		if err != nil {
 			return fmt.Errorf("failed to upload thumbnail %s: %w", thumbOut, err)
 		}
@@ -156,24 +179,29 @@ func GenerateThumb(segmentURI string, input []byte, output *url.URL, segmentOffs
 	return nil
 }
 
-func GenerateThumbsAndVTT(requestID, input string, output *url.URL) error {
-	err := GenerateThumbsFromManifest(requestID, input, output)
+func GenerateThumbsAndVTT(requestID, input string, output *url.URL, opts Options, progress *Progress) error {
+	err := GenerateThumbsFromManifest(requestID, input, output, opts, progress)
 	if err != nil {
 		return err
 	}
-	err = GenerateThumbsVTT(requestID, input, output)
+	err = GenerateThumbsVTT(requestID, input, output, opts)
 	if err != nil {
 		return err
 	}
+	err = GenerateSpritesVTT(requestID, input, output, opts)
+	if err != nil {
+		return fmt.Errorf("failed to generate sprites: %w", err)
+	}
 	return nil
 }
 
-func GenerateThumbsFromManifest(requestID, input string, output *url.URL) error {
+func GenerateThumbsFromManifest(requestID, input string, output *url.URL, opts Options, progress *Progress) error {
 	if output == nil {
 		return fmt.Errorf("output URL is nil")
 	}
+	opts = opts.WithDefaults()
 
-	// parse manifest and generate one thumbnail per segment
+	// parse manifest and generate a thumbnail per segment, only for the segments needed for the interval
 	mediaPlaylist, err := clients.DownloadRenditionManifest(requestID, input)
 	if err != nil {
 		return err
@@ -190,57 +218,68 @@ func GenerateThumbsFromManifest(requestID, input string, output *url.URL) error
 	// parallelise the thumb uploads
 	uploadGroup, _ := errgroup.WithContext(context.Background())
 	uploadGroup.SetLimit(5)
-	for _, segment := range mediaPlaylist.GetAllSegments() {
-		segment := segment
-		uploadGroup.Go(func() error {
-			segURL, _ := url.Parse(segment.URI)
-			// if the URL is valid and absolute then we should just use it as is, otherwise append the path to inputURL
-			if segURL == nil || !segURL.IsAbs() {
-				segURL = inputURL.JoinPath("..", segment.URI)
-			}
-			var (
-				rc  io.ReadCloser
-				err error
-			)
-			// save the segment to memory
-			err = backoff.Retry(func() error {
-				rc, err = clients.GetFile(context.Background(), requestID, segURL.String(), nil)
-				return err
-			}, clients.DownloadRetryBackoff())
-			if err != nil {
-				return fmt.Errorf("error downloading segment %s: %w", segURL.Redacted(), err)
-			}
-			defer rc.Close()
-
-			bs, err := io.ReadAll(rc)
+	cues := selectThumbs(mediaPlaylist.GetAllSegments(), opts.Interval)
+	progress.Add(len(cues))
+	for _, cue := range cues {
+		segment := cue.segment
+		uploadGroup.Go(func() (err error) {
+			defer func() {
+				progress.Done(path.Base(segment.URI), err)
+			}()
+			bs, err := downloadSegment(requestID, inputURL, segment)
 			if err != nil {
 				return err
 			}
 
 			// generate thumbnail for the segment
-			return GenerateThumb(path.Base(segment.URI), bs, output, segmentOffset)
+			return GenerateThumb(path.Base(segment.URI), bs, output, segmentOffset, opts)
 		})
 	}
 	return uploadGroup.Wait()
 }
 
-func processSegment(input string, thumbOut string) error {
+// downloadSegment saves a segment of the manifest at inputURL to memory
+func downloadSegment(requestID string, inputURL *url.URL, segment *m3u8.MediaSegment) ([]byte, error) {
+	segURL, _ := url.Parse(segment.URI)
+	// if the URL is valid and absolute then we should just use it as is, otherwise append the path to inputURL
+	if segURL == nil || !segURL.IsAbs() {
+		segURL = inputURL.JoinPath("..", segment.URI)
+	}
+	var (
+		rc  io.ReadCloser
+		err error
+	)
+	err = backoff.Retry(func() error {
+		rc, err = clients.GetFile(context.Background(), requestID, segURL.String(), nil)
+		return err
+	}, clients.DownloadRetryBackoff())
+	if err != nil {
+		return nil, fmt.Errorf("error downloading segment %s: %w", segURL.Redacted(), err)
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+func processSegment(input string, thumbOut string, opts Options) error {
 	// generate thumbnail
 	var ffmpegErr bytes.Buffer
+	outArgs := ffmpeg.KwArgs{
+		"ss":      "00:00:00",
+		"vframes": "1",
+		// video filter to resize
+		"vf": fmt.Sprintf("scale=%s:force_original_aspect_ratio=decrease", opts.scale()),
+	}
+	for k, v := range opts.encoderArgs() {
+		outArgs[k] = v
+	}
 
 	err := backoff.Retry(func() error {
 		ffmpegErr = bytes.Buffer{}
-		return ffmpeg.
-			Input(input, ffmpeg.KwArgs{"skip_frame": "nokey"}). // only extract key frames
-			Output(
-				thumbOut,
-				ffmpeg.KwArgs{
-					"ss":      "00:00:00",
-					"vframes": "1",
-					// video filter to resize
-					"vf": fmt.Sprintf("scale=%s:force_original_aspect_ratio=decrease", resolution),
-				},
-			).OverWriteOutput().WithErrorOutput(&ffmpegErr).Run()
+		return runInPool("segment", func() error {
+			return ffmpeg.
+				Input(input, ffmpeg.KwArgs{"skip_frame": "nokey"}). // only extract key frames
+				Output(thumbOut, outArgs).OverWriteOutput().WithErrorOutput(&ffmpegErr).Run()
+		})
 	}, clients.DownloadRetryBackoff())
 	if err != nil {
 		return fmt.Errorf("error running ffmpeg for thumbnails %s [%s]: %w", input, ffmpegErr.String(), err)
@@ -267,10 +306,15 @@ func segmentIndex(segmentURI string) (int64, error) {
 	return i, nil
 }
 
-func thumbFilename(segmentURI string, segmentOffset int64) (string, error) {
+func thumbFilename(segmentURI string, segmentOffset int64, ext string) (string, error) {
 	i, err := segmentIndex(segmentURI)
 	if err != nil {
 		return "", err
 	}
-	return fmt.Sprintf("keyframes_%d.png", i-segmentOffset), nil
+	return fmt.Sprintf("keyframes_%d%s", i-segmentOffset, ext), nil
+}
+
+func vttTimestamp(secs float64) string {
+	const layout = "15:04:05.000"
+	return time.Time{}.Add(time.Duration(secs * float64(time.Second))).Format(layout)
 }
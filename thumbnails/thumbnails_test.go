@@ -8,6 +8,7 @@ import (
 	"path"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"gopkg.in/vansante/go-ffprobe.v2"
@@ -16,7 +17,7 @@ import (
 func generateThumb(t *testing.T, filename string, out *url.URL) {
 	bs, err := os.ReadFile(filename)
 	require.NoError(t, err)
-	err = GenerateThumb(path.Base(filename), bs, out, 0)
+	err = GenerateThumb(path.Base(filename), bs, out, 0, Options{})
 	require.NoError(t, err)
 }
 
@@ -45,7 +46,7 @@ func TestGenerateThumbs(t *testing.T) {
 	out, err = url.Parse(outDir)
 	require.NoError(t, err)
 
-	err = GenerateThumbsFromManifest("req ID", path.Join(wd, "..", "test/fixtures/tiny.m3u8"), out)
+	err = GenerateThumbsFromManifest("req ID", path.Join(wd, "..", "test/fixtures/tiny.m3u8"), out, Options{}, nil)
 	require.NoError(t, err)
 
 	testGenerateThumbsRun(t, outDir, path.Join(wd, "..", "test/fixtures/tiny.m3u8"))
@@ -89,7 +90,7 @@ clip_102.ts
 		require.NoError(t, err)
 	}
 
-	err = GenerateThumbsFromManifest("req ID", inputFile, out)
+	err = GenerateThumbsFromManifest("req ID", inputFile, out, Options{}, nil)
 	require.NoError(t, err)
 
 	testGenerateThumbsRun(t, outDir, inputFile)
@@ -99,7 +100,7 @@ func testGenerateThumbsRun(t *testing.T, outDir, input string) {
 	out, err := url.Parse(outDir)
 	require.NoError(t, err)
 
-	err = GenerateThumbsVTT("req ID", input, out)
+	err = GenerateThumbsVTT("req ID", input, out, Options{})
 	require.NoError(t, err)
 
 	expectedVtt := `WEBVTT
@@ -161,7 +162,7 @@ func Test_thumbFilename(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := thumbFilename(tt.segmentURI, tt.segmentOffset)
+			got, err := thumbFilename(tt.segmentURI, tt.segmentOffset, ".png")
 			require.NoError(t, err)
 			require.Equal(t, tt.want, got)
 		})
@@ -200,3 +201,46 @@ func Test_segmentIndex(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateThumbsVTTSkipMissing(t *testing.T) {
+	defer func(timeout, interval time.Duration) {
+		ThumbWaitTimeout, ThumbWaitInterval = timeout, interval
+	}(ThumbWaitTimeout, ThumbWaitInterval)
+	ThumbWaitTimeout, ThumbWaitInterval = 100*time.Millisecond, 10*time.Millisecond
+	prefixes := segmentPrefix
+	t.Cleanup(func() { segmentPrefix = prefixes })
+	segmentPrefix = append(segmentPrefix[:len(segmentPrefix):len(segmentPrefix)], "seg-")
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	outDir, err := os.MkdirTemp(os.TempDir(), "thumbs*")
+	require.NoError(t, err)
+	defer os.RemoveAll(outDir)
+	out, err := url.Parse(outDir)
+	require.NoError(t, err)
+
+	// only the first and last thumbnails exist
+	require.NoError(t, os.Mkdir(filepath.Join(outDir, "thumbnails"), 0755))
+	for _, name := range []string{"keyframes_0.png", "keyframes_2.png"} {
+		require.NoError(t, os.WriteFile(filepath.Join(outDir, "thumbnails", name), []byte("png"), 0644))
+	}
+	input := path.Join(wd, "..", "test/fixtures/tiny.m3u8")
+
+	err = GenerateThumbsVTT("req ID", input, out, Options{})
+	require.ErrorContains(t, err, "failed to find thumb keyframes_1.png")
+
+	err = GenerateThumbsVTT("req ID", input, out, Options{SkipMissing: true})
+	require.NoError(t, err)
+
+	vtt, err := os.ReadFile(filepath.Join(outDir, "thumbnails/thumbnails.vtt"))
+	require.NoError(t, err)
+	require.Equal(t, `WEBVTT
+
+00:00:00.000 --> 00:00:20.000
+keyframes_0.png
+
+00:00:20.000 --> 00:00:30.000
+keyframes_2.png
+
+`, string(vtt))
+}
@@ -0,0 +1,68 @@
+package transcode
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+
+	"github.com/grafov/m3u8"
+	"github.com/livepeer/catalyst-api/clients"
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/livepeer/catalyst-api/video"
+)
+
+// uploadSubtitles extracts the subtitles and closed captions of the local copy of the source to WebVTT, and uploads
+// each under subtitles/ with a playlist of a single segment covering the whole source. It returns the EXT-X-MEDIA
+// entries of the ones that were uploaded, to be referenced from the master playlist.
+func uploadSubtitles(requestID, localSource string, inputInfo video.InputVideo, targetOSURL *url.URL, durationSecs float64) []*m3u8.Alternative {
+	dir, err := os.MkdirTemp("", "subtitles-")
+	if err != nil {
+		log.LogError(requestID, "failed to create subtitles dir", err)
+		return nil
+	}
+	defer os.RemoveAll(dir)
+
+	var alternatives []*m3u8.Alternative
+	for i, file := range video.ExtractSubtitles(requestID, localSource, inputInfo, dir) {
+		subtitlesPath := path.Join("subtitles", fmt.Sprint(i))
+		if err := uploadSubtitleFile(targetOSURL.JoinPath(subtitlesPath).String(), file.Path, durationSecs); err != nil {
+			log.LogError(requestID, "failed to upload subtitles", err, "name", file.Name)
+			continue
+		}
+		alternatives = append(alternatives, file.Alternative(path.Join(subtitlesPath, "index.m3u8")))
+	}
+	if len(alternatives) > 0 {
+		log.Log(requestID, "uploaded subtitles", "count", len(alternatives))
+	}
+	return alternatives
+}
+
+func uploadSubtitleFile(targetURL, vttPath string, durationSecs float64) error {
+	content, err := os.ReadFile(vttPath)
+	if err != nil {
+		return err
+	}
+	playlist, err := m3u8.NewMediaPlaylist(0, 1)
+	if err != nil {
+		return err
+	}
+	if err := playlist.Append("0.vtt", durationSecs, ""); err != nil {
+		return err
+	}
+	playlist.Close()
+
+	for filename, data := range map[string][]byte{"0.vtt": content, "index.m3u8": playlist.Encode().Bytes()} {
+		err := clients.UploadWithBackup(targetURL, filename, func(osURL string) error {
+			return clients.UploadToOSURL(osURL, filename, bytes.NewReader(data), UploadTimeout)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to upload %s: %w", filename, err)
+		}
+		if err := clients.RecordReaderChecksum(targetURL, filename, bytes.NewReader(data)); err != nil {
+			return fmt.Errorf("failed to compute the checksum of %s: %w", filename, err)
+		}
+	}
+	return nil
+}
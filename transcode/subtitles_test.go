@@ -0,0 +1,28 @@
+package transcode
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploadSubtitleFile(t *testing.T) {
+	vttPath := filepath.Join(t.TempDir(), "subtitles.vtt")
+	require.NoError(t, os.WriteFile(vttPath, []byte("WEBVTT\n\n00:00.000 --> 00:01.000\nHello\n"), 0644))
+
+	outputDir := t.TempDir()
+	target := (&url.URL{Scheme: "file", Path: outputDir}).JoinPath("subtitles", "0")
+	require.NoError(t, uploadSubtitleFile(target.String(), vttPath, 12.5))
+
+	vtt, err := os.ReadFile(filepath.Join(outputDir, "subtitles", "0", "0.vtt"))
+	require.NoError(t, err)
+	require.Contains(t, string(vtt), "Hello")
+	playlist, err := os.ReadFile(filepath.Join(outputDir, "subtitles", "0", "index.m3u8"))
+	require.NoError(t, err)
+	require.Contains(t, string(playlist), "#EXT-X-TARGETDURATION:13")
+	require.Contains(t, string(playlist), "#EXTINF:12.500,\n0.vtt")
+	require.Contains(t, string(playlist), "#EXT-X-ENDLIST")
+}
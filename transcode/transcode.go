@@ -1,14 +1,13 @@
 package transcode
 
 import (
-	"bufio"
-	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"math"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -19,6 +18,7 @@ import (
 	"github.com/cenkalti/backoff/v4"
 	c2pa2 "github.com/livepeer/catalyst-api/c2pa"
 	"github.com/livepeer/catalyst-api/clients"
+	"github.com/livepeer/catalyst-api/errors"
 	"github.com/livepeer/catalyst-api/log"
 	"github.com/livepeer/catalyst-api/metrics"
 	"github.com/livepeer/catalyst-api/video"
@@ -41,7 +41,19 @@ type TranscodeSegmentRequest struct {
 	AccessToken       string                 `json:"accessToken"`
 	TranscodeAPIUrl   string                 `json:"transcodeAPIUrl"`
 	Profiles          []video.EncodedProfile `json:"profiles"`
-	Detection         struct {
+	// DefaultProfiles is the ladder used when no profiles are requested, the global default if nil
+	DefaultProfiles []video.EncodedProfile `json:"-"`
+	// LadderConstraints limit the ladder generated from the DefaultProfiles, if any
+	LadderConstraints *video.LadderConstraints `json:"-"`
+	// AudioOptions the source was segmented with, described in the master playlist
+	AudioOptions *video.AudioOptions `json:"-"`
+	// OutputNaming templates the paths of the segments, playlists and MP4s, the default layout if nil
+	OutputNaming *video.OutputNaming `json:"-"`
+	// HLSEncryption encrypts the rendition segments and adds the #EXT-X-KEY of the key to the playlists, if any
+	HLSEncryption *video.HLSEncryption `json:"-"`
+	// SourceColor is written into the transcoded renditions when it isn't the BT.709 players assume, set from the probe
+	SourceColor video.ColorMetadata `json:"-"`
+	Detection   struct {
 		Freq                uint `json:"freq"`
 		SampleRate          uint `json:"sampleRate"`
 		SceneClassification []struct {
@@ -58,7 +70,7 @@ type TranscodeSegmentRequest struct {
 }
 
 func RunTranscodeProcess(transcodeRequest TranscodeSegmentRequest, streamName string, inputInfo video.InputVideo, broadcaster clients.BroadcasterClient) ([]video.OutputVideo, int, error) {
-	log.AddContext(transcodeRequest.RequestID, "source_manifest", transcodeRequest.SourceManifestURL, "stream_name", streamName)
+	log.AddContext(transcodeRequest.RequestID, "source_manifest", transcodeRequest.SourceManifestURL, log.KeyStream, streamName)
 	log.Log(transcodeRequest.RequestID, "RunTranscodeProcess (v2) Beginning")
 
 	var segmentsCount = 0
@@ -74,12 +86,21 @@ func RunTranscodeProcess(transcodeRequest TranscodeSegmentRequest, streamName st
 	sourceManifestOSURL := transcodeRequest.SourceManifestURL
 
 	// transcodeProfiles are desired constraints for transcoding process
-	transcodeProfiles, err := video.SetTranscodeProfiles(inputInfo, transcodeRequest.Profiles, transcodeRequest.IsClip)
+	transcodeProfiles, err := video.SetTranscodeProfilesWithDefaults(inputInfo, transcodeRequest.Profiles, transcodeRequest.DefaultProfiles, transcodeRequest.LadderConstraints, transcodeRequest.IsClip)
 	if err != nil {
 		return outputs, segmentsCount, fmt.Errorf("failed to set playback profiles: %w", err)
 	} else if len(transcodeProfiles) == 0 {
 		return outputs, segmentsCount, fmt.Errorf("no transcode profiles could be resolved")
 	}
+	if videoTrack, err := inputInfo.GetTrack(video.TrackTypeVideo); err == nil {
+		if videoTrack.VFR {
+			log.Log(transcodeRequest.RequestID, "transcoding variable frame rate source at a constant frame rate", "avg_fps", videoTrack.FPS)
+		}
+		transcodeRequest.SourceColor = videoTrack.Color()
+		if transcodeRequest.SourceColor.NeedsTagging() {
+			log.Log(transcodeRequest.RequestID, "preserving source color in the renditions", "primaries", videoTrack.ColorPrimaries, "transfer", videoTrack.ColorTransfer, "matrix", videoTrack.ColorSpace, "range", videoTrack.ColorRange)
+		}
+	}
 
 	// Download the "source" manifest that contains all the segments we'll be transcoding
 	sourceManifest, err := clients.DownloadRenditionManifest(transcodeRequest.RequestID, sourceManifestOSURL)
@@ -142,6 +163,9 @@ func RunTranscodeProcess(transcodeRequest TranscodeSegmentRequest, streamName st
 	manifestID := "manifest-" + transcodeRequest.RequestID
 	// transcodedStats hold actual info from transcoded results within requested constraints (this usually differs from requested profiles)
 	transcodedStats := statsFromProfiles(transcodeProfiles)
+	for _, stats := range transcodedStats {
+		stats.VideoRange = transcodeRequest.SourceColor.VideoRange()
+	}
 
 	renditionList := video.TRenditionList{RenditionSegmentTable: make(map[string]*video.TSegmentList)}
 	// Only populate video.TRenditionList map if MP4/FragmentedMP4 is enabled or short-form video detection.
@@ -244,8 +268,15 @@ func RunTranscodeProcess(transcodeRequest TranscodeSegmentRequest, streamName st
 	// Wait for disk-writing goroutine to finish. This will be a no-op if MP4s are not requested.
 	wg.Wait()
 
+	// The subtitles are extracted from the local copy of the source, which HLS sources and clips don't have
+	var subtitles []*m3u8.Alternative
+	if transcodeRequest.LocalSourceTmp != "" && !transcodeRequest.IsClip {
+		sourceDuration, _ := video.GetTotalDurationAndSegments(&sourceManifest)
+		subtitles = uploadSubtitles(transcodeRequest.RequestID, transcodeRequest.LocalSourceTmp, inputInfo, hlsTargetURL, sourceDuration)
+	}
+
 	// Build the manifests and push them to storage
-	manifestURL, err := clients.GenerateAndUploadManifests(sourceManifest, hlsTargetURL.String(), transcodedStats, transcodeRequest.IsClip)
+	manifestURL, err := clients.GenerateAndUploadManifests(sourceManifest, hlsTargetURL.String(), transcodedStats, transcodeRequest.IsClip, transcodeRequest.AudioOptions, subtitles, transcodeRequest.OutputNaming, transcodeRequest.HLSEncryption)
 	if err != nil {
 		return outputs, segmentsCount, err
 	}
@@ -267,6 +298,15 @@ func RunTranscodeProcess(transcodeRequest TranscodeSegmentRequest, streamName st
 			return outputs, segmentsCount, fmt.Errorf("a valid mp4 or fragmented-mp4 URL must be provided since MP4 output was requested")
 		}
 
+		// the source is hashed once for the provenance claims of all the signed renditions
+		var sourceHash string
+		if transcodeRequest.C2PA != nil && transcodeRequest.LocalSourceTmp != "" {
+			sourceHash, err = c2pa2.HashFile(transcodeRequest.LocalSourceTmp)
+			if err != nil {
+				log.LogError(transcodeRequest.RequestID, "error hashing source for C2PA manifest", err)
+			}
+		}
+
 		var concatFiles []string
 		for rendition, segments := range renditionList.RenditionSegmentTable {
 			// Create a single .ts file for a given rendition by concatenating all segments in order
@@ -324,17 +364,25 @@ func RunTranscodeProcess(transcodeRequest TranscodeSegmentRequest, streamName st
 
 				// Add C2PA Signature
 				if transcodeRequest.C2PA != nil {
+					provenance := c2pa2.Provenance{SourceHash: sourceHash}
+					if renditionIndex >= 0 {
+						provenance.Parameters = transcodeProfiles[renditionIndex]
+					}
 					for _, f := range standardMp4OutputFiles {
-						if err := transcodeRequest.C2PA.SignFile(f, f, rendition, transcodeRequest.LocalSourceTmp); err != nil {
+						if err := transcodeRequest.C2PA.SignFileWithProvenance(f, f, rendition, transcodeRequest.LocalSourceTmp, provenance); err != nil {
 							log.LogError(transcodeRequest.RequestID, "error signing C2PA manifest", err, "file", f)
 						}
 					}
 				}
 
 				// Upload the mp4 file
-				mp4Out, err := uploadMp4Files(mp4TargetUrlBase, standardMp4OutputFiles, rendition)
+				namingVars := video.NamingVars{Rendition: rendition}
+				if renditionIndex >= 0 {
+					namingVars.Width, namingVars.Height = transcodeProfiles[renditionIndex].Width, transcodeProfiles[renditionIndex].Height
+				}
+				mp4Out, err := uploadMp4Files(mp4TargetUrlBase, standardMp4OutputFiles, transcodeRequest.OutputNaming.MP4Path(namingVars))
 				if err != nil {
-					return outputs, segmentsCount, fmt.Errorf("error uploading transmuxed standard mp4 file: %s", err)
+					return outputs, segmentsCount, fmt.Errorf("error uploading transmuxed standard mp4 file: %w", err)
 				}
 				mp4OutputsPre = append(mp4OutputsPre, mp4Out...)
 			}
@@ -425,7 +473,7 @@ func RunTranscodeProcess(transcodeRequest TranscodeSegmentRequest, streamName st
 	if transcodeRequest.HlsTargetURL != "" {
 		for _, rendition := range transcodedStats {
 			videoManifestURL := strings.ReplaceAll(rendition.ManifestLocation, hlsTargetURL.String(), hlsPlaybackBaseURL)
-			output.Videos = append(output.Videos, video.OutputVideoFile{Location: videoManifestURL, SizeBytes: rendition.Bytes})
+			output.Videos = append(output.Videos, video.OutputVideoFile{Location: videoManifestURL, SizeBytes: rendition.Bytes, FPS: rendition.FPS})
 		}
 	}
 	output.MP4Outputs = mp4Outputs
@@ -434,31 +482,30 @@ func RunTranscodeProcess(transcodeRequest TranscodeSegmentRequest, streamName st
 	return outputs, segmentsCount, nil
 }
 
-func uploadMp4Files(basePath *url.URL, mp4OutputFiles []string, prefix string) ([]video.OutputVideoFile, error) {
+// uploadMp4Files uploads the files to mp4Path, relative to the base path, or with their own names if it's empty
+func uploadMp4Files(basePath *url.URL, mp4OutputFiles []string, mp4Path string) ([]video.OutputVideoFile, error) {
 	var mp4OutputsPre []video.OutputVideoFile
 	// e. Upload all mp4 related output files
 	for _, o := range mp4OutputFiles {
-		var filename string
-		mp4OutputFile, err := os.Open(o)
+		dir, filename := basePath, filepath.Base(o)
 		defer os.Remove(o)
-		if err != nil {
-			return []video.OutputVideoFile{}, fmt.Errorf("failed to open %s to upload: %s", o, err)
+		if mp4Path != "" {
+			dir, filename = basePath.JoinPath(path.Dir(mp4Path)), path.Base(mp4Path)
 		}
-		if prefix != "" {
-			filename = fmt.Sprintf("%s.mp4", prefix)
-		} else {
-			filename = filepath.Base(mp4OutputFile.Name())
-		}
-		err = backoff.Retry(func() error {
-			return clients.UploadToOSURL(basePath.String(), filename, bufio.NewReader(mp4OutputFile), UploadTimeout)
-		}, clients.UploadRetryBackoff())
+		// large MP4s are uploaded in parts, each retried by the upload itself
+		err := clients.UploadWithBackup(dir.String(), filename, func(osURL string) error {
+			return clients.UploadFileToOSURL(osURL, filename, o, UploadTimeout, nil)
+		})
 		if err != nil {
-			return []video.OutputVideoFile{}, fmt.Errorf("failed to upload %s: %s", mp4OutputFile.Name(), err)
+			return []video.OutputVideoFile{}, fmt.Errorf("failed to upload %s: %w", o, err)
+		}
+		if err := clients.RecordFileChecksum(dir.String(), filename, o); err != nil {
+			log.LogNoRequestID("failed to record MP4 checksum", "file", o, "err", err)
 		}
 
 		mp4Out := video.OutputVideoFile{
 			Type:     "mp4",
-			Location: basePath.JoinPath(filename).String(),
+			Location: dir.JoinPath(filename).String(),
 		}
 		mp4OutputsPre = append(mp4OutputsPre, mp4Out)
 	}
@@ -532,8 +579,16 @@ func transcodeSegment(
 	}
 
 	var tr clients.TranscodeResult
-	var sourceSegment *bytes.Buffer
+	var sourceSegment *clients.SpillBuffer
+	// the segments spilled to disk are removed once they're uploaded, or before they're transcoded again on a retry
+	cleanup := func() {
+		tr.Close()
+		_ = sourceSegment.Close()
+		tr, sourceSegment = clients.TranscodeResult{}, nil
+	}
+	defer cleanup()
 	err := backoff.Retry(func() error {
+		cleanup()
 		ctx, cancel := context.WithTimeout(context.Background(), clients.MaxCopyFileDuration)
 		defer cancel()
 		rc, err := clients.GetFile(ctx, transcodeRequest.RequestID, segment.Input.URL.String(), nil)
@@ -562,7 +617,7 @@ func transcodeSegment(
 			// TODO: failed to run TranscodeSegmentWithRemoteBroadcaster: CreateStream(): http POST(https://origin.livepeer.com/api/stream) returned 422 422 Unprocessable Entity
 			tr, err = broadcasterClient.TranscodeSegmentWithRemoteBroadcaster(r, int64(segment.Index), transcodeProfiles, streamName, segment.Input.DurationMillis)
 			if err != nil {
-				return fmt.Errorf("failed to run TranscodeSegmentWithRemoteBroadcaster: %s", err)
+				return errors.WithFailureReason(errors.FailureBroadcaster, fmt.Errorf("failed to run TranscodeSegmentWithRemoteBroadcaster: %s", err))
 			}
 		} else {
 			transcodeConf := clients.LivepeerTranscodeConfiguration{
@@ -584,7 +639,7 @@ func transcodeSegment(
 			}
 			tr, err = broadcaster.TranscodeSegment(r, int64(segment.Index), segment.Input.DurationMillis, manifestID, transcodeConf)
 			if err != nil {
-				return fmt.Errorf("failed to run TranscodeSegment: %s", err)
+				return errors.WithFailureReason(errors.FailureBroadcaster, fmt.Errorf("failed to run TranscodeSegment: %s", err))
 			}
 		}
 		return nil
@@ -608,17 +663,19 @@ func transcodeSegment(
 // withPipedSource is used to duplicate the reading of the `in` reader in case we need a copy of the contents. If
 // `copySource` is false then the `in` reader is returned as is. Otherwise, then a non-nill buffer will be returned and
 // filled after the returned reader is consumed (if present). If no reader is returned (empty transcodeProfiles) the
-// buffer will be already filled with the contents of the `in` reader.
-func withPipedSource(in io.Reader, copySource bool, transcodeProfiles []video.EncodedProfile) (io.Reader, *bytes.Buffer, error) {
+// buffer will be already filled with the contents of the `in` reader. The buffer spills to disk like the transcoded
+// segments.
+func withPipedSource(in io.Reader, copySource bool, transcodeProfiles []video.EncodedProfile) (io.Reader, *clients.SpillBuffer, error) {
 	if !copySource {
 		return in, nil, nil
 	}
 
-	source := new(bytes.Buffer)
+	source := clients.NewSpillBuffer()
 	if len(transcodeProfiles) == 0 {
 		// This is a copy-only job, so skip transcoding with the broadcaster
 		_, err := io.Copy(source, in)
 		if err != nil {
+			source.Close()
 			return nil, nil, fmt.Errorf("failed to copy source segment: %s", err)
 		}
 		return nil, source, nil
@@ -631,7 +688,7 @@ func withPipedSource(in io.Reader, copySource bool, transcodeProfiles []video.En
 func processTranscodeResult(
 	segment segmentInfo,
 	transcodeRequest TranscodeSegmentRequest,
-	sourceSegment *bytes.Buffer,
+	sourceSegment *clients.SpillBuffer,
 	transcodeResult clients.TranscodeResult,
 	encodedProfiles []video.EncodedProfile,
 	targetOSURL *url.URL,
@@ -640,22 +697,31 @@ func processTranscodeResult(
 	segmentChannel chan<- video.TranscodedSegmentInfo) error {
 
 	for renditionIndex, profile := range encodedProfiles {
-		var mediaData []byte
+		var media *clients.SpillBuffer
 		if profile.Copy {
-			mediaData = sourceSegment.Bytes()
+			media = sourceSegment
 		} else {
 			for _, transcodedSegment := range transcodeResult.Renditions {
 				if transcodedSegment.Name == profile.Name {
-					mediaData = transcodedSegment.MediaData
+					media = transcodedSegment.Segment()
 					break
 				}
 			}
 		}
-		if mediaData == nil {
+		if media == nil {
 			return fmt.Errorf("failed to find rendition with name %q while parsing transcode result", profile.Name)
 		}
+		if filter := transcodeRequest.SourceColor.BitstreamFilter(profile.Encoder); !profile.Copy && filter != "" && transcodeRequest.SourceColor.NeedsTagging() {
+			tagged, err := tagSegmentColor(media, filter)
+			if err != nil {
+				return fmt.Errorf("failed to tag the color of segment %d of profile %s: %w", segment.Index, profile.Name, err)
+			}
+			defer tagged.Close()
+			media = tagged
+		}
 
-		targetRenditionURL, err := url.JoinPath(targetOSURL.String(), profile.Name)
+		segmentPath := transcodeRequest.OutputNaming.SegmentPath(video.NamingVars{Rendition: profile.Name, Width: profile.Width, Height: profile.Height, Index: segment.Index})
+		targetRenditionURL, err := url.JoinPath(targetOSURL.String(), path.Dir(segmentPath))
 		if err != nil {
 			return fmt.Errorf("error building rendition segment URL %q: %s", log.RedactURL(targetRenditionURL), err)
 		}
@@ -667,7 +733,12 @@ func processTranscodeResult(
 				// add new entry for segment # and corresponding byte stream if the profile
 				// exists in the renditionList which contains only profiles for which mp4s will
 				// be generated i.e. all profiles for mp4 inputs and only highest quality
-				// rendition for hls inputs like recordings.
+				// rendition for hls inputs like recordings. These segments are held in memory until they're
+				// written to disk for transmuxing, so a spilled segment is read back.
+				mediaData, err := media.Bytes()
+				if err != nil {
+					return fmt.Errorf("failed to read segment %d of profile %s: %w", segment.Index, profile.Name, err)
+				}
 				segmentsList.AddSegmentData(segment.Index, mediaData)
 
 				// send this transcoded segment to the segment channel so that it can be written
@@ -681,15 +752,29 @@ func processTranscodeResult(
 			}
 		}
 
-		err = backoff.Retry(func() error {
-			return clients.UploadToOSURL(targetRenditionURL, fmt.Sprintf("%d.ts", segment.Index), bytes.NewReader(mediaData), UploadTimeout)
-		}, clients.UploadRetryBackoff())
+		uploaded := media
+		if transcodeRequest.HLSEncryption != nil {
+			encrypted, err := encryptSegment(media, transcodeRequest.HLSEncryption, segment.Index)
+			if err != nil {
+				return fmt.Errorf("failed to encrypt segment %d of profile %s: %w", segment.Index, profile.Name, err)
+			}
+			defer encrypted.Close()
+			uploaded = encrypted
+		}
+
+		segmentFilename := path.Base(segmentPath)
+		err = clients.UploadWithBackup(targetRenditionURL, segmentFilename, func(osURL string) error {
+			return clients.UploadToOSURL(osURL, segmentFilename, uploaded.NewReader(), UploadTimeout)
+		})
 		if err != nil {
 			return fmt.Errorf("failed to upload segment %d of profile %s: %w", segment.Index, profile.Name, err)
 		}
+		if err := clients.RecordReaderChecksum(targetRenditionURL, segmentFilename, uploaded.NewReader()); err != nil {
+			return fmt.Errorf("failed to compute the checksum of segment %d of profile %s: %w", segment.Index, profile.Name, err)
+		}
 
 		// bitrate calculation
-		transcodedStats[renditionIndex].Bytes += int64(len(mediaData))
+		transcodedStats[renditionIndex].Bytes += int64(media.Len())
 		transcodedStats[renditionIndex].DurationMs += float64(segment.Input.DurationMillis)
 	}
 
@@ -700,6 +785,28 @@ func processTranscodeResult(
 	return nil
 }
 
+// tagSegmentColor writes the source's colorimetry into a transcoded segment, which the broadcaster doesn't carry over, so
+// that e.g. the renditions of BT.2020 sources aren't displayed washed out as BT.709
+func tagSegmentColor(media *clients.SpillBuffer, filter string) (*clients.SpillBuffer, error) {
+	tagged := clients.NewSpillBuffer()
+	if err := video.TagColor(media.NewReader(), tagged, filter); err != nil {
+		tagged.Close()
+		return nil, err
+	}
+	return tagged, nil
+}
+
+// encryptSegment encrypts a segment of an encrypted HLS output, whose IV is its media sequence number as the playlists'
+// #EXT-X-KEY has none
+func encryptSegment(media *clients.SpillBuffer, encryption *video.HLSEncryption, index int) (*clients.SpillBuffer, error) {
+	encrypted := clients.NewSpillBuffer()
+	if err := encryption.EncryptSegment(index, media.NewReader(), encrypted); err != nil {
+		encrypted.Close()
+		return nil, err
+	}
+	return encrypted, nil
+}
+
 func getProfileIndex(transcodeProfiles []video.EncodedProfile, profile string) int {
 	for i, p := range transcodeProfiles {
 		if p.Name == profile {
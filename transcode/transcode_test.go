@@ -20,6 +20,7 @@ import (
 	"github.com/grafov/m3u8"
 	"github.com/livepeer/catalyst-api/clients"
 	"github.com/livepeer/catalyst-api/config"
+	"github.com/livepeer/catalyst-api/crypto"
 	"github.com/livepeer/catalyst-api/video"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -195,6 +196,19 @@ low-bitrate/index.m3u8
 	require.Equal(t, 2, len(outputs[0].Videos))
 }
 
+func newSpillBuffer(t *testing.T, content string) *clients.SpillBuffer {
+	b := clients.NewSpillBuffer()
+	_, err := b.Write([]byte(content))
+	require.NoError(t, err)
+	return b
+}
+
+func spillBufferString(t *testing.T, b *clients.SpillBuffer) string {
+	content, err := b.Bytes()
+	require.NoError(t, err)
+	return string(content)
+}
+
 func TestProcessTranscodeResult(t *testing.T) {
 	dir := filepath.Join(testDataDir, "process-transcode-result")
 	err := os.MkdirAll(dir, os.ModePerm)
@@ -204,7 +218,7 @@ func TestProcessTranscodeResult(t *testing.T) {
 		name                       string
 		segment                    segmentInfo
 		transcodeRequest           TranscodeSegmentRequest
-		sourceSegment              *bytes.Buffer
+		sourceSegment              *clients.SpillBuffer
 		transcodeResult            clients.TranscodeResult
 		encodedProfiles            []video.EncodedProfile
 		targetOSURL                *url.URL
@@ -217,7 +231,7 @@ func TestProcessTranscodeResult(t *testing.T) {
 			name:             "Error when profile not found",
 			segment:          segmentInfo{Index: 0, IsLastSegment: false},
 			transcodeRequest: TranscodeSegmentRequest{IsClip: false, RequestID: "request-id"},
-			sourceSegment:    bytes.NewBuffer([]byte("source data")),
+			sourceSegment:    newSpillBuffer(t, "source data"),
 			transcodeResult: clients.TranscodeResult{
 				Renditions: []*clients.RenditionSegment{{Name: "profile2", MediaData: []byte("media data")}},
 			},
@@ -231,7 +245,7 @@ func TestProcessTranscodeResult(t *testing.T) {
 			name:             "Successful with only copy profile",
 			segment:          segmentInfo{Index: 0, IsLastSegment: false, Input: clients.SourceSegment{DurationMillis: 4000}},
 			transcodeRequest: TranscodeSegmentRequest{IsClip: false, RequestID: "request-id"},
-			sourceSegment:    bytes.NewBuffer([]byte("source data")),
+			sourceSegment:    newSpillBuffer(t, "source data"),
 			transcodeResult:  clients.TranscodeResult{},
 			encodedProfiles:  []video.EncodedProfile{{Name: "profile1", Copy: true}},
 			targetOSURL:      &url.URL{Scheme: "file", Path: dir},
@@ -248,7 +262,7 @@ func TestProcessTranscodeResult(t *testing.T) {
 			name:             "Successful with transcode profiles",
 			segment:          segmentInfo{Index: 0, IsLastSegment: false, Input: clients.SourceSegment{DurationMillis: 4000}},
 			transcodeRequest: TranscodeSegmentRequest{IsClip: false, RequestID: "request-id"},
-			sourceSegment:    bytes.NewBuffer([]byte("source data")),
+			sourceSegment:    newSpillBuffer(t, "source data"),
 			transcodeResult: clients.TranscodeResult{
 				Renditions: []*clients.RenditionSegment{
 					{Name: "profile1", MediaData: []byte("media data")},
@@ -284,7 +298,7 @@ func TestProcessTranscodeResult(t *testing.T) {
 			name:             "Successful with copy and transcode profiles",
 			segment:          segmentInfo{Index: 0, IsLastSegment: false, Input: clients.SourceSegment{DurationMillis: 4000}},
 			transcodeRequest: TranscodeSegmentRequest{IsClip: false, RequestID: "request-id"},
-			sourceSegment:    bytes.NewBuffer([]byte("source data")),
+			sourceSegment:    newSpillBuffer(t, "source data"),
 			transcodeResult: clients.TranscodeResult{
 				Renditions: []*clients.RenditionSegment{
 					{Name: "profile1", MediaData: []byte("media data")},
@@ -329,7 +343,7 @@ func TestProcessTranscodeResult(t *testing.T) {
 			name:             "Propagates segments for mp4 generation",
 			segment:          segmentInfo{Index: 0, IsLastSegment: false, Input: clients.SourceSegment{DurationMillis: 4000}},
 			transcodeRequest: TranscodeSegmentRequest{IsClip: false, RequestID: "request-id", GenerateMP4: true},
-			sourceSegment:    bytes.NewBuffer([]byte("source data")),
+			sourceSegment:    newSpillBuffer(t, "source data"),
 			transcodeResult: clients.TranscodeResult{
 				Renditions: []*clients.RenditionSegment{
 					{Name: "profile1", MediaData: []byte("media data")},
@@ -451,6 +465,34 @@ func TestProcessTranscodeResult(t *testing.T) {
 	}
 }
 
+func TestProcessTranscodeResultEncryptsHLSSegments(t *testing.T) {
+	dir := filepath.Join(testDataDir, "process-transcode-result-encrypted")
+	require.NoError(t, os.MkdirAll(dir, os.ModePerm))
+	encryption := &video.HLSEncryption{PlaybackID: "abc123", Key: crypto.DeriveHLSKey([]byte("secret"), "abc123")}
+
+	transcodedStats := []*video.RenditionStats{{Name: "profile1"}}
+	err := processTranscodeResult(
+		segmentInfo{Index: 2, Input: clients.SourceSegment{DurationMillis: 4000}},
+		TranscodeSegmentRequest{RequestID: "request-id", HLSEncryption: encryption},
+		nil,
+		clients.TranscodeResult{Renditions: []*clients.RenditionSegment{{Name: "profile1", MediaData: []byte("media data")}}},
+		[]video.EncodedProfile{{Name: "profile1"}},
+		&url.URL{Scheme: "file", Path: dir},
+		transcodedStats,
+		&video.TRenditionList{RenditionSegmentTable: map[string]*video.TSegmentList{}},
+		make(chan video.TranscodedSegmentInfo, 1),
+	)
+	require.NoError(t, err)
+
+	// the uploaded segment is encrypted with the segment's index as its IV, the stats are of the plaintext
+	var expected bytes.Buffer
+	require.NoError(t, crypto.EncryptHLSSegment(encryption.Key, 2, strings.NewReader("media data"), &expected))
+	uploaded, err := os.ReadFile(filepath.Join(dir, "profile1", "2.ts"))
+	require.NoError(t, err)
+	require.Equal(t, expected.Bytes(), uploaded)
+	require.Equal(t, int64(10), transcodedStats[0].Bytes)
+}
+
 func TestItCalculatesTheTranscodeCompletionPercentageCorrectly(t *testing.T) {
 	require.Equal(t, 0.5, calculateCompletedRatio(2, 1))
 	require.Equal(t, 0.5, calculateCompletedRatio(4, 2))
@@ -717,7 +759,7 @@ func TestWithPipedSource(t *testing.T) {
 		reader, buffer, err := withPipedSource(in, true, nil)
 		require.NoError(err)
 		require.Equal(nil, reader)
-		require.Equal("hello", buffer.String())
+		require.Equal("hello", spillBufferString(t, buffer))
 
 		// check input was consumed
 		require.Equal(0, in.Len())
@@ -741,7 +783,7 @@ func TestWithPipedSource(t *testing.T) {
 		require.NoError(err)
 		require.Equal(2, n)
 		require.Equal("he", string(buf))
-		require.Equal("he", buffer.String())
+		require.Equal("he", spillBufferString(t, buffer))
 		require.Equal(originalLen-2, in.Len())
 	})
 }
@@ -0,0 +1,76 @@
+package video
+
+import (
+	"fmt"
+
+	"github.com/grafov/m3u8"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// AudioGroupID is the GROUP-ID of the audio of the renditions in the master playlists of jobs with AudioOptions
+const AudioGroupID = "audio"
+
+// AudioOptions change the audio of a VOD job's renditions. They're applied when the source is segmented, before it's
+// transcoded, so that every rendition has the same audio.
+type AudioOptions struct {
+	// Downmix audio with more than two channels, e.g. 5.1, to stereo
+	Downmix bool `json:"downmix,omitempty"`
+	// Track is the index of the audio track to keep among the source's audio tracks, from 0. If nil, the track ffmpeg
+	// picks is kept, the one with the most channels.
+	Track *int `json:"track,omitempty"`
+	// Drop the audio, so that the renditions are video only
+	Drop bool `json:"drop,omitempty"`
+}
+
+func (a *AudioOptions) Validate() error {
+	if a == nil {
+		return nil
+	}
+	if a.Track != nil && *a.Track < 0 {
+		return fmt.Errorf("invalid audio track %d", *a.Track)
+	}
+	if a.Drop && (a.Downmix || a.Track != nil) {
+		return fmt.Errorf("audio can't be both dropped and downmixed or selected")
+	}
+	return nil
+}
+
+// segmentArgs adds the options to the arguments of the ffmpeg command that segments the source
+func (a *AudioOptions) segmentArgs(args ffmpeg.KwArgs) {
+	if a == nil {
+		return
+	}
+	if a.Drop {
+		args["an"] = ""
+		delete(args, "c:a")
+		return
+	}
+	if a.Track != nil {
+		args["map"] = []string{"0:v:0", fmt.Sprintf("0:a:%d", *a.Track)}
+	}
+	if a.Downmix {
+		args["ac"] = 2
+	}
+}
+
+// Alternative describes the audio of the renditions as the EXT-X-MEDIA entry of their audio group. The audio is muxed
+// in the renditions, so the entry has no URI. Jobs without options and jobs whose audio was dropped have no audio group.
+func (a *AudioOptions) Alternative() *m3u8.Alternative {
+	if a == nil || a.Drop || *a == (AudioOptions{}) {
+		return nil
+	}
+	name := "Main"
+	if a.Downmix {
+		name = "Stereo"
+	}
+	if a.Track != nil {
+		name = fmt.Sprintf("%s (track %d)", name, *a.Track)
+	}
+	return &m3u8.Alternative{
+		GroupId:    AudioGroupID,
+		Type:       "AUDIO",
+		Name:       name,
+		Default:    true,
+		Autoselect: "YES",
+	}
+}
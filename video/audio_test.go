@@ -0,0 +1,43 @@
+package video
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+func TestValidateAudioOptions(t *testing.T) {
+	track, negative := 1, -1
+	require.NoError(t, (*AudioOptions)(nil).Validate())
+	require.NoError(t, (&AudioOptions{Downmix: true, Track: &track}).Validate())
+	require.NoError(t, (&AudioOptions{Drop: true}).Validate())
+	require.Error(t, (&AudioOptions{Track: &negative}).Validate())
+	require.Error(t, (&AudioOptions{Drop: true, Downmix: true}).Validate())
+	require.Error(t, (&AudioOptions{Drop: true, Track: &track}).Validate())
+}
+
+func TestAudioOptionsSegmentArgs(t *testing.T) {
+	segmentArgs := func(a *AudioOptions) ffmpeg.KwArgs {
+		args := ffmpeg.KwArgs{"c:a": "aac", "c:v": "copy"}
+		a.segmentArgs(args)
+		return args
+	}
+	track := 2
+
+	require.Equal(t, ffmpeg.KwArgs{"c:a": "aac", "c:v": "copy"}, segmentArgs(nil))
+	require.Equal(t, ffmpeg.KwArgs{"c:a": "aac", "c:v": "copy", "ac": 2, "map": []string{"0:v:0", "0:a:2"}}, segmentArgs(&AudioOptions{Downmix: true, Track: &track}))
+	require.Equal(t, ffmpeg.KwArgs{"c:v": "copy", "an": ""}, segmentArgs(&AudioOptions{Drop: true}))
+}
+
+func TestAudioOptionsAlternative(t *testing.T) {
+	track := 1
+	require.Nil(t, (*AudioOptions)(nil).Alternative())
+	require.Nil(t, (&AudioOptions{}).Alternative())
+	require.Nil(t, (&AudioOptions{Drop: true}).Alternative())
+	require.Equal(t, "Stereo", (&AudioOptions{Downmix: true}).Alternative().Name)
+	alt := (&AudioOptions{Track: &track}).Alternative()
+	require.Equal(t, "Main (track 1)", alt.Name)
+	require.Equal(t, AudioGroupID, alt.GroupId)
+	require.Equal(t, "AUDIO", alt.Type)
+}
@@ -0,0 +1,131 @@
+package video
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// HLS VIDEO-RANGE of the renditions of HDR sources, SDR being the default when it's omitted
+const (
+	VideoRangePQ  = "PQ"
+	VideoRangeHLG = "HLG"
+)
+
+// ColorMetadata is the colorimetry of a video stream, as named by ffprobe
+type ColorMetadata struct {
+	Primaries string
+	Transfer  string
+	Matrix    string
+	Range     string
+}
+
+// Color returns the colorimetry the track was probed with
+func (t VideoTrack) Color() ColorMetadata {
+	return ColorMetadata{Primaries: t.ColorPrimaries, Transfer: t.ColorTransfer, Matrix: t.ColorSpace, Range: t.ColorRange}
+}
+
+// VideoRange is the HLS VIDEO-RANGE of video with this transfer characteristic, empty for SDR
+func (c ColorMetadata) VideoRange() string {
+	switch c.Transfer {
+	case "smpte2084":
+		return VideoRangePQ
+	case "arib-std-b67":
+		return VideoRangeHLG
+	}
+	return ""
+}
+
+// NeedsTagging is whether the colorimetry differs from the BT.709 that players assume for untagged video, so that it
+// has to be written into the transcoded renditions for them to be displayed with the source's colors
+func (c ColorMetadata) NeedsTagging() bool {
+	for _, v := range []string{c.Primaries, c.Transfer, c.Matrix} {
+		if _, ok := colorCodePoints[v]; ok && v != "bt709" {
+			return true
+		}
+	}
+	return c.Range == "pc"
+}
+
+// colorCodePoints are the ITU-T H.273 code points of ffprobe's color names, shared by the primaries, transfer
+// characteristics and matrix coefficients where the names are the same
+var colorCodePoints = map[string]int{
+	"bt709":        1,
+	"bt470bg":      5,
+	"smpte170m":    6,
+	"bt2020":       9,
+	"bt2020nc":     9,
+	"bt2020c":      10,
+	"iec61966-2-1": 13,
+	"bt2020-10":    14,
+	"bt2020-12":    15,
+	"smpte2084":    16,
+	"arib-std-b67": 18,
+}
+
+// BitstreamFilter returns the ffmpeg bitstream filter that writes the colorimetry into the VUI of H.264 or HEVC video
+// without re-encoding it, or an empty string if the codec isn't one of them or there's nothing to write
+func (c ColorMetadata) BitstreamFilter(encoder string) string {
+	var filter string
+	switch strings.ToUpper(encoder) {
+	case "", "H264":
+		filter = "h264_metadata"
+	case "HEVC", "H265":
+		filter = "hevc_metadata"
+	default:
+		return ""
+	}
+	var opts []string
+	if code, ok := colorCodePoints[c.Primaries]; ok && c.Primaries != "bt2020nc" && c.Primaries != "bt2020c" {
+		opts = append(opts, fmt.Sprintf("colour_primaries=%d", code))
+	}
+	if code, ok := colorCodePoints[c.Transfer]; ok {
+		opts = append(opts, fmt.Sprintf("transfer_characteristics=%d", code))
+	}
+	if code, ok := colorCodePoints[c.Matrix]; ok && c.Matrix != "bt2020" {
+		opts = append(opts, fmt.Sprintf("matrix_coefficients=%d", code))
+	}
+	switch c.Range {
+	case "tv":
+		opts = append(opts, "video_full_range_flag=0")
+	case "pc":
+		opts = append(opts, "video_full_range_flag=1")
+	}
+	if len(opts) == 0 {
+		return ""
+	}
+	return filter + "=" + strings.Join(opts, ":")
+}
+
+// TagColor copies an MPEG-TS segment through the bitstream filter returned by BitstreamFilter
+func TagColor(in io.Reader, out io.Writer, filter string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "ffmpeg",
+		"-loglevel", "error",
+		"-i", "pipe:0",
+		"-map", "0",
+		"-c", "copy",
+		"-bsf:v", filter,
+		"-f", "mpegts",
+		"pipe:1",
+	)
+	var stderr bytes.Buffer
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = in, out, &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to tag segment color [%s]: %w", stderr.String(), err)
+	}
+	return nil
+}
+
+// known drops the values ffprobe reports when the stream doesn't specify its colorimetry
+func known(v string) string {
+	if v == "unknown" || v == "unspecified" || v == "reserved" {
+		return ""
+	}
+	return v
+}
@@ -0,0 +1,38 @@
+package video
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestColorMetadata(t *testing.T) {
+	pq := ColorMetadata{Primaries: "bt2020", Transfer: "smpte2084", Matrix: "bt2020nc", Range: "tv"}
+	require.Equal(t, VideoRangePQ, pq.VideoRange())
+	require.True(t, pq.NeedsTagging())
+	require.Equal(t, "h264_metadata=colour_primaries=9:transfer_characteristics=16:matrix_coefficients=9:video_full_range_flag=0", pq.BitstreamFilter(""))
+	require.Equal(t, "hevc_metadata=colour_primaries=9:transfer_characteristics=16:matrix_coefficients=9:video_full_range_flag=0", pq.BitstreamFilter("HEVC"))
+	require.Equal(t, "", pq.BitstreamFilter("VP9"))
+
+	hlg := ColorMetadata{Primaries: "bt2020", Transfer: "arib-std-b67", Matrix: "bt2020nc"}
+	require.Equal(t, VideoRangeHLG, hlg.VideoRange())
+
+	sdr := ColorMetadata{Primaries: "bt709", Transfer: "bt709", Matrix: "bt709", Range: "tv"}
+	require.Equal(t, "", sdr.VideoRange())
+	require.False(t, sdr.NeedsTagging())
+	require.False(t, ColorMetadata{Matrix: "unknown"}.NeedsTagging())
+	require.Equal(t, "", ColorMetadata{}.BitstreamFilter("H264"))
+
+	// SD sources are tagged BT.601, which players would otherwise display as BT.709
+	require.True(t, ColorMetadata{Primaries: "smpte170m", Matrix: "smpte170m"}.NeedsTagging())
+	require.True(t, ColorMetadata{Range: "pc"}.NeedsTagging())
+}
+
+func TestHDRFromTransfer(t *testing.T) {
+	require.Equal(t, HDR10, hdrFromTransfer("", "smpte2084"))
+	require.Equal(t, HDR10, hdrFromTransfer(HDRWideGamut, "smpte2084"))
+	require.Equal(t, HDRHLG, hdrFromTransfer(HDRWideGamut, "arib-std-b67"))
+	require.Equal(t, HDRDolbyVision, hdrFromTransfer(HDRDolbyVision, "smpte2084"))
+	require.Equal(t, HDRWideGamut, hdrFromTransfer(HDRWideGamut, "bt2020-10"))
+	require.Equal(t, "", hdrFromTransfer("", "bt709"))
+}
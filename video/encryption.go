@@ -0,0 +1,35 @@
+package video
+
+import (
+	"io"
+	"net/url"
+
+	"github.com/livepeer/catalyst-api/crypto"
+)
+
+// HLSEncryptionMethod is the #EXT-X-KEY method of encrypted HLS outputs
+const HLSEncryptionMethod = "AES-128"
+
+// HLSEncryption encrypts the segments of a VOD job's HLS output with the key of its asset, served to the players by
+// the key server at /asset/keys/<playback_id>
+type HLSEncryption struct {
+	// PlaybackID of the asset, which its key is derived from
+	PlaybackID string `json:"playback_id"`
+	// KeyURI is the #EXT-X-KEY URI of the rendition playlists, /asset/keys/<playback_id> on the playlists' host if empty
+	KeyURI string `json:"key_uri,omitempty"`
+	// Key is derived from the playback ID when the job is submitted
+	Key []byte `json:"-"`
+}
+
+// URI is where players fetch the key from
+func (e *HLSEncryption) URI() string {
+	if e.KeyURI != "" {
+		return e.KeyURI
+	}
+	return "/asset/keys/" + url.PathEscape(e.PlaybackID)
+}
+
+// EncryptSegment encrypts the segment with the given index, which is its media sequence number in the playlists
+func (e *HLSEncryption) EncryptSegment(index int, r io.Reader, w io.Writer) error {
+	return crypto.EncryptHLSSegment(e.Key, uint64(index), r, w)
+}
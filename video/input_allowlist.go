@@ -0,0 +1,60 @@
+package video
+
+import (
+	"fmt"
+	"strings"
+)
+
+// InputAllowlist restricts the sources that VOD jobs accept, so that unsupported inputs are rejected once they're
+// probed rather than failing part of the way through the pipeline. Empty lists and zero limits allow anything.
+type InputAllowlist struct {
+	// Containers as named by ffprobe, e.g. mp4 (which includes mov), hls, matroska or mpegts
+	Containers       []string
+	VideoCodecs      []string
+	AudioCodecs      []string
+	MaxColorBitDepth int
+	MaxAudioChannels int
+	RejectHDR        bool
+}
+
+// SourceAllowlist is checked against the probe of every VOD source, set with the -input-* flags
+var SourceAllowlist InputAllowlist
+
+// Check returns an error naming the first property of the input that isn't allowed
+func (a InputAllowlist) Check(iv InputVideo) error {
+	if !allowed(a.Containers, iv.Format) {
+		return fmt.Errorf("unsupported input: container %q is not allowed, expected one of %s", iv.Format, strings.Join(a.Containers, ", "))
+	}
+	if videoTrack, err := iv.GetTrack(TrackTypeVideo); err == nil {
+		if !allowed(a.VideoCodecs, videoTrack.Codec) {
+			return fmt.Errorf("unsupported input: video codec %q is not allowed, expected one of %s", videoTrack.Codec, strings.Join(a.VideoCodecs, ", "))
+		}
+		if a.MaxColorBitDepth > 0 && videoTrack.ColorBitDepth > a.MaxColorBitDepth {
+			return fmt.Errorf("unsupported input: video bit depth %d is above the maximum of %d", videoTrack.ColorBitDepth, a.MaxColorBitDepth)
+		}
+		if a.RejectHDR && videoTrack.HDR != "" {
+			return fmt.Errorf("unsupported input: HDR video (%s) is not allowed", videoTrack.HDR)
+		}
+	}
+	if audioTrack, err := iv.GetTrack(TrackTypeAudio); err == nil {
+		if !allowed(a.AudioCodecs, audioTrack.Codec) {
+			return fmt.Errorf("unsupported input: audio codec %q is not allowed, expected one of %s", audioTrack.Codec, strings.Join(a.AudioCodecs, ", "))
+		}
+		if a.MaxAudioChannels > 0 && audioTrack.Channels > a.MaxAudioChannels {
+			return fmt.Errorf("unsupported input: %d audio channels (%s) are above the maximum of %d", audioTrack.Channels, audioTrack.ChannelLayout, a.MaxAudioChannels)
+		}
+	}
+	return nil
+}
+
+func allowed(list []string, value string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, v := range list {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
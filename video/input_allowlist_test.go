@@ -0,0 +1,44 @@
+package video
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInputAllowlist(t *testing.T) {
+	iv := InputVideo{
+		Format: "mp4",
+		Tracks: []InputTrack{
+			{
+				Type:       TrackTypeVideo,
+				Codec:      "hevc",
+				VideoTrack: VideoTrack{ColorBitDepth: 10, HDR: HDR10},
+			},
+			{
+				Type:       TrackTypeAudio,
+				Codec:      "aac",
+				AudioTrack: AudioTrack{Channels: 6, ChannelLayout: "5.1(side)"},
+			},
+		},
+	}
+
+	require.NoError(t, InputAllowlist{}.Check(iv))
+	require.NoError(t, InputAllowlist{
+		Containers:       []string{"mp4", "hls"},
+		VideoCodecs:      []string{"h264", "HEVC"},
+		AudioCodecs:      []string{"aac"},
+		MaxColorBitDepth: 10,
+		MaxAudioChannels: 6,
+	}.Check(iv))
+
+	require.EqualError(t, InputAllowlist{Containers: []string{"hls"}}.Check(iv), `unsupported input: container "mp4" is not allowed, expected one of hls`)
+	require.EqualError(t, InputAllowlist{VideoCodecs: []string{"h264", "vp9"}}.Check(iv), `unsupported input: video codec "hevc" is not allowed, expected one of h264, vp9`)
+	require.EqualError(t, InputAllowlist{MaxColorBitDepth: 8}.Check(iv), "unsupported input: video bit depth 10 is above the maximum of 8")
+	require.EqualError(t, InputAllowlist{RejectHDR: true}.Check(iv), "unsupported input: HDR video (hdr10) is not allowed")
+	require.EqualError(t, InputAllowlist{AudioCodecs: []string{"opus"}}.Check(iv), `unsupported input: audio codec "aac" is not allowed, expected one of opus`)
+	require.EqualError(t, InputAllowlist{MaxAudioChannels: 2}.Check(iv), "unsupported input: 6 audio channels (5.1(side)) are above the maximum of 2")
+
+	// the track limits only apply to the tracks the input has
+	require.NoError(t, InputAllowlist{VideoCodecs: []string{"h264"}}.Check(InputVideo{Tracks: iv.Tracks[1:]}))
+}
@@ -0,0 +1,89 @@
+package video
+
+import (
+	"strings"
+)
+
+// Kinds of corrupt source files, told apart by the errors ffprobe reports for them
+const (
+	// CorruptionTruncated is a file that ends early, usually because its upload didn't finish
+	CorruptionTruncated = "truncated"
+	// CorruptionInvalid is a file that isn't media ffprobe can read, or whose data is damaged
+	CorruptionInvalid = "invalid"
+)
+
+type corruptionPattern struct {
+	// match is a lowercase substring of ffprobe's error
+	match   string
+	kind    string
+	message string
+}
+
+// corruptionPatterns are checked in order, so the most specific patterns come first
+var corruptionPatterns = []corruptionPattern{
+	{
+		match:   "moov atom not found",
+		kind:    CorruptionTruncated,
+		message: "the MP4 file is incomplete, its index (moov atom) is missing. This usually means the upload didn't finish, or the recording was stopped before it was finalised - upload the complete file again",
+	},
+	{
+		match:   "partial file",
+		kind:    CorruptionTruncated,
+		message: "the file is incomplete, it ends before the end of its media data. Upload the complete file again",
+	},
+	{
+		match:   "stream ends prematurely",
+		kind:    CorruptionTruncated,
+		message: "the file is incomplete, it ends before the end of its media data. Upload the complete file again",
+	},
+	{
+		match:   "end of file",
+		kind:    CorruptionTruncated,
+		message: "the file is incomplete, it ends before the end of its media data. Upload the complete file again",
+	},
+	{
+		match:   "invalid data found when processing input",
+		kind:    CorruptionInvalid,
+		message: "the file isn't a media file that can be read, or its container is damaged. Check that the file plays, and re-export it if it doesn't",
+	},
+	{
+		match:   "invalid nal unit size",
+		kind:    CorruptionInvalid,
+		message: "the video data of the file is damaged. Check that the file plays, and re-export it if it doesn't",
+	},
+	{
+		match:   "error splitting the input into nal units",
+		kind:    CorruptionInvalid,
+		message: "the video data of the file is damaged. Check that the file plays, and re-export it if it doesn't",
+	},
+}
+
+// CorruptSourceError is a probe error classified as a known kind of corruption, with a message the uploader can act on
+type CorruptSourceError struct {
+	Kind    string
+	Message string
+	Err     error
+}
+
+func (e CorruptSourceError) Error() string {
+	return "corrupt source: " + e.Message
+}
+
+func (e CorruptSourceError) Unwrap() error {
+	return e.Err
+}
+
+// ClassifyProbeError returns a CorruptSourceError if the error of probing a source shows that the file is truncated or
+// damaged, or nil if it doesn't, e.g. when the source couldn't be fetched
+func ClassifyProbeError(err error) *CorruptSourceError {
+	if err == nil {
+		return nil
+	}
+	msg := strings.ToLower(err.Error())
+	for _, p := range corruptionPatterns {
+		if strings.Contains(msg, p.match) {
+			return &CorruptSourceError{Kind: p.kind, Message: p.message, Err: err}
+		}
+	}
+	return nil
+}
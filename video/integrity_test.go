@@ -0,0 +1,25 @@
+package video
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyProbeError(t *testing.T) {
+	require.Nil(t, ClassifyProbeError(nil))
+	require.Nil(t, ClassifyProbeError(errors.New("error running ffprobe [Connection refused] exit status 1")))
+
+	probeErr := fmt.Errorf("error probing: %w", errors.New("error running ffprobe [[mov,mp4,m4a,3gp,3g2,mj2 @ 0x1] moov atom not found\nin.mp4: Invalid data found when processing input] exit status 1"))
+	corrupt := ClassifyProbeError(probeErr)
+	require.NotNil(t, corrupt)
+	require.Equal(t, CorruptionTruncated, corrupt.Kind)
+	require.ErrorIs(t, corrupt, probeErr)
+	require.Contains(t, corrupt.Error(), "corrupt source: the MP4 file is incomplete")
+
+	corrupt = ClassifyProbeError(errors.New("ffprobe error: [h264 @ 0x1] Invalid NAL unit size (1234 > 56)."))
+	require.NotNil(t, corrupt)
+	require.Equal(t, CorruptionInvalid, corrupt.Kind)
+}
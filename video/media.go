@@ -91,6 +91,8 @@ type RenditionStats struct {
 	DurationMs       float64
 	ManifestLocation string
 	BitsPerSecond    uint32
+	// VideoRange is the HLS VIDEO-RANGE of the rendition, empty for SDR
+	VideoRange string
 }
 
 type TranscodedSegmentInfo struct {
@@ -0,0 +1,133 @@
+package video
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The default layout of a VOD job's outputs
+const (
+	DefaultSegmentTemplate  = "{rendition}/{index}.ts"
+	DefaultPlaylistTemplate = "{rendition}/index.m3u8"
+	DefaultMP4Template      = "{rendition}.mp4"
+)
+
+// OutputNaming templates the paths of a VOD job's outputs, relative to their output location. The templates can use
+// the placeholders {rendition}, {width} and {height}, and {index}, the number of a segment, in the segment template
+// only. Empty templates keep the default layout. The master playlist is always index.m3u8 at the root of the HLS output.
+type OutputNaming struct {
+	Segment  string `json:"segment,omitempty"`
+	Playlist string `json:"playlist,omitempty"`
+	MP4      string `json:"mp4,omitempty"`
+}
+
+// NamingVars are the values of the placeholders of the templates
+type NamingVars struct {
+	Rendition string
+	Width     int64
+	Height    int64
+	Index     int
+}
+
+var placeholderRegexp = regexp.MustCompile(`\{[^{}]*\}`)
+
+func (n *OutputNaming) Validate() error {
+	if n == nil {
+		return nil
+	}
+	templates := []struct {
+		name, template, ext string
+		required            []string
+	}{
+		{"segment", n.Segment, ".ts", []string{"{rendition}", "{index}"}},
+		{"playlist", n.Playlist, ".m3u8", []string{"{rendition}"}},
+		{"mp4", n.MP4, ".mp4", []string{"{rendition}"}},
+	}
+	for _, t := range templates {
+		if t.template == "" {
+			continue
+		}
+		if err := validateTemplate(t.template, t.ext, t.required, t.name == "segment"); err != nil {
+			return fmt.Errorf("invalid %s template %q: %w", t.name, t.template, err)
+		}
+	}
+	return nil
+}
+
+func validateTemplate(template, ext string, required []string, withIndex bool) error {
+	for _, placeholder := range placeholderRegexp.FindAllString(template, -1) {
+		switch placeholder {
+		case "{rendition}", "{width}", "{height}":
+		case "{index}":
+			if !withIndex {
+				return fmt.Errorf("{index} can only be used in segment templates")
+			}
+		default:
+			return fmt.Errorf("unknown placeholder %s", placeholder)
+		}
+	}
+	// every rendition, and every segment, needs a path of its own
+	for _, placeholder := range required {
+		if !strings.Contains(template, placeholder) {
+			return fmt.Errorf("missing %s", placeholder)
+		}
+	}
+	if !strings.HasSuffix(template, ext) {
+		return fmt.Errorf("should end with %s", ext)
+	}
+	if strings.ContainsAny(template, `?#\`) || strings.HasPrefix(template, "/") || path.Clean(template) != template {
+		return fmt.Errorf("should be a relative path without special characters")
+	}
+	for _, element := range strings.Split(template, "/") {
+		if element == ".." {
+			return fmt.Errorf("should be a relative path without special characters")
+		}
+	}
+	return nil
+}
+
+// SegmentPath is the path of a rendition's segment, relative to the HLS output
+func (n *OutputNaming) SegmentPath(v NamingVars) string {
+	if n == nil || n.Segment == "" {
+		return expand(DefaultSegmentTemplate, v)
+	}
+	return expand(n.Segment, v)
+}
+
+// PlaylistPath is the path of a rendition's playlist, relative to the HLS output
+func (n *OutputNaming) PlaylistPath(v NamingVars) string {
+	if n == nil || n.Playlist == "" {
+		return expand(DefaultPlaylistTemplate, v)
+	}
+	return expand(n.Playlist, v)
+}
+
+// MP4Path is the path of a rendition's MP4, relative to the MP4 output
+func (n *OutputNaming) MP4Path(v NamingVars) string {
+	if n == nil || n.MP4 == "" {
+		return expand(DefaultMP4Template, v)
+	}
+	return expand(n.MP4, v)
+}
+
+// SegmentURI is the URI of a segment in its rendition's playlist, relative to the playlist
+func (n *OutputNaming) SegmentURI(v NamingVars) string {
+	uri, err := filepath.Rel(path.Dir(n.PlaylistPath(v)), n.SegmentPath(v))
+	if err != nil {
+		return n.SegmentPath(v)
+	}
+	return filepath.ToSlash(uri)
+}
+
+func expand(template string, v NamingVars) string {
+	return strings.NewReplacer(
+		"{rendition}", v.Rendition,
+		"{width}", strconv.FormatInt(v.Width, 10),
+		"{height}", strconv.FormatInt(v.Height, 10),
+		"{index}", strconv.Itoa(v.Index),
+	).Replace(template)
+}
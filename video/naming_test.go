@@ -0,0 +1,55 @@
+package video
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateOutputNaming(t *testing.T) {
+	require.NoError(t, (*OutputNaming)(nil).Validate())
+	require.NoError(t, (&OutputNaming{}).Validate())
+	require.NoError(t, (&OutputNaming{
+		Segment:  "{rendition}/seg-{index}.ts",
+		Playlist: "{rendition}.m3u8",
+		MP4:      "mp4/{width}x{height}-{rendition}.mp4",
+	}).Validate())
+
+	for _, naming := range []OutputNaming{
+		{Segment: "{index}.ts"},
+		{Segment: "{rendition}.ts"},
+		{Segment: "{rendition}/{index}.mp4"},
+		{Segment: "{rendition}/{foo}/{index}.ts"},
+		{Playlist: "{rendition}/{index}.m3u8"},
+		{Playlist: "index.m3u8"},
+		{MP4: "/{rendition}.mp4"},
+		{MP4: "../{rendition}.mp4"},
+		{MP4: "a/../{rendition}.mp4"},
+		{MP4: "{rendition}?.mp4"},
+	} {
+		require.Error(t, naming.Validate(), naming)
+	}
+}
+
+func TestOutputNamingPaths(t *testing.T) {
+	vars := NamingVars{Rendition: "720p0", Width: 1280, Height: 720, Index: 3}
+
+	var defaults *OutputNaming
+	require.Equal(t, "720p0/3.ts", defaults.SegmentPath(vars))
+	require.Equal(t, "720p0/index.m3u8", defaults.PlaylistPath(vars))
+	require.Equal(t, "720p0.mp4", defaults.MP4Path(vars))
+	require.Equal(t, "3.ts", defaults.SegmentURI(vars))
+
+	naming := &OutputNaming{
+		Segment:  "segments/{rendition}/seg-{index}.ts",
+		Playlist: "{rendition}.m3u8",
+		MP4:      "{width}x{height}.mp4",
+	}
+	require.Equal(t, "segments/720p0/seg-3.ts", naming.SegmentPath(vars))
+	require.Equal(t, "720p0.m3u8", naming.PlaylistPath(vars))
+	require.Equal(t, "1280x720.mp4", naming.MP4Path(vars))
+	require.Equal(t, "segments/720p0/seg-3.ts", naming.SegmentURI(vars))
+
+	naming = &OutputNaming{Segment: "{rendition}/{index}.ts", Playlist: "playlists/{rendition}.m3u8"}
+	require.Equal(t, "../720p0/3.ts", naming.SegmentURI(vars))
+}
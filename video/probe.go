@@ -2,8 +2,12 @@ package video
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"os/exec"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -29,7 +33,7 @@ type Probe struct {
 func (p Probe) ProbeFile(requestID string, url string, ffProbeOptions ...string) (InputVideo, error) {
 	iv, err := p.runProbe(url, ffProbeOptions...)
 	if err == nil {
-		return iv, nil
+		return withVideoStreamDetails(requestID, url, iv), nil
 	}
 
 	// ignore these probing errors if found and re-run with fatal loglevel to obtain the probe data
@@ -37,12 +41,76 @@ func (p Probe) ProbeFile(requestID string, url string, ffProbeOptions ...string)
 	for _, ignoreMsg := range p.IgnoreErrMessages {
 		if strings.Contains(errMsg, ignoreMsg) {
 			log.Log(requestID, "ignoring probe error", "err", err)
-			return p.runProbe(url, "-loglevel", "fatal")
+			iv, err = p.runProbe(url, "-loglevel", "fatal")
+			if err != nil {
+				return iv, err
+			}
+			return withVideoStreamDetails(requestID, url, iv), nil
 		}
 	}
 	return InputVideo{}, err
 }
 
+// withVideoStreamDetails adds the colorimetry and closed captions that the ffprobe library doesn't parse to the video
+// track, if there's one. They're only used to preserve the colors and captions in the outputs, so the input is still
+// processed if they can't be probed.
+func withVideoStreamDetails(requestID, url string, iv InputVideo) InputVideo {
+	videoTrack, err := iv.GetTrack(TrackTypeVideo)
+	if err != nil {
+		return iv
+	}
+	details, err := probeVideoStream(url)
+	if err != nil {
+		log.LogError(requestID, "failed to probe video stream details", err)
+		return iv
+	}
+	videoTrack.ColorPrimaries = known(details.ColorPrimaries)
+	videoTrack.ColorTransfer = known(details.ColorTransfer)
+	videoTrack.ColorRange = known(details.ColorRange)
+	videoTrack.HDR = hdrFromTransfer(videoTrack.HDR, videoTrack.ColorTransfer)
+	videoTrack.ClosedCaptions = details.ClosedCaptions == 1
+	_ = iv.SetTrack(TrackTypeVideo, videoTrack)
+	return iv
+}
+
+// videoStreamDetails are the fields of the first video stream that the ffprobe library doesn't parse
+type videoStreamDetails struct {
+	ColorPrimaries string `json:"color_primaries"`
+	ColorTransfer  string `json:"color_transfer"`
+	ColorRange     string `json:"color_range"`
+	// ClosedCaptions is 1 if the stream carries CEA-608/708 captions
+	ClosedCaptions int `json:"closed_captions"`
+}
+
+func probeVideoStream(url string) (videoStreamDetails, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "ffprobe",
+		"-loglevel", "fatal",
+		"-select_streams", "v:0",
+		"-show_entries", "stream=color_primaries,color_transfer,color_range,closed_captions",
+		"-print_format", "json",
+		url,
+	).Output()
+	if err != nil {
+		return videoStreamDetails{}, fmt.Errorf("error probing video stream: %w", err)
+	}
+	return parseVideoStreamDetails(out)
+}
+
+func parseVideoStreamDetails(out []byte) (videoStreamDetails, error) {
+	var probe struct {
+		Streams []videoStreamDetails `json:"streams"`
+	}
+	if err := json.Unmarshal(out, &probe); err != nil {
+		return videoStreamDetails{}, fmt.Errorf("error parsing video stream probe: %w", err)
+	}
+	if len(probe.Streams) == 0 {
+		return videoStreamDetails{}, nil
+	}
+	return probe.Streams[0], nil
+}
+
 func (p Probe) runProbe(url string, ffProbeOptions ...string) (iv InputVideo, err error) {
 	if len(ffProbeOptions) == 0 {
 		ffProbeOptions = []string{"-loglevel", "error"}
@@ -52,6 +120,10 @@ func (p Probe) runProbe(url string, ffProbeOptions ...string) (iv InputVideo, er
 		probeCtx, probeCancel := context.WithTimeout(context.Background(), 60*time.Second)
 		defer probeCancel()
 		data, err = ffprobe.ProbeURL(probeCtx, url, ffProbeOptions...)
+		if ClassifyProbeError(err) != nil {
+			// a corrupt file won't probe any better on a retry
+			return backoff.Permanent(err)
+		}
 		return err
 	}
 
@@ -133,11 +205,14 @@ func parseProbeOutput(probeData *ffprobe.ProbeData) (InputVideo, error) {
 		return InputVideo{}, fmt.Errorf("error parsing avg fps numerator from probed data: %w", err)
 	}
 	// if fps is 0, try parsing the RFrameRate in the probed data which can be valid for hls files
+	var vfr bool
 	if fps == 0 {
 		fps, err = parseFps(videoStream.RFrameRate)
 		if err != nil {
 			return InputVideo{}, fmt.Errorf("error parsing real fps numerator from probed data: %w", err)
 		}
+	} else if realFps, err := parseFps(videoStream.RFrameRate); err == nil {
+		vfr = isVariableFrameRate(fps, realFps)
 	}
 
 	duration, err := strconv.ParseFloat(videoStream.Duration, 64)
@@ -176,6 +251,10 @@ func parseProbeOutput(probeData *ffprobe.ProbeData) (InputVideo, error) {
 					Rotation:           rotation,
 					DisplayAspectRatio: videoStream.DisplayAspectRatio,
 					PixelFormat:        videoStream.PixFmt,
+					ColorBitDepth:      colorBitDepth(videoStream),
+					ColorSpace:         videoStream.ColorSpace,
+					HDR:                hdrFormat(videoStream),
+					VFR:                vfr,
 				},
 			},
 		},
@@ -187,7 +266,7 @@ func parseProbeOutput(probeData *ffprobe.ProbeData) (InputVideo, error) {
 		return InputVideo{}, err
 	}
 
-	return iv, nil
+	return addSubtitleTracks(probeData, iv), nil
 }
 
 func addAudioTrack(probeData *ffprobe.ProbeData, iv InputVideo) (InputVideo, error) {
@@ -219,16 +298,107 @@ func addAudioTrack(probeData *ffprobe.ProbeData, iv InputVideo) (InputVideo, err
 		DurationSec:  parseAssetDuration(audioTrack.Duration),
 		StartTimeSec: audioStartTime,
 		AudioTrack: AudioTrack{
-			Channels:   audioTrack.Channels,
-			SampleBits: audioTrack.BitsPerSample,
-			SampleRate: sampleRate,
-			BitDepth:   bitDepth,
+			Channels:      audioTrack.Channels,
+			SampleBits:    audioTrack.BitsPerSample,
+			SampleRate:    sampleRate,
+			BitDepth:      bitDepth,
+			ChannelLayout: audioTrack.ChannelLayout,
 		},
 	})
 
 	return iv, nil
 }
 
+func addSubtitleTracks(probeData *ffprobe.ProbeData, iv InputVideo) InputVideo {
+	for i, stream := range probeData.StreamType(ffprobe.StreamSubtitle) {
+		language, _ := stream.TagList.GetString("language")
+		if language == "und" {
+			language = ""
+		}
+		title, _ := stream.TagList.GetString("title")
+		iv.Tracks = append(iv.Tracks, InputTrack{
+			Type:          TrackTypeSubtitle,
+			Codec:         stream.CodecName,
+			DurationSec:   parseAssetDuration(stream.Duration),
+			SubtitleTrack: SubtitleTrack{Index: i, Language: language, Title: title},
+		})
+	}
+	return iv
+}
+
+// vfrTolerance is how far the average frame rate of a stream can be from its base frame rate, as a ratio of the base,
+// before the stream is considered to have a variable frame rate. CFR streams can be a few frames short of their base
+// rate on average, e.g. when they start with a gap, where VFR screen recordings are usually far below it.
+const vfrTolerance = 0.05
+
+// isVariableFrameRate compares the average frame rate of a stream to its base frame rate, r_frame_rate in ffprobe,
+// the lowest rate all of its timestamps can be represented at, which only matches the average of CFR streams
+func isVariableFrameRate(avgFps, realFps float64) bool {
+	if avgFps <= 0 || realFps <= 0 {
+		return false
+	}
+	return math.Abs(realFps-avgFps)/realFps > vfrTolerance
+}
+
+// HDR formats detected by the probe
+const (
+	HDRDolbyVision = "dolby_vision"
+	HDR10          = "hdr10"
+	HDRHLG         = "hlg"
+	// HDRWideGamut is a 10+ bit BT.2020 track whose transfer characteristics couldn't be probed
+	HDRWideGamut = "bt2020"
+)
+
+var pixelFormatBitDepth = regexp.MustCompile(`[a-z](9|10|12|14|16)(le|be)$`)
+
+// colorBitDepth is the bits per color component of a video stream, from bits_per_raw_sample when ffprobe reports
+// it and otherwise from the pixel format, which names the depth of anything above 8 bits, e.g. yuv420p10le
+func colorBitDepth(stream *ffprobe.Stream) int {
+	if depth, err := strconv.Atoi(stream.BitsPerRawSample); err == nil && depth > 0 {
+		return depth
+	}
+	if stream.PixFmt == "" {
+		return 0
+	}
+	if m := pixelFormatBitDepth.FindStringSubmatch(stream.PixFmt); m != nil {
+		depth, _ := strconv.Atoi(m[1])
+		return depth
+	}
+	return 8
+}
+
+// hdrFormat detects HDR video from its side data, falling back on the color space, since the ffprobe library
+// doesn't parse the transfer characteristics
+func hdrFormat(stream *ffprobe.Stream) string {
+	if _, err := stream.SideDataList.GetSideData("DOVI configuration record"); err == nil {
+		return HDRDolbyVision
+	}
+	for _, sideDataType := range []string{"Mastering display metadata", "Content light level metadata"} {
+		if _, err := stream.SideDataList.GetSideData(sideDataType); err == nil {
+			return HDR10
+		}
+	}
+	if strings.HasPrefix(stream.ColorSpace, "bt2020") && colorBitDepth(stream) >= 10 {
+		return HDRWideGamut
+	}
+	return ""
+}
+
+// hdrFromTransfer refines the HDR format detected from side data with the transfer characteristics: PQ without HDR10
+// metadata is still HDR10, and HLG has no metadata to detect it by
+func hdrFromTransfer(hdr, transfer string) string {
+	if hdr != "" && hdr != HDRWideGamut {
+		return hdr
+	}
+	switch transfer {
+	case "smpte2084":
+		return HDR10
+	case "arib-std-b67":
+		return HDRHLG
+	}
+	return hdr
+}
+
 func parseAssetDuration(duration string) float64 {
 	d, _ := strconv.ParseFloat(duration, 64)
 	return d
@@ -79,6 +79,113 @@ func TestDefaultBitrate(t *testing.T) {
 	require.Equal(t, DefaultProfile720p.Bitrate, track.Bitrate)
 }
 
+func TestItAnalysesColorAndAudioLayout(t *testing.T) {
+	iv, err := parseProbeOutput(&ffprobe.ProbeData{
+		Streams: []*ffprobe.Stream{
+			{
+				CodecType:    "video",
+				PixFmt:       "yuv420p10le",
+				ColorSpace:   "bt2020nc",
+				SideDataList: ffprobe.SideDataList{{"side_data_type": "Mastering display metadata"}},
+			},
+			{
+				CodecType:     "audio",
+				Channels:      6,
+				ChannelLayout: "5.1(side)",
+			},
+		},
+		Format: &ffprobe.Format{
+			Size: "1",
+		},
+	})
+	require.NoError(t, err)
+	videoTrack, err := iv.GetTrack(TrackTypeVideo)
+	require.NoError(t, err)
+	require.Equal(t, 10, videoTrack.ColorBitDepth)
+	require.Equal(t, "bt2020nc", videoTrack.ColorSpace)
+	require.Equal(t, HDR10, videoTrack.HDR)
+	audioTrack, err := iv.GetTrack(TrackTypeAudio)
+	require.NoError(t, err)
+	require.Equal(t, "5.1(side)", audioTrack.ChannelLayout)
+}
+
+func TestItListsSubtitleTracks(t *testing.T) {
+	iv, err := parseProbeOutput(&ffprobe.ProbeData{
+		Streams: []*ffprobe.Stream{
+			{CodecType: "video"},
+			{CodecType: "subtitle", CodecName: "mov_text", TagList: ffprobe.Tags{"language": "eng"}},
+			{CodecType: "subtitle", CodecName: "subrip", TagList: ffprobe.Tags{"language": "und", "title": "Commentary"}},
+		},
+		Format: &ffprobe.Format{
+			Size: "1",
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, iv.Tracks, 3)
+	require.Equal(t, InputTrack{Type: TrackTypeSubtitle, Codec: "mov_text", SubtitleTrack: SubtitleTrack{Index: 0, Language: "eng"}}, iv.Tracks[1])
+	require.Equal(t, InputTrack{Type: TrackTypeSubtitle, Codec: "subrip", SubtitleTrack: SubtitleTrack{Index: 1, Title: "Commentary"}}, iv.Tracks[2])
+}
+
+func TestParseVideoStreamDetails(t *testing.T) {
+	details, err := parseVideoStreamDetails([]byte(`{"programs": [], "streams": [{"color_range": "tv", "color_transfer": "smpte2084", "color_primaries": "bt2020", "closed_captions": 1}]}`))
+	require.NoError(t, err)
+	require.Equal(t, videoStreamDetails{ColorPrimaries: "bt2020", ColorTransfer: "smpte2084", ColorRange: "tv", ClosedCaptions: 1}, details)
+
+	details, err = parseVideoStreamDetails([]byte(`{"streams": []}`))
+	require.NoError(t, err)
+	require.Equal(t, videoStreamDetails{}, details)
+
+	_, err = parseVideoStreamDetails([]byte(`not json`))
+	require.Error(t, err)
+}
+
+func TestItDetectsVariableFrameRate(t *testing.T) {
+	probe := func(avgFrameRate, rFrameRate string) VideoTrack {
+		iv, err := parseProbeOutput(&ffprobe.ProbeData{
+			Streams: []*ffprobe.Stream{
+				{
+					CodecType:    "video",
+					AvgFrameRate: avgFrameRate,
+					RFrameRate:   rFrameRate,
+				},
+			},
+			Format: &ffprobe.Format{
+				Size: "1",
+			},
+		})
+		require.NoError(t, err)
+		track, err := iv.GetTrack(TrackTypeVideo)
+		require.NoError(t, err)
+		return track.VideoTrack
+	}
+
+	track := probe("8280/300", "60/1")
+	require.True(t, track.VFR)
+	require.Equal(t, 27.6, track.FPS)
+	require.False(t, probe("30000/1001", "30000/1001").VFR)
+	require.False(t, probe("2990/100", "30/1").VFR)
+	require.False(t, probe("0/0", "30/1").VFR)
+}
+
+func TestHDRFormat(t *testing.T) {
+	require.Equal(t, "", hdrFormat(&ffprobe.Stream{PixFmt: "yuv420p"}))
+	require.Equal(t, "", hdrFormat(&ffprobe.Stream{PixFmt: "yuv420p", ColorSpace: "bt2020nc"}))
+	require.Equal(t, HDRWideGamut, hdrFormat(&ffprobe.Stream{PixFmt: "yuv420p10le", ColorSpace: "bt2020nc"}))
+	require.Equal(t, HDRDolbyVision, hdrFormat(&ffprobe.Stream{
+		PixFmt:       "yuv420p10le",
+		SideDataList: ffprobe.SideDataList{{"side_data_type": "DOVI configuration record"}},
+	}))
+}
+
+func TestColorBitDepth(t *testing.T) {
+	require.Equal(t, 0, colorBitDepth(&ffprobe.Stream{}))
+	require.Equal(t, 8, colorBitDepth(&ffprobe.Stream{PixFmt: "yuv420p"}))
+	require.Equal(t, 10, colorBitDepth(&ffprobe.Stream{PixFmt: "yuv420p10le"}))
+	require.Equal(t, 12, colorBitDepth(&ffprobe.Stream{PixFmt: "yuv444p12be"}))
+	require.Equal(t, 8, colorBitDepth(&ffprobe.Stream{PixFmt: "rgb48le"}))
+	require.Equal(t, 10, colorBitDepth(&ffprobe.Stream{PixFmt: "yuv420p", BitsPerRawSample: "10"}))
+}
+
 func TestProbe(t *testing.T) {
 	require := require.New(t)
 	probe := Probe{}
@@ -95,10 +202,11 @@ func TestProbe(t *testing.T) {
 				Bitrate:     1234521,
 				DurationSec: 16.2,
 				VideoTrack: VideoTrack{
-					Width:       576,
-					Height:      1024,
-					FPS:         30,
-					PixelFormat: "yuv420p",
+					Width:         576,
+					Height:        1024,
+					FPS:           30,
+					PixelFormat:   "yuv420p",
+					ColorBitDepth: 8,
 				},
 			},
 			{
@@ -107,8 +215,9 @@ func TestProbe(t *testing.T) {
 				Bitrate:     128248,
 				DurationSec: 16.207007,
 				AudioTrack: AudioTrack{
-					Channels:   2,
-					SampleRate: 44100,
+					Channels:      2,
+					SampleRate:    44100,
+					ChannelLayout: "stereo",
 				},
 			},
 		},
@@ -3,7 +3,9 @@ package video
 import (
 	"fmt"
 	"math"
+	"sort"
 	"strconv"
+	"strings"
 )
 
 const (
@@ -12,6 +14,7 @@ const (
 	MaxVideoBitrate         = 288_000_000
 	TrackTypeVideo          = "video"
 	TrackTypeAudio          = "audio"
+	TrackTypeSubtitle       = "subtitle"
 )
 
 var (
@@ -61,6 +64,20 @@ type VideoTrack struct {
 	FPS                float64 `json:"fps,omitempty"`
 	Rotation           int64   `json:"rotation,omitempty"`
 	DisplayAspectRatio string  `json:"display_aspect_ratio,omitempty"`
+	// ColorBitDepth is the bits per color component, e.g. 10 for yuv420p10le
+	ColorBitDepth int    `json:"color_bit_depth,omitempty"`
+	ColorSpace    string `json:"color_space,omitempty"`
+	// ColorPrimaries, ColorTransfer and ColorRange are the rest of the colorimetry, as named by ffprobe, e.g. bt2020,
+	// smpte2084 and tv
+	ColorPrimaries string `json:"color_primaries,omitempty"`
+	ColorTransfer  string `json:"color_transfer,omitempty"`
+	ColorRange     string `json:"color_range,omitempty"`
+	// HDR is the HDR format of the track, one of the HDR* constants, empty for SDR
+	HDR string `json:"hdr,omitempty"`
+	// VFR is whether the frame rate is variable, e.g. in screen recordings, in which case FPS is the average
+	VFR bool `json:"vfr,omitempty"`
+	// ClosedCaptions is whether the video carries CEA-608/708 captions
+	ClosedCaptions bool `json:"closed_captions,omitempty"`
 }
 
 type AudioTrack struct {
@@ -68,6 +85,15 @@ type AudioTrack struct {
 	SampleRate int `json:"sample_rate,omitempty"`
 	SampleBits int `json:"sample_bits,omitempty"`
 	BitDepth   int `json:"bit_depth,omitempty"`
+	// ChannelLayout as named by ffprobe, e.g. stereo or 5.1(side)
+	ChannelLayout string `json:"channel_layout,omitempty"`
+}
+
+type SubtitleTrack struct {
+	// Index of the track among the source's subtitle tracks, from 0
+	Index    int    `json:"index"`
+	Language string `json:"language,omitempty"`
+	Title    string `json:"title,omitempty"`
 }
 
 type InputTrack struct {
@@ -83,6 +109,9 @@ type InputTrack struct {
 
 	// Fields only used if this is an Audio Track
 	AudioTrack
+
+	// Fields only used if this is a Subtitle Track
+	SubtitleTrack
 }
 
 var DefaultProfile360p = EncodedProfile{
@@ -105,7 +134,63 @@ var DefaultProfile720p = EncodedProfile{
 // DefaultTranscodeProfiles defines the default set of encoding profiles to use when none are specified
 var DefaultTranscodeProfiles = []EncodedProfile{DefaultProfile360p, DefaultProfile720p}
 
+// Encoders and encoder profiles the broadcasters accept, compared case insensitively
+var (
+	ladderEncoders        = []string{"h264", "h.264", "h265", "h.265", "hevc", "vp8", "vp9"}
+	ladderEncoderProfiles = []string{"none", "h264baseline", "h264main", "h264high", "h264constrainedhigh"}
+)
+
+// ValidateLadder checks a default ladder configured by the operator. Each profile needs a unique name, a resolution
+// to compare against the source and a bitrate, the encoder and profile are optional. The quality defaults to
+// DefaultQuality.
+func ValidateLadder(profiles []EncodedProfile) error {
+	names := map[string]bool{}
+	for i, profile := range profiles {
+		if profile.Name == "" {
+			return fmt.Errorf("transcode profile %d needs a name", i)
+		}
+		if names[profile.Name] {
+			return fmt.Errorf("transcode profile %q is a duplicate", profile.Name)
+		}
+		names[profile.Name] = true
+		if profile.Width <= 0 || profile.Height <= 0 {
+			return fmt.Errorf("transcode profile %q needs a width and a height", profile.Name)
+		}
+		if profile.Bitrate < AbsoluteMinVideoBitrate || profile.Bitrate > MaxVideoBitrate {
+			return fmt.Errorf("transcode profile %q bitrate should be between %d and %d", profile.Name, AbsoluteMinVideoBitrate, MaxVideoBitrate)
+		}
+		if profile.FPS < 0 || profile.FPSDen < 0 {
+			return fmt.Errorf("transcode profile %q fps should not be negative", profile.Name)
+		}
+		if profile.Encoder != "" && !containsFold(ladderEncoders, profile.Encoder) {
+			return fmt.Errorf("transcode profile %q encoder %q is unknown: use one of %s", profile.Name, profile.Encoder, strings.Join(ladderEncoders, ", "))
+		}
+		if profile.Profile != "" && !containsFold(ladderEncoderProfiles, profile.Profile) {
+			return fmt.Errorf("transcode profile %q profile %q is unknown: use one of %s", profile.Name, profile.Profile, strings.Join(ladderEncoderProfiles, ", "))
+		}
+		if profiles[i].Quality == 0 {
+			profiles[i].Quality = DefaultQuality
+		}
+	}
+	return nil
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
 func SetTranscodeProfiles(inputVideoStats InputVideo, transcodeProfiles []EncodedProfile, isClip bool) ([]EncodedProfile, error) {
+	return SetTranscodeProfilesWithDefaults(inputVideoStats, transcodeProfiles, nil, nil, isClip)
+}
+
+// SetTranscodeProfilesWithDefaults is SetTranscodeProfiles with the default ladder used when no profiles are
+// specified, DefaultTranscodeProfiles if nil, and the constraints on the ladder generated from it, if any
+func SetTranscodeProfilesWithDefaults(inputVideoStats InputVideo, transcodeProfiles, defaultProfiles []EncodedProfile, constraints *LadderConstraints, isClip bool) ([]EncodedProfile, error) {
 	videoTrack, err := inputVideoStats.GetTrack(TrackTypeVideo)
 	if err != nil {
 		return nil, fmt.Errorf("no video track found in input video: %w", err)
@@ -124,16 +209,34 @@ func SetTranscodeProfiles(inputVideoStats InputVideo, transcodeProfiles []Encode
 	// If Profiles were not specified, use the default set. Notice that they can come
 	// as an empty array for no transcoding, which is why we check nil instead of len
 	if transcodeProfiles == nil {
-		transcodeProfiles, err = GetDefaultPlaybackProfiles(videoTrack, copySource)
+		transcodeProfiles, err = GetConstrainedPlaybackProfiles(defaultProfiles, constraints, videoTrack, copySource)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get default transcode profiles: %w", err)
 		}
 	} else if copySource {
 		transcodeProfiles = append(transcodeProfiles, GetSourceCopyProfile(videoTrack))
 	}
+	if videoTrack.VFR {
+		transcodeProfiles = withConstantFrameRate(transcodeProfiles, videoTrack.FPS)
+	}
 	return transcodeProfiles, nil
 }
 
+// withConstantFrameRate sets the frame rate of the profiles that keep the source's to its average, rounded, so that
+// variable frame rate sources are transcoded at a constant one and their audio doesn't drift from the video. Copied
+// renditions keep the source's frame rate.
+func withConstantFrameRate(profiles []EncodedProfile, sourceFPS float64) []EncodedProfile {
+	fps := int64(math.Max(1, math.Round(sourceFPS)))
+	normalized := make([]EncodedProfile, len(profiles))
+	for i, profile := range profiles {
+		if profile.FPS == 0 && !profile.Copy {
+			profile.FPS, profile.FPSDen = fps, 1
+		}
+		normalized[i] = profile
+	}
+	return normalized
+}
+
 func GenerateSingleProfileWithTargetParams(videoTrack InputTrack, videoProfile EncodedProfile) []EncodedProfile {
 	profiles := make([]EncodedProfile, 0)
 	var quality uint = DefaultQuality
@@ -164,12 +267,26 @@ func GetSourceCopyProfile(video InputTrack) EncodedProfile {
 }
 
 func GetDefaultPlaybackProfiles(video InputTrack, copySource bool) ([]EncodedProfile, error) {
+	return GetPlaybackProfiles(nil, video, copySource)
+}
+
+// GetPlaybackProfiles adapts the ladder to the source video, keeping the profiles of a lower quality than the source.
+// The ladder is DefaultTranscodeProfiles if nil.
+func GetPlaybackProfiles(ladder []EncodedProfile, video InputTrack, copySource bool) ([]EncodedProfile, error) {
+	return GetConstrainedPlaybackProfiles(ladder, nil, video, copySource)
+}
+
+// GetConstrainedPlaybackProfiles is GetPlaybackProfiles with the constraints of the request on the adapted ladder
+func GetConstrainedPlaybackProfiles(ladder []EncodedProfile, constraints *LadderConstraints, video InputTrack, copySource bool) ([]EncodedProfile, error) {
+	if ladder == nil {
+		ladder = DefaultTranscodeProfiles
+	}
 	videoBitrate := video.Bitrate
 	if videoBitrate > MaxVideoBitrate {
 		videoBitrate = MaxVideoBitrate
 	}
-	profiles := make([]EncodedProfile, 0, len(DefaultTranscodeProfiles)+1)
-	for _, profile := range DefaultTranscodeProfiles {
+	profiles := make([]EncodedProfile, 0, len(ladder)+1)
+	for _, profile := range ladder {
 		// transcoding job will adjust the width to match aspect ratio. no need to
 		// check it here.
 		lowerQualityThanSrc := profile.Height < video.Height && profile.Bitrate < video.Bitrate
@@ -196,7 +313,97 @@ func GetDefaultPlaybackProfiles(video InputTrack, copySource bool) ([]EncodedPro
 			Quality: DefaultQuality,
 		})
 	}
-	return profiles, nil
+	return constraints.apply(profiles, video), nil
+}
+
+// LadderConstraints limit the ladder generated when no profiles are requested, so that a request can keep its output
+// costs down without listing its profiles. A constraint left at zero doesn't apply.
+type LadderConstraints struct {
+	// MaxRenditions is the most renditions generated, including the one at the resolution of the source
+	MaxRenditions int `json:"max_renditions,omitempty"`
+	// MinHeight and MaxHeight bound the resolution of the renditions
+	MinHeight int64 `json:"min_height,omitempty"`
+	MaxHeight int64 `json:"max_height,omitempty"`
+	// MaxTotalBitrate bounds the sum of the bitrates of the renditions, in bits per second
+	MaxTotalBitrate int64 `json:"max_total_bitrate,omitempty"`
+}
+
+func (c *LadderConstraints) Validate() error {
+	if c == nil {
+		return nil
+	}
+	if c.MaxRenditions < 0 || c.MinHeight < 0 || c.MaxHeight < 0 || c.MaxTotalBitrate < 0 {
+		return fmt.Errorf("ladder constraints should not be negative")
+	}
+	if c.MinHeight > 0 && c.MaxHeight > 0 && c.MinHeight > c.MaxHeight {
+		return fmt.Errorf("ladder min_height %d is above max_height %d", c.MinHeight, c.MaxHeight)
+	}
+	if c.MaxTotalBitrate > 0 && c.MaxTotalBitrate < AbsoluteMinVideoBitrate {
+		return fmt.Errorf("ladder max_total_bitrate should be at least %d", AbsoluteMinVideoBitrate)
+	}
+	return nil
+}
+
+// apply constrains a generated ladder, whose last profile is the one at the resolution of the source. That profile is
+// always kept, scaled down to the max height if it's above it and no other rendition is left, and the renditions
+// below it are dropped from the highest until the ladder fits, so that the lowest stay for ABR playback. A copy of the
+// source can't have its bitrate lowered, so a ladder of a source copy alone can be above the max total bitrate.
+func (c *LadderConstraints) apply(profiles []EncodedProfile, video InputTrack) []EncodedProfile {
+	if c == nil || len(profiles) == 0 {
+		return profiles
+	}
+	top := profiles[len(profiles)-1]
+	var rungs []EncodedProfile
+	for _, profile := range profiles[:len(profiles)-1] {
+		if (c.MinHeight == 0 || profile.Height >= c.MinHeight) && (c.MaxHeight == 0 || profile.Height <= c.MaxHeight) {
+			rungs = append(rungs, profile)
+		}
+	}
+	sort.SliceStable(rungs, func(i, j int) bool { return rungs[i].Height < rungs[j].Height })
+
+	if c.MaxHeight > 0 && top.Height > c.MaxHeight {
+		if len(rungs) > 0 {
+			top, rungs = rungs[len(rungs)-1], rungs[:len(rungs)-1]
+		} else {
+			top = scaledProfile(video, top.Bitrate, c.MaxHeight)
+		}
+	}
+	if c.MaxRenditions > 0 && len(rungs)+1 > c.MaxRenditions {
+		rungs = rungs[:c.MaxRenditions-1]
+	}
+	if c.MaxTotalBitrate > 0 {
+		total := top.Bitrate
+		for _, rung := range rungs {
+			total += rung.Bitrate
+		}
+		for total > c.MaxTotalBitrate && len(rungs) > 0 {
+			total -= rungs[len(rungs)-1].Bitrate
+			rungs = rungs[:len(rungs)-1]
+		}
+		if total > c.MaxTotalBitrate && !top.Copy {
+			top.Bitrate = c.MaxTotalBitrate
+		}
+	}
+	return append(rungs, top)
+}
+
+// scaledProfile is a rendition of the source scaled down to a height, keeping its aspect ratio and bits per pixel
+func scaledProfile(video InputTrack, bitrate, height int64) EncodedProfile {
+	width := video.Width
+	if video.Height > 0 {
+		width = video.Width * height / video.Height
+		bitrate = int64(float64(bitrate) * float64(height*height) / float64(video.Height*video.Height))
+	}
+	if bitrate < AbsoluteMinVideoBitrate {
+		bitrate = AbsoluteMinVideoBitrate
+	}
+	return EncodedProfile{
+		Name:    strconv.FormatInt(nearestEven(height), 10) + "p0",
+		Bitrate: bitrate,
+		Width:   nearestEven(width),
+		Height:  nearestEven(height),
+		Quality: DefaultQuality,
+	}
 }
 
 // When the input video's height and bitrate combo is too low to meet the default ABR ladder we output a low bitrate
@@ -248,6 +455,10 @@ type OutputVideo struct {
 	Manifest   string            `json:"manifest,omitempty"`
 	Videos     []OutputVideoFile `json:"videos"`
 	MP4Outputs []OutputVideoFile `json:"mp4_outputs,omitempty"`
+	Preview    string            `json:"preview,omitempty"`
+	Poster     string            `json:"poster,omitempty"`
+	// SignedManifest is a time-limited GET URL of the manifest, when signed URLs were requested
+	SignedManifest string `json:"signed_manifest,omitempty"`
 }
 
 type OutputVideoFile struct {
@@ -257,6 +468,10 @@ type OutputVideoFile struct {
 	Width     int64  `json:"width,omitempty"`
 	Height    int64  `json:"height,omitempty"`
 	Bitrate   int64  `json:"bitrate,omitempty"`
+	// FPS the rendition was transcoded at, when it was set rather than the source's kept
+	FPS int64 `json:"fps,omitempty"`
+	// SignedLocation is a time-limited GET URL of the file, when signed URLs were requested
+	SignedLocation string `json:"signed_location,omitempty"`
 }
 
 func PopulateOutput(requestID string, probe Prober, outputURL string, videoFile OutputVideoFile) (OutputVideoFile, error) {
@@ -390,6 +390,98 @@ func TestGetDefaultPlaybackProfilesFixtures(t *testing.T) {
 	}
 }
 
+func TestGetPlaybackProfilesLadder(t *testing.T) {
+	track := InputTrack{Type: "video", Bitrate: 4_000_000, VideoTrack: VideoTrack{Width: 1280, Height: 720}}
+	ladder := []EncodedProfile{
+		{Name: "240p0", Width: 426, Height: 240, Bitrate: 250_000, Quality: DefaultQuality},
+		{Name: "1080p0", Width: 1920, Height: 1080, Bitrate: 6_000_000, Quality: DefaultQuality},
+	}
+	got, err := GetPlaybackProfiles(ladder, track, false)
+	require.NoError(t, err)
+	require.Equal(t, []EncodedProfile{
+		ladder[0],
+		{Name: "720p0", Width: 1280, Height: 720, Bitrate: 4_800_000, Quality: DefaultQuality},
+	}, got)
+
+	got, err = SetTranscodeProfilesWithDefaults(InputVideo{Format: "mp4", Tracks: []InputTrack{track}}, nil, ladder, nil, false)
+	require.NoError(t, err)
+	require.Equal(t, "240p0", got[0].Name)
+}
+
+func TestGetConstrainedPlaybackProfiles(t *testing.T) {
+	track := InputTrack{Type: "video", Bitrate: 8_000_000, VideoTrack: VideoTrack{Width: 1920, Height: 1080}}
+	p360 := EncodedProfile{Name: "360p0", Width: 640, Height: 360, Bitrate: 1_000_000, Quality: DefaultQuality}
+	p720 := EncodedProfile{Name: "720p0", Width: 1280, Height: 720, Bitrate: 4_000_000, Quality: DefaultQuality}
+	p1080 := EncodedProfile{Name: "1080p0", Width: 1920, Height: 1080, Bitrate: 9_600_000, Quality: DefaultQuality}
+
+	tests := []struct {
+		name        string
+		constraints *LadderConstraints
+		copySource  bool
+		want        []EncodedProfile
+	}{
+		{name: "unconstrained", want: []EncodedProfile{p360, p720, p1080}},
+		{name: "max renditions keeps the source resolution and the lowest", constraints: &LadderConstraints{MaxRenditions: 2}, want: []EncodedProfile{p360, p1080}},
+		{name: "max height", constraints: &LadderConstraints{MaxHeight: 720}, want: []EncodedProfile{p360, p720}},
+		{name: "min height", constraints: &LadderConstraints{MinHeight: 480}, want: []EncodedProfile{p720, p1080}},
+		{name: "max total bitrate", constraints: &LadderConstraints{MaxTotalBitrate: 11_000_000}, want: []EncodedProfile{p360, p1080}},
+		{
+			name:        "max height below every rendition scales the source down",
+			constraints: &LadderConstraints{MinHeight: 400, MaxHeight: 480},
+			want:        []EncodedProfile{{Name: "480p0", Width: 854, Height: 480, Bitrate: 1_896_296, Quality: DefaultQuality}},
+		},
+		{
+			name:        "max total bitrate below the source rendition lowers its bitrate",
+			constraints: &LadderConstraints{MaxTotalBitrate: 2_000_000},
+			want:        []EncodedProfile{{Name: "1080p0", Width: 1920, Height: 1080, Bitrate: 2_000_000, Quality: DefaultQuality}},
+		},
+		{
+			name:        "source copy keeps its bitrate",
+			constraints: &LadderConstraints{MaxTotalBitrate: 2_000_000},
+			copySource:  true,
+			want:        []EncodedProfile{GetSourceCopyProfile(track)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetConstrainedPlaybackProfiles(nil, tt.constraints, track, tt.copySource)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidateLadderConstraints(t *testing.T) {
+	require.NoError(t, (*LadderConstraints)(nil).Validate())
+	require.NoError(t, (&LadderConstraints{MaxRenditions: 3, MinHeight: 360, MaxHeight: 1080, MaxTotalBitrate: 8_000_000}).Validate())
+	require.ErrorContains(t, (&LadderConstraints{MaxRenditions: -1}).Validate(), "should not be negative")
+	require.ErrorContains(t, (&LadderConstraints{MinHeight: 720, MaxHeight: 360}).Validate(), "is above max_height")
+	require.ErrorContains(t, (&LadderConstraints{MaxTotalBitrate: 1000}).Validate(), "should be at least")
+}
+
+func TestValidateLadder(t *testing.T) {
+	ladder := []EncodedProfile{
+		{Name: "360p0", Width: 640, Height: 360, Bitrate: 1_000_000},
+		{Name: "1080p0", Width: 1920, Height: 1080, Bitrate: 6_000_000, Encoder: "HEVC", Profile: "H264High", Quality: 20},
+	}
+	require.NoError(t, ValidateLadder(ladder))
+	require.Equal(t, DefaultQuality, ladder[0].Quality)
+	require.Equal(t, uint(20), ladder[1].Quality)
+	require.NoError(t, ValidateLadder(nil))
+
+	for problem, profile := range map[string]EncodedProfile{
+		"needs a name":                {Width: 640, Height: 360, Bitrate: 1_000_000},
+		"needs a width":               {Name: "360p0", Height: 360, Bitrate: 1_000_000},
+		"bitrate should be":           {Name: "360p0", Width: 640, Height: 360},
+		"fps should not":              {Name: "360p0", Width: 640, Height: 360, Bitrate: 1_000_000, FPS: -1},
+		"encoder \"av1\" is unknown":  {Name: "360p0", Width: 640, Height: 360, Bitrate: 1_000_000, Encoder: "av1"},
+		"profile \"high\" is unknown": {Name: "360p0", Width: 640, Height: 360, Bitrate: 1_000_000, Profile: "high"},
+	} {
+		require.ErrorContains(t, ValidateLadder([]EncodedProfile{profile}), problem)
+	}
+	require.ErrorContains(t, ValidateLadder([]EncodedProfile{ladder[0], ladder[0]}), "is a duplicate")
+}
+
 func TestPopulateOutput(t *testing.T) {
 	out, err := PopulateOutput("requestID", Probe{}, "fixtures/bbb-180rotated.mov", OutputVideoFile{})
 	require.NoError(t, err)
@@ -400,3 +492,31 @@ func TestPopulateOutput(t *testing.T) {
 		Bitrate:   414661,
 	}, out)
 }
+
+func TestSetTranscodeProfilesNormalizesVariableFrameRate(t *testing.T) {
+	track := InputTrack{Type: "video", Bitrate: 1_000_000, VideoTrack: VideoTrack{Width: 1280, Height: 720, FPS: 27.6, VFR: true}}
+	profiles := []EncodedProfile{
+		{Name: "360p0", Width: 640, Height: 360, Bitrate: 500_000},
+		{Name: "720p0", Width: 1280, Height: 720, Bitrate: 1_000_000, FPS: 24},
+	}
+
+	got, err := SetTranscodeProfilesWithDefaults(InputVideo{Format: "mp4", Tracks: []InputTrack{track}}, profiles, nil, nil, false)
+	require.NoError(t, err)
+	require.Equal(t, int64(28), got[0].FPS)
+	require.Equal(t, int64(1), got[0].FPSDen)
+	require.Equal(t, int64(24), got[1].FPS, "a requested frame rate should be kept")
+	require.Zero(t, profiles[0].FPS, "the requested profiles shouldn't be modified")
+
+	// the source rendition of HLS sources is copied as it is
+	got, err = SetTranscodeProfilesWithDefaults(InputVideo{Format: "hls", Tracks: []InputTrack{track}}, profiles[:1], nil, nil, false)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	require.Equal(t, int64(28), got[0].FPS)
+	require.True(t, got[1].Copy)
+	require.Zero(t, got[1].FPS)
+
+	track.VFR = false
+	got, err = SetTranscodeProfilesWithDefaults(InputVideo{Format: "mp4", Tracks: []InputTrack{track}}, profiles, nil, nil, false)
+	require.NoError(t, err)
+	require.Zero(t, got[0].FPS)
+}
@@ -2,10 +2,21 @@ package video
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/grafov/m3u8"
 	ffmpeg "github.com/u2takey/ffmpeg-go"
+	"golang.org/x/sync/errgroup"
 )
 
 // Segment splits a source video URL into segments
@@ -13,25 +24,238 @@ import (
 // FFMPEG can use remote files, but depending on the layout of the file can get bogged
 // down and end up making multiple range requests per segment.
 // Because of this, we download first and then clean up at the end.
-func Segment(sourceFilename string, outputManifestURL string, targetSegmentSize int64) error {
+//
+// The audio options are applied here, so that every rendition transcoded from the segments has the same audio.
+// ffmpeg is killed when the context is done, and the context's error is returned.
+func Segment(ctx context.Context, sourceFilename string, outputManifestURL string, targetSegmentSize int64, audio *AudioOptions) error {
+	args := segmentArgs(outputManifestURL, targetSegmentSize, audio)
+
 	// Do the segmenting, using the local file as source
 	ffmpegErr := bytes.Buffer{}
-	err := ffmpeg.Input(sourceFilename).
-		Output(
-			strings.Replace(outputManifestURL, ".m3u8", "", 1)+"%d.ts",
-			ffmpeg.KwArgs{
-				"c:a":               "aac",
-				"c:v":               "copy",
-				"f":                 "segment",
-				"segment_list":      outputManifestURL,
-				"segment_list_type": "m3u8",
-				"segment_format":    "mpegts",
-				"segment_time":      targetSegmentSize,
-				"min_seg_duration":  "2",
-			},
-		).OverWriteOutput().WithErrorOutput(&ffmpegErr).Run()
+	output := ffmpeg.Input(sourceFilename).
+		Output(strings.Replace(outputManifestURL, ".m3u8", "", 1)+"%d.ts", args)
+	output.Context = ctx
+	err := output.OverWriteOutput().WithErrorOutput(&ffmpegErr).Run()
+	if ctx.Err() != nil {
+		return fmt.Errorf("failed to segment source file (%s): %w", sourceFilename, ctx.Err())
+	}
 	if err != nil {
 		return fmt.Errorf("failed to segment source file (%s) [%s]: %s", sourceFilename, ffmpegErr.String(), err)
 	}
 	return nil
 }
+
+func segmentArgs(segmentList string, targetSegmentSize int64, audio *AudioOptions) ffmpeg.KwArgs {
+	args := ffmpeg.KwArgs{
+		"c:a":               "aac",
+		"c:v":               "copy",
+		"f":                 "segment",
+		"segment_list":      segmentList,
+		"segment_list_type": "m3u8",
+		"segment_format":    "mpegts",
+		"segment_time":      targetSegmentSize,
+		"min_seg_duration":  "2",
+		// subtitles are extracted from the source to WebVTT rather than carried in the segments
+		"sn": "",
+	}
+	audio.segmentArgs(args)
+	return args
+}
+
+// keyframeSearchWindow is how far after a split point the next keyframe is looked for
+const keyframeSearchWindow = 30
+
+// SegmentParallel segments a long source in parts that are segmented at the same time, each starting on a keyframe.
+// The parts are segmented locally, then their segments are written to the output numbered in order, as Segment would
+// have, followed by a manifest stitched from the parts' manifests. Segmenting is stopped when the context is done.
+func SegmentParallel(ctx context.Context, sourceFilename string, outputManifestURL string, targetSegmentSize int64, audio *AudioOptions, durationSecs float64, parts int) error {
+	starts, err := partStarts(ctx, sourceFilename, durationSecs, parts)
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp(os.TempDir(), "segments-*")
+	if err != nil {
+		return fmt.Errorf("failed to create local dir for segmenting: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	eg := errgroup.Group{}
+	playlists := make([]*m3u8.MediaPlaylist, len(starts))
+	for i := range starts {
+		i := i
+		eg.Go(func() error {
+			end := durationSecs
+			if i+1 < len(starts) {
+				end = starts[i+1]
+			}
+			playlist, err := segmentPart(ctx, sourceFilename, dir, i, starts[i], end, targetSegmentSize, audio)
+			playlists[i] = playlist
+			return err
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+
+	playlist, files, err := stitchParts(playlists, dir, strings.TrimSuffix(path.Base(outputManifestURL), ".m3u8"))
+	if err != nil {
+		return err
+	}
+	return uploadSegments(ctx, outputManifestURL, playlist, files)
+}
+
+// partStarts splits a source in parts of about the same duration, starting on the first keyframe after each split
+func partStarts(ctx context.Context, sourceFilename string, durationSecs float64, parts int) ([]float64, error) {
+	starts := []float64{0}
+	for i := 1; i < parts; i++ {
+		start, err := keyframeAfter(ctx, sourceFilename, durationSecs*float64(i)/float64(parts))
+		if err != nil {
+			return nil, err
+		}
+		if start > starts[len(starts)-1] && start < durationSecs {
+			starts = append(starts, start)
+		}
+	}
+	return starts, nil
+}
+
+func keyframeAfter(ctx context.Context, sourceFilename string, t float64) (float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "ffprobe",
+		"-loglevel", "error",
+		"-select_streams", "v:0",
+		"-read_intervals", fmt.Sprintf("%.3f%%+%d", t, keyframeSearchWindow),
+		"-show_entries", "packet=pts_time,flags",
+		"-of", "csv=p=0",
+		sourceFilename,
+	).Output()
+	if err != nil {
+		return 0, fmt.Errorf("error finding keyframe after %.1fs: %w", t, err)
+	}
+	return parseKeyframeAfter(out, t)
+}
+
+// parseKeyframeAfter finds the first keyframe at or after t in ffprobe's CSV list of packets, e.g. 12.012000,K__
+func parseKeyframeAfter(out []byte, t float64) (float64, error) {
+	for _, line := range strings.Split(string(out), "\n") {
+		ptsTime, flags, ok := strings.Cut(strings.TrimSpace(line), ",")
+		if !ok || !strings.HasPrefix(flags, "K") {
+			continue
+		}
+		pts, err := strconv.ParseFloat(ptsTime, 64)
+		if err != nil || pts < t {
+			continue
+		}
+		return pts, nil
+	}
+	return 0, fmt.Errorf("no keyframe found within %ds after %.1fs", keyframeSearchWindow, t)
+}
+
+// segmentPart segments the part of the source from start to end, keeping the source's timestamps so that the parts'
+// segments play back to back
+func segmentPart(ctx context.Context, sourceFilename, dir string, part int, start, end float64, targetSegmentSize int64, audio *AudioOptions) (*m3u8.MediaPlaylist, error) {
+	segmentList := filepath.Join(dir, fmt.Sprintf("part%d.m3u8", part))
+	args := segmentArgs(segmentList, targetSegmentSize, audio)
+	args["t"] = end - start
+	args["output_ts_offset"] = start
+
+	ffmpegErr := bytes.Buffer{}
+	output := ffmpeg.Input(sourceFilename, ffmpeg.KwArgs{"ss": start}).
+		Output(filepath.Join(dir, fmt.Sprintf("part%d_%%d.ts", part)), args)
+	output.Context = ctx
+	err := output.OverWriteOutput().WithErrorOutput(&ffmpegErr).Run()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("failed to segment part %d of source file (%s): %w", part, sourceFilename, ctx.Err())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to segment part %d of source file (%s) [%s]: %s", part, sourceFilename, ffmpegErr.String(), err)
+	}
+
+	f, err := os.Open(segmentList)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest of part %d: %w", part, err)
+	}
+	defer f.Close()
+	playlist, playlistType, err := m3u8.DecodeFrom(f, true)
+	if err != nil || playlistType != m3u8.MEDIA {
+		return nil, fmt.Errorf("failed to parse manifest of part %d: %v", part, err)
+	}
+	return playlist.(*m3u8.MediaPlaylist), nil
+}
+
+// stitchParts joins the parts' manifests into one, renaming their segments <prefix><n>.ts in order. It returns the
+// manifest and the local files of its segments.
+func stitchParts(parts []*m3u8.MediaPlaylist, dir, prefix string) (*m3u8.MediaPlaylist, []string, error) {
+	var segments []*m3u8.MediaSegment
+	for _, part := range parts {
+		segments = append(segments, part.GetAllSegments()...)
+	}
+	if len(segments) == 0 {
+		return nil, nil, fmt.Errorf("segmenting produced no segments")
+	}
+
+	playlist, err := m3u8.NewMediaPlaylist(0, uint(len(segments)))
+	if err != nil {
+		return nil, nil, err
+	}
+	files := make([]string, len(segments))
+	for i, segment := range segments {
+		files[i] = filepath.Join(dir, segment.URI)
+		if err := playlist.Append(fmt.Sprintf("%s%d.ts", prefix, i), segment.Duration, ""); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, part := range parts {
+		if part.TargetDuration > playlist.TargetDuration {
+			playlist.TargetDuration = part.TargetDuration
+		}
+	}
+	playlist.MediaType = m3u8.VOD
+	playlist.Close()
+	return playlist, files, nil
+}
+
+const segmentUploadConcurrency = 8
+
+// uploadSegments writes the segments next to the manifest, and the manifest once they've all been written
+func uploadSegments(ctx context.Context, outputManifestURL string, playlist *m3u8.MediaPlaylist, files []string) error {
+	eg := errgroup.Group{}
+	eg.SetLimit(segmentUploadConcurrency)
+	for i, file := range files {
+		segmentURL := strings.TrimSuffix(outputManifestURL, path.Base(outputManifestURL)) + playlist.Segments[i].URI
+		file := file
+		eg.Go(func() error {
+			f, err := os.Open(file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			return postSegmentFile(ctx, segmentURL, f)
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+	return postSegmentFile(ctx, outputManifestURL, playlist.Encode())
+}
+
+var segmentUploadClient = &http.Client{Timeout: 10 * time.Minute}
+
+func postSegmentFile(ctx context.Context, url string, body io.Reader) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := segmentUploadClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to write %s: status %d", url, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package video
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/grafov/m3u8"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKeyframeAfter(t *testing.T) {
+	out := []byte("9.976000,___\n10.010000,___\n10.043000,___\n12.012000,K__\n12.045000,___\n14.014000,K_D\n")
+
+	keyframe, err := parseKeyframeAfter(out, 10)
+	require.NoError(t, err)
+	require.Equal(t, 12.012, keyframe)
+
+	keyframe, err = parseKeyframeAfter(out, 13)
+	require.NoError(t, err)
+	require.Equal(t, 14.014, keyframe)
+
+	_, err = parseKeyframeAfter(out, 15)
+	require.ErrorContains(t, err, "no keyframe found")
+}
+
+func TestStitchParts(t *testing.T) {
+	part := func(targetDuration float64, segments map[string]float64, uris ...string) *m3u8.MediaPlaylist {
+		playlist, err := m3u8.NewMediaPlaylist(0, uint(len(uris)))
+		require.NoError(t, err)
+		for _, uri := range uris {
+			require.NoError(t, playlist.Append(uri, segments[uri], ""))
+		}
+		playlist.TargetDuration = targetDuration
+		return playlist
+	}
+	durations := map[string]float64{"part0_0.ts": 10, "part0_1.ts": 8, "part1_0.ts": 11, "part1_1.ts": 4}
+
+	playlist, files, err := stitchParts([]*m3u8.MediaPlaylist{
+		part(10, durations, "part0_0.ts", "part0_1.ts"),
+		part(11, durations, "part1_0.ts", "part1_1.ts"),
+	}, "/tmp/segments", "index")
+	require.NoError(t, err)
+	require.Equal(t, []string{"/tmp/segments/part0_0.ts", "/tmp/segments/part0_1.ts", "/tmp/segments/part1_0.ts", "/tmp/segments/part1_1.ts"}, files)
+	require.Equal(t, `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-PLAYLIST-TYPE:VOD
+#EXT-X-MEDIA-SEQUENCE:0
+#EXT-X-TARGETDURATION:11
+#EXTINF:10.000,
+index0.ts
+#EXTINF:8.000,
+index1.ts
+#EXTINF:11.000,
+index2.ts
+#EXTINF:4.000,
+index3.ts
+#EXT-X-ENDLIST
+`, playlist.String())
+
+	_, _, err = stitchParts([]*m3u8.MediaPlaylist{part(10, durations)}, "/tmp/segments", "index")
+	require.ErrorContains(t, err, "no segments")
+}
+
+func TestUploadSegmentsWritesTheManifestLast(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	contents := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, r.URL.Path)
+		contents[r.URL.Path] = string(body)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "part0_0.ts"), []byte("first"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "part1_0.ts"), []byte("second"), 0644))
+	playlist, err := m3u8.NewMediaPlaylist(0, 2)
+	require.NoError(t, err)
+	require.NoError(t, playlist.Append("index0.ts", 10, ""))
+	require.NoError(t, playlist.Append("index1.ts", 10, ""))
+	playlist.Close()
+
+	err = uploadSegments(context.Background(), server.URL+"/api/ffmpeg/stream/index.m3u8", playlist, []string{filepath.Join(dir, "part0_0.ts"), filepath.Join(dir, "part1_0.ts")})
+	require.NoError(t, err)
+	require.Len(t, received, 3)
+	require.Equal(t, "/api/ffmpeg/stream/index.m3u8", received[2])
+	require.Equal(t, "first", contents["/api/ffmpeg/stream/index0.ts"])
+	require.Equal(t, "second", contents["/api/ffmpeg/stream/index1.ts"])
+	require.True(t, strings.HasSuffix(contents["/api/ffmpeg/stream/index.m3u8"], "#EXT-X-ENDLIST\n"))
+}
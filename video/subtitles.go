@@ -0,0 +1,128 @@
+package video
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/grafov/m3u8"
+	"github.com/livepeer/catalyst-api/log"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// SubtitleGroupID is the GROUP-ID of the subtitles in the master playlists of sources with subtitles or captions
+const SubtitleGroupID = "subtitles"
+
+// textSubtitleCodecs are the subtitle codecs that can be converted to WebVTT. Bitmap subtitles, e.g. from DVDs, can't.
+var textSubtitleCodecs = []string{"mov_text", "subrip", "webvtt", "ass", "ssa", "text"}
+
+// SubtitleFile is a WebVTT file extracted from a source's subtitle track or closed captions
+type SubtitleFile struct {
+	Name     string
+	Language string
+	Path     string
+	// ClosedCaptions is whether the file was extracted from the CEA-608/708 captions of the video
+	ClosedCaptions bool
+}
+
+// closedCaptionCharacteristics tell players that the captions describe sounds as well as the dialog
+const closedCaptionCharacteristics = "public.accessibility.transcribes-spoken-dialog,public.accessibility.describes-music-and-sound"
+
+// Alternative describes the subtitles as the EXT-X-MEDIA entry of the subtitle group, with the URI of their playlist
+func (f SubtitleFile) Alternative(uri string) *m3u8.Alternative {
+	alt := &m3u8.Alternative{
+		GroupId:    SubtitleGroupID,
+		Type:       "SUBTITLES",
+		Name:       f.Name,
+		Language:   f.Language,
+		Autoselect: "YES",
+		URI:        uri,
+	}
+	if f.ClosedCaptions {
+		alt.Characteristics = closedCaptionCharacteristics
+	}
+	return alt
+}
+
+// ExtractSubtitles converts the text subtitle tracks and the closed captions of a local source file to WebVTT files in
+// dir. Tracks that can't be converted are skipped, so that the subtitles never fail the job.
+func ExtractSubtitles(requestID, sourceFile string, iv InputVideo, dir string) []SubtitleFile {
+	var files []SubtitleFile
+	names := map[string]bool{}
+	for _, track := range iv.Tracks {
+		if track.Type != TrackTypeSubtitle {
+			continue
+		}
+		if !allowed(textSubtitleCodecs, track.Codec) {
+			log.Log(requestID, "skipping subtitle track that can't be converted to WebVTT", "index", track.Index, "codec", track.Codec)
+			continue
+		}
+		path := filepath.Join(dir, fmt.Sprintf("subtitles-%d.vtt", track.Index))
+		err := runSubtitleExtraction(ffmpeg.Input(sourceFile), path, fmt.Sprintf("0:s:%d", track.Index))
+		if err != nil {
+			log.LogError(requestID, "failed to extract subtitle track", err, "index", track.Index)
+			continue
+		}
+		name := track.Title
+		if name == "" {
+			name = track.Language
+		}
+		if name == "" {
+			name = fmt.Sprintf("Subtitles %d", track.Index+1)
+		}
+		files = append(files, SubtitleFile{Name: uniqueName(names, name), Language: track.Language, Path: path})
+	}
+
+	if videoTrack, err := iv.GetTrack(TrackTypeVideo); err == nil && videoTrack.ClosedCaptions {
+		path := filepath.Join(dir, "closed-captions.vtt")
+		if err := extractClosedCaptions(sourceFile, path); err != nil {
+			log.LogError(requestID, "failed to extract closed captions", err)
+		} else {
+			files = append(files, SubtitleFile{Name: uniqueName(names, "Closed captions"), Path: path, ClosedCaptions: true})
+		}
+	}
+	return files
+}
+
+// extractClosedCaptions decodes the CEA-608/708 captions carried in the video with the subcc output of the movie
+// source, which needs the path escaped as a filter argument
+func extractClosedCaptions(sourceFile, path string) error {
+	if strings.ContainsAny(sourceFile, `'\:[],;`) {
+		return fmt.Errorf("can't read closed captions from %q", sourceFile)
+	}
+	input := ffmpeg.Input(fmt.Sprintf("movie=%s[out0+subcc]", sourceFile), ffmpeg.KwArgs{"f": "lavfi"})
+	if err := runSubtitleExtraction(input, path, "0:1"); err != nil {
+		return err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if !bytes.Contains(content, []byte("-->")) {
+		return fmt.Errorf("no captions were decoded")
+	}
+	return nil
+}
+
+func runSubtitleExtraction(input *ffmpeg.Stream, path, streamMap string) error {
+	ffmpegErr := bytes.Buffer{}
+	err := input.
+		Output(path, ffmpeg.KwArgs{"map": streamMap, "c:s": "webvtt", "f": "webvtt"}).
+		OverWriteOutput().WithErrorOutput(&ffmpegErr).Run()
+	if err != nil {
+		return fmt.Errorf("failed to convert %s to WebVTT [%s]: %w", streamMap, ffmpegErr.String(), err)
+	}
+	return nil
+}
+
+// uniqueName makes the names of the subtitles unique, as the playlist can't have two entries with the same name
+func uniqueName(names map[string]bool, name string) string {
+	unique := name
+	for i := 2; names[unique]; i++ {
+		unique = fmt.Sprintf("%s %d", name, i)
+	}
+	names[unique] = true
+	return unique
+}
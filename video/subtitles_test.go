@@ -0,0 +1,37 @@
+package video
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubtitleFileAlternative(t *testing.T) {
+	alt := SubtitleFile{Name: "English", Language: "eng"}.Alternative("subtitles/0/index.m3u8")
+	require.Equal(t, SubtitleGroupID, alt.GroupId)
+	require.Equal(t, "SUBTITLES", alt.Type)
+	require.Equal(t, "English", alt.Name)
+	require.Equal(t, "eng", alt.Language)
+	require.Equal(t, "subtitles/0/index.m3u8", alt.URI)
+	require.False(t, alt.Default)
+	require.Empty(t, alt.Characteristics)
+
+	alt = SubtitleFile{Name: "Closed captions", ClosedCaptions: true}.Alternative("subtitles/1/index.m3u8")
+	require.Equal(t, closedCaptionCharacteristics, alt.Characteristics)
+}
+
+func TestExtractSubtitlesSkipsBitmapTracks(t *testing.T) {
+	iv := InputVideo{Tracks: []InputTrack{
+		{Type: TrackTypeVideo},
+		{Type: TrackTypeSubtitle, Codec: "hdmv_pgs_subtitle", SubtitleTrack: SubtitleTrack{Index: 0}},
+	}}
+	require.Empty(t, ExtractSubtitles("requestID", "source.mp4", iv, t.TempDir()))
+}
+
+func TestUniqueName(t *testing.T) {
+	names := map[string]bool{}
+	require.Equal(t, "eng", uniqueName(names, "eng"))
+	require.Equal(t, "eng 2", uniqueName(names, "eng"))
+	require.Equal(t, "eng 3", uniqueName(names, "eng"))
+	require.Equal(t, "fra", uniqueName(names, "fra"))
+}
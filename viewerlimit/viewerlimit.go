@@ -0,0 +1,124 @@
+package viewerlimit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/livepeer/catalyst-api/log"
+	"github.com/patrickmn/go-cache"
+)
+
+const (
+	// policyCacheTTL is how long the limit returned by the policy endpoint is used for
+	policyCacheTTL = 30 * time.Second
+	// policyErrorCacheTTL is how long the configured limit is used for once the policy endpoint fails
+	policyErrorCacheTTL = 5 * time.Second
+	policyFetchTimeout  = 5 * time.Second
+)
+
+// Counter counts the current viewers of a stream across the cluster
+type Counter interface {
+	Viewers(ctx context.Context, playbackID string) (int, error)
+}
+
+// Policy is the response of the policy endpoint, a MaxViewers of 0 meaning the stream isn't limited
+type Policy struct {
+	MaxViewers int `json:"max_viewers"`
+}
+
+// Limiter enforces a max number of concurrent viewers per playback ID, e.g. for ticketed events. The limits come from
+// -viewer-limits, or from -viewer-limit-policy-url which takes precedence.
+type Limiter struct {
+	limits    map[string]int
+	policyURL string
+	counter   Counter
+
+	client   *http.Client
+	policies *cache.Cache
+}
+
+func NewLimiter(limits map[string]int, policyURL string, counter Counter) *Limiter {
+	return &Limiter{
+		limits:    limits,
+		policyURL: policyURL,
+		counter:   counter,
+		client:    &http.Client{Timeout: policyFetchTimeout},
+		policies:  cache.New(policyCacheTTL, time.Minute),
+	}
+}
+
+// Limit returns the max concurrent viewers of a stream, 0 if it isn't limited. The configured limit is used while the
+// policy endpoint is unavailable.
+func (l *Limiter) Limit(ctx context.Context, playbackID string) int {
+	if l.policyURL == "" {
+		return l.limits[playbackID]
+	}
+	if cached, ok := l.policies.Get(playbackID); ok {
+		return cached.(int)
+	}
+	limit, err := l.fetchPolicy(ctx, playbackID)
+	if err != nil {
+		log.LogCtx(ctx, "error fetching viewer limit policy, using the configured limit", "playback_id", playbackID, "err", err)
+		limit = l.limits[playbackID]
+		l.policies.Set(playbackID, limit, policyErrorCacheTTL)
+		return limit
+	}
+	l.policies.SetDefault(playbackID, limit)
+	return limit
+}
+
+func (l *Limiter) fetchPolicy(ctx context.Context, playbackID string) (int, error) {
+	u, err := url.Parse(l.policyURL)
+	if err != nil {
+		return 0, err
+	}
+	query := u.Query()
+	query.Set("playback_id", playbackID)
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		// no policy for the stream
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	var policy Policy
+	if err := json.NewDecoder(resp.Body).Decode(&policy); err != nil {
+		return 0, fmt.Errorf("invalid policy: %w", err)
+	}
+	if policy.MaxViewers < 0 {
+		return 0, fmt.Errorf("invalid policy: negative max_viewers %d", policy.MaxViewers)
+	}
+	return policy.MaxViewers, nil
+}
+
+// Allow tells whether one more viewer can watch a stream, with its current viewers and limit. Viewers are let in when
+// they can't be counted, so that an outage doesn't stop the playback of every limited stream.
+func (l *Limiter) Allow(ctx context.Context, playbackID string) (allowed bool, viewers, limit int) {
+	limit = l.Limit(ctx, playbackID)
+	if limit == 0 {
+		return true, 0, 0
+	}
+	viewers, err := l.counter.Viewers(ctx, playbackID)
+	if err != nil {
+		log.LogCtx(ctx, "error counting viewers, allowing the viewer", "playback_id", playbackID, "err", err)
+		return true, 0, limit
+	}
+	return viewers < limit, viewers, limit
+}
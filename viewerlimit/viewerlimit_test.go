@@ -0,0 +1,72 @@
+package viewerlimit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type stubCounter struct {
+	viewers int
+	err     error
+}
+
+func (s stubCounter) Viewers(context.Context, string) (int, error) {
+	return s.viewers, s.err
+}
+
+func TestConfiguredLimits(t *testing.T) {
+	l := NewLimiter(map[string]int{"abc123": 10}, "", stubCounter{viewers: 9})
+	allowed, viewers, limit := l.Allow(context.Background(), "abc123")
+	require.True(t, allowed)
+	require.Equal(t, 9, viewers)
+	require.Equal(t, 10, limit)
+
+	l.counter = stubCounter{viewers: 10}
+	allowed, _, _ = l.Allow(context.Background(), "abc123")
+	require.False(t, allowed)
+
+	// streams without a limit aren't counted
+	l.counter = stubCounter{err: fmt.Errorf("shouldn't be counted")}
+	allowed, _, limit = l.Allow(context.Background(), "def456")
+	require.True(t, allowed)
+	require.Zero(t, limit)
+
+	// viewers are let in when they can't be counted
+	allowed, _, _ = l.Allow(context.Background(), "abc123")
+	require.True(t, allowed)
+}
+
+func TestPolicyEndpoint(t *testing.T) {
+	requests := 0
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(status)
+		if r.URL.Query().Get("playback_id") == "abc123" {
+			w.Write([]byte(`{"max_viewers": 100}`)) // nolint:errcheck
+		}
+	}))
+	defer server.Close()
+
+	l := NewLimiter(map[string]int{"abc123": 10, "def456": 20}, server.URL+"/limits", stubCounter{viewers: 50})
+	// the policy takes precedence and is cached
+	require.Equal(t, 100, l.Limit(context.Background(), "abc123"))
+	require.Equal(t, 100, l.Limit(context.Background(), "abc123"))
+	require.Equal(t, 1, requests)
+	allowed, _, _ := l.Allow(context.Background(), "abc123")
+	require.True(t, allowed)
+
+	status = http.StatusNotFound
+	require.Zero(t, l.Limit(context.Background(), "ghi789"))
+
+	// the configured limit is used while the endpoint fails
+	status = http.StatusInternalServerError
+	require.Equal(t, 20, l.Limit(context.Background(), "def456"))
+	allowed, _, _ = l.Allow(context.Background(), "def456")
+	require.False(t, allowed)
+}